@@ -13,7 +13,9 @@ import (
 
 	"github.com/gorilla/websocket"
 	exchange "github.com/openware/irix"
+	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/common/crypto"
+	"github.com/openware/pkg/currency"
 	"github.com/openware/pkg/order"
 	"github.com/openware/pkg/request"
 )
@@ -143,6 +145,34 @@ func (a *Alphapoint) GetProductPairs() (ProductPairs, error) {
 	return response, nil
 }
 
+// FetchExchangeLimits fetches the price and amount steps of every product
+// pair
+func (a *Alphapoint) FetchExchangeLimits(item asset.Item) ([]order.MinMaxLevel, error) {
+	if item != asset.Spot {
+		return nil, fmt.Errorf("%s %w", item, asset.ErrNotSupported)
+	}
+	pairs, err := a.GetProductPairs()
+	if err != nil {
+		return nil, err
+	}
+	limits := make([]order.MinMaxLevel, 0, len(pairs.ProductPairs))
+	for x := range pairs.ProductPairs {
+		var cp currency.Pair
+		cp, err = currency.NewPairFromStrings(pairs.ProductPairs[x].Product1Label,
+			pairs.ProductPairs[x].Product2Label)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, order.MinMaxLevel{
+			Pair:       cp,
+			Asset:      item,
+			StepPrice:  exchange.StepFromDecimalPlaces(int64(pairs.ProductPairs[x].Product2Decimalplaces)),
+			StepAmount: exchange.StepFromDecimalPlaces(int64(pairs.ProductPairs[x].Product1Decimalplaces)),
+		})
+	}
+	return limits, nil
+}
+
 // GetProducts gets the currency products currently supported on alphapoint
 func (a *Alphapoint) GetProducts() (Products, error) {
 	response := Products{}
@@ -557,7 +587,11 @@ func (a *Alphapoint) SendAuthenticatedHTTPRequest(ep exchange.URL, method, path
 		return err
 	}
 
-	n := a.Requester.GetNonce(true)
+	n, release, err := a.NextNonce()
+	if err != nil {
+		return err
+	}
+	defer release()
 
 	headers := make(map[string]string)
 	headers["Content-Type"] = "application/json"
@@ -2,6 +2,7 @@ package alphapoint
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"testing"
 	"time"
@@ -232,6 +233,19 @@ func TestGetProductPairs(t *testing.T) {
 	}
 }
 
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	if err := a.UpdateOrderExecutionLimits(asset.Futures); !errors.Is(err, asset.ErrNotSupported) {
+		t.Errorf("received: %v but expected: %v", err, asset.ErrNotSupported)
+	}
+	if !onlineTest {
+		t.Skip()
+	}
+	if err := a.UpdateOrderExecutionLimits(asset.Spot); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestGetProducts(t *testing.T) {
 	t.Parallel()
 	var products Products
@@ -2,6 +2,7 @@ package alphapoint
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -87,6 +88,15 @@ func (a *Alphapoint) UpdateTradablePairs(forceUpdate bool) error {
 	return common.ErrFunctionNotSupported
 }
 
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (a *Alphapoint) UpdateOrderExecutionLimits(item asset.Item) error {
+	limits, err := a.FetchExchangeLimits(item)
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return a.LoadLimits(limits)
+}
+
 // UpdateAccountInfo retrieves balances for all enabled currencies on the
 // Alphapoint exchange
 func (a *Alphapoint) UpdateAccountInfo(assetType asset.Item) (account.Holdings, error) {
@@ -236,6 +246,9 @@ func (a *Alphapoint) SubmitOrder(s *order.Submit) (order.SubmitResponse, error)
 	if err := s.Validate(); err != nil {
 		return submitOrderResponse, err
 	}
+	if err := a.ConformOrderToLimits(s); err != nil {
+		return submitOrderResponse, err
+	}
 
 	fPair, err := a.FormatExchangeCurrency(s.Pair, s.AssetType)
 	if err != nil {
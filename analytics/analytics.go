@@ -0,0 +1,219 @@
+// Package analytics derives execution metrics from orderbooks: the average
+// price of filling a size, liquidity near the mid price, slippage and
+// imbalance, with fee adjusted variants.
+package analytics
+
+import (
+	"fmt"
+	"sort"
+
+	exchange "github.com/openware/irix"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
+	"github.com/openware/pkg/orderbook"
+)
+
+// NewBook prepares an orderbook, such as a FetchOrderbook result, for
+// analysis. Bids must be in descending and asks in ascending price order.
+func NewBook(b *orderbook.Base) (*Book, error) {
+	if b == nil {
+		return nil, errNilBook
+	}
+	return &Book{
+		Exchange:    b.Exchange,
+		Pair:        b.Pair,
+		Asset:       b.Asset,
+		LastUpdated: b.LastUpdated,
+		bids:        newSide(b.Bids),
+		asks:        newSide(b.Asks),
+	}, nil
+}
+
+func newSide(levels orderbook.Items) side {
+	s := side{
+		levels: levels,
+		base:   make([]float64, len(levels)),
+		quote:  make([]float64, len(levels)),
+	}
+	var base, quote float64
+	for i := range levels {
+		base += levels[i].Amount
+		quote += levels[i].Amount * levels[i].Price
+		s.base[i], s.quote[i] = base, quote
+	}
+	return s
+}
+
+// through returns the base amount and quote value of the first n levels
+func (s *side) through(n int) (base, quote float64) {
+	if n == 0 {
+		return 0, 0
+	}
+	return s.base[n-1], s.quote[n-1]
+}
+
+// Mid returns the price halfway between the best bid and ask
+func (b *Book) Mid() (float64, error) {
+	if len(b.bids.levels) == 0 || len(b.asks.levels) == 0 {
+		return 0, fmt.Errorf("cannot calculate mid for %s %s %s: %w",
+			b.Exchange,
+			b.Pair,
+			b.Asset,
+			errNoLiquidity)
+	}
+	return (b.bids.levels[0].Price + b.asks.levels[0].Price) / 2, nil
+}
+
+// taken returns the side of the book a taker on s fills against
+func (b *Book) taken(s order.Side) (*side, bool, error) {
+	switch s {
+	case order.Buy, order.Bid:
+		return &b.asks, true, nil
+	case order.Sell, order.Ask:
+		return &b.bids, false, nil
+	default:
+		return nil, false, fmt.Errorf("%w, received %s", errInvalidSide, s)
+	}
+}
+
+// FillBase returns the fill of a market order for a base amount
+func (b *Book) FillBase(s order.Side, amount float64) (Fill, error) {
+	return b.fill(s, amount, false)
+}
+
+// FillQuote returns the fill of a market order spending, or receiving, a quote
+// amount
+func (b *Book) FillQuote(s order.Side, amount float64) (Fill, error) {
+	return b.fill(s, amount, true)
+}
+
+func (b *Book) fill(s order.Side, amount float64, quote bool) (Fill, error) {
+	if amount <= 0 {
+		return Fill{}, errInvalidAmount
+	}
+	levels, buy, err := b.taken(s)
+	if err != nil {
+		return Fill{}, err
+	}
+	if len(levels.levels) == 0 {
+		return Fill{}, fmt.Errorf("cannot fill %s on %s %s %s: %w",
+			s,
+			b.Exchange,
+			b.Pair,
+			b.Asset,
+			errNoLiquidity)
+	}
+	totals := levels.base
+	if quote {
+		totals = levels.quote
+	}
+	// The level the fill completes at
+	n := sort.Search(len(totals), func(i int) bool { return totals[i] >= amount })
+	f := Fill{Side: s, BestPrice: levels.levels[0].Price}
+	f.Amount, f.Cost = levels.through(n)
+	f.Levels = n
+	if n < len(totals) {
+		price := levels.levels[n].Price
+		if quote {
+			f.Amount += (amount - f.Cost) / price
+			f.Cost = amount
+		} else {
+			f.Cost += (amount - f.Amount) * price
+			f.Amount = amount
+		}
+		f.Levels = n + 1
+		f.Complete = true
+	}
+	f.WorstPrice = levels.levels[f.Levels-1].Price
+	f.AveragePrice = f.Cost / f.Amount
+	f.SlippageBPS = (f.AveragePrice - f.BestPrice) / f.BestPrice * 1e4
+	if !buy {
+		f.SlippageBPS = -f.SlippageBPS
+	}
+	return f, nil
+}
+
+// Slippage returns how far the average price of a market order for a base
+// amount is from the best price in basis points
+func (b *Book) Slippage(s order.Side, amount float64) (float64, error) {
+	f, err := b.FillBase(s, amount)
+	if err != nil {
+		return 0, err
+	}
+	return f.SlippageBPS, nil
+}
+
+// Depth returns the liquidity resting within bps basis points either side of
+// the mid price
+func (b *Book) Depth(bps float64) (Band, error) {
+	if bps <= 0 {
+		return Band{}, errInvalidBand
+	}
+	mid, err := b.Mid()
+	if err != nil {
+		return Band{}, err
+	}
+	lower, upper := mid*(1-bps/1e4), mid*(1+bps/1e4)
+	bids := sort.Search(len(b.bids.levels), func(i int) bool { return b.bids.levels[i].Price < lower })
+	asks := sort.Search(len(b.asks.levels), func(i int) bool { return b.asks.levels[i].Price > upper })
+	band := Band{BPS: bps, Mid: mid}
+	band.BidAmount, band.BidValue = b.bids.through(bids)
+	band.AskAmount, band.AskValue = b.asks.through(asks)
+	return band, nil
+}
+
+// DepthPercent returns the liquidity resting within pct percent either side of
+// the mid price
+func (b *Book) DepthPercent(pct float64) (Band, error) {
+	return b.Depth(pct * 100)
+}
+
+// Imbalance returns the difference between the bid and ask amounts of the top
+// levels of the book over their sum, from -1 when only asks rest to 1 when
+// only bids rest. Zero levels takes the whole book.
+func (b *Book) Imbalance(levels int) (float64, error) {
+	nBids, nAsks := len(b.bids.levels), len(b.asks.levels)
+	if levels > 0 {
+		if nBids > levels {
+			nBids = levels
+		}
+		if nAsks > levels {
+			nAsks = levels
+		}
+	}
+	bid, _ := b.bids.through(nBids)
+	ask, _ := b.asks.through(nAsks)
+	if bid+ask == 0 {
+		return 0, fmt.Errorf("cannot calculate imbalance for %s %s %s: %w",
+			b.Exchange,
+			b.Pair,
+			b.Asset,
+			errNoLiquidity)
+	}
+	return (bid - ask) / (bid + ask), nil
+}
+
+// WithFee returns the fill charged a fee at rate, a fraction of its cost
+func (f Fill) WithFee(rate float64) Fill {
+	f.Fee = f.Cost * rate
+	net := f.Cost + f.Fee
+	if f.Side == order.Sell || f.Side == order.Ask {
+		net = f.Cost - f.Fee
+	}
+	f.EffectivePrice = net / f.Amount
+	return f
+}
+
+// TakerFeeRate returns an exchange's taker trade fee for a pair as a fraction
+// of the notional, derived from the fee of a unit order
+func TakerFeeRate(e exchange.FeeCalculator, p currency.Pair) (float64, error) {
+	if e == nil {
+		return 0, errNoFeeCalculator
+	}
+	return e.GetFeeByType(&exchange.FeeBuilder{
+		FeeType:       exchange.CryptocurrencyTradeFee,
+		Pair:          p,
+		PurchasePrice: 1,
+		Amount:        1,
+	})
+}
@@ -0,0 +1,160 @@
+package analytics
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	exchange "github.com/openware/irix"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
+	"github.com/openware/pkg/orderbook"
+)
+
+var testPair = currency.NewPair(currency.BTC, currency.USD)
+
+func testBase() *orderbook.Base {
+	return &orderbook.Base{
+		Exchange: "analyticsTest",
+		Pair:     testPair,
+		Asset:    asset.Spot,
+		Bids: orderbook.Items{
+			{Price: 99, Amount: 1},
+			{Price: 98, Amount: 2},
+			{Price: 95, Amount: 4},
+		},
+		Asks: orderbook.Items{
+			{Price: 101, Amount: 1},
+			{Price: 102, Amount: 1},
+			{Price: 110, Amount: 10},
+		},
+	}
+}
+
+func nearly(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestFill(t *testing.T) {
+	t.Parallel()
+	b, err := NewBook(testBase())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := b.FillBase(order.Buy, 2.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.Complete || f.Levels != 3 || f.WorstPrice != 110 || !nearly(f.Cost, 101+102+55) {
+		t.Errorf("unexpected buy fill %+v", f)
+	}
+	if !nearly(f.AveragePrice, 258/2.5) || !nearly(f.SlippageBPS, (258/2.5-101)/101*1e4) {
+		t.Errorf("unexpected buy price %v slippage %v", f.AveragePrice, f.SlippageBPS)
+	}
+
+	if f, err = b.FillQuote(order.Sell, 197); err != nil {
+		t.Fatal(err)
+	}
+	if !f.Complete || f.Levels != 2 || !nearly(f.Amount, 2) || !nearly(f.AveragePrice, 98.5) {
+		t.Errorf("unexpected sell fill %+v", f)
+	}
+	if slippage, _ := b.Slippage(order.Sell, 3); !nearly(slippage, (99-(99+196)/3.0)/99*1e4) {
+		t.Errorf("unexpected sell slippage %v", slippage)
+	}
+
+	// A size beyond the book fills what rests
+	if f, err = b.FillBase(order.Sell, 100); err != nil {
+		t.Fatal(err)
+	}
+	if f.Complete || f.Amount != 7 || f.Levels != 3 || f.WorstPrice != 95 {
+		t.Errorf("unexpected partial fill %+v", f)
+	}
+
+	f, err = b.FillBase(order.Buy, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f = f.WithFee(0.001); !nearly(f.Fee, 0.101) || !nearly(f.EffectivePrice, 101.101) {
+		t.Errorf("unexpected fee adjusted buy %+v", f)
+	}
+	if f, err = b.FillBase(order.Sell, 1); err != nil {
+		t.Fatal(err)
+	}
+	if f = f.WithFee(0.001); !nearly(f.EffectivePrice, 98.901) {
+		t.Errorf("unexpected fee adjusted sell %+v", f)
+	}
+
+	if _, err = b.FillBase(order.Buy, 0); !errors.Is(err, errInvalidAmount) {
+		t.Errorf("expected %v, received %v", errInvalidAmount, err)
+	}
+	if _, err = b.FillBase(order.AnySide, 1); !errors.Is(err, errInvalidSide) {
+		t.Errorf("expected %v, received %v", errInvalidSide, err)
+	}
+	empty, err := NewBook(&orderbook.Base{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = empty.FillBase(order.Buy, 1); !errors.Is(err, errNoLiquidity) {
+		t.Errorf("expected %v, received %v", errNoLiquidity, err)
+	}
+	if _, err = NewBook(nil); !errors.Is(err, errNilBook) {
+		t.Errorf("expected %v, received %v", errNilBook, err)
+	}
+}
+
+func TestDepthAndImbalance(t *testing.T) {
+	t.Parallel()
+	b, err := NewBook(testBase())
+	if err != nil {
+		t.Fatal(err)
+	}
+	band, err := b.Depth(200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if band.Mid != 100 || band.BidAmount != 3 || band.BidValue != 99+196 || band.AskAmount != 2 || band.AskValue != 203 {
+		t.Errorf("unexpected band %+v", band)
+	}
+	if band, err = b.DepthPercent(10); err != nil {
+		t.Fatal(err)
+	}
+	if band.BPS != 1000 || band.BidAmount != 7 || band.AskAmount != 12 {
+		t.Errorf("unexpected band %+v", band)
+	}
+	if _, err = b.Depth(0); !errors.Is(err, errInvalidBand) {
+		t.Errorf("expected %v, received %v", errInvalidBand, err)
+	}
+
+	imbalance, err := b.Imbalance(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !nearly(imbalance, (3-2)/5.0) {
+		t.Errorf("unexpected imbalance %v", imbalance)
+	}
+	if imbalance, _ = b.Imbalance(0); !nearly(imbalance, (7-12)/19.0) {
+		t.Errorf("unexpected imbalance %v", imbalance)
+	}
+}
+
+type testFees struct{ rate float64 }
+
+func (f testFees) GetFeeByType(b *exchange.FeeBuilder) (float64, error) {
+	return f.rate * b.PurchasePrice * b.Amount, nil
+}
+
+func TestTakerFeeRate(t *testing.T) {
+	t.Parallel()
+	rate, err := TakerFeeRate(testFees{rate: 0.002}, testPair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rate != 0.002 {
+		t.Errorf("expected rate 0.002, received %v", rate)
+	}
+	if _, err = TakerFeeRate(nil, testPair); !errors.Is(err, errNoFeeCalculator) {
+		t.Errorf("expected %v, received %v", errNoFeeCalculator, err)
+	}
+}
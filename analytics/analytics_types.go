@@ -0,0 +1,94 @@
+package analytics
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	exchange "github.com/openware/irix"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
+	"github.com/openware/pkg/orderbook"
+)
+
+var (
+	errNilBook           = errors.New("orderbook is nil")
+	errNoLiquidity       = errors.New("no liquidity")
+	errInvalidAmount     = errors.New("amount must be positive")
+	errInvalidSide       = errors.New("side must be buy or sell")
+	errInvalidBand       = errors.New("band must be positive")
+	errNoFeeCalculator   = errors.New("fee calculator unset")
+	errExchangeNameUnset = errors.New("exchange name unset")
+)
+
+// Book is an orderbook prepared for analysis, holding the running totals of
+// each side so fills and bands are found by search rather than by walking
+// the book. A Book must not be modified once prepared.
+type Book struct {
+	Exchange    string
+	Pair        currency.Pair
+	Asset       asset.Item
+	LastUpdated time.Time
+	bids        side
+	asks        side
+}
+
+// side is one side of a book, best price first, with the base amount and
+// quote value held through each level
+type side struct {
+	levels orderbook.Items
+	base   []float64
+	quote  []float64
+}
+
+// Fill is the outcome of taking liquidity from a book
+type Fill struct {
+	// Side is the taker's side, buys take asks and sells take bids
+	Side order.Side
+	// Amount is the base amount filled
+	Amount float64
+	// Cost is the quote value paid for a buy or received for a sell
+	Cost         float64
+	AveragePrice float64
+	BestPrice    float64
+	WorstPrice   float64
+	// Levels is the number of price levels taken from
+	Levels int
+	// Complete is set when the book held enough to fill the requested size
+	Complete bool
+	// SlippageBPS is how far the average price is from the best price in
+	// basis points, positive when adverse
+	SlippageBPS float64
+	// Fee is the quote fee charged on the fill, set by WithFee
+	Fee float64
+	// EffectivePrice is the average price net of fees, set by WithFee
+	EffectivePrice float64
+}
+
+// Band is the liquidity resting within a distance of the mid price
+type Band struct {
+	// BPS is the distance from the mid price in basis points
+	BPS       float64
+	Mid       float64
+	BidAmount float64
+	BidValue  float64
+	AskAmount float64
+	AskValue  float64
+}
+
+// Tracker prepares the books of an exchange held by the orderbook service,
+// which both the websocket buffer and REST FetchOrderbook results update. A
+// book is only prepared again once its depth has changed.
+type Tracker struct {
+	exchange string
+	fees     exchange.FeeCalculator
+	books    map[currency.Code]map[currency.Code]map[asset.Item]*tracked
+	m        sync.Mutex
+}
+
+// tracked is a prepared book and the alert raised when its depth changes
+type tracked struct {
+	book    *Book
+	changed <-chan bool
+}
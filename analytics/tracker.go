@@ -0,0 +1,86 @@
+package analytics
+
+import (
+	exchange "github.com/openware/irix"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
+	"github.com/openware/pkg/orderbook"
+)
+
+// NewTracker returns a tracker of an exchange's books, fees is optional and
+// used for fee adjusted fills
+func NewTracker(exchangeName string, fees exchange.FeeCalculator) (*Tracker, error) {
+	if exchangeName == "" {
+		return nil, errExchangeNameUnset
+	}
+	return &Tracker{
+		exchange: exchangeName,
+		fees:     fees,
+		books:    make(map[currency.Code]map[currency.Code]map[asset.Item]*tracked),
+	}, nil
+}
+
+// Book returns the prepared book for a pair and asset. The book prepared on a
+// previous call is returned until the depth alerts a change.
+func (t *Tracker) Book(p currency.Pair, a asset.Item) (*Book, error) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	m1, ok := t.books[p.Base]
+	if !ok {
+		m1 = make(map[currency.Code]map[asset.Item]*tracked)
+		t.books[p.Base] = m1
+	}
+	m2, ok := m1[p.Quote]
+	if !ok {
+		m2 = make(map[asset.Item]*tracked)
+		m1[p.Quote] = m2
+	}
+	if tr, ok := m2[a]; ok {
+		select {
+		case <-tr.changed:
+		default:
+			return tr.book, nil
+		}
+	}
+	depth, err := orderbook.GetDepth(t.exchange, p, a)
+	if err != nil {
+		return nil, err
+	}
+	// Wait before retrieving so changes made meanwhile are not missed
+	changed := depth.Wait(nil)
+	book, err := NewBook(depth.Retrieve())
+	if err != nil {
+		return nil, err
+	}
+	m2[a] = &tracked{book: book, changed: changed}
+	return book, nil
+}
+
+// FillBase returns the fill of a market order for a base amount charged the
+// exchange's taker fee
+func (t *Tracker) FillBase(p currency.Pair, a asset.Item, s order.Side, amount float64) (Fill, error) {
+	return t.fill(p, a, s, amount, false)
+}
+
+// FillQuote returns the fill of a market order for a quote amount charged the
+// exchange's taker fee
+func (t *Tracker) FillQuote(p currency.Pair, a asset.Item, s order.Side, amount float64) (Fill, error) {
+	return t.fill(p, a, s, amount, true)
+}
+
+func (t *Tracker) fill(p currency.Pair, a asset.Item, s order.Side, amount float64, quote bool) (Fill, error) {
+	rate, err := TakerFeeRate(t.fees, p)
+	if err != nil {
+		return Fill{}, err
+	}
+	book, err := t.Book(p, a)
+	if err != nil {
+		return Fill{}, err
+	}
+	f, err := book.fill(s, amount, quote)
+	if err != nil {
+		return Fill{}, err
+	}
+	return f.WithFee(rate), nil
+}
@@ -0,0 +1,72 @@
+package analytics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/order"
+	"github.com/openware/pkg/orderbook"
+)
+
+func TestTracker(t *testing.T) {
+	t.Parallel()
+	if _, err := NewTracker("", nil); !errors.Is(err, errExchangeNameUnset) {
+		t.Fatalf("expected %v, received %v", errExchangeNameUnset, err)
+	}
+	base := testBase()
+	base.Exchange = "trackerTest"
+	tr, err := NewTracker(base.Exchange, testFees{rate: 0.001})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tr.Book(testPair, asset.Futures); err == nil {
+		t.Fatal("expected error for a book which is not loaded")
+	}
+	if err = base.Process(); err != nil {
+		t.Fatal(err)
+	}
+	book, err := tr.Book(testPair, asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := tr.Book(testPair, asset.Spot); again != book {
+		t.Error("expected unchanged book not to be prepared again")
+	}
+
+	// The depth alerts the change asynchronously
+	depth, err := orderbook.GetDepth(base.Exchange, testPair, asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	depth.UpdateBidAskByPrice(nil, orderbook.Items{{Price: 101, Amount: 3}}, 0)
+	deadline := time.Now().Add(time.Second)
+	for book, _ = tr.Book(testPair, asset.Spot); book.asks.levels[0].Amount != 3; book, _ = tr.Book(testPair, asset.Spot) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected changed book to be prepared again")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	f, err := tr.FillBase(testPair, asset.Spot, order.Buy, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.AveragePrice != 101 || !nearly(f.Fee, 0.202) {
+		t.Errorf("unexpected fill %+v", f)
+	}
+	if f, err = tr.FillQuote(testPair, asset.Spot, order.Sell, 99); err != nil {
+		t.Fatal(err)
+	}
+	if f.Amount != 1 || !nearly(f.EffectivePrice, 98.901) {
+		t.Errorf("unexpected fill %+v", f)
+	}
+	noFees, err := NewTracker(base.Exchange, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = noFees.FillBase(testPair, asset.Spot, order.Buy, 1); !errors.Is(err, errNoFeeCalculator) {
+		t.Errorf("expected %v, received %v", errNoFeeCalculator, err)
+	}
+}
@@ -0,0 +1,356 @@
+// Package arbitrage watches a pair's venues for spreads executable across
+// them.
+package arbitrage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	exchange "github.com/openware/irix"
+	"github.com/openware/irix/analytics"
+	"github.com/openware/irix/consolidated"
+	"github.com/openware/irix/ticker"
+	"github.com/openware/pkg/log"
+)
+
+// NewMonitor returns a monitor of the configured pairs
+func NewMonitor(c *Config) (*Monitor, error) {
+	if len(c.Pairs) == 0 {
+		return nil, errNoPairs
+	}
+	if c.MinSpreadBPS < 0 || c.MinProfit < 0 || c.MinSize < 0 || c.MaxSize < 0 {
+		return nil, errInvalidThreshold
+	}
+	if c.OnOpportunity == nil {
+		return nil, errOpportunityCallback
+	}
+	m := &Monitor{cfg: *c}
+	for i := range c.Pairs {
+		cp := c.Pairs[i]
+		if len(cp.Venues) < 2 {
+			return nil, fmt.Errorf("%w for %s", errTooFewVenues, cp.Pair)
+		}
+		for _, p := range m.pairs {
+			if p.Pair.Pair.Equal(cp.Pair) {
+				return nil, fmt.Errorf("%w %s", errDuplicatePair, cp.Pair)
+			}
+		}
+		p := &pair{
+			Pair:       cp,
+			quotes:     make(map[string]*quote),
+			withdrawal: make(map[string]float64),
+			last:       make(map[route]Opportunity),
+		}
+		var books []consolidated.Venue
+		for j := range cp.Venues {
+			v := cp.Venues[j]
+			if v.Exchange == nil || v.Pair.IsEmpty() || v.Asset == "" {
+				return nil, fmt.Errorf("%s venue at index %d: exchange, pair and asset are required",
+					cp.Pair, j)
+			}
+			name := v.Exchange.GetName()
+			for _, existing := range p.names {
+				if strings.EqualFold(existing, name) {
+					return nil, fmt.Errorf("%w %s for %s", errDuplicateVenue, name, cp.Pair)
+				}
+			}
+			p.names = append(p.names, name)
+			if v.Ticker {
+				p.tickers = append(p.tickers, &tickerVenue{Venue: v, name: name})
+				continue
+			}
+			v.AdjustFees = true
+			books = append(books, v.Venue)
+		}
+		if len(books) != 0 {
+			agg, err := consolidated.NewAggregator(&consolidated.Config{
+				Pair:   cp.Pair,
+				Venues: books,
+				Depth:  c.Depth,
+				OnBook: func(b *consolidated.Book) { m.onBook(p, b) },
+			})
+			if err != nil {
+				return nil, err
+			}
+			p.aggregator = agg
+		}
+		m.pairs = append(m.pairs, p)
+	}
+	return m, nil
+}
+
+// Start fetches the venues' fees and follows their books and tickers until
+// stopped. Ticker venues must have a ticker to subscribe to.
+func (m *Monitor) Start() (err error) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	if m.shutdown != nil {
+		return errRunning
+	}
+	shutdown := make(chan struct{})
+	var started []*pair
+	defer func() {
+		if err != nil {
+			close(shutdown)
+			m.stop(started)
+		}
+	}()
+	for _, p := range m.pairs {
+		if m.cfg.WithdrawalCosts {
+			for i := range p.Venues {
+				v := p.Venues[i]
+				fee, err := v.Exchange.GetFeeByType(&exchange.FeeBuilder{
+					FeeType: exchange.CryptocurrencyWithdrawalFee,
+					Pair:    v.Pair,
+					Amount:  1,
+				})
+				if err != nil {
+					return fmt.Errorf("cannot fetch %s %s withdrawal fee: %w",
+						p.names[i], v.Pair.Base, err)
+				}
+				p.withdrawal[p.names[i]] = fee
+			}
+		}
+		for _, tv := range p.tickers {
+			if tv.fee, err = analytics.TakerFeeRate(tv.Exchange, tv.Pair); err != nil {
+				return fmt.Errorf("cannot fetch %s taker fee: %w", tv.name, err)
+			}
+		}
+		started = append(started, p)
+		for _, tv := range p.tickers {
+			if tv.pipe, err = ticker.SubscribeTicker(tv.name, tv.Pair, tv.Asset); err != nil {
+				return err
+			}
+			if t, err := ticker.GetTicker(tv.name, tv.Pair, tv.Asset); err == nil {
+				m.onTicker(p, tv, t)
+			}
+			m.wg.Add(1)
+			go m.follow(p, tv, shutdown)
+		}
+		if p.aggregator != nil {
+			if err = p.aggregator.Start(); err != nil {
+				return err
+			}
+		}
+	}
+	m.shutdown = shutdown
+	return nil
+}
+
+// Stop stops following the venues
+func (m *Monitor) Stop() error {
+	m.m.Lock()
+	defer m.m.Unlock()
+	if m.shutdown == nil {
+		return errNotRunning
+	}
+	close(m.shutdown)
+	m.shutdown = nil
+	m.stop(m.pairs)
+	return nil
+}
+
+// stop stops the pairs' aggregators and ticker subscriptions once shutdown
+// is closed
+func (m *Monitor) stop(pairs []*pair) {
+	for _, p := range pairs {
+		if p.aggregator != nil {
+			// Not running when starting it failed
+			_ = p.aggregator.Stop()
+		}
+	}
+	m.wg.Wait()
+	for _, p := range pairs {
+		for _, tv := range p.tickers {
+			if tv.pipe.C == nil {
+				continue
+			}
+			if err := tv.pipe.Release(); err != nil {
+				log.Errorf(log.Ticker, "arbitrage cannot release %s %s ticker: %v", tv.name, tv.Pair, err)
+			}
+			tv.pipe.C = nil
+		}
+		p.m.Lock()
+		p.quotes = make(map[string]*quote)
+		p.last = make(map[route]Opportunity)
+		p.m.Unlock()
+	}
+}
+
+// follow quotes a ticker venue from its ticker updates
+func (m *Monitor) follow(p *pair, tv *tickerVenue, shutdown chan struct{}) {
+	defer m.wg.Done()
+	pipe := tv.pipe.C
+	for {
+		select {
+		case <-shutdown:
+			return
+		case data, ok := <-pipe:
+			if !ok {
+				return
+			}
+			// The dispatcher publishes a pointer to a copy of the ticker
+			cpy, ok := data.(*interface{})
+			if !ok {
+				continue
+			}
+			if t, ok := (*cpy).(ticker.Price); ok {
+				m.onTicker(p, tv, &t)
+			}
+		}
+	}
+}
+
+// onBook takes the orderbook venues' levels from a consolidated book
+func (m *Monitor) onBook(p *pair, b *consolidated.Book) {
+	quotes := make(map[string]*quote)
+	for i := range b.Bids {
+		q := quoteOf(quotes, b.Bids[i].Exchange)
+		q.bids = append(q.bids, b.Bids[i])
+	}
+	for i := range b.Asks {
+		q := quoteOf(quotes, b.Asks[i].Exchange)
+		q.asks = append(q.asks, b.Asks[i])
+	}
+	p.m.Lock()
+	defer p.m.Unlock()
+	for i := range p.Venues {
+		if p.Venues[i].Ticker {
+			continue
+		}
+		if q, ok := quotes[p.names[i]]; ok {
+			p.quotes[p.names[i]] = q
+		} else {
+			delete(p.quotes, p.names[i])
+		}
+	}
+	m.evaluate(p)
+}
+
+// onTicker converts a ticker's best bid and ask into a venue's levels
+func (m *Monitor) onTicker(p *pair, tv *tickerVenue, t *ticker.Price) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	rate := 1.0
+	if tv.QuoteRate != nil {
+		var err error
+		if rate, err = tv.QuoteRate(); err != nil || rate <= 0 {
+			log.Errorf(log.Ticker, "arbitrage %s: dropping %s %s quote, rate %v: %v",
+				p.Pair.Pair, tv.name, tv.Pair, rate, err)
+			delete(p.quotes, tv.name)
+			m.evaluate(p)
+			return
+		}
+	}
+	q := &quote{}
+	if t.Bid > 0 && t.BidSize > 0 {
+		q.bids = []consolidated.Level{{
+			Exchange:   tv.name,
+			Price:      t.Bid * rate * (1 - tv.fee),
+			Amount:     t.BidSize,
+			VenuePrice: t.Bid,
+		}}
+	}
+	if t.Ask > 0 && t.AskSize > 0 {
+		q.asks = []consolidated.Level{{
+			Exchange:   tv.name,
+			Price:      t.Ask * rate * (1 + tv.fee),
+			Amount:     t.AskSize,
+			VenuePrice: t.Ask,
+		}}
+	}
+	p.quotes[tv.name] = q
+	m.evaluate(p)
+}
+
+// evaluate sizes the spread of each route between the pair's venues, calling
+// back with opportunities meeting the thresholds which are new or changed.
+// The pair's lock must be held.
+func (m *Monitor) evaluate(p *pair) {
+	now := time.Now()
+	for _, buy := range p.names {
+		for _, sell := range p.names {
+			if buy == sell {
+				continue
+			}
+			r := route{buy: buy, sell: sell}
+			o, ok := m.opportunity(p, r)
+			if !ok {
+				delete(p.last, r)
+				continue
+			}
+			if last, ok := p.last[r]; ok && last == o {
+				continue
+			}
+			p.last[r] = o
+			o.Detected = now
+			m.cfg.OnOpportunity(o)
+		}
+	}
+}
+
+// opportunity sizes buying on one venue and selling on another while their
+// books cross and reports whether it meets the thresholds
+func (m *Monitor) opportunity(p *pair, r route) (Opportunity, bool) {
+	b, s := p.quotes[r.buy], p.quotes[r.sell]
+	if b == nil || s == nil || len(b.asks) == 0 || len(s.bids) == 0 {
+		return Opportunity{}, false
+	}
+	asks, bids := b.asks, s.bids
+	o := Opportunity{
+		Pair:      p.Pair.Pair,
+		Buy:       r.buy,
+		Sell:      r.sell,
+		BuyPrice:  asks[0].Price,
+		SellPrice: bids[0].Price,
+		SpreadBPS: (bids[0].Price - asks[0].Price) / asks[0].Price * 1e4,
+	}
+	i, j := 0, 0
+	askLeft, bidLeft := asks[0].Amount, bids[0].Amount
+	for i < len(asks) && j < len(bids) && bids[j].Price > asks[i].Price {
+		take := askLeft
+		if bidLeft < take {
+			take = bidLeft
+		}
+		if m.cfg.MaxSize > 0 && o.Size+take > m.cfg.MaxSize {
+			take = m.cfg.MaxSize - o.Size
+		}
+		o.Size += take
+		o.Cost += take * asks[i].Price
+		o.Proceeds += take * bids[j].Price
+		if m.cfg.MaxSize > 0 && o.Size >= m.cfg.MaxSize {
+			break
+		}
+		if askLeft -= take; askLeft <= 0 {
+			if i++; i < len(asks) {
+				askLeft = asks[i].Amount
+			}
+		}
+		if bidLeft -= take; bidLeft <= 0 {
+			if j++; j < len(bids) {
+				bidLeft = bids[j].Amount
+			}
+		}
+	}
+	if o.Size <= 0 {
+		return Opportunity{}, false
+	}
+	// The base withdrawn is valued at what it cost to buy
+	o.WithdrawalCost = p.withdrawal[r.buy] * o.Cost / o.Size
+	o.PnL = o.Proceeds - o.Cost - o.WithdrawalCost
+	return o, o.PnL > 0 &&
+		o.PnL >= m.cfg.MinProfit &&
+		o.Size >= m.cfg.MinSize &&
+		o.SpreadBPS >= m.cfg.MinSpreadBPS
+}
+
+// quoteOf returns a venue's quote, adding it when missing
+func quoteOf(quotes map[string]*quote, name string) *quote {
+	q, ok := quotes[name]
+	if !ok {
+		q = &quote{}
+		quotes[name] = q
+	}
+	return q
+}
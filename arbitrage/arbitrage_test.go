@@ -0,0 +1,235 @@
+package arbitrage
+
+import (
+	"errors"
+	"log"
+	"math"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	exchange "github.com/openware/irix"
+	"github.com/openware/irix/consolidated"
+	"github.com/openware/irix/ticker"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/dispatch"
+	"github.com/openware/pkg/orderbook"
+)
+
+func TestMain(m *testing.M) {
+	if err := dispatch.Start(1, dispatch.DefaultJobsLimit); err != nil {
+		log.Fatal(err)
+	}
+	os.Exit(m.Run())
+}
+
+type testExchange struct {
+	name       string
+	fee        float64
+	withdrawal float64
+}
+
+func (e testExchange) GetName() string { return e.name }
+
+func (e testExchange) GetFeeByType(b *exchange.FeeBuilder) (float64, error) {
+	if b.FeeType == exchange.CryptocurrencyWithdrawalFee {
+		return e.withdrawal, nil
+	}
+	return e.fee * b.PurchasePrice * b.Amount, nil
+}
+
+func nearly(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+// collector keeps the latest opportunity of each route
+type collector struct {
+	latest map[route]Opportunity
+	m      sync.Mutex
+}
+
+func (c *collector) add(o Opportunity) {
+	c.m.Lock()
+	c.latest[route{buy: o.Buy, sell: o.Sell}] = o
+	c.m.Unlock()
+}
+
+// wait waits for a route's opportunity to satisfy check
+func (c *collector) wait(t *testing.T, r route, check func(Opportunity) bool) Opportunity {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		c.m.Lock()
+		o, ok := c.latest[r]
+		c.m.Unlock()
+		if ok && check(o) {
+			return o
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected opportunity for %+v, received %+v", r, o)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestNewMonitor(t *testing.T) {
+	t.Parallel()
+	p := currency.NewPair(currency.BTC, currency.USD)
+	v := func(name string) Venue {
+		return Venue{Venue: consolidated.Venue{Exchange: testExchange{name: name}, Pair: p, Asset: asset.Spot}}
+	}
+	on := func(Opportunity) {}
+	for _, tc := range []struct {
+		c   Config
+		err error
+	}{
+		{Config{OnOpportunity: on}, errNoPairs},
+		{Config{Pairs: []Pair{{Pair: p}}, MinProfit: -1, OnOpportunity: on}, errInvalidThreshold},
+		{Config{Pairs: []Pair{{Pair: p}}}, errOpportunityCallback},
+		{Config{Pairs: []Pair{{Pair: p, Venues: []Venue{v("a")}}}, OnOpportunity: on}, errTooFewVenues},
+		{Config{Pairs: []Pair{{Pair: p, Venues: []Venue{v("a"), v("A")}}}, OnOpportunity: on}, errDuplicateVenue},
+		{Config{
+			Pairs: []Pair{
+				{Pair: p, Venues: []Venue{v("a"), v("b")}},
+				{Pair: p, Venues: []Venue{v("a"), v("b")}},
+			},
+			OnOpportunity: on,
+		}, errDuplicatePair},
+	} {
+		c := tc.c
+		if _, err := NewMonitor(&c); !errors.Is(err, tc.err) {
+			t.Errorf("expected %v, received %v", tc.err, err)
+		}
+	}
+	m, err := NewMonitor(&Config{Pairs: []Pair{{Pair: p, Venues: []Venue{v("a"), v("b")}}}, OnOpportunity: on})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Stop(); !errors.Is(err, errNotRunning) {
+		t.Errorf("expected %v, received %v", errNotRunning, err)
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	t.Parallel()
+	usd := currency.NewPair(currency.BTC, currency.USD)
+	usdt := currency.NewPair(currency.BTC, currency.USDT)
+	a := testExchange{name: "arbitrageA", withdrawal: 0.01}
+	b := testExchange{name: "arbitrageB"}
+	c := testExchange{name: "arbitrageC"}
+	for _, base := range []*orderbook.Base{
+		{
+			Exchange: a.name,
+			Pair:     usd,
+			Asset:    asset.Spot,
+			Bids:     orderbook.Items{{Price: 99, Amount: 1}},
+			Asks:     orderbook.Items{{Price: 100, Amount: 1}, {Price: 101, Amount: 2}},
+		},
+		{
+			Exchange: b.name,
+			Pair:     usdt,
+			Asset:    asset.Spot,
+			Bids:     orderbook.Items{{Price: 103, Amount: 1.5}, {Price: 100.5, Amount: 5}},
+			Asks:     orderbook.Items{{Price: 104, Amount: 1}},
+		},
+	} {
+		if err := base.Process(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tick := &ticker.Price{
+		ExchangeName: c.name,
+		Pair:         usd,
+		AssetType:    asset.Spot,
+		Bid:          106,
+		BidSize:      0.5,
+		Ask:          107,
+		AskSize:      1,
+	}
+	if err := ticker.ProcessTicker(tick); err != nil {
+		t.Fatal(err)
+	}
+
+	col := &collector{latest: make(map[route]Opportunity)}
+	m, err := NewMonitor(&Config{
+		Pairs: []Pair{{
+			Pair: usd,
+			Venues: []Venue{
+				{Venue: consolidated.Venue{Exchange: a, Pair: usd, Asset: asset.Spot}},
+				{Venue: consolidated.Venue{
+					Exchange:  b,
+					Pair:      usdt,
+					Asset:     asset.Spot,
+					QuoteRate: func() (float64, error) { return 1, nil },
+				}},
+				{Venue: consolidated.Venue{Exchange: c, Pair: usd, Asset: asset.Spot}, Ticker: true},
+			},
+		}},
+		MinProfit:       1.5,
+		WithdrawalCosts: true,
+		OnOpportunity:   col.add,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err = m.Stop(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	// One at 100 against 103 and half at 101 against 103, withdrawing 0.01
+	// at the average cost
+	o := col.wait(t, route{buy: a.name, sell: b.name}, func(o Opportunity) bool { return o.Size == 1.5 })
+	if !nearly(o.Cost, 150.5) || !nearly(o.Proceeds, 154.5) || !nearly(o.PnL, 4-0.01*150.5/1.5) {
+		t.Errorf("unexpected opportunity %+v", o)
+	}
+	if o.BuyPrice != 100 || o.SellPrice != 103 || !nearly(o.SpreadBPS, 300) {
+		t.Errorf("unexpected opportunity prices %+v", o)
+	}
+	o = col.wait(t, route{buy: a.name, sell: c.name}, func(o Opportunity) bool { return true })
+	if o.Size != 0.5 || !nearly(o.PnL, 3-1) {
+		t.Errorf("unexpected opportunity %+v", o)
+	}
+
+	// Buying from b to sell to c makes one, below the minimum
+	col.m.Lock()
+	_, ok := col.latest[route{buy: b.name, sell: c.name}]
+	col.m.Unlock()
+	if ok {
+		t.Error("expected opportunity below the minimum profit not to be emitted")
+	}
+
+	// Ticker updates requote the venue
+	// The dispatcher can miss a subscriber so the ticker is published until
+	// the venue is requoted, as a live feed would
+	tick.Bid, tick.BidSize = 108, 2
+	requoted := make(chan struct{})
+	defer close(requoted)
+	go func() {
+		for {
+			cpy := *tick
+			if err := ticker.ProcessTicker(&cpy); err != nil {
+				t.Error(err)
+			}
+			select {
+			case <-requoted:
+				return
+			case <-time.After(5 * time.Millisecond):
+			}
+		}
+	}()
+	o = col.wait(t, route{buy: b.name, sell: c.name}, func(o Opportunity) bool { return true })
+	if o.Size != 1 || !nearly(o.PnL, 4) {
+		t.Errorf("unexpected opportunity %+v", o)
+	}
+	o = col.wait(t, route{buy: a.name, sell: c.name}, func(o Opportunity) bool { return o.Size == 2 })
+	if !nearly(o.PnL, 8+7-0.01*201/2) {
+		t.Errorf("unexpected opportunity %+v", o)
+	}
+}
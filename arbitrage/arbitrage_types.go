@@ -0,0 +1,122 @@
+package arbitrage
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/openware/irix/consolidated"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/dispatch"
+)
+
+var (
+	errNoPairs             = errors.New("no pairs")
+	errTooFewVenues        = errors.New("at least two venues are required")
+	errDuplicatePair       = errors.New("duplicate pair")
+	errDuplicateVenue      = errors.New("duplicate venue")
+	errInvalidThreshold    = errors.New("thresholds cannot be negative")
+	errOpportunityCallback = errors.New("opportunity callback unset")
+	errRunning             = errors.New("monitor already running")
+	errNotRunning          = errors.New("monitor not running")
+)
+
+// Venue is a venue watched for a pair
+type Venue struct {
+	consolidated.Venue
+	// Ticker quotes the venue from its ticker's best bid and ask and their
+	// sizes rather than its orderbook. A side without a size is not quoted.
+	Ticker bool
+}
+
+// Pair is a normalised pair watched across venues
+type Pair struct {
+	Pair   currency.Pair
+	Venues []Venue
+}
+
+// Config configures a monitor, taker fees are always charged
+type Config struct {
+	Pairs []Pair
+	// Depth limits the orderbook levels taken from each venue, zero takes
+	// them all
+	Depth int
+	// MinSpreadBPS is the minimum spread between the fee adjusted best bid
+	// and ask
+	MinSpreadBPS float64
+	// MinProfit is the minimum expected profit in the quote currency
+	MinProfit float64
+	// MinSize is the minimum executable base amount
+	MinSize float64
+	// MaxSize caps the base amount an opportunity is sized at, zero is
+	// uncapped
+	MaxSize float64
+	// WithdrawalCosts charges the buying venue's fee for withdrawing the
+	// base currency bought to the selling venue
+	WithdrawalCosts bool
+	// OnOpportunity is called when an opportunity is found or changes
+	OnOpportunity func(Opportunity)
+}
+
+// Opportunity is an executable spread between buying on one venue and
+// selling on another, prices are in the pair's quote currency and net of
+// taker fees
+type Opportunity struct {
+	Pair currency.Pair
+	Buy  string
+	Sell string
+	// BuyPrice and SellPrice are the best fee adjusted ask and bid
+	BuyPrice  float64
+	SellPrice float64
+	SpreadBPS float64
+	// Size is the base amount executable while the books cross
+	Size           float64
+	Cost           float64
+	Proceeds       float64
+	WithdrawalCost float64
+	PnL            float64
+	Detected       time.Time
+}
+
+// Monitor watches venues for executable cross-venue spreads
+type Monitor struct {
+	cfg      Config
+	pairs    []*pair
+	shutdown chan struct{}
+	wg       sync.WaitGroup
+	m        sync.Mutex
+}
+
+// pair is the state of a watched pair
+type pair struct {
+	Pair
+	aggregator *consolidated.Aggregator
+	tickers    []*tickerVenue
+	// quotes are the levels of each venue by name
+	quotes map[string]*quote
+	// names are the venues' exchange names in venue order
+	names []string
+	// withdrawal is each venue's base withdrawal fee by name
+	withdrawal map[string]float64
+	last       map[route]Opportunity
+	m          sync.Mutex
+}
+
+// quote is a venue's fee adjusted levels in the pair's quote currency
+type quote struct {
+	bids []consolidated.Level
+	asks []consolidated.Level
+}
+
+// tickerVenue is a venue quoted from its ticker
+type tickerVenue struct {
+	Venue
+	name string
+	fee  float64
+	pipe dispatch.Pipe
+}
+
+// route is a buying and selling venue
+type route struct {
+	buy, sell string
+}
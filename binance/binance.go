@@ -10,9 +10,11 @@ import (
 	"net/url"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	exchange "github.com/openware/irix"
+	"github.com/openware/irix/stream/buffer"
 	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/common"
 	"github.com/openware/pkg/common/convert"
@@ -28,7 +30,7 @@ type Binance struct {
 	exchange.Base
 	// Valid string list that is required by the exchange
 	validLimits []int
-	obm         *orderbookManager
+	obm         *buffer.SyncManager
 }
 
 const (
@@ -39,6 +41,7 @@ const (
 
 	// Public endpoints
 	exchangeInfo      = "/api/v3/exchangeInfo"
+	serverTime        = "/api/v3/time"
 	orderBookDepth    = "/api/v3/depth"
 	recentTrades      = "/api/v3/trades"
 	aggregatedTrades  = "/api/v3/aggTrades"
@@ -69,6 +72,10 @@ const (
 	assetDetail                            = "/wapi/v3/assetDetail.html"
 	undocumentedInterestHistory            = "/gateway-api/v1/public/isolated-margin/pair/vip-level"
 	undocumentedCrossMarginInterestHistory = "/gateway-api/v1/friendly/margin/vip/spec/list-all"
+
+	// Order rejection identifiers
+	orderDoesNotExistCode = "-2013"
+	orderDoesNotExistMsg  = "Order does not exist"
 )
 
 // GetInterestHistory gets interest history for currency/currencies provided
@@ -102,6 +109,18 @@ func (b *Binance) GetExchangeInfo() (ExchangeInfo, error) {
 	return resp, b.SendHTTPRequest(exchange.RestSpotSupplementary, exchangeInfo, spotDefaultRate, &resp)
 }
 
+// GetServerTime returns the current server time
+func (b *Binance) GetServerTime() (time.Time, error) {
+	var resp struct {
+		ServerTime int64 `json:"serverTime"`
+	}
+	err := b.SendHTTPRequest(exchange.RestSpotSupplementary, serverTime, spotDefaultRate, &resp)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, resp.ServerTime*int64(time.Millisecond)), nil
+}
+
 // GetOrderBook returns full orderbook information
 //
 // OrderBookDataRequestParams contains the following members
@@ -505,18 +524,24 @@ func (b *Binance) newOrder(api string, o *NewOrderRequest, resp *NewOrderRespons
 	params.Set("type", string(o.TradeType))
 	if o.QuoteOrderQty > 0 {
 		params.Set("quoteOrderQty", strconv.FormatFloat(o.QuoteOrderQty, 'f', -1, 64))
+	} else if o.QuantityString != "" {
+		params.Set("quantity", o.QuantityString)
 	} else {
 		params.Set("quantity", strconv.FormatFloat(o.Quantity, 'f', -1, 64))
 	}
 	if o.TradeType == BinanceRequestParamsOrderLimit {
-		params.Set("price", strconv.FormatFloat(o.Price, 'f', -1, 64))
+		if o.PriceString != "" {
+			params.Set("price", o.PriceString)
+		} else {
+			params.Set("price", strconv.FormatFloat(o.Price, 'f', -1, 64))
+		}
 	}
 	if o.TimeInForce != "" {
 		params.Set("timeInForce", string(o.TimeInForce))
 	}
 
 	if o.NewClientOrderID != "" {
-		params.Set("newClientOrderID", o.NewClientOrderID)
+		params.Set("newClientOrderId", o.NewClientOrderID)
 	}
 
 	if o.StopPrice != 0 {
@@ -631,6 +656,20 @@ func (b *Binance) QueryOrder(symbol currency.Pair, origClientOrderID string, ord
 	return resp, nil
 }
 
+// wrapOrderNotFound wraps Binance's unknown order rejection with
+// exchange.ErrOrderNotFound so callers can distinguish it from request errors
+func wrapOrderNotFound(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	if strings.Contains(msg, orderDoesNotExistCode) ||
+		strings.Contains(msg, orderDoesNotExistMsg) {
+		return fmt.Errorf("%w: %v", exchange.ErrOrderNotFound, err)
+	}
+	return err
+}
+
 // GetAccount returns binance user accounts
 func (b *Binance) GetAccount() (*Account, error) {
 	type response struct {
@@ -694,7 +733,7 @@ func (b *Binance) SendAuthHTTPRequest(ePath exchange.URL, method, path string, p
 		params.Set("recvWindow", strconv.FormatInt(convert.RecvWindow(recvWindow), 10))
 	}
 	params.Set("recvWindow", strconv.FormatInt(convert.RecvWindow(recvWindow), 10))
-	params.Set("timestamp", strconv.FormatInt(time.Now().Unix()*1000, 10))
+	params.Set("timestamp", strconv.FormatInt(b.Clock.Now().Unix()*1000, 10))
 	signature := params.Encode()
 	hmacSigned := crypto.GetHMAC(crypto.HashSHA256, []byte(signature), []byte(b.API.Credentials.Secret))
 	hmacSignedStr := crypto.HexEncodeToString(hmacSigned)
@@ -32,7 +32,10 @@ func TestMain(m *testing.M) {
 	if err != nil {
 		log.Fatal("Binance setup error", err)
 	}
-	b.setupOrderbookManager()
+	err = b.setupOrderbookManager()
+	if err != nil {
+		log.Fatal("Binance orderbook manager setup error", err)
+	}
 	b.Websocket.DataHandler = sharedtestvalues.GetWebsocketInterfaceChannelOverride()
 	log.Printf(sharedtestvalues.LiveTesting, b.Name)
 	os.Exit(m.Run())
@@ -36,7 +36,10 @@ func TestMain(m *testing.M) {
 		log.Fatal("Binance setup error", err)
 	}
 
-	b.setupOrderbookManager()
+	err = b.setupOrderbookManager()
+	if err != nil {
+		log.Fatal("Binance orderbook manager setup error", err)
+	}
 
 	serverDetails, newClient, err := mock.NewVCRServer(mockfile)
 	if err != nil {
@@ -3,6 +3,7 @@ package binance
 import (
 	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -1115,6 +1116,14 @@ func TestGetMarginExchangeInfo(t *testing.T) {
 	}
 }
 
+func TestGetServerTime(t *testing.T) {
+	t.Parallel()
+	_, err := b.GetServerTime()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
 func TestGetExchangeInfo(t *testing.T) {
 	t.Parallel()
 	info, err := b.GetExchangeInfo()
@@ -2075,7 +2084,9 @@ func TestWsTradeUpdate(t *testing.T) {
 }
 
 func TestWsDepthUpdate(t *testing.T) {
-	b.setupOrderbookManager()
+	if err := b.setupOrderbookManager(); err != nil {
+		t.Fatal(err)
+	}
 	seedLastUpdateID := int64(161)
 	book := OrderBook{
 		Asks: []OrderbookItem{
@@ -2386,27 +2397,39 @@ var websocketDepthUpdate = []byte(`{"E":1608001030784,"U":7145637266,"a":[["1945
 
 func TestProcessUpdate(t *testing.T) {
 	t.Parallel()
-	p := currency.NewPair(currency.BTC, currency.USDT)
+	p := currency.NewPair(currency.LTC, currency.USDT)
 	var depth WebsocketDepthStream
 	err := json.Unmarshal(websocketDepthUpdate, &depth)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	err = b.obm.stageWsUpdate(&depth, p, asset.Spot)
+	err = b.SeedLocalCacheWithBook(p, &OrderBook{
+		Bids:         []OrderbookItem{{Price: 19455.18, Quantity: 1}},
+		Asks:         []OrderbookItem{{Price: 19455.19, Quantity: 1}},
+		LastUpdateID: depth.FirstUpdateID,
+	})
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	err = b.obm.fetchBookViaREST(p)
+	err = b.ProcessUpdate(p, asset.Spot, &depth)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	err = b.obm.cleanup(p)
+	ob, err := b.Websocket.Orderbook.GetOrderbook(p, asset.Spot)
 	if err != nil {
 		t.Fatal(err)
 	}
+	if ob.LastUpdateID != depth.LastUpdateID {
+		t.Errorf("expected update %d to be applied, book is at %d",
+			depth.LastUpdateID,
+			ob.LastUpdateID)
+	}
+	if b.obm.IsSyncing(p, asset.Spot) {
+		t.Error("expected aligned update not to resynchronise the book")
+	}
 }
 
 func TestUFuturesHistoricalTrades(t *testing.T) {
@@ -2497,3 +2520,17 @@ func TestWsOutboundAccountPosition(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestWsSubscriptionAcknowledgement(t *testing.T) {
+	t.Parallel()
+	if err := wsConfirmSubscription([]byte(`{"result":null,"id":312}`)); err != nil {
+		t.Error(err)
+	}
+	err := wsConfirmSubscription([]byte(`{"error":{"code":2,"msg":"Invalid request: unknown variant"},"id":312}`))
+	if err == nil || !strings.Contains(err.Error(), "unknown variant") {
+		t.Errorf("expected rejection, received %v", err)
+	}
+	if err = b.wsHandleData([]byte(`{"result":null,"id":312}`)); err != nil {
+		t.Error(err)
+	}
+}
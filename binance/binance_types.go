@@ -1,10 +1,8 @@
 package binance
 
 import (
-	"sync"
 	"time"
 
-	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/currency"
 )
 
@@ -328,6 +326,10 @@ type NewOrderRequest struct {
 	StopPrice        float64 // Used with STOP_LOSS, STOP_LOSS_LIMIT, TAKE_PROFIT, and TAKE_PROFIT_LIMIT orders.
 	IcebergQty       float64 // Used with LIMIT, STOP_LOSS_LIMIT, and TAKE_PROFIT_LIMIT to create an iceberg order.
 	NewOrderRespType string
+	// QuantityString and PriceString are exact formatted values sent in place
+	// of Quantity and Price when set
+	QuantityString string
+	PriceString    string
 }
 
 // NewOrderResponse is the return structured response from the exchange
@@ -776,6 +778,15 @@ type WsPayload struct {
 	ID     int64    `json:"id"`
 }
 
+// wsSubscriptionResponse is Binance's reply to a subscription request
+type wsSubscriptionResponse struct {
+	ID    int64 `json:"id"`
+	Error *struct {
+		Code    int64  `json:"code"`
+		Message string `json:"msg"`
+	} `json:"error"`
+}
+
 // CrossMarginInterestData stores cross margin data for borrowing
 type CrossMarginInterestData struct {
 	Code          int64  `json:"code,string"`
@@ -791,24 +802,3 @@ type CrossMarginInterestData struct {
 	} `json:"data"`
 	Success bool `json:"success"`
 }
-
-// orderbookManager defines a way of managing and maintaining synchronisation
-// across connections and assets.
-type orderbookManager struct {
-	state map[currency.Code]map[currency.Code]map[asset.Item]*update
-	sync.Mutex
-
-	jobs chan job
-}
-
-type update struct {
-	buffer       chan *WebsocketDepthStream
-	fetchingBook bool
-	initialSync  bool
-}
-
-// job defines a synchonisation job that tells a go routine to fetch an
-// orderbook via the REST protocol
-type job struct {
-	Pair currency.Pair
-}
@@ -24,6 +24,13 @@ import (
 const (
 	binanceDefaultWebsocketURL = "wss://stream.binance.com:9443/stream"
 	pingDelay                  = time.Minute * 9
+	// Binance disconnects clients sending more than 5 messages a second
+	wsMessageRate = 5
+	// wsSubscriptionsPerMessage keeps subscribe requests to a size Binance
+	// reliably acknowledges
+	wsSubscriptionsPerMessage = 200
+	// Binance allows up to 1024 streams on a single connection
+	wsStreamsPerConnection = 1024
 )
 
 var listenKey string
@@ -32,11 +39,8 @@ var (
 	// maxWSUpdateBuffer defines max websocket updates to apply when an
 	// orderbook is initially fetched
 	maxWSUpdateBuffer = 150
-	// maxWSOrderbookJobs defines max websocket orderbook jobs in queue to fetch
-	// an orderbook snapshot via REST
-	maxWSOrderbookJobs = 2000
-	// maxWSOrderbookWorkers defines a max amount of workers allowed to execute
-	// jobs from the job channel
+	// maxWSOrderbookWorkers defines a max amount of orderbooks fetched via
+	// REST at once
 	maxWSOrderbookWorkers = 10
 )
 
@@ -85,23 +89,47 @@ func (b *Binance) WsConnect() error {
 		Delay:             pingDelay,
 	})
 
-	go b.wsReadData()
-	b.setupOrderbookManager()
-	return nil
+	go b.wsReadData(b.Websocket.Conn)
+	return b.setupOrderbookManager()
 }
 
-func (b *Binance) setupOrderbookManager() {
-	if b.obm == nil {
-		b.obm = &orderbookManager{
-			state: make(map[currency.Code]map[currency.Code]map[asset.Item]*update),
-			jobs:  make(chan job, maxWSOrderbookJobs),
-		}
+// wsConnectShard connects an additional connection carrying market data
+// streams once the primary connection holds wsStreamsPerConnection
+func (b *Binance) wsConnectShard(conn stream.Connection) error {
+	// The user data stream stays on the primary connection
+	conn.SetURL(strings.Split(conn.GetURL(), "?streams=")[0])
+	var dialer websocket.Dialer
+	dialer.HandshakeTimeout = b.Config.HTTPTimeout
+	err := conn.Dial(&dialer, http.Header{})
+	if err != nil {
+		return fmt.Errorf("%v - Unable to connect to Websocket. Error: %s",
+			b.Name,
+			err)
+	}
+	conn.SetupPingHandler(stream.PingHandler{
+		UseGorillaHandler: true,
+		MessageType:       websocket.PongMessage,
+		Delay:             pingDelay,
+	})
+	go b.wsReadData(conn)
+	return nil
+}
 
-		for i := 0; i < maxWSOrderbookWorkers; i++ {
-			// 10 workers for synchronising book
-			b.SynchroniseWebsocketOrderbook()
-		}
+// setupOrderbookManager sets up the sync manager which fetches orderbooks via
+// REST and splices the depth updates buffered meanwhile
+func (b *Binance) setupOrderbookManager() error {
+	if b.obm != nil {
+		return nil
 	}
+	obm, err := buffer.NewSyncManager(&b.Websocket.Orderbook,
+		b.UpdateOrderbook,
+		maxWSUpdateBuffer,
+		maxWSOrderbookWorkers)
+	if err != nil {
+		return err
+	}
+	b.obm = obm
+	return nil
 }
 
 // KeepAuthKeyAlive will continuously send messages to
@@ -127,12 +155,12 @@ func (b *Binance) KeepAuthKeyAlive() {
 }
 
 // wsReadData receives and passes on websocket messages for processing
-func (b *Binance) wsReadData() {
+func (b *Binance) wsReadData(conn stream.Connection) {
 	b.Websocket.Wg.Add(1)
 	defer b.Websocket.Wg.Done()
 
 	for {
-		resp := b.Websocket.Conn.ReadMessage()
+		resp := conn.ReadMessage()
 		if resp.Raw == nil {
 			return
 		}
@@ -150,6 +178,16 @@ func (b *Binance) wsHandleData(respRaw []byte) error {
 		return err
 	}
 
+	if id, ok := multiStreamData["id"].(float64); ok {
+		_, acknowledged := multiStreamData["result"]
+		_, rejected := multiStreamData["error"]
+		if acknowledged || rejected {
+			// Resolves the subscription request sent with this ID
+			b.Websocket.Match.IncomingWithData(int64(id), respRaw)
+			return nil
+		}
+	}
+
 	if r, ok := multiStreamData["result"]; ok {
 		if r == nil {
 			return nil
@@ -396,11 +434,8 @@ func (b *Binance) wsHandleData(respRaw []byte) error {
 							err)
 					}
 
-					init, err := b.UpdateLocalBuffer(&depth)
+					err = b.UpdateLocalBuffer(&depth)
 					if err != nil {
-						if init {
-							return nil
-						}
 						return fmt.Errorf("%v - UpdateLocalCache error: %s",
 							b.Name,
 							err)
@@ -468,43 +503,30 @@ func (b *Binance) SeedLocalCacheWithBook(p currency.Pair, orderbookNew *OrderBoo
 	newOrderBook.LastUpdateID = orderbookNew.LastUpdateID
 	newOrderBook.VerifyOrderbook = b.CanVerifyOrderbook
 
-	return b.Websocket.Orderbook.LoadSnapshot(&newOrderBook)
+	return b.obm.LoadSnapshot(&newOrderBook)
 }
 
-// UpdateLocalBuffer updates and returns the most recent iteration of the orderbook
-func (b *Binance) UpdateLocalBuffer(wsdp *WebsocketDepthStream) (bool, error) {
+// UpdateLocalBuffer applies a depth update to the local orderbook, fetching
+// the orderbook via REST when it is not in sync
+func (b *Binance) UpdateLocalBuffer(wsdp *WebsocketDepthStream) error {
 	enabledPairs, err := b.GetEnabledPairs(asset.Spot)
 	if err != nil {
-		return false, err
+		return err
 	}
 
 	format, err := b.GetPairFormat(asset.Spot, true)
 	if err != nil {
-		return false, err
+		return err
 	}
 
 	currencyPair, err := currency.NewPairFromFormattedPairs(wsdp.Pair,
 		enabledPairs,
 		format)
 	if err != nil {
-		return false, err
-	}
-
-	err = b.obm.stageWsUpdate(wsdp, currencyPair, asset.Spot)
-	if err != nil {
-		init, err2 := b.obm.checkIsInitialSync(currencyPair)
-		if err2 != nil {
-			return false, err2
-		}
-		return init, err
-	}
-
-	err = b.applyBufferUpdate(currencyPair)
-	if err != nil {
-		b.flushAndCleanup(currencyPair)
+		return err
 	}
 
-	return false, err
+	return b.ProcessUpdate(currencyPair, asset.Spot, wsdp)
 }
 
 // GenerateSubscriptions generates the default subscription set
@@ -537,34 +559,58 @@ func (b *Binance) GenerateSubscriptions() ([]stream.ChannelSubscription, error)
 
 // Subscribe subscribes to a set of channels
 func (b *Binance) Subscribe(channelsToSubscribe []stream.ChannelSubscription) error {
+	return b.subscribeOn(b.Websocket.Conn, channelsToSubscribe)
+}
+
+// subscribeOn subscribes to a set of channels on a connection
+func (b *Binance) subscribeOn(conn stream.Connection, channelsToSubscribe []stream.ChannelSubscription) error {
 	payload := WsPayload{
 		Method: "SUBSCRIBE",
+		ID:     conn.GenerateMessageID(false),
 	}
 
 	for i := range channelsToSubscribe {
 		payload.Params = append(payload.Params, channelsToSubscribe[i].Channel)
 	}
-	err := b.Websocket.Conn.SendJSONMessage(payload)
+	// Expected before sending as the acknowledgement can arrive first
+	err := b.Websocket.ExpectSubscriptions(payload.ID, channelsToSubscribe...)
 	if err != nil {
 		return err
 	}
-	b.Websocket.AddSuccessfulSubscriptions(channelsToSubscribe...)
-	return nil
+	return conn.SendJSONMessage(stream.WithPriority(stream.PrioritySubscription, payload))
 }
 
 // Unsubscribe unsubscribes from a set of channels
 func (b *Binance) Unsubscribe(channelsToUnsubscribe []stream.ChannelSubscription) error {
+	return b.unsubscribeOn(b.Websocket.Conn, channelsToUnsubscribe)
+}
+
+// unsubscribeOn unsubscribes from a set of channels on a connection
+func (b *Binance) unsubscribeOn(conn stream.Connection, channelsToUnsubscribe []stream.ChannelSubscription) error {
 	payload := WsPayload{
 		Method: "UNSUBSCRIBE",
+		ID:     conn.GenerateMessageID(false),
 	}
 	for i := range channelsToUnsubscribe {
 		payload.Params = append(payload.Params, channelsToUnsubscribe[i].Channel)
 	}
-	err := b.Websocket.Conn.SendJSONMessage(payload)
+	err := b.Websocket.ExpectUnsubscriptions(payload.ID, channelsToUnsubscribe...)
 	if err != nil {
 		return err
 	}
-	b.Websocket.RemoveSuccessfulUnsubscriptions(channelsToUnsubscribe...)
+	return conn.SendJSONMessage(stream.WithPriority(stream.PrioritySubscription, payload))
+}
+
+// wsConfirmSubscription returns the error Binance rejected a subscription
+// request with
+func wsConfirmSubscription(resp []byte) error {
+	var r wsSubscriptionResponse
+	if err := json.Unmarshal(resp, &r); err != nil {
+		return err
+	}
+	if r.Error != nil {
+		return fmt.Errorf("subscription rejected with code %d: %s", r.Error.Code, r.Error.Message)
+	}
 	return nil
 }
 
@@ -612,314 +658,12 @@ func (b *Binance) ProcessUpdate(cp currency.Pair, a asset.Item, ws *WebsocketDep
 		updateAsk = append(updateAsk, orderbook.Item{Price: p, Amount: a})
 	}
 
-	return b.Websocket.Orderbook.Update(&buffer.Update{
-		Bids:     updateBid,
-		Asks:     updateAsk,
-		Pair:     cp,
-		UpdateID: ws.LastUpdateID,
-		Asset:    a,
+	return b.obm.Update(&buffer.Update{
+		Bids:          updateBid,
+		Asks:          updateAsk,
+		Pair:          cp,
+		FirstUpdateID: ws.FirstUpdateID,
+		UpdateID:      ws.LastUpdateID,
+		Asset:         a,
 	})
 }
-
-// applyBufferUpdate applies the buffer to the orderbook or initiates a new
-// orderbook sync by the REST protocol which is off handed to go routine.
-func (b *Binance) applyBufferUpdate(pair currency.Pair) error {
-	fetching, err := b.obm.checkIsFetchingBook(pair)
-	if err != nil {
-		return err
-	}
-	if fetching {
-		return nil
-	}
-
-	recent, err := b.Websocket.Orderbook.GetOrderbook(pair, asset.Spot)
-	if err != nil || (recent.Asks == nil && recent.Bids == nil) {
-		return b.obm.fetchBookViaREST(pair)
-	}
-
-	return b.obm.checkAndProcessUpdate(b.ProcessUpdate, pair, recent)
-}
-
-// SynchroniseWebsocketOrderbook synchronises full orderbook for currency pair
-// asset
-func (b *Binance) SynchroniseWebsocketOrderbook() {
-	b.Websocket.Wg.Add(1)
-	go func() {
-		defer b.Websocket.Wg.Done()
-		for {
-			select {
-			case j := <-b.obm.jobs:
-				err := b.processJob(j.Pair)
-				if err != nil {
-					log.Errorf(log.WebsocketMgr,
-						"%s processing websocket orderbook error %v",
-						b.Name, err)
-				}
-			case <-b.Websocket.ShutdownC:
-				return
-			}
-		}
-	}()
-}
-
-// processJob fetches and processes orderbook updates
-func (b *Binance) processJob(p currency.Pair) error {
-	err := b.SeedLocalCache(p)
-	if err != nil {
-		return fmt.Errorf("%s %s seeding local cache for orderbook error: %v",
-			p, asset.Spot, err)
-	}
-
-	err = b.obm.stopFetchingBook(p)
-	if err != nil {
-		return err
-	}
-
-	// Immediately apply the buffer updates so we don't wait for a
-	// new update to initiate this.
-	err = b.applyBufferUpdate(p)
-	if err != nil {
-		b.flushAndCleanup(p)
-		return err
-	}
-	return nil
-}
-
-// flushAndCleanup flushes orderbook and clean local cache
-func (b *Binance) flushAndCleanup(p currency.Pair) {
-	errClean := b.Websocket.Orderbook.FlushOrderbook(p, asset.Spot)
-	if errClean != nil {
-		log.Errorf(log.WebsocketMgr,
-			"%s flushing websocket error: %v",
-			b.Name,
-			errClean)
-	}
-	errClean = b.obm.cleanup(p)
-	if errClean != nil {
-		log.Errorf(log.WebsocketMgr, "%s cleanup websocket error: %v",
-			b.Name,
-			errClean)
-	}
-}
-
-// stageWsUpdate stages websocket update to roll through updates that need to
-// be applied to a fetched orderbook via REST.
-func (o *orderbookManager) stageWsUpdate(u *WebsocketDepthStream, pair currency.Pair, a asset.Item) error {
-	o.Lock()
-	defer o.Unlock()
-	m1, ok := o.state[pair.Base]
-	if !ok {
-		m1 = make(map[currency.Code]map[asset.Item]*update)
-		o.state[pair.Base] = m1
-	}
-
-	m2, ok := m1[pair.Quote]
-	if !ok {
-		m2 = make(map[asset.Item]*update)
-		m1[pair.Quote] = m2
-	}
-
-	state, ok := m2[a]
-	if !ok {
-		state = &update{
-			// 100ms update assuming we might have up to a 10 second delay.
-			// There could be a potential 100 updates for the currency.
-			buffer:       make(chan *WebsocketDepthStream, maxWSUpdateBuffer),
-			fetchingBook: false,
-			initialSync:  true,
-		}
-		m2[a] = state
-	}
-
-	select {
-	// Put update in the channel buffer to be processed
-	case state.buffer <- u:
-		return nil
-	default:
-		<-state.buffer    // pop one element
-		state.buffer <- u // to shift buffer on fail
-		return fmt.Errorf("channel blockage for %s, asset %s and connection",
-			pair, a)
-	}
-}
-
-// checkIsFetchingBook checks status if the book is currently being via the REST
-// protocol.
-func (o *orderbookManager) checkIsFetchingBook(pair currency.Pair) (bool, error) {
-	o.Lock()
-	defer o.Unlock()
-	state, ok := o.state[pair.Base][pair.Quote][asset.Spot]
-	if !ok {
-		return false,
-			fmt.Errorf("check is fetching book cannot match currency pair %s asset type %s",
-				pair,
-				asset.Spot)
-	}
-	return state.fetchingBook, nil
-}
-
-// stopFetchingBook completes the book fetching.
-func (o *orderbookManager) stopFetchingBook(pair currency.Pair) error {
-	o.Lock()
-	defer o.Unlock()
-	state, ok := o.state[pair.Base][pair.Quote][asset.Spot]
-	if !ok {
-		return fmt.Errorf("could not match pair %s and asset type %s in hash table",
-			pair,
-			asset.Spot)
-	}
-	if !state.fetchingBook {
-		return fmt.Errorf("fetching book already set to false for %s %s",
-			pair,
-			asset.Spot)
-	}
-	state.fetchingBook = false
-	return nil
-}
-
-// completeInitialSync sets if an asset type has completed its initial sync
-func (o *orderbookManager) completeInitialSync(pair currency.Pair) error {
-	o.Lock()
-	defer o.Unlock()
-	state, ok := o.state[pair.Base][pair.Quote][asset.Spot]
-	if !ok {
-		return fmt.Errorf("complete initial sync cannot match currency pair %s asset type %s",
-			pair,
-			asset.Spot)
-	}
-	if !state.initialSync {
-		return fmt.Errorf("initital sync already set to false for %s %s",
-			pair,
-			asset.Spot)
-	}
-	state.initialSync = false
-	return nil
-}
-
-// checkIsInitialSync checks status if the book is Initial Sync being via the REST
-// protocol.
-func (o *orderbookManager) checkIsInitialSync(pair currency.Pair) (bool, error) {
-	o.Lock()
-	defer o.Unlock()
-	state, ok := o.state[pair.Base][pair.Quote][asset.Spot]
-	if !ok {
-		return false,
-			fmt.Errorf("checkIsInitialSync of orderbook cannot match currency pair %s asset type %s",
-				pair,
-				asset.Spot)
-	}
-	return state.initialSync, nil
-}
-
-// fetchBookViaREST pushes a job of fetching the orderbook via the REST protocol
-// to get an initial full book that we can apply our buffered updates too.
-func (o *orderbookManager) fetchBookViaREST(pair currency.Pair) error {
-	o.Lock()
-	defer o.Unlock()
-
-	state, ok := o.state[pair.Base][pair.Quote][asset.Spot]
-	if !ok {
-		return fmt.Errorf("fetch book via rest cannot match currency pair %s asset type %s",
-			pair,
-			asset.Spot)
-	}
-
-	state.initialSync = true
-	state.fetchingBook = true
-
-	select {
-	case o.jobs <- job{pair}:
-		return nil
-	default:
-		return fmt.Errorf("%s %s book synchronisation channel blocked up",
-			pair,
-			asset.Spot)
-	}
-}
-
-func (o *orderbookManager) checkAndProcessUpdate(processor func(currency.Pair, asset.Item, *WebsocketDepthStream) error, pair currency.Pair, recent *orderbook.Base) error {
-	o.Lock()
-	defer o.Unlock()
-	state, ok := o.state[pair.Base][pair.Quote][asset.Spot]
-	if !ok {
-		return fmt.Errorf("could not match pair [%s] asset type [%s] in hash table to process websocket orderbook update",
-			pair, asset.Spot)
-	}
-
-	// This will continuously remove updates from the buffered channel and
-	// apply them to the current orderbook.
-buffer:
-	for {
-		select {
-		case d := <-state.buffer:
-			process, err := state.validate(d, recent)
-			if err != nil {
-				return err
-			}
-			if process {
-				err := processor(pair, asset.Spot, d)
-				if err != nil {
-					return fmt.Errorf("%s %s processing update error: %w",
-						pair, asset.Spot, err)
-				}
-			}
-		default:
-			break buffer
-		}
-	}
-	return nil
-}
-
-// validate checks for correct update alignment
-func (u *update) validate(updt *WebsocketDepthStream, recent *orderbook.Base) (bool, error) {
-	if updt.LastUpdateID <= recent.LastUpdateID {
-		// Drop any event where u is <= lastUpdateId in the snapshot.
-		return false, nil
-	}
-
-	id := recent.LastUpdateID + 1
-	if u.initialSync {
-		// The first processed event should have U <= lastUpdateId+1 AND
-		// u >= lastUpdateId+1.
-		if updt.FirstUpdateID > id || updt.LastUpdateID < id {
-			return false, fmt.Errorf("initial websocket orderbook sync failure for pair %s and asset %s",
-				recent.Pair,
-				asset.Spot)
-		}
-		u.initialSync = false
-	} else if updt.FirstUpdateID != id {
-		// While listening to the stream, each new event's U should be
-		// equal to the previous event's u+1.
-		return false, fmt.Errorf("websocket orderbook synchronisation failure for pair %s and asset %s",
-			recent.Pair,
-			asset.Spot)
-	}
-	return true, nil
-}
-
-// cleanup cleans up buffer and reset fetch and init
-func (o *orderbookManager) cleanup(pair currency.Pair) error {
-	o.Lock()
-	state, ok := o.state[pair.Base][pair.Quote][asset.Spot]
-	if !ok {
-		o.Unlock()
-		return fmt.Errorf("cleanup cannot match %s %s to hash table",
-			pair,
-			asset.Spot)
-	}
-
-bufferEmpty:
-	for {
-		select {
-		case <-state.buffer:
-			// bleed and discard buffer
-		default:
-			break bufferEmpty
-		}
-	}
-	o.Unlock()
-	// disable rest orderbook synchronisation
-	_ = o.stopFetchingBook(pair)
-	_ = o.completeInitialSync(pair)
-	return nil
-}
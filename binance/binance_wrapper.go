@@ -57,6 +57,7 @@ func (b *Binance) SetDefaults() {
 	b.Verbose = true
 	b.API.CredentialsValidator.RequiresKey = true
 	b.API.CredentialsValidator.RequiresSecret = true
+	b.RateLimitBuckets = rateLimitBuckets()
 	b.SetValues()
 
 	fmt1 := currency.PairStore{
@@ -212,6 +213,7 @@ func (b *Binance) Setup(exch *config.ExchangeConfig) error {
 	if err != nil {
 		return err
 	}
+	b.SetServerTimeSource(b.GetServerTime)
 	ePoint, err := b.API.Endpoints.GetURL(exchange.WebsocketSpot)
 	if err != nil {
 		return err
@@ -233,6 +235,12 @@ func (b *Binance) Setup(exch *config.ExchangeConfig) error {
 		BufferEnabled:                    exch.OrderbookConfig.WebsocketBufferEnabled,
 		SortBuffer:                       true,
 		SortBufferByUpdateIDs:            true,
+		MaxSubscriptionsPerMessage:       wsSubscriptionsPerMessage,
+		MaxSubscriptionsPerConnection:    wsStreamsPerConnection,
+		ShardConnector:                   b.wsConnectShard,
+		ShardSubscriber:                  b.subscribeOn,
+		ShardUnsubscriber:                b.unsubscribeOn,
+		ConfirmSubscriptions:             wsConfirmSubscription,
 	})
 	if err != nil {
 		return err
@@ -241,6 +249,7 @@ func (b *Binance) Setup(exch *config.ExchangeConfig) error {
 	return b.Websocket.SetupNewConnection(stream.ConnectionSetup{
 		ResponseCheckTimeout: exch.WebsocketResponseCheckTimeout,
 		ResponseMaxLimit:     exch.WebsocketResponseMaxLimit,
+		MessageRate:          wsMessageRate,
 	})
 }
 
@@ -318,6 +327,7 @@ func (b *Binance) Run() {
 	for x := range a {
 		if err = b.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
 			err = b.UpdateOrderExecutionLimits(a[x])
+			b.SetOrderExecutionLimitsLoadError(a[x], err)
 			if err != nil {
 				log.Errorf(log.ExchangeSys,
 					"Could not set %s exchange exchange limits: %v",
@@ -552,6 +562,8 @@ func (b *Binance) UpdateOrderbook(p currency.Pair, assetType asset.Item) (*order
 	if err != nil {
 		return book, err
 	}
+	// The websocket sync manager aligns depth updates to the snapshot by ID
+	book.LastUpdateID = orderbookNew.LastUpdateID
 	for x := range orderbookNew.Bids {
 		book.Bids = append(book.Bids, orderbook.Item{
 			Amount: orderbookNew.Bids[x].Quantity,
@@ -758,6 +770,13 @@ func (b *Binance) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 	if err := s.Validate(); err != nil {
 		return submitOrderResponse, err
 	}
+	if err := exchange.AttachClientOrderID(b, s); err != nil {
+		return submitOrderResponse, err
+	}
+	if err := b.ConformOrderToLimits(s); err != nil {
+		return submitOrderResponse, err
+	}
+	price, amount := b.SubmitValues(s)
 	switch s.AssetType {
 	case asset.Spot, asset.Margin:
 		var sideType string
@@ -781,12 +800,15 @@ func (b *Binance) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 		}
 
 		var orderRequest = NewOrderRequest{
-			Symbol:      s.Pair,
-			Side:        sideType,
-			Price:       s.Price,
-			Quantity:    s.Amount,
-			TradeType:   requestParamsOrderType,
-			TimeInForce: timeInForce,
+			Symbol:           s.Pair,
+			Side:             sideType,
+			Price:            s.Price,
+			Quantity:         s.Amount,
+			PriceString:      price,
+			QuantityString:   amount,
+			TradeType:        requestParamsOrderType,
+			TimeInForce:      timeInForce,
+			NewClientOrderID: s.ClientOrderID,
 		}
 		response, err := b.NewOrder(&orderRequest)
 		if err != nil {
@@ -1001,16 +1023,37 @@ func (b *Binance) CancelAllOrders(req *order.Cancel) (order.CancelAllResponse, e
 
 // GetOrderInfo returns information on a current open order
 func (b *Binance) GetOrderInfo(orderID string, pair currency.Pair, assetType asset.Item) (order.Detail, error) {
-	var respData order.Detail
-	orderIDInt, err := strconv.ParseInt(orderID, 10, 64)
-	if err != nil {
-		return respData, err
+	if orderID == "" {
+		return order.Detail{}, order.ErrOrderIDNotSet
 	}
+	return b.getOrderInfo(orderID, "", pair, assetType)
+}
+
+// GetOrderInfoByClientOrderID returns order information based on the client
+// order ID supplied on submission
+func (b *Binance) GetOrderInfoByClientOrderID(clientOrderID string, pair currency.Pair, assetType asset.Item) (order.Detail, error) {
+	if clientOrderID == "" {
+		return order.Detail{}, order.ErrOrderIDNotSet
+	}
+	return b.getOrderInfo("", clientOrderID, pair, assetType)
+}
+
+// getOrderInfo returns order information by either order ID or client order ID
+func (b *Binance) getOrderInfo(orderID, clientOrderID string, pair currency.Pair, assetType asset.Item) (order.Detail, error) {
+	var respData order.Detail
 	switch assetType {
 	case asset.Spot:
-		resp, err := b.QueryOrder(pair, "", orderIDInt)
+		var orderIDInt int64
+		if orderID != "" {
+			var err error
+			orderIDInt, err = strconv.ParseInt(orderID, 10, 64)
+			if err != nil {
+				return respData, err
+			}
+		}
+		resp, err := b.QueryOrder(pair, clientOrderID, orderIDInt)
 		if err != nil {
-			return respData, err
+			return respData, wrapOrderNotFound(err)
 		}
 		orderSide := order.Side(resp.Side)
 		status, err := order.StringToOrderStatus(resp.Status)
@@ -1026,6 +1069,7 @@ func (b *Binance) GetOrderInfo(orderID string, pair currency.Pair, assetType ass
 			Amount:         resp.OrigQty,
 			Exchange:       b.Name,
 			ID:             strconv.FormatInt(resp.OrderID, 10),
+			ClientOrderID:  resp.ClientOrderID,
 			Side:           orderSide,
 			Type:           orderType,
 			Pair:           pair,
@@ -1038,9 +1082,9 @@ func (b *Binance) GetOrderInfo(orderID string, pair currency.Pair, assetType ass
 			LastUpdated:    resp.UpdateTime,
 		}, nil
 	case asset.CoinMarginedFutures:
-		orderData, err := b.FuturesOpenOrderData(pair, orderID, "")
+		orderData, err := b.FuturesOpenOrderData(pair, orderID, clientOrderID)
 		if err != nil {
-			return respData, err
+			return respData, wrapOrderNotFound(err)
 		}
 		var feeBuilder exchange.FeeBuilder
 		feeBuilder.Amount = orderData.ExecutedQuantity
@@ -1057,7 +1101,7 @@ func (b *Binance) GetOrderInfo(orderID string, pair currency.Pair, assetType ass
 		respData.Exchange = b.Name
 		respData.ExecutedAmount = orderData.ExecutedQuantity
 		respData.Fee = fee
-		respData.ID = orderID
+		respData.ID = strconv.FormatInt(orderData.OrderID, 10)
 		respData.Pair = pair
 		respData.Price = orderData.Price
 		respData.RemainingAmount = orderData.OriginalQuantity - orderData.ExecutedQuantity
@@ -1067,9 +1111,9 @@ func (b *Binance) GetOrderInfo(orderID string, pair currency.Pair, assetType ass
 		respData.Date = orderData.Time
 		respData.LastUpdated = orderData.UpdateTime
 	case asset.USDTMarginedFutures:
-		orderData, err := b.UGetOrderData(pair, orderID, "")
+		orderData, err := b.UGetOrderData(pair, orderID, clientOrderID)
 		if err != nil {
-			return respData, err
+			return respData, wrapOrderNotFound(err)
 		}
 		var feeBuilder exchange.FeeBuilder
 		feeBuilder.Amount = orderData.ExecutedQuantity
@@ -1086,7 +1130,7 @@ func (b *Binance) GetOrderInfo(orderID string, pair currency.Pair, assetType ass
 		respData.Exchange = b.Name
 		respData.ExecutedAmount = orderData.ExecutedQuantity
 		respData.Fee = fee
-		respData.ID = orderID
+		respData.ID = strconv.FormatInt(orderData.OrderID, 10)
 		respData.Pair = pair
 		respData.Price = orderData.Price
 		respData.RemainingAmount = orderData.OriginalQuantity - orderData.ExecutedQuantity
@@ -1603,10 +1647,10 @@ func (b *Binance) UpdateOrderExecutionLimits(a asset.Item) error {
 			return nil
 		}
 	default:
-		err = fmt.Errorf("unhandled asset type %s", a)
+		err = fmt.Errorf("%s %w", a, asset.ErrNotSupported)
 	}
 	if err != nil {
-		return fmt.Errorf("cannot update exchange execution limits: %v", err)
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
 	}
 	return b.LoadLimits(limits)
 }
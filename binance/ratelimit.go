@@ -3,6 +3,7 @@ package binance
 import (
 	"time"
 
+	"github.com/openware/irix/ratelimit"
 	"github.com/openware/pkg/request"
 	"golang.org/x/time/rate"
 )
@@ -25,6 +26,33 @@ const (
 	uFuturesRequestRate      = 2400
 	uFuturesOrderInterval    = time.Minute
 	uFuturesOrderRequestRate = 1200
+	// Binance reports the daily order count but only enforces it per account
+	spotOrderDailyInterval    = 24 * time.Hour
+	spotOrderDailyRequestRate = 200000
+	// SAPI endpoints share the spot host but are weighted separately, 12000
+	// per minute per IP
+	sapiInterval    = time.Minute
+	sapiRequestRate = 12000
+)
+
+// Usage headers Binance returns on REST responses and the hosts and paths
+// they are counted for
+const (
+	usedWeightHeader     = "X-Mbx-Used-Weight-1m"
+	sapiUsedWeightHeader = "X-Sapi-Used-Ip-Weight-1m"
+	orderCount10sHeader  = "X-Mbx-Order-Count-10s"
+	orderCount1dHeader   = "X-Mbx-Order-Count-1d"
+	orderCount1mHeader   = "X-Mbx-Order-Count-1m"
+	spotAPIHost          = "api.binance.com"
+	uFuturesAPIHost      = "fapi.binance.com"
+	cFuturesAPIHost      = "dapi.binance.com"
+	spotAPIPath          = "/api/"
+	sapiPath             = "/sapi/"
+	spotOrderPath        = "/api/v3/order"
+	uFuturesOrderPath    = "/fapi/v1/order"
+	uFuturesBatchPath    = "/fapi/v1/batchOrders"
+	cFuturesOrderPath    = "/dapi/v1/order"
+	cFuturesBatchPath    = "/dapi/v1/batchOrders"
 )
 
 // Binance Spot rate limits
@@ -255,3 +283,19 @@ func orderbookLimit(depth int) request.EndpointLimit {
 
 	return spotOrderbookDepth5000Rate
 }
+
+// rateLimitBuckets returns the usage headers the adaptive rate limiter
+// tightens on. Each API host has its own budget, SAPI is budgeted apart from
+// the spot API on the same host and order counts only cover order endpoints.
+func rateLimitBuckets() []ratelimit.Bucket {
+	return []ratelimit.Bucket{
+		{Header: usedWeightHeader, Window: spotInterval, Cap: spotRequestRate, Host: spotAPIHost, Paths: []string{spotAPIPath}},
+		{Header: sapiUsedWeightHeader, Window: sapiInterval, Cap: sapiRequestRate, Host: spotAPIHost, Paths: []string{sapiPath}},
+		{Header: orderCount10sHeader, Window: spotOrderInterval, Cap: spotOrderRequestRate, Host: spotAPIHost, Paths: []string{spotOrderPath}},
+		{Header: orderCount1dHeader, Window: spotOrderDailyInterval, Cap: spotOrderDailyRequestRate, Host: spotAPIHost, Paths: []string{spotOrderPath}},
+		{Header: usedWeightHeader, Window: uFuturesInterval, Cap: uFuturesRequestRate, Host: uFuturesAPIHost},
+		{Header: orderCount1mHeader, Window: uFuturesOrderInterval, Cap: uFuturesOrderRequestRate, Host: uFuturesAPIHost, Paths: []string{uFuturesOrderPath, uFuturesBatchPath}},
+		{Header: usedWeightHeader, Window: cFuturesInterval, Cap: cFuturesRequestRate, Host: cFuturesAPIHost},
+		{Header: orderCount1mHeader, Window: cFuturesOrderInterval, Cap: cFuturesOrderRequestRate, Host: cFuturesAPIHost, Paths: []string{cFuturesOrderPath, cFuturesBatchPath}},
+	}
+}
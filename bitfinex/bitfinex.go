@@ -15,6 +15,7 @@ import (
 
 	exchange "github.com/openware/irix"
 	"github.com/openware/irix/portfolio/withdraw"
+	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/common"
 	"github.com/openware/pkg/common/crypto"
 	"github.com/openware/pkg/currency"
@@ -60,6 +61,7 @@ const (
 	bitfinexLendbook           = "lendbook/"
 	bitfinexLends              = "lends/"
 	bitfinexLeaderboard        = "rankings"
+	bitfinexSymbolsDetails     = "symbols_details"
 
 	// Version 2 API endpoints
 	bitfinexAPIVersion2     = "/v2/"
@@ -67,6 +69,9 @@ const (
 	bitfinexV2Balances      = "auth/r/wallets"
 	bitfinexV2AccountInfo   = "auth/r/info/user"
 	bitfinexV2FundingInfo   = "auth/r/info/funding/%s"
+	bitfinexV2OrderSubmit   = "auth/w/order/submit"
+	bitfinexV2Orders        = "auth/r/orders"
+	bitfinexV2OrderHistory  = "auth/r/orders/hist"
 	bitfinexDerivativeData  = "status/deriv?"
 	bitfinexPlatformStatus  = "platform/status"
 	bitfinexTickerBatch     = "tickers"
@@ -86,8 +91,19 @@ const (
 	bitfinexMaintenanceMode = 0
 	bitfinexOperativeMode   = 1
 
+	// bitfinexMaxClientOrderID bounds cids to the 45 bits accepted
+	bitfinexMaxClientOrderID = 1 << 45
+	// bitfinexOrderHistoryLimit is how many closed orders are searched for a
+	// client order ID
+	bitfinexOrderHistoryLimit = 500
+	// bitfinexAmountDecimalPlaces is the precision of order amounts
+	bitfinexAmountDecimalPlaces = 8
+
 	bitfinexChecksumFlag   = 131072
 	bitfinexWsSequenceFlag = 65536
+
+	// Bitfinex allows 25 public channels on a single connection
+	bitfinexWsChannelsPerConnection = 25
 )
 
 // Bitfinex is the overarching type across the bitfinex package
@@ -622,6 +638,49 @@ func (b *Bitfinex) GetStats(symbol string) ([]Stat, error) {
 	return response, b.SendHTTPRequest(exchange.RestSpot, path, &response, statsV1)
 }
 
+// GetSymbolsDetails returns the order size limits of all trading pairs
+func (b *Bitfinex) GetSymbolsDetails() ([]SymbolDetails, error) {
+	var response []SymbolDetails
+	path := bitfinexAPIVersion + bitfinexSymbolsDetails
+	return response, b.SendHTTPRequest(exchange.RestSpot, path, &response, configs)
+}
+
+// FetchExchangeLimits fetches spot order execution limits. Prices are quoted
+// to significant figures rather than to a tick, so no price step is set.
+func (b *Bitfinex) FetchExchangeLimits() ([]order.MinMaxLevel, error) {
+	details, err := b.GetSymbolsDetails()
+	if err != nil {
+		return nil, err
+	}
+	limits := make([]order.MinMaxLevel, 0, len(details))
+	for x := range details {
+		var cp currency.Pair
+		cp, err = symbolDetailsPair(details[x].Pair)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, order.MinMaxLevel{
+			Pair:       cp,
+			Asset:      asset.Spot,
+			MinAmount:  details[x].MinimumOrderSize,
+			MaxAmount:  details[x].MaximumOrderSize,
+			StepAmount: exchange.StepFromDecimalPlaces(bitfinexAmountDecimalPlaces),
+		})
+	}
+	return limits, nil
+}
+
+// symbolDetailsPair parses a version 1 pair such as btcusd or tesbtc:testusd
+func symbolDetailsPair(symbol string) (currency.Pair, error) {
+	if i := strings.Index(symbol, ":"); i > 0 {
+		return currency.NewPairFromStrings(symbol[:i], symbol[i+1:])
+	}
+	if len(symbol) != 6 {
+		return currency.Pair{}, fmt.Errorf("%w %s", errUnknownPairFormat, symbol)
+	}
+	return currency.NewPairFromStrings(symbol[:3], symbol[3:])
+}
+
 // GetFundingBook the entire margin funding book for both bids and asks sides
 // per currency string
 // symbol - example "USD"
@@ -1046,6 +1105,114 @@ func (b *Bitfinex) NewOrder(currencyPair, orderType string, amount, price float6
 		orderV1)
 }
 
+// NewOrderV2 submits a new order carrying a client order ID, the amount is
+// negative when selling
+func (b *Bitfinex) NewOrderV2(symbol, orderType string, amount, price float64, clientID int64) (OrderV2, error) {
+	req := make(map[string]interface{})
+	req["symbol"] = symbol
+	req["type"] = orderType
+	req["amount"] = strconv.FormatFloat(amount, 'f', -1, 64)
+	req["price"] = strconv.FormatFloat(price, 'f', -1, 64)
+	req["cid"] = clientID
+	var resp []interface{}
+	err := b.SendAuthenticatedHTTPRequestV2(exchange.RestSpot, http.MethodPost,
+		bitfinexV2OrderSubmit,
+		req,
+		&resp,
+		orderV1)
+	if err != nil {
+		return OrderV2{}, err
+	}
+	// [MTS, TYPE, MESSAGE_ID, null, [ORDER], CODE, STATUS, TEXT]
+	if len(resp) < 8 {
+		return OrderV2{}, errors.New("unexpected order submission response, check for api updates")
+	}
+	if status, _ := resp[6].(string); status != "SUCCESS" {
+		return OrderV2{}, fmt.Errorf("order submission %v: %v", resp[6], resp[7])
+	}
+	orders, ok := resp[4].([]interface{})
+	if !ok || len(orders) == 0 {
+		return OrderV2{}, errors.New("type assertion failed for order, check for api updates")
+	}
+	data, ok := orders[0].([]interface{})
+	if !ok {
+		return OrderV2{}, errors.New("type assertion failed for order, check for api updates")
+	}
+	return parseOrderV2(data)
+}
+
+// GetOrdersV2 returns active orders with their client order IDs
+func (b *Bitfinex) GetOrdersV2() ([]OrderV2, error) {
+	return b.getOrdersV2(bitfinexV2Orders, nil)
+}
+
+// GetOrderHistoryV2 returns recently closed orders with their client order
+// IDs
+func (b *Bitfinex) GetOrderHistoryV2(limit int64) ([]OrderV2, error) {
+	req := make(map[string]interface{})
+	if limit > 0 {
+		req["limit"] = limit
+	}
+	return b.getOrdersV2(bitfinexV2OrderHistory, req)
+}
+
+// getOrdersV2 returns the orders of a version 2 order endpoint
+func (b *Bitfinex) getOrdersV2(path string, req map[string]interface{}) ([]OrderV2, error) {
+	var data [][]interface{}
+	err := b.SendAuthenticatedHTTPRequestV2(exchange.RestSpot, http.MethodPost,
+		path,
+		req,
+		&data,
+		orderMulti)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]OrderV2, len(data))
+	for i := range data {
+		if resp[i], err = parseOrderV2(data[i]); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// parseOrderV2 parses a version 2 order array
+func parseOrderV2(data []interface{}) (OrderV2, error) {
+	// [ID, GID, CID, SYMBOL, MTS_CREATE, MTS_UPDATE, AMOUNT, AMOUNT_ORIG,
+	// TYPE, TYPE_PREV, MTS_TIF, _, FLAGS, STATUS, _, _, PRICE, PRICE_AVG, ...]
+	if len(data) < 18 {
+		return OrderV2{}, errors.New("unexpected order length, check for api updates")
+	}
+	var o OrderV2
+	id, ok := data[0].(float64)
+	if !ok {
+		return o, errors.New("type assertion failed for order ID, check for api updates")
+	}
+	o.ID = int64(id)
+	if gid, ok := data[1].(float64); ok {
+		o.GroupID = int64(gid)
+	}
+	if cid, ok := data[2].(float64); ok {
+		o.ClientID = int64(cid)
+	}
+	if o.Symbol, ok = data[3].(string); !ok {
+		return o, errors.New("type assertion failed for symbol, check for api updates")
+	}
+	if created, ok := data[4].(float64); ok {
+		o.Created = time.Unix(0, int64(created)*int64(time.Millisecond))
+	}
+	if updated, ok := data[5].(float64); ok {
+		o.Updated = time.Unix(0, int64(updated)*int64(time.Millisecond))
+	}
+	o.Amount, _ = data[6].(float64)
+	o.OriginalAmount, _ = data[7].(float64)
+	o.Type, _ = data[8].(string)
+	o.Status, _ = data[13].(string)
+	o.Price, _ = data[16].(float64)
+	o.AveragePrice, _ = data[17].(float64)
+	return o, nil
+}
+
 // NewOrderMulti allows several new orders at once
 func (b *Bitfinex) NewOrderMulti(orders []PlaceOrder) (OrderMultiResponse, error) {
 	response := OrderMultiResponse{}
@@ -1400,7 +1567,11 @@ func (b *Bitfinex) SendAuthenticatedHTTPRequest(ep exchange.URL, method, path st
 		return err
 	}
 
-	n := b.Requester.GetNonce(true)
+	n, release, err := b.NextNonce()
+	if err != nil {
+		return err
+	}
+	defer release()
 
 	req := make(map[string]interface{})
 	req["request"] = bitfinexAPIVersion + path
@@ -1461,8 +1632,13 @@ func (b *Bitfinex) SendAuthenticatedHTTPRequestV2(ep exchange.URL, method, path
 		body = bytes.NewBuffer(payload)
 	}
 
-	// This is done in a weird way because bitfinex doesn't accept unixnano
-	n := strconv.FormatInt(int64(b.Requester.GetNonce(false))*1e9, 10)
+	// Version 1 and 2 share the nonce sequence of the API key
+	nonce, release, err := b.NextNonce()
+	if err != nil {
+		return err
+	}
+	defer release()
+	n := nonce.String()
 	headers := make(map[string]string)
 	headers["Content-Type"] = "application/json"
 	headers["Accept"] = "application/json"
@@ -1,6 +1,8 @@
 package bitfinex
 
 import (
+	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"os"
@@ -1211,6 +1213,39 @@ func TestWsTradingPairSnapshot(t *testing.T) {
 	}
 }
 
+func TestWsRawBook(t *testing.T) {
+	b.WebsocketSubdChannels[23406] = WebsocketChanInfo{Pair: "tETHUSD", Channel: wsBook}
+	for _, msg := range []string{
+		`[23406,[[1,100,1],[2,100,2],[3,99,1],[4,101,-1.5]],1]`,
+		// A partial fill keeps the order's queue position
+		`[23406,[1,100,0.5],2]`,
+		`[23406,[3,0,1],3]`,
+		`[23406,[5,101,-1],4]`,
+	} {
+		if err := b.wsHandleData([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p, err := currency.NewPairFromString("ETHUSD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bids, asks, err := b.Websocket.Orderbook.GetL3Orders(p, asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bids) != 2 || bids[0].ID != "1" || bids[0].Amount != 0.5 || len(asks) != 2 || asks[1].ID != "5" {
+		t.Errorf("unexpected orders %+v %+v", bids, asks)
+	}
+	book, err := b.Websocket.Orderbook.GetOrderbook(p, asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(book.Bids) != 1 || book.Bids[0].Amount != 2.5 || len(book.Asks) != 1 || book.Asks[0].Amount != 2.5 {
+		t.Errorf("unexpected price levels %+v %+v", book.Bids, book.Asks)
+	}
+}
+
 func TestWsTradeResponse(t *testing.T) {
 	b.WebsocketSubdChannels[18788] = WebsocketChanInfo{Pair: "BTCUSD", Channel: wsTrades}
 	pressXToJSON := `[18788,[[412685577,1580268444802,11.1998,176.3],[412685575,1580268444802,5,176.29952759],[412685574,1580268374717,1.99069999,176.41],[412685573,1580268374717,1.00930001,176.41],[412685572,1580268358760,0.9907,176.47],[412685571,1580268324362,0.5505,176.44],[412685570,1580268297270,-0.39040819,176.39],[412685568,1580268297270,-0.39780162,176.46475676],[412685567,1580268283470,-0.09,176.41],[412685566,1580268256536,-2.31310783,176.48],[412685565,1580268256536,-0.59669217,176.49],[412685564,1580268256536,-0.9902,176.49],[412685562,1580268194474,0.9902,176.55],[412685561,1580268186215,0.1,176.6],[412685560,1580268185964,-2.17096773,176.5],[412685559,1580268185964,-1.82903227,176.51],[412685558,1580268181215,2.098914,176.53],[412685557,1580268169844,16.7302,176.55],[412685556,1580268169844,3.25,176.54],[412685555,1580268155725,0.23576115,176.45],[412685553,1580268155725,3,176.44596249],[412685552,1580268155725,3.25,176.44],[412685551,1580268155725,5,176.44],[412685550,1580268155725,0.65830078,176.41],[412685549,1580268155725,0.45063807,176.41],[412685548,1580268153825,-0.67604704,176.39],[412685547,1580268145713,2.5883,176.41],[412685543,1580268087513,12.92927,176.33],[412685542,1580268087513,0.40083,176.33],[412685533,1580268005756,-0.17096773,176.32]]]`
@@ -1558,10 +1593,13 @@ var testOb = orderbook.Base{
 }
 
 func TestChecksum(t *testing.T) {
-	err := validateCRC32(&testOb, 190468240)
+	checksum, err := wsChecksum{}.Checksum(testOb.Bids, testOb.Asks)
 	if err != nil {
 		t.Fatal(err)
 	}
+	if checksum != 190468240 {
+		t.Errorf("expected checksum [190468240], received [%d]", checksum)
+	}
 }
 
 func TestReOrderbyID(t *testing.T) {
@@ -1605,3 +1643,62 @@ func TestReOrderbyID(t *testing.T) {
 		}
 	}
 }
+
+func TestParseOrderV2(t *testing.T) {
+	t.Parallel()
+	var data []interface{}
+	err := json.Unmarshal([]byte(`[1747566428,null,1678988263842,"tBTCUSD",1678988263843,1678988263850,-0.5,-1,"EXCHANGE LIMIT",null,null,null,0,"PARTIALLY FILLED @ 23000(-0.5)",null,null,23000,23000,0,0,null,null,null,0,0,null,null,null,"API>BFX",null,null,null]`), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := parseOrderV2(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.ID != 1747566428 || o.ClientID != 1678988263842 || o.Symbol != "tBTCUSD" ||
+		o.Amount != -0.5 || o.OriginalAmount != -1 || o.Type != "EXCHANGE LIMIT" || o.Price != 23000 {
+		t.Errorf("unexpected order %+v", o)
+	}
+	if s := orderV2Status(o.Status); s != order.PartiallyFilled {
+		t.Errorf("expected %s, received %s", order.PartiallyFilled, s)
+	}
+	if s := orderV2Status("CANCELED was: PARTIALLY FILLED @ 23000(-0.5)"); s != order.PartiallyCancelled {
+		t.Errorf("expected %s, received %s", order.PartiallyCancelled, s)
+	}
+	if _, err = parseOrderV2(data[:10]); err == nil {
+		t.Error("expected error on short order")
+	}
+}
+
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	if err := b.UpdateOrderExecutionLimits(asset.Spot); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.GetOrderExecutionLimits(asset.Spot, currency.NewPair(currency.BTC, currency.USD)); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.UpdateOrderExecutionLimits(asset.Margin); !errors.Is(err, asset.ErrNotSupported) {
+		t.Fatalf("received: %v but expected: %v", err, asset.ErrNotSupported)
+	}
+}
+
+func TestSymbolDetailsPair(t *testing.T) {
+	t.Parallel()
+	cp, err := symbolDetailsPair("btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cp.Equal(currency.NewPair(currency.BTC, currency.USD)) {
+		t.Errorf("received: %v but expected: %v", cp, "BTCUSD")
+	}
+	if cp, err = symbolDetailsPair("tesbtc:testusd"); err != nil {
+		t.Fatal(err)
+	}
+	if !cp.Base.Match(currency.NewCode("TESBTC")) || !cp.Quote.Match(currency.NewCode("TESTUSD")) {
+		t.Errorf("received: %v but expected: %v", cp, "TESBTC:TESTUSD")
+	}
+	if _, err = symbolDetailsPair("btcusdt"); !errors.Is(err, errUnknownPairFormat) {
+		t.Fatalf("received: %v but expected: %v", err, errUnknownPairFormat)
+	}
+}
@@ -1,6 +1,7 @@
 package bitfinex
 
 import (
+	"errors"
 	"time"
 
 	"github.com/openware/pkg/order"
@@ -24,6 +25,11 @@ type WalletDataV2 struct {
 	UnsettledInterest float64
 }
 
+var (
+	errClientOrderIDNotNumeric = errors.New("client order ID must be an integer")
+	errUnknownPairFormat       = errors.New("unknown pair format")
+)
+
 // AcceptedOrderType defines the accepted market types, exchange strings denote non-contract order types.
 var AcceptedOrderType = []string{"market", "limit", "stop", "trailing-stop",
 	"fill-or-kill", "exchange market", "exchange limit", "exchange stop",
@@ -321,6 +327,22 @@ type Order struct {
 	OrderID               int64   `json:"order_id,omitempty"`
 }
 
+// OrderV2 holds a version 2 order, amounts are negative when selling
+type OrderV2 struct {
+	ID             int64
+	GroupID        int64
+	ClientID       int64
+	Symbol         string
+	Created        time.Time
+	Updated        time.Time
+	Amount         float64
+	OriginalAmount float64
+	Type           string
+	Status         string
+	Price          float64
+	AveragePrice   float64
+}
+
 // OrderMultiResponse holds order information on the executed orders
 type OrderMultiResponse struct {
 	Orders []Order `json:"order_ids"`
@@ -785,3 +807,9 @@ type WsCancelOfferRequest struct {
 type WsCancelAllOrdersRequest struct {
 	All int64 `json:"all"`
 }
+
+// wsChecksum verifies the checksums sent on book channels, funding books sign
+// their amounts the other way around
+type wsChecksum struct {
+	funding bool
+}
@@ -4,12 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"hash/crc32"
 	"net/http"
 	"sort"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -29,15 +27,6 @@ import (
 
 var comms = make(chan stream.Response)
 
-type checksum struct {
-	Token    int
-	Sequence int64
-}
-
-// checksumStore quick global for now
-var checksumStore = make(map[int]*checksum)
-var cMtx sync.Mutex
-
 // WsConnect starts a new websocket connection
 func (b *Bitfinex) WsConnect() error {
 	if !b.Websocket.IsEnabled() || !b.IsEnabled() {
@@ -78,6 +67,20 @@ func (b *Bitfinex) WsConnect() error {
 	return nil
 }
 
+// wsConnectShard connects an additional public connection once the primary
+// carries bitfinexWsChannelsPerConnection channels
+func (b *Bitfinex) wsConnectShard(conn stream.Connection) error {
+	var dialer websocket.Dialer
+	err := conn.Dial(&dialer, http.Header{})
+	if err != nil {
+		return fmt.Errorf("%v unable to connect to Websocket. Error: %s",
+			b.Name,
+			err)
+	}
+	go b.wsReadData(conn)
+	return nil
+}
+
 // wsReadData receives and passes on websocket messages for processing
 func (b *Bitfinex) wsReadData(ws stream.Connection) {
 	b.Websocket.Wg.Add(1)
@@ -164,28 +167,6 @@ func (b *Bitfinex) wsHandleData(respRaw []byte) error {
 			if datum == "hb" {
 				return nil
 			}
-
-			// Capturing checksum and storing value
-			if datum == "cs" {
-				var tokenF float64
-				tokenF, ok = d[2].(float64)
-				if !ok {
-					return errors.New("checksum token type assertion failure")
-				}
-				var seqNoF float64
-				seqNoF, ok = d[3].(float64)
-				if !ok {
-					return errors.New("sequence number type assertion failure")
-				}
-
-				cMtx.Lock()
-				checksumStore[chanID] = &checksum{
-					Token:    int(tokenF),
-					Sequence: int64(seqNoF),
-				}
-				cMtx.Unlock()
-				return nil
-			}
 		}
 
 		chanInfo, ok := b.WebsocketSubdChannels[chanID]
@@ -229,6 +210,18 @@ func (b *Bitfinex) wsHandleData(respRaw []byte) error {
 			}
 		}
 
+		// The checksum covers the book as it stands after the preceding update
+		if datum == "cs" {
+			tokenF, ok := d[2].(float64)
+			if !ok {
+				return errors.New("checksum token type assertion failure")
+			}
+			return b.Websocket.Orderbook.VerifyChecksum(pair,
+				chanAsset,
+				uint32(int32(tokenF)),
+				wsChecksum{funding: chanAsset == asset.MarginFunding})
+		}
+
 		switch chanInfo.Channel {
 		case wsBook:
 			var newOrderbook []WebsocketBook
@@ -240,11 +233,6 @@ func (b *Bitfinex) wsHandleData(respRaw []byte) error {
 				return errors.New("no data within orderbook snapshot")
 			}
 
-			sequenceNo, ok := d[2].(float64)
-			if !ok {
-				return errors.New("type assertion failure")
-			}
-
 			var fundingRate bool
 			switch id := obSnapBundle[0].(type) {
 			case []interface{}:
@@ -313,7 +301,7 @@ func (b *Bitfinex) wsHandleData(respRaw []byte) error {
 						Amount: amountRate})
 				}
 
-				err := b.WsUpdateOrderbook(pair, chanAsset, newOrderbook, chanID, int64(sequenceNo), fundingRate)
+				err := b.WsUpdateOrderbook(pair, chanAsset, newOrderbook, fundingRate)
 				if err != nil {
 					return fmt.Errorf("bitfinex_websocket.go updating orderbook error: %s",
 						err)
@@ -932,11 +920,35 @@ func (b *Bitfinex) wsHandleOrder(data []interface{}) {
 }
 
 // WsInsertSnapshot add the initial orderbook snapshot when subscribed to a
-// channel
+// channel. Raw trading pair books are kept order by order, funding books by
+// offer ID.
 func (b *Bitfinex) WsInsertSnapshot(p currency.Pair, assetType asset.Item, books []WebsocketBook, fundingRate bool) error {
 	if len(books) == 0 {
 		return errors.New("bitfinex.go error - no orderbooks submitted")
 	}
+	if !fundingRate {
+		snapshot := buffer.L3Snapshot{
+			Exchange:        b.Name,
+			Pair:            p,
+			Asset:           assetType,
+			VerifyOrderbook: b.CanVerifyOrderbook,
+		}
+		for i := range books {
+			o := buffer.L3Order{
+				ID:     strconv.FormatInt(books[i].ID, 10),
+				Price:  books[i].Price,
+				Amount: books[i].Amount,
+			}
+			if o.Amount > 0 {
+				snapshot.Bids = append(snapshot.Bids, o)
+			} else {
+				o.Amount *= -1
+				snapshot.Asks = append(snapshot.Asks, o)
+			}
+		}
+		return b.Websocket.Orderbook.LoadL3Snapshot(&snapshot)
+	}
+
 	var book orderbook.Base
 	for i := range books {
 		item := orderbook.Item{
@@ -945,20 +957,11 @@ func (b *Bitfinex) WsInsertSnapshot(p currency.Pair, assetType asset.Item, books
 			Price:  books[i].Price,
 			Period: books[i].Period,
 		}
-		if fundingRate {
-			if item.Amount < 0 {
-				item.Amount *= -1
-				book.Bids = append(book.Bids, item)
-			} else {
-				book.Asks = append(book.Asks, item)
-			}
+		if item.Amount < 0 {
+			item.Amount *= -1
+			book.Bids = append(book.Bids, item)
 		} else {
-			if books[i].Amount > 0 {
-				book.Bids = append(book.Bids, item)
-			} else {
-				item.Amount *= -1
-				book.Asks = append(book.Asks, item)
-			}
+			book.Asks = append(book.Asks, item)
 		}
 	}
 
@@ -973,9 +976,34 @@ func (b *Bitfinex) WsInsertSnapshot(p currency.Pair, assetType asset.Item, books
 
 // WsUpdateOrderbook updates the orderbook list, removing and adding to the
 // orderbook sides
-func (b *Bitfinex) WsUpdateOrderbook(p currency.Pair, assetType asset.Item, book []WebsocketBook, channelID int, sequenceNo int64, fundingRate bool) error {
-	orderbookUpdate := buffer.Update{Asset: assetType, Pair: p}
+func (b *Bitfinex) WsUpdateOrderbook(p currency.Pair, assetType asset.Item, book []WebsocketBook, fundingRate bool) error {
+	if !fundingRate {
+		for i := range book {
+			u := buffer.L3Update{
+				Action:  buffer.L3Done,
+				OrderID: strconv.FormatInt(book[i].ID, 10),
+				Pair:    p,
+				Asset:   assetType,
+			}
+			if book[i].Price > 0 {
+				u.Action = buffer.L3Open
+				u.Price = book[i].Price
+				u.Amount = book[i].Amount
+				u.Side = order.Bid
+				if u.Amount < 0 {
+					u.Amount *= -1
+					u.Side = order.Ask
+				}
+			}
+			err := b.Websocket.Orderbook.UpdateL3(&u)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 
+	orderbookUpdate := buffer.Update{Asset: assetType, Pair: p}
 	for i := range book {
 		item := orderbook.Item{
 			ID:     book[i].ID,
@@ -986,69 +1014,23 @@ func (b *Bitfinex) WsUpdateOrderbook(p currency.Pair, assetType asset.Item, book
 
 		if book[i].Price > 0 {
 			orderbookUpdate.Action = buffer.UpdateInsert
-			if fundingRate {
-				if book[i].Amount < 0 {
-					item.Amount *= -1
-					orderbookUpdate.Bids = append(orderbookUpdate.Bids, item)
-				} else {
-					orderbookUpdate.Asks = append(orderbookUpdate.Asks, item)
-				}
+			if book[i].Amount < 0 {
+				item.Amount *= -1
+				orderbookUpdate.Bids = append(orderbookUpdate.Bids, item)
 			} else {
-				if book[i].Amount > 0 {
-					orderbookUpdate.Bids = append(orderbookUpdate.Bids, item)
-				} else {
-					item.Amount *= -1
-					orderbookUpdate.Asks = append(orderbookUpdate.Asks, item)
-				}
+				orderbookUpdate.Asks = append(orderbookUpdate.Asks, item)
 			}
 		} else {
 			orderbookUpdate.Action = buffer.Delete
-			if fundingRate {
-				if book[i].Amount == 1 {
-					// delete bid
-					orderbookUpdate.Asks = append(orderbookUpdate.Asks, item)
-				} else {
-					// delete ask
-					orderbookUpdate.Bids = append(orderbookUpdate.Bids, item)
-				}
+			if book[i].Amount == 1 {
+				// delete bid
+				orderbookUpdate.Asks = append(orderbookUpdate.Asks, item)
 			} else {
-				if book[i].Amount == 1 {
-					// delete bid
-					orderbookUpdate.Bids = append(orderbookUpdate.Bids, item)
-				} else {
-					// delete ask
-					orderbookUpdate.Asks = append(orderbookUpdate.Asks, item)
-				}
+				// delete ask
+				orderbookUpdate.Bids = append(orderbookUpdate.Bids, item)
 			}
 		}
 	}
-
-	cMtx.Lock()
-	checkme := checksumStore[channelID]
-	if checkme == nil {
-		cMtx.Unlock()
-		return b.Websocket.Orderbook.Update(&orderbookUpdate)
-	}
-	checksumStore[channelID] = nil
-	cMtx.Unlock()
-
-	if checkme.Sequence+1 == sequenceNo {
-		// Sequence numbers get dropped, if checksum is not in line with
-		// sequence, do not check.
-		ob, err := b.Websocket.Orderbook.GetOrderbook(p, assetType)
-		if err != nil {
-			return fmt.Errorf("cannot calculate websocket checksum: book not found for %s %s %w",
-				p,
-				assetType,
-				err)
-		}
-
-		err = validateCRC32(ob, checkme.Token)
-		if err != nil {
-			return err
-		}
-	}
-
 	return b.Websocket.Orderbook.Update(&orderbookUpdate)
 }
 
@@ -1105,11 +1087,17 @@ func (b *Bitfinex) GenerateDefaultSubscriptions() ([]stream.ChannelSubscription,
 
 // Subscribe sends a websocket message to receive data from the channel
 func (b *Bitfinex) Subscribe(channelsToSubscribe []stream.ChannelSubscription) error {
+	return b.subscribeOn(b.Websocket.Conn, channelsToSubscribe)
+}
+
+// subscribeOn subscribes to channels on conn, enabling checksums and
+// sequence numbers for the connection first
+func (b *Bitfinex) subscribeOn(conn stream.Connection, channelsToSubscribe []stream.ChannelSubscription) error {
 	var errs common.Errors
 	checksum := make(map[string]interface{})
 	checksum["event"] = "conf"
 	checksum["flags"] = bitfinexChecksumFlag + bitfinexWsSequenceFlag
-	err := b.Websocket.Conn.SendJSONMessage(checksum)
+	err := conn.SendJSONMessage(checksum)
 	if err != nil {
 		return err
 	}
@@ -1123,7 +1111,7 @@ func (b *Bitfinex) Subscribe(channelsToSubscribe []stream.ChannelSubscription) e
 			req[k] = v
 		}
 
-		err := b.Websocket.Conn.SendJSONMessage(req)
+		err := conn.SendJSONMessage(req)
 		if err != nil {
 			errs = append(errs, err)
 			continue
@@ -1138,6 +1126,11 @@ func (b *Bitfinex) Subscribe(channelsToSubscribe []stream.ChannelSubscription) e
 
 // Unsubscribe sends a websocket message to stop receiving data from the channel
 func (b *Bitfinex) Unsubscribe(channelsToUnsubscribe []stream.ChannelSubscription) error {
+	return b.unsubscribeOn(b.Websocket.Conn, channelsToUnsubscribe)
+}
+
+// unsubscribeOn unsubscribes from channels on conn
+func (b *Bitfinex) unsubscribeOn(conn stream.Connection, channelsToUnsubscribe []stream.ChannelSubscription) error {
 	var errs common.Errors
 	for i := range channelsToUnsubscribe {
 		req := make(map[string]interface{})
@@ -1148,7 +1141,7 @@ func (b *Bitfinex) Unsubscribe(channelsToUnsubscribe []stream.ChannelSubscriptio
 			req[k] = v
 		}
 
-		err := b.Websocket.Conn.SendJSONMessage(req)
+		err := conn.SendJSONMessage(req)
 		if err != nil {
 			errs = append(errs, err)
 			continue
@@ -1205,7 +1198,9 @@ func (b *Bitfinex) WsAddSubscriptionChannel(chanID int, channel, pair string) {
 
 // WsNewOrder authenticated new order request
 func (b *Bitfinex) WsNewOrder(data *WsNewOrderRequest) (string, error) {
-	data.CustomID = b.Websocket.AuthConn.GenerateMessageID(false)
+	if data.CustomID == 0 {
+		data.CustomID = b.Websocket.AuthConn.GenerateMessageID(false)
+	}
 	request := makeRequestInterface(wsOrderNew, data)
 	resp, err := b.Websocket.AuthConn.SendMessageReturnResponse(data.CustomID, request)
 	if err != nil {
@@ -1346,62 +1341,61 @@ func makeRequestInterface(channelName string, data interface{}) []interface{} {
 	return []interface{}{0, channelName, nil, data}
 }
 
-func validateCRC32(book *orderbook.Base, token int) error {
-	// Order ID's need to be sub-sorted in ascending order, this needs to be
-	// done on the main book to ensure that we do not cut price levels out below
-	reOrderByID(book.Bids)
-	reOrderByID(book.Asks)
-
-	// RO precision calculation is based on order ID's and amount values
-	var bids, asks []orderbook.Item
-	for i := 0; i < 25; i++ {
-		if i < len(book.Bids) {
-			bids = append(bids, book.Bids[i])
-		}
-		if i < len(book.Asks) {
-			asks = append(asks, book.Asks[i])
-		}
-	}
-
-	// ensure '-' (negative amount) is passed back to string buffer as
-	// this is needed for calcs - These get swapped if funding rate
-	bidmod := float64(1)
-	if book.IsFundingRate {
-		bidmod = -1
-	}
+// isOrderbookChannel reports whether a subscription carries orderbook data
+func isOrderbookChannel(c *stream.ChannelSubscription) bool {
+	return c.Channel == wsBook
+}
 
-	askMod := float64(-1)
-	if book.IsFundingRate {
-		askMod = 1
+// newChecksum returns the checksum Bitfinex sends for the top 25 orders of
+// each side, taken by order ID and signed amount. Amounts are signed as asks
+// negative for trading books and bids negative for funding books.
+func newChecksum(fundingRate bool) *buffer.CRC32 {
+	bidMod, askMod := float64(1), float64(-1)
+	if fundingRate {
+		bidMod, askMod = -1, 1
+	}
+	return &buffer.CRC32{
+		Depth:     25,
+		Separator: ":",
+		Format: func(item orderbook.Item, bid bool) string {
+			mod := askMod
+			if bid {
+				mod = bidMod
+			}
+			return strconv.FormatInt(item.ID, 10) + ":" +
+				strconv.FormatFloat(mod*item.Amount, 'f', -1, 64)
+		},
 	}
+}
 
-	var check strings.Builder
-	for i := 0; i < 25; i++ {
-		if i < len(bids) {
-			check.WriteString(strconv.FormatInt(bids[i].ID, 10))
-			check.WriteString(":")
-			check.WriteString(strconv.FormatFloat(bidmod*bids[i].Amount, 'f', -1, 64))
-			check.WriteString(":")
-		}
+// Checksum implements buffer.Checksummer for funding books keyed by offer ID
+func (c wsChecksum) Checksum(bids, asks orderbook.Items) (uint32, error) {
+	// Offer IDs are sub-sorted in ascending order within a price level,
+	// copies are sorted so the book itself is left as is
+	bids = append(bids[:0:0], bids...)
+	asks = append(asks[:0:0], asks...)
+	reOrderByID(bids)
+	reOrderByID(asks)
+	return newChecksum(c.funding).Checksum(bids, asks)
+}
 
-		if i < len(asks) {
-			check.WriteString(strconv.FormatInt(asks[i].ID, 10))
-			check.WriteString(":")
-			check.WriteString(strconv.FormatFloat(askMod*asks[i].Amount, 'f', -1, 64))
-			check.WriteString(":")
+// ChecksumL3 implements buffer.L3Checksummer for raw trading books
+func (c wsChecksum) ChecksumL3(bids, asks []buffer.L3Order) (uint32, error) {
+	var items [2]orderbook.Items
+	for side, orders := range [][]buffer.L3Order{bids, asks} {
+		for i := range orders {
+			id, err := strconv.ParseInt(orders[i].ID, 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			items[side] = append(items[side], orderbook.Item{
+				ID:     id,
+				Price:  orders[i].Price,
+				Amount: orders[i].Amount,
+			})
 		}
 	}
-
-	checksumStr := strings.TrimSuffix(check.String(), ":")
-	checksum := crc32.ChecksumIEEE([]byte(checksumStr))
-	if checksum == uint32(token) {
-		return nil
-	}
-	return fmt.Errorf("invalid checksum for %s %s: calculated [%d] does not match [%d]",
-		book.Asset,
-		book.Pair,
-		checksum,
-		uint32(token))
+	return c.Checksum(items[0], items[1])
 }
 
 // reOrderByID sub sorts orderbook items by its corresponding ID when price
@@ -3,6 +3,7 @@ package bitfinex
 import (
 	"errors"
 	"fmt"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
@@ -206,9 +207,14 @@ func (b *Bitfinex) Setup(exch *config.ExchangeConfig) error {
 		UnSubscriber:                     b.Unsubscribe,
 		GenerateSubscriptions:            b.GenerateDefaultSubscriptions,
 		Features:                         &b.Features.Supports.WebsocketCapabilities,
+		OrderbookChannel:                 isOrderbookChannel,
 		OrderbookBufferLimit:             exch.OrderbookConfig.WebsocketBufferLimit,
 		BufferEnabled:                    exch.OrderbookConfig.WebsocketBufferEnabled,
 		UpdateEntriesByID:                true,
+		MaxSubscriptionsPerConnection:    bitfinexWsChannelsPerConnection,
+		ShardConnector:                   b.wsConnectShard,
+		ShardSubscriber:                  b.subscribeOn,
+		ShardUnsubscriber:                b.unsubscribeOn,
 	})
 	if err != nil {
 		return err
@@ -250,6 +256,20 @@ func (b *Bitfinex) Run() {
 		b.PrintEnabledPairs()
 	}
 
+	a := b.GetAssetTypes()
+	for x := range a {
+		if err := b.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
+			err = b.UpdateOrderExecutionLimits(a[x])
+			b.SetOrderExecutionLimitsLoadError(a[x], err)
+			if err != nil {
+				log.Errorf(log.ExchangeSys,
+					"Could not set %s exchange exchange limits: %v",
+					b.Name,
+					err)
+			}
+		}
+	}
+
 	if !b.GetEnabledFeatures().AutoPairUpdates {
 		return
 	}
@@ -599,6 +619,22 @@ func (b *Bitfinex) SubmitOrder(o *order.Submit) (order.SubmitResponse, error) {
 	if err != nil {
 		return submitOrderResponse, err
 	}
+	err = b.ConformOrderToLimits(o)
+	if err != nil {
+		return submitOrderResponse, err
+	}
+
+	err = exchange.AttachClientOrderID(b, o)
+	if err != nil {
+		return submitOrderResponse, err
+	}
+	cid, err := strconv.ParseInt(o.ClientOrderID, 10, 64)
+	if err != nil {
+		return submitOrderResponse, fmt.Errorf("%s %w, received %s",
+			b.Name,
+			errClientOrderIDNotNumeric,
+			o.ClientOrderID)
+	}
 
 	fpair, err := b.FormatExchangeCurrency(o.Pair, o.AssetType)
 	if err != nil {
@@ -607,7 +643,7 @@ func (b *Bitfinex) SubmitOrder(o *order.Submit) (order.SubmitResponse, error) {
 
 	if b.Websocket.CanUseAuthenticatedWebsocketForWrapper() {
 		submitOrderResponse.OrderID, err = b.WsNewOrder(&WsNewOrderRequest{
-			CustomID: b.Websocket.AuthConn.GenerateMessageID(false),
+			CustomID: cid,
 			Type:     o.Type.String(),
 			Symbol:   fpair.String(),
 			Amount:   o.Amount,
@@ -617,26 +653,28 @@ func (b *Bitfinex) SubmitOrder(o *order.Submit) (order.SubmitResponse, error) {
 			return submitOrderResponse, err
 		}
 	} else {
-		var response Order
-		isBuying := o.Side == order.Buy
+		var response OrderV2
+		amount := o.Amount
+		if o.Side == order.Sell || o.Side == order.Ask {
+			amount = -amount
+		}
 		b.appendOptionalDelimiter(&fpair)
-		orderType := o.Type.Lower()
+		orderType := strings.ToUpper(o.Type.String())
 		if o.AssetType == asset.Spot {
-			orderType = "exchange " + orderType
+			orderType = "EXCHANGE " + orderType
 		}
-		response, err = b.NewOrder(fpair.String(),
+		response, err = b.NewOrderV2("t"+fpair.String(),
 			orderType,
-			o.Amount,
+			amount,
 			o.Price,
-			isBuying,
-			false)
+			cid)
 		if err != nil {
 			return submitOrderResponse, err
 		}
 		if response.ID > 0 {
 			submitOrderResponse.OrderID = strconv.FormatInt(response.ID, 10)
 		}
-		if response.RemainingAmount == 0 {
+		if response.Amount == 0 {
 			submitOrderResponse.FullyMatched = true
 		}
 
@@ -645,6 +683,11 @@ func (b *Bitfinex) SubmitOrder(o *order.Submit) (order.SubmitResponse, error) {
 	return submitOrderResponse, err
 }
 
+// GenerateClientOrderID returns a client order ID which fits the cid field
+func (b *Bitfinex) GenerateClientOrderID() (string, error) {
+	return exchange.GenerateNumericClientOrderID(bitfinexMaxClientOrderID)
+}
+
 // ModifyOrder will allow of changing orderbook placement and limit to
 // market conversion
 func (b *Bitfinex) ModifyOrder(action *order.Modify) (string, error) {
@@ -710,6 +753,108 @@ func (b *Bitfinex) GetOrderInfo(orderID string, pair currency.Pair, assetType as
 	return orderDetail, common.ErrNotYetImplemented
 }
 
+// GetOrderInfoByClientOrderID returns order information based on the client
+// order ID supplied on submission. Active and recently closed orders are
+// searched as Bitfinex only keeps cids unique within a day.
+func (b *Bitfinex) GetOrderInfoByClientOrderID(clientOrderID string, pair currency.Pair, assetType asset.Item) (order.Detail, error) {
+	if clientOrderID == "" {
+		return order.Detail{}, order.ErrOrderIDNotSet
+	}
+	cid, err := strconv.ParseInt(clientOrderID, 10, 64)
+	if err != nil {
+		return order.Detail{}, fmt.Errorf("%s %w, received %s",
+			b.Name,
+			errClientOrderIDNotNumeric,
+			clientOrderID)
+	}
+	active, err := b.GetOrdersV2()
+	if err != nil {
+		return order.Detail{}, err
+	}
+	closed, err := b.GetOrderHistoryV2(bitfinexOrderHistoryLimit)
+	if err != nil {
+		return order.Detail{}, err
+	}
+	var matched []OrderV2
+	seen := make(map[int64]bool)
+	for _, o := range append(active, closed...) {
+		if o.ClientID == cid && !seen[o.ID] {
+			seen[o.ID] = true
+			matched = append(matched, o)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return order.Detail{}, fmt.Errorf("%s client order ID %s %w",
+			b.Name,
+			clientOrderID,
+			exchange.ErrOrderNotFound)
+	case 1:
+		return b.orderV2ToDetail(&matched[0])
+	default:
+		return order.Detail{}, fmt.Errorf("%s client order ID %s %w: %d orders carry it",
+			b.Name,
+			clientOrderID,
+			exchange.ErrOrderOutcomeUnknown,
+			len(matched))
+	}
+}
+
+// orderV2ToDetail converts a version 2 order to an order detail
+func (b *Bitfinex) orderV2ToDetail(o *OrderV2) (order.Detail, error) {
+	p, a, err := b.GetRequestFormattedPairAndAssetType(strings.TrimPrefix(o.Symbol, "t"))
+	if err != nil {
+		return order.Detail{}, err
+	}
+	side := order.Buy
+	if o.OriginalAmount < 0 {
+		side = order.Sell
+	}
+	oType, err := order.StringToOrderType(strings.TrimPrefix(o.Type, "EXCHANGE "))
+	if err != nil {
+		return order.Detail{}, err
+	}
+	amount, remaining := math.Abs(o.OriginalAmount), math.Abs(o.Amount)
+	return order.Detail{
+		Exchange:        b.Name,
+		ID:              strconv.FormatInt(o.ID, 10),
+		ClientOrderID:   strconv.FormatInt(o.ClientID, 10),
+		Pair:            p,
+		AssetType:       a,
+		Side:            side,
+		Type:            oType,
+		Status:          orderV2Status(o.Status),
+		Price:           o.Price,
+		Amount:          amount,
+		ExecutedAmount:  amount - remaining,
+		RemainingAmount: remaining,
+		Date:            o.Created,
+		LastUpdated:     o.Updated,
+	}, nil
+}
+
+// orderV2Status converts a version 2 order status such as
+// "PARTIALLY FILLED @ 100(0.5)" to an order status
+func orderV2Status(status string) order.Status {
+	switch {
+	case strings.HasPrefix(status, "ACTIVE"):
+		return order.Active
+	case strings.HasPrefix(status, "EXECUTED"):
+		return order.Filled
+	case strings.HasPrefix(status, "PARTIALLY FILLED"):
+		return order.PartiallyFilled
+	case strings.HasPrefix(status, "CANCELED") && strings.Contains(status, "PARTIALLY FILLED"):
+		return order.PartiallyCancelled
+	case strings.HasPrefix(status, "CANCELED"):
+		return order.Cancelled
+	case strings.HasPrefix(status, "INSUFFICIENT"):
+		return order.InsufficientBalance
+	case strings.HasPrefix(status, "RSN_"):
+		return order.Rejected
+	}
+	return order.UnknownStatus
+}
+
 // GetDepositAddress returns a deposit address for a specified currency
 func (b *Bitfinex) GetDepositAddress(c currency.Code, accountID string) (string, error) {
 	if accountID == "" {
@@ -951,6 +1096,18 @@ func (b *Bitfinex) appendOptionalDelimiter(p *currency.Pair) {
 	}
 }
 
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (b *Bitfinex) UpdateOrderExecutionLimits(a asset.Item) error {
+	if a != asset.Spot {
+		return fmt.Errorf("%s %w", a, asset.ErrNotSupported)
+	}
+	limits, err := b.FetchExchangeLimits()
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return b.LoadLimits(limits)
+}
+
 // ValidateCredentials validates current credentials used for wrapper
 // functionality
 func (b *Bitfinex) ValidateCredentials(assetType asset.Item) error {
@@ -478,7 +478,12 @@ func (b *Bithumb) SendAuthenticatedHTTPRequest(ep exchange.URL, path string, par
 		params = url.Values{}
 	}
 
-	n := b.Requester.GetNonceMilli().String()
+	nonce, release, err := b.NextNonce()
+	if err != nil {
+		return err
+	}
+	defer release()
+	n := nonce.String()
 
 	params.Set("endpoint", path)
 	payload := params.Encode()
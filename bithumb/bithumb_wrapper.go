@@ -11,6 +11,7 @@ import (
 
 	exchange "github.com/openware/irix"
 	"github.com/openware/irix/config"
+	"github.com/openware/irix/nonce"
 	"github.com/openware/irix/portfolio/withdraw"
 	"github.com/openware/irix/protocol"
 	"github.com/openware/irix/ticker"
@@ -56,6 +57,7 @@ func (b *Bithumb) SetDefaults() {
 	b.Verbose = true
 	b.API.CredentialsValidator.RequiresKey = true
 	b.API.CredentialsValidator.RequiresSecret = true
+	b.NonceFormat = nonce.Milliseconds
 
 	requestFmt := &currency.PairFormat{Uppercase: true, Delimiter: currency.UnderscoreDelimiter}
 	configFmt := &currency.PairFormat{Uppercase: true, Index: "KRW"}
@@ -394,6 +396,9 @@ func (b *Bithumb) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 	if err := s.Validate(); err != nil {
 		return submitOrderResponse, err
 	}
+	if err := b.ConformOrderToLimits(s); err != nil {
+		return submitOrderResponse, err
+	}
 
 	fPair, err := b.FormatExchangeCurrency(s.Pair, s.AssetType)
 	if err != nil {
@@ -13,8 +13,10 @@ import (
 	"time"
 
 	exchange "github.com/openware/irix"
+	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/common/crypto"
 	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
 	"github.com/openware/pkg/request"
 )
 
@@ -272,6 +274,45 @@ func (b *Bitmex) GetActiveAndIndexInstruments() ([]Instrument, error) {
 			&activeAndIndices)
 }
 
+// FetchExchangeLimits fetches the tick and lot sizes of every active
+// instrument for the supplied asset type
+func (b *Bitmex) FetchExchangeLimits(a asset.Item) ([]order.MinMaxLevel, error) {
+	if a != asset.PerpetualContract && a != asset.Futures {
+		return nil, fmt.Errorf("%s %w", a, asset.ErrNotSupported)
+	}
+	instruments, err := b.GetActiveInstruments(&GenericRequestParams{})
+	if err != nil {
+		return nil, err
+	}
+	var limits []order.MinMaxLevel
+	for x := range instruments {
+		if instrumentAsset(instruments[x].Symbol.String()) != a {
+			continue
+		}
+		limits = append(limits, order.MinMaxLevel{
+			Pair:       instruments[x].Symbol,
+			Asset:      a,
+			StepPrice:  instruments[x].TickSize,
+			MinAmount:  float64(instruments[x].LotSize),
+			MaxAmount:  float64(instruments[x].MaxOrderQty),
+			StepAmount: float64(instruments[x].LotSize),
+		})
+	}
+	return limits, nil
+}
+
+// instrumentAsset returns the asset type an instrument symbol is listed under
+func instrumentAsset(symbol string) asset.Item {
+	switch {
+	case strings.Contains(symbol, "."):
+		return asset.Index
+	case strings.Contains(symbol, "USD"):
+		return asset.PerpetualContract
+	default:
+		return asset.Futures
+	}
+}
+
 // GetActiveIntervals returns funding history
 func (b *Bitmex) GetActiveIntervals() (InstrumentInterval, error) {
 	var interval InstrumentInterval
@@ -854,7 +895,7 @@ func (b *Bitmex) SendAuthenticatedHTTPRequest(ep exchange.URL, verb, path string
 	if err != nil {
 		return err
 	}
-	expires := time.Now().Add(time.Second * 10)
+	expires := b.Clock.Now().Add(time.Second * 10)
 	timestamp := expires.UnixNano()
 	timestampStr := strconv.FormatInt(timestamp, 10)
 	timestampNew := timestampStr[:13]
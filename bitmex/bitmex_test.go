@@ -1,6 +1,7 @@
 package bitmex
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"os"
@@ -1053,3 +1054,27 @@ func TestGetHistoricTrades(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	if err := b.UpdateOrderExecutionLimits(asset.PerpetualContract); err != nil {
+		t.Error("UpdateOrderExecutionLimits() error", err)
+	}
+	if err := b.UpdateOrderExecutionLimits(asset.Index); !errors.Is(err, asset.ErrNotSupported) {
+		t.Errorf("received: %v but expected: %v", err, asset.ErrNotSupported)
+	}
+}
+
+func TestInstrumentAsset(t *testing.T) {
+	t.Parallel()
+	for symbol, expected := range map[string]asset.Item{
+		".BXBT":  asset.Index,
+		"XBTUSD": asset.PerpetualContract,
+		"ETHXBT": asset.Futures,
+		"XBTZ21": asset.Futures,
+	} {
+		if a := instrumentAsset(symbol); a != expected {
+			t.Errorf("%s received: %v but expected: %v", symbol, a, expected)
+		}
+	}
+}
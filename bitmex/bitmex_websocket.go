@@ -664,7 +664,7 @@ func (b *Bitmex) websocketSendAuth() error {
 		return fmt.Errorf("%v AuthenticatedWebsocketAPISupport not enabled", b.Name)
 	}
 	b.Websocket.SetCanUseAuthenticatedEndpoints(true)
-	timestamp := time.Now().Add(time.Hour * 1).Unix()
+	timestamp := b.Clock.Now().Add(time.Hour * 1).Unix()
 	newTimestamp := strconv.FormatInt(timestamp, 10)
 	hmac := crypto.GetHMAC(crypto.HashSHA256,
 		[]byte("GET/realtime"+newTimestamp),
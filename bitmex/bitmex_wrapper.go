@@ -1,6 +1,7 @@
 package bitmex
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
@@ -205,6 +206,20 @@ func (b *Bitmex) Run() {
 		b.PrintEnabledPairs()
 	}
 
+	a := b.GetAssetTypes()
+	for x := range a {
+		if err := b.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
+			err = b.UpdateOrderExecutionLimits(a[x])
+			b.SetOrderExecutionLimitsLoadError(a[x], err)
+			if err != nil {
+				log.Errorf(log.ExchangeSys,
+					"Could not set %s exchange exchange limits: %v",
+					b.Name,
+					err)
+			}
+		}
+	}
+
 	if !b.GetEnabledFeatures().AutoPairUpdates {
 		return
 	}
@@ -247,18 +262,8 @@ func (b *Bitmex) UpdateTradablePairs(forceUpdate bool) error {
 	}
 
 	for x := range pairs {
-		if strings.Contains(pairs[x], ".") {
-			assetPairs[asset.Index] = append(assetPairs[asset.Index], pairs[x])
-			continue
-		}
-
-		if strings.Contains(pairs[x], "USD") {
-			assetPairs[asset.PerpetualContract] = append(assetPairs[asset.PerpetualContract],
-				pairs[x])
-			continue
-		}
-
-		assetPairs[asset.Futures] = append(assetPairs[asset.Futures], pairs[x])
+		a := instrumentAsset(pairs[x])
+		assetPairs[a] = append(assetPairs[a], pairs[x])
 	}
 
 	for a, values := range assetPairs {
@@ -536,18 +541,26 @@ func (b *Bitmex) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 	if err := s.Validate(); err != nil {
 		return submitOrderResponse, err
 	}
+	if err := b.ConformOrderToLimits(s); err != nil {
+		return submitOrderResponse, err
+	}
 
 	if math.Mod(s.Amount, 1) != 0 {
 		return submitOrderResponse,
 			errors.New("order contract amount can not have decimals")
 	}
 
+	if err := exchange.AttachClientOrderID(b, s); err != nil {
+		return submitOrderResponse, err
+	}
+
 	fPair, err := b.FormatExchangeCurrency(s.Pair, s.AssetType)
 	if err != nil {
 		return submitOrderResponse, err
 	}
 
 	var orderNewParams = OrderNewParams{
+		ClientOrderID: s.ClientOrderID,
 		OrderType:     s.Type.Title(),
 		Symbol:        fPair.String(),
 		OrderQuantity: s.Amount,
@@ -641,6 +654,47 @@ func (b *Bitmex) GetOrderInfo(orderID string, pair currency.Pair, assetType asse
 	return orderDetail, common.ErrNotYetImplemented
 }
 
+// GetOrderInfoByClientOrderID returns order information based on the client
+// order ID supplied on submission
+func (b *Bitmex) GetOrderInfoByClientOrderID(clientOrderID string, pair currency.Pair, assetType asset.Item) (order.Detail, error) {
+	if clientOrderID == "" {
+		return order.Detail{}, order.ErrOrderIDNotSet
+	}
+	filter, err := json.Marshal(map[string]string{"clOrdID": clientOrderID})
+	if err != nil {
+		return order.Detail{}, err
+	}
+	resp, err := b.GetOrders(&OrdersRequest{Filter: string(filter)})
+	if err != nil {
+		return order.Detail{}, err
+	}
+	if len(resp) == 0 {
+		return order.Detail{}, fmt.Errorf("%s client order ID %s %w",
+			b.Name,
+			clientOrderID,
+			exchange.ErrOrderNotFound)
+	}
+	orderType := orderTypeMap[resp[0].OrdType]
+	if orderType == "" {
+		orderType = order.UnknownType
+	}
+	return order.Detail{
+		Date:            resp[0].Timestamp,
+		Price:           resp[0].Price,
+		Amount:          float64(resp[0].OrderQty),
+		ExecutedAmount:  float64(resp[0].CumQty),
+		RemainingAmount: float64(resp[0].LeavesQty),
+		Exchange:        b.Name,
+		ID:              resp[0].OrderID,
+		ClientOrderID:   resp[0].ClOrdID,
+		Side:            orderSideMap[resp[0].Side],
+		Type:            orderType,
+		Status:          order.Status(resp[0].OrdStatus),
+		Pair:            pair,
+		AssetType:       assetType,
+	}, nil
+}
+
 // GetDepositAddress returns a deposit address for a specified currency
 func (b *Bitmex) GetDepositAddress(cryptocurrency currency.Code, _ string) (string, error) {
 	return b.GetCryptoDepositAddress(cryptocurrency.String())
@@ -802,6 +856,15 @@ func (b *Bitmex) AuthenticateWebsocket() error {
 	return b.websocketSendAuth()
 }
 
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (b *Bitmex) UpdateOrderExecutionLimits(a asset.Item) error {
+	limits, err := b.FetchExchangeLimits(a)
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return b.LoadLimits(limits)
+}
+
 // ValidateCredentials validates current credentials used for wrapper
 // functionality
 func (b *Bitmex) ValidateCredentials(assetType asset.Item) error {
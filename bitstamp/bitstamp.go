@@ -13,6 +13,8 @@ import (
 	"time"
 
 	exchange "github.com/openware/irix"
+	"github.com/openware/irix/stream/buffer"
+	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/common"
 	"github.com/openware/pkg/common/crypto"
 	"github.com/openware/pkg/currency"
@@ -54,6 +56,7 @@ const (
 	bitstampAPIReturnType         = "string"
 	bitstampAPITradingPairsInfo   = "trading-pairs-info"
 	bitstampOHLC                  = "ohlc"
+	bitstampOrderNotFoundMsg      = "order not found"
 
 	bitstampRateInterval = time.Minute * 10
 	bitstampRequestRate  = 8000
@@ -63,6 +66,8 @@ const (
 // Bitstamp is the overarching type across the bitstamp package
 type Bitstamp struct {
 	exchange.Base
+	// obm fetches websocket orderbooks via REST when they fall out of sync
+	obm *buffer.SyncManager
 }
 
 // GetFee returns an estimate of fee based on type of transaction
@@ -209,6 +214,41 @@ func (b *Bitstamp) GetTradingPairs() ([]TradingPair, error) {
 	return result, b.SendHTTPRequest(exchange.RestSpot, path, &result)
 }
 
+// FetchExchangeLimits fetches spot order execution limits
+func (b *Bitstamp) FetchExchangeLimits() ([]order.MinMaxLevel, error) {
+	pairs, err := b.GetTradingPairs()
+	if err != nil {
+		return nil, err
+	}
+	limits := make([]order.MinMaxLevel, 0, len(pairs))
+	for x := range pairs {
+		if pairs[x].Trading != "Enabled" {
+			continue
+		}
+		var cp currency.Pair
+		cp, err = currency.NewPairFromString(pairs[x].Name)
+		if err != nil {
+			return nil, err
+		}
+		// The minimum order is the counter currency value e.g. "5.0 USD"
+		var minNotional float64
+		if fields := strings.Fields(pairs[x].MinimumOrder); len(fields) > 0 {
+			minNotional, err = strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s minimum order %q: %w", pairs[x].Name, pairs[x].MinimumOrder, err)
+			}
+		}
+		limits = append(limits, order.MinMaxLevel{
+			Pair:        cp,
+			Asset:       asset.Spot,
+			StepPrice:   exchange.StepFromDecimalPlaces(int64(pairs[x].CounterDecimals)),
+			StepAmount:  exchange.StepFromDecimalPlaces(int64(pairs[x].BaseDecimals)),
+			MinNotional: minNotional,
+		})
+	}
+	return limits, nil
+}
+
 // GetTransactions returns transaction information
 // value paramater ["time"] = "minute", "hour", "day" will collate your
 // response into time intervals.
@@ -351,6 +391,17 @@ func (b *Bitstamp) GetOrderStatus(orderID int64) (OrderStatus, error) {
 		b.SendAuthenticatedHTTPRequest(exchange.RestSpot, bitstampAPIOrderStatus, false, req, &resp)
 }
 
+// GetOrderStatusByClientOrderID returns the status of an order by the client
+// order ID supplied on submission
+func (b *Bitstamp) GetOrderStatusByClientOrderID(clientOrderID string) (OrderStatus, error) {
+	resp := OrderStatus{}
+	req := url.Values{}
+	req.Add("client_order_id", clientOrderID)
+
+	return resp,
+		b.SendAuthenticatedHTTPRequest(exchange.RestSpot, bitstampAPIOrderStatus, true, req, &resp)
+}
+
 // CancelExistingOrder cancels order by ID
 func (b *Bitstamp) CancelExistingOrder(orderID int64) (CancelOrder, error) {
 	var req = url.Values{}
@@ -374,10 +425,13 @@ func (b *Bitstamp) CancelAllExistingOrders() (bool, error) {
 }
 
 // PlaceOrder places an order on the exchange.
-func (b *Bitstamp) PlaceOrder(currencyPair string, price, amount float64, buy, market bool) (Order, error) {
+func (b *Bitstamp) PlaceOrder(currencyPair string, price, amount float64, buy, market bool, clientOrderID string) (Order, error) {
 	var req = url.Values{}
 	req.Add("amount", strconv.FormatFloat(amount, 'f', -1, 64))
 	req.Add("price", strconv.FormatFloat(price, 'f', -1, 64))
+	if clientOrderID != "" {
+		req.Add("client_order_id", clientOrderID)
+	}
 	response := Order{}
 	orderType := order.Buy.Lower()
 
@@ -617,7 +671,12 @@ func (b *Bitstamp) SendAuthenticatedHTTPRequest(ep exchange.URL, path string, v2
 	if err != nil {
 		return err
 	}
-	n := b.Requester.GetNonce(true).String()
+	nonce, release, err := b.NextNonce()
+	if err != nil {
+		return err
+	}
+	defer release()
+	n := nonce.String()
 
 	if values == nil {
 		values = url.Values{}
@@ -725,3 +725,20 @@ func TestGetHistoricTrades(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	if err := b.UpdateOrderExecutionLimits(asset.Spot); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.UpdateOrderExecutionLimits(asset.Futures); err == nil {
+		t.Fatal("expected unhandled case")
+	}
+	p, err := b.GetPrecision(asset.Spot, currency.NewPair(currency.LTC, currency.USD))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.PriceTick.String() != "0.01" || p.AmountStep.String() != "0.00000001" || p.MinNotional.String() != "5" {
+		t.Errorf("unexpected precision %+v", p)
+	}
+}
@@ -102,6 +102,7 @@ type Order struct {
 	Price    float64 `json:"price,string"`
 	Amount   float64 `json:"amount,string"`
 	Currency string  `json:"currency_pair"`
+	ClientID string  `json:"client_order_id"`
 }
 
 // OrderStatus holds order status information
@@ -110,6 +111,7 @@ type OrderStatus struct {
 	Amount       float64 `json:"amount,string"`
 	Type         int     `json:"type"`
 	ID           int64   `json:"id,string"`
+	ClientID     string  `json:"client_order_id"`
 	DateTime     string  `json:"datetime"`
 	Status       string
 	Transactions []struct {
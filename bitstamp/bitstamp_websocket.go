@@ -21,6 +21,11 @@ import (
 
 const (
 	bitstampWSURL = "wss://ws.bitstamp.net"
+	// wsOrderbookBufferLimit bounds the sequenced updates staged per book
+	// while it is fetched via REST
+	wsOrderbookBufferLimit = 150
+	// wsOrderbookWorkers is the number of books fetched via REST at once
+	wsOrderbookWorkers = 5
 )
 
 // WsConnect connects to a websocket feed
@@ -238,7 +243,7 @@ func (b *Bitstamp) wsUpdateOrderbook(update websocketOrderBook, p currency.Pair,
 
 		bids = append(bids, orderbook.Item{Price: target, Amount: amount})
 	}
-	return b.Websocket.Orderbook.LoadSnapshot(&orderbook.Base{
+	return b.obm.LoadSnapshot(&orderbook.Base{
 		Bids:            bids,
 		Asks:            asks,
 		Pair:            p,
@@ -279,7 +284,7 @@ func (b *Bitstamp) seedOrderBook() error {
 		newOrderBook.Exchange = b.Name
 		newOrderBook.VerifyOrderbook = b.CanVerifyOrderbook
 
-		err = b.Websocket.Orderbook.LoadSnapshot(&newOrderBook)
+		err = b.obm.LoadSnapshot(&newOrderBook)
 		if err != nil {
 			return err
 		}
@@ -2,6 +2,7 @@ package bitstamp
 
 import (
 	"errors"
+	"fmt"
 	"sort"
 	"strconv"
 	"strings"
@@ -13,6 +14,7 @@ import (
 	"github.com/openware/irix/portfolio/withdraw"
 	"github.com/openware/irix/protocol"
 	"github.com/openware/irix/stream"
+	"github.com/openware/irix/stream/buffer"
 	"github.com/openware/irix/ticker"
 	"github.com/openware/pkg/account"
 	"github.com/openware/pkg/asset"
@@ -173,11 +175,20 @@ func (b *Bitstamp) Setup(exch *config.ExchangeConfig) error {
 		Features:                         &b.Features.Supports.WebsocketCapabilities,
 		OrderbookBufferLimit:             exch.OrderbookConfig.WebsocketBufferLimit,
 		BufferEnabled:                    exch.OrderbookConfig.WebsocketBufferEnabled,
+		OrderbookResync:                  b.resyncOrderbook,
 	})
 	if err != nil {
 		return err
 	}
 
+	b.obm, err = buffer.NewSyncManager(&b.Websocket.Orderbook,
+		b.UpdateOrderbook,
+		wsOrderbookBufferLimit,
+		wsOrderbookWorkers)
+	if err != nil {
+		return err
+	}
+
 	return b.Websocket.SetupNewConnection(stream.ConnectionSetup{
 		URL:                  b.Websocket.GetWebsocketURL(),
 		ResponseCheckTimeout: exch.WebsocketResponseCheckTimeout,
@@ -204,6 +215,20 @@ func (b *Bitstamp) Run() {
 		b.PrintEnabledPairs()
 	}
 
+	a := b.GetAssetTypes()
+	for x := range a {
+		if err := b.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
+			err = b.UpdateOrderExecutionLimits(a[x])
+			b.SetOrderExecutionLimitsLoadError(a[x], err)
+			if err != nil {
+				log.Errorf(log.ExchangeSys,
+					"Could not set %s exchange exchange limits: %v",
+					b.Name,
+					err)
+			}
+		}
+	}
+
 	if !b.GetEnabledFeatures().AutoPairUpdates {
 		return
 	}
@@ -460,6 +485,12 @@ func (b *Bitstamp) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 	if err := s.Validate(); err != nil {
 		return submitOrderResponse, err
 	}
+	if err := b.ConformOrderToLimits(s); err != nil {
+		return submitOrderResponse, err
+	}
+	if err := exchange.AttachClientOrderID(b, s); err != nil {
+		return submitOrderResponse, err
+	}
 
 	fPair, err := b.FormatExchangeCurrency(s.Pair, s.AssetType)
 	if err != nil {
@@ -472,7 +503,8 @@ func (b *Bitstamp) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 		s.Price,
 		s.Amount,
 		buy,
-		market)
+		market,
+		s.ClientOrderID)
 	if err != nil {
 		return submitOrderResponse, err
 	}
@@ -531,6 +563,52 @@ func (b *Bitstamp) GetOrderInfo(orderID string, pair currency.Pair, assetType as
 	return orderDetail, common.ErrNotYetImplemented
 }
 
+// GetOrderInfoByClientOrderID returns order information based on the client
+// order ID supplied on submission
+func (b *Bitstamp) GetOrderInfoByClientOrderID(clientOrderID string, pair currency.Pair, assetType asset.Item) (order.Detail, error) {
+	if clientOrderID == "" {
+		return order.Detail{}, order.ErrOrderIDNotSet
+	}
+	resp, err := b.GetOrderStatusByClientOrderID(clientOrderID)
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), bitstampOrderNotFoundMsg) {
+			return order.Detail{}, fmt.Errorf("%s client order ID %s %w: %v",
+				b.Name,
+				clientOrderID,
+				exchange.ErrOrderNotFound,
+				err)
+		}
+		return order.Detail{}, err
+	}
+	return order.Detail{
+		Exchange:      b.Name,
+		ID:            strconv.FormatInt(resp.ID, 10),
+		ClientOrderID: clientOrderID,
+		Pair:          pair,
+		AssetType:     assetType,
+		Status:        orderStatus(resp.Status, len(resp.Transactions) > 0),
+	}, nil
+}
+
+// orderStatus converts an order_status status to an order status
+func orderStatus(status string, traded bool) order.Status {
+	switch status {
+	case "Open":
+		if traded {
+			return order.PartiallyFilled
+		}
+		return order.Active
+	case "Finished":
+		return order.Filled
+	case "Canceled":
+		if traded {
+			return order.PartiallyCancelled
+		}
+		return order.Cancelled
+	}
+	return order.UnknownStatus
+}
+
 // GetDepositAddress returns a deposit address for a specified currency
 func (b *Bitstamp) GetDepositAddress(cryptocurrency currency.Code, _ string) (string, error) {
 	return b.GetCryptoDepositAddress(cryptocurrency)
@@ -892,3 +970,21 @@ func (b *Bitstamp) GetHistoricCandlesExtended(pair currency.Pair, a asset.Item,
 	ret.SortCandlesByTimestamp(false)
 	return ret, nil
 }
+
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (b *Bitstamp) UpdateOrderExecutionLimits(a asset.Item) error {
+	if a != asset.Spot {
+		return fmt.Errorf("%s %w", a, asset.ErrNotSupported)
+	}
+	limits, err := b.FetchExchangeLimits()
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return b.LoadLimits(limits)
+}
+
+// resyncOrderbook fetches a websocket orderbook via REST once it falls out of
+// sync
+func (b *Bitstamp) resyncOrderbook(p currency.Pair, a asset.Item) {
+	b.obm.Resync(p, a)
+}
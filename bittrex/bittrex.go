@@ -11,8 +11,10 @@ import (
 	"time"
 
 	exchange "github.com/openware/irix"
+	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/common/crypto"
 	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
 	"github.com/openware/pkg/request"
 )
 
@@ -81,6 +83,32 @@ func (b *Bittrex) GetMarkets() (Market, error) {
 	return markets, nil
 }
 
+// FetchExchangeLimits fetches spot order execution limits, markets only
+// provide a minimum trade size
+func (b *Bittrex) FetchExchangeLimits() ([]order.MinMaxLevel, error) {
+	markets, err := b.GetMarkets()
+	if err != nil {
+		return nil, err
+	}
+	var limits []order.MinMaxLevel
+	for x := range markets.Result {
+		if !markets.Result[x].IsActive || markets.Result[x].MarketName == "" {
+			continue
+		}
+		var cp currency.Pair
+		cp, err = currency.NewPairFromString(markets.Result[x].MarketName)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, order.MinMaxLevel{
+			Pair:      cp,
+			Asset:     asset.Spot,
+			MinAmount: markets.Result[x].MinTradeSize,
+		})
+	}
+	return limits, nil
+}
+
 // GetCurrencies is used to get all supported currencies at Bittrex
 func (b *Bittrex) GetCurrencies() (Currency, error) {
 	var currencies Currency
@@ -427,7 +455,12 @@ func (b *Bittrex) SendAuthenticatedHTTPRequest(ep exchange.URL, path string, val
 	if err != nil {
 		return err
 	}
-	n := b.Requester.GetNonce(true).String()
+	nonce, release, err := b.NextNonce()
+	if err != nil {
+		return err
+	}
+	defer release()
+	n := nonce.String()
 
 	values.Set("apikey", b.API.Credentials.Key)
 	values.Set("nonce", n)
@@ -575,3 +575,17 @@ func TestGetHistoricTrades(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	err := b.UpdateOrderExecutionLimits(asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = b.UpdateOrderExecutionLimits(asset.Margin); err == nil {
+		t.Fatal("expected unhandled case")
+	}
+	if _, err = b.GetOrderExecutionLimits(asset.Spot, currency.NewPairWithDelimiter("BTC", "LTC", "-")); err != nil {
+		t.Fatal(err)
+	}
+}
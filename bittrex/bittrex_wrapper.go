@@ -2,6 +2,7 @@ package bittrex
 
 import (
 	"errors"
+	"fmt"
 	"sort"
 	"strconv"
 	"strings"
@@ -180,6 +181,20 @@ func (b *Bittrex) Run() {
 		}
 	}
 
+	a := b.GetAssetTypes()
+	for x := range a {
+		if err := b.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
+			err = b.UpdateOrderExecutionLimits(a[x])
+			b.SetOrderExecutionLimitsLoadError(a[x], err)
+			if err != nil {
+				log.Errorf(log.ExchangeSys,
+					"Could not set %s exchange exchange limits: %v",
+					b.Name,
+					err)
+			}
+		}
+	}
+
 	if !b.GetEnabledFeatures().AutoPairUpdates && !forceUpdate {
 		return
 	}
@@ -438,6 +453,9 @@ func (b *Bittrex) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 	if err := s.Validate(); err != nil {
 		return submitOrderResponse, err
 	}
+	if err := b.ConformOrderToLimits(s); err != nil {
+		return submitOrderResponse, err
+	}
 
 	buy := s.Side == order.Buy
 	if s.Type != order.Limit {
@@ -720,3 +738,15 @@ func (b *Bittrex) GetHistoricCandles(pair currency.Pair, a asset.Item, start, en
 func (b *Bittrex) GetHistoricCandlesExtended(pair currency.Pair, a asset.Item, start, end time.Time, interval kline.Interval) (kline.Item, error) {
 	return kline.Item{}, common.ErrFunctionNotSupported
 }
+
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (b *Bittrex) UpdateOrderExecutionLimits(a asset.Item) error {
+	if a != asset.Spot {
+		return fmt.Errorf("%s %w", a, asset.ErrNotSupported)
+	}
+	limits, err := b.FetchExchangeLimits()
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return b.LoadLimits(limits)
+}
@@ -14,9 +14,11 @@ import (
 	"time"
 
 	exchange "github.com/openware/irix"
+	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/common"
 	"github.com/openware/pkg/common/crypto"
 	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
 	"github.com/openware/pkg/request"
 )
 
@@ -85,6 +87,31 @@ func (b *BTCMarkets) GetMarkets() ([]Market, error) {
 	return resp, b.SendHTTPRequest(btcMarketsUnauthPath, &resp)
 }
 
+// FetchExchangeLimits fetches spot order execution limits
+func (b *BTCMarkets) FetchExchangeLimits() ([]order.MinMaxLevel, error) {
+	markets, err := b.GetMarkets()
+	if err != nil {
+		return nil, err
+	}
+	limits := make([]order.MinMaxLevel, 0, len(markets))
+	for x := range markets {
+		var cp currency.Pair
+		cp, err = currency.NewPairFromStrings(markets[x].BaseAsset, markets[x].QuoteAsset)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, order.MinMaxLevel{
+			Pair:       cp,
+			Asset:      asset.Spot,
+			StepPrice:  exchange.StepFromDecimalPlaces(markets[x].PriceDecimals),
+			MinAmount:  markets[x].MinOrderAmount,
+			MaxAmount:  markets[x].MaxOrderAmount,
+			StepAmount: exchange.StepFromDecimalPlaces(markets[x].AmountDecimals),
+		})
+	}
+	return limits, nil
+}
+
 // GetTicker returns a ticker
 // symbol - example "btc" or "ltc"
 func (b *BTCMarkets) GetTicker(marketID string) (Ticker, error) {
@@ -700,7 +727,7 @@ func (b *BTCMarkets) SendAuthenticatedRequest(method, path string, data, result
 			b.Name)
 	}
 
-	now := time.Now()
+	now := b.Clock.Now()
 	strTime := strconv.FormatInt(now.UTC().UnixNano()/1000000, 10)
 
 	var body io.Reader
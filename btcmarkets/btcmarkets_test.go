@@ -800,3 +800,14 @@ func TestGetHistoricTrades(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	err := b.UpdateOrderExecutionLimits(asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = b.GetOrderExecutionLimits(asset.Spot, currency.NewPair(currency.BTC, currency.AUD)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/openware/irix/stream"
@@ -342,7 +341,7 @@ func (b *BTCMarkets) Subscribe(channelsToSubscribe []stream.ChannelSubscription)
 		if !common.StringDataCompare(payload.Channels, authChannels[i]) {
 			continue
 		}
-		signTime := strconv.FormatInt(time.Now().UTC().UnixNano()/1000000, 10)
+		signTime := strconv.FormatInt(b.Clock.Now().UTC().UnixNano()/1000000, 10)
 		strToSign := "/users/self/subscribe" + "\n" + signTime
 		tempSign := crypto.GetHMAC(crypto.HashSHA512,
 			[]byte(strToSign),
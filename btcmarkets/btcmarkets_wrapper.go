@@ -145,6 +145,7 @@ func (b *BTCMarkets) Setup(exch *config.ExchangeConfig) error {
 	if err != nil {
 		return err
 	}
+	b.SetServerTimeSource(b.GetServerTime)
 
 	wsURL, err := b.API.Endpoints.GetURL(exchange.WebsocketSpot)
 	if err != nil {
@@ -242,6 +243,20 @@ func (b *BTCMarkets) Run() {
 		}
 	}
 
+	a := b.GetAssetTypes()
+	for x := range a {
+		if err := b.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
+			err = b.UpdateOrderExecutionLimits(a[x])
+			b.SetOrderExecutionLimitsLoadError(a[x], err)
+			if err != nil {
+				log.Errorf(log.ExchangeSys,
+					"Could not set %s exchange exchange limits: %v",
+					b.Name,
+					err)
+			}
+		}
+	}
+
 	if !b.GetEnabledFeatures().AutoPairUpdates && !forceUpdate {
 		return
 	}
@@ -496,6 +511,12 @@ func (b *BTCMarkets) SubmitOrder(s *order.Submit) (order.SubmitResponse, error)
 	if err := s.Validate(); err != nil {
 		return resp, err
 	}
+	if err := b.ConformOrderToLimits(s); err != nil {
+		return resp, err
+	}
+	if err := exchange.AttachClientOrderID(b, s); err != nil {
+		return resp, err
+	}
 
 	if s.Side == order.Sell {
 		s.Side = order.Ask
@@ -519,7 +540,7 @@ func (b *BTCMarkets) SubmitOrder(s *order.Submit) (order.SubmitResponse, error)
 		"",
 		false,
 		"",
-		s.ClientID)
+		s.ClientOrderID)
 	if err != nil {
 		return resp, err
 	}
@@ -1028,3 +1049,15 @@ func (b *BTCMarkets) GetHistoricCandlesExtended(p currency.Pair, a asset.Item, s
 	ret.SortCandlesByTimestamp(false)
 	return ret, nil
 }
+
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (b *BTCMarkets) UpdateOrderExecutionLimits(a asset.Item) error {
+	if a != asset.Spot {
+		return fmt.Errorf("%s %w", a, asset.ErrNotSupported)
+	}
+	limits, err := b.FetchExchangeLimits()
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return b.LoadLimits(limits)
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/openware/pkg/common/crypto"
 	"github.com/openware/pkg/currency"
 	"github.com/openware/pkg/log"
+	"github.com/openware/pkg/order"
 	"github.com/openware/pkg/request"
 )
 
@@ -77,6 +78,38 @@ func (b *BTSE) GetMarketSummary(symbol string, spot bool) (MarketSummary, error)
 	return m, b.SendHTTPRequest(exchange.RestSpot, http.MethodGet, path, &m, spot, queryFunc)
 }
 
+// FetchExchangeLimits fetches order execution limits for spot or futures
+func (b *BTSE) FetchExchangeLimits(a asset.Item) ([]order.MinMaxLevel, error) {
+	if a != asset.Spot && a != asset.Futures {
+		return nil, fmt.Errorf("%s %w", a, asset.ErrNotSupported)
+	}
+	markets, err := b.GetMarketSummary("", a == asset.Spot)
+	if err != nil {
+		return nil, err
+	}
+	var limits []order.MinMaxLevel
+	for x := range markets {
+		if !markets[x].Active {
+			continue
+		}
+		var cp currency.Pair
+		cp, err = currency.NewPairFromString(markets[x].Symbol)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, order.MinMaxLevel{
+			Pair:       cp,
+			Asset:      a,
+			MinPrice:   markets[x].MinValidPrice,
+			StepPrice:  markets[x].MinPriceIncrement,
+			MinAmount:  markets[x].MinOrderSize,
+			MaxAmount:  markets[x].MaxOrderSize,
+			StepAmount: markets[x].MinSizeIncrement,
+		})
+	}
+	return limits, nil
+}
+
 // FetchOrderBook gets orderbook data for a given pair
 func (b *BTSE) FetchOrderBook(symbol string, group, limitBids, limitAsks int, spot bool) (*Orderbook, error) {
 	var o Orderbook
@@ -170,6 +203,15 @@ func (b *BTSE) GetServerTime() (*ServerTime, error) {
 	return &s, b.SendHTTPRequest(exchange.RestSpot, http.MethodGet, btseTime, &s, true, queryFunc)
 }
 
+// serverTime returns the server time for the clock
+func (b *BTSE) serverTime() (time.Time, error) {
+	s, err := b.GetServerTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, s.Epoch*int64(time.Millisecond)), nil
+}
+
 // GetWalletInformation returns the users account balance
 func (b *BTSE) GetWalletInformation() ([]CurrencyBalance, error) {
 	var a []CurrencyBalance
@@ -474,7 +516,7 @@ func (b *BTSE) SendAuthenticatedHTTPRequest(ep exchange.URL, method, endpoint st
 	}
 	var hmac []byte
 	var body io.Reader
-	nonce := strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+	nonce := strconv.FormatInt(b.Clock.Now().UnixNano()/int64(time.Millisecond), 10)
 	headers := map[string]string{
 		"btse-api":   b.API.Credentials.Key,
 		"btse-nonce": nonce,
@@ -914,3 +914,21 @@ func TestWsUnexpectedData(t *testing.T) {
 		t.Error("expected error response from bad data")
 	}
 }
+
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	err := b.UpdateOrderExecutionLimits(asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = b.UpdateOrderExecutionLimits(asset.Margin); err == nil {
+		t.Fatal("expected unhandled case")
+	}
+	p, err := b.GetPrecision(asset.Spot, currency.NewPairWithDelimiter("BTC", "USD", "-"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.PriceTick.IsPositive() || !p.AmountStep.IsPositive() {
+		t.Fatalf("unexpected precision %+v", p)
+	}
+}
@@ -181,6 +181,7 @@ func (b *BTSE) Setup(exch *config.ExchangeConfig) error {
 	if err != nil {
 		return err
 	}
+	b.SetServerTimeSource(b.serverTime)
 
 	wsRunningURL, err := b.API.Endpoints.GetURL(exchange.WebsocketSpot)
 	if err != nil {
@@ -233,6 +234,20 @@ func (b *BTSE) Run() {
 		b.PrintEnabledPairs()
 	}
 
+	a := b.GetAssetTypes()
+	for x := range a {
+		if err := b.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
+			err = b.UpdateOrderExecutionLimits(a[x])
+			b.SetOrderExecutionLimitsLoadError(a[x], err)
+			if err != nil {
+				log.Errorf(log.ExchangeSys,
+					"Could not set %s exchange exchange limits: %v",
+					b.Name,
+					err)
+			}
+		}
+	}
+
 	if !b.GetEnabledFeatures().AutoPairUpdates {
 		return
 	}
@@ -499,6 +514,12 @@ func (b *BTSE) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 	if err := s.Validate(); err != nil {
 		return resp, err
 	}
+	if err := b.ConformOrderToLimits(s); err != nil {
+		return resp, err
+	}
+	if err := exchange.AttachClientOrderID(b, s); err != nil {
+		return resp, err
+	}
 
 	fPair, err := b.FormatExchangeCurrency(s.Pair, s.AssetType)
 	if err != nil {
@@ -509,7 +530,7 @@ func (b *BTSE) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 		return resp, errors.New("order outside of limits")
 	}
 
-	r, err := b.CreateOrder(s.ClientID, 0.0,
+	r, err := b.CreateOrder(s.ClientOrderID, 0.0,
 		false,
 		s.Price, s.Side.String(), s.Amount, 0, 0,
 		fPair.String(), goodTillCancel,
@@ -674,6 +695,85 @@ func (b *BTSE) GetOrderInfo(orderID string, pair currency.Pair, assetType asset.
 	return od, nil
 }
 
+// GetOrderInfoByClientOrderID returns order information based on the client
+// order ID supplied on submission, orders which are no longer open are found
+// through their fills
+func (b *BTSE) GetOrderInfoByClientOrderID(clientOrderID string, pair currency.Pair, assetType asset.Item) (order.Detail, error) {
+	if clientOrderID == "" {
+		return order.Detail{}, order.ErrOrderIDNotSet
+	}
+	fPair, err := b.FormatExchangeCurrency(pair, asset.Spot)
+	if err != nil {
+		return order.Detail{}, err
+	}
+	od := order.Detail{
+		Exchange:      b.Name,
+		ClientOrderID: clientOrderID,
+		Pair:          pair,
+		AssetType:     assetType,
+		Status:        order.UnknownStatus,
+	}
+
+	o, err := b.GetOrders(fPair.String(), "", clientOrderID)
+	if err != nil {
+		return order.Detail{}, err
+	}
+	for i := range o {
+		if o[i].ClOrderID != clientOrderID {
+			continue
+		}
+		od.ID = o[i].OrderID
+		od.Amount = o[i].Size
+		od.ExecutedAmount = o[i].FilledSize
+		od.Price = o[i].Price
+		od.Date = time.Unix(o[i].Timestamp, 0)
+		od.Type = orderIntToType(o[i].OrderType)
+		od.Status = order.Status(o[i].OrderState)
+		od.Side = order.Buy
+		if strings.EqualFold(o[i].Side, order.Ask.String()) {
+			od.Side = order.Sell
+		}
+		return od, nil
+	}
+
+	th, err := b.TradeHistory(fPair.String(),
+		time.Time{}, time.Time{},
+		0, 0, 0,
+		false,
+		clientOrderID, "")
+	if err != nil {
+		return order.Detail{}, err
+	}
+	for i := range th {
+		if th[i].ClOrderID != clientOrderID {
+			continue
+		}
+		createdAt, err := parseOrderTime(th[i].TradeID)
+		if err != nil {
+			log.Errorf(log.ExchangeSys,
+				"%s GetOrderInfoByClientOrderID unable to parse time: %s\n", b.Name, err)
+		}
+		od.ID = th[i].OrderID
+		od.ExecutedAmount += th[i].Size
+		od.Trades = append(od.Trades, order.TradeHistory{
+			Timestamp: createdAt,
+			TID:       th[i].TradeID,
+			Price:     th[i].Price,
+			Amount:    th[i].Size,
+			Exchange:  b.Name,
+			Side:      order.Side(th[i].Side),
+			Fee:       th[i].FeeAmount,
+		})
+	}
+	if od.ID == "" {
+		return order.Detail{}, fmt.Errorf("%s client order ID %s %w",
+			b.Name,
+			clientOrderID,
+			exchange.ErrOrderNotFound)
+	}
+	return od, nil
+}
+
 // GetDepositAddress returns a deposit address for a specified currency
 func (b *BTSE) GetDepositAddress(cryptocurrency currency.Code, accountID string) (string, error) {
 	address, err := b.GetWalletAddress(cryptocurrency.String())
@@ -1051,3 +1151,12 @@ func OrderSizeLimits(pair string) (limits OrderSizeLimit, found bool) {
 	val, ok := resp.(OrderSizeLimit)
 	return val, ok
 }
+
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (b *BTSE) UpdateOrderExecutionLimits(a asset.Item) error {
+	limits, err := b.FetchExchangeLimits(a)
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return b.LoadLimits(limits)
+}
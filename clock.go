@@ -0,0 +1,183 @@
+package irix
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/openware/pkg/log"
+)
+
+const (
+	// DefaultClockSyncInterval is how often the server time is sampled once
+	// the clock is in use
+	DefaultClockSyncInterval = time.Minute * 10
+
+	// clockSampleCount is the number of recent samples the offset is
+	// estimated from
+	clockSampleCount = 8
+)
+
+var errNoTimeSource = errors.New("no server time source set")
+
+// TimeSource returns the current time of an exchange's server
+type TimeSource func() (time.Time, error)
+
+// clockSample is a single server time measurement
+type clockSample struct {
+	offset time.Duration
+	rtt    time.Duration
+}
+
+// Clock tracks the offset between the local clock and an exchange's server
+// so timestamps and nonces in signed requests are not rejected when the host
+// drifts. Without a time source it returns the local time.
+type Clock struct {
+	Exchange string
+
+	source   TimeSource
+	interval time.Duration
+	samples  []clockSample
+	offset   time.Duration
+	rtt      time.Duration
+	lastSync time.Time
+	started  bool
+	shutdown chan struct{}
+	syncMtx  sync.Mutex
+	mtx      sync.RWMutex
+}
+
+// SetSource sets the server time source and sampling interval. Sampling
+// starts the first time Now is called so exchanges which never sign a request
+// do not poll.
+func (c *Clock) SetSource(source TimeSource, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultClockSyncInterval
+	}
+	c.mtx.Lock()
+	c.source = source
+	c.interval = interval
+	c.mtx.Unlock()
+}
+
+// Now returns the local time corrected by the estimated server offset. The
+// first call with a time source set takes a sample before returning.
+func (c *Clock) Now() time.Time {
+	c.mtx.Lock()
+	start := c.source != nil && !c.started
+	var shutdown chan struct{}
+	if start {
+		c.started = true
+		c.shutdown = make(chan struct{})
+		shutdown = c.shutdown
+	}
+	c.mtx.Unlock()
+	if start {
+		if err := c.Sync(); err != nil {
+			log.Warnf(log.ExchangeSys, "%s server time sync failed, using local clock: %v", c.Exchange, err)
+		}
+		go c.run(shutdown)
+	}
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return time.Now().Add(c.offset)
+}
+
+// Offset returns the estimated server time minus local time
+func (c *Clock) Offset() time.Duration {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.offset
+}
+
+// RTT returns the round trip time of the sample the offset was taken from
+func (c *Clock) RTT() time.Duration {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.rtt
+}
+
+// LastSync returns when the server time was last sampled successfully
+func (c *Clock) LastSync() time.Time {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.lastSync
+}
+
+// Sync samples the server time once. The server is assumed to stamp its
+// response half way through the round trip and the offset is taken from the
+// recent sample with the lowest round trip time, which carries the least
+// queuing error.
+func (c *Clock) Sync() error {
+	c.mtx.RLock()
+	source := c.source
+	c.mtx.RUnlock()
+	if source == nil {
+		return errNoTimeSource
+	}
+	c.syncMtx.Lock()
+	defer c.syncMtx.Unlock()
+	sent := time.Now()
+	server, err := source()
+	if err != nil {
+		return err
+	}
+	received := time.Now()
+	rtt := received.Sub(sent)
+	offset := server.Sub(sent.Add(rtt / 2))
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.samples = append(c.samples, clockSample{offset: offset, rtt: rtt})
+	if len(c.samples) > clockSampleCount {
+		c.samples = c.samples[len(c.samples)-clockSampleCount:]
+	}
+	best := c.samples[0]
+	for _, s := range c.samples[1:] {
+		if s.rtt < best.rtt {
+			best = s
+		}
+	}
+	c.offset = best.offset
+	c.rtt = best.rtt
+	c.lastSync = received
+	return nil
+}
+
+// Stop stops background sampling, the last estimate remains in use. It is
+// called when the exchange or its websocket shuts down, sampling restarts on
+// the next call to Now.
+func (c *Clock) Stop() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.started {
+		close(c.shutdown)
+		c.started = false
+	}
+}
+
+// hasSource reports whether a server time source is set
+func (c *Clock) hasSource() bool {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.source != nil
+}
+
+// run samples the server time every interval until shutdown is closed
+func (c *Clock) run(shutdown chan struct{}) {
+	c.mtx.RLock()
+	interval := c.interval
+	c.mtx.RUnlock()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-shutdown:
+			return
+		case <-ticker.C:
+			if err := c.Sync(); err != nil {
+				log.Warnf(log.ExchangeSys, "%s server time sync failed: %v", c.Exchange, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package irix
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openware/irix/stream"
+)
+
+func TestClockWithoutSource(t *testing.T) {
+	t.Parallel()
+	var c Clock
+	if err := c.Sync(); !errors.Is(err, errNoTimeSource) {
+		t.Errorf("expected %v, received %v", errNoTimeSource, err)
+	}
+	if d := time.Since(c.Now()); d < 0 || d > time.Second {
+		t.Errorf("expected local time, received offset %s", d)
+	}
+	c.Stop()
+}
+
+func TestClockSync(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	var c Clock
+	c.SetSource(func() (time.Time, error) {
+		n := atomic.AddInt32(&calls, 1)
+		if n == 3 {
+			return time.Time{}, errors.New("unavailable")
+		}
+		if n == 2 {
+			// A slow sample must not replace the faster one
+			time.Sleep(time.Millisecond * 20)
+			return time.Now().Add(time.Hour), nil
+		}
+		return time.Now().Add(time.Minute), nil
+	}, time.Hour)
+
+	now := c.Now()
+	if d := now.Sub(time.Now().Add(time.Minute)); d > time.Second || d < -time.Second {
+		t.Errorf("expected server time, received offset %s", d)
+	}
+	defer c.Stop()
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("expected one sample on first use, received %d", calls)
+	}
+	if c.LastSync().IsZero() {
+		t.Error("expected last sync to be set")
+	}
+
+	if err := c.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if off := c.Offset(); off < time.Minute-time.Second || off > time.Minute+time.Second {
+		t.Errorf("expected offset from lowest rtt sample, received %s", off)
+	}
+	if c.RTT() >= time.Millisecond*20 {
+		t.Errorf("expected lowest rtt, received %s", c.RTT())
+	}
+	if err := c.Sync(); err == nil {
+		t.Error("expected source error")
+	}
+	if off := c.Offset(); off < time.Minute-time.Second || off > time.Minute+time.Second {
+		t.Errorf("expected offset kept after failed sample, received %s", off)
+	}
+}
+
+func TestSetServerTimeSource(t *testing.T) {
+	t.Parallel()
+	b := Base{Name: "clock"}
+	source := func() (time.Time, error) { return time.Now().Add(time.Hour), nil }
+	b.SetServerTimeSource(source)
+	if d := time.Since(b.Clock.Now()); d < 0 || d > time.Second {
+		t.Error("expected source to be ignored without authenticated support")
+	}
+
+	b.API.AuthenticatedSupport = true
+	b.SetServerTimeSource(source)
+	defer b.Clock.Stop()
+	if d := time.Until(b.Clock.Now()); d < time.Hour-time.Second {
+		t.Errorf("expected corrected time, received offset %s", d)
+	}
+}
+
+func TestBaseShutdownStopsClock(t *testing.T) {
+	t.Parallel()
+	b := Base{Name: "clock", Websocket: stream.New()}
+	b.API.AuthenticatedSupport = true
+	var calls int32
+	b.SetServerTimeSource(func() (time.Time, error) {
+		atomic.AddInt32(&calls, 1)
+		return time.Now(), nil
+	})
+	b.Clock.Now()
+	if err := b.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	b.Clock.mtx.RLock()
+	started := b.Clock.started
+	b.Clock.mtx.RUnlock()
+	if started {
+		t.Fatal("expected sampling to stop on shutdown")
+	}
+	// Sampling restarts with the next signed request
+	b.Clock.Now()
+	defer b.Clock.Stop()
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("expected a fresh sample after restart, received %d samples", n)
+	}
+}
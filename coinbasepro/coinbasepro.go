@@ -13,6 +13,8 @@ import (
 	"time"
 
 	exchange "github.com/openware/irix"
+	"github.com/openware/irix/stream/buffer"
+	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/common"
 	"github.com/openware/pkg/common/crypto"
 	"github.com/openware/pkg/currency"
@@ -50,12 +52,20 @@ const (
 	coinbaseproWithdrawalCoinbase      = "withdrawals/coinbase"
 	coinbaseproWithdrawalCrypto        = "withdrawals/crypto"
 	coinbaseproCoinbaseAccounts        = "coinbase-accounts"
+	coinbaseproAddresses               = "addresses"
 	coinbaseproTrailingVolume          = "users/self/trailing-volume"
+
+	orderNotFoundMsg = "NotFound"
 )
 
+var errCoinbaseAccountNotFound = errors.New("no coinbase account found for currency")
+
 // CoinbasePro is the overarching type across the coinbasepro package
 type CoinbasePro struct {
 	exchange.Base
+	// l3 keeps level 3 websocket orderbooks in sync, fetching them via REST
+	// when needed
+	l3 *buffer.SyncManager
 }
 
 // GetProducts returns supported currency pairs on the exchange with specific
@@ -66,6 +76,34 @@ func (c *CoinbasePro) GetProducts() ([]Product, error) {
 	return products, c.SendHTTPRequest(exchange.RestSpot, coinbaseproProducts, &products)
 }
 
+// FetchExchangeLimits fetches product metadata and returns the tick and lot
+// sizes, amount bounds and minimum funds of each product
+func (c *CoinbasePro) FetchExchangeLimits() ([]order.MinMaxLevel, error) {
+	products, err := c.GetProducts()
+	if err != nil {
+		return nil, err
+	}
+	limits := make([]order.MinMaxLevel, 0, len(products))
+	for x := range products {
+		var cp currency.Pair
+		cp, err = currency.NewPairFromStrings(products[x].BaseCurrency,
+			products[x].QuoteCurrency)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, order.MinMaxLevel{
+			Pair:        cp,
+			Asset:       asset.Spot,
+			StepPrice:   products[x].QuoteIncrement,
+			StepAmount:  products[x].BaseIncrement,
+			MinAmount:   products[x].BaseMinSize,
+			MaxAmount:   products[x].BaseMaxSize,
+			MinNotional: products[x].MinMarketFunds,
+		})
+	}
+	return limits, nil
+}
+
 // GetOrderbook returns orderbook by currency pair and level
 func (c *CoinbasePro) GetOrderbook(symbol string, level int) (interface{}, error) {
 	orderbook := OrderbookResponse{}
@@ -237,6 +275,15 @@ func (c *CoinbasePro) GetServerTime() (ServerTime, error) {
 	return serverTime, c.SendHTTPRequest(exchange.RestSpot, coinbaseproTime, &serverTime)
 }
 
+// serverTime returns the API server time for the clock
+func (c *CoinbasePro) serverTime() (time.Time, error) {
+	t, err := c.GetServerTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, int64(t.Epoch*float64(time.Second))), nil
+}
+
 // GetAccounts returns a list of trading accounts associated with the APIKEYS
 func (c *CoinbasePro) GetAccounts() ([]AccountResponse, error) {
 	var resp []AccountResponse
@@ -287,17 +334,17 @@ func (c *CoinbasePro) GetHolds(accountID string) ([]AccountHolds, error) {
 // stp - [optional] Self-trade prevention flag
 //
 // LIMIT ORDER PARAMS
-// price - Price per bitcoin
-// amount - Amount of BTC to buy or sell
+// price - Price per bitcoin, formatted exactly as sent
+// amount - Amount of BTC to buy or sell, formatted exactly as sent
 // timeInforce - [optional] GTC, GTT, IOC, or FOK (default is GTC)
 // cancelAfter - [optional] min, hour, day * Requires time_in_force to be GTT
 // postOnly - [optional] Post only flag Invalid when time_in_force is IOC or FOK
-func (c *CoinbasePro) PlaceLimitOrder(clientRef string, price, amount float64, side, timeInforce, cancelAfter, productID, stp string, postOnly bool) (string, error) {
+func (c *CoinbasePro) PlaceLimitOrder(clientRef, price, amount, side, timeInforce, cancelAfter, productID, stp string, postOnly bool) (string, error) {
 	resp := GeneralizedOrderResponse{}
 	req := make(map[string]interface{})
 	req["type"] = order.Limit.Lower()
-	req["price"] = strconv.FormatFloat(price, 'f', -1, 64)
-	req["size"] = strconv.FormatFloat(amount, 'f', -1, 64)
+	req["price"] = price
+	req["size"] = amount
 	req["side"] = side
 	req["product_id"] = productID
 
@@ -462,6 +509,15 @@ func (c *CoinbasePro) GetOrder(orderID string) (GeneralizedOrderResponse, error)
 	return resp, c.SendAuthenticatedHTTPRequest(exchange.RestSpot, http.MethodGet, path, nil, &resp)
 }
 
+// GetOrderByClientOID returns a single order by the client_oid supplied on
+// placement
+func (c *CoinbasePro) GetOrderByClientOID(clientOID string) (GeneralizedOrderResponse, error) {
+	resp := GeneralizedOrderResponse{}
+	path := fmt.Sprintf("%s/client:%s", coinbaseproOrders, clientOID)
+
+	return resp, c.SendAuthenticatedHTTPRequest(exchange.RestSpot, http.MethodGet, path, nil, &resp)
+}
+
 // GetFills returns a list of recent fills
 func (c *CoinbasePro) GetFills(orderID, currencyPair string) ([]FillResponse, error) {
 	var resp []FillResponse
@@ -625,6 +681,16 @@ func (c *CoinbasePro) GetCoinbaseAccounts() ([]CoinbaseAccounts, error) {
 		c.SendAuthenticatedHTTPRequest(exchange.RestSpot, http.MethodGet, coinbaseproCoinbaseAccounts, nil, &resp)
 }
 
+// GenerateCryptoAddress generates a one time crypto deposit address for a
+// coinbase account
+func (c *CoinbasePro) GenerateCryptoAddress(accountID string) (CryptoAddress, error) {
+	var resp CryptoAddress
+	path := coinbaseproCoinbaseAccounts + "/" + accountID + "/" + coinbaseproAddresses
+
+	return resp,
+		c.SendAuthenticatedHTTPRequest(exchange.RestSpot, http.MethodPost, path, nil, &resp)
+}
+
 // GetReport returns batches of historic information about your account in
 // various human and machine readable forms.
 //
@@ -719,7 +785,7 @@ func (c *CoinbasePro) SendAuthenticatedHTTPRequest(ep exchange.URL, method, path
 		}
 	}
 
-	now := time.Now()
+	now := c.Clock.Now()
 	n := strconv.FormatInt(now.Unix(), 10)
 	message := n + method + "/" + path + string(payload)
 	hmac := crypto.GetHMAC(crypto.HashSHA256, []byte(message), []byte(c.API.Credentials.Secret))
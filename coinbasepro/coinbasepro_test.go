@@ -16,6 +16,7 @@ import (
 	"github.com/openware/irix/portfolio/withdraw"
 	"github.com/openware/irix/sharedtestvalues"
 	"github.com/openware/irix/stream"
+	"github.com/openware/irix/stream/buffer"
 	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/common"
 	"github.com/openware/pkg/common/convert"
@@ -147,7 +148,7 @@ func TestAuthRequests(t *testing.T) {
 	if err == nil {
 		t.Error("Expecting error")
 	}
-	orderResponse, err := c.PlaceLimitOrder("", 0.001, 0.001,
+	orderResponse, err := c.PlaceLimitOrder("", "0.001", "0.001",
 		order.Buy.Lower(), "", "", testPair.String(), "", false)
 	if orderResponse != "" {
 		t.Error("Expecting no data returned")
@@ -583,8 +584,10 @@ func TestWithdrawInternationalBank(t *testing.T) {
 
 func TestGetDepositAddress(t *testing.T) {
 	_, err := c.GetDepositAddress(currency.BTC, "")
-	if err == nil {
-		t.Error("GetDepositAddress() error", err)
+	if areTestAPIKeysSet() && err != nil {
+		t.Errorf("Could not get deposit address: %s", err)
+	} else if !areTestAPIKeysSet() && err == nil {
+		t.Error("Expecting an error when no keys are set")
 	}
 }
 
@@ -1016,3 +1019,68 @@ func TestGetHistoricTrades(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	err := c.UpdateOrderExecutionLimits(asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.UpdateOrderExecutionLimits(asset.Futures); err == nil {
+		t.Fatal("expected unhandled case")
+	}
+	cp := currency.NewPair(currency.BTC, currency.USD)
+	p, err := c.GetPrecision(asset.Spot, cp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.PriceTick.String() != "0.01" {
+		t.Errorf("received: %v but expected: %v", p.PriceTick, "0.01")
+	}
+}
+
+func TestWsFullChannel(t *testing.T) {
+	full := stream.ChannelSubscription{Channel: "full", Currency: testPair, Asset: asset.Spot}
+	c.Websocket.AddSuccessfulSubscriptions(full)
+	defer c.Websocket.RemoveSuccessfulUnsubscriptions(full)
+	err := c.l3.LoadL3Snapshot(&buffer.L3Snapshot{
+		Exchange: c.Name,
+		Pair:     testPair,
+		Asset:    asset.Spot,
+		Bids:     []buffer.L3Order{{ID: "b1", Price: 4000, Amount: 1}},
+		Asks:     []buffer.L3Order{{ID: "a1", Price: 5000, Amount: 2}},
+		Sequence: 100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []string{
+		// Stale messages preceding the snapshot are dropped
+		`{"type": "done", "product_id": "BTC-USD", "sequence": 99, "order_id": "b1", "reason": "canceled", "side": "buy"}`,
+		`{"type": "open", "product_id": "BTC-USD", "sequence": 101, "order_id": "b2", "price": "4000", "remaining_size": "3", "side": "buy"}`,
+		`{"type": "match", "product_id": "BTC-USD", "sequence": 102, "maker_order_id": "a1", "taker_order_id": "t1", "trade_id": 1, "size": "0.5", "price": "5000", "side": "sell"}`,
+		`{"type": "change", "product_id": "BTC-USD", "sequence": 103, "order_id": "b1", "new_size": "0.5", "old_size": "1", "price": "4000", "side": "buy"}`,
+		// Received orders only advance the sequence
+		`{"type": "received", "product_id": "BTC-USD", "sequence": 104, "order_id": "b3", "size": "1", "price": "3000", "side": "buy", "order_type": "limit"}`,
+		`{"type": "done", "product_id": "BTC-USD", "sequence": 105, "order_id": "b3", "reason": "canceled", "side": "buy"}`,
+	} {
+		if err = c.wsHandleData([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	book, err := c.Websocket.Orderbook.GetOrderbook(testPair, asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(book.Bids) != 1 || book.Bids[0].Amount != 3.5 || len(book.Asks) != 1 || book.Asks[0].Amount != 1.5 {
+		t.Errorf("unexpected orderbook %+v %+v", book.Bids, book.Asks)
+	}
+	pos, err := c.Websocket.Orderbook.GetQueuePosition(testPair, asset.Spot, "b2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos.Index != 1 || pos.Ahead != 0.5 {
+		t.Errorf("unexpected queue position %+v", pos)
+	}
+}
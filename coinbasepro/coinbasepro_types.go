@@ -8,13 +8,15 @@ import (
 
 // Product holds product information
 type Product struct {
-	ID             string      `json:"id"`
-	BaseCurrency   string      `json:"base_currency"`
-	QuoteCurrency  string      `json:"quote_currency"`
-	BaseMinSize    float64     `json:"base_min_size,string"`
-	BaseMaxSize    interface{} `json:"base_max_size"`
-	QuoteIncrement float64     `json:"quote_increment,string"`
-	DisplayName    string      `json:"string"`
+	ID             string  `json:"id"`
+	BaseCurrency   string  `json:"base_currency"`
+	QuoteCurrency  string  `json:"quote_currency"`
+	BaseMinSize    float64 `json:"base_min_size,string"`
+	BaseMaxSize    float64 `json:"base_max_size,string"`
+	BaseIncrement  float64 `json:"base_increment,string"`
+	QuoteIncrement float64 `json:"quote_increment,string"`
+	MinMarketFunds float64 `json:"min_market_funds,string"`
+	DisplayName    string  `json:"string"`
 }
 
 // Ticker holds basic ticker information
@@ -278,6 +280,16 @@ type CoinbaseAccounts struct {
 	} `json:"sep_deposit_information"`
 }
 
+// CryptoAddress holds a generated crypto deposit address
+type CryptoAddress struct {
+	ID             string    `json:"id"`
+	Address        string    `json:"address"`
+	DestinationTag string    `json:"destination_tag"`
+	Network        string    `json:"network"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
 // Report holds historical information
 type Report struct {
 	ID          string    `json:"id"`
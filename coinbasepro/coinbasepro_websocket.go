@@ -25,6 +25,11 @@ import (
 
 const (
 	coinbaseproWebsocketURL = "wss://ws-feed.pro.coinbase.com"
+	// wsL3StageLimit bounds the updates staged per book while its level 3
+	// snapshot is fetched
+	wsL3StageLimit = 10000
+	// wsOrderbookWorkers is the number of books fetched via REST at once
+	wsOrderbookWorkers = 5
 )
 
 // WsConnect initiates a websocket connection
@@ -195,6 +200,7 @@ func (c *CoinbasePro) wsHandleData(respRaw []byte) error {
 				Pair:            p,
 			}
 		}
+		return c.processL3(&wsOrder)
 	case "match":
 		var wsOrder wsOrderReceived
 		err := json.Unmarshal(respRaw, &wsOrder)
@@ -214,6 +220,10 @@ func (c *CoinbasePro) wsHandleData(respRaw []byte) error {
 		if err != nil {
 			return err
 		}
+		err = c.processL3(&wsOrder)
+		if err != nil {
+			return err
+		}
 
 		if wsOrder.UserID != "" {
 			c.Websocket.DataHandler <- &order.Detail{
@@ -271,6 +281,121 @@ func statusToStandardStatus(stat string) (order.Status, error) {
 	}
 }
 
+// processL3 applies order messages from the full channel to the product's
+// level 3 book, loading the book over REST when it is not held. The full
+// channel replaces level2 for a product as both maintain its orderbook.
+func (c *CoinbasePro) processL3(o *wsOrderReceived) error {
+	var u buffer.L3Update
+	switch o.Type {
+	case "received", "activate":
+		// Sequenced but not yet resting
+		u = buffer.L3Update{Action: buffer.L3Sequence}
+	case "open":
+		side, err := order.StringToOrderSide(o.Side)
+		if err != nil {
+			return err
+		}
+		u = buffer.L3Update{Action: buffer.L3Open, Side: side, Price: o.Price, Amount: o.RemainingSize}
+	case "match":
+		u = buffer.L3Update{Action: buffer.L3Match, OrderID: o.MakerOrderID, Amount: o.Size}
+	case "change":
+		u = buffer.L3Update{Action: buffer.L3Change, Price: o.Price, Amount: o.NewSize}
+	case "done":
+		u = buffer.L3Update{Action: buffer.L3Done}
+	default:
+		return nil
+	}
+	p, err := currency.NewPairFromString(o.ProductID)
+	if err != nil {
+		return err
+	}
+	if !c.fullChannelSubscribed(p) {
+		// Order messages from the user channel alone carry no book
+		return nil
+	}
+	if u.OrderID == "" {
+		u.OrderID = o.OrderID
+	}
+	u.Sequence = o.Sequence
+	u.Time = o.Time
+	u.Pair = p
+	u.Asset = asset.Spot
+
+	return c.l3.UpdateL3(&u)
+}
+
+// fullChannelSubscribed reports whether a product's book is kept from the
+// full channel
+func (c *CoinbasePro) fullChannelSubscribed(p currency.Pair) bool {
+	_, err := c.Websocket.GetSubscriptionState(&stream.ChannelSubscription{
+		Channel:  "full",
+		Currency: p,
+		Asset:    asset.Spot,
+	})
+	return err == nil
+}
+
+// resyncOrderbook resynchronises a websocket orderbook once it falls out of
+// sync. Level 3 books are fetched via REST, level2 updates carry no sequence
+// to splice a REST snapshot with so the channel is resubscribed for a fresh
+// snapshot instead.
+func (c *CoinbasePro) resyncOrderbook(p currency.Pair, a asset.Item) {
+	if c.fullChannelSubscribed(p) {
+		c.l3.Resync(p, a)
+		return
+	}
+	fpair, err := c.FormatExchangeCurrency(p, a)
+	if err == nil {
+		err = c.Websocket.ResubscribeToChannel(&stream.ChannelSubscription{
+			Channel:  "level2",
+			Currency: fpair,
+			Asset:    a,
+		})
+	}
+	if err != nil {
+		c.Websocket.DataHandler <- fmt.Errorf("%s resubscribe to orderbook %s error %w", c.Name, p, err)
+	}
+}
+
+// fetchL3Snapshot fetches a product's level 3 book from REST
+func (c *CoinbasePro) fetchL3Snapshot(p currency.Pair, a asset.Item) (*buffer.L3Snapshot, error) {
+	fpair, err := c.FormatExchangeCurrency(p, a)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.GetOrderbook(fpair.String(), 3)
+	if err != nil {
+		return nil, err
+	}
+	book, ok := resp.(OrderbookL3)
+	if !ok {
+		return nil, errors.New("coinbasepro_websocket.go error - unexpected level 3 orderbook response")
+	}
+	snapshot := buffer.L3Snapshot{
+		Exchange:        c.Name,
+		Pair:            p,
+		Asset:           a,
+		Sequence:        book.Sequence,
+		LastUpdated:     time.Now(),
+		VerifyOrderbook: c.CanVerifyOrderbook,
+	}
+	for i := range book.Bids {
+		snapshot.Bids = append(snapshot.Bids, buffer.L3Order{
+			ID:     book.Bids[i].OrderID,
+			Price:  book.Bids[i].Price,
+			Amount: book.Bids[i].Amount,
+		})
+	}
+	for i := range book.Asks {
+		snapshot.Asks = append(snapshot.Asks, buffer.L3Order{
+			ID:     book.Asks[i].OrderID,
+			Price:  book.Asks[i].Price,
+			Amount: book.Asks[i].Amount,
+		})
+	}
+	return &snapshot, nil
+}
+
 // ProcessSnapshot processes the initial orderbook snap shot
 func (c *CoinbasePro) ProcessSnapshot(snapshot *WebsocketOrderbookSnapshot) error {
 	var base orderbook.Base
@@ -414,7 +539,7 @@ subscriptions:
 
 		if channelsToSubscribe[i].Channel == "user" ||
 			channelsToSubscribe[i].Channel == "full" {
-			n := strconv.FormatInt(time.Now().Unix(), 10)
+			n := strconv.FormatInt(c.Clock.Now().Unix(), 10)
 			message := n + http.MethodGet + "/users/self/verify"
 			hmac := crypto.GetHMAC(crypto.HashSHA256, []byte(message),
 				[]byte(c.API.Credentials.Secret))
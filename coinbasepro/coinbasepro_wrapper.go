@@ -14,6 +14,7 @@ import (
 	"github.com/openware/irix/portfolio/withdraw"
 	"github.com/openware/irix/protocol"
 	"github.com/openware/irix/stream"
+	"github.com/openware/irix/stream/buffer"
 	"github.com/openware/irix/ticker"
 	"github.com/openware/pkg/account"
 	"github.com/openware/pkg/asset"
@@ -157,6 +158,7 @@ func (c *CoinbasePro) Setup(exch *config.ExchangeConfig) error {
 	if err != nil {
 		return err
 	}
+	c.SetServerTimeSource(c.serverTime)
 
 	wsRunningURL, err := c.API.Endpoints.GetURL(exchange.WebsocketSpot)
 	if err != nil {
@@ -179,11 +181,20 @@ func (c *CoinbasePro) Setup(exch *config.ExchangeConfig) error {
 		OrderbookBufferLimit:             exch.OrderbookConfig.WebsocketBufferLimit,
 		BufferEnabled:                    exch.OrderbookConfig.WebsocketBufferEnabled,
 		SortBuffer:                       true,
+		OrderbookResync:                  c.resyncOrderbook,
 	})
 	if err != nil {
 		return err
 	}
 
+	c.l3, err = buffer.NewL3SyncManager(&c.Websocket.Orderbook,
+		c.fetchL3Snapshot,
+		wsL3StageLimit,
+		wsOrderbookWorkers)
+	if err != nil {
+		return err
+	}
+
 	return c.Websocket.SetupNewConnection(stream.ConnectionSetup{
 		ResponseCheckTimeout: exch.WebsocketResponseCheckTimeout,
 		ResponseMaxLimit:     exch.WebsocketResponseMaxLimit,
@@ -263,6 +274,20 @@ func (c *CoinbasePro) Run() {
 		}
 	}
 
+	a := c.GetAssetTypes()
+	for x := range a {
+		if err = c.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
+			err = c.UpdateOrderExecutionLimits(a[x])
+			c.SetOrderExecutionLimitsLoadError(a[x], err)
+			if err != nil {
+				log.Errorf(log.ExchangeSys,
+					"Could not set %s exchange exchange limits: %v",
+					c.Name,
+					err)
+			}
+		}
+	}
+
 	if !c.GetEnabledFeatures().AutoPairUpdates && !forceUpdate {
 		return
 	}
@@ -507,6 +532,13 @@ func (c *CoinbasePro) SubmitOrder(s *order.Submit) (order.SubmitResponse, error)
 	if err := s.Validate(); err != nil {
 		return submitOrderResponse, err
 	}
+	if err := exchange.AttachClientOrderID(c, s); err != nil {
+		return submitOrderResponse, err
+	}
+	if err := c.ConformOrderToLimits(s); err != nil {
+		return submitOrderResponse, err
+	}
+	price, amount := c.SubmitValues(s)
 
 	fpair, err := c.FormatExchangeCurrency(s.Pair, asset.Spot)
 	if err != nil {
@@ -516,16 +548,16 @@ func (c *CoinbasePro) SubmitOrder(s *order.Submit) (order.SubmitResponse, error)
 	var response string
 	switch s.Type {
 	case order.Market:
-		response, err = c.PlaceMarketOrder("",
+		response, err = c.PlaceMarketOrder(s.ClientOrderID,
 			s.Amount,
 			s.Amount,
 			s.Side.Lower(),
 			fpair.String(),
 			"")
 	case order.Limit:
-		response, err = c.PlaceLimitOrder("",
-			s.Price,
-			s.Amount,
+		response, err = c.PlaceLimitOrder(s.ClientOrderID,
+			price,
+			amount,
 			s.Side.Lower(),
 			"",
 			"",
@@ -582,9 +614,34 @@ func (c *CoinbasePro) GetOrderInfo(orderID string, pair currency.Pair, assetType
 	if errGo != nil {
 		return order.Detail{}, fmt.Errorf("error retrieving order %s : %s", orderID, errGo)
 	}
-	od, errOd := time.Parse(time.RFC3339, genOrderDetail.DoneAt)
-	if errOd != nil {
-		return order.Detail{}, fmt.Errorf("error parsing order done at time: %s", errOd)
+	return c.getOrderDetail(&genOrderDetail)
+}
+
+// GetOrderInfoByClientOrderID returns order information based on the client
+// order ID supplied on submission
+func (c *CoinbasePro) GetOrderInfoByClientOrderID(clientOrderID string, pair currency.Pair, assetType asset.Item) (order.Detail, error) {
+	if clientOrderID == "" {
+		return order.Detail{}, order.ErrOrderIDNotSet
+	}
+	genOrderDetail, err := c.GetOrderByClientOID(clientOrderID)
+	if err != nil {
+		if strings.Contains(err.Error(), orderNotFoundMsg) {
+			return order.Detail{}, fmt.Errorf("%w: %v", exchange.ErrOrderNotFound, err)
+		}
+		return order.Detail{}, fmt.Errorf("error retrieving client order %s : %s", clientOrderID, err)
+	}
+	return c.getOrderDetail(&genOrderDetail)
+}
+
+// getOrderDetail converts an order response and its fills to an order detail
+func (c *CoinbasePro) getOrderDetail(genOrderDetail *GeneralizedOrderResponse) (order.Detail, error) {
+	od := genOrderDetail.CreatedAt
+	if genOrderDetail.DoneAt != "" {
+		var errOd error
+		od, errOd = time.Parse(time.RFC3339, genOrderDetail.DoneAt)
+		if errOd != nil {
+			return order.Detail{}, fmt.Errorf("error parsing order done at time: %s", errOd)
+		}
 	}
 	os, errOs := order.StringToOrderStatus(genOrderDetail.Status)
 	if errOs != nil {
@@ -606,6 +663,7 @@ func (c *CoinbasePro) GetOrderInfo(orderID string, pair currency.Pair, assetType
 	response := order.Detail{
 		Exchange:        c.GetName(),
 		ID:              genOrderDetail.ID,
+		AssetType:       asset.Spot,
 		Pair:            p,
 		Side:            ss,
 		Type:            tt,
@@ -617,7 +675,7 @@ func (c *CoinbasePro) GetOrderInfo(orderID string, pair currency.Pair, assetType
 		RemainingAmount: genOrderDetail.Size - genOrderDetail.FilledSize,
 		Fee:             genOrderDetail.FillFees,
 	}
-	fillResponse, errGF := c.GetFills(orderID, genOrderDetail.ProductID)
+	fillResponse, errGF := c.GetFills(genOrderDetail.ID, genOrderDetail.ProductID)
 	if errGF != nil {
 		return response, fmt.Errorf("error retrieving the order fills: %s", errGF)
 	}
@@ -641,8 +699,29 @@ func (c *CoinbasePro) GetOrderInfo(orderID string, pair currency.Pair, assetType
 }
 
 // GetDepositAddress returns a deposit address for a specified currency
+// accountID is the coinbase account to deposit to, when empty the account of
+// the currency is used
 func (c *CoinbasePro) GetDepositAddress(cryptocurrency currency.Code, accountID string) (string, error) {
-	return "", common.ErrFunctionNotSupported
+	if accountID == "" {
+		accounts, err := c.GetCoinbaseAccounts()
+		if err != nil {
+			return "", err
+		}
+		for x := range accounts {
+			if accounts[x].Active && cryptocurrency.Match(currency.NewCode(accounts[x].Currency)) {
+				accountID = accounts[x].ID
+				break
+			}
+		}
+		if accountID == "" {
+			return "", fmt.Errorf("%w %s", errCoinbaseAccountNotFound, cryptocurrency)
+		}
+	}
+	address, err := c.GenerateCryptoAddress(accountID)
+	if err != nil {
+		return "", err
+	}
+	return address.Address, nil
 }
 
 // WithdrawCryptocurrencyFunds returns a withdrawal ID when a withdrawal is
@@ -954,3 +1033,15 @@ func (c *CoinbasePro) ValidateCredentials(assetType asset.Item) error {
 	_, err := c.UpdateAccountInfo(assetType)
 	return c.CheckTransientError(err)
 }
+
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (c *CoinbasePro) UpdateOrderExecutionLimits(a asset.Item) error {
+	if a != asset.Spot {
+		return fmt.Errorf("%s %w", a, asset.ErrNotSupported)
+	}
+	limits, err := c.FetchExchangeLimits()
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return c.LoadLimits(limits)
+}
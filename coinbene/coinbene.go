@@ -14,8 +14,10 @@ import (
 	"time"
 
 	exchange "github.com/openware/irix"
+	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/common"
 	"github.com/openware/pkg/common/crypto"
+	"github.com/openware/pkg/currency"
 	"github.com/openware/pkg/order"
 	"github.com/openware/pkg/request"
 )
@@ -85,6 +87,30 @@ func (c *Coinbene) GetAllPairs() ([]PairData, error) {
 	return resp.Data, c.SendHTTPRequest(exchange.RestSpot, path, spotPairs, &resp)
 }
 
+// FetchExchangeLimits fetches spot order execution limits
+func (c *Coinbene) FetchExchangeLimits() ([]order.MinMaxLevel, error) {
+	pairs, err := c.GetAllPairs()
+	if err != nil {
+		return nil, err
+	}
+	limits := make([]order.MinMaxLevel, 0, len(pairs))
+	for x := range pairs {
+		var cp currency.Pair
+		cp, err = currency.NewPairFromStrings(pairs[x].BaseAsset, pairs[x].QuoteAsset)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, order.MinMaxLevel{
+			Pair:       cp,
+			Asset:      asset.Spot,
+			StepPrice:  exchange.StepFromDecimalPlaces(pairs[x].PricePrecision),
+			MinAmount:  pairs[x].MinAmount,
+			StepAmount: exchange.StepFromDecimalPlaces(pairs[x].AmountPrecision),
+		})
+	}
+	return limits, nil
+}
+
 // GetPairInfo gets info about a single pair
 func (c *Coinbene) GetPairInfo(symbol string) (PairData, error) {
 	resp := struct {
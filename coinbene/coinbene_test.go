@@ -779,3 +779,14 @@ func TestGetHistoricTrades(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	err := c.UpdateOrderExecutionLimits(asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.GetOrderExecutionLimits(asset.Spot, currency.NewPair(currency.BTC, currency.USDT)); err != nil {
+		t.Fatal(err)
+	}
+}
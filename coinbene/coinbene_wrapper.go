@@ -227,6 +227,20 @@ func (c *Coinbene) Run() {
 		c.PrintEnabledPairs()
 	}
 
+	a := c.GetAssetTypes()
+	for x := range a {
+		if err := c.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
+			err = c.UpdateOrderExecutionLimits(a[x])
+			c.SetOrderExecutionLimitsLoadError(a[x], err)
+			if err != nil {
+				log.Errorf(log.ExchangeSys,
+					"Could not set %s exchange exchange limits: %v",
+					c.Name,
+					err)
+			}
+		}
+	}
+
 	if !c.GetEnabledFeatures().AutoPairUpdates {
 		return
 	}
@@ -568,6 +582,12 @@ func (c *Coinbene) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 	if err := s.Validate(); err != nil {
 		return resp, err
 	}
+	if err := c.ConformOrderToLimits(s); err != nil {
+		return resp, err
+	}
+	if err := exchange.AttachClientOrderID(c, s); err != nil {
+		return resp, err
+	}
 
 	if s.Side != order.Buy && s.Side != order.Sell {
 		return resp,
@@ -585,7 +605,7 @@ func (c *Coinbene) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 		fpair.String(),
 		s.Side.String(),
 		s.Type.String(),
-		s.ClientID,
+		s.ClientOrderID,
 		0)
 	if err != nil {
 		return resp, err
@@ -941,3 +961,15 @@ func (c *Coinbene) GetHistoricCandles(pair currency.Pair, a asset.Item, start, e
 func (c *Coinbene) GetHistoricCandlesExtended(pair currency.Pair, a asset.Item, start, end time.Time, interval kline.Interval) (kline.Item, error) {
 	return c.GetHistoricCandles(pair, a, start, end, interval)
 }
+
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (c *Coinbene) UpdateOrderExecutionLimits(a asset.Item) error {
+	if a != asset.Spot {
+		return fmt.Errorf("%s %w", a, asset.ErrNotSupported)
+	}
+	limits, err := c.FetchExchangeLimits()
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return c.LoadLimits(limits)
+}
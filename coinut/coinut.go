@@ -77,6 +77,31 @@ func (c *COINUT) GetInstruments() (Instruments, error) {
 	return result, c.SendHTTPRequest(exchange.RestSpot, coinutInstruments, params, false, &result)
 }
 
+// FetchExchangeLimits fetches spot order execution limits, instruments only
+// provide a price precision
+func (c *COINUT) FetchExchangeLimits() ([]order.MinMaxLevel, error) {
+	instruments, err := c.GetInstruments()
+	if err != nil {
+		return nil, err
+	}
+	var limits []order.MinMaxLevel
+	for _, list := range instruments.Instruments {
+		for x := range list {
+			var cp currency.Pair
+			cp, err = currency.NewPairFromStrings(list[x].Base, list[x].Quote)
+			if err != nil {
+				return nil, err
+			}
+			limits = append(limits, order.MinMaxLevel{
+				Pair:      cp,
+				Asset:     asset.Spot,
+				StepPrice: exchange.StepFromDecimalPlaces(int64(list[x].DecimalPlaces)),
+			})
+		}
+	}
+	return limits, nil
+}
+
 // GetInstrumentTicker returns a ticker for a specific instrument
 func (c *COINUT) GetInstrumentTicker(instrumentID int64) (Ticker, error) {
 	var result Ticker
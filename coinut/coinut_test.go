@@ -1146,3 +1146,14 @@ func TestGetHistoricTrades(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	err := c.UpdateOrderExecutionLimits(asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.GetOrderExecutionLimits(asset.Spot, currency.NewPair(currency.LTC, currency.BTC)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -243,6 +243,20 @@ func (c *COINUT) Run() {
 		}
 	}
 
+	a := c.GetAssetTypes()
+	for x := range a {
+		if err := c.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
+			err = c.UpdateOrderExecutionLimits(a[x])
+			c.SetOrderExecutionLimitsLoadError(a[x], err)
+			if err != nil {
+				log.Errorf(log.ExchangeSys,
+					"Could not set %s exchange exchange limits: %v",
+					c.Name,
+					err)
+			}
+		}
+	}
+
 	if !c.GetEnabledFeatures().AutoPairUpdates && !forceUpdate {
 		return
 	}
@@ -571,6 +585,9 @@ func (c *COINUT) SubmitOrder(o *order.Submit) (order.SubmitResponse, error) {
 	if err := o.Validate(); err != nil {
 		return order.SubmitResponse{}, err
 	}
+	if err := c.ConformOrderToLimits(o); err != nil {
+		return order.SubmitResponse{}, err
+	}
 
 	var submitOrderResponse order.SubmitResponse
 	var err error
@@ -1077,3 +1094,15 @@ func (c *COINUT) GetHistoricCandles(pair currency.Pair, a asset.Item, start, end
 func (c *COINUT) GetHistoricCandlesExtended(pair currency.Pair, a asset.Item, start, end time.Time, interval kline.Interval) (kline.Item, error) {
 	return kline.Item{}, common.ErrFunctionNotSupported
 }
+
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (c *COINUT) UpdateOrderExecutionLimits(a asset.Item) error {
+	if a != asset.Spot {
+		return fmt.Errorf("%s %w", a, asset.ErrNotSupported)
+	}
+	limits, err := c.FetchExchangeLimits()
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return c.LoadLimits(limits)
+}
@@ -330,6 +330,9 @@ type FeaturesEnabledConfig struct {
 	AutoPairUpdates bool `json:"autoPairUpdates"`
 	Websocket       bool `json:"websocketAPI"`
 	SaveTradeData   bool `json:"saveTradeData"`
+	// RoundOrdersToLimits rounds order prices and amounts to the loaded tick
+	// and lot sizes before they are validated and submitted
+	RoundOrdersToLimits bool `json:"roundOrdersToLimits"`
 }
 
 // FeaturesConfig stores the exchanges supported and enabled features
@@ -375,6 +378,31 @@ type APIConfig struct {
 	CredentialsValidator *APICredentialsValidatorConfig `json:"credentialsValidator,omitempty"`
 	OldEndPoints         *APIEndpointsConfig            `json:"endpoints,omitempty"`
 	Endpoints            map[string]string              `json:"urlEndpoints"`
+	Nonce                *NonceConfig                   `json:"nonce,omitempty"`
+	RateLimit            *RateLimitConfig               `json:"rateLimit,omitempty"`
+}
+
+// NonceConfig stores where authenticated request nonces are persisted and the
+// format they are issued in
+type NonceConfig struct {
+	// Store is memory, file or socket
+	Store string `json:"store,omitempty"`
+	// Path is the directory of a file store or the Unix socket of a socket
+	// store
+	Path string `json:"path,omitempty"`
+	// Format is s, ms, us, ns or counter and overrides the exchange default
+	Format string `json:"format,omitempty"`
+}
+
+// RateLimitConfig stores where the rate limit state learnt from exchange
+// responses is kept, processes sharing a file or socket store share one budget
+// per API key
+type RateLimitConfig struct {
+	// Store is memory, file or socket
+	Store string `json:"store,omitempty"`
+	// Path is the directory of a file store or the Unix socket of a socket
+	// store
+	Path string `json:"path,omitempty"`
 }
 
 // OrderbookConfig stores the orderbook configuration variables
@@ -0,0 +1,486 @@
+// Package conformance checks that an exchange wrapper keeps the promises of
+// the IBotExchange interface. Every REST endpoint is pointed at a local replay
+// server so the suite runs offline, checks which need market data use the
+// recorded VCR fixtures when supplied and are skipped otherwise.
+package conformance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	exchange "github.com/openware/irix"
+	"github.com/openware/irix/config"
+	"github.com/openware/irix/portfolio/withdraw"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/common"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
+)
+
+// Fixture describes an exchange under test
+type Fixture struct {
+	Exchange exchange.IBotExchange
+	// Config is the exchange configuration, when nil a configuration is built
+	// from the exchange defaults without fetching tradable pairs
+	Config *config.ExchangeConfig
+	// MockFile is an optional VCR recording in the mock package format which
+	// is replayed for REST requests
+	MockFile string
+	// Order enables order lifecycle checks. The order is submitted, looked up
+	// and cancelled, so it should only be set for simulated exchanges or
+	// recordings which include the order endpoints.
+	Order *order.Submit
+	// Prepare is called once the exchange is set up and before any check
+	// runs, used to fund simulated accounts
+	Prepare func(exchange.IBotExchange) error
+}
+
+// LoadConfig reads a single exchange configuration file
+func LoadConfig(path string) (*config.ExchangeConfig, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg config.ExchangeConfig
+	return &cfg, json.Unmarshal(contents, &cfg)
+}
+
+// Run sets up the exchange against a local replay server and runs every
+// conformance check as a subtest
+func Run(t *testing.T, f *Fixture) {
+	t.Helper()
+	if f == nil || f.Exchange == nil {
+		t.Fatal("conformance fixture exchange not set")
+	}
+	if err := setup(t, f); err != nil {
+		t.Fatal(err)
+	}
+	if f.Prepare != nil {
+		if err := f.Prepare(f.Exchange); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Run("PairFormatting", func(t *testing.T) { checkPairFormatting(t, f.Exchange) })
+	t.Run("Orderbook", func(t *testing.T) { checkOrderbook(t, f.Exchange) })
+	t.Run("Ticker", func(t *testing.T) { checkTicker(t, f.Exchange) })
+	t.Run("OrderValidation", func(t *testing.T) { checkOrderValidation(t, f.Exchange) })
+	t.Run("UnsupportedFeatures", func(t *testing.T) { checkUnsupportedFeatures(t, f.Exchange) })
+	if f.Order != nil {
+		t.Run("OrderLifecycle", func(t *testing.T) { checkOrderLifecycle(t, f.Exchange, f.Order) })
+	}
+}
+
+// setup configures the exchange and redirects its REST endpoints to the
+// replay server. Endpoints are redirected before setup, as some exchanges
+// fetch metadata while setting up, and again afterwards in case the config
+// overrides them.
+func setup(t *testing.T, f *Fixture) error {
+	e := f.Exchange
+	e.SetDefaults()
+	b := e.GetBase()
+	cfg := f.Config
+	if cfg == nil {
+		cfg = &config.ExchangeConfig{
+			Name:           b.Name,
+			HTTPTimeout:    exchange.DefaultHTTPTimeout,
+			BaseCurrencies: b.BaseCurrencies,
+		}
+		if err := b.SetupDefaults(cfg); err != nil {
+			return err
+		}
+	}
+	cfg.Enabled = true
+	cfg.Verbose = false
+	if cfg.Features == nil {
+		cfg.Features = &config.FeaturesConfig{}
+	}
+	cfg.Features.Enabled.Websocket = false
+	if cfg.WebsocketTrafficTimeout < time.Second {
+		cfg.WebsocketTrafficTimeout = time.Second * 30
+	}
+
+	srv, err := newReplayServer(f.MockFile)
+	if err != nil {
+		return err
+	}
+	t.Cleanup(srv.Close)
+	defaults := redirect(b, srv, nil)
+	if err = e.Setup(cfg); err != nil {
+		return fmt.Errorf("%s setup: %w", b.Name, err)
+	}
+	redirect(b, srv, defaults)
+	b.Verbose = false
+	b.SkipAuthCheck = true
+	// Replayed requests are local so rate limiting only slows the suite down
+	_ = e.DisableRateLimiter()
+	return nil
+}
+
+// redirect points every REST endpoint at the replay server keeping the path
+// of the original URL so recordings match. It returns the original URLs so a
+// second pass ignores URLs the config has overridden.
+func redirect(b *exchange.Base, srv *replayServer, defaults map[string]string) map[string]string {
+	b.SetHTTPClient(srv.Client())
+	if b.API.Endpoints == nil {
+		return nil
+	}
+	if defaults == nil {
+		defaults = b.API.Endpoints.GetURLMap()
+	}
+	for k, v := range defaults {
+		if strings.Contains(k, "Websocket") {
+			continue
+		}
+		// Only the preferred URL is replayed, failover URLs share its paths
+		v = strings.Split(v, exchange.EndpointListSeparator)[0]
+		var path string
+		if u, err := url.Parse(v); err == nil {
+			path = strings.TrimSuffix(u.Path, "/")
+		}
+		// The key came from the endpoint map so setting it cannot fail
+		_ = b.API.Endpoints.SetRunning(k, srv.URL+path)
+	}
+	return defaults
+}
+
+// isUnsupported reports whether an error is one of the standard errors for
+// functionality an exchange does not provide. ErrNotYetImplemented marks
+// functionality the exchange offers which the wrapper does not yet cover.
+func isUnsupported(err error) bool {
+	return errors.Is(err, common.ErrFunctionNotSupported) ||
+		errors.Is(err, common.ErrNotYetImplemented)
+}
+
+// call runs fn and reports a panic as a test failure so one misbehaving
+// method does not abort the suite
+func call(t *testing.T, name string, fn func() error) (err error) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("%s panicked: %v", name, r)
+			err = fmt.Errorf("%s panicked", name)
+		}
+	}()
+	return fn()
+}
+
+// firstPair returns the first enabled pair of an asset
+func firstPair(e exchange.IBotExchange, a asset.Item) (currency.Pair, bool) {
+	pairs, err := e.GetEnabledPairs(a)
+	if err != nil || len(pairs) == 0 {
+		return currency.Pair{}, false
+	}
+	return pairs[0], true
+}
+
+// checkPairFormatting verifies that every enabled pair formats to a request
+// symbol which resolves back to the same pair
+func checkPairFormatting(t *testing.T, e exchange.IBotExchange) {
+	b := e.GetBase()
+	assets := e.GetAssetTypes()
+	var checked int
+	for x := range assets {
+		pairs, err := e.GetEnabledPairs(assets[x])
+		if err != nil {
+			continue
+		}
+		for y := range pairs {
+			checked++
+			symbol, err := b.FormatSymbol(pairs[y], assets[x])
+			if err != nil {
+				t.Errorf("%s %s FormatSymbol: %v", assets[x], pairs[y], err)
+				continue
+			}
+			fPair, err := b.FormatExchangeCurrency(pairs[y], assets[x])
+			if err != nil {
+				t.Errorf("%s %s FormatExchangeCurrency: %v", assets[x], pairs[y], err)
+				continue
+			}
+			if fPair.String() != symbol {
+				t.Errorf("%s %s FormatExchangeCurrency %s differs from FormatSymbol %s",
+					assets[x], pairs[y], fPair, symbol)
+			}
+			back, _, err := b.GetRequestFormattedPairAndAssetType(symbol)
+			if err != nil {
+				t.Errorf("%s %s symbol %s does not resolve: %v", assets[x], pairs[y], symbol, err)
+				continue
+			}
+			if !back.Equal(pairs[y]) {
+				t.Errorf("%s %s symbol %s resolves to %s", assets[x], pairs[y], symbol, back)
+			}
+		}
+	}
+	if checked == 0 {
+		t.Skip("no enabled pairs")
+	}
+}
+
+// checkOrderbook verifies the orderbook of the first enabled pair of each
+// asset is sorted, positive and uncrossed
+func checkOrderbook(t *testing.T, e exchange.IBotExchange) {
+	supported := e.GetBase().Features.Supports.RESTCapabilities.OrderbookFetching
+	assets := e.GetAssetTypes()
+	var checked int
+	for x := range assets {
+		cp, ok := firstPair(e, assets[x])
+		if !ok {
+			continue
+		}
+		ob, err := e.UpdateOrderbook(cp, assets[x])
+		if err != nil {
+			if supported && errors.Is(err, common.ErrFunctionNotSupported) {
+				t.Errorf("%s %s orderbook fetching is flagged as supported but returned %v", assets[x], cp, err)
+			}
+			continue
+		}
+		checked++
+		if ob.Exchange != e.GetName() || ob.Asset != assets[x] || !ob.Pair.Equal(cp) {
+			t.Errorf("%s %s orderbook identifies as %s %s %s", assets[x], cp, ob.Exchange, ob.Asset, ob.Pair)
+		}
+		for i := range ob.Bids {
+			if ob.Bids[i].Price <= 0 || ob.Bids[i].Amount <= 0 {
+				t.Errorf("%s %s bid %d has price %v amount %v", assets[x], cp, i, ob.Bids[i].Price, ob.Bids[i].Amount)
+			}
+			if i > 0 && ob.Bids[i].Price >= ob.Bids[i-1].Price {
+				t.Errorf("%s %s bids not sorted descending at %d", assets[x], cp, i)
+			}
+		}
+		for i := range ob.Asks {
+			if ob.Asks[i].Price <= 0 || ob.Asks[i].Amount <= 0 {
+				t.Errorf("%s %s ask %d has price %v amount %v", assets[x], cp, i, ob.Asks[i].Price, ob.Asks[i].Amount)
+			}
+			if i > 0 && ob.Asks[i].Price <= ob.Asks[i-1].Price {
+				t.Errorf("%s %s asks not sorted ascending at %d", assets[x], cp, i)
+			}
+		}
+		if len(ob.Bids) > 0 && len(ob.Asks) > 0 && ob.Bids[0].Price >= ob.Asks[0].Price {
+			t.Errorf("%s %s orderbook crossed, bid %v ask %v", assets[x], cp, ob.Bids[0].Price, ob.Asks[0].Price)
+		}
+	}
+	if checked == 0 {
+		t.Skip("no recorded orderbook")
+	}
+}
+
+// checkTicker verifies the ticker of the first enabled pair of each asset is
+// identified correctly and its prices are consistent
+func checkTicker(t *testing.T, e exchange.IBotExchange) {
+	supported := e.GetBase().Features.Supports.RESTCapabilities.TickerFetching
+	assets := e.GetAssetTypes()
+	var checked int
+	for x := range assets {
+		cp, ok := firstPair(e, assets[x])
+		if !ok {
+			continue
+		}
+		tick, err := e.UpdateTicker(cp, assets[x])
+		if err != nil {
+			if supported && errors.Is(err, common.ErrFunctionNotSupported) {
+				t.Errorf("%s %s ticker fetching is flagged as supported but returned %v", assets[x], cp, err)
+			}
+			continue
+		}
+		checked++
+		if tick.ExchangeName != e.GetName() || tick.AssetType != assets[x] || !tick.Pair.Equal(cp) {
+			t.Errorf("%s %s ticker identifies as %s %s %s", assets[x], cp, tick.ExchangeName, tick.AssetType, tick.Pair)
+		}
+		for name, v := range map[string]float64{
+			"last": tick.Last, "bid": tick.Bid, "ask": tick.Ask,
+			"high": tick.High, "low": tick.Low, "volume": tick.Volume,
+		} {
+			if v < 0 {
+				t.Errorf("%s %s ticker %s is negative: %v", assets[x], cp, name, v)
+			}
+		}
+		if tick.Bid > 0 && tick.Ask > 0 && tick.Bid > tick.Ask {
+			t.Errorf("%s %s ticker bid %v above ask %v", assets[x], cp, tick.Bid, tick.Ask)
+		}
+		if tick.High > 0 && tick.Low > 0 && tick.Low > tick.High {
+			t.Errorf("%s %s ticker low %v above high %v", assets[x], cp, tick.Low, tick.High)
+		}
+	}
+	if checked == 0 {
+		t.Skip("no recorded ticker")
+	}
+}
+
+// checkOrderValidation verifies that invalid order requests are rejected with
+// the standard order errors before anything is sent
+func checkOrderValidation(t *testing.T, e exchange.IBotExchange) {
+	if err := call(t, "SubmitOrder", func() error {
+		_, err := e.SubmitOrder(nil)
+		return err
+	}); !errors.Is(err, order.ErrSubmissionIsNil) && !isUnsupported(err) {
+		t.Errorf("SubmitOrder nil expected %v, received %v", order.ErrSubmissionIsNil, err)
+	}
+	if err := call(t, "SubmitOrder", func() error {
+		_, err := e.SubmitOrder(&order.Submit{})
+		return err
+	}); !errors.Is(err, order.ErrPairIsEmpty) && !isUnsupported(err) {
+		t.Errorf("SubmitOrder empty expected %v, received %v", order.ErrPairIsEmpty, err)
+	}
+	if err := call(t, "CancelOrder", func() error {
+		return e.CancelOrder(nil)
+	}); !errors.Is(err, order.ErrCancelOrderIsNil) && !isUnsupported(err) {
+		t.Errorf("CancelOrder nil expected %v, received %v", order.ErrCancelOrderIsNil, err)
+	}
+	if err := call(t, "ModifyOrder", func() error {
+		_, err := e.ModifyOrder(nil)
+		return err
+	}); !errors.Is(err, order.ErrModifyOrderIsNil) && !isUnsupported(err) {
+		t.Errorf("ModifyOrder nil expected %v, received %v", order.ErrModifyOrderIsNil, err)
+	}
+}
+
+// checkUnsupportedFeatures verifies that functionality flagged as unsupported
+// never succeeds and that flagged functionality never returns
+// ErrFunctionNotSupported. Flagged functionality may still return
+// ErrNotYetImplemented while the wrapper is incomplete.
+func checkUnsupportedFeatures(t *testing.T, e exchange.IBotExchange) {
+	features := e.GetBase().Features.Supports.RESTCapabilities
+	a := asset.Spot
+	if assets := e.GetAssetTypes(); len(assets) > 0 && !e.SupportsAsset(a) {
+		a = assets[0]
+	}
+	cp, _ := firstPair(e, a)
+	withdrawal := &withdraw.Request{
+		Exchange: e.GetName(),
+		Currency: currency.BTC,
+		Amount:   1,
+		Type:     withdraw.Crypto,
+		Crypto:   withdraw.CryptoRequest{Address: "conformance"},
+	}
+	for _, tc := range []struct {
+		name      string
+		supported bool
+		fn        func() error
+	}{
+		{"GetDepositAddress", features.CryptoDeposit, func() error {
+			_, err := e.GetDepositAddress(currency.BTC, "")
+			return err
+		}},
+		{"WithdrawCryptocurrencyFunds", features.CryptoWithdrawal, func() error {
+			_, err := e.WithdrawCryptocurrencyFunds(withdrawal)
+			return err
+		}},
+		{"WithdrawFiatFunds", features.FiatWithdraw, func() error {
+			_, err := e.WithdrawFiatFunds(withdrawal)
+			return err
+		}},
+		{"GetWithdrawalsHistory", features.WithdrawalHistory, func() error {
+			_, err := e.GetWithdrawalsHistory(currency.BTC)
+			return err
+		}},
+		{"ModifyOrder", features.ModifyOrder, func() error {
+			_, err := e.ModifyOrder(&order.Modify{
+				ID:        "conformance",
+				Pair:      cp,
+				AssetType: a,
+				Price:     1,
+				Amount:    1,
+			})
+			return err
+		}},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := call(t, tc.name, tc.fn)
+			switch {
+			case tc.supported && errors.Is(err, common.ErrFunctionNotSupported):
+				t.Errorf("%s is flagged as supported but returned %v", tc.name, err)
+			case !tc.supported && err == nil:
+				t.Errorf("%s is flagged as unsupported but succeeded", tc.name)
+			case !tc.supported && !isUnsupported(err):
+				t.Logf("%s is flagged as unsupported but returned a non standard error: %v", tc.name, err)
+			}
+		})
+	}
+}
+
+// checkOrderLifecycle submits an order then verifies it can be retrieved,
+// cancelled exactly once and is reported in the order history afterwards
+func checkOrderLifecycle(t *testing.T, e exchange.IBotExchange, s *order.Submit) {
+	submission := *s
+	resp, err := e.SubmitOrder(&submission)
+	if err != nil {
+		t.Fatalf("SubmitOrder: %v", err)
+	}
+	if !resp.IsOrderPlaced || resp.OrderID == "" {
+		t.Fatalf("SubmitOrder returned no placed order ID: %+v", resp)
+	}
+
+	d, err := e.GetOrderInfo(resp.OrderID, s.Pair, s.AssetType)
+	if err != nil {
+		t.Fatalf("GetOrderInfo: %v", err)
+	}
+	if d.ID != resp.OrderID || !d.Pair.Equal(s.Pair) || d.Side != s.Side {
+		t.Errorf("GetOrderInfo returned %s %s %s for %s %s %s", d.ID, d.Pair, d.Side, resp.OrderID, s.Pair, s.Side)
+	}
+	if s.ClientOrderID != "" {
+		byClient, err := e.GetOrderInfoByClientOrderID(s.ClientOrderID, s.Pair, s.AssetType)
+		if err != nil {
+			t.Errorf("GetOrderInfoByClientOrderID: %v", err)
+		} else if byClient.ID != resp.OrderID {
+			t.Errorf("GetOrderInfoByClientOrderID returned %s, expected %s", byClient.ID, resp.OrderID)
+		}
+	}
+	if _, err = e.GetOrderInfo("conformance-missing", s.Pair, s.AssetType); !errors.Is(err, exchange.ErrOrderNotFound) {
+		t.Errorf("GetOrderInfo unknown order expected %v, received %v", exchange.ErrOrderNotFound, err)
+	}
+	if d.Status != order.New && d.Status != order.PartiallyFilled {
+		t.Skipf("order is %s and cannot be cancelled", d.Status)
+	}
+
+	active, err := e.GetActiveOrders(&order.GetOrdersRequest{AssetType: s.AssetType, Pairs: currency.Pairs{s.Pair}})
+	if err != nil {
+		t.Fatalf("GetActiveOrders: %v", err)
+	}
+	if !containsOrder(active, resp.OrderID) {
+		t.Errorf("GetActiveOrders does not include open order %s", resp.OrderID)
+	}
+
+	cancel := &order.Cancel{ID: resp.OrderID, Pair: s.Pair, AssetType: s.AssetType, Side: s.Side}
+	if err = e.CancelOrder(cancel); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+	if err = e.CancelOrder(cancel); err == nil {
+		t.Error("CancelOrder succeeded twice for the same order")
+	}
+	d, err = e.GetOrderInfo(resp.OrderID, s.Pair, s.AssetType)
+	if err != nil {
+		t.Fatalf("GetOrderInfo after cancel: %v", err)
+	}
+	if d.Status != order.Cancelled {
+		t.Errorf("expected %s after cancel, received %s", order.Cancelled, d.Status)
+	}
+	active, err = e.GetActiveOrders(&order.GetOrdersRequest{AssetType: s.AssetType, Pairs: currency.Pairs{s.Pair}})
+	if err != nil {
+		t.Fatalf("GetActiveOrders: %v", err)
+	}
+	if containsOrder(active, resp.OrderID) {
+		t.Errorf("GetActiveOrders still includes cancelled order %s", resp.OrderID)
+	}
+	history, err := e.GetOrderHistory(&order.GetOrdersRequest{AssetType: s.AssetType, Pairs: currency.Pairs{s.Pair}})
+	if err != nil {
+		t.Fatalf("GetOrderHistory: %v", err)
+	}
+	if !containsOrder(history, resp.OrderID) {
+		t.Errorf("GetOrderHistory does not include cancelled order %s", resp.OrderID)
+	}
+}
+
+// containsOrder reports whether an order ID is in a list of orders
+func containsOrder(orders []order.Detail, id string) bool {
+	for x := range orders {
+		if orders[x].ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package conformance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	exchange "github.com/openware/irix"
+	"github.com/openware/irix/binance"
+	"github.com/openware/irix/bitfinex"
+	"github.com/openware/irix/bitflyer"
+	"github.com/openware/irix/bithumb"
+	"github.com/openware/irix/bitmex"
+	"github.com/openware/irix/bitstamp"
+	"github.com/openware/irix/bittrex"
+	"github.com/openware/irix/btcmarkets"
+	"github.com/openware/irix/btse"
+	"github.com/openware/irix/coinbasepro"
+	"github.com/openware/irix/coinbene"
+	"github.com/openware/irix/coinut"
+	"github.com/openware/irix/exmo"
+	"github.com/openware/irix/ftx"
+	"github.com/openware/irix/gateio"
+	"github.com/openware/irix/gemini"
+	"github.com/openware/irix/hitbtc"
+	"github.com/openware/irix/huobi"
+	"github.com/openware/irix/itbit"
+	"github.com/openware/irix/kraken"
+	"github.com/openware/irix/lakebtc"
+	"github.com/openware/irix/lbank"
+	"github.com/openware/irix/localbitcoins"
+	"github.com/openware/irix/okcoin"
+	"github.com/openware/irix/okex"
+	"github.com/openware/irix/paper"
+	"github.com/openware/irix/poloniex"
+	"github.com/openware/irix/yobit"
+	"github.com/openware/irix/zb"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
+)
+
+// fixture returns the conformance fixture for an exchange package. The
+// package's test config is used when present, recordings are taken from
+// testdata and then from the package's own VCR file.
+func fixture(t *testing.T, e exchange.IBotExchange, pkg string) *Fixture {
+	t.Helper()
+	f := &Fixture{Exchange: e}
+	dir := filepath.Join("..", pkg)
+	if cfgPath := filepath.Join(dir, pkg+".conf.json"); fileExists(cfgPath) {
+		cfg, err := LoadConfig(cfgPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Config = cfg
+	}
+	for _, mockPath := range []string{
+		filepath.Join("testdata", pkg+".json"),
+		filepath.Join(dir, pkg+".mock.json"),
+	} {
+		if fileExists(mockPath) {
+			f.MockFile = mockPath
+			break
+		}
+	}
+	return f
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestExchanges(t *testing.T) {
+	for _, tc := range []struct {
+		pkg  string
+		exch exchange.IBotExchange
+	}{
+		{"binance", new(binance.Binance)},
+		{"bitfinex", new(bitfinex.Bitfinex)},
+		{"bitflyer", new(bitflyer.Bitflyer)},
+		{"bithumb", new(bithumb.Bithumb)},
+		{"bitmex", new(bitmex.Bitmex)},
+		{"bitstamp", new(bitstamp.Bitstamp)},
+		{"bittrex", new(bittrex.Bittrex)},
+		{"btcmarkets", new(btcmarkets.BTCMarkets)},
+		{"btse", new(btse.BTSE)},
+		{"coinbasepro", new(coinbasepro.CoinbasePro)},
+		{"coinbene", new(coinbene.Coinbene)},
+		{"coinut", new(coinut.COINUT)},
+		{"exmo", new(exmo.EXMO)},
+		{"ftx", new(ftx.FTX)},
+		{"gateio", new(gateio.Gateio)},
+		{"gemini", new(gemini.Gemini)},
+		{"hitbtc", new(hitbtc.HitBTC)},
+		{"huobi", new(huobi.HUOBI)},
+		{"itbit", new(itbit.ItBit)},
+		{"kraken", new(kraken.Kraken)},
+		{"lakebtc", new(lakebtc.LakeBTC)},
+		{"lbank", new(lbank.Lbank)},
+		{"localbitcoins", new(localbitcoins.LocalBitcoins)},
+		{"okcoin", new(okcoin.OKCoin)},
+		{"okex", new(okex.OKEX)},
+		{"poloniex", new(poloniex.Poloniex)},
+		{"yobit", new(yobit.Yobit)},
+		{"zb", new(zb.ZB)},
+	} {
+		tc := tc
+		t.Run(tc.pkg, func(t *testing.T) {
+			t.Parallel()
+			Run(t, fixture(t, tc.exch, tc.pkg))
+		})
+	}
+}
+
+func TestPaper(t *testing.T) {
+	t.Parallel()
+	Run(t, &Fixture{
+		Exchange: new(paper.Paper),
+		Prepare: func(e exchange.IBotExchange) error {
+			return e.(*paper.Paper).Deposit(currency.USD, 1000)
+		},
+		Order: &order.Submit{
+			Pair:          currency.NewPairWithDelimiter("BTC", "USD", "-"),
+			AssetType:     asset.Spot,
+			Side:          order.Buy,
+			Type:          order.Limit,
+			Price:         100,
+			Amount:        1,
+			ClientOrderID: "conformance-1",
+		},
+	})
+}
@@ -0,0 +1,87 @@
+package conformance
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/openware/pkg/mock"
+)
+
+// replayServer serves recorded VCR fixtures. Unlike the mock package server a
+// request without a recording is answered with a 404 instead of exiting, so
+// checks which depend on it can be skipped.
+type replayServer struct {
+	*httptest.Server
+	routes map[string]map[string][]mock.HTTPResponse
+}
+
+// newReplayServer starts a TLS replay server for the fixture file at path, an
+// empty path serves no recordings
+func newReplayServer(path string) (*replayServer, error) {
+	r := &replayServer{routes: make(map[string]map[string][]mock.HTTPResponse)}
+	if path != "" {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var fixture mock.VCRMock
+		if err = json.Unmarshal(contents, &fixture); err != nil {
+			return nil, err
+		}
+		if fixture.Routes != nil {
+			r.routes = fixture.Routes
+		}
+	}
+	r.Server = httptest.NewTLSServer(http.HandlerFunc(r.handle))
+	return r, nil
+}
+
+// handle matches a request to a recorded response by path, method and
+// parameters
+func (r *replayServer) handle(w http.ResponseWriter, req *http.Request) {
+	responses, ok := r.routes[req.URL.Path][req.Method]
+	if !ok {
+		notRecorded(w, req)
+		return
+	}
+
+	vals := req.URL.Query()
+	isQuery := true
+	if req.Method == http.MethodPost || req.Method == http.MethodPut {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			notRecorded(w, req)
+			return
+		}
+		switch {
+		case strings.Contains(req.Header.Get("Content-Type"), "application/json"):
+			vals, err = mock.DeriveURLValsFromJSONMap(body)
+			isQuery = false
+		case len(body) > 0:
+			vals, err = url.ParseQuery(string(body))
+			isQuery = false
+		}
+		if err != nil {
+			notRecorded(w, req)
+			return
+		}
+	}
+
+	payload, err := mock.MatchAndGetResponse(responses, vals, isQuery)
+	if err != nil {
+		notRecorded(w, req)
+		return
+	}
+	mock.MessageWriteJSON(w, http.StatusOK, payload)
+}
+
+// notRecorded answers a request which has no recording
+func notRecorded(w http.ResponseWriter, req *http.Request) {
+	mock.MessageWriteJSON(w, http.StatusNotFound, map[string]string{
+		"error": "no recording for " + req.Method + " " + req.URL.String(),
+	})
+}
@@ -0,0 +1,271 @@
+// Package consolidated merges the books of a pair traded on several venues
+// into one book tagged by venue.
+package consolidated
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openware/irix/analytics"
+	"github.com/openware/pkg/log"
+	"github.com/openware/pkg/orderbook"
+)
+
+// NewAggregator returns an aggregator of the configured venues
+func NewAggregator(c *Config) (*Aggregator, error) {
+	if c.Pair.IsEmpty() {
+		return nil, errPairUnset
+	}
+	if len(c.Venues) == 0 {
+		return nil, errNoVenues
+	}
+	if c.Depth < 0 {
+		return nil, errInvalidDepth
+	}
+	a := &Aggregator{
+		pair:   c.Pair,
+		depth:  c.Depth,
+		onBook: c.OnBook,
+		onBBO:  c.OnBBO,
+	}
+	for i := range c.Venues {
+		v := c.Venues[i]
+		if v.Exchange == nil || v.Pair.IsEmpty() || v.Asset == "" {
+			return nil, fmt.Errorf("%w at index %d: exchange, pair and asset are required",
+				errInvalidVenue, i)
+		}
+		name := v.Exchange.GetName()
+		for _, existing := range a.venues {
+			if strings.EqualFold(existing.name, name) &&
+				existing.Pair.Equal(v.Pair) &&
+				existing.Asset == v.Asset {
+				return nil, fmt.Errorf("%w %s %s %s", errDuplicateVenue, name, v.Pair, v.Asset)
+			}
+		}
+		a.venues = append(a.venues, &venue{Venue: v, name: name, index: i})
+	}
+	return a, nil
+}
+
+// Start fetches each venue's taker fee where fees are adjusted for and
+// follows the venues' books until stopped
+func (a *Aggregator) Start() error {
+	a.m.Lock()
+	defer a.m.Unlock()
+	if a.shutdown != nil {
+		return errRunning
+	}
+	depths := make([]*orderbook.Depth, len(a.venues))
+	for i, v := range a.venues {
+		if v.AdjustFees {
+			fee, err := analytics.TakerFeeRate(v.Exchange, v.Pair)
+			if err != nil {
+				return fmt.Errorf("cannot fetch %s taker fee: %w", v.name, err)
+			}
+			v.fee = fee
+		}
+		// Deployed so changes are followed before the first snapshot arrives
+		depth, err := orderbook.DeployDepth(v.name, v.Pair, v.Asset)
+		if err != nil {
+			return err
+		}
+		depths[i] = depth
+	}
+	a.shutdown = make(chan struct{})
+	for i := range a.venues {
+		a.wg.Add(1)
+		go a.follow(a.venues[i], depths[i], a.shutdown)
+	}
+	return nil
+}
+
+// Stop stops following the venues' books
+func (a *Aggregator) Stop() error {
+	a.m.Lock()
+	if a.shutdown == nil {
+		a.m.Unlock()
+		return errNotRunning
+	}
+	close(a.shutdown)
+	a.shutdown = nil
+	a.m.Unlock()
+	a.wg.Wait()
+	return nil
+}
+
+// Book returns the latest consolidated book
+func (a *Aggregator) Book() (*Book, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+	if a.book == nil {
+		return nil, fmt.Errorf("%w for %s", errNoBook, a.pair)
+	}
+	return a.book, nil
+}
+
+// BBO returns the latest best bid and offer
+func (a *Aggregator) BBO() (BBO, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+	if a.book == nil {
+		return BBO{}, fmt.Errorf("%w for %s", errNoBook, a.pair)
+	}
+	return a.bbo, nil
+}
+
+// Refresh rebuilds every venue's levels, such as after a quote rate changes
+func (a *Aggregator) Refresh() error {
+	for _, v := range a.venues {
+		depth, err := orderbook.GetDepth(v.name, v.Pair, v.Asset)
+		if err != nil {
+			return err
+		}
+		a.update(v, depth.Retrieve())
+	}
+	return nil
+}
+
+// follow updates the consolidated book each time a venue's depth changes
+func (a *Aggregator) follow(v *venue, depth *orderbook.Depth, shutdown chan struct{}) {
+	defer a.wg.Done()
+	for {
+		select {
+		case <-shutdown:
+			return
+		default:
+		}
+		// Wait before retrieving so changes made meanwhile are not missed
+		changed := depth.Wait(shutdown)
+		a.update(v, depth.Retrieve())
+		// The kick is dropped when nothing receives it, so shutdown is
+		// selected on rather than trusting the reply
+		select {
+		case <-shutdown:
+			return
+		case kicked := <-changed:
+			if kicked {
+				return
+			}
+		}
+	}
+}
+
+// update converts a venue's book and publishes the consolidated book
+func (a *Aggregator) update(v *venue, b *orderbook.Base) {
+	bids, asks, err := a.convert(v, b)
+	if err != nil {
+		// A venue which cannot be priced is left out rather than quoted stale
+		log.Errorf(log.OrderBook,
+			"consolidated %s: dropping %s %s %s: %v",
+			a.pair,
+			v.name,
+			v.Pair,
+			v.Asset,
+			err)
+	}
+
+	a.publish.Lock()
+	defer a.publish.Unlock()
+	a.m.Lock()
+	// Only the venue's levels are replaced, the others stay merged
+	a.bids = replace(a.bids, v.index, bids, func(x, y float64) bool { return x > y })
+	a.asks = replace(a.asks, v.index, asks, func(x, y float64) bool { return x < y })
+	book := &Book{
+		Pair:        a.pair,
+		Bids:        publish(a.bids),
+		Asks:        publish(a.asks),
+		LastUpdated: time.Now(),
+	}
+	bbo := BBO{Pair: a.pair, LastUpdated: book.LastUpdated}
+	if len(book.Bids) != 0 {
+		bbo.Bid = book.Bids[0]
+	}
+	if len(book.Asks) != 0 {
+		bbo.Ask = book.Asks[0]
+	}
+	changed := a.book == nil || bbo.Bid != a.bbo.Bid || bbo.Ask != a.bbo.Ask
+	a.book, a.bbo = book, bbo
+	a.m.Unlock()
+
+	if a.onBook != nil {
+		a.onBook(book)
+	}
+	if changed && a.onBBO != nil {
+		a.onBBO(bbo)
+	}
+}
+
+// convert prices a venue's levels in the consolidated quote currency
+func (a *Aggregator) convert(v *venue, b *orderbook.Base) (bids, asks []Level, err error) {
+	rate := 1.0
+	if v.QuoteRate != nil {
+		rate, err = v.QuoteRate()
+		if err != nil {
+			return nil, nil, err
+		}
+		if rate <= 0 {
+			return nil, nil, fmt.Errorf("%w, received %v", errInvalidRate, rate)
+		}
+	}
+	// Takers sell into bids for less and buy from asks for more
+	return a.levels(v, b.Bids, rate*(1-v.fee)), a.levels(v, b.Asks, rate*(1+v.fee)), nil
+}
+
+// levels converts the top levels of a side by multiplying their prices
+func (a *Aggregator) levels(v *venue, items orderbook.Items, multiplier float64) []Level {
+	n := len(items)
+	if a.depth > 0 && n > a.depth {
+		n = a.depth
+	}
+	levels := make([]Level, n)
+	for i := 0; i < n; i++ {
+		levels[i] = Level{
+			Exchange:   v.name,
+			Price:      items[i].Price * multiplier,
+			Amount:     items[i].Amount,
+			VenuePrice: items[i].Price,
+		}
+	}
+	return levels
+}
+
+// replace swaps a venue's levels in a merged side sorted best first for its
+// new levels, better reports whether a price is better than another. Levels
+// at the same price keep venue order.
+func replace(merged []quote, venue int, levels []Level, better func(x, y float64) bool) []quote {
+	out := make([]quote, 0, len(merged)+len(levels))
+	i, j := 0, 0
+	for i < len(merged) && j < len(levels) {
+		if merged[i].venue == venue {
+			i++
+			continue
+		}
+		if better(levels[j].Price, merged[i].Price) ||
+			(levels[j].Price == merged[i].Price && venue < merged[i].venue) {
+			out = append(out, quote{Level: levels[j], venue: venue})
+			j++
+			continue
+		}
+		out = append(out, merged[i])
+		i++
+	}
+	for ; i < len(merged); i++ {
+		if merged[i].venue != venue {
+			out = append(out, merged[i])
+		}
+	}
+	for ; j < len(levels); j++ {
+		out = append(out, quote{Level: levels[j], venue: venue})
+	}
+	return out
+}
+
+// publish copies a merged side's levels for a published book
+func publish(side []quote) []Level {
+	levels := make([]Level, len(side))
+	for i := range side {
+		levels[i] = side[i].Level
+	}
+	return levels
+}
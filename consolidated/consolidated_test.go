@@ -0,0 +1,214 @@
+package consolidated
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	exchange "github.com/openware/irix"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/orderbook"
+)
+
+type testExchange struct {
+	name string
+	fee  float64
+}
+
+func (e testExchange) GetName() string { return e.name }
+
+func (e testExchange) GetFeeByType(b *exchange.FeeBuilder) (float64, error) {
+	return e.fee * b.PurchasePrice * b.Amount, nil
+}
+
+func nearly(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestNewAggregator(t *testing.T) {
+	t.Parallel()
+	p := currency.NewPair(currency.BTC, currency.USD)
+	v := Venue{Exchange: testExchange{name: "a"}, Pair: p, Asset: asset.Spot}
+	for _, tc := range []struct {
+		c   Config
+		err error
+	}{
+		{Config{Venues: []Venue{v}}, errPairUnset},
+		{Config{Pair: p}, errNoVenues},
+		{Config{Pair: p, Venues: []Venue{v}, Depth: -1}, errInvalidDepth},
+		{Config{Pair: p, Venues: []Venue{{Pair: p, Asset: asset.Spot}}}, errInvalidVenue},
+		{Config{Pair: p, Venues: []Venue{v, v}}, errDuplicateVenue},
+	} {
+		c := tc.c
+		if _, err := NewAggregator(&c); !errors.Is(err, tc.err) {
+			t.Errorf("expected %v, received %v", tc.err, err)
+		}
+	}
+	a, err := NewAggregator(&Config{Pair: p, Venues: []Venue{v}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = a.Book(); !errors.Is(err, errNoBook) {
+		t.Errorf("expected %v, received %v", errNoBook, err)
+	}
+	if err = a.Stop(); !errors.Is(err, errNotRunning) {
+		t.Errorf("expected %v, received %v", errNotRunning, err)
+	}
+}
+
+func TestAggregator(t *testing.T) {
+	t.Parallel()
+	usd := currency.NewPair(currency.BTC, currency.USD)
+	usdt := currency.NewPair(currency.BTC, currency.USDT)
+	a := &orderbook.Base{
+		Exchange: "consolidatedA",
+		Pair:     usd,
+		Asset:    asset.Spot,
+		Bids:     orderbook.Items{{Price: 100, Amount: 1}, {Price: 98, Amount: 2}},
+		Asks:     orderbook.Items{{Price: 102, Amount: 1}, {Price: 104, Amount: 2}},
+	}
+	if err := a.Process(); err != nil {
+		t.Fatal(err)
+	}
+	b := &orderbook.Base{
+		Exchange: "consolidatedB",
+		Pair:     usdt,
+		Asset:    asset.Spot,
+		Bids:     orderbook.Items{{Price: 200, Amount: 3}, {Price: 190, Amount: 1}},
+		Asks:     orderbook.Items{{Price: 206, Amount: 3}, {Price: 210, Amount: 1}},
+	}
+	if err := b.Process(); err != nil {
+		t.Fatal(err)
+	}
+
+	rate := 0.5
+	bbos := make(chan BBO, 10)
+	agg, err := NewAggregator(&Config{
+		Pair: usd,
+		Venues: []Venue{
+			{Exchange: testExchange{name: a.Exchange}, Pair: usd, Asset: asset.Spot},
+			{
+				Exchange:   testExchange{name: b.Exchange, fee: 0.01},
+				Pair:       usdt,
+				Asset:      asset.Spot,
+				QuoteRate:  func() (float64, error) { return rate, nil },
+				AdjustFees: true,
+			},
+		},
+		Depth: 1,
+		OnBBO: func(bbo BBO) { bbos <- bbo },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = agg.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err = agg.Start(); !errors.Is(err, errRunning) {
+		t.Errorf("expected %v, received %v", errRunning, err)
+	}
+
+	// Each venue publishes once started, the best bid and offer only when it
+	// changes
+	waitBBO(t, bbos)
+	book := waitBook(t, agg, 2)
+	bbo, err := agg.BBO()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bbo.Bid.Exchange != a.Exchange || bbo.Bid.Price != 100 {
+		t.Errorf("unexpected best bid %+v", bbo.Bid)
+	}
+	if bbo.Ask.Exchange != a.Exchange || bbo.Ask.Price != 102 {
+		t.Errorf("unexpected best ask %+v", bbo.Ask)
+	}
+	// 200 USDT at 0.5 less a 1% fee
+	if l := book.Bids[1]; l.Exchange != b.Exchange || !nearly(l.Price, 99) || l.VenuePrice != 200 || l.Amount != 3 {
+		t.Errorf("unexpected converted bid %+v", l)
+	}
+	if l := book.Asks[1]; l.Exchange != b.Exchange || !nearly(l.Price, 104.03) {
+		t.Errorf("unexpected converted ask %+v", l)
+	}
+
+	// An update to one venue reprices only its levels
+	depth, err := orderbook.GetDepth(b.Exchange, usdt, asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	depth.UpdateBidAskByPrice(orderbook.Items{{Price: 210, Amount: 2}}, nil, 0)
+	// Skip any best bid and offer published while starting
+	for bbo = waitBBO(t, bbos); bbo.Bid.Exchange != b.Exchange; bbo = waitBBO(t, bbos) {
+	}
+	if bbo.Bid.Exchange != b.Exchange || !nearly(bbo.Bid.Price, 103.95) || bbo.Ask.Exchange != a.Exchange {
+		t.Errorf("unexpected best bid and offer %+v", bbo)
+	}
+
+	if err = agg.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	// A venue which cannot be priced is dropped
+	rate = 0
+	if err = agg.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if book, _ = agg.Book(); len(book.Bids) != 1 || book.Bids[0].Exchange != a.Exchange {
+		t.Errorf("expected unpriced venue to be dropped, received %+v", book.Bids)
+	}
+}
+
+func waitBBO(t *testing.T, bbos <-chan BBO) BBO {
+	t.Helper()
+	select {
+	case bbo := <-bbos:
+		return bbo
+	case <-time.After(time.Second):
+		t.Fatal("expected best bid and offer to be published")
+	}
+	return BBO{}
+}
+
+// waitBook waits for a book with levels on each side
+func waitBook(t *testing.T, agg *Aggregator, levels int) *Book {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		book, err := agg.Book()
+		if err == nil && len(book.Bids) == levels && len(book.Asks) == levels {
+			return book
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d levels a side, received %+v", levels, book)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	t.Parallel()
+	better := func(x, y float64) bool { return x > y }
+	var bids []quote
+	bids = replace(bids, 2, []Level{{Exchange: "c", Price: 11}}, better)
+	bids = replace(bids, 1, []Level{{Exchange: "b", Price: 9}, {Exchange: "b", Price: 8}}, better)
+	bids = replace(bids, 0, []Level{{Exchange: "a", Price: 10}, {Exchange: "a", Price: 8}}, better)
+	if got := venues(bids); got != "cabab" {
+		t.Errorf("expected merged venues cabab, received %s", got)
+	}
+	// Replacing a venue's levels leaves the others in place
+	bids = replace(bids, 1, []Level{{Exchange: "b", Price: 12}}, better)
+	if got := venues(bids); got != "bcaa" {
+		t.Errorf("expected merged venues bcaa, received %s", got)
+	}
+	if bids = replace(bids, 2, nil, better); venues(bids) != "baa" {
+		t.Errorf("expected merged venues baa, received %s", venues(bids))
+	}
+}
+
+func venues(side []quote) string {
+	var s string
+	for i := range side {
+		s += side[i].Exchange
+	}
+	return s
+}
@@ -0,0 +1,123 @@
+package consolidated
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	exchange "github.com/openware/irix"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+)
+
+var (
+	errPairUnset      = errors.New("consolidated pair unset")
+	errNoVenues       = errors.New("no venues")
+	errInvalidVenue   = errors.New("invalid venue")
+	errDuplicateVenue = errors.New("duplicate venue")
+	errInvalidDepth   = errors.New("depth cannot be negative")
+	errInvalidRate    = errors.New("quote rate must be positive")
+	errRunning        = errors.New("aggregator already running")
+	errNotRunning     = errors.New("aggregator not running")
+	errNoBook         = errors.New("no consolidated book")
+)
+
+// Exchange is the part of an IBotExchange a venue needs, the book itself is
+// read from the orderbook service its websocket buffer and REST requests
+// update
+type Exchange interface {
+	GetName() string
+	exchange.FeeCalculator
+}
+
+// Venue is a book merged into the consolidated book
+type Venue struct {
+	Exchange Exchange
+	// Pair is the pair as the venue trades it, such as BTC-USDT for a
+	// consolidated BTC-USD book
+	Pair  currency.Pair
+	Asset asset.Item
+	// QuoteRate converts the venue's quote currency into the consolidated
+	// quote currency, nil when they are the same. It is called each time the
+	// venue's book changes.
+	QuoteRate func() (float64, error)
+	// AdjustFees prices levels at what a taker pays or receives once the
+	// venue's taker fee is charged
+	AdjustFees bool
+}
+
+// Config configures an aggregator
+type Config struct {
+	// Pair is the consolidated pair
+	Pair   currency.Pair
+	Venues []Venue
+	// Depth limits the levels taken from each venue, zero takes them all
+	Depth int
+	// OnBook is called with every consolidated book published
+	OnBook func(*Book)
+	// OnBBO is called when the best bid or offer changes
+	OnBBO func(BBO)
+}
+
+// Level is a price level of a venue in the consolidated book
+type Level struct {
+	Exchange string
+	// Price is converted into the consolidated quote currency and adjusted
+	// for fees when the venue is
+	Price  float64
+	Amount float64
+	// VenuePrice is the price as quoted by the venue
+	VenuePrice float64
+}
+
+// Book is a consolidated book, levels at the same price are listed in venue
+// order. Published books must not be modified.
+type Book struct {
+	Pair        currency.Pair
+	Bids        []Level
+	Asks        []Level
+	LastUpdated time.Time
+}
+
+// BBO is the best bid and offer across venues, an empty side has a zero level
+type BBO struct {
+	Pair        currency.Pair
+	Bid         Level
+	Ask         Level
+	LastUpdated time.Time
+}
+
+// Aggregator merges the books of several venues into a consolidated book,
+// updating it as each venue's book changes
+type Aggregator struct {
+	pair   currency.Pair
+	venues []*venue
+	depth  int
+	onBook func(*Book)
+	onBBO  func(BBO)
+	book   *Book
+	bbo    BBO
+	// bids and asks are the merged sides the book is published from
+	bids     []quote
+	asks     []quote
+	shutdown chan struct{}
+	wg       sync.WaitGroup
+	m        sync.Mutex
+	// publish serialises building and publishing books so callbacks see
+	// them in order
+	publish sync.Mutex
+}
+
+// venue is a venue and its position in the configuration
+type venue struct {
+	Venue
+	name  string
+	index int
+	fee   float64
+}
+
+// quote is a level in a merged side and the index of its venue
+type quote struct {
+	Level
+	venue int
+}
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/openware/irix/stream"
 )
 
 const (
@@ -55,6 +56,8 @@ type Connection struct {
 }
 
 type Client struct {
+	// reconnect is the backoff used when a connection drops
+	reconnect     stream.Backoff
 	publicConn    Connection
 	privateConn   Connection
 	isTerminating bool
@@ -68,6 +71,8 @@ type Client struct {
 	outbox        chan Response
 	LogFunc       LogFunc
 	wg            sync.WaitGroup
+	// clock corrects nonces by the offset of the exchange clock
+	clock clock
 }
 
 // New returns a pointer of Client struct
@@ -83,9 +88,19 @@ func New(wsRootURL, restRootURL, key, secret string) *Client {
 		httpClient:    &http.Client{},
 		LogFunc:       defaultLogFunc,
 		isTerminating: false,
+		reconnect:     stream.DefaultBackoff(),
 	}
 }
 
+// SetBackoff sets the backoff used when a connection drops
+func (c *Client) SetBackoff(b stream.Backoff) error {
+	if err := b.Validate(); err != nil {
+		return err
+	}
+	c.reconnect = b
+	return nil
+}
+
 // Connect instansiate WS Connections
 func (c *Client) Connect() error {
 	publicWsEndpoint := c.wsRootURL + marketEndpoint
@@ -109,6 +124,14 @@ func (c *Client) Connect() error {
 	return nil
 }
 
+// ServerTimeOffset returns the estimated exchange time minus local time used
+// for nonces
+func (c *Client) ServerTimeOffset() time.Duration {
+	c.clock.RLock()
+	defer c.clock.RUnlock()
+	return c.clock.offset
+}
+
 func (c *Client) Listen() <-chan Response {
 	go c.readConnection(c.publicConn)
 	go c.readConnection(c.privateConn)
@@ -150,11 +173,20 @@ func (c *Client) readConnection(cnx Connection) {
 				c.LogFunc("Stop reading from %s cnx. Connection closed\n", cnx.Type())
 				return
 			}
-			for {
+			for failed := 0; ; {
 				conn, _, err := websocket.DefaultDialer.Dial(cnx.Endpoint, http.Header{})
 				if err != nil {
-					c.LogFunc("Reconnection error in %s cnx\n Error message: %s\n", cnx.Type(), err.Error())
-					time.Sleep(1 * time.Second)
+					failed++
+					if c.isTerminating {
+						return
+					}
+					if c.reconnect.MaxAttempts > 0 && failed >= c.reconnect.MaxAttempts {
+						c.LogFunc("Giving up reconnecting %s cnx after %d attempts\n Error message: %s\n", cnx.Type(), failed, err.Error())
+						return
+					}
+					delay := c.reconnect.Delay(failed)
+					c.LogFunc("Reconnection error in %s cnx, retrying in %s\n Error message: %s\n", cnx.Type(), delay, err.Error())
+					time.Sleep(delay)
 					continue
 				}
 
@@ -192,6 +224,7 @@ func (c *Client) readConnection(cnx Connection) {
 		}
 
 		if parsed.Method == "public/heartbeat" {
+			c.clock.observe(int64(parsed.Id), time.Now())
 			c.respondHeartBeat(cnx.IsPrivate, parsed.Id)
 			continue
 		}
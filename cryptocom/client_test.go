@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/openware/irix/stream"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -84,3 +86,13 @@ func TestConnectionRead(t *testing.T) {
 	// assertion
 	assert.Equal(t, expectedResponse, <-msgs)
 }
+
+func TestSetBackoff(t *testing.T) {
+	c := New("test", "test", "test", "test")
+	assert.Equal(t, stream.DefaultBackoff(), c.reconnect)
+	assert.Error(t, c.SetBackoff(stream.Backoff{}))
+
+	b := stream.Backoff{Initial: time.Second, Max: 5 * time.Second, Multiplier: 2}
+	assert.NoError(t, c.SetBackoff(b))
+	assert.Equal(t, b, c.reconnect)
+}
@@ -5,6 +5,9 @@ go 1.15
 require (
 	github.com/google/uuid v1.2.0
 	github.com/gorilla/websocket v1.4.2
+	github.com/openware/irix v0.0.0
 	github.com/shopspring/decimal v1.2.0
 	github.com/stretchr/testify v1.7.0
 )
+
+replace github.com/openware/irix => ../
@@ -14,7 +14,7 @@ func (c *Client) AuthRequest() *Request {
 		Type:   AuthRequest,
 		Method: "public/auth",
 		ApiKey: c.key,
-		Nonce:  generateNonce(),
+		Nonce:  c.generateNonce(),
 	}
 
 	c.generateSignature(r)
@@ -27,7 +27,7 @@ func (c *Client) subscribeRequest(channels []string) *Request {
 		Type:   SubscribeRequest,
 		Method: "subscribe",
 		Params: map[string]interface{}{"channels": channels},
-		Nonce:  generateNonce(),
+		Nonce:  c.generateNonce(),
 	}
 }
 
@@ -59,7 +59,7 @@ func (c *Client) createOrderLimitRequest(
 			"quantity":        volume,
 			"client_oid":      uuid,
 		},
-		Nonce: generateNonce(),
+		Nonce: c.generateNonce(),
 	}
 }
 
@@ -88,7 +88,7 @@ func (c *Client) createOrderMarketRequest(
 			volumeKey:         volume,
 			"client_oid":      uuid,
 		},
-		Nonce: generateNonce(),
+		Nonce: c.generateNonce(),
 	}
 }
 
@@ -101,7 +101,7 @@ func (c *Client) cancelOrderRequest(reqID int, remoteID, market string) *Request
 			"instrument_name": market,
 			"order_id":        remoteID,
 		},
-		Nonce: generateNonce(),
+		Nonce: c.generateNonce(),
 	}
 }
 
@@ -114,7 +114,7 @@ func (c *Client) cancelAllOrdersRequest(reqID int, market string) *Request {
 		Params: map[string]interface{}{
 			"instrument_name": market,
 		},
-		Nonce: generateNonce(),
+		Nonce: c.generateNonce(),
 	}
 }
 
@@ -126,7 +126,7 @@ func (c *Client) getOrderDetailsRequest(reqID int, remoteID string) *Request {
 		Params: map[string]interface{}{
 			"order_id": remoteID,
 		},
-		Nonce: generateNonce(),
+		Nonce: c.generateNonce(),
 	}
 }
 
@@ -139,7 +139,7 @@ func (c *Client) restGetOrderDetailsRequest(reqID int, remoteID string) *Request
 			"order_id": remoteID,
 		},
 		ApiKey: c.key,
-		Nonce:  generateNonce(),
+		Nonce:  c.generateNonce(),
 	}
 
 	c.generateSignature(r)
@@ -153,7 +153,7 @@ func (c *Client) restGetBalanceRequest(reqID int) *Request {
 		Method: "private/get-account-summary",
 		Params: map[string]interface{}{},
 		ApiKey: c.key,
-		Nonce:  generateNonce(),
+		Nonce:  c.generateNonce(),
 	}
 
 	c.generateSignature(r)
@@ -169,7 +169,7 @@ func (c *Client) restGetTradesRequest(reqID int, market string) *Request {
 			"instrument_name": market,
 		},
 		ApiKey: c.key,
-		Nonce:  generateNonce(),
+		Nonce:  c.generateNonce(),
 	}
 
 	c.generateSignature(r)
@@ -187,7 +187,7 @@ func (c *Client) restOpenOrdersRequest(reqID int, market string, page int, pageS
 			"page_size":       strconv.Itoa(pageSize),
 		},
 		ApiKey: c.key,
-		Nonce:  generateNonce(),
+		Nonce:  c.generateNonce(),
 	}
 
 	c.generateSignature(r)
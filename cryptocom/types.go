@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 )
 
@@ -37,8 +38,36 @@ type Response struct {
 	Result  map[string]interface{}
 }
 
-func generateNonce() string {
-	return fmt.Sprintf("%d", time.Now().Unix()*1000)
+// minServerMillis rejects heartbeat ids which are not millisecond timestamps
+const minServerMillis = 1e12
+
+// clock is the offset of the exchange clock from the local clock, taken from
+// heartbeats whose id is the server time in milliseconds
+type clock struct {
+	sync.RWMutex
+	offset time.Duration
+}
+
+// observe updates the offset from a server timestamp. The one way latency is
+// unknown so the server clock is slightly underestimated.
+func (c *clock) observe(serverMillis int64, received time.Time) {
+	if serverMillis < minServerMillis {
+		return
+	}
+	server := time.Unix(0, serverMillis*int64(time.Millisecond))
+	c.Lock()
+	c.offset = server.Sub(received)
+	c.Unlock()
+}
+
+func (c *clock) now() time.Time {
+	c.RLock()
+	defer c.RUnlock()
+	return time.Now().Add(c.offset)
+}
+
+func (c *Client) generateNonce() string {
+	return fmt.Sprintf("%d", c.clock.now().Unix()*1000)
 }
 
 func (r *Request) Encode() ([]byte, error) {
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -19,7 +20,8 @@ func TestEncode(t *testing.T) {
 		"key2": "test2",
 	}
 	jsonParams, _ := json.Marshal(params)
-	nonce := generateNonce()
+	c := &Client{}
+	nonce := c.generateNonce()
 
 	t.Run("AuthRequest", func(t *testing.T) {
 		expected := fmt.Sprintf(
@@ -36,7 +38,7 @@ func TestEncode(t *testing.T) {
 			Method:    method,
 			ApiKey:    apiKey,
 			Signature: signature,
-			Nonce:     generateNonce(),
+			Nonce:     c.generateNonce(),
 		}
 
 		b, _ := request.Encode()
@@ -128,3 +130,14 @@ func TestEncode(t *testing.T) {
 		assert.Equal(t, err, errors.New("invalid type"))
 	})
 }
+
+func TestServerClock(t *testing.T) {
+	var c clock
+	received := time.Now()
+	c.observe(received.Add(-time.Minute).UnixNano()/int64(time.Millisecond), received)
+	assert.InDelta(t, float64(-time.Minute), float64(c.offset), float64(time.Millisecond))
+	assert.WithinDuration(t, time.Now().Add(-time.Minute), c.now(), time.Second)
+
+	c.observe(1, received)
+	assert.InDelta(t, float64(-time.Minute), float64(c.offset), float64(time.Millisecond), "heartbeat ids which are not timestamps are ignored")
+}
@@ -0,0 +1,331 @@
+package irix
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+
+	"github.com/openware/irix/ticker"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
+	"github.com/openware/pkg/orderbook"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	errPrecisionNotLoaded  = errors.New("price and amount precision not loaded")
+	errConformedAmountZero = errors.New("amount is zero after conforming to lot size")
+	errConformedPriceZero  = errors.New("price is zero after conforming to tick size")
+)
+
+// Precision defines the exact tick and lot sizes of a trading pair
+type Precision struct {
+	PriceTick   decimal.Decimal
+	AmountStep  decimal.Decimal
+	MinNotional decimal.Decimal
+}
+
+// precisionStore holds decimal precision for each loaded trading pair
+type precisionStore struct {
+	m   map[asset.Item]map[*currency.Item]map[*currency.Item]*Precision
+	mtx sync.RWMutex
+}
+
+// ConformedSubmit holds the exact price and amount a submission was conformed
+// to, formatted as they are sent to the exchange
+type ConformedSubmit struct {
+	Price  string
+	Amount string
+}
+
+// DecimalLevel is an orderbook price level with exact price and amount
+type DecimalLevel struct {
+	Price  decimal.Decimal
+	Amount decimal.Decimal
+	ID     int64
+}
+
+// LoadLimits loads the order execution limits and keeps an exact decimal copy
+// of the tick and lot sizes for price and amount formatting
+func (b *Base) LoadLimits(levels []order.MinMaxLevel) error {
+	if len(levels) > 0 {
+		// A minimum without a maximum is unbounded, the embedded store would
+		// otherwise reject the level as min exceeding max
+		bounded := make([]order.MinMaxLevel, len(levels))
+		copy(bounded, levels)
+		for x := range bounded {
+			if bounded[x].MaxPrice == 0 && bounded[x].MinPrice > 0 {
+				bounded[x].MaxPrice = math.MaxFloat64
+			}
+			if bounded[x].MaxAmount == 0 && bounded[x].MinAmount > 0 {
+				bounded[x].MaxAmount = math.MaxFloat64
+			}
+		}
+		levels = bounded
+	}
+	if err := b.ExecutionLimits.LoadLimits(levels); err != nil {
+		return err
+	}
+	for x := range levels {
+		b.SetOrderExecutionLimitsLoadError(levels[x].Asset, nil)
+	}
+	b.precision.mtx.Lock()
+	defer b.precision.mtx.Unlock()
+	if b.precision.m == nil {
+		b.precision.m = make(map[asset.Item]map[*currency.Item]map[*currency.Item]*Precision)
+	}
+	for x := range levels {
+		m1, ok := b.precision.m[levels[x].Asset]
+		if !ok {
+			m1 = make(map[*currency.Item]map[*currency.Item]*Precision)
+			b.precision.m[levels[x].Asset] = m1
+		}
+		m2, ok := m1[levels[x].Pair.Base.Item]
+		if !ok {
+			m2 = make(map[*currency.Item]*Precision)
+			m1[levels[x].Pair.Base.Item] = m2
+		}
+		// Step sizes are parsed from exchange strings, the shortest decimal
+		// representation of the float recovers the original value exactly
+		m2[levels[x].Pair.Quote.Item] = &Precision{
+			PriceTick:   decimal.NewFromFloat(levels[x].StepPrice),
+			AmountStep:  decimal.NewFromFloat(levels[x].StepAmount),
+			MinNotional: decimal.NewFromFloat(levels[x].MinNotional),
+		}
+	}
+	return nil
+}
+
+// GetPrecision returns the exact tick and lot sizes for a trading pair
+func (b *Base) GetPrecision(a asset.Item, cp currency.Pair) (Precision, error) {
+	b.precision.mtx.RLock()
+	defer b.precision.mtx.RUnlock()
+	p, ok := b.precision.m[a][cp.Base.Item][cp.Quote.Item]
+	if !ok {
+		return Precision{}, fmt.Errorf("%s %s %s %w", b.Name, a, cp, errPrecisionNotLoaded)
+	}
+	return *p, nil
+}
+
+// ConformPrice rounds a price to the tick size of the trading pair. Buy prices
+// are rounded down and sell prices up so an order never crosses further than
+// requested.
+func (b *Base) ConformPrice(a asset.Item, cp currency.Pair, side order.Side, price decimal.Decimal) (decimal.Decimal, error) {
+	p, err := b.GetPrecision(a, cp)
+	if err != nil {
+		return price, err
+	}
+	return RoundToStep(price, p.PriceTick, side == order.Sell || side == order.Ask), nil
+}
+
+// ConformAmount rounds an amount down to the lot size of the trading pair so
+// an order never exceeds the requested amount
+func (b *Base) ConformAmount(a asset.Item, cp currency.Pair, amount decimal.Decimal) (decimal.Decimal, error) {
+	p, err := b.GetPrecision(a, cp)
+	if err != nil {
+		return amount, err
+	}
+	return RoundToStep(amount, p.AmountStep, false), nil
+}
+
+// FormatPrice returns a price conformed to the tick size of the trading pair
+// formatted with exactly the tick's decimal places
+func (b *Base) FormatPrice(a asset.Item, cp currency.Pair, side order.Side, price decimal.Decimal) (string, error) {
+	p, err := b.GetPrecision(a, cp)
+	if err != nil {
+		return "", err
+	}
+	return FormatDecimal(RoundToStep(price, p.PriceTick, side == order.Sell || side == order.Ask), p.PriceTick), nil
+}
+
+// FormatAmount returns an amount conformed to the lot size of the trading pair
+// formatted with exactly the lot's decimal places
+func (b *Base) FormatAmount(a asset.Item, cp currency.Pair, amount decimal.Decimal) (string, error) {
+	p, err := b.GetPrecision(a, cp)
+	if err != nil {
+		return "", err
+	}
+	return FormatDecimal(RoundToStep(amount, p.AmountStep, false), p.AmountStep), nil
+}
+
+// ConformSubmit sets an exact price and amount on an order submission and
+// returns them formatted as they are sent. When precision is loaded for the
+// pair the values are conformed to the tick and lot sizes first.
+func (b *Base) ConformSubmit(s *order.Submit, price, amount decimal.Decimal) (ConformedSubmit, error) {
+	if s == nil {
+		return ConformedSubmit{}, order.ErrSubmissionIsNil
+	}
+	var priceStep, amountStep decimal.Decimal
+	if p, err := b.GetPrecision(s.AssetType, s.Pair); err == nil {
+		amount = RoundToStep(amount, p.AmountStep, false)
+		if amount.IsZero() {
+			return ConformedSubmit{}, fmt.Errorf("%s %s %w", b.Name, s.Pair, errConformedAmountZero)
+		}
+		if !price.IsZero() {
+			price = RoundToStep(price, p.PriceTick, s.Side == order.Sell || s.Side == order.Ask)
+			if price.IsZero() {
+				return ConformedSubmit{}, fmt.Errorf("%s %s %w", b.Name, s.Pair, errConformedPriceZero)
+			}
+		}
+		priceStep, amountStep = p.PriceTick, p.AmountStep
+	}
+	s.Price, _ = price.Float64()
+	s.Amount, _ = amount.Float64()
+	return ConformedSubmit{
+		Price:  FormatDecimal(price, priceStep),
+		Amount: FormatDecimal(amount, amountStep),
+	}, nil
+}
+
+// SubmitValues returns the price and amount a wrapper sends for a submission.
+// Values on the tick and lot sizes of the pair, such as those set by
+// ConformSubmit, are formatted exactly as ConformSubmit returned them. Any
+// other values are formatted from their floats.
+func (b *Base) SubmitValues(s *order.Submit) (price, amount string) {
+	price = strconv.FormatFloat(s.Price, 'f', -1, 64)
+	amount = strconv.FormatFloat(s.Amount, 'f', -1, 64)
+	p, err := b.GetPrecision(s.AssetType, s.Pair)
+	if err != nil {
+		return price, amount
+	}
+	// The shortest decimal representation of a float set from a decimal on
+	// the step recovers that decimal exactly
+	if v := decimal.NewFromFloat(s.Price); onStep(v, p.PriceTick) {
+		price = FormatDecimal(v, p.PriceTick)
+	}
+	if v := decimal.NewFromFloat(s.Amount); onStep(v, p.AmountStep) {
+		amount = FormatDecimal(v, p.AmountStep)
+	}
+	return price, amount
+}
+
+// onStep returns whether a value is a multiple of step
+func onStep(value, step decimal.Decimal) bool {
+	return step.Sign() > 0 && value.Mod(step).IsZero()
+}
+
+// RoundToStep rounds a value to a multiple of step, down unless roundUp is
+// set. A zero or negative step returns the value unchanged.
+func RoundToStep(value, step decimal.Decimal, roundUp bool) decimal.Decimal {
+	if step.Sign() <= 0 {
+		return value
+	}
+	steps := value.Div(step)
+	if roundUp {
+		steps = steps.Ceil()
+	} else {
+		steps = steps.Floor()
+	}
+	return steps.Mul(step)
+}
+
+// FormatDecimal formats a value with the decimal places of step. A zero step
+// formats the value without trailing zeros.
+func FormatDecimal(value, step decimal.Decimal) string {
+	if step.IsZero() {
+		return value.String()
+	}
+	places := -step.Exponent()
+	if places < 0 {
+		places = 0
+	}
+	return value.StringFixed(places)
+}
+
+// DecimalLevels converts orderbook levels to decimal levels with the shortest
+// decimal representation of each float. Use Base.DecimalLevels where the
+// pair's precision is loaded to recover the exchange's values exactly.
+func DecimalLevels(items []orderbook.Item) []DecimalLevel {
+	levels := make([]DecimalLevel, len(items))
+	for x := range items {
+		levels[x] = DecimalLevel{
+			Price:  decimal.NewFromFloat(items[x].Price),
+			Amount: decimal.NewFromFloat(items[x].Amount),
+			ID:     items[x].ID,
+		}
+	}
+	return levels
+}
+
+// DecimalLevels converts orderbook levels to exact decimal levels. Prices and
+// amounts are quantised to the tick and lot sizes of the trading pair, which
+// removes any error introduced by parsing the exchange's values into floats.
+func (b *Base) DecimalLevels(a asset.Item, cp currency.Pair, items []orderbook.Item) ([]DecimalLevel, error) {
+	p, err := b.GetPrecision(a, cp)
+	if err != nil {
+		return nil, err
+	}
+	levels := make([]DecimalLevel, len(items))
+	for x := range items {
+		levels[x] = DecimalLevel{
+			Price:  quantise(items[x].Price, p.PriceTick),
+			Amount: quantise(items[x].Amount, p.AmountStep),
+			ID:     items[x].ID,
+		}
+	}
+	return levels, nil
+}
+
+// TickerDecimal converts a ticker to exact decimals. Prices are quantised to
+// the tick size and volumes to the lot size of the trading pair.
+func (b *Base) TickerDecimal(t *ticker.Price) (ticker.PriceDecimal, error) {
+	if t == nil {
+		return ticker.PriceDecimal{}, errors.New("ticker is nil")
+	}
+	p, err := b.GetPrecision(t.AssetType, t.Pair)
+	if err != nil {
+		return ticker.PriceDecimal{}, err
+	}
+	return ticker.PriceDecimal{
+		Last:        quantise(t.Last, p.PriceTick),
+		High:        quantise(t.High, p.PriceTick),
+		Low:         quantise(t.Low, p.PriceTick),
+		Bid:         quantise(t.Bid, p.PriceTick),
+		Ask:         quantise(t.Ask, p.PriceTick),
+		Volume:      quantise(t.Volume, p.AmountStep),
+		QuoteVolume: decimal.NewFromFloat(t.QuoteVolume),
+		Open:        quantise(t.Open, p.PriceTick),
+		Close:       quantise(t.Close, p.PriceTick),
+		Pair:        t.Pair,
+		AssetType:   t.AssetType,
+		LastUpdated: t.LastUpdated,
+	}, nil
+}
+
+// quantise rounds a float to the nearest multiple of step, a zero step uses
+// the float's shortest decimal representation
+func quantise(value float64, step decimal.Decimal) decimal.Decimal {
+	d := decimal.NewFromFloat(value)
+	if step.Sign() <= 0 {
+		return d
+	}
+	return d.Div(step).Round(0).Mul(step)
+}
+
+// FeeDecimal calculates a fee with exact decimal arithmetic. Trade fees are
+// derived from the exchange's rate for a unit order and multiplied by the
+// decimal notional, all other fees are flat and converted directly.
+func FeeDecimal(e FeeCalculator, f *FeeBuilder, price, amount decimal.Decimal) (decimal.Decimal, error) {
+	if f == nil {
+		return decimal.Zero, errors.New("fee builder is nil")
+	}
+	unit := *f
+	if f.FeeType == CryptocurrencyTradeFee || f.FeeType == OfflineTradeFee {
+		unit.PurchasePrice, unit.Amount = 1, 1
+		rate, err := e.GetFeeByType(&unit)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		return decimal.NewFromFloat(rate).Mul(price).Mul(amount), nil
+	}
+	unit.PurchasePrice, _ = price.Float64()
+	unit.Amount, _ = amount.Float64()
+	fee, err := e.GetFeeByType(&unit)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return decimal.NewFromFloat(fee), nil
+}
@@ -0,0 +1,259 @@
+package irix
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/openware/irix/ticker"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
+	"github.com/openware/pkg/orderbook"
+	"github.com/shopspring/decimal"
+)
+
+type fixedFee struct {
+	rate float64
+	flat float64
+}
+
+func (f fixedFee) GetFeeByType(fb *FeeBuilder) (float64, error) {
+	if fb.FeeType == CryptocurrencyTradeFee {
+		return f.rate * fb.PurchasePrice * fb.Amount, nil
+	}
+	return f.flat, nil
+}
+
+func precisionTestBase(t *testing.T) (*Base, currency.Pair) {
+	t.Helper()
+	b := &Base{Name: "decimalex"}
+	cp := currency.NewPair(currency.BTC, currency.USDT)
+	err := b.LoadLimits([]order.MinMaxLevel{{
+		Pair:        cp,
+		Asset:       asset.Spot,
+		StepPrice:   0.01,
+		StepAmount:  0.00001,
+		MinNotional: 10,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, cp
+}
+
+func TestGetPrecision(t *testing.T) {
+	t.Parallel()
+	b, cp := precisionTestBase(t)
+	_, err := b.GetPrecision(asset.Margin, cp)
+	if !errors.Is(err, errPrecisionNotLoaded) {
+		t.Fatalf("received: %v but expected: %v", err, errPrecisionNotLoaded)
+	}
+	p, err := b.GetPrecision(asset.Spot, cp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.PriceTick.String() != "0.01" || p.AmountStep.String() != "0.00001" || p.MinNotional.String() != "10" {
+		t.Fatalf("unexpected precision %+v", p)
+	}
+	// Execution limits are still loaded on the embedded store
+	if _, err = b.GetOrderExecutionLimits(asset.Spot, cp); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConformPriceAndAmount(t *testing.T) {
+	t.Parallel()
+	b, cp := precisionTestBase(t)
+	price := decimal.RequireFromString("35123.456")
+	buy, err := b.ConformPrice(asset.Spot, cp, order.Buy, price)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buy.String() != "35123.45" {
+		t.Errorf("received: %v but expected: %v", buy, "35123.45")
+	}
+	sell, err := b.ConformPrice(asset.Spot, cp, order.Sell, price)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sell.String() != "35123.46" {
+		t.Errorf("received: %v but expected: %v", sell, "35123.46")
+	}
+	amount, err := b.ConformAmount(asset.Spot, cp, decimal.RequireFromString("0.1").Add(decimal.RequireFromString("0.2")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount.String() != "0.3" {
+		t.Errorf("received: %v but expected: %v", amount, "0.3")
+	}
+
+	s, err := b.FormatPrice(asset.Spot, cp, order.Buy, decimal.RequireFromString("35000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != "35000.00" {
+		t.Errorf("received: %v but expected: %v", s, "35000.00")
+	}
+	s, err = b.FormatAmount(asset.Spot, cp, decimal.RequireFromString("0.123456789"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != "0.12345" {
+		t.Errorf("received: %v but expected: %v", s, "0.12345")
+	}
+	if _, err = b.FormatAmount(asset.Futures, cp, decimal.Zero); !errors.Is(err, errPrecisionNotLoaded) {
+		t.Fatalf("received: %v but expected: %v", err, errPrecisionNotLoaded)
+	}
+}
+
+func TestConformSubmit(t *testing.T) {
+	t.Parallel()
+	b, cp := precisionTestBase(t)
+	if _, err := b.ConformSubmit(nil, decimal.Zero, decimal.Zero); !errors.Is(err, order.ErrSubmissionIsNil) {
+		t.Fatalf("received: %v but expected: %v", err, order.ErrSubmissionIsNil)
+	}
+	s := &order.Submit{Pair: cp, AssetType: asset.Spot, Side: order.Buy, Type: order.Limit}
+	c, err := b.ConformSubmit(s, decimal.RequireFromString("0.1").Add(decimal.RequireFromString("35000.2")), decimal.RequireFromString("0.000019"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Price != 35000.3 || s.Amount != 0.00001 {
+		t.Fatalf("unexpected conformed submission price %v amount %v", s.Price, s.Amount)
+	}
+	if c.Price != "35000.30" || c.Amount != "0.00001" {
+		t.Fatalf("unexpected conformed values price %v amount %v", c.Price, c.Amount)
+	}
+	if _, err = b.ConformSubmit(s, decimal.Zero, decimal.RequireFromString("0.000001")); !errors.Is(err, errConformedAmountZero) {
+		t.Fatalf("received: %v but expected: %v", err, errConformedAmountZero)
+	}
+	if _, err = b.ConformSubmit(s, decimal.RequireFromString("0.001"), decimal.NewFromInt(1)); !errors.Is(err, errConformedPriceZero) {
+		t.Fatalf("received: %v but expected: %v", err, errConformedPriceZero)
+	}
+	// Unloaded pairs are set without conforming
+	s = &order.Submit{Pair: currency.NewPair(currency.ETH, currency.USDT), AssetType: asset.Spot}
+	if c, err = b.ConformSubmit(s, decimal.RequireFromString("1.23456"), decimal.RequireFromString("0.3")); err != nil {
+		t.Fatal(err)
+	}
+	if s.Price != 1.23456 || s.Amount != 0.3 {
+		t.Fatalf("unexpected submission price %v amount %v", s.Price, s.Amount)
+	}
+	if c.Price != "1.23456" || c.Amount != "0.3" {
+		t.Fatalf("unexpected values price %v amount %v", c.Price, c.Amount)
+	}
+}
+
+func TestSubmitValues(t *testing.T) {
+	t.Parallel()
+	b, cp := precisionTestBase(t)
+	s := &order.Submit{Pair: cp, AssetType: asset.Spot, Side: order.Sell, Type: order.Limit}
+	c, err := b.ConformSubmit(s, decimal.RequireFromString("35000.3"), decimal.RequireFromString("0.1").Add(decimal.RequireFromString("0.2")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	price, amount := b.SubmitValues(s)
+	if price != "35000.30" || amount != "0.30000" {
+		t.Errorf("unexpected exact values price %v amount %v", price, amount)
+	}
+	if price != c.Price || amount != c.Amount {
+		t.Errorf("received: %v %v but expected: %v %v", price, amount, c.Price, c.Amount)
+	}
+	// Floats changed after conforming are sent as changed
+	s.Price = 1.005
+	if price, amount = b.SubmitValues(s); price != "1.005" || amount != "0.30000" {
+		t.Errorf("unexpected values price %v amount %v", price, amount)
+	}
+	// Unloaded pairs are formatted from their floats
+	s.Pair = currency.NewPair(currency.ETH, currency.USDT)
+	if price, amount = b.SubmitValues(s); price != "1.005" || amount != "0.3" {
+		t.Errorf("unexpected float values price %v amount %v", price, amount)
+	}
+}
+
+func TestRoundToStep(t *testing.T) {
+	t.Parallel()
+	v := decimal.RequireFromString("1.2345")
+	if r := RoundToStep(v, decimal.Zero, false); !r.Equal(v) {
+		t.Errorf("received: %v but expected: %v", r, v)
+	}
+	if r := RoundToStep(v, decimal.RequireFromString("0.5"), false); r.String() != "1" {
+		t.Errorf("received: %v but expected: %v", r, "1")
+	}
+	if r := RoundToStep(v, decimal.RequireFromString("0.5"), true); r.String() != "1.5" {
+		t.Errorf("received: %v but expected: %v", r, "1.5")
+	}
+	if f := FormatDecimal(decimal.NewFromInt(100), decimal.NewFromInt(10)); f != "100" {
+		t.Errorf("received: %v but expected: %v", f, "100")
+	}
+	if f := FormatDecimal(decimal.RequireFromString("1.50"), decimal.Zero); f != "1.5" {
+		t.Errorf("received: %v but expected: %v", f, "1.5")
+	}
+}
+
+func TestDecimalLevels(t *testing.T) {
+	t.Parallel()
+	levels := DecimalLevels([]orderbook.Item{{Price: 0.1 + 0.2, Amount: 1e-8, ID: 1}, {Price: 0.3, Amount: 2}})
+	if len(levels) != 2 {
+		t.Fatal("unexpected level count")
+	}
+	if levels[0].Amount.String() != "0.00000001" || levels[0].ID != 1 {
+		t.Errorf("unexpected level %+v", levels[0])
+	}
+	if levels[1].Price.String() != "0.3" {
+		t.Errorf("received: %v but expected: %v", levels[1].Price, "0.3")
+	}
+
+	b, cp := precisionTestBase(t)
+	if _, err := b.DecimalLevels(asset.Futures, cp, nil); !errors.Is(err, errPrecisionNotLoaded) {
+		t.Fatalf("received: %v but expected: %v", err, errPrecisionNotLoaded)
+	}
+	levels, err := b.DecimalLevels(asset.Spot, cp, []orderbook.Item{{Price: 0.1 + 0.2, Amount: 0.00001 * 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if levels[0].Price.String() != "0.3" || levels[0].Amount.String() != "0.00003" {
+		t.Errorf("unexpected quantised level %+v", levels[0])
+	}
+}
+
+func TestTickerDecimal(t *testing.T) {
+	t.Parallel()
+	b, cp := precisionTestBase(t)
+	if _, err := b.TickerDecimal(nil); err == nil {
+		t.Fatal("expected error on nil ticker")
+	}
+	if _, err := b.TickerDecimal(&ticker.Price{Pair: cp, AssetType: asset.Margin}); !errors.Is(err, errPrecisionNotLoaded) {
+		t.Fatalf("received: %v but expected: %v", err, errPrecisionNotLoaded)
+	}
+	p, err := b.TickerDecimal(&ticker.Price{Pair: cp, AssetType: asset.Spot, Last: 0.1 + 0.2, Volume: 1.1 * 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Last.String() != "0.3" || p.Volume.String() != "3.3" || p.Pair != cp {
+		t.Errorf("unexpected quantised ticker %+v", p)
+	}
+}
+
+func TestFeeDecimal(t *testing.T) {
+	t.Parallel()
+	e := fixedFee{rate: 0.001, flat: 0.0005}
+	if _, err := FeeDecimal(e, nil, decimal.Zero, decimal.Zero); err == nil {
+		t.Fatal("expected error on nil fee builder")
+	}
+	fee, err := FeeDecimal(e,
+		&FeeBuilder{FeeType: CryptocurrencyTradeFee},
+		decimal.RequireFromString("0.3"),
+		decimal.RequireFromString("0.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fee.String() != "0.00003" {
+		t.Errorf("received: %v but expected: %v", fee, "0.00003")
+	}
+	fee, err = FeeDecimal(e, &FeeBuilder{FeeType: CryptocurrencyWithdrawalFee}, decimal.Zero, decimal.NewFromInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fee.String() != "0.0005" {
+		t.Errorf("received: %v but expected: %v", fee, "0.0005")
+	}
+}
@@ -0,0 +1,299 @@
+package irix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"time"
+
+	"github.com/openware/pkg/log"
+	"github.com/openware/pkg/request"
+)
+
+const (
+	// EndpointListSeparator separates the failover URLs configured for a
+	// single endpoint key, in order of preference
+	EndpointListSeparator = ","
+
+	// DefaultEndpointFailureThreshold is the number of consecutive failures
+	// after which an endpoint is taken out of rotation
+	DefaultEndpointFailureThreshold = 3
+	// DefaultEndpointCooldown is how long an unhealthy endpoint is skipped
+	// before it is tried again
+	DefaultEndpointCooldown = time.Second * 30
+	// DefaultEndpointMaxLatency is the response latency above which a request
+	// counts as a failure of the endpoint which served it
+	DefaultEndpointMaxLatency = time.Second * 10
+
+	// endpointLatencyWeight is the weight of the newest sample in the moving
+	// average of an endpoint's latency
+	endpointLatencyWeight = 0.2
+)
+
+var (
+	errInvalidHealthPolicy = errors.New("endpoint health policy values must be positive")
+	errNoEndpointForKey    = errors.New("no endpoint path found for the given key")
+)
+
+// endpoint tracks the passive health of one candidate URL of an endpoint key
+type endpoint struct {
+	url       string
+	failures  int
+	latency   time.Duration
+	downUntil time.Time
+}
+
+// healthy reports whether the endpoint is in rotation at t
+func (e *endpoint) healthy(t time.Time) bool {
+	return !t.Before(e.downUntil)
+}
+
+// EndpointStatus is a snapshot of the health of a candidate URL
+type EndpointStatus struct {
+	URL                 string
+	Healthy             bool
+	ConsecutiveFailures int
+	Latency             time.Duration
+	DownUntil           time.Time
+}
+
+// splitEndpointURLs splits a configured endpoint value into its candidate
+// URLs, dropping empty entries
+func splitEndpointURLs(val string) []string {
+	var urls []string
+	for _, u := range strings.Split(val, EndpointListSeparator) {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// SetHealthPolicy sets how many consecutive failures take an endpoint out of
+// rotation, how long it stays out and the latency above which a response
+// counts as a failure
+func (e *Endpoints) SetHealthPolicy(failureThreshold int, cooldown, maxLatency time.Duration) error {
+	if failureThreshold <= 0 || cooldown <= 0 || maxLatency <= 0 {
+		return errInvalidHealthPolicy
+	}
+	e.Lock()
+	e.failureThreshold = failureThreshold
+	e.cooldown = cooldown
+	e.maxLatency = maxLatency
+	e.Unlock()
+	return nil
+}
+
+// GetURLs returns every candidate URL for a key in order of preference
+func (e *Endpoints) GetURLs(key URL) ([]string, error) {
+	e.RLock()
+	defer e.RUnlock()
+	candidates, ok := e.health[key.String()]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", errNoEndpointForKey, key)
+	}
+	urls := make([]string, len(candidates))
+	for x := range candidates {
+		urls[x] = candidates[x].url
+	}
+	return urls, nil
+}
+
+// GetEndpointStatus returns the health of every candidate URL for a key in
+// order of preference
+func (e *Endpoints) GetEndpointStatus(key URL) ([]EndpointStatus, error) {
+	e.RLock()
+	defer e.RUnlock()
+	candidates, ok := e.health[key.String()]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", errNoEndpointForKey, key)
+	}
+	now := time.Now()
+	status := make([]EndpointStatus, len(candidates))
+	for x := range candidates {
+		status[x] = EndpointStatus{
+			URL:                 candidates[x].url,
+			Healthy:             candidates[x].healthy(now),
+			ConsecutiveFailures: candidates[x].failures,
+			Latency:             candidates[x].latency,
+			DownUntil:           candidates[x].downUntil,
+		}
+	}
+	return status, nil
+}
+
+// ReportSuccess records a response from the endpoint serving rawURL. A
+// response slower than the maximum latency counts as a failure.
+func (e *Endpoints) ReportSuccess(rawURL string, latency time.Duration) {
+	e.Lock()
+	defer e.Unlock()
+	for _, ep := range e.match(rawURL) {
+		if ep.latency == 0 {
+			ep.latency = latency
+		} else {
+			ep.latency += time.Duration(endpointLatencyWeight * float64(latency-ep.latency))
+		}
+		if latency > e.maxLatency {
+			e.fail(ep)
+			continue
+		}
+		if !ep.healthy(time.Now()) || ep.failures >= e.failureThreshold {
+			log.Infof(log.ExchangeSys, "%s endpoint %s recovered", e.Exchange, ep.url)
+		}
+		ep.failures = 0
+		ep.downUntil = time.Time{}
+	}
+}
+
+// ReportFailure records a failed request to the endpoint serving rawURL
+func (e *Endpoints) ReportFailure(rawURL string) {
+	e.Lock()
+	defer e.Unlock()
+	for _, ep := range e.match(rawURL) {
+		e.fail(ep)
+	}
+}
+
+// fail records a failure against an endpoint and takes it out of rotation
+// once the threshold is reached. An endpoint which fails again straight after
+// its cooldown is taken out again without waiting on the threshold. The
+// caller must hold the lock.
+func (e *Endpoints) fail(ep *endpoint) {
+	ep.failures++
+	if ep.failures < e.failureThreshold {
+		return
+	}
+	ep.downUntil = time.Now().Add(e.cooldown)
+	log.Warnf(log.ExchangeSys,
+		"%s endpoint %s failed %d consecutive requests, skipping until %s",
+		e.Exchange,
+		ep.url,
+		ep.failures,
+		ep.downUntil.Format(time.RFC3339))
+}
+
+// match returns every candidate whose URL prefixes rawURL. The same URL can
+// be configured under several keys so all of them are returned. The caller
+// must hold the lock.
+func (e *Endpoints) match(rawURL string) []*endpoint {
+	var matched []*endpoint
+	for _, candidates := range e.health {
+		for x := range candidates {
+			if strings.HasPrefix(rawURL, candidates[x].url) {
+				matched = append(matched, candidates[x])
+			}
+		}
+	}
+	return matched
+}
+
+// selectURL picks the preferred healthy candidate. When every candidate is
+// out of rotation the one which recovers first is used so requests are never
+// refused outright. The caller must hold the lock.
+func selectURL(candidates []*endpoint, now time.Time, skip map[string]bool) (string, bool) {
+	var fallback *endpoint
+	for x := range candidates {
+		if skip[candidates[x].url] {
+			continue
+		}
+		if candidates[x].healthy(now) {
+			return candidates[x].url, true
+		}
+		if fallback == nil || candidates[x].downUntil.Before(fallback.downUntil) {
+			fallback = candidates[x]
+		}
+	}
+	if fallback == nil {
+		return "", false
+	}
+	return fallback.url, true
+}
+
+// failover returns rawURL rewritten onto the next candidate which has not
+// been tried yet
+func (e *Endpoints) failover(rawURL string, tried map[string]bool) (string, bool) {
+	e.RLock()
+	defer e.RUnlock()
+	now := time.Now()
+	for _, candidates := range e.health {
+		for x := range candidates {
+			if !strings.HasPrefix(rawURL, candidates[x].url) {
+				continue
+			}
+			next, ok := selectURL(candidates, now, tried)
+			if !ok {
+				return "", false
+			}
+			return next + strings.TrimPrefix(rawURL, candidates[x].url), true
+		}
+	}
+	return "", false
+}
+
+// SendPayload sends a request once the adaptive rate limiter allows it and
+// tracks the health of the endpoint which served it. Idempotent requests which
+// fail because the endpoint is unreachable or erroring are retried against the
+// next configured candidate for the same key.
+func (b *Base) SendPayload(ctx context.Context, i *request.Item) error {
+	if i == nil {
+		return b.Requester.SendPayload(ctx, i)
+	}
+	if b.API.Endpoints == nil {
+		return b.sendLimited(ctx, i)
+	}
+	item := *i
+	tried := make(map[string]bool)
+	for {
+		var wrote, firstByte time.Time
+		trace := &httptrace.ClientTrace{
+			WroteRequest:         func(httptrace.WroteRequestInfo) { wrote = time.Now() },
+			GotFirstResponseByte: func() { firstByte = time.Now() },
+		}
+		err := b.sendLimited(httptrace.WithClientTrace(ctx, trace), &item)
+		responded := !firstByte.IsZero()
+		if responded && !isServerError(err) {
+			b.API.Endpoints.ReportSuccess(item.Path, firstByte.Sub(wrote))
+			return err
+		}
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		b.API.Endpoints.ReportFailure(item.Path)
+		if item.Method != http.MethodGet || item.Body != nil || item.NonceEnabled {
+			return err
+		}
+		for _, ep := range b.API.Endpoints.matchURLs(item.Path) {
+			tried[ep] = true
+		}
+		next, ok := b.API.Endpoints.failover(item.Path, tried)
+		if !ok {
+			return err
+		}
+		if b.Verbose {
+			log.Debugf(log.ExchangeSys, "%s request to %s failed, retrying on %s: %v",
+				b.Name, item.Path, next, err)
+		}
+		item.Path = next
+	}
+}
+
+// matchURLs returns the candidate URLs which prefix rawURL
+func (e *Endpoints) matchURLs(rawURL string) []string {
+	e.RLock()
+	defer e.RUnlock()
+	matched := e.match(rawURL)
+	urls := make([]string, len(matched))
+	for x := range matched {
+		urls[x] = matched[x].url
+	}
+	return urls
+}
+
+// isServerError reports whether a request error carries a 5xx status. The
+// requester only surfaces the status in the error text.
+func isServerError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "unsuccessful HTTP status code: 5")
+}
@@ -0,0 +1,187 @@
+package irix
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openware/pkg/request"
+)
+
+func newFailoverEndpoints(t *testing.T, urls string) *Endpoints {
+	t.Helper()
+	b := Base{Name: "failover"}
+	e := b.NewEndpoints()
+	if err := e.SetRunning(RestSpot.String(), urls); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestSetRunningFailoverList(t *testing.T) {
+	t.Parallel()
+	e := newFailoverEndpoints(t, "https://primary.com, https://mirror.com,")
+	urls, err := e.GetURLs(RestSpot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 2 || urls[0] != "https://primary.com" || urls[1] != "https://mirror.com" {
+		t.Errorf("unexpected candidates %v", urls)
+	}
+	if m := e.GetURLMap(); m[RestSpot.String()] != "https://primary.com,https://mirror.com" {
+		t.Errorf("unexpected url map value %s", m[RestSpot.String()])
+	}
+	if _, err = e.GetURLs(RestFutures); !errors.Is(err, errNoEndpointForKey) {
+		t.Errorf("expected %v, received %v", errNoEndpointForKey, err)
+	}
+
+	// An invalid candidate leaves the existing list in place
+	if err = e.SetRunning(RestSpot.String(), "https://other.com,bad"); err != nil {
+		t.Fatal(err)
+	}
+	if u, _ := e.GetURL(RestSpot); u != "https://primary.com" {
+		t.Errorf("expected primary, received %s", u)
+	}
+}
+
+func TestEndpointHealth(t *testing.T) {
+	t.Parallel()
+	e := newFailoverEndpoints(t, "https://primary.com,https://mirror.com")
+	if err := e.SetHealthPolicy(0, time.Second, time.Second); !errors.Is(err, errInvalidHealthPolicy) {
+		t.Errorf("expected %v, received %v", errInvalidHealthPolicy, err)
+	}
+	if err := e.SetHealthPolicy(2, time.Millisecond*50, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	e.ReportFailure("https://primary.com/api/v3/ticker")
+	if u, _ := e.GetURL(RestSpot); u != "https://primary.com" {
+		t.Errorf("expected primary below threshold, received %s", u)
+	}
+	e.ReportFailure("https://primary.com/api/v3/ticker")
+	if u, _ := e.GetURL(RestSpot); u != "https://mirror.com" {
+		t.Errorf("expected failover to mirror, received %s", u)
+	}
+	status, err := e.GetEndpointStatus(RestSpot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status[0].Healthy || status[0].ConsecutiveFailures != 2 || !status[1].Healthy {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	// Every candidate down falls back to the one which recovers first
+	e.ReportFailure("https://mirror.com/api")
+	e.ReportFailure("https://mirror.com/api")
+	if u, _ := e.GetURL(RestSpot); u != "https://primary.com" {
+		t.Errorf("expected earliest recovery, received %s", u)
+	}
+
+	time.Sleep(time.Millisecond * 60)
+	if u, _ := e.GetURL(RestSpot); u != "https://primary.com" {
+		t.Errorf("expected primary restored after cooldown, received %s", u)
+	}
+	// A failure straight after the cooldown takes it out again
+	e.ReportFailure("https://primary.com/api")
+	if u, _ := e.GetURL(RestSpot); u != "https://mirror.com" {
+		t.Errorf("expected mirror after probation failure, received %s", u)
+	}
+	time.Sleep(time.Millisecond * 60)
+	e.ReportSuccess("https://primary.com/api", time.Millisecond)
+	status, err = e.GetEndpointStatus(RestSpot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status[0].Healthy || status[0].ConsecutiveFailures != 0 || status[0].Latency != time.Millisecond {
+		t.Errorf("unexpected status after recovery %+v", status[0])
+	}
+
+	// Slow responses count as failures
+	e.ReportSuccess("https://primary.com/api", time.Second*2)
+	e.ReportSuccess("https://primary.com/api", time.Second*2)
+	if u, _ := e.GetURL(RestSpot); u != "https://mirror.com" {
+		t.Errorf("expected failover on latency, received %s", u)
+	}
+}
+
+func TestSendPayloadFailover(t *testing.T) {
+	t.Parallel()
+	var primaryHits int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&primaryHits, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer primary.Close()
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/ticker" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"price":1}`))
+	}))
+	defer mirror.Close()
+
+	b := Base{Name: "failover"}
+	b.Requester = request.New(b.Name, new(http.Client))
+	b.API.Endpoints = b.NewEndpoints()
+	if err := b.API.Endpoints.SetRunning(RestSpot.String(), primary.URL+"/api,"+mirror.URL+"/api"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.API.Endpoints.SetHealthPolicy(1, time.Minute, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	var resp struct {
+		Price float64 `json:"price"`
+	}
+	ep, err := b.API.Endpoints.GetURL(RestSpot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.SendPayload(context.Background(), &request.Item{
+		Method: http.MethodGet,
+		Path:   ep + "/ticker",
+		Result: &resp,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Price != 1 {
+		t.Errorf("expected mirror response, received %v", resp.Price)
+	}
+	if ep, _ = b.API.Endpoints.GetURL(RestSpot); ep != mirror.URL+"/api" {
+		t.Errorf("expected mirror preferred, received %s", ep)
+	}
+
+	// Non idempotent requests are not retried
+	err = b.SendPayload(context.Background(), &request.Item{
+		Method: http.MethodPost,
+		Path:   primary.URL + "/api/order",
+	})
+	if err == nil {
+		t.Error("expected error from primary")
+	}
+	if hits := atomic.LoadInt32(&primaryHits); hits != 2 {
+		t.Errorf("expected two primary requests, received %d", hits)
+	}
+
+	// Client errors do not affect health
+	err = b.SendPayload(context.Background(), &request.Item{
+		Method: http.MethodGet,
+		Path:   mirror.URL + "/api/missing",
+	})
+	if err == nil {
+		t.Error("expected not found error")
+	}
+	status, err := b.API.Endpoints.GetEndpointStatus(RestSpot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status[1].Healthy || status[1].ConsecutiveFailures != 0 {
+		t.Errorf("unexpected mirror status %+v", status[1])
+	}
+}
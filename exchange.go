@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/openware/irix/config"
@@ -160,6 +161,7 @@ func (b *Base) SetFeatureDefaults() {
 		}
 
 		b.Features.Enabled.AutoPairUpdates = b.Config.Features.Enabled.AutoPairUpdates
+		b.Features.Enabled.RoundOrdersToLimits = b.Config.Features.Enabled.RoundOrdersToLimits
 	}
 }
 
@@ -420,7 +422,9 @@ func (b *Base) GetRequestFormattedPairAndAssetType(p string) (currency.Pair, ass
 			return response, assetTypes[i], err
 		}
 
-		pairs, err := b.CurrencyPairs.GetPairs(assetTypes[i], true)
+		// Enabled pairs are split using the config format so index based
+		// pairs such as QTUMKRW resolve to the correct base currency
+		pairs, err := b.GetEnabledPairs(assetTypes[i])
 		if err != nil {
 			return response, assetTypes[i], err
 		}
@@ -547,6 +551,7 @@ func (b *Base) SetupDefaults(exch *config.ExchangeConfig) error {
 	b.LoadedByConfig = true
 	b.Config = exch
 	b.Verbose = exch.Verbose
+	b.Clock.Exchange = b.Name
 
 	b.API.AuthenticatedSupport = exch.API.AuthenticatedSupport
 	b.API.AuthenticatedWebsocketSupport = exch.API.AuthenticatedWebsocketSupport
@@ -589,6 +594,16 @@ func (b *Base) SetupDefaults(exch *config.ExchangeConfig) error {
 		return err
 	}
 
+	err = b.SetNonceStore(exch.API.Nonce)
+	if err != nil {
+		return err
+	}
+
+	err = b.SetRateLimitStore(exch.API.RateLimit)
+	if err != nil {
+		return err
+	}
+
 	b.SetAPICredentialDefaults()
 
 	err = b.SetClientProxyAddress(exch.ProxyAddress)
@@ -830,7 +845,9 @@ func (b *Base) SetAPIURL() error {
 				val == config.WebsocketURLNonDefaultMessage {
 				continue
 			}
-			checkInsecureEndpoint(val)
+			for _, u := range splitEndpointURLs(val) {
+				checkInsecureEndpoint(u)
+			}
 			err = b.API.Endpoints.SetRunning(key, val)
 			if err != nil {
 				return err
@@ -842,6 +859,38 @@ func (b *Base) SetAPIURL() error {
 	return nil
 }
 
+// SetServerTimeSource registers the exchange's server time so signed requests
+// use the corrected clock. It has no effect without authenticated support as
+// only signed requests depend on the server time. Sampling stops when the
+// exchange or its websocket shuts down.
+//
+// Only exchanges which sign with a timestamp checked against a window and
+// provide a server time endpoint use it: Binance, BTC Markets, BTSE, Coinbase
+// Pro, Huobi, Kraken, OKCoin and OKEX. Bitmex and FTX sign with the clock but
+// have no server time endpoint, so it returns the local time. Exchanges
+// signing with an increasing nonce do not depend on the server time and are
+// excluded.
+func (b *Base) SetServerTimeSource(source TimeSource) {
+	if !b.API.AuthenticatedSupport && !b.API.AuthenticatedWebsocketSupport {
+		return
+	}
+	if !b.Clock.hasSource() && b.Websocket != nil {
+		b.Websocket.OnShutdown(b.Clock.Stop)
+	}
+	b.Clock.Exchange = b.Name
+	b.Clock.SetSource(source, DefaultClockSyncInterval)
+}
+
+// Shutdown stops the exchange's background routines, the websocket is shut
+// down when connected and the server clock stops sampling
+func (b *Base) Shutdown() error {
+	b.Clock.Stop()
+	if b.Websocket != nil && b.Websocket.IsConnected() {
+		return b.Websocket.Shutdown()
+	}
+	return nil
+}
+
 // SupportsREST returns whether or not the exchange supports
 // REST
 func (b *Base) SupportsREST() bool {
@@ -947,14 +996,23 @@ func (b *Base) CheckTransientError(err error) error {
 	return err
 }
 
-// DisableRateLimiter disables the rate limiting system for the exchange
+// DisableRateLimiter disables the rate limiting system for the exchange,
+// including pacing learnt from response headers
 func (b *Base) DisableRateLimiter() error {
-	return b.Requester.DisableRateLimiter()
+	err := b.Requester.DisableRateLimiter()
+	if err == nil {
+		atomic.StoreInt32(&b.rateLimitDisabled, 1)
+	}
+	return err
 }
 
 // EnableRateLimiter enables the rate limiting system for the exchange
 func (b *Base) EnableRateLimiter() error {
-	return b.Requester.EnableRateLimiter()
+	err := b.Requester.EnableRateLimiter()
+	if err == nil {
+		atomic.StoreInt32(&b.rateLimitDisabled, 0)
+	}
+	return err
 }
 
 // StoreAssetPairFormat initialises and stores a defined asset format
@@ -1161,8 +1219,12 @@ func (b *Base) SetSaveTradeDataStatus(enabled bool) {
 // NewEndpoints declares default and running URLs maps
 func (b *Base) NewEndpoints() *Endpoints {
 	return &Endpoints{
-		Exchange: b.Name,
-		defaults: make(map[string]string),
+		Exchange:         b.Name,
+		defaults:         make(map[string]string),
+		health:           make(map[string][]*endpoint),
+		failureThreshold: DefaultEndpointFailureThreshold,
+		cooldown:         DefaultEndpointCooldown,
+		maxLatency:       DefaultEndpointMaxLatency,
 	}
 }
 
@@ -1177,7 +1239,9 @@ func (e *Endpoints) SetDefaultEndpoints(m map[URL]string) error {
 	return nil
 }
 
-// SetRunning populates running URLs map
+// SetRunning populates running URLs map. The value may list several
+// failover URLs separated by EndpointListSeparator in order of preference,
+// health already tracked for a URL is kept.
 func (e *Endpoints) SetRunning(key, val string) error {
 	e.Lock()
 	defer e.Unlock()
@@ -1185,16 +1249,35 @@ func (e *Endpoints) SetRunning(key, val string) error {
 	if err != nil {
 		return err
 	}
-	_, err = url.ParseRequestURI(val)
-	if err != nil {
-		log.Warnf(log.ExchangeSys,
-			"Could not set custom URL for %s to %s for exchange %s. invalid URI for request.",
-			key,
-			val,
-			e.Exchange)
-		return nil
+	urls := splitEndpointURLs(val)
+	if len(urls) == 0 {
+		urls = []string{val}
+	}
+	for x := range urls {
+		_, err = url.ParseRequestURI(urls[x])
+		if err != nil {
+			log.Warnf(log.ExchangeSys,
+				"Could not set custom URL for %s to %s for exchange %s. invalid URI for request.",
+				key,
+				val,
+				e.Exchange)
+			return nil
+		}
+	}
+	existing := make(map[string]*endpoint)
+	for _, ep := range e.health[key] {
+		existing[ep.url] = ep
+	}
+	candidates := make([]*endpoint, len(urls))
+	for x := range urls {
+		if ep, ok := existing[urls[x]]; ok {
+			candidates[x] = ep
+			continue
+		}
+		candidates[x] = &endpoint{url: urls[x]}
 	}
-	e.defaults[key] = val
+	e.defaults[key] = strings.Join(urls, EndpointListSeparator)
+	e.health[key] = candidates
 	return nil
 }
 
@@ -1207,18 +1290,19 @@ func validateKey(keyVal string) error {
 	return errors.New("keyVal invalid")
 }
 
-// GetURL gets default url from URLs map
+// GetURL gets the preferred healthy url for a key from URLs map
 func (e *Endpoints) GetURL(key URL) (string, error) {
 	e.RLock()
 	defer e.RUnlock()
-	val, ok := e.defaults[key.String()]
+	val, ok := selectURL(e.health[key.String()], time.Now(), nil)
 	if !ok {
-		return "", fmt.Errorf("no endpoint path found for the given key: %v", key)
+		return "", fmt.Errorf("%w: %v", errNoEndpointForKey, key)
 	}
 	return val, nil
 }
 
-// GetURLMap gets all urls for either running or default map based on the bool value supplied
+// GetURLMap gets all configured urls by key, failover urls are joined by
+// EndpointListSeparator as they are in the config
 func (e *Endpoints) GetURLMap() map[string]string {
 	e.RLock()
 	var urlMap = make(map[string]string)
@@ -1271,9 +1355,10 @@ func (u URL) String() string {
 	}
 }
 
-// UpdateOrderExecutionLimits updates order execution limits this is overridable
+// UpdateOrderExecutionLimits updates order execution limits this is overridable.
+// Exchanges which cannot provide limits report them unsupported.
 func (b *Base) UpdateOrderExecutionLimits(a asset.Item) error {
-	return common.ErrNotYetImplemented
+	return fmt.Errorf("%s %s order execution limits %w", b.Name, a, common.ErrFunctionNotSupported)
 }
 
 // DisableAssetWebsocketSupport disables websocket functionality for the
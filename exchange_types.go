@@ -5,9 +5,13 @@ import (
 	"time"
 
 	"github.com/openware/irix/config"
+	"github.com/openware/irix/metrics"
+	"github.com/openware/irix/nonce"
 	"github.com/openware/irix/protocol"
+	"github.com/openware/irix/ratelimit"
 	"github.com/openware/irix/stream"
 	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/common/timedmutex"
 	"github.com/openware/pkg/currency"
 	"github.com/openware/pkg/kline"
 	"github.com/openware/pkg/order"
@@ -156,6 +160,9 @@ type FeaturesEnabled struct {
 	AutoPairUpdates bool
 	Kline           kline.ExchangeCapabilitiesEnabled
 	SaveTradeData   bool
+	// RoundOrdersToLimits rounds order prices and amounts to the loaded tick
+	// and lot sizes before they are validated and submitted
+	RoundOrdersToLimits bool
 }
 
 // FeaturesSupported stores the exchanges supported features
@@ -168,10 +175,17 @@ type FeaturesSupported struct {
 	Kline                 kline.ExchangeCapabilitiesSupported
 }
 
-// Endpoints stores running url endpoints for exchanges
+// Endpoints stores running url endpoints for exchanges. Each key holds one or
+// more candidate URLs in order of preference, requests go to the first
+// candidate which is healthy.
 type Endpoints struct {
 	Exchange string
 	defaults map[string]string
+	health   map[string][]*endpoint
+
+	failureThreshold int
+	cooldown         time.Duration
+	maxLatency       time.Duration
 	sync.RWMutex
 }
 
@@ -228,6 +242,29 @@ type Base struct {
 	// integrity.
 	CanVerifyOrderbook bool
 	order.ExecutionLimits
+	precision  precisionStore
+	limitsLoad limitsLoadStore
+	// Clock corrects the local time by the server offset for signed requests
+	Clock Clock
+	// NonceFormat is the format the exchange expects nonces in, it can be
+	// overridden by config
+	NonceFormat nonce.Format
+	nonceStore  nonce.Store
+	nonces      *nonce.Manager
+	nonceKey    string
+	nonceLock   *timedmutex.TimedMutex
+	nonceMtx    sync.Mutex
+	// RateLimitBuckets are the usage headers the exchange reports, read by
+	// the adaptive rate limiter alongside the generic X-RateLimit-* headers
+	RateLimitBuckets  []ratelimit.Bucket
+	rateLimitStore    ratelimit.Store
+	rateLimiter       *ratelimit.Limiter
+	rateLimitKey      string
+	rateLimitDisabled int32
+	rateLimitMtx      sync.Mutex
+	// Metrics receives REST and websocket measurements, metrics.Default is
+	// used when unset
+	Metrics metrics.Recorder
 
 	AssetWebsocketSupport
 }
@@ -11,10 +11,12 @@ import (
 	"time"
 
 	exchange "github.com/openware/irix"
+	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/common"
 	"github.com/openware/pkg/common/crypto"
 	"github.com/openware/pkg/currency"
 	"github.com/openware/pkg/log"
+	"github.com/openware/pkg/order"
 	"github.com/openware/pkg/request"
 )
 
@@ -85,6 +87,34 @@ func (e *EXMO) GetPairSettings() (map[string]PairSettings, error) {
 	return result, e.SendHTTPRequest(exchange.RestSpot, urlPath, &result)
 }
 
+// FetchExchangeLimits fetches spot order execution limits. Amounts are in the
+// quote currency and quantities in the base currency.
+func (e *EXMO) FetchExchangeLimits() ([]order.MinMaxLevel, error) {
+	settings, err := e.GetPairSettings()
+	if err != nil {
+		return nil, err
+	}
+	limits := make([]order.MinMaxLevel, 0, len(settings))
+	for symbol, s := range settings {
+		var cp currency.Pair
+		cp, err = currency.NewPairFromString(symbol)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, order.MinMaxLevel{
+			Pair:        cp,
+			Asset:       asset.Spot,
+			MinPrice:    s.MinPrice,
+			MaxPrice:    s.MaxPrice,
+			StepPrice:   exchange.StepFromDecimalPlaces(s.PricePrecision),
+			MinAmount:   s.MinQuantity,
+			MaxAmount:   s.MaxQuantity,
+			MinNotional: s.MinAmount,
+		})
+	}
+	return limits, nil
+}
+
 // GetCurrency returns a list of currencies
 func (e *EXMO) GetCurrency() ([]string, error) {
 	var result []string
@@ -327,7 +357,12 @@ func (e *EXMO) SendAuthenticatedHTTPRequest(epath exchange.URL, method, endpoint
 		return err
 	}
 
-	n := e.Requester.GetNonce(true).String()
+	nonce, release, err := e.NextNonce()
+	if err != nil {
+		return err
+	}
+	defer release()
+	n := nonce.String()
 	vals.Set("nonce", n)
 
 	payload := vals.Encode()
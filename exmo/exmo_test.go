@@ -467,3 +467,14 @@ func TestGetHistoricTrades(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	err := e.UpdateOrderExecutionLimits(asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = e.GetOrderExecutionLimits(asset.Spot, currency.NewPair(currency.BTC, currency.USD)); err != nil {
+		t.Fatal(err)
+	}
+}
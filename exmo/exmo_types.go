@@ -45,6 +45,8 @@ type PairSettings struct {
 	MaxPrice    float64 `json:"max_price,string"`
 	MaxAmount   float64 `json:"max_amount,string"`
 	MinAmount   float64 `json:"min_amount,string"`
+	// PricePrecision is the decimal places of the price
+	PricePrecision int64 `json:"price_precision"`
 }
 
 // AuthResponse stores the auth response
@@ -141,6 +141,20 @@ func (e *EXMO) Run() {
 		e.PrintEnabledPairs()
 	}
 
+	a := e.GetAssetTypes()
+	for x := range a {
+		if err := e.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
+			err = e.UpdateOrderExecutionLimits(a[x])
+			e.SetOrderExecutionLimitsLoadError(a[x], err)
+			if err != nil {
+				log.Errorf(log.ExchangeSys,
+					"Could not set %s exchange exchange limits: %v",
+					e.Name,
+					err)
+			}
+		}
+	}
+
 	if !e.GetEnabledFeatures().AutoPairUpdates {
 		return
 	}
@@ -432,6 +446,9 @@ func (e *EXMO) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 	if err := s.Validate(); err != nil {
 		return submitOrderResponse, err
 	}
+	if err := e.ConformOrderToLimits(s); err != nil {
+		return submitOrderResponse, err
+	}
 
 	var oT string
 	switch s.Type {
@@ -673,3 +690,15 @@ func (e *EXMO) GetHistoricCandles(pair currency.Pair, a asset.Item, start, end t
 func (e *EXMO) GetHistoricCandlesExtended(pair currency.Pair, a asset.Item, start, end time.Time, interval kline.Interval) (kline.Item, error) {
 	return kline.Item{}, common.ErrFunctionNotSupported
 }
+
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (e *EXMO) UpdateOrderExecutionLimits(a asset.Item) error {
+	if a != asset.Spot {
+		return fmt.Errorf("%s %w", a, asset.ErrNotSupported)
+	}
+	limits, err := e.FetchExchangeLimits()
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return e.LoadLimits(limits)
+}
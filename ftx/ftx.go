@@ -14,6 +14,7 @@ import (
 	"time"
 
 	exchange "github.com/openware/irix"
+	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/common"
 	"github.com/openware/pkg/common/crypto"
 	"github.com/openware/pkg/currency"
@@ -113,6 +114,7 @@ const (
 	closedStatus          = "closed"
 	spotString            = "spot"
 	futuresString         = "future"
+	orderNotFoundMsg      = "Order not found"
 
 	ratePeriod = time.Second
 	rateLimit  = 30
@@ -135,6 +137,46 @@ func (f *FTX) GetMarkets() ([]MarketData, error) {
 	return resp.Data, f.SendHTTPRequest(exchange.RestSpot, getMarkets, &resp)
 }
 
+// FetchExchangeLimits fetches market metadata and returns the tick and lot
+// sizes for the supplied asset type
+func (f *FTX) FetchExchangeLimits(a asset.Item) ([]order.MinMaxLevel, error) {
+	var marketType string
+	switch a {
+	case asset.Spot:
+		marketType = spotString
+	case asset.Futures:
+		marketType = futuresString
+	default:
+		return nil, fmt.Errorf("%s %w", a, asset.ErrNotSupported)
+	}
+	markets, err := f.GetMarkets()
+	if err != nil {
+		return nil, err
+	}
+	var limits []order.MinMaxLevel
+	for x := range markets {
+		if markets[x].MarketType != marketType {
+			continue
+		}
+		var cp currency.Pair
+		cp, err = currency.NewPairFromString(markets[x].Name)
+		if err != nil {
+			return nil, err
+		}
+		// The smallest order is a single size increment at a single price
+		// increment
+		limits = append(limits, order.MinMaxLevel{
+			Pair:       cp,
+			Asset:      a,
+			MinPrice:   markets[x].PriceIncrement,
+			StepPrice:  markets[x].PriceIncrement,
+			MinAmount:  markets[x].SizeIncrement,
+			StepAmount: markets[x].SizeIncrement,
+		})
+	}
+	return limits, nil
+}
+
 // GetMarket gets market data for a provided asset type
 func (f *FTX) GetMarket(marketName string) (MarketData, error) {
 	resp := struct {
@@ -562,14 +604,15 @@ func (f *FTX) GetTriggerOrderHistory(marketName string, startTime, endTime time.
 	return resp.Data, f.SendAuthHTTPRequest(exchange.RestSpot, http.MethodGet, endpoint, nil, &resp)
 }
 
-// Order places an order
-func (f *FTX) Order(marketName, side, orderType, reduceOnly, ioc, postOnly, clientID string, price, size float64) (OrderData, error) {
+// Order places an order, price and size are sent as the exact numbers they
+// are formatted as
+func (f *FTX) Order(marketName, side, orderType, reduceOnly, ioc, postOnly, clientID, price, size string) (OrderData, error) {
 	req := make(map[string]interface{})
 	req["market"] = marketName
 	req["side"] = side
-	req["price"] = price
+	req["price"] = json.Number(price)
 	req["type"] = orderType
-	req["size"] = size
+	req["size"] = json.Number(size)
 	if reduceOnly != "" {
 		req["reduceOnly"] = reduceOnly
 	}
@@ -984,7 +1027,7 @@ func (f *FTX) SendAuthHTTPRequest(ep exchange.URL, method, path string, data, re
 	if err != nil {
 		return err
 	}
-	ts := strconv.FormatInt(time.Now().UnixNano()/1000000, 10)
+	ts := strconv.FormatInt(f.Clock.Now().UnixNano()/1000000, 10)
 	var body io.Reader
 	var hmac, payload []byte
 	if data != nil {
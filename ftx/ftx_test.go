@@ -491,7 +491,7 @@ func TestOrder(t *testing.T) {
 	if !areTestAPIKeysSet() || !canManipulateRealOrders {
 		t.Skip("skipping test, either api keys or canManipulateRealOrders isnt set correctly")
 	}
-	_, err := f.Order(spotPair, order.Buy.Lower(), "limit", "", "", "", "", 0.0001, 500)
+	_, err := f.Order(spotPair, order.Buy.Lower(), "limit", "", "", "", "", "0.0001", "500")
 	if err != nil {
 		t.Error(err)
 	}
@@ -1570,3 +1570,25 @@ func TestSubaccountTransfer(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	err := f.UpdateOrderExecutionLimits(asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = f.UpdateOrderExecutionLimits(asset.Margin); err == nil {
+		t.Fatal("expected unhandled case")
+	}
+	cp, err := currency.NewPairFromString("BTC/USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := f.GetPrecision(asset.Spot, cp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.PriceTick.IsPositive() || !p.AmountStep.IsPositive() {
+		t.Fatalf("unexpected precision %+v", p)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"hash/crc32"
 	"net/http"
 	"strconv"
 	"strings"
@@ -42,6 +41,16 @@ const (
 
 var obSuccess = make(map[currency.Pair]bool)
 
+// orderbookChecksum verifies merged orderbooks against the checksum sent with
+// each update
+var orderbookChecksum = &buffer.CRC32{
+	Depth:     100,
+	Separator: ":",
+	Format: func(item orderbook.Item, _ bool) string {
+		return checksumParseNumber(item.Price) + ":" + checksumParseNumber(item.Amount)
+	},
+}
+
 // WsConnect connects to a websocket feed
 func (f *FTX) WsConnect() error {
 	if !f.Websocket.IsEnabled() || !f.IsEnabled() {
@@ -74,7 +83,7 @@ func (f *FTX) WsConnect() error {
 
 // WsAuth sends an authentication message to receive auth data
 func (f *FTX) WsAuth() error {
-	intNonce := time.Now().UnixNano() / 1000000
+	intNonce := f.Clock.Now().UnixNano() / 1000000
 	strNonce := strconv.FormatInt(intNonce, 10)
 	hmac := crypto.GetHMAC(
 		crypto.HashSHA256,
@@ -280,12 +289,9 @@ func (f *FTX) wsHandleData(respRaw []byte) error {
 			if len(resultData.OBData.Asks) == 0 && len(resultData.OBData.Bids) == 0 {
 				return nil
 			}
+			// A book failing its checksum is resubscribed by the buffer
 			err = f.WsProcessUpdateOB(&resultData.OBData, p, a)
 			if err != nil {
-				err2 := f.wsResubToOB(p)
-				if err2 != nil {
-					f.Websocket.DataHandler <- err2
-				}
 				return err
 			}
 		case wsTrades:
@@ -407,6 +413,14 @@ func (f *FTX) wsHandleData(respRaw []byte) error {
 				}
 				return err
 			}
+			// A snapshot failing its checksum is resubscribed by the buffer
+			err = f.Websocket.Orderbook.VerifyChecksum(p,
+				a,
+				uint32(resultData.OBData.Checksum),
+				nil)
+			if err != nil {
+				return err
+			}
 			// reset obchecksum failure blockage for pair
 			delete(obSuccess, p)
 		case wsMarkets:
@@ -431,9 +445,9 @@ func (f *FTX) WsProcessUpdateOB(data *WsOrderbookData, p currency.Pair, a asset.
 		Asset:      a,
 		Pair:       p,
 		UpdateTime: timestampFromFloat64(data.Time),
+		Checksum:   uint32(data.Checksum),
 	}
 
-	var err error
 	for x := range data.Bids {
 		update.Bids = append(update.Bids, orderbook.Item{
 			Price:  data.Bids[x][0],
@@ -447,24 +461,7 @@ func (f *FTX) WsProcessUpdateOB(data *WsOrderbookData, p currency.Pair, a asset.
 		})
 	}
 
-	err = f.Websocket.Orderbook.Update(&update)
-	if err != nil {
-		return err
-	}
-
-	updatedOb, err := f.Websocket.Orderbook.GetOrderbook(p, a)
-	if err != nil {
-		return err
-	}
-	checksum := f.CalcUpdateOBChecksum(updatedOb)
-
-	if checksum != data.Checksum {
-		log.Warnf(log.ExchangeSys, "%s checksum failure for item %s",
-			f.Name,
-			p)
-		return errors.New("checksum failed")
-	}
-	return nil
+	return f.Websocket.Orderbook.Update(&update)
 }
 
 func (f *FTX) wsResubToOB(p currency.Pair) error {
@@ -487,13 +484,6 @@ func (f *FTX) wsResubToOB(p currency.Pair) error {
 
 // WsProcessPartialOB creates an OB from websocket data
 func (f *FTX) WsProcessPartialOB(data *WsOrderbookData, p currency.Pair, a asset.Item) error {
-	signedChecksum := f.CalcPartialOBChecksum(data)
-	if signedChecksum != data.Checksum {
-		return fmt.Errorf("%s channel: %s. Orderbook partial for %v checksum invalid",
-			f.Name,
-			a,
-			p)
-	}
 	var bids, asks []orderbook.Item
 	for x := range data.Bids {
 		bids = append(bids, orderbook.Item{
@@ -520,44 +510,17 @@ func (f *FTX) WsProcessPartialOB(data *WsOrderbookData, p currency.Pair, a asset
 	return f.Websocket.Orderbook.LoadSnapshot(&newOrderBook)
 }
 
-// CalcPartialOBChecksum calculates checksum of partial OB data received from WS
-func (f *FTX) CalcPartialOBChecksum(data *WsOrderbookData) int64 {
-	var checksum strings.Builder
-	var price, amount string
-	for i := 0; i < 100; i++ {
-		if len(data.Bids)-1 >= i {
-			price = checksumParseNumber(data.Bids[i][0])
-			amount = checksumParseNumber(data.Bids[i][1])
-			checksum.WriteString(price + ":" + amount + ":")
-		}
-		if len(data.Asks)-1 >= i {
-			price = checksumParseNumber(data.Asks[i][0])
-			amount = checksumParseNumber(data.Asks[i][1])
-			checksum.WriteString(price + ":" + amount + ":")
-		}
-	}
-	checksumStr := strings.TrimSuffix(checksum.String(), ":")
-	return int64(crc32.ChecksumIEEE([]byte(checksumStr)))
-}
-
 // CalcUpdateOBChecksum calculates checksum of update OB data received from WS
 func (f *FTX) CalcUpdateOBChecksum(data *orderbook.Base) int64 {
-	var checksum strings.Builder
-	var price, amount string
-	for i := 0; i < 100; i++ {
-		if len(data.Bids)-1 >= i {
-			price = checksumParseNumber(data.Bids[i].Price)
-			amount = checksumParseNumber(data.Bids[i].Amount)
-			checksum.WriteString(price + ":" + amount + ":")
-		}
-		if len(data.Asks)-1 >= i {
-			price = checksumParseNumber(data.Asks[i].Price)
-			amount = checksumParseNumber(data.Asks[i].Amount)
-			checksum.WriteString(price + ":" + amount + ":")
-		}
-	}
-	checksumStr := strings.TrimSuffix(checksum.String(), ":")
-	return int64(crc32.ChecksumIEEE([]byte(checksumStr)))
+	// Without a minimum depth the checksum cannot fail
+	checksum, _ := orderbookChecksum.Checksum(data.Bids, data.Asks)
+	return int64(checksum)
+}
+
+// isOrderbookChannel reports whether a subscription is to the orderbook
+// channel, which sends a fresh partial when resubscribed
+func isOrderbookChannel(c *stream.ChannelSubscription) bool {
+	return c.Channel == wsOrderbook
 }
 
 func checksumParseNumber(num float64) string {
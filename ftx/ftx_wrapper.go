@@ -186,6 +186,8 @@ func (f *FTX) Setup(exch *config.ExchangeConfig) error {
 		UnSubscriber:                     f.Unsubscribe,
 		GenerateSubscriptions:            f.GenerateDefaultSubscriptions,
 		Features:                         &f.Features.Supports.WebsocketCapabilities,
+		OrderbookChannel:                 isOrderbookChannel,
+		OrderbookChecksum:                orderbookChecksum,
 		OrderbookBufferLimit:             exch.OrderbookConfig.WebsocketBufferLimit,
 		BufferEnabled:                    exch.OrderbookConfig.WebsocketBufferEnabled,
 	})
@@ -217,6 +219,20 @@ func (f *FTX) Run() {
 		f.PrintEnabledPairs()
 	}
 
+	a := f.GetAssetTypes()
+	for x := range a {
+		if err := f.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
+			err = f.UpdateOrderExecutionLimits(a[x])
+			f.SetOrderExecutionLimitsLoadError(a[x], err)
+			if err != nil {
+				log.Errorf(log.ExchangeSys,
+					"Could not set %s exchange exchange limits: %v",
+					f.Name,
+					err)
+			}
+		}
+	}
+
 	if !f.GetEnabledFeatures().AutoPairUpdates {
 		return
 	}
@@ -538,6 +554,13 @@ func (f *FTX) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 	if err := s.Validate(); err != nil {
 		return resp, err
 	}
+	if err := exchange.AttachClientOrderID(f, s); err != nil {
+		return resp, err
+	}
+	if err := f.ConformOrderToLimits(s); err != nil {
+		return resp, err
+	}
+	price, amount := f.SubmitValues(s)
 
 	if s.Side == order.Ask {
 		s.Side = order.Sell
@@ -558,8 +581,8 @@ func (f *FTX) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 		"",
 		"",
 		s.ClientOrderID,
-		s.Price,
-		s.Amount)
+		price,
+		amount)
 	if err != nil {
 		return resp, err
 	}
@@ -700,11 +723,32 @@ func (s *OrderData) GetCompatible(f *FTX) (OrderVars, error) {
 
 // GetOrderInfo returns order information based on order ID
 func (f *FTX) GetOrderInfo(orderID string, pair currency.Pair, assetType asset.Item) (order.Detail, error) {
-	var resp order.Detail
 	orderData, err := f.GetOrderStatus(orderID)
 	if err != nil {
-		return resp, err
+		return order.Detail{}, err
+	}
+	return f.orderDataToDetail(&orderData)
+}
+
+// GetOrderInfoByClientOrderID returns order information based on the client
+// order ID supplied on submission
+func (f *FTX) GetOrderInfoByClientOrderID(clientOrderID string, pair currency.Pair, assetType asset.Item) (order.Detail, error) {
+	if clientOrderID == "" {
+		return order.Detail{}, order.ErrOrderIDNotSet
+	}
+	orderData, err := f.GetOrderStatusByClientID(clientOrderID)
+	if err != nil {
+		if strings.Contains(err.Error(), orderNotFoundMsg) {
+			return order.Detail{}, fmt.Errorf("%w: %v", exchange.ErrOrderNotFound, err)
+		}
+		return order.Detail{}, err
 	}
+	return f.orderDataToDetail(&orderData)
+}
+
+// orderDataToDetail converts FTX order data to an order detail
+func (f *FTX) orderDataToDetail(orderData *OrderData) (order.Detail, error) {
+	var resp order.Detail
 	p, err := currency.NewPairFromString(orderData.Market)
 	if err != nil {
 		return resp, err
@@ -1099,3 +1143,12 @@ func (f *FTX) GetHistoricCandlesExtended(p currency.Pair, a asset.Item, start, e
 	ret.SortCandlesByTimestamp(false)
 	return ret, nil
 }
+
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (f *FTX) UpdateOrderExecutionLimits(a asset.Item) error {
+	limits, err := f.FetchExchangeLimits(a)
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return f.LoadLimits(limits)
+}
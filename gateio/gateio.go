@@ -11,10 +11,12 @@ import (
 
 	exchange "github.com/openware/irix"
 	"github.com/openware/irix/portfolio/withdraw"
+	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/common/convert"
 	"github.com/openware/pkg/common/crypto"
 	"github.com/openware/pkg/currency"
 	"github.com/openware/pkg/kline"
+	"github.com/openware/pkg/order"
 	"github.com/openware/pkg/request"
 )
 
@@ -90,6 +92,29 @@ func (g *Gateio) GetMarketInfo() (MarketInfoResponse, error) {
 	return result, nil
 }
 
+// FetchExchangeLimits fetches spot order execution limits
+func (g *Gateio) FetchExchangeLimits() ([]order.MinMaxLevel, error) {
+	info, err := g.GetMarketInfo()
+	if err != nil {
+		return nil, err
+	}
+	limits := make([]order.MinMaxLevel, 0, len(info.Pairs))
+	for x := range info.Pairs {
+		var cp currency.Pair
+		cp, err = currency.NewPairFromString(info.Pairs[x].Symbol)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, order.MinMaxLevel{
+			Pair:      cp,
+			Asset:     asset.Spot,
+			StepPrice: exchange.StepFromDecimalPlaces(int64(info.Pairs[x].DecimalPlaces)),
+			MinAmount: info.Pairs[x].MinAmount,
+		})
+	}
+	return limits, nil
+}
+
 // GetLatestSpotPrice returns latest spot price of symbol
 // updated every 10 seconds
 //
@@ -841,3 +841,14 @@ func TestGetHistoricTrades(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	err := g.UpdateOrderExecutionLimits(asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = g.GetOrderExecutionLimits(asset.Spot, currency.NewPair(currency.BTC, currency.USDT)); err != nil {
+		t.Fatal(err)
+	}
+}
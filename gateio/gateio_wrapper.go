@@ -200,6 +200,20 @@ func (g *Gateio) Run() {
 		g.PrintEnabledPairs()
 	}
 
+	a := g.GetAssetTypes()
+	for x := range a {
+		if err := g.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
+			err = g.UpdateOrderExecutionLimits(a[x])
+			g.SetOrderExecutionLimitsLoadError(a[x], err)
+			if err != nil {
+				log.Errorf(log.ExchangeSys,
+					"Could not set %s exchange exchange limits: %v",
+					g.Name,
+					err)
+			}
+		}
+	}
+
 	if !g.GetEnabledFeatures().AutoPairUpdates {
 		return
 	}
@@ -481,6 +495,9 @@ func (g *Gateio) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 	if err := s.Validate(); err != nil {
 		return submitOrderResponse, err
 	}
+	if err := g.ConformOrderToLimits(s); err != nil {
+		return submitOrderResponse, err
+	}
 
 	var orderTypeFormat string
 	if s.Side == order.Buy {
@@ -854,3 +871,15 @@ func (g *Gateio) GetHistoricCandles(pair currency.Pair, a asset.Item, start, end
 func (g *Gateio) GetHistoricCandlesExtended(pair currency.Pair, a asset.Item, start, end time.Time, interval kline.Interval) (kline.Item, error) {
 	return g.GetHistoricCandles(pair, a, start, end, interval)
 }
+
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (g *Gateio) UpdateOrderExecutionLimits(a asset.Item) error {
+	if a != asset.Spot {
+		return fmt.Errorf("%s %w", a, asset.ErrNotSupported)
+	}
+	limits, err := g.FetchExchangeLimits()
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return g.LoadLimits(limits)
+}
@@ -11,9 +11,12 @@ import (
 	"strings"
 
 	exchange "github.com/openware/irix"
+	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/common"
 	"github.com/openware/pkg/common/crypto"
+	"github.com/openware/pkg/currency"
 	"github.com/openware/pkg/log"
+	"github.com/openware/pkg/order"
 	"github.com/openware/pkg/request"
 )
 
@@ -23,6 +26,7 @@ const (
 	geminiAPIVersion    = "1"
 
 	geminiSymbols            = "symbols"
+	geminiSymbolDetails      = "symbols/details"
 	geminiTicker             = "pubticker"
 	geminiAuction            = "auction"
 	geminiAuctionHistory     = "history"
@@ -46,6 +50,9 @@ const (
 	// Too many requests returns this
 	geminiRateError = "429"
 
+	// Unknown orders return this reason
+	geminiOrderNotFoundReason = "OrderNotFound"
+
 	// Assigned API key roles on creation
 	geminiRoleTrader      = "trader"
 	geminiRoleFundManager = "fundmanager"
@@ -67,6 +74,47 @@ func (g *Gemini) GetSymbols() ([]string, error) {
 	return symbols, g.SendHTTPRequest(exchange.RestSpot, path, &symbols)
 }
 
+// GetSymbolDetails returns the trading increments and minimum order size of
+// a symbol
+func (g *Gemini) GetSymbolDetails(symbol string) (SymbolDetails, error) {
+	var details SymbolDetails
+	path := fmt.Sprintf("/v%s/%s/%s", geminiAPIVersion, geminiSymbolDetails, symbol)
+	return details, g.SendHTTPRequest(exchange.RestSpot, path, &details)
+}
+
+// FetchExchangeLimits fetches the order execution limits of every enabled
+// pair
+func (g *Gemini) FetchExchangeLimits(a asset.Item) ([]order.MinMaxLevel, error) {
+	if a != asset.Spot {
+		return nil, fmt.Errorf("%s %w", a, asset.ErrNotSupported)
+	}
+	pairs, err := g.GetEnabledPairs(a)
+	if err != nil {
+		return nil, err
+	}
+	limits := make([]order.MinMaxLevel, 0, len(pairs))
+	for x := range pairs {
+		var symbol currency.Pair
+		symbol, err = g.FormatExchangeCurrency(pairs[x], a)
+		if err != nil {
+			return nil, err
+		}
+		var details SymbolDetails
+		details, err = g.GetSymbolDetails(symbol.String())
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, order.MinMaxLevel{
+			Pair:       pairs[x],
+			Asset:      a,
+			StepPrice:  details.QuoteIncrement,
+			MinAmount:  details.MinOrderSize,
+			StepAmount: details.TickSize,
+		})
+	}
+	return limits, nil
+}
+
 // GetTicker returns information about recent trading activity for the symbol
 func (g *Gemini) GetTicker(currencyPair string) (TickerV2, error) {
 	ticker := TickerV2{}
@@ -155,13 +203,16 @@ func (g *Gemini) GetAuctionHistory(currencyPair string, params url.Values) ([]Au
 
 // NewOrder Only limit orders are supported through the API at present.
 // returns order ID if successful
-func (g *Gemini) NewOrder(symbol string, amount, price float64, side, orderType string) (int64, error) {
+func (g *Gemini) NewOrder(symbol string, amount, price float64, side, orderType, clientOrderID string) (int64, error) {
 	req := make(map[string]interface{})
 	req["symbol"] = symbol
 	req["amount"] = strconv.FormatFloat(amount, 'f', -1, 64)
 	req["price"] = strconv.FormatFloat(price, 'f', -1, 64)
 	req["side"] = side
 	req["type"] = orderType
+	if clientOrderID != "" {
+		req["client_order_id"] = clientOrderID
+	}
 
 	response := Order{}
 	err := g.SendAuthenticatedHTTPRequest(exchange.RestSpot, http.MethodPost, geminiOrderNew, req, &response)
@@ -228,6 +279,17 @@ func (g *Gemini) GetOrderStatus(orderID int64) (Order, error) {
 	return response, nil
 }
 
+// GetOrderStatusByClientOrderID returns the orders submitted with a client
+// order ID
+func (g *Gemini) GetOrderStatusByClientOrderID(clientOrderID string) ([]Order, error) {
+	req := make(map[string]interface{})
+	req["client_order_id"] = clientOrderID
+
+	var response []Order
+	return response,
+		g.SendAuthenticatedHTTPRequest(exchange.RestSpot, http.MethodPost, geminiOrderStatus, req, &response)
+}
+
 // GetOrders returns active orders in the market
 func (g *Gemini) GetOrders() ([]Order, error) {
 	var response interface{}
@@ -375,7 +437,12 @@ func (g *Gemini) SendAuthenticatedHTTPRequest(ep exchange.URL, method, path stri
 
 	req := make(map[string]interface{})
 	req["request"] = fmt.Sprintf("/v%s/%s", geminiAPIVersion, path)
-	req["nonce"] = g.Requester.GetNonce(true).String()
+	nonce, release, err := g.NextNonce()
+	if err != nil {
+		return err
+	}
+	defer release()
+	req["nonce"] = nonce.String()
 
 	for key, value := range params {
 		req[key] = value
@@ -120,7 +120,8 @@ func TestNewOrder(t *testing.T) {
 		1,
 		9000000,
 		order.Sell.Lower(),
-		"exchange limit")
+		"exchange limit",
+		"")
 	if err != nil && mockTests {
 		t.Error("NewOrder() error", err)
 	} else if err == nil && !mockTests {
@@ -427,12 +428,13 @@ func TestSubmitOrder(t *testing.T) {
 			Base:      currency.LTC,
 			Quote:     currency.BTC,
 		},
-		Side:      order.Buy,
-		Type:      order.Limit,
-		Price:     10,
-		Amount:    1,
-		ClientID:  "1234234",
-		AssetType: asset.Spot,
+		Side:          order.Buy,
+		Type:          order.Limit,
+		Price:         10,
+		Amount:        1,
+		ClientID:      "1234234",
+		ClientOrderID: "1",
+		AssetType:     asset.Spot,
 	}
 
 	response, err := g.SubmitOrder(orderSubmission)
@@ -1224,3 +1226,21 @@ func TestGetHistoricTrades(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	err := g.UpdateOrderExecutionLimits(asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	limits, err := g.GetOrderExecutionLimits(asset.Spot, currency.NewPair(currency.BTC, currency.USD))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = limits.Conforms(10, 1, order.Limit); err != nil {
+		t.Error(err)
+	}
+	if err = limits.Conforms(10, 0.000001, order.Limit); err == nil {
+		t.Error("expected an amount below the minimum order size to be rejected")
+	}
+}
@@ -13,6 +13,17 @@ const (
 	candles1d        = "candles_1d"
 )
 
+// SymbolDetails holds the trading increments of a symbol
+type SymbolDetails struct {
+	Symbol         string  `json:"symbol"`
+	BaseCurrency   string  `json:"base_currency"`
+	QuoteCurrency  string  `json:"quote_currency"`
+	TickSize       float64 `json:"tick_size"`
+	QuoteIncrement float64 `json:"quote_increment"`
+	MinOrderSize   float64 `json:"min_order_size,string"`
+	Status         string  `json:"status"`
+}
+
 // Ticker holds returned ticker data from the exchange
 type Ticker struct {
 	Ask    float64 `json:"ask,string"`
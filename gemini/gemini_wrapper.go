@@ -253,6 +253,20 @@ func (g *Gemini) Run() {
 		}
 	}
 
+	a := g.GetAssetTypes()
+	for x := range a {
+		if err := g.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
+			err = g.UpdateOrderExecutionLimits(a[x])
+			g.SetOrderExecutionLimitsLoadError(a[x], err)
+			if err != nil {
+				log.Errorf(log.ExchangeSys,
+					"Could not set %s exchange exchange limits: %v",
+					g.Name,
+					err)
+			}
+		}
+	}
+
 	if !g.GetEnabledFeatures().AutoPairUpdates && !forceUpdate {
 		return
 	}
@@ -521,12 +535,19 @@ func (g *Gemini) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 	if err := s.Validate(); err != nil {
 		return submitOrderResponse, err
 	}
+	if err := g.ConformOrderToLimits(s); err != nil {
+		return submitOrderResponse, err
+	}
 
 	if s.Type != order.Limit {
 		return submitOrderResponse,
 			errors.New("only limit orders are enabled through this exchange")
 	}
 
+	if err := exchange.AttachClientOrderID(g, s); err != nil {
+		return submitOrderResponse, err
+	}
+
 	fpair, err := g.FormatExchangeCurrency(s.Pair, asset.Spot)
 	if err != nil {
 		return submitOrderResponse, err
@@ -536,7 +557,8 @@ func (g *Gemini) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 		s.Amount,
 		s.Price,
 		s.Side.String(),
-		"exchange limit")
+		"exchange limit",
+		s.ClientOrderID)
 	if err != nil {
 		return submitOrderResponse, err
 	}
@@ -598,6 +620,57 @@ func (g *Gemini) GetOrderInfo(orderID string, pair currency.Pair, assetType asse
 	return orderDetail, common.ErrNotYetImplemented
 }
 
+// GetOrderInfoByClientOrderID returns order information based on the client
+// order ID supplied on submission
+func (g *Gemini) GetOrderInfoByClientOrderID(clientOrderID string, pair currency.Pair, assetType asset.Item) (order.Detail, error) {
+	if clientOrderID == "" {
+		return order.Detail{}, order.ErrOrderIDNotSet
+	}
+	resp, err := g.GetOrderStatusByClientOrderID(clientOrderID)
+	if err != nil {
+		if strings.Contains(err.Error(), geminiOrderNotFoundReason) {
+			return order.Detail{}, fmt.Errorf("%s client order ID %s %w: %v",
+				g.Name,
+				clientOrderID,
+				exchange.ErrOrderNotFound,
+				err)
+		}
+		return order.Detail{}, err
+	}
+	if len(resp) == 0 {
+		return order.Detail{}, fmt.Errorf("%s client order ID %s %w",
+			g.Name,
+			clientOrderID,
+			exchange.ErrOrderNotFound)
+	}
+	o := &resp[0]
+	status := order.Active
+	switch {
+	case o.IsCancelled && o.ExecutedAmount > 0:
+		status = order.PartiallyCancelled
+	case o.IsCancelled:
+		status = order.Cancelled
+	case !o.IsLive:
+		status = order.Filled
+	case o.ExecutedAmount > 0:
+		status = order.PartiallyFilled
+	}
+	return order.Detail{
+		ID:              strconv.FormatInt(o.OrderID, 10),
+		ClientOrderID:   o.ClientOrderID,
+		Amount:          o.OriginalAmount,
+		ExecutedAmount:  o.ExecutedAmount,
+		RemainingAmount: o.RemainingAmount,
+		Exchange:        g.Name,
+		Price:           o.Price,
+		Date:            time.Unix(0, o.TimestampMS*int64(time.Millisecond)),
+		Side:            order.Side(strings.ToUpper(o.Side)),
+		Status:          status,
+		Pair:            pair,
+		AssetType:       assetType,
+	}, nil
+}
+
 // GetDepositAddress returns a deposit address for a specified currency
 func (g *Gemini) GetDepositAddress(cryptocurrency currency.Code, _ string) (string, error) {
 	addr, err := g.GetCryptoDepositAddress("", cryptocurrency.String())
@@ -766,6 +839,15 @@ func (g *Gemini) GetOrderHistory(req *order.GetOrdersRequest) ([]order.Detail, e
 	return orders, nil
 }
 
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (g *Gemini) UpdateOrderExecutionLimits(a asset.Item) error {
+	limits, err := g.FetchExchangeLimits(a)
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return g.LoadLimits(limits)
+}
+
 // ValidateCredentials validates current credentials used for wrapper
 // functionality
 func (g *Gemini) ValidateCredentials(assetType asset.Item) error {
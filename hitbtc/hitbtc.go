@@ -11,8 +11,10 @@ import (
 	"time"
 
 	exchange "github.com/openware/irix"
+	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/common/crypto"
 	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
 	"github.com/openware/pkg/request"
 )
 
@@ -43,6 +45,8 @@ const (
 	transferBalance     = "transferBalance"
 )
 
+var errClientOrderIDRequired = errors.New("client order ID required to replace an order")
+
 // HitBTC is the overarching type across the hitbtc package
 type HitBTC struct {
 	exchange.Base
@@ -113,6 +117,32 @@ func (h *HitBTC) GetSymbolsDetailed() ([]Symbol, error) {
 	return resp, h.SendHTTPRequest(exchange.RestSpot, path, &resp)
 }
 
+// FetchExchangeLimits fetches spot order execution limits
+func (h *HitBTC) FetchExchangeLimits() ([]order.MinMaxLevel, error) {
+	symbols, err := h.GetSymbolsDetailed()
+	if err != nil {
+		return nil, err
+	}
+	limits := make([]order.MinMaxLevel, len(symbols))
+	for x := range symbols {
+		var cp currency.Pair
+		cp, err = currency.NewPairFromStrings(symbols[x].BaseCurrency, symbols[x].QuoteCurrency)
+		if err != nil {
+			return nil, err
+		}
+		// The smallest order is a single quantity increment
+		limits[x] = order.MinMaxLevel{
+			Pair:       cp,
+			Asset:      asset.Spot,
+			MinPrice:   symbols[x].TickSize,
+			StepPrice:  symbols[x].TickSize,
+			MinAmount:  symbols[x].QuantityIncrement,
+			StepAmount: symbols[x].QuantityIncrement,
+		}
+	}
+	return limits, nil
+}
+
 // GetTicker returns ticker information
 func (h *HitBTC) GetTicker(symbol string) (TickerResponse, error) {
 	var resp TickerResponse
@@ -332,6 +362,20 @@ func (h *HitBTC) GetOrders(currency string) ([]OrderHistoryResponse, error) {
 		&result)
 }
 
+// GetOrderByClientOrderID returns the orders history entries for a client
+// order ID, which include active orders
+func (h *HitBTC) GetOrderByClientOrderID(clientOrderID string) ([]OrderHistoryResponse, error) {
+	values := url.Values{}
+	values.Set("clientOrderId", clientOrderID)
+	var result []OrderHistoryResponse
+
+	return result, h.SendAuthenticatedHTTPRequest(exchange.RestSpot, http.MethodGet,
+		apiV2OrderHistory+"?"+values.Encode(),
+		url.Values{},
+		tradingRequests,
+		&result)
+}
+
 // GetOpenOrders List of your currently open orders.
 func (h *HitBTC) GetOpenOrders(currency string) ([]OrderHistoryResponse, error) {
 	values := url.Values{}
@@ -346,10 +390,13 @@ func (h *HitBTC) GetOpenOrders(currency string) ([]OrderHistoryResponse, error)
 }
 
 // PlaceOrder places an order on the exchange
-func (h *HitBTC) PlaceOrder(currency string, rate, amount float64, orderType, side string) (OrderResponse, error) {
+func (h *HitBTC) PlaceOrder(currency string, rate, amount float64, orderType, side, clientOrderID string) (OrderResponse, error) {
 	var result OrderResponse
 	values := url.Values{}
 
+	if clientOrderID != "" {
+		values.Set("clientOrderId", clientOrderID)
+	}
 	values.Set("symbol", currency)
 	values.Set("rate", strconv.FormatFloat(rate, 'f', -1, 64))
 	values.Set("quantity", strconv.FormatFloat(amount, 'f', -1, 64))
@@ -364,6 +411,25 @@ func (h *HitBTC) PlaceOrder(currency string, rate, amount float64, orderType, si
 		&result)
 }
 
+// ReplaceOrder changes the quantity and price of an open order identified by
+// its client order ID. The replacement order takes requestClientID as its
+// client order ID.
+func (h *HitBTC) ReplaceOrder(clientOrderID, requestClientID string, quantity, price float64) (OrderHistoryResponse, error) {
+	var result OrderHistoryResponse
+	values := url.Values{}
+	values.Set("quantity", strconv.FormatFloat(quantity, 'f', -1, 64))
+	values.Set("price", strconv.FormatFloat(price, 'f', -1, 64))
+	if requestClientID != "" {
+		values.Set("requestClientId", requestClientID)
+	}
+
+	return result, h.SendAuthenticatedHTTPRequest(exchange.RestSpot, http.MethodPatch,
+		apiOrder+"/"+url.PathEscape(clientOrderID),
+		values,
+		tradingRequests,
+		&result)
+}
+
 // CancelExistingOrder cancels a specific order by OrderID
 func (h *HitBTC) CancelExistingOrder(orderID int64) (bool, error) {
 	result := GenericResponse{}
@@ -1,6 +1,7 @@
 package hitbtc
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"os"
@@ -382,6 +383,20 @@ func TestModifyOrder(t *testing.T) {
 	}
 }
 
+func TestModifyOrderRequiresClientOrderID(t *testing.T) {
+	t.Parallel()
+	_, err := h.ModifyOrder(&order.Modify{
+		ID:        "1337",
+		Pair:      currency.NewPair(currency.BTC, currency.USD),
+		AssetType: asset.Spot,
+		Price:     1,
+		Amount:    1,
+	})
+	if !errors.Is(err, errClientOrderIDRequired) {
+		t.Errorf("received: %v but expected: %v", err, errClientOrderIDRequired)
+	}
+}
+
 func TestWithdraw(t *testing.T) {
 	withdrawCryptoRequest := withdraw.Request{
 		Amount:      -1,
@@ -488,7 +503,8 @@ func TestWsPlaceOrder(t *testing.T) {
 	_, err := h.wsPlaceOrder(currency.NewPair(currency.LTC, currency.BTC),
 		order.Buy.String(),
 		1,
-		1)
+		1,
+		"")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1020,3 +1036,21 @@ func TestGetHistoricTrades(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	err := h.UpdateOrderExecutionLimits(asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = h.UpdateOrderExecutionLimits(asset.Margin); err == nil {
+		t.Fatal("expected unhandled case")
+	}
+	p, err := h.GetPrecision(asset.Spot, currency.NewPair(currency.BTC, currency.USD))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.PriceTick.IsPositive() || !p.AmountStep.IsPositive() {
+		t.Fatalf("unexpected precision %+v", p)
+	}
+}
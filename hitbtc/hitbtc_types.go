@@ -463,7 +463,7 @@ type WsSubmitOrderRequest struct {
 
 // WsSubmitOrderRequestData WS request data
 type WsSubmitOrderRequestData struct {
-	ClientOrderID int64   `json:"clientOrderId,string,omitempty"`
+	ClientOrderID string  `json:"clientOrderId,omitempty"`
 	Symbol        string  `json:"symbol"`
 	Side          string  `json:"side"`
 	Price         float64 `json:"price,string"`
@@ -586,7 +586,7 @@ func (h *HitBTC) wsLogin() error {
 }
 
 // wsPlaceOrder sends a websocket message to submit an order
-func (h *HitBTC) wsPlaceOrder(pair currency.Pair, side string, price, quantity float64) (*WsSubmitOrderSuccessResponse, error) {
+func (h *HitBTC) wsPlaceOrder(pair currency.Pair, side string, price, quantity float64, clientOrderID string) (*WsSubmitOrderSuccessResponse, error) {
 	if !h.Websocket.CanUseAuthenticatedEndpoints() {
 		return nil, fmt.Errorf("%v not authenticated, cannot place order", h.Name)
 	}
@@ -600,7 +600,7 @@ func (h *HitBTC) wsPlaceOrder(pair currency.Pair, side string, price, quantity f
 	request := WsSubmitOrderRequest{
 		Method: "newOrder",
 		Params: WsSubmitOrderRequestData{
-			ClientOrderID: id,
+			ClientOrderID: clientOrderID,
 			Symbol:        fpair.String(),
 			Side:          strings.ToLower(side),
 			Price:         price,
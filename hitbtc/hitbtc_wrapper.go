@@ -256,6 +256,20 @@ func (h *HitBTC) Run() {
 		}
 	}
 
+	a := h.GetAssetTypes()
+	for x := range a {
+		if err := h.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
+			err = h.UpdateOrderExecutionLimits(a[x])
+			h.SetOrderExecutionLimitsLoadError(a[x], err)
+			if err != nil {
+				log.Errorf(log.ExchangeSys,
+					"Could not set %s exchange exchange limits: %v",
+					h.Name,
+					err)
+			}
+		}
+	}
+
 	if !h.GetEnabledFeatures().AutoPairUpdates && !forceUpdate {
 		return
 	}
@@ -536,9 +550,17 @@ func (h *HitBTC) SubmitOrder(o *order.Submit) (order.SubmitResponse, error) {
 	if err != nil {
 		return submitOrderResponse, err
 	}
+	err = h.ConformOrderToLimits(o)
+	if err != nil {
+		return submitOrderResponse, err
+	}
+	err = exchange.AttachClientOrderID(h, o)
+	if err != nil {
+		return submitOrderResponse, err
+	}
 	if h.Websocket.IsConnected() && h.Websocket.CanUseAuthenticatedEndpoints() {
 		var response *WsSubmitOrderSuccessResponse
-		response, err = h.wsPlaceOrder(o.Pair, o.Side.String(), o.Amount, o.Price)
+		response, err = h.wsPlaceOrder(o.Pair, o.Side.String(), o.Amount, o.Price, o.ClientOrderID)
 		if err != nil {
 			return submitOrderResponse, err
 		}
@@ -557,7 +579,8 @@ func (h *HitBTC) SubmitOrder(o *order.Submit) (order.SubmitResponse, error) {
 			o.Price,
 			o.Amount,
 			strings.ToLower(o.Type.String()),
-			strings.ToLower(o.Side.String()))
+			strings.ToLower(o.Side.String()),
+			o.ClientOrderID)
 		if err != nil {
 			return submitOrderResponse, err
 		}
@@ -573,10 +596,31 @@ func (h *HitBTC) SubmitOrder(o *order.Submit) (order.SubmitResponse, error) {
 	return submitOrderResponse, nil
 }
 
-// ModifyOrder will allow of changing orderbook placement and limit to
-// market conversion
+// ModifyOrder replaces the quantity and price of an order identified by its
+// client order ID and returns the ID of the replacement order
 func (h *HitBTC) ModifyOrder(action *order.Modify) (string, error) {
-	return "", common.ErrFunctionNotSupported
+	if err := action.Validate(); err != nil {
+		return "", err
+	}
+	if action.ClientOrderID == "" {
+		return "", errClientOrderIDRequired
+	}
+	if h.Websocket.IsConnected() && h.Websocket.CanUseAuthenticatedEndpoints() {
+		response, err := h.wsReplaceOrder(action.ClientOrderID, action.Amount, action.Price)
+		if err != nil {
+			return "", err
+		}
+		return response.Result.ID, nil
+	}
+	requestClientID, err := h.GenerateClientOrderID()
+	if err != nil {
+		return "", err
+	}
+	response, err := h.ReplaceOrder(action.ClientOrderID, requestClientID, action.Amount, action.Price)
+	if err != nil {
+		return "", err
+	}
+	return response.ID, nil
 }
 
 // CancelOrder cancels an order by its corresponding ID number
@@ -628,6 +672,65 @@ func (h *HitBTC) GetOrderInfo(orderID string, pair currency.Pair, assetType asse
 	return orderDetail, common.ErrNotYetImplemented
 }
 
+// GetOrderInfoByClientOrderID returns order information based on the client
+// order ID supplied on submission
+func (h *HitBTC) GetOrderInfoByClientOrderID(clientOrderID string, pair currency.Pair, assetType asset.Item) (order.Detail, error) {
+	if clientOrderID == "" {
+		return order.Detail{}, order.ErrOrderIDNotSet
+	}
+	resp, err := h.GetOrderByClientOrderID(clientOrderID)
+	if err != nil {
+		return order.Detail{}, err
+	}
+	if len(resp) == 0 {
+		return order.Detail{}, fmt.Errorf("%s client order ID %s %w",
+			h.Name,
+			clientOrderID,
+			exchange.ErrOrderNotFound)
+	}
+	o := &resp[0]
+	return order.Detail{
+		ID:              o.ID,
+		ClientOrderID:   o.ClientOrderID,
+		Amount:          o.Quantity,
+		ExecutedAmount:  o.CumQuantity,
+		RemainingAmount: o.Quantity - o.CumQuantity,
+		Exchange:        h.Name,
+		Price:           o.Price,
+		Date:            o.CreatedAt,
+		LastUpdated:     o.UpdatedAt,
+		Side:            order.Side(strings.ToUpper(o.Side)),
+		Status:          orderStatus(o.Status),
+		Pair:            pair,
+		AssetType:       assetType,
+	}, nil
+}
+
+// GenerateClientOrderID returns a client order ID of 32 characters, the
+// longest clientOrderId accepted
+func (h *HitBTC) GenerateClientOrderID() (string, error) {
+	id, err := exchange.GenerateClientOrderID()
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(id, "-", ""), nil
+}
+
+// orderStatus converts a HitBTC order status to an order status
+func orderStatus(status string) order.Status {
+	switch status {
+	case "new", "suspended":
+		return order.Active
+	case "partiallyFilled":
+		return order.PartiallyFilled
+	case "filled":
+		return order.Filled
+	case "canceled", "expired":
+		return order.Cancelled
+	}
+	return order.UnknownStatus
+}
+
 // GetDepositAddress returns a deposit address for a specified currency
 func (h *HitBTC) GetDepositAddress(currency currency.Code, _ string) (string, error) {
 	resp, err := h.GetDepositAddresses(currency.String())
@@ -888,3 +991,15 @@ func (h *HitBTC) GetHistoricCandlesExtended(pair currency.Pair, a asset.Item, st
 	ret.SortCandlesByTimestamp(false)
 	return ret, nil
 }
+
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (h *HitBTC) UpdateOrderExecutionLimits(a asset.Item) error {
+	if a != asset.Spot {
+		return fmt.Errorf("%s %w", a, asset.ErrNotSupported)
+	}
+	limits, err := h.FetchExchangeLimits()
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return h.LoadLimits(limits)
+}
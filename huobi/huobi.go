@@ -6,16 +6,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"net/url"
 	"strconv"
 	"time"
 
 	exchange "github.com/openware/irix"
+	"github.com/openware/irix/stream/buffer"
 	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/common"
 	"github.com/openware/pkg/common/crypto"
 	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
 	"github.com/openware/pkg/request"
 )
 
@@ -62,12 +65,17 @@ const (
 	huobiWithdrawCancel        = "dw/withdraw-virtual/%s/cancel"
 	huobiStatusError           = "error"
 	huobiMarginRates           = "margin/loan-info"
+
+	// huobiRecordInvalidMsg is returned when no order has the ID queried
+	huobiRecordInvalidMsg = "record invalid"
 )
 
 // HUOBI is the overarching type across this package
 type HUOBI struct {
 	exchange.Base
 	AccountID string
+	// obm fetches websocket orderbooks via REST when they fall out of sync
+	obm *buffer.SyncManager
 }
 
 // GetMarginRates gets margin rates
@@ -288,6 +296,39 @@ func (h *HUOBI) GetSymbols() ([]Symbol, error) {
 	return result.Symbols, err
 }
 
+// FetchSpotExchangeLimits fetches spot symbol metadata and returns the tick
+// and lot sizes, amount bounds and minimum order value of each online symbol
+func (h *HUOBI) FetchSpotExchangeLimits() ([]order.MinMaxLevel, error) {
+	symbols, err := h.GetSymbols()
+	if err != nil {
+		return nil, err
+	}
+	var limits []order.MinMaxLevel
+	for x := range symbols {
+		if symbols[x].State != "online" {
+			continue
+		}
+		var cp currency.Pair
+		cp, err = currency.NewPairFromStrings(symbols[x].BaseCurrency,
+			symbols[x].QuoteCurrency)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, order.MinMaxLevel{
+			Pair:         cp,
+			Asset:        asset.Spot,
+			StepPrice:    math.Pow10(-int(symbols[x].PricePrecision)),
+			StepAmount:   math.Pow10(-int(symbols[x].AmountPrecision)),
+			MinAmount:    symbols[x].MinOrderAmt,
+			MaxAmount:    symbols[x].MaxOrderAmt,
+			MinNotional:  symbols[x].MinOrderValue,
+			MarketMinQty: symbols[x].MinOrderAmt,
+			MarketMaxQty: symbols[x].SellMarketMaxOrderAmt,
+		})
+	}
+	return limits, nil
+}
+
 // GetCurrencies returns a list of currencies supported by Huobi
 func (h *HUOBI) GetCurrencies() ([]string, error) {
 	type response struct {
@@ -320,6 +361,15 @@ func (h *HUOBI) GetTimestamp() (int64, error) {
 	return result.Timestamp, err
 }
 
+// serverTime returns the Huobi server time for the clock
+func (h *HUOBI) serverTime() (time.Time, error) {
+	ts, err := h.GetTimestamp()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, ts*int64(time.Millisecond)), nil
+}
+
 // GetAccounts returns the Huobi user accounts
 func (h *HUOBI) GetAccounts() ([]Account, error) {
 	result := struct {
@@ -365,22 +415,30 @@ func (h *HUOBI) SpotNewOrder(arg *SpotNewOrderRequestParams) (int64, error) {
 	}
 
 	data := struct {
-		AccountID int    `json:"account-id,string"`
-		Amount    string `json:"amount"`
-		Price     string `json:"price"`
-		Source    string `json:"source"`
-		Symbol    string `json:"symbol"`
-		Type      string `json:"type"`
+		AccountID     int    `json:"account-id,string"`
+		Amount        string `json:"amount"`
+		Price         string `json:"price"`
+		Source        string `json:"source"`
+		Symbol        string `json:"symbol"`
+		Type          string `json:"type"`
+		ClientOrderID string `json:"client-order-id,omitempty"`
 	}{
-		AccountID: arg.AccountID,
-		Amount:    strconv.FormatFloat(arg.Amount, 'f', -1, 64),
-		Symbol:    symbolValue,
-		Type:      string(arg.Type),
+		AccountID:     arg.AccountID,
+		ClientOrderID: arg.ClientOrderID,
+		Amount:        strconv.FormatFloat(arg.Amount, 'f', -1, 64),
+		Symbol:        symbolValue,
+		Type:          string(arg.Type),
+	}
+	if arg.AmountString != "" {
+		data.Amount = arg.AmountString
 	}
 
 	// Only set price if order type is not equal to buy-market or sell-market
 	if arg.Type != SpotNewOrderRequestTypeBuyMarket && arg.Type != SpotNewOrderRequestTypeSellMarket {
 		data.Price = strconv.FormatFloat(arg.Price, 'f', -1, 64)
+		if arg.PriceString != "" {
+			data.Price = arg.PriceString
+		}
 	}
 
 	if arg.Source != "" {
@@ -469,6 +527,23 @@ func (h *HUOBI) GetOrder(orderID int64) (OrderInfo, error) {
 	return resp.Order, err
 }
 
+// GetOrderByClientOrderID returns order info for the client order ID
+// supplied on placement
+func (h *HUOBI) GetOrderByClientOrderID(clientOrderID string) (OrderInfo, error) {
+	resp := struct {
+		Order OrderInfo `json:"data"`
+	}{}
+	urlVal := url.Values{}
+	urlVal.Set("clientOrderId", clientOrderID)
+	err := h.SendAuthenticatedHTTPRequest(exchange.RestSpot, http.MethodGet,
+		huobiGetOrder,
+		urlVal,
+		nil,
+		&resp,
+		false)
+	return resp.Order, err
+}
+
 // GetOrderMatchResults returns matched order info for the specified order
 func (h *HUOBI) GetOrderMatchResults(orderID int64) ([]OrderMatchInfo, error) {
 	resp := struct {
@@ -828,7 +903,7 @@ func (h *HUOBI) SendAuthenticatedHTTPRequest(ep exchange.URL, method, endpoint s
 		values = url.Values{}
 	}
 
-	now := time.Now()
+	now := h.Clock.Now()
 	values.Set("AccessKeyId", h.API.Credentials.Key)
 	values.Set("SignatureMethod", "HmacSHA256")
 	values.Set("SignatureVersion", "2")
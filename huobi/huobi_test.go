@@ -36,7 +36,7 @@ var wsSetupRan bool
 func TestMain(m *testing.M) {
 	h.SetDefaults()
 	wd, _ := os.Getwd()
-	hConfig, err := config.FromFile(filepath.Join(wd, "houbi.conf.json"))
+	hConfig, err := config.FromFile(filepath.Join(wd, "huobi.conf.json"))
 	if err != nil {
 		log.Fatal("Huobi Setup() init error", err)
 	}
@@ -2151,7 +2151,7 @@ func TestWsSubResponse(t *testing.T) {
   "ts": 1489474081631,
   "topic": "accounts"
 }`)
-	err := h.wsHandleData(pressXToJSON)
+	err := h.wsHandleData(h.Websocket.Conn, pressXToJSON)
 	if err != nil {
 		t.Error(err)
 	}
@@ -2172,7 +2172,7 @@ func TestWsKline(t *testing.T) {
     "vol": 0.0
   }
 }`)
-	err := h.wsHandleData(pressXToJSON)
+	err := h.wsHandleData(h.Websocket.Conn, pressXToJSON)
 	if err != nil {
 		t.Error(err)
 	}
@@ -2185,7 +2185,7 @@ func TestWsUnsubscribe(t *testing.T) {
   "unsubbed": "market.btcusdt.trade.detail",
   "ts": 1494326028889
 }`)
-	err := h.wsHandleData(pressXToJSON)
+	err := h.wsHandleData(h.Websocket.Conn, pressXToJSON)
 	if err != nil {
 		t.Error(err)
 	}
@@ -2218,7 +2218,7 @@ func TestWsKlineArray(t *testing.T) {
     }
   ]
 }`)
-	err := h.wsHandleData(pressXToJSON)
+	err := h.wsHandleData(h.Websocket.Conn, pressXToJSON)
 	if err != nil {
 		t.Error(err)
 	}
@@ -2241,7 +2241,7 @@ func TestWsMarketDepth(t *testing.T) {
     "ts": 1572362902012
   }
 }`)
-	err := h.wsHandleData(pressXToJSON)
+	err := h.wsHandleData(h.Websocket.Conn, pressXToJSON)
 	if err != nil {
 		t.Error(err)
 	}
@@ -2260,7 +2260,7 @@ func TestWsBestBidOffer(t *testing.T) {
 		"askSize": "0.3"
 	  }
 	}`)
-	err := h.wsHandleData(pressXToJSON)
+	err := h.wsHandleData(h.Websocket.Conn, pressXToJSON)
 	if err != nil {
 		t.Error(err)
 	}
@@ -2285,7 +2285,7 @@ func TestWsTradeDetail(t *testing.T) {
 			]
 	  }
 	}`)
-	err := h.wsHandleData(pressXToJSON)
+	err := h.wsHandleData(h.Websocket.Conn, pressXToJSON)
 	if err != nil {
 		t.Error(err)
 	}
@@ -2307,7 +2307,7 @@ func TestWsTicker(t *testing.T) {
 		"vol":    121906001.754751
 	  }
 }`)
-	err := h.wsHandleData(pressXToJSON)
+	err := h.wsHandleData(h.Websocket.Conn, pressXToJSON)
 	if err != nil {
 		t.Error(err)
 	}
@@ -2330,7 +2330,7 @@ func TestWsAccountUpdate(t *testing.T) {
 		]
 	  }
 	}`)
-	err := h.wsHandleData(pressXToJSON)
+	err := h.wsHandleData(h.Websocket.Conn, pressXToJSON)
 	if err != nil {
 		t.Error(err)
 	}
@@ -2360,7 +2360,7 @@ func TestWsOrderUpdate(t *testing.T) {
     "filled-fees": "8.000000000000000000"
   }
 }`)
-	err := h.wsHandleData(pressXToJSON)
+	err := h.wsHandleData(h.Websocket.Conn, pressXToJSON)
 	if err != nil {
 		t.Error(err)
 	}
@@ -2372,7 +2372,7 @@ func TestWsSubsbOp(t *testing.T) {
 	  "topic": "accounts",
 	  "cid": "123"
 	}`)
-	err := h.wsHandleData(pressXToJSON)
+	err := h.wsHandleData(h.Websocket.Conn, pressXToJSON)
 	if err != nil {
 		t.Error(err)
 	}
@@ -2383,7 +2383,7 @@ func TestWsSubsbOp(t *testing.T) {
 	  "ts": 1489474081631,
 	  "topic": "accounts"
 	}`)
-	err = h.wsHandleData(pressXToJSON)
+	err = h.wsHandleData(h.Websocket.Conn, pressXToJSON)
 	if err != nil {
 		t.Error(err)
 	}
@@ -2402,7 +2402,7 @@ func TestWsMarketByPrice(t *testing.T) {
 			]
 		}
 	}`)
-	err := h.wsHandleData(pressXToJSON)
+	err := h.wsHandleData(h.Websocket.Conn, pressXToJSON)
 	if err != nil {
 		t.Error(err)
 	}
@@ -2428,7 +2428,7 @@ func TestWsMarketByPrice(t *testing.T) {
 			]
 		}
 	}`)
-	err = h.wsHandleData(pressXToJSON)
+	err = h.wsHandleData(h.Websocket.Conn, pressXToJSON)
 	if err != nil {
 		t.Error(err)
 	}
@@ -2453,7 +2453,7 @@ func TestWsOrdersUpdate(t *testing.T) {
 			"order-type": "buy-limit"
 	}
 	}`)
-	err := h.wsHandleData(pressXToJSON)
+	err := h.wsHandleData(h.Websocket.Conn, pressXToJSON)
 	if err != nil {
 		t.Error(err)
 	}
@@ -2594,3 +2594,22 @@ func TestGetHistoricTrades(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	err := h.UpdateOrderExecutionLimits(asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = h.UpdateOrderExecutionLimits(asset.Futures); err == nil {
+		t.Fatal("expected unhandled case")
+	}
+	cp := currency.NewPair(currency.BTC, currency.USDT)
+	p, err := h.GetPrecision(asset.Spot, cp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.PriceTick.IsPositive() || !p.AmountStep.IsPositive() || !p.MinNotional.IsPositive() {
+		t.Fatalf("unexpected precision %+v", p)
+	}
+}
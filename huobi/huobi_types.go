@@ -1,6 +1,7 @@
 package huobi
 
 import (
+	"github.com/openware/irix/stream"
 	"github.com/openware/pkg/currency"
 	"github.com/openware/pkg/order"
 )
@@ -640,6 +641,7 @@ type OrderInfo struct {
 	CanceledAt       int64   `json:"canceled-at"`
 	Exchange         string  `json:"exchange"`
 	Batch            string  `json:"batch"`
+	ClientOrderID    string  `json:"client-order-id"`
 }
 
 // OrderMatchInfo stores the order match info
@@ -695,6 +697,11 @@ type SpotNewOrderRequestParams struct {
 	Source    string                        `json:"source"`            // Order source, api: API call, margin-api: loan asset transaction
 	Symbol    currency.Pair                 `json:"symbol"`            // The symbol to use; example btcusdt, bccbtc......
 	Type      SpotNewOrderRequestParamsType `json:"type"`              // 订单类型, buy-market: 市价买, sell-market: 市价卖, buy-limit: 限价买, sell-limit: 限价卖
+	// AmountString and PriceString are exact formatted values sent in place
+	// of Amount and Price when set
+	AmountString  string `json:"-"`
+	PriceString   string `json:"-"`
+	ClientOrderID string `json:"client-order-id,omitempty"`
 }
 
 // DepositAddress stores the users deposit address info
@@ -856,8 +863,9 @@ type WsAuthenticationRequest struct {
 
 // WsMessage defines read data from the websocket connection
 type WsMessage struct {
-	Raw []byte
-	URL string
+	Raw  []byte
+	URL  string
+	Conn stream.Connection
 }
 
 // WsAuthenticatedSubscriptionRequest request for subscription on authenticated connection
@@ -51,7 +51,16 @@ const (
 	authOp           = "auth"
 
 	loginDelay = 50 * time.Millisecond
-	rateLimit  = 20
+	// Huobi allows 50 requests a second on each connection
+	wsMessageRate = 50
+	// wsChannelsPerConnection keeps each market data connection within the
+	// channels Huobi serves on one connection
+	wsChannelsPerConnection = 30
+	// wsOrderbookBufferLimit bounds the sequenced updates staged per book
+	// while it is fetched via REST
+	wsOrderbookBufferLimit = 150
+	// wsOrderbookWorkers is the number of books fetched via REST at once
+	wsOrderbookWorkers = 5
 )
 
 // Instantiates a communications channel between websocket connections
@@ -96,6 +105,18 @@ func (h *HUOBI) wsDial(dialer *websocket.Dialer) error {
 	return nil
 }
 
+// wsConnectShard connects an additional market data connection once the
+// primary connection carries wsChannelsPerConnection channels
+func (h *HUOBI) wsConnectShard(conn stream.Connection) error {
+	var dialer websocket.Dialer
+	err := conn.Dial(&dialer, http.Header{})
+	if err != nil {
+		return err
+	}
+	go h.wsFunnelConnectionData(conn, wsMarketURL)
+	return nil
+}
+
 func (h *HUOBI) wsAuthenticatedDial(dialer *websocket.Dialer) error {
 	if !h.GetAuthenticatedAPISupport(exchange.WebsocketAuthentication) {
 		return fmt.Errorf("%v AuthenticatedWebsocketAPISupport not enabled",
@@ -119,7 +140,7 @@ func (h *HUOBI) wsFunnelConnectionData(ws stream.Connection, url string) {
 		if resp.Raw == nil {
 			return
 		}
-		comms <- WsMessage{Raw: resp.Raw, URL: url}
+		comms <- WsMessage{Raw: resp.Raw, URL: url, Conn: ws}
 	}
 }
 
@@ -129,7 +150,7 @@ func (h *HUOBI) wsReadData() {
 	defer h.Websocket.Wg.Done()
 	for {
 		resp := <-comms
-		err := h.wsHandleData(resp.Raw)
+		err := h.wsHandleData(resp.Conn, resp.Raw)
 		if err != nil {
 			h.Websocket.DataHandler <- err
 		}
@@ -176,7 +197,9 @@ func stringToOrderType(oType string) (order.Type, error) {
 		errors.New(oType + " not recognised as order type")
 }
 
-func (h *HUOBI) wsHandleData(respRaw []byte) error {
+// wsHandleData handles a message read from conn, pings are answered on the
+// connection they arrived on
+func (h *HUOBI) wsHandleData(conn stream.Connection, respRaw []byte) error {
 	var init WsResponse
 	err := json.Unmarshal(respRaw, &init)
 	if err != nil {
@@ -190,7 +213,7 @@ func (h *HUOBI) wsHandleData(respRaw []byte) error {
 		return nil
 	}
 	if init.Ping != 0 {
-		h.sendPingResponse(init.Ping)
+		h.sendPingResponse(conn, init.Ping)
 		return nil
 	}
 
@@ -419,8 +442,8 @@ func (h *HUOBI) wsHandleData(respRaw []byte) error {
 	return nil
 }
 
-func (h *HUOBI) sendPingResponse(pong int64) {
-	err := h.Websocket.Conn.SendJSONMessage(WsPong{Pong: pong})
+func (h *HUOBI) sendPingResponse(conn stream.Connection, pong int64) {
+	err := conn.SendJSONMessage(WsPong{Pong: pong})
 	if err != nil {
 		log.Error(log.ExchangeSys, err)
 	}
@@ -468,7 +491,7 @@ func (h *HUOBI) WsProcessOrderbook(update *WsDepth, symbol string) error {
 	newOrderBook.Exchange = h.Name
 	newOrderBook.VerifyOrderbook = h.CanVerifyOrderbook
 
-	return h.Websocket.Orderbook.LoadSnapshot(&newOrderBook)
+	return h.obm.LoadSnapshot(&newOrderBook)
 }
 
 // GenerateDefaultSubscriptions Adds default subscriptions to websocket to be handled by ManageSubscriptions()
@@ -504,6 +527,12 @@ func (h *HUOBI) GenerateDefaultSubscriptions() ([]stream.ChannelSubscription, er
 
 // Subscribe sends a websocket message to receive data from the channel
 func (h *HUOBI) Subscribe(channelsToSubscribe []stream.ChannelSubscription) error {
+	return h.subscribeOn(h.Websocket.Conn, channelsToSubscribe)
+}
+
+// subscribeOn subscribes to market data channels on conn, account and order
+// channels always use the authenticated connection
+func (h *HUOBI) subscribeOn(conn stream.Connection, channelsToSubscribe []stream.ChannelSubscription) error {
 	var errs common.Errors
 	for i := range channelsToSubscribe {
 		if strings.Contains(channelsToSubscribe[i].Channel, "orders.") ||
@@ -518,9 +547,9 @@ func (h *HUOBI) Subscribe(channelsToSubscribe []stream.ChannelSubscription) erro
 			h.Websocket.AddSuccessfulSubscriptions(channelsToSubscribe[i])
 			continue
 		}
-		err := h.Websocket.Conn.SendJSONMessage(WsRequest{
+		err := conn.SendJSONMessage(stream.WithPriority(stream.PrioritySubscription, WsRequest{
 			Subscribe: channelsToSubscribe[i].Channel,
-		})
+		}))
 		if err != nil {
 			errs = append(errs, err)
 			continue
@@ -535,6 +564,11 @@ func (h *HUOBI) Subscribe(channelsToSubscribe []stream.ChannelSubscription) erro
 
 // Unsubscribe sends a websocket message to stop receiving data from the channel
 func (h *HUOBI) Unsubscribe(channelsToUnsubscribe []stream.ChannelSubscription) error {
+	return h.unsubscribeOn(h.Websocket.Conn, channelsToUnsubscribe)
+}
+
+// unsubscribeOn unsubscribes from market data channels on conn
+func (h *HUOBI) unsubscribeOn(conn stream.Connection, channelsToUnsubscribe []stream.ChannelSubscription) error {
 	var errs common.Errors
 	for i := range channelsToUnsubscribe {
 		if strings.Contains(channelsToUnsubscribe[i].Channel, "orders.") ||
@@ -549,9 +583,9 @@ func (h *HUOBI) Unsubscribe(channelsToUnsubscribe []stream.ChannelSubscription)
 			h.Websocket.RemoveSuccessfulUnsubscriptions(channelsToUnsubscribe[i])
 			continue
 		}
-		err := h.Websocket.Conn.SendJSONMessage(WsRequest{
+		err := conn.SendJSONMessage(stream.WithPriority(stream.PrioritySubscription, WsRequest{
 			Unsubscribe: channelsToUnsubscribe[i].Channel,
-		})
+		}))
 		if err != nil {
 			errs = append(errs, err)
 			continue
@@ -581,7 +615,7 @@ func (h *HUOBI) wsLogin() error {
 		return fmt.Errorf("%v AuthenticatedWebsocketAPISupport not enabled", h.Name)
 	}
 	h.Websocket.SetCanUseAuthenticatedEndpoints(true)
-	timestamp := time.Now().UTC().Format(wsDateTimeFormatting)
+	timestamp := h.Clock.Now().UTC().Format(wsDateTimeFormatting)
 	request := WsAuthenticationRequest{
 		Op:               authOp,
 		AccessKeyID:      h.API.Credentials.Key,
@@ -602,7 +636,7 @@ func (h *HUOBI) wsLogin() error {
 }
 
 func (h *HUOBI) wsAuthenticatedSubscribe(operation, endpoint, topic string) error {
-	timestamp := time.Now().UTC().Format(wsDateTimeFormatting)
+	timestamp := h.Clock.Now().UTC().Format(wsDateTimeFormatting)
 	request := WsAuthenticatedSubscriptionRequest{
 		Op:               operation,
 		AccessKeyID:      h.API.Credentials.Key,
@@ -613,14 +647,14 @@ func (h *HUOBI) wsAuthenticatedSubscribe(operation, endpoint, topic string) erro
 	}
 	hmac := h.wsGenerateSignature(timestamp, endpoint)
 	request.Signature = crypto.Base64Encode(hmac)
-	return h.Websocket.AuthConn.SendJSONMessage(request)
+	return h.Websocket.AuthConn.SendJSONMessage(stream.WithPriority(stream.PrioritySubscription, request))
 }
 
 func (h *HUOBI) wsGetAccountsList() (*WsAuthenticatedAccountsListResponse, error) {
 	if !h.Websocket.CanUseAuthenticatedEndpoints() {
 		return nil, fmt.Errorf("%v not authenticated cannot get accounts list", h.Name)
 	}
-	timestamp := time.Now().UTC().Format(wsDateTimeFormatting)
+	timestamp := h.Clock.Now().UTC().Format(wsDateTimeFormatting)
 	request := WsAuthenticatedAccountsListRequest{
 		Op:               requestOp,
 		AccessKeyID:      h.API.Credentials.Key,
@@ -659,7 +693,7 @@ func (h *HUOBI) wsGetOrdersList(accountID int64, pair currency.Pair) (*WsAuthent
 		return nil, err
 	}
 
-	timestamp := time.Now().UTC().Format(wsDateTimeFormatting)
+	timestamp := h.Clock.Now().UTC().Format(wsDateTimeFormatting)
 	request := WsAuthenticatedOrdersListRequest{
 		Op:               requestOp,
 		AccessKeyID:      h.API.Credentials.Key,
@@ -698,7 +732,7 @@ func (h *HUOBI) wsGetOrderDetails(orderID string) (*WsAuthenticatedOrderDetailRe
 	if !h.Websocket.CanUseAuthenticatedEndpoints() {
 		return nil, fmt.Errorf("%v not authenticated cannot get order details", h.Name)
 	}
-	timestamp := time.Now().UTC().Format(wsDateTimeFormatting)
+	timestamp := h.Clock.Now().UTC().Format(wsDateTimeFormatting)
 	request := WsAuthenticatedOrderDetailsRequest{
 		Op:               requestOp,
 		AccessKeyID:      h.API.Credentials.Key,
@@ -3,6 +3,7 @@ package huobi
 import (
 	"errors"
 	"fmt"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
@@ -14,6 +15,7 @@ import (
 	"github.com/openware/irix/portfolio/withdraw"
 	"github.com/openware/irix/protocol"
 	"github.com/openware/irix/stream"
+	"github.com/openware/irix/stream/buffer"
 	"github.com/openware/irix/ticker"
 	"github.com/openware/pkg/account"
 	"github.com/openware/pkg/asset"
@@ -185,6 +187,7 @@ func (h *HUOBI) Setup(exch *config.ExchangeConfig) error {
 	if err != nil {
 		return err
 	}
+	h.SetServerTimeSource(h.serverTime)
 
 	wsRunningURL, err := h.API.Endpoints.GetURL(exchange.WebsocketSpot)
 	if err != nil {
@@ -206,13 +209,26 @@ func (h *HUOBI) Setup(exch *config.ExchangeConfig) error {
 		Features:                         &h.Features.Supports.WebsocketCapabilities,
 		OrderbookBufferLimit:             exch.OrderbookConfig.WebsocketBufferLimit,
 		BufferEnabled:                    exch.OrderbookConfig.WebsocketBufferEnabled,
+		MaxSubscriptionsPerConnection:    wsChannelsPerConnection,
+		ShardConnector:                   h.wsConnectShard,
+		ShardSubscriber:                  h.subscribeOn,
+		ShardUnsubscriber:                h.unsubscribeOn,
+		OrderbookResync:                  h.resyncOrderbook,
 	})
 	if err != nil {
 		return err
 	}
 
+	h.obm, err = buffer.NewSyncManager(&h.Websocket.Orderbook,
+		h.UpdateOrderbook,
+		wsOrderbookBufferLimit,
+		wsOrderbookWorkers)
+	if err != nil {
+		return err
+	}
+
 	err = h.Websocket.SetupNewConnection(stream.ConnectionSetup{
-		RateLimit:            rateLimit,
+		MessageRate:          wsMessageRate,
 		ResponseCheckTimeout: exch.WebsocketResponseCheckTimeout,
 		ResponseMaxLimit:     exch.WebsocketResponseMaxLimit,
 	})
@@ -221,7 +237,7 @@ func (h *HUOBI) Setup(exch *config.ExchangeConfig) error {
 	}
 
 	return h.Websocket.SetupNewConnection(stream.ConnectionSetup{
-		RateLimit:            rateLimit,
+		MessageRate:          wsMessageRate,
 		ResponseCheckTimeout: exch.WebsocketResponseCheckTimeout,
 		ResponseMaxLimit:     exch.WebsocketResponseMaxLimit,
 		URL:                  wsAccountsOrdersURL,
@@ -313,6 +329,20 @@ func (h *HUOBI) Run() {
 		}
 	}
 
+	a := h.GetAssetTypes()
+	for x := range a {
+		if err = h.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
+			err = h.UpdateOrderExecutionLimits(a[x])
+			h.SetOrderExecutionLimitsLoadError(a[x], err)
+			if err != nil {
+				log.Errorf(log.ExchangeSys,
+					"Could not set %s exchange exchange limits: %v",
+					h.Name,
+					err)
+			}
+		}
+	}
+
 	if !h.GetEnabledFeatures().AutoPairUpdates && !forceUpdate {
 		return
 	}
@@ -804,6 +834,16 @@ func (h *HUOBI) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 	if err := s.Validate(); err != nil {
 		return submitOrderResponse, err
 	}
+	// Limits are only provided for spot
+	if s.AssetType == asset.Spot {
+		if err := h.ConformOrderToLimits(s); err != nil {
+			return submitOrderResponse, err
+		}
+	}
+	if err := exchange.AttachClientOrderID(h, s); err != nil {
+		return submitOrderResponse, err
+	}
+	price, amount := h.SubmitValues(s)
 	switch s.AssetType {
 	case asset.Spot:
 		accountID, err := strconv.ParseInt(s.ClientID, 10, 64)
@@ -812,10 +852,12 @@ func (h *HUOBI) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 		}
 		var formattedType SpotNewOrderRequestParamsType
 		var params = SpotNewOrderRequestParams{
-			Amount:    s.Amount,
-			Source:    "api",
-			Symbol:    s.Pair,
-			AccountID: int(accountID),
+			Amount:        s.Amount,
+			AmountString:  amount,
+			Source:        "api",
+			Symbol:        s.Pair,
+			AccountID:     int(accountID),
+			ClientOrderID: s.ClientOrderID,
 		}
 		switch {
 		case s.Side == order.Buy && s.Type == order.Market:
@@ -824,10 +866,10 @@ func (h *HUOBI) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 			formattedType = SpotNewOrderRequestTypeSellMarket
 		case s.Side == order.Buy && s.Type == order.Limit:
 			formattedType = SpotNewOrderRequestTypeBuyLimit
-			params.Price = s.Price
+			params.Price, params.PriceString = s.Price, price
 		case s.Side == order.Sell && s.Type == order.Limit:
 			formattedType = SpotNewOrderRequestTypeSellLimit
-			params.Price = s.Price
+			params.Price, params.PriceString = s.Price, price
 		}
 		params.Type = formattedType
 		response, err := h.SpotNewOrder(&params)
@@ -1049,64 +1091,11 @@ func (h *HUOBI) GetOrderInfo(orderID string, pair currency.Pair, assetType asset
 			return orderDetail, errors.New(h.Name + " - GetOrderInfo orderID mismatch. Expected: " +
 				orderID + " Received: " + responseID)
 		}
-		typeDetails := strings.Split(respData.Type, "-")
-		orderSide, err := order.StringToOrderSide(typeDetails[0])
-		if err != nil {
-			if h.Websocket.IsConnected() {
-				h.Websocket.DataHandler <- order.ClassificationError{
-					Exchange: h.Name,
-					OrderID:  orderID,
-					Err:      err,
-				}
-			} else {
-				return orderDetail, err
-			}
-		}
-		orderType, err := order.StringToOrderType(typeDetails[1])
-		if err != nil {
-			if h.Websocket.IsConnected() {
-				h.Websocket.DataHandler <- order.ClassificationError{
-					Exchange: h.Name,
-					OrderID:  orderID,
-					Err:      err,
-				}
-			} else {
-				return orderDetail, err
-			}
-		}
-		orderStatus, err := order.StringToOrderStatus(respData.State)
-		if err != nil {
-			if h.Websocket.IsConnected() {
-				h.Websocket.DataHandler <- order.ClassificationError{
-					Exchange: h.Name,
-					OrderID:  orderID,
-					Err:      err,
-				}
-			} else {
-				return orderDetail, err
-			}
-		}
-		var p currency.Pair
-		var a asset.Item
-		p, a, err = h.GetRequestFormattedPairAndAssetType(respData.Symbol)
+		var err error
+		orderDetail, err = h.spotOrderToDetail(respData)
 		if err != nil {
 			return orderDetail, err
 		}
-		orderDetail = order.Detail{
-			Exchange:       h.Name,
-			ID:             orderID,
-			AccountID:      strconv.FormatInt(respData.AccountID, 10),
-			Pair:           p,
-			Type:           orderType,
-			Side:           orderSide,
-			Date:           time.Unix(0, respData.CreatedAt*int64(time.Millisecond)),
-			Status:         orderStatus,
-			Price:          respData.Price,
-			Amount:         respData.Amount,
-			ExecutedAmount: respData.FilledAmount,
-			Fee:            respData.FilledFees,
-			AssetType:      a,
-		}
 	case asset.CoinMarginedFutures:
 		orderInfo, err := h.GetSwapOrderInfo(pair, orderID, "")
 		if err != nil {
@@ -1160,6 +1149,107 @@ func (h *HUOBI) GetOrderInfo(orderID string, pair currency.Pair, assetType asset
 	return orderDetail, nil
 }
 
+// GetOrderInfoByClientOrderID returns order information based on the client
+// order ID supplied on submission, only spot orders are supported
+func (h *HUOBI) GetOrderInfoByClientOrderID(clientOrderID string, pair currency.Pair, assetType asset.Item) (order.Detail, error) {
+	if clientOrderID == "" {
+		return order.Detail{}, order.ErrOrderIDNotSet
+	}
+	if assetType != asset.Spot {
+		return order.Detail{}, fmt.Errorf("%s %w", assetType, asset.ErrNotSupported)
+	}
+	resp, err := h.GetOrderByClientOrderID(clientOrderID)
+	if err != nil {
+		if strings.Contains(err.Error(), huobiRecordInvalidMsg) {
+			return order.Detail{}, fmt.Errorf("%s client order ID %s %w: %v",
+				h.Name,
+				clientOrderID,
+				exchange.ErrOrderNotFound,
+				err)
+		}
+		return order.Detail{}, err
+	}
+	if resp.ID == 0 {
+		return order.Detail{}, fmt.Errorf("%s client order ID %s %w",
+			h.Name,
+			clientOrderID,
+			exchange.ErrOrderNotFound)
+	}
+	return h.spotOrderToDetail(&resp)
+}
+
+// GenerateClientOrderID returns a numeric client order ID, which both the
+// spot client-order-id and the futures client_order_id accept
+func (h *HUOBI) GenerateClientOrderID() (string, error) {
+	return exchange.GenerateNumericClientOrderID(math.MaxInt64)
+}
+
+// spotOrderToDetail converts spot order info to an order detail
+func (h *HUOBI) spotOrderToDetail(respData *OrderInfo) (order.Detail, error) {
+	var orderDetail order.Detail
+	orderID := strconv.FormatInt(respData.ID, 10)
+	typeDetails := strings.Split(respData.Type, "-")
+	orderSide, err := order.StringToOrderSide(typeDetails[0])
+	if err != nil {
+		if h.Websocket.IsConnected() {
+			h.Websocket.DataHandler <- order.ClassificationError{
+				Exchange: h.Name,
+				OrderID:  orderID,
+				Err:      err,
+			}
+		} else {
+			return orderDetail, err
+		}
+	}
+	var orderType order.Type
+	if len(typeDetails) > 1 {
+		orderType, err = order.StringToOrderType(typeDetails[1])
+	}
+	if err != nil {
+		if h.Websocket.IsConnected() {
+			h.Websocket.DataHandler <- order.ClassificationError{
+				Exchange: h.Name,
+				OrderID:  orderID,
+				Err:      err,
+			}
+		} else {
+			return orderDetail, err
+		}
+	}
+	orderStatus, err := order.StringToOrderStatus(respData.State)
+	if err != nil {
+		if h.Websocket.IsConnected() {
+			h.Websocket.DataHandler <- order.ClassificationError{
+				Exchange: h.Name,
+				OrderID:  orderID,
+				Err:      err,
+			}
+		} else {
+			return orderDetail, err
+		}
+	}
+	p, a, err := h.GetRequestFormattedPairAndAssetType(respData.Symbol)
+	if err != nil {
+		return orderDetail, err
+	}
+	return order.Detail{
+		Exchange:       h.Name,
+		ID:             orderID,
+		ClientOrderID:  respData.ClientOrderID,
+		AccountID:      strconv.FormatInt(respData.AccountID, 10),
+		Pair:           p,
+		Type:           orderType,
+		Side:           orderSide,
+		Date:           time.Unix(0, respData.CreatedAt*int64(time.Millisecond)),
+		Status:         orderStatus,
+		Price:          respData.Price,
+		Amount:         respData.Amount,
+		ExecutedAmount: respData.FilledAmount,
+		Fee:            respData.FilledFees,
+		AssetType:      a,
+	}, nil
+}
+
 // GetDepositAddress returns a deposit address for a specified currency
 func (h *HUOBI) GetDepositAddress(cryptocurrency currency.Code, accountID string) (string, error) {
 	resp, err := h.QueryDepositAddress(cryptocurrency.Lower().String())
@@ -1659,3 +1749,21 @@ func compatibleVars(side, orderPriceType string, status int64) (OrderVars, error
 	}
 	return resp, nil
 }
+
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (h *HUOBI) UpdateOrderExecutionLimits(a asset.Item) error {
+	if a != asset.Spot {
+		return fmt.Errorf("%s %w", a, asset.ErrNotSupported)
+	}
+	limits, err := h.FetchSpotExchangeLimits()
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return h.LoadLimits(limits)
+}
+
+// resyncOrderbook fetches a websocket orderbook via REST once it falls out of
+// sync
+func (h *HUOBI) resyncOrderbook(p currency.Pair, a asset.Item) {
+	h.obm.Resync(p, a)
+}
@@ -0,0 +1,20 @@
+package irix
+
+import "github.com/openware/irix/metrics"
+
+// SetMetrics sets the recorder REST requests and the exchange's websocket
+// report to, nil falls back to metrics.Default
+func (b *Base) SetMetrics(r metrics.Recorder) {
+	b.Metrics = r
+	if b.Websocket != nil {
+		b.Websocket.SetMetrics(r)
+	}
+}
+
+// recorder returns the exchange's metrics recorder
+func (b *Base) recorder() metrics.Recorder {
+	if b.Metrics == nil {
+		return metrics.Default()
+	}
+	return b.Metrics
+}
@@ -0,0 +1,54 @@
+package irix
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openware/irix/metrics"
+	"github.com/openware/irix/stream"
+	"github.com/openware/pkg/request"
+)
+
+func TestSendPayloadMetrics(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	reg, err := metrics.NewRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := Base{Name: "instrumented", Websocket: stream.New()}
+	b.Requester = request.New(b.Name, new(http.Client))
+	b.SetMetrics(reg)
+
+	for _, path := range []string{"/ok", "/ok", "/fail"} {
+		var resp struct{}
+		_ = b.SendPayload(context.Background(), &request.Item{Method: http.MethodGet, Path: srv.URL + path + "?q=1", Result: &resp})
+	}
+	host := srv.Listener.Addr().String()
+	ok := metrics.Labels{Exchange: "instrumented", Endpoint: host + "/ok"}
+	fail := metrics.Labels{Exchange: "instrumented", Endpoint: host + "/fail"}
+	if v, _ := reg.Value(metrics.RESTRequests, ok); v != 2 {
+		t.Errorf("expected 2 requests, received %v", v)
+	}
+	if v, _ := reg.Value(metrics.RESTErrors, fail); v != 1 {
+		t.Errorf("expected 1 error, received %v", v)
+	}
+	if _, found := reg.Value(metrics.RESTErrors, ok); found {
+		t.Error("expected no errors for successful requests")
+	}
+	if c := reg.Count(metrics.RESTDuration, ok); c != 2 {
+		t.Errorf("expected 2 duration observations, received %d", c)
+	}
+	if c := reg.Count(metrics.RateLimitWait, ok); c != 2 {
+		t.Errorf("expected 2 rate limit wait observations, received %d", c)
+	}
+}
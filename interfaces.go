@@ -56,6 +56,7 @@ type IBotExchange interface {
 	CancelBatchOrders(o []order.Cancel) (order.CancelBatchResponse, error)
 	CancelAllOrders(orders *order.Cancel) (order.CancelAllResponse, error)
 	GetOrderInfo(orderID string, pair currency.Pair, assetType asset.Item) (order.Detail, error)
+	GetOrderInfoByClientOrderID(clientOrderID string, pair currency.Pair, assetType asset.Item) (order.Detail, error)
 	GetDepositAddress(cryptocurrency currency.Code, accountID string) (string, error)
 	GetOrderHistory(getOrdersRequest *order.GetOrdersRequest) ([]order.Detail, error)
 	GetWithdrawalsHistory(code currency.Code) ([]WithdrawalHistory, error)
@@ -91,4 +92,25 @@ type IBotExchange interface {
 	GetOrderExecutionLimits(a asset.Item, cp currency.Pair) (*order.Limits, error)
 	CheckOrderExecutionLimits(a asset.Item, cp currency.Pair, price, amount float64, orderType order.Type) error
 	UpdateOrderExecutionLimits(a asset.Item) error
+	// Shutdown stops the exchange's background routines
+	Shutdown() error
+}
+
+// OrderSubmitter defines the functionality required to submit an order and
+// reconcile it by client order ID
+type OrderSubmitter interface {
+	GetName() string
+	SubmitOrder(s *order.Submit) (order.SubmitResponse, error)
+	GetOrderInfoByClientOrderID(clientOrderID string, pair currency.Pair, assetType asset.Item) (order.Detail, error)
+}
+
+// ClientOrderIDGenerator is implemented by exchanges whose client order IDs
+// have a format of their own, such as integers
+type ClientOrderIDGenerator interface {
+	GenerateClientOrderID() (string, error)
+}
+
+// FeeCalculator defines the functionality required to calculate exchange fees
+type FeeCalculator interface {
+	GetFeeByType(f *FeeBuilder) (float64, error)
 }
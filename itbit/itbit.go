@@ -322,7 +322,12 @@ func (i *ItBit) SendAuthenticatedHTTPRequest(ep exchange.URL, method, path strin
 		}
 	}
 
-	n := i.Requester.GetNonce(true).String()
+	nonce, release, err := i.NextNonce()
+	if err != nil {
+		return err
+	}
+	defer release()
+	n := nonce.String()
 	timestamp := strconv.FormatInt(time.Now().UnixNano()/1000000, 10)
 	message, err := json.Marshal([]string{method, urlPath, string(PayloadJSON), n, timestamp})
 	if err != nil {
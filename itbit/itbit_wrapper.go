@@ -363,6 +363,9 @@ func (i *ItBit) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 	if err := s.Validate(); err != nil {
 		return submitOrderResponse, err
 	}
+	if err := i.ConformOrderToLimits(s); err != nil {
+		return submitOrderResponse, err
+	}
 
 	var wallet string
 	wallets, err := i.GetWallets(url.Values{})
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -52,6 +53,16 @@ func (k *Kraken) GetServerTime() (TimeResponse, error) {
 	return response.Result, GetError(response.Error)
 }
 
+// serverTime returns the server time for the clock, Kraken only reports whole
+// seconds
+func (k *Kraken) serverTime() (time.Time, error) {
+	t, err := k.GetServerTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(t.Unixtime, 0), nil
+}
+
 // SeedAssets seeds Kraken's asset list and stores it in the
 // asset translator
 func (k *Kraken) SeedAssets() error {
@@ -114,6 +125,51 @@ func (k *Kraken) GetAssetPairs(assetPairs []string, info string) (map[string]Ass
 	return response.Result, GetError(response.Error)
 }
 
+// FetchSpotExchangeLimits fetches asset pair metadata and returns the tick and
+// lot sizes and minimum order amount of each spot pair
+func (k *Kraken) FetchSpotExchangeLimits() ([]order.MinMaxLevel, error) {
+	if !assetTranslator.Seeded() {
+		if err := k.SeedAssets(); err != nil {
+			return nil, err
+		}
+	}
+	pairs, err := k.GetAssetPairs([]string{}, "")
+	if err != nil {
+		return nil, err
+	}
+	var limits []order.MinMaxLevel
+	for i := range pairs {
+		if strings.Contains(pairs[i].Altname, ".d") {
+			continue
+		}
+		base := assetTranslator.LookupAltname(pairs[i].Base)
+		quote := assetTranslator.LookupAltname(pairs[i].Quote)
+		if base == "" || quote == "" {
+			continue
+		}
+		var cp currency.Pair
+		cp, err = currency.NewPairFromStrings(base, quote)
+		if err != nil {
+			return nil, err
+		}
+		var minAmount float64
+		if pairs[i].Ordermin != "" {
+			minAmount, err = strconv.ParseFloat(pairs[i].Ordermin, 64)
+			if err != nil {
+				return nil, err
+			}
+		}
+		limits = append(limits, order.MinMaxLevel{
+			Pair:       cp,
+			Asset:      asset.Spot,
+			StepPrice:  math.Pow10(-pairs[i].PairDecimals),
+			StepAmount: math.Pow10(-pairs[i].LotDecimals),
+			MinAmount:  minAmount,
+		})
+	}
+	return limits, nil
+}
+
 // GetTicker returns ticker information from kraken
 func (k *Kraken) GetTicker(symbol currency.Pair) (Ticker, error) {
 	tick := Ticker{}
@@ -868,8 +924,15 @@ func (k *Kraken) AddOrder(symbol currency.Pair, side, orderType string, volume,
 		"volume":    {strconv.FormatFloat(volume, 'f', -1, 64)},
 	}
 
+	if args.VolumeString != "" {
+		params.Set("volume", args.VolumeString)
+	}
+
 	if orderType == order.Limit.Lower() || price > 0 {
 		params.Set("price", strconv.FormatFloat(price, 'f', -1, 64))
+		if args.PriceString != "" {
+			params.Set("price", args.PriceString)
+		}
 	}
 
 	if price2 != 0 {
@@ -880,6 +943,10 @@ func (k *Kraken) AddOrder(symbol currency.Pair, side, orderType string, volume,
 		params.Set("leverage", strconv.FormatFloat(leverage, 'f', -1, 64))
 	}
 
+	if args.UserRef != 0 {
+		params.Set("userref", strconv.FormatInt(int64(args.UserRef), 10))
+	}
+
 	if args.OrderFlags != "" {
 		params.Set("oflags", args.OrderFlags)
 	}
@@ -980,7 +1047,12 @@ func (k *Kraken) SendAuthenticatedHTTPRequest(ep exchange.URL, method string, pa
 	}
 	path := fmt.Sprintf("/%s/private/%s", krakenAPIVersion, method)
 
-	params.Set("nonce", k.Requester.GetNonce(true).String())
+	nonce, release, err := k.NextNonce()
+	if err != nil {
+		return err
+	}
+	defer release()
+	params.Set("nonce", nonce.String())
 	encoded := params.Encode()
 	shasum := crypto.GetSHA256([]byte(params.Get("nonce") + encoded))
 	signature := crypto.Base64Encode(crypto.GetHMAC(crypto.HashSHA512,
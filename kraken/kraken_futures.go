@@ -38,6 +38,34 @@ func (k *Kraken) GetFuturesMarkets() (FuturesInstrumentData, error) {
 	return resp, k.SendHTTPRequest(exchange.RestFutures, futuresInstruments, &resp)
 }
 
+// FetchFuturesExchangeLimits fetches futures instrument metadata and returns
+// the tick size of each tradable instrument, orders are sized in whole
+// contracts
+func (k *Kraken) FetchFuturesExchangeLimits() ([]order.MinMaxLevel, error) {
+	markets, err := k.GetFuturesMarkets()
+	if err != nil {
+		return nil, err
+	}
+	var limits []order.MinMaxLevel
+	for x := range markets.Instruments {
+		if !markets.Instruments[x].Tradable {
+			continue
+		}
+		var cp currency.Pair
+		cp, err = currency.NewPairFromString(markets.Instruments[x].Symbol)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, order.MinMaxLevel{
+			Pair:       cp,
+			Asset:      asset.Futures,
+			StepPrice:  markets.Instruments[x].TickSize,
+			StepAmount: 1,
+		})
+	}
+	return limits, nil
+}
+
 // GetFuturesTickers gets a list of futures tickers and their data
 func (k *Kraken) GetFuturesTickers() (FuturesTickerData, error) {
 	var resp FuturesTickerData
@@ -265,7 +293,7 @@ func (k *Kraken) SendFuturesAuthRequest(method, path string, postData url.Values
 	if postData == nil {
 		postData = url.Values{}
 	}
-	nonce := strconv.FormatInt(time.Now().UnixNano()/1000000, 10)
+	nonce := strconv.FormatInt(k.Clock.Now().UnixNano()/1000000, 10)
 	reqData := ""
 	if len(data) > 0 {
 		temp, err := json.Marshal(data)
@@ -1,6 +1,7 @@
 package kraken
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"os"
@@ -1148,7 +1149,7 @@ func TestWsAddOrder(t *testing.T) {
 		OrderType: order.Limit.Lower(),
 		OrderSide: order.Buy.Lower(),
 		Pair:      "XBT/USD",
-		Price:     -100,
+		Price:     "-100",
 	})
 	if err != nil {
 		t.Error(err)
@@ -1256,6 +1257,18 @@ func TestWsSubscriptionStatus(t *testing.T) {
 	}
 }
 
+func TestWsSubscriptionAcknowledgement(t *testing.T) {
+	t.Parallel()
+	err := wsConfirmSubscription([]byte(`{"event":"subscriptionStatus","reqid":7,"status":"subscribed"}`))
+	if err != nil {
+		t.Error(err)
+	}
+	err = wsConfirmSubscription([]byte(`{"errorMessage":"Subscription depth not supported","event":"subscriptionStatus","reqid":7,"status":"error"}`))
+	if err == nil || !strings.Contains(err.Error(), "depth not supported") {
+		t.Errorf("expected rejection, received %v", err)
+	}
+}
+
 func TestWsTicker(t *testing.T) {
 	pressXToJSON := []byte(`{
   "channelID": 1337,
@@ -1999,8 +2012,89 @@ func TestChecksumCalculation(t *testing.T) {
 		t.Fatalf("expected %s but received %s", expected, v)
 	}
 
-	err := validateCRC32(&testOb, krakenAPIDocChecksum, 5, 8)
+	c, err := newChecksum(5, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check, err := c.Checksum(testOb.Bids, testOb.Asks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if check != krakenAPIDocChecksum {
+		t.Fatalf("expected checksum %d but received %d", krakenAPIDocChecksum, check)
+	}
+	if _, err = newChecksum(0, 8); err == nil {
+		t.Fatal("expected error without decimal places")
+	}
+}
+
+func TestClientOrderIDToUserRef(t *testing.T) {
+	t.Parallel()
+	if ref, _ := clientOrderIDToUserRef(""); ref != 0 {
+		t.Errorf("expected 0 userref for empty client order ID, received %d", ref)
+	}
+	if ref, exact := clientOrderIDToUserRef("1337"); ref != 1337 || !exact {
+		t.Errorf("expected numeric client order ID to be used as is, received %d %v", ref, exact)
+	}
+	id := "6f0b4cd6-79a1-4b7c-8f4a-b0a5d9d0b3a1"
+	ref, exact := clientOrderIDToUserRef(id)
+	if ref <= 0 {
+		t.Errorf("expected positive userref, received %d", ref)
+	}
+	if exact {
+		t.Error("hashed userref must not be reported exact")
+	}
+	if again, _ := clientOrderIDToUserRef(id); ref != again {
+		t.Error("userref derivation must be deterministic")
+	}	// Generated client order IDs are used as the userref
+	generated, err := k.GenerateClientOrderID()
 	if err != nil {
 		t.Fatal(err)
 	}
+	if _, exact = clientOrderIDToUserRef(generated); !exact {
+		t.Errorf("expected generated client order ID %s to be an exact userref", generated)
+	}
+}
+
+func TestOrderIDs(t *testing.T) {
+	t.Parallel()
+	txids := orderIDs(
+		map[string]OrderInfo{"a": {}, "b": {}},
+		map[string]OrderInfo{"b": {}, "c": {}},
+	)
+	if len(txids) != 3 {
+		t.Errorf("expected 3 unique order IDs, received %v", txids)
+	}
+}
+
+func TestGetOrderInfoByClientOrderID(t *testing.T) {
+	t.Parallel()
+	_, err := k.GetOrderInfoByClientOrderID("", currency.Pair{}, asset.Spot)
+	if !errors.Is(err, order.ErrOrderIDNotSet) {
+		t.Errorf("received: %v but expected: %v", err, order.ErrOrderIDNotSet)
+	}
+	_, err = k.GetOrderInfoByClientOrderID("1337", currency.Pair{}, asset.Margin)
+	if !errors.Is(err, asset.ErrNotSupported) {
+		t.Errorf("received: %v but expected: %v", err, asset.ErrNotSupported)
+	}
+}
+
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	err := k.UpdateOrderExecutionLimits(asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = k.UpdateOrderExecutionLimits(asset.Margin); err == nil {
+		t.Fatal("expected unhandled case")
+	}
+	cp := currency.NewPair(currency.XBT, currency.USD)
+	limit, err := k.GetOrderExecutionLimits(asset.Spot, cp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = limit.Conforms(30000, 0.00000001, order.Limit)
+	if !errors.Is(err, order.ErrAmountBelowMin) {
+		t.Fatalf("received: %v but expected: %v", err, order.ErrAmountBelowMin)
+	}
 }
@@ -1,6 +1,7 @@
 package kraken
 
 import (
+	"errors"
 	"time"
 
 	"github.com/openware/irix/stream"
@@ -73,6 +74,9 @@ const (
 
 var (
 	assetTranslator assetTranslatorStore
+
+	errFuturesOrderNotOpen = errors.New("futures order not open and cannot be reconciled by client order ID")
+	errUserRefNotUnique    = errors.New("userref does not uniquely identify an order")
 )
 
 // GenericResponse stores general response data for functions that only return success
@@ -409,6 +413,10 @@ type AddOrderOptions struct {
 	ClosePrice     float64
 	ClosePrice2    float64
 	Validate       bool
+	// VolumeString and PriceString are exact formatted values sent in place
+	// of the volume and price when set
+	VolumeString string
+	PriceString  string
 }
 
 // CancelOrderResponse type
@@ -659,9 +667,9 @@ type WsAddOrderRequest struct {
 	OrderType       string  `json:"ordertype"`
 	OrderSide       string  `json:"type"`
 	Pair            string  `json:"pair"`
-	Price           float64 `json:"price,string,omitempty"`  // optional
+	Price           string  `json:"price,omitempty"`         // optional
 	Price2          float64 `json:"price2,string,omitempty"` // optional
-	Volume          float64 `json:"volume,string,omitempty"`
+	Volume          string  `json:"volume,omitempty"`
 	Leverage        float64 `json:"leverage,omitempty"`         // optional
 	OFlags          string  `json:"oflags,omitempty"`           // optional
 	StartTime       string  `json:"starttm,omitempty"`          // optional
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"hash/crc32"
 	"net/http"
 	"strconv"
 	"strings"
@@ -51,9 +50,13 @@ const (
 	krakenWsAddOrderStatus       = "addOrderStatus"
 	krakenWsCancelOrderStatus    = "cancelOrderStatus"
 	krakenWsCancelAllOrderStatus = "cancelAllStatus"
-	krakenWsRateLimit            = 50
-	krakenWsPingDelay            = time.Second * 27
-	krakenWsOrderbookDepth       = 1000
+	// Kraken does not document a message limit for the public connection
+	// but drops orderbook subscriptions sent faster than one a second, the
+	// authenticated connection carries orders and keeps a faster rate
+	krakenWsMessageRate     = 1
+	krakenWsAuthMessageRate = 20
+	krakenWsPingDelay       = time.Second * 27
+	krakenWsOrderbookDepth  = 1000
 )
 
 // orderbookMutex Ensures if two entries arrive at once, only one can be
@@ -342,6 +345,11 @@ func (k *Kraken) wsHandleData(respRaw []byte) error {
 						respRaw)
 				}
 				if sub.Status != "subscribed" && sub.Status != "unsubscribed" {
+					// A rejection of an expected request fails its
+					// subscriptions
+					if sub.RequestID > 0 && k.Websocket.Match.IncomingWithData(sub.RequestID, respRaw) {
+						return nil
+					}
 					return fmt.Errorf("%v %v %v",
 						k.Name,
 						sub.RequestID,
@@ -989,63 +997,34 @@ func (k *Kraken) wsProcessOrderBookUpdate(channelData *WebsocketChannelData, ask
 		}
 	}
 	update.UpdateTime = highestLastUpdate
-	err := k.Websocket.Orderbook.Update(&update)
-	if err != nil {
-		return err
-	}
 
-	book, err := k.Websocket.Orderbook.GetOrderbook(channelData.Pair, asset.Spot)
+	token, err := strconv.ParseUint(checksum, 10, 32)
 	if err != nil {
-		return fmt.Errorf("cannot calculate websocket checksum: book not found for %s %s %w",
-			channelData.Pair,
-			asset.Spot,
-			err)
+		return err
 	}
-
-	token, err := strconv.ParseInt(checksum, 10, 64)
+	update.Checksum = uint32(token)
+	update.Checksummer, err = newChecksum(priceDP, amtDP)
 	if err != nil {
-		return err
+		return fmt.Errorf("%s %s %w", channelData.Pair, asset.Spot, err)
 	}
-
-	return validateCRC32(book, uint32(token), priceDP, amtDP)
+	return k.Websocket.Orderbook.Update(&update)
 }
 
-func validateCRC32(b *orderbook.Base, token uint32, decPrice, decAmount int) error {
-	if len(b.Asks) < 10 || len(b.Bids) < 10 {
-		return fmt.Errorf("%s %s insufficient bid and asks to calculate checksum",
-			b.Pair,
-			b.Asset)
-	}
-
+// newChecksum returns the checksum of the top ten asks followed by the top ten
+// bids, with prices and amounts formatted to the decimal places of the update
+func newChecksum(decPrice, decAmount int) (*buffer.CRC32, error) {
 	if decPrice == 0 || decAmount == 0 {
-		return fmt.Errorf("%s %s trailing decimal count not calculated",
-			b.Pair,
-			b.Asset)
-	}
-
-	var checkStr strings.Builder
-	for i := 0; i < 10; i++ {
-		priceStr := trim(strconv.FormatFloat(b.Asks[i].Price, 'f', decPrice, 64))
-		checkStr.WriteString(priceStr)
-		amountStr := trim(strconv.FormatFloat(b.Asks[i].Amount, 'f', decAmount, 64))
-		checkStr.WriteString(amountStr)
-	}
-
-	for i := 0; i < 10; i++ {
-		priceStr := trim(strconv.FormatFloat(b.Bids[i].Price, 'f', decPrice, 64))
-		checkStr.WriteString(priceStr)
-		amountStr := trim(strconv.FormatFloat(b.Bids[i].Amount, 'f', decAmount, 64))
-		checkStr.WriteString(amountStr)
-	}
-
-	if check := crc32.ChecksumIEEE([]byte(checkStr.String())); check != token {
-		return fmt.Errorf("%s %s invalid checksum %d, expected %d",
-			b.Pair,
-			b.Asset,
-			check,
-			token)
-	}
-	return nil
+		return nil, errors.New("trailing decimal count not calculated")
+	}
+	return &buffer.CRC32{
+		Depth:    10,
+		MinDepth: 10,
+		Layout:   buffer.AsksThenBids,
+		Format: func(item orderbook.Item, _ bool) string {
+			return trim(strconv.FormatFloat(item.Price, 'f', decPrice, 64)) +
+				trim(strconv.FormatFloat(item.Amount, 'f', decAmount, 64))
+		},
+	}, nil
 }
 
 // trim removes '.' and prefixed '0' from subsequent string
@@ -1188,30 +1167,22 @@ channels:
 	}
 
 	var errs common.Errors
-	for subType, subs := range subscriptions {
+	for _, subs := range subscriptions {
 		for i := range *subs {
+			conn := k.Websocket.Conn
 			if common.StringDataContains(authenticatedChannels, (*subs)[i].Subscription.Name) {
-				_, err := k.Websocket.AuthConn.SendMessageReturnResponse((*subs)[i].RequestID, (*subs)[i])
-				if err != nil {
-					errs = append(errs, err)
-					continue
-				}
-				k.Websocket.AddSuccessfulSubscriptions((*subs)[i].Channels...)
-				continue
-			}
-			if subType == "book" {
-				// There is an undocumented subscription limit that is present
-				// on websocket orderbooks, to subscribe to the channel while
-				// actually receiving the snapshots a rudimentary sleep is
-				// imposed and requests are batched in allotments of 20 items.
-				time.Sleep(time.Second)
+				conn = k.Websocket.AuthConn
 			}
-			_, err := k.Websocket.Conn.SendMessageReturnResponse((*subs)[i].RequestID, (*subs)[i])
+			// Expected before sending as the acknowledgement can arrive first
+			err := k.Websocket.ExpectSubscriptions((*subs)[i].RequestID, (*subs)[i].Channels...)
 			if err != nil {
 				errs = append(errs, err)
 				continue
 			}
-			k.Websocket.AddSuccessfulSubscriptions((*subs)[i].Channels...)
+			err = conn.SendJSONMessage(stream.WithPriority(stream.PrioritySubscription, (*subs)[i]))
+			if err != nil {
+				errs = append(errs, err)
+			}
 		}
 	}
 	if errs != nil {
@@ -1220,6 +1191,25 @@ channels:
 	return nil
 }
 
+// wsConfirmSubscription returns the error Kraken rejected a subscription
+// request with
+func wsConfirmSubscription(resp []byte) error {
+	var sub wsSubscription
+	if err := json.Unmarshal(resp, &sub); err != nil {
+		return err
+	}
+	if sub.Status != "subscribed" && sub.Status != "unsubscribed" {
+		return fmt.Errorf("subscription rejected: %s", sub.ErrorMessage)
+	}
+	return nil
+}
+
+// isOrderbookChannel reports whether a subscription is to the book channel,
+// which sends a fresh snapshot when resubscribed
+func isOrderbookChannel(c *stream.ChannelSubscription) bool {
+	return c.Channel == krakenWsOrderbook
+}
+
 // Unsubscribe sends a websocket message to stop receiving data from the channel
 func (k *Kraken) Unsubscribe(channelsToUnsubscribe []stream.ChannelSubscription) error {
 	var unsubs []WebsocketSubscriptionEventRequest
@@ -1261,22 +1251,19 @@ channels:
 
 	var errs common.Errors
 	for i := range unsubs {
+		conn := k.Websocket.Conn
 		if common.StringDataContains(authenticatedChannels, unsubs[i].Subscription.Name) {
-			_, err := k.Websocket.AuthConn.SendMessageReturnResponse(unsubs[i].RequestID, unsubs[i])
-			if err != nil {
-				errs = append(errs, err)
-				continue
-			}
-			k.Websocket.RemoveSuccessfulUnsubscriptions(unsubs[i].Channels...)
-			continue
+			conn = k.Websocket.AuthConn
 		}
-
-		_, err := k.Websocket.Conn.SendMessageReturnResponse(unsubs[i].RequestID, unsubs[i])
+		err := k.Websocket.ExpectUnsubscriptions(unsubs[i].RequestID, unsubs[i].Channels...)
 		if err != nil {
 			errs = append(errs, err)
 			continue
 		}
-		k.Websocket.RemoveSuccessfulUnsubscriptions(unsubs[i].Channels...)
+		err = conn.SendJSONMessage(stream.WithPriority(stream.PrioritySubscription, unsubs[i]))
+		if err != nil {
+			errs = append(errs, err)
+		}
 	}
 	if errs != nil {
 		return errs
@@ -1290,7 +1277,8 @@ func (k *Kraken) wsAddOrder(request *WsAddOrderRequest) (string, error) {
 	request.RequestID = id
 	request.Event = krakenWsAddOrder
 	request.Token = authToken
-	jsonResp, err := k.Websocket.AuthConn.SendMessageReturnResponse(id, request)
+	jsonResp, err := k.Websocket.AuthConn.SendMessageReturnResponse(id,
+		stream.WithPriority(stream.PriorityOrder, request))
 	if err != nil {
 		return "", err
 	}
@@ -1326,7 +1314,8 @@ func (k *Kraken) wsCancelOrders(orderIDs []string) error {
 
 	defer delete(cancelOrdersStatus, id)
 
-	_, err := k.Websocket.AuthConn.SendMessageReturnResponse(id, request)
+	_, err := k.Websocket.AuthConn.SendMessageReturnResponse(id,
+		stream.WithPriority(stream.PriorityOrder, request))
 	if err != nil {
 		return err
 	}
@@ -1354,7 +1343,8 @@ func (k *Kraken) wsCancelAllOrders() (*WsCancelOrderResponse, error) {
 		RequestID: id,
 	}
 
-	jsonResp, err := k.Websocket.AuthConn.SendMessageReturnResponse(id, request)
+	jsonResp, err := k.Websocket.AuthConn.SendMessageReturnResponse(id,
+		stream.WithPriority(stream.PriorityOrder, request))
 	if err != nil {
 		return &WsCancelOrderResponse{}, err
 	}
@@ -3,6 +3,8 @@ package kraken
 import (
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
@@ -196,6 +198,7 @@ func (k *Kraken) Setup(exch *config.ExchangeConfig) error {
 	if err != nil {
 		return err
 	}
+	k.SetServerTimeSource(k.serverTime)
 
 	err = k.SeedAssets()
 	if err != nil {
@@ -219,6 +222,8 @@ func (k *Kraken) Setup(exch *config.ExchangeConfig) error {
 		UnSubscriber:                     k.Unsubscribe,
 		GenerateSubscriptions:            k.GenerateDefaultSubscriptions,
 		Features:                         &k.Features.Supports.WebsocketCapabilities,
+		OrderbookChannel:                 isOrderbookChannel,
+		ConfirmSubscriptions:             wsConfirmSubscription,
 		OrderbookBufferLimit:             exch.OrderbookConfig.WebsocketBufferLimit,
 		BufferEnabled:                    exch.OrderbookConfig.WebsocketBufferEnabled,
 		SortBuffer:                       true,
@@ -228,7 +233,7 @@ func (k *Kraken) Setup(exch *config.ExchangeConfig) error {
 	}
 
 	err = k.Websocket.SetupNewConnection(stream.ConnectionSetup{
-		RateLimit:            krakenWsRateLimit,
+		MessageRate:          krakenWsMessageRate,
 		ResponseCheckTimeout: exch.WebsocketResponseCheckTimeout,
 		ResponseMaxLimit:     exch.WebsocketResponseMaxLimit,
 		URL:                  krakenWSURL,
@@ -238,7 +243,7 @@ func (k *Kraken) Setup(exch *config.ExchangeConfig) error {
 	}
 
 	return k.Websocket.SetupNewConnection(stream.ConnectionSetup{
-		RateLimit:            krakenWsRateLimit,
+		MessageRate:          krakenWsAuthMessageRate,
 		ResponseCheckTimeout: exch.WebsocketResponseCheckTimeout,
 		ResponseMaxLimit:     exch.WebsocketResponseMaxLimit,
 		URL:                  krakenAuthWSURL,
@@ -314,6 +319,20 @@ func (k *Kraken) Run() {
 		}
 	}
 
+	a := k.GetAssetTypes()
+	for x := range a {
+		if err = k.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
+			err = k.UpdateOrderExecutionLimits(a[x])
+			k.SetOrderExecutionLimitsLoadError(a[x], err)
+			if err != nil {
+				log.Errorf(log.ExchangeSys,
+					"Could not set %s exchange exchange limits: %v",
+					k.Name,
+					err)
+			}
+		}
+	}
+
 	if !k.GetEnabledFeatures().AutoPairUpdates && !forceUpdate {
 		return
 	}
@@ -689,18 +708,34 @@ func (k *Kraken) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 	if err != nil {
 		return submitOrderResponse, err
 	}
+	err = exchange.AttachClientOrderID(k, s)
+	if err != nil {
+		return submitOrderResponse, err
+	}
+	err = k.ConformOrderToLimits(s)
+	if err != nil {
+		return submitOrderResponse, err
+	}
+	price, amount := k.SubmitValues(s)
 	switch s.AssetType {
 	case asset.Spot:
+		userRef, _ := clientOrderIDToUserRef(s.ClientOrderID)
 		if k.Websocket.CanUseAuthenticatedWebsocketForWrapper() {
 			var resp string
 			s.Pair.Delimiter = "/" // required pair format: ISO 4217-A3
-			resp, err = k.wsAddOrder(&WsAddOrderRequest{
+			req := &WsAddOrderRequest{
 				OrderType: s.Type.Lower(),
 				OrderSide: s.Side.Lower(),
 				Pair:      s.Pair.String(),
-				Price:     s.Price,
-				Volume:    s.Amount,
-			})
+				Volume:    amount,
+			}
+			if s.Price != 0 {
+				req.Price = price
+			}
+			if userRef != 0 {
+				req.UserReferenceID = strconv.FormatInt(int64(userRef), 10)
+			}
+			resp, err = k.wsAddOrder(req)
 			if err != nil {
 				return submitOrderResponse, err
 			}
@@ -715,7 +750,11 @@ func (k *Kraken) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 				s.Price,
 				0,
 				0,
-				&AddOrderOptions{})
+				&AddOrderOptions{
+					UserRef:      userRef,
+					VolumeString: amount,
+					PriceString:  price,
+				})
 			if err != nil {
 				return submitOrderResponse, err
 			}
@@ -962,6 +1001,147 @@ func (k *Kraken) GetOrderInfo(orderID string, pair currency.Pair, assetType asse
 	return orderDetail, nil
 }
 
+// GetOrderInfoByClientOrderID returns order information based on the client
+// order ID supplied on submission. Spot orders are matched by the userref
+// derived from the client order ID, a match is only reported when the userref
+// is the client order ID itself and a single order carries it.
+func (k *Kraken) GetOrderInfoByClientOrderID(clientOrderID string, pair currency.Pair, assetType asset.Item) (order.Detail, error) {
+	if clientOrderID == "" {
+		return order.Detail{}, order.ErrOrderIDNotSet
+	}
+	switch assetType {
+	case asset.Spot:
+		userRef, exact := clientOrderIDToUserRef(clientOrderID)
+		openOrders, err := k.GetOpenOrders(OrderInfoOptions{UserRef: userRef})
+		if err != nil {
+			return order.Detail{}, err
+		}
+		closedOrders, err := k.GetClosedOrders(GetClosedOrdersOptions{UserRef: userRef})
+		if err != nil {
+			return order.Detail{}, err
+		}
+		txids := orderIDs(openOrders.Open, closedOrders.Closed)
+		switch {
+		case len(txids) == 0:
+			// No order carries the userref so the order was not placed,
+			// whether or not the userref is shared
+		case exact && len(txids) == 1:
+			return k.GetOrderInfo(txids[0], pair, assetType)
+		default:
+			// A hashed userref can be shared with an unrelated order so it
+			// cannot confirm which order, if any, is the one submitted
+			return order.Detail{}, fmt.Errorf("%s client order ID %s %w: %v matching userref %d",
+				k.Name,
+				clientOrderID,
+				exchange.ErrOrderOutcomeUnknown,
+				errUserRefNotUnique,
+				userRef)
+		}
+	case asset.Futures:
+		openOrders, err := k.FuturesOpenOrders()
+		if err != nil {
+			return order.Detail{}, err
+		}
+		for i := range openOrders.OpenOrders {
+			if openOrders.OpenOrders[i].ClientOrderID != clientOrderID {
+				continue
+			}
+			return k.futuresOpenOrderToDetail(&openOrders.OpenOrders[i])
+		}
+		// Fills do not carry the client order ID, so an order that is no
+		// longer open cannot be confirmed as absent
+		return order.Detail{}, fmt.Errorf("%s client order ID %s %w",
+			k.Name,
+			clientOrderID,
+			errFuturesOrderNotOpen)
+	default:
+		return order.Detail{}, fmt.Errorf("%s %w", assetType, asset.ErrNotSupported)
+	}
+	return order.Detail{}, fmt.Errorf("%s client order ID %s %w",
+		k.Name,
+		clientOrderID,
+		exchange.ErrOrderNotFound)
+}
+
+// futuresOpenOrderToDetail converts an open futures order to an order detail
+func (k *Kraken) futuresOpenOrderToDetail(o *FOpenOrdersData) (order.Detail, error) {
+	p, err := currency.NewPairFromString(o.Symbol)
+	if err != nil {
+		return order.Detail{}, err
+	}
+	side, err := compatibleOrderSide(o.Side)
+	if err != nil {
+		return order.Detail{}, err
+	}
+	oType, err := compatibleOrderType(o.OrderType)
+	if err != nil {
+		return order.Detail{}, err
+	}
+	status, err := order.StringToOrderStatus(o.Status)
+	if err != nil {
+		return order.Detail{}, err
+	}
+	date, err := time.Parse(krakenFormat, o.ReceivedTime)
+	if err != nil {
+		return order.Detail{}, err
+	}
+	return order.Detail{
+		Exchange:        k.Name,
+		ID:              o.OrderID,
+		ClientOrderID:   o.ClientOrderID,
+		Pair:            p,
+		AssetType:       asset.Futures,
+		Side:            side,
+		Type:            oType,
+		Status:          status,
+		Price:           o.LimitPrice,
+		Amount:          o.FilledSize + o.UnfilledSize,
+		ExecutedAmount:  o.FilledSize,
+		RemainingAmount: o.UnfilledSize,
+		Date:            date,
+	}, nil
+}
+
+// orderIDs returns the unique order IDs across sets of orders
+func orderIDs(sets ...map[string]OrderInfo) []string {
+	var txids []string
+	seen := make(map[string]bool)
+	for _, orders := range sets {
+		for k := range orders {
+			if !seen[k] {
+				seen[k] = true
+				txids = append(txids, k)
+			}
+		}
+	}
+	return txids
+}
+
+// GenerateClientOrderID returns a client order ID which is a positive int32 so
+// it is used as a spot order's userref as is
+func (k *Kraken) GenerateClientOrderID() (string, error) {
+	return exchange.GenerateNumericClientOrderID(math.MaxInt32)
+}
+
+// clientOrderIDToUserRef maps a client order ID to Kraken's numeric userref.
+// Numeric IDs are used as is and reported exact, other IDs are hashed into
+// the positive int32 range and may collide with the userref of another order.
+func clientOrderIDToUserRef(clientOrderID string) (ref int32, exact bool) {
+	if clientOrderID == "" {
+		return 0, false
+	}
+	if n, err := strconv.ParseInt(clientOrderID, 10, 32); err == nil && n > 0 {
+		return int32(n), true
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(clientOrderID))
+	ref = int32(h.Sum32() & math.MaxInt32)
+	if ref == 0 {
+		ref = 1
+	}
+	return ref, false
+}
+
 // GetDepositAddress returns a deposit address for a specified currency
 func (k *Kraken) GetDepositAddress(cryptocurrency currency.Code, _ string) (string, error) {
 	methods, err := k.GetDepositMethods(cryptocurrency.String())
@@ -1480,3 +1660,21 @@ func compatibleFillOrderType(fillType string) (order.Type, error) {
 	}
 	return resp, nil
 }
+
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (k *Kraken) UpdateOrderExecutionLimits(a asset.Item) error {
+	var limits []order.MinMaxLevel
+	var err error
+	switch a {
+	case asset.Spot:
+		limits, err = k.FetchSpotExchangeLimits()
+	case asset.Futures:
+		limits, err = k.FetchFuturesExchangeLimits()
+	default:
+		err = fmt.Errorf("%s %w", a, asset.ErrNotSupported)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return k.LoadLimits(limits)
+}
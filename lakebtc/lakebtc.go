@@ -295,7 +295,12 @@ func (l *LakeBTC) SendAuthenticatedHTTPRequest(ep exchange.URL, method, params s
 	if err != nil {
 		return err
 	}
-	n := l.Requester.GetNonce(true).String()
+	nonce, release, err := l.NextNonce()
+	if err != nil {
+		return err
+	}
+	defer release()
+	n := nonce.String()
 
 	req := fmt.Sprintf("tonce=%s&accesskey=%s&requestmethod=post&id=1&method=%s&params=%s", n, l.API.Credentials.Key, method, params)
 	hmac := crypto.GetHMAC(crypto.HashSHA1, []byte(req), []byte(l.API.Credentials.Secret))
@@ -315,7 +320,7 @@ func (l *LakeBTC) SendAuthenticatedHTTPRequest(ep exchange.URL, method, params s
 	}
 
 	headers := make(map[string]string)
-	headers["Json-Rpc-Tonce"] = l.Nonce.String()
+	headers["Json-Rpc-Tonce"] = n
 	headers["Authorization"] = "Basic " + crypto.Base64Encode([]byte(l.API.Credentials.Key+":"+crypto.HexEncodeToString(hmac)))
 	headers["Content-Type"] = "application/json-rpc"
 
@@ -415,6 +415,9 @@ func (l *LakeBTC) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 	if err := s.Validate(); err != nil {
 		return submitOrderResponse, err
 	}
+	if err := l.ConformOrderToLimits(s); err != nil {
+		return submitOrderResponse, err
+	}
 
 	fPair, err := l.FormatExchangeCurrency(s.Pair, s.AssetType)
 	if err != nil {
@@ -18,7 +18,9 @@ import (
 
 	exchange "github.com/openware/irix"
 	"github.com/openware/irix/stream"
+	"github.com/openware/pkg/asset"
 	gctcrypto "github.com/openware/pkg/common/crypto"
+	"github.com/openware/pkg/currency"
 	"github.com/openware/pkg/order"
 	"github.com/openware/pkg/request"
 )
@@ -325,6 +327,48 @@ func (l *Lbank) GetPairInfo() ([]PairInfoResponse, error) {
 	return resp, l.SendHTTPRequest(exchange.RestSpot, path, &resp)
 }
 
+// FetchExchangeLimits fetches the order execution limits of every trading
+// pair
+func (l *Lbank) FetchExchangeLimits(a asset.Item) ([]order.MinMaxLevel, error) {
+	if a != asset.Spot {
+		return nil, fmt.Errorf("%s %w", a, asset.ErrNotSupported)
+	}
+	info, err := l.GetPairInfo()
+	if err != nil {
+		return nil, err
+	}
+	limits := make([]order.MinMaxLevel, 0, len(info))
+	for x := range info {
+		var cp currency.Pair
+		cp, err = currency.NewPairFromString(info[x].Symbol)
+		if err != nil {
+			return nil, err
+		}
+		var priceDecimals, amountDecimals int64
+		priceDecimals, err = strconv.ParseInt(info[x].PriceAccuracy, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		amountDecimals, err = strconv.ParseInt(info[x].QuantityAccuracy, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		var minAmount float64
+		minAmount, err = strconv.ParseFloat(info[x].MinimumQuantity, 64)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, order.MinMaxLevel{
+			Pair:       cp,
+			Asset:      a,
+			StepPrice:  exchange.StepFromDecimalPlaces(priceDecimals),
+			MinAmount:  minAmount,
+			StepAmount: exchange.StepFromDecimalPlaces(amountDecimals),
+		})
+	}
+	return limits, nil
+}
+
 // OrderTransactionDetails gets info about transactions
 func (l *Lbank) OrderTransactionDetails(symbol, orderID string) (TransactionHistoryResp, error) {
 	var resp TransactionHistoryResp
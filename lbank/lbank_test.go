@@ -199,6 +199,14 @@ func TestGetPairInfo(t *testing.T) {
 	}
 }
 
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	err := l.UpdateOrderExecutionLimits(asset.Spot)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
 func TestOrderTransactionDetails(t *testing.T) {
 	t.Parallel()
 	if !areTestAPIKeysSet() {
@@ -154,6 +154,20 @@ func (l *Lbank) Run() {
 		l.PrintEnabledPairs()
 	}
 
+	a := l.GetAssetTypes()
+	for x := range a {
+		if err := l.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
+			err = l.UpdateOrderExecutionLimits(a[x])
+			l.SetOrderExecutionLimitsLoadError(a[x], err)
+			if err != nil {
+				log.Errorf(log.ExchangeSys,
+					"Could not set %s exchange exchange limits: %v",
+					l.Name,
+					err)
+			}
+		}
+	}
+
 	if !l.GetEnabledFeatures().AutoPairUpdates {
 		return
 	}
@@ -426,6 +440,9 @@ func (l *Lbank) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 	if err := s.Validate(); err != nil {
 		return resp, err
 	}
+	if err := l.ConformOrderToLimits(s); err != nil {
+		return resp, err
+	}
 
 	if s.Side != order.Buy && s.Side != order.Sell {
 		return resp,
@@ -860,6 +877,15 @@ func (l *Lbank) getAllOpenOrderID() (map[string][]string, error) {
 	return resp, nil
 }
 
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (l *Lbank) UpdateOrderExecutionLimits(a asset.Item) error {
+	limits, err := l.FetchExchangeLimits(a)
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return l.LoadLimits(limits)
+}
+
 // ValidateCredentials validates current credentials used for wrapper
 // functionality
 func (l *Lbank) ValidateCredentials(assetType asset.Item) error {
@@ -751,7 +751,12 @@ func (l *LocalBitcoins) SendAuthenticatedHTTPRequest(ep exchange.URL, method, pa
 	if err != nil {
 		return err
 	}
-	n := l.Requester.GetNonce(true).String()
+	nonce, release, err := l.NextNonce()
+	if err != nil {
+		return err
+	}
+	defer release()
+	n := nonce.String()
 
 	path = "/api/" + path
 	encoded := params.Encode()
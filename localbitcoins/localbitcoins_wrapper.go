@@ -343,6 +343,9 @@ func (l *LocalBitcoins) SubmitOrder(s *order.Submit) (order.SubmitResponse, erro
 	if err := s.Validate(); err != nil {
 		return submitOrderResponse, err
 	}
+	if err := l.ConformOrderToLimits(s); err != nil {
+		return submitOrderResponse, err
+	}
 
 	fPair, err := l.FormatExchangeCurrency(s.Pair, s.AssetType)
 	if err != nil {
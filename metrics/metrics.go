@@ -0,0 +1,166 @@
+// Package metrics defines the instrumentation hooks exchanges and websocket
+// connections report through and a Registry which exports them in the
+// Prometheus text format. Nothing is recorded until a Recorder is set, either
+// per exchange or process wide through SetDefault.
+package metrics
+
+import (
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+// Metric names
+const (
+	// RESTRequests counts REST requests sent
+	RESTRequests = "irix_rest_requests_total"
+	// RESTErrors counts REST requests which failed
+	RESTErrors = "irix_rest_request_errors_total"
+	// RESTDuration is the time taken by REST requests in seconds
+	RESTDuration = "irix_rest_request_duration_seconds"
+	// RateLimitWait is the time REST requests waited on the rate limiter in
+	// seconds
+	RateLimitWait = "irix_rate_limit_wait_seconds"
+	// WebsocketReconnects counts reconnection attempts after a disconnect
+	WebsocketReconnects = "irix_websocket_reconnects_total"
+	// WebsocketDisconnects counts websocket disconnections
+	WebsocketDisconnects = "irix_websocket_disconnects_total"
+	// WebsocketMessagesReceived counts messages read from a connection
+	WebsocketMessagesReceived = "irix_websocket_messages_received_total"
+	// WebsocketMessagesSent counts messages written to a connection
+	WebsocketMessagesSent = "irix_websocket_messages_sent_total"
+	// WebsocketPingRTT is the round trip of websocket pings in seconds
+	WebsocketPingRTT = "irix_websocket_ping_rtt_seconds"
+	// WebsocketData counts processed websocket data passed to the data
+	// handler
+	WebsocketData = "irix_websocket_data_total"
+	// DataHandlerBacklog is the number of items waiting in the data handler
+	DataHandlerBacklog = "irix_websocket_data_handler_backlog"
+	// DataHandlerBacklogEvents counts times the data handler consumer fell
+	// behind
+	DataHandlerBacklogEvents = "irix_websocket_data_handler_backlog_events_total"
+	// WebsocketDataDropped counts websocket data discarded because its queue
+	// was full
+	WebsocketDataDropped = "irix_websocket_data_dropped_total"
+	// WebsocketDataConflated counts websocket data replaced by a newer value
+	// for the same pair before it was consumed
+	WebsocketDataConflated = "irix_websocket_data_conflated_total"
+	// OrderbookChecksumFailures counts local orderbooks dropped for failing
+	// the exchange's checksum
+	OrderbookChecksumFailures = "irix_orderbook_checksum_failures_total"
+)
+
+// help describes each metric for the exporter
+var help = map[string]string{
+	RESTRequests:              "REST requests sent.",
+	RESTErrors:                "REST requests which returned an error.",
+	RESTDuration:              "REST request duration in seconds.",
+	RateLimitWait:             "Time REST requests waited on the rate limiter in seconds.",
+	WebsocketReconnects:       "Websocket reconnection attempts.",
+	WebsocketDisconnects:      "Websocket disconnections.",
+	WebsocketMessagesReceived: "Websocket messages received.",
+	WebsocketMessagesSent:     "Websocket messages sent.",
+	WebsocketPingRTT:          "Websocket ping round trip in seconds.",
+	WebsocketData:             "Processed websocket data passed to the data handler.",
+	DataHandlerBacklog:        "Items waiting in the websocket data handler.",
+	DataHandlerBacklogEvents:  "Times the websocket data handler consumer fell behind.",
+	WebsocketDataDropped:      "Websocket data discarded because its queue was full.",
+	WebsocketDataConflated:    "Websocket data replaced by a newer value before it was consumed.",
+	OrderbookChecksumFailures: "Local orderbooks dropped for failing the exchange checksum.",
+}
+
+// Labels identify the source of a measurement, unset labels are exported
+// empty
+type Labels struct {
+	Exchange string
+	Endpoint string
+	Asset    string
+}
+
+// Recorder receives measurements. Implementations must be safe for
+// concurrent use.
+type Recorder interface {
+	// AddCounter increases a counter by delta
+	AddCounter(name string, l Labels, delta float64)
+	// ObserveHistogram records a value in a histogram
+	ObserveHistogram(name string, l Labels, value float64)
+	// SetGauge sets a gauge to value
+	SetGauge(name string, l Labels, value float64)
+}
+
+// Nop discards every measurement
+type Nop struct{}
+
+// AddCounter does nothing
+func (Nop) AddCounter(string, Labels, float64) {}
+
+// ObserveHistogram does nothing
+func (Nop) ObserveHistogram(string, Labels, float64) {}
+
+// SetGauge does nothing
+func (Nop) SetGauge(string, Labels, float64) {}
+
+// recorderBox lets atomic.Value hold differing Recorder implementations
+type recorderBox struct {
+	Recorder
+}
+
+var defaultRecorder atomic.Value
+
+// SetDefault sets the recorder used by exchanges and websockets without
+// their own, nil restores Nop
+func SetDefault(r Recorder) {
+	if r == nil {
+		r = Nop{}
+	}
+	defaultRecorder.Store(recorderBox{r})
+}
+
+// Default returns the process wide recorder
+func Default() Recorder {
+	if r, ok := defaultRecorder.Load().(recorderBox); ok {
+		return r.Recorder
+	}
+	return Nop{}
+}
+
+// idSegment replaces path segments which identify a single resource so each
+// order, account or withdrawal does not create its own series
+const idSegment = "{id}"
+
+// minIDLength is the length from which a segment containing digits is taken
+// to be an identifier rather than a route such as v3 or 24hr
+const minIDLength = 12
+
+// Endpoint returns a low cardinality endpoint label for a request URL, the
+// host and path without query or credentials. Path segments which look like
+// identifiers are replaced with {id}.
+func Endpoint(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	segments := strings.Split(u.Path, "/")
+	for i := range segments {
+		if isIDSegment(segments[i]) {
+			segments[i] = idSegment
+		}
+	}
+	return u.Host + strings.Join(segments, "/")
+}
+
+// isIDSegment reports whether a path segment is a number, or is long and
+// contains a digit as UUIDs, hashes and exchange order IDs do
+func isIDSegment(s string) bool {
+	if s == "" {
+		return false
+	}
+	var digits int
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			digits++
+		}
+	}
+	return digits == len(s) || (digits > 0 && len(s) >= minIDLength)
+}
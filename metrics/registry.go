@@ -0,0 +1,284 @@
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentType is the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets suit request latencies and rate limiter waits in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var errInvalidBuckets = errors.New("histogram buckets must be increasing")
+
+// metric kinds
+const (
+	counterKind   = "counter"
+	gaugeKind     = "gauge"
+	histogramKind = "histogram"
+)
+
+// histogram holds cumulative bucket counts
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// family is every series of one metric
+type family struct {
+	kind       string
+	values     map[Labels]float64
+	histograms map[Labels]*histogram
+}
+
+// Registry keeps measurements in memory and exports them in the Prometheus
+// text format. It implements Recorder and http.Handler.
+type Registry struct {
+	buckets  []float64
+	families map[string]*family
+	mtx      sync.Mutex
+}
+
+// NewRegistry returns an empty registry using buckets for histograms,
+// DefaultBuckets when none are supplied
+func NewRegistry(buckets ...float64) (*Registry, error) {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			return nil, fmt.Errorf("%w: %v", errInvalidBuckets, buckets)
+		}
+	}
+	b := make([]float64, len(buckets))
+	copy(b, buckets)
+	return &Registry{buckets: b, families: make(map[string]*family)}, nil
+}
+
+// family returns the family for name, nil when it was registered as another
+// kind in which case the measurement is dropped. The caller must hold the
+// lock.
+func (r *Registry) family(name, kind string) *family {
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{kind: kind}
+		if kind == histogramKind {
+			f.histograms = make(map[Labels]*histogram)
+		} else {
+			f.values = make(map[Labels]float64)
+		}
+		r.families[name] = f
+	}
+	if f.kind != kind {
+		return nil
+	}
+	return f
+}
+
+// AddCounter increases a counter by delta, negative deltas are ignored
+func (r *Registry) AddCounter(name string, l Labels, delta float64) {
+	if delta < 0 {
+		return
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if f := r.family(name, counterKind); f != nil {
+		f.values[l] += delta
+	}
+}
+
+// SetGauge sets a gauge to value
+func (r *Registry) SetGauge(name string, l Labels, value float64) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if f := r.family(name, gaugeKind); f != nil {
+		f.values[l] = value
+	}
+}
+
+// ObserveHistogram records value in a histogram
+func (r *Registry) ObserveHistogram(name string, l Labels, value float64) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	f := r.family(name, histogramKind)
+	if f == nil {
+		return
+	}
+	h, ok := f.histograms[l]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(r.buckets))}
+		f.histograms[l] = h
+	}
+	for i := range r.buckets {
+		if value <= r.buckets[i] {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+// Value returns a counter or gauge value, used by tests and health checks
+func (r *Registry) Value(name string, l Labels) (float64, bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	f, ok := r.families[name]
+	if !ok || f.values == nil {
+		return 0, false
+	}
+	v, ok := f.values[l]
+	return v, ok
+}
+
+// Count returns the number of observations in a histogram
+func (r *Registry) Count(name string, l Labels) uint64 {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	f, ok := r.families[name]
+	if !ok || f.histograms == nil {
+		return 0
+	}
+	if h, ok := f.histograms[l]; ok {
+		return h.count
+	}
+	return 0
+}
+
+// WriteTo writes every metric in the Prometheus text format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f := r.families[name]
+		if h, ok := help[name]; ok {
+			cw.printf("# HELP %s %s\n", name, h)
+		}
+		cw.printf("# TYPE %s %s\n", name, f.kind)
+		if f.kind != histogramKind {
+			for _, l := range sortedLabels(f.values, nil) {
+				cw.printf("%s{%s} %s\n", name, formatLabels(l, ""), formatFloat(f.values[l]))
+			}
+			continue
+		}
+		for _, l := range sortedLabels(nil, f.histograms) {
+			h := f.histograms[l]
+			for i := range r.buckets {
+				cw.printf("%s_bucket{%s} %d\n", name, formatLabels(l, formatFloat(r.buckets[i])), h.counts[i])
+			}
+			cw.printf("%s_bucket{%s} %d\n", name, formatLabels(l, "+Inf"), h.count)
+			cw.printf("%s_sum{%s} %s\n", name, formatLabels(l, ""), formatFloat(h.sum))
+			cw.printf("%s_count{%s} %d\n", name, formatLabels(l, ""), h.count)
+		}
+	}
+	if cw.err == nil {
+		cw.err = cw.w.(*bufio.Writer).Flush()
+	}
+	return cw.n, cw.err
+}
+
+// ServeHTTP exports the registry to a Prometheus scrape
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	_, _ = r.WriteTo(w)
+}
+
+// Serve exports the registry at /metrics on addr until the returned server
+// is shut down
+func Serve(addr string, r *Registry) (*http.Server, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", r)
+	srv := &http.Server{
+		Addr:              l.Addr().String(),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go srv.Serve(l) // nolint:errcheck // returns http.ErrServerClosed on shutdown
+	return srv, nil
+}
+
+// sortedLabels returns the label sets of a family in a stable order
+func sortedLabels(values map[Labels]float64, histograms map[Labels]*histogram) []Labels {
+	labels := make([]Labels, 0, len(values)+len(histograms))
+	for l := range values {
+		labels = append(labels, l)
+	}
+	for l := range histograms {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].Exchange != labels[j].Exchange {
+			return labels[i].Exchange < labels[j].Exchange
+		}
+		if labels[i].Endpoint != labels[j].Endpoint {
+			return labels[i].Endpoint < labels[j].Endpoint
+		}
+		return labels[i].Asset < labels[j].Asset
+	})
+	return labels
+}
+
+// formatLabels renders a label set, le is added for histogram buckets
+func formatLabels(l Labels, le string) string {
+	s := `exchange="` + escape(l.Exchange) + `",endpoint="` + escape(l.Endpoint) + `",asset="` + escape(l.Asset) + `"`
+	if le != "" {
+		s += `,le="` + le + `"`
+	}
+	return s
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// escape escapes a label value
+func escape(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+// formatFloat renders a sample value
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// countingWriter keeps the first write error and the bytes written
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) printf(format string, args ...interface{}) {
+	if c.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(c.w, format, args...)
+	c.n += int64(n)
+	c.err = err
+}
@@ -0,0 +1,113 @@
+//go:build !windows
+// +build !windows
+
+package nonce
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+var errInvalidKey = errors.New("nonce key must be a valid file name")
+
+// FileStore persists the last nonce of each key in a directory. Every Next
+// holds an exclusive lock on the key's lock file so processes sharing the
+// directory never issue the same nonce, and the value is replaced atomically
+// so a crash never leaves it partly written.
+type FileStore struct {
+	dir string
+	// mtx avoids lock file contention between goroutines of this process
+	mtx sync.Mutex
+}
+
+// NewFileStore returns a store backed by dir, creating it if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		return nil, errStorePathEmpty
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Next reserves the next nonce for key
+func (f *FileStore) Next(key string, floor int64) (int64, error) {
+	if key == "" {
+		return 0, errKeyEmpty
+	}
+	if strings.ContainsAny(key, `/\`) || key == "." || key == ".." {
+		return 0, errInvalidKey
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	lock, err := os.OpenFile(filepath.Join(f.dir, key+".lock"), os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	defer lock.Close()
+	if err = syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil {
+		return 0, err
+	}
+	defer syscall.Flock(int(lock.Fd()), syscall.LOCK_UN) // nolint:errcheck // closing the file releases the lock regardless
+
+	path := filepath.Join(f.dir, key+".nonce")
+	var last int64
+	contents, err := ioutil.ReadFile(path)
+	switch {
+	case err == nil:
+		last, err = strconv.ParseInt(strings.TrimSpace(string(contents)), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+	case !os.IsNotExist(err):
+		return 0, err
+	}
+
+	n := next(last, floor)
+	if err = f.write(path, n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// write replaces the nonce file through a synced temporary file
+func (f *FileStore) write(path string, n int64) error {
+	tmp, err := ioutil.TempFile(f.dir, filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.WriteString(strconv.FormatInt(n, 10)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	dir, err := os.Open(f.dir)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// Close is a no-op as no files are held open between calls
+func (f *FileStore) Close() error {
+	return nil
+}
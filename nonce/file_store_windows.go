@@ -0,0 +1,26 @@
+//go:build windows
+// +build windows
+
+package nonce
+
+import "errors"
+
+var errFileStoreUnsupported = errors.New("file nonce store is not supported on windows")
+
+// FileStore is unavailable on windows as it relies on flock
+type FileStore struct{}
+
+// NewFileStore returns an error on windows
+func NewFileStore(dir string) (*FileStore, error) {
+	return nil, errFileStoreUnsupported
+}
+
+// Next returns an error on windows
+func (f *FileStore) Next(key string, floor int64) (int64, error) {
+	return 0, errFileStoreUnsupported
+}
+
+// Close is a no-op on windows
+func (f *FileStore) Close() error {
+	return nil
+}
@@ -0,0 +1,179 @@
+// Package nonce issues strictly increasing nonces for authenticated exchange
+// requests. The last issued nonce of each key is held in a Store so nonces
+// keep increasing across restarts, a backwards moving clock and every process
+// on the host which shares the store.
+package nonce
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Format is the unit a nonce is issued in
+type Format string
+
+// Nonce formats
+const (
+	Seconds      Format = "s"
+	Milliseconds Format = "ms"
+	Microseconds Format = "us"
+	Nanoseconds  Format = "ns"
+	// Counter issues 1, 2, 3... without reference to the clock
+	Counter Format = "counter"
+)
+
+// Store kinds which can be built by NewStore
+const (
+	MemoryStoreKind = "memory"
+	FileStoreKind   = "file"
+	SocketStoreKind = "socket"
+)
+
+var (
+	errInvalidFormat    = errors.New("invalid nonce format")
+	errInvalidStoreKind = errors.New("invalid nonce store")
+	errStorePathEmpty   = errors.New("nonce store path cannot be empty")
+	errKeyEmpty         = errors.New("nonce key cannot be empty")
+	errStoreNil         = errors.New("nonce store cannot be nil")
+)
+
+// Value is an issued nonce
+type Value int64
+
+// String returns the nonce in base 10
+func (v Value) String() string {
+	return strconv.FormatInt(int64(v), 10)
+}
+
+// ParseFormat validates a configured format, an empty format is returned as
+// the fallback
+func ParseFormat(s string, fallback Format) (Format, error) {
+	if s == "" {
+		return fallback, nil
+	}
+	switch f := Format(s); f {
+	case Seconds, Milliseconds, Microseconds, Nanoseconds, Counter:
+		return f, nil
+	}
+	return "", fmt.Errorf("%w: %s", errInvalidFormat, s)
+}
+
+// floor returns the lowest nonce the format allows at t
+func (f Format) floor(t time.Time) int64 {
+	switch f {
+	case Seconds:
+		return t.Unix()
+	case Milliseconds:
+		return t.UnixNano() / int64(time.Millisecond)
+	case Microseconds:
+		return t.UnixNano() / int64(time.Microsecond)
+	case Nanoseconds:
+		return t.UnixNano()
+	}
+	return 0
+}
+
+// Store reserves nonces for keys. Next must be atomic for every user of the
+// store and return a value greater than the last value issued for the key and
+// no less than floor.
+type Store interface {
+	Next(key string, floor int64) (int64, error)
+	Close() error
+}
+
+// NewStore builds a store by kind. Path is the directory for a file store and
+// the socket for a socket store.
+func NewStore(kind, path string) (Store, error) {
+	switch kind {
+	case "", MemoryStoreKind:
+		return NewMemoryStore(), nil
+	case FileStoreKind:
+		return NewFileStore(path)
+	case SocketStoreKind:
+		return DialSocketStore(path)
+	}
+	return nil, fmt.Errorf("%w: %s", errInvalidStoreKind, kind)
+}
+
+// next returns the value a store issues after last
+func next(last, floor int64) int64 {
+	if floor > last {
+		return floor
+	}
+	return last + 1
+}
+
+// MemoryStore keeps nonces for the life of the process only
+type MemoryStore struct {
+	last map[string]int64
+	mtx  sync.Mutex
+}
+
+// NewMemoryStore returns an empty memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{last: make(map[string]int64)}
+}
+
+// Next reserves the next nonce for key
+func (m *MemoryStore) Next(key string, floor int64) (int64, error) {
+	if key == "" {
+		return 0, errKeyEmpty
+	}
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	n := next(m.last[key], floor)
+	m.last[key] = n
+	return n, nil
+}
+
+// Close is a no-op for a memory store
+func (m *MemoryStore) Close() error {
+	return nil
+}
+
+// Manager issues nonces in one format for one key, usually an exchange and
+// API key pair
+type Manager struct {
+	key    string
+	format Format
+	store  Store
+	now    func() time.Time
+}
+
+// NewManager returns a manager which issues nonces for key from store
+func NewManager(key string, format Format, store Store) (*Manager, error) {
+	if key == "" {
+		return nil, errKeyEmpty
+	}
+	if store == nil {
+		return nil, errStoreNil
+	}
+	if _, err := ParseFormat(string(format), ""); err != nil || format == "" {
+		return nil, fmt.Errorf("%w: %q", errInvalidFormat, format)
+	}
+	return &Manager{key: key, format: format, store: store, now: time.Now}, nil
+}
+
+// SetClock sets the clock time based formats are taken from, used to follow
+// the exchange's server time
+func (m *Manager) SetClock(now func() time.Time) {
+	if now != nil {
+		m.now = now
+	}
+}
+
+// Format returns the format nonces are issued in
+func (m *Manager) Format() Format {
+	return m.format
+}
+
+// Next returns the next nonce. A time based nonce is the current time in the
+// format unless the last issued nonce is already at or beyond it, in which
+// case it is one more than the last.
+func (m *Manager) Next() (Value, error) {
+	n, err := m.store.Next(m.key, m.format.floor(m.now()))
+	return Value(n), err
+}
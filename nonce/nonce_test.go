@@ -0,0 +1,329 @@
+package nonce
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	helperEnv   = "NONCE_TEST_HELPER_DIR"
+	helperCount = 200
+)
+
+// TestMain lets the test binary act as a second process sharing a file store
+func TestMain(m *testing.M) {
+	if dir := os.Getenv(helperEnv); dir != "" {
+		store, err := NewFileStore(dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		for i := 0; i < helperCount; i++ {
+			n, err := store.Next("shared", 0)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			fmt.Println(n)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func fixedClock(t time.Time) func() time.Time {
+	return func() time.Time { return t }
+}
+
+func TestParseFormat(t *testing.T) {
+	t.Parallel()
+	f, err := ParseFormat("", Milliseconds)
+	if err != nil || f != Milliseconds {
+		t.Errorf("expected fallback, received %v %v", f, err)
+	}
+	if f, err = ParseFormat("us", Milliseconds); err != nil || f != Microseconds {
+		t.Errorf("expected microseconds, received %v %v", f, err)
+	}
+	if _, err = ParseFormat("hours", Milliseconds); !errors.Is(err, errInvalidFormat) {
+		t.Errorf("expected %v, received %v", errInvalidFormat, err)
+	}
+}
+
+func TestManager(t *testing.T) {
+	t.Parallel()
+	if _, err := NewManager("", Nanoseconds, NewMemoryStore()); !errors.Is(err, errKeyEmpty) {
+		t.Errorf("expected %v, received %v", errKeyEmpty, err)
+	}
+	if _, err := NewManager("key", Nanoseconds, nil); !errors.Is(err, errStoreNil) {
+		t.Errorf("expected %v, received %v", errStoreNil, err)
+	}
+	if _, err := NewManager("key", "", NewMemoryStore()); !errors.Is(err, errInvalidFormat) {
+		t.Errorf("expected %v, received %v", errInvalidFormat, err)
+	}
+
+	now := time.Unix(1600000000, 123456789)
+	for _, tc := range []struct {
+		format Format
+		first  Value
+	}{
+		{Seconds, 1600000000},
+		{Milliseconds, 1600000000123},
+		{Microseconds, 1600000000123456},
+		{Nanoseconds, 1600000000123456789},
+		{Counter, 1},
+	} {
+		m, err := NewManager("key", tc.format, NewMemoryStore())
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.SetClock(fixedClock(now))
+		n, err := m.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != tc.first {
+			t.Errorf("%s: expected %d, received %d", tc.format, tc.first, n)
+		}
+		// The clock has not moved so the next nonce must still increase
+		if n, _ = m.Next(); n != tc.first+1 {
+			t.Errorf("%s: expected %d, received %d", tc.format, tc.first+1, n)
+		}
+	}
+}
+
+func TestBackwardsClock(t *testing.T) {
+	t.Parallel()
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewManager("key", Milliseconds, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetClock(fixedClock(time.Unix(1600000000, 0)))
+	first, err := m.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A restarted process whose clock moved back an hour continues from the
+	// persisted nonce
+	restarted, err := NewManager("key", Milliseconds, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restarted.SetClock(fixedClock(time.Unix(1600000000-3600, 0)))
+	n, err := restarted.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != first+1 {
+		t.Errorf("expected %d, received %d", first+1, n)
+	}
+	if n.String() != strconv.FormatInt(int64(first)+1, 10) {
+		t.Errorf("unexpected string %s", n)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	t.Parallel()
+	if _, err := NewFileStore(""); !errors.Is(err, errStorePathEmpty) {
+		t.Errorf("expected %v, received %v", errStorePathEmpty, err)
+	}
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.Next("../escape", 0); !errors.Is(err, errInvalidKey) {
+		t.Errorf("expected %v, received %v", errInvalidKey, err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "corrupt.nonce"), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.Next("corrupt", 0); err == nil {
+		t.Error("expected error reading corrupt nonce file")
+	}
+
+	// Two stores on the same directory stand in for two processes
+	other, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertUnique(t, []Store{store, other}, 50)
+}
+
+func TestFileStoreAcrossProcesses(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file store is not supported on windows")
+	}
+	dir := t.TempDir()
+	outputs := make([][]byte, 2)
+	var wg sync.WaitGroup
+	errs := make(chan error, len(outputs))
+	for i := range outputs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cmd := exec.Command(os.Args[0], "-test.run=^$")
+			cmd.Env = append(os.Environ(), helperEnv+"="+dir)
+			out, err := cmd.Output()
+			if err != nil {
+				errs <- err
+				return
+			}
+			outputs[i] = out
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]bool)
+	for i := range outputs {
+		lines := strings.Fields(string(outputs[i]))
+		if len(lines) != helperCount {
+			t.Fatalf("expected %d nonces, received %d", helperCount, len(lines))
+		}
+		var last int64
+		for _, l := range lines {
+			if seen[l] {
+				t.Fatalf("nonce %s issued twice", l)
+			}
+			seen[l] = true
+			n, err := strconv.ParseInt(l, 10, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n <= last {
+				t.Fatalf("nonce %d not greater than %d", n, last)
+			}
+			last = n
+		}
+	}
+}
+
+func TestSocketStore(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "nonce.sock")
+	if _, err := DialSocketStore(path); err == nil {
+		t.Error("expected error dialling without a server")
+	}
+	srv, err := Serve(path, NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Serve(path, NewMemoryStore()); !errors.Is(err, errSocketInUse) {
+		t.Errorf("expected %v, received %v", errSocketInUse, err)
+	}
+
+	a, err := NewStore(SocketStoreKind, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := DialSocketStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertUnique(t, []Store{a, b}, 50)
+	if _, err = a.Next("", 0); !errors.Is(err, errKeyEmpty) {
+		t.Errorf("expected %v, received %v", errKeyEmpty, err)
+	}
+
+	// Clients redial a restarted server
+	last, err := a.Next("shared", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = srv.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = srv.Close(); !errors.Is(err, errServerClosed) {
+		t.Errorf("expected %v, received %v", errServerClosed, err)
+	}
+	srv, err = Serve(path, NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	n, err := a.Next("shared", last+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != last+1 {
+		t.Errorf("expected %d, received %d", last+1, n)
+	}
+	if err = a.Close(); err != nil {
+		t.Error(err)
+	}
+	if err = b.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNewStore(t *testing.T) {
+	t.Parallel()
+	if s, err := NewStore("", ""); err != nil || s == nil {
+		t.Errorf("expected memory store, received %v", err)
+	}
+	if _, err := NewStore("redis", ""); !errors.Is(err, errInvalidStoreKind) {
+		t.Errorf("expected %v, received %v", errInvalidStoreKind, err)
+	}
+	if _, err := NewStore(FileStoreKind, ""); err == nil {
+		t.Error("expected error for empty file store path")
+	}
+}
+
+// assertUnique issues nonces concurrently from every store and checks none is
+// repeated
+func assertUnique(t *testing.T, stores []Store, perGoroutine int) {
+	t.Helper()
+	var mtx sync.Mutex
+	seen := make(map[int64]bool)
+	var wg sync.WaitGroup
+	errs := make(chan error, len(stores)*2)
+	for _, s := range stores {
+		for g := 0; g < 2; g++ {
+			wg.Add(1)
+			go func(s Store) {
+				defer wg.Done()
+				for i := 0; i < perGoroutine; i++ {
+					n, err := s.Next("shared", 0)
+					if err != nil {
+						errs <- err
+						return
+					}
+					mtx.Lock()
+					if seen[n] {
+						mtx.Unlock()
+						errs <- fmt.Errorf("nonce %d issued twice", n)
+						return
+					}
+					seen[n] = true
+					mtx.Unlock()
+				}
+			}(s)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if len(seen) != len(stores)*2*perGoroutine {
+		t.Errorf("expected %d nonces, received %d", len(stores)*2*perGoroutine, len(seen))
+	}
+}
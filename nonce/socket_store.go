@@ -0,0 +1,211 @@
+package nonce
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"sync"
+)
+
+var (
+	errServerClosed = errors.New("nonce server closed")
+	errSocketInUse  = errors.New("nonce socket already has a server listening")
+)
+
+// socketRequest reserves a nonce from a Server
+type socketRequest struct {
+	Key   string `json:"key"`
+	Floor int64  `json:"floor"`
+}
+
+// socketResponse carries a reserved nonce or the reason it was refused
+type socketResponse struct {
+	Nonce int64  `json:"nonce"`
+	Error string `json:"error,omitempty"`
+}
+
+// Server shares a store with other processes over a Unix socket. Requests
+// are newline delimited JSON.
+type Server struct {
+	store    Store
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+	mtx      sync.Mutex
+}
+
+// Serve listens on the Unix socket at path and serves nonces from store until
+// closed. A stale socket file left by a previous server is removed.
+func Serve(path string, store Store) (*Server, error) {
+	if path == "" {
+		return nil, errStorePathEmpty
+	}
+	if store == nil {
+		return nil, errStoreNil
+	}
+	if _, err := os.Stat(path); err == nil {
+		if c, dialErr := net.Dial("unix", path); dialErr == nil {
+			c.Close()
+			return nil, errSocketInUse
+		}
+		if err = os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{store: store, listener: l, conns: make(map[net.Conn]struct{})}
+	s.wg.Add(1)
+	go s.accept()
+	return s, nil
+}
+
+// accept hands each connection to its own handler
+func (s *Server) accept() {
+	defer s.wg.Done()
+	for {
+		c, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mtx.Lock()
+		if s.closed {
+			s.mtx.Unlock()
+			c.Close()
+			return
+		}
+		s.conns[c] = struct{}{}
+		s.wg.Add(1)
+		s.mtx.Unlock()
+		go s.handle(c)
+	}
+}
+
+// handle serves requests on one connection until it is closed
+func (s *Server) handle(c net.Conn) {
+	defer func() {
+		s.mtx.Lock()
+		delete(s.conns, c)
+		s.mtx.Unlock()
+		c.Close()
+		s.wg.Done()
+	}()
+	dec := json.NewDecoder(c)
+	enc := json.NewEncoder(c)
+	for {
+		var req socketRequest
+		if err := dec.Decode(&req); err != nil {
+			return
+		}
+		var resp socketResponse
+		n, err := s.store.Next(req.Key, req.Floor)
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		resp.Nonce = n
+		if err = enc.Encode(&resp); err != nil {
+			return
+		}
+	}
+}
+
+// Close stops the server and drops every client, the backing store is left
+// open
+func (s *Server) Close() error {
+	s.mtx.Lock()
+	if s.closed {
+		s.mtx.Unlock()
+		return errServerClosed
+	}
+	s.closed = true
+	err := s.listener.Close()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mtx.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+// SocketStore reserves nonces from a Server over a Unix socket so every
+// process on the host shares one sequence per key
+type SocketStore struct {
+	path string
+	conn net.Conn
+	enc  *json.Encoder
+	dec  *json.Decoder
+	mtx  sync.Mutex
+}
+
+// DialSocketStore connects to the server listening at path
+func DialSocketStore(path string) (*SocketStore, error) {
+	if path == "" {
+		return nil, errStorePathEmpty
+	}
+	s := &SocketStore{path: path}
+	if err := s.dial(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// dial connects to the server, the caller must hold the lock once the store
+// is in use
+func (s *SocketStore) dial() error {
+	c, err := net.Dial("unix", s.path)
+	if err != nil {
+		return err
+	}
+	s.conn = c
+	s.enc = json.NewEncoder(c)
+	s.dec = json.NewDecoder(c)
+	return nil
+}
+
+// Next reserves the next nonce for key. A dropped connection is redialled
+// once, which is safe as the server never issues a nonce twice.
+func (s *SocketStore) Next(key string, floor int64) (int64, error) {
+	if key == "" {
+		return 0, errKeyEmpty
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			if err = s.dial(); err != nil {
+				return 0, err
+			}
+		}
+		var resp socketResponse
+		if err = s.enc.Encode(socketRequest{Key: key, Floor: floor}); err == nil {
+			err = s.dec.Decode(&resp)
+		}
+		if err != nil {
+			s.conn.Close()
+			s.conn = nil
+			continue
+		}
+		if resp.Error != "" {
+			return 0, errors.New(resp.Error)
+		}
+		return resp.Nonce, nil
+	}
+	return 0, err
+}
+
+// Close closes the connection to the server
+func (s *SocketStore) Close() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
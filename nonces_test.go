@@ -0,0 +1,94 @@
+package irix
+
+import (
+	"testing"
+
+	"github.com/openware/irix/config"
+	"github.com/openware/irix/nonce"
+)
+
+func TestNextNonce(t *testing.T) {
+	t.Parallel()
+	b := Base{Name: "nonces"}
+	first, release, err := b.NextNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if b.NonceFormat != nonce.Nanoseconds {
+		t.Errorf("expected nanosecond default, received %s", b.NonceFormat)
+	}
+	second, release, err := b.NextNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if second <= first {
+		t.Errorf("expected %d to be greater than %d", second, first)
+	}
+
+	// A new API key starts its own sequence
+	b.NonceFormat = nonce.Counter
+	if err = b.SetNonceStore(nil); err != nil {
+		t.Fatal(err)
+	}
+	b.API.Credentials.Key = "key"
+	n, release, _ := b.NextNonce()
+	release()
+	if n != 1 {
+		t.Errorf("expected counter to start at 1, received %d", n)
+	}
+	b.API.Credentials.Key = "other"
+	n, release, _ = b.NextNonce()
+	release()
+	if n != 1 {
+		t.Errorf("expected new key to start at 1, received %d", n)
+	}
+}
+
+func TestSetNonceStore(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	newBase := func() *Base {
+		b := &Base{Name: "nonces", NonceFormat: nonce.Milliseconds}
+		if err := b.SetNonceStore(&config.NonceConfig{Store: nonce.FileStoreKind, Path: dir, Format: "counter"}); err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	if n, _, _ := newBase().NextNonce(); n != 1 {
+		t.Errorf("expected 1, received %d", n)
+	}
+	// A restarted exchange continues from the persisted nonce
+	if n, _, _ := newBase().NextNonce(); n != 2 {
+		t.Errorf("expected 2, received %d", n)
+	}
+
+	b := &Base{Name: "nonces"}
+	if err := b.SetNonceStore(&config.NonceConfig{Format: "minutes"}); err == nil {
+		t.Error("expected invalid format error")
+	}
+	if err := b.SetNonceStore(&config.NonceConfig{Store: "redis"}); err == nil {
+		t.Error("expected invalid store error")
+	}
+}
+
+func TestNextNonceRelease(t *testing.T) {
+	t.Parallel()
+	b := Base{Name: "nonces"}
+	_, release, err := b.NextNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	_, next, err := b.NextNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A second release must not unlock the nonce issued after it
+	release()
+	if !b.nonceLock.UnlockIfLocked() {
+		t.Error("expected nonce lock to be held by the later caller")
+	}
+	next()
+}
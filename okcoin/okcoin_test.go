@@ -23,6 +23,7 @@ import (
 	"github.com/openware/pkg/currency"
 	"github.com/openware/pkg/kline"
 	"github.com/openware/pkg/order"
+	"github.com/openware/pkg/orderbook"
 )
 
 // Please supply you own test keys here for due diligence testing.
@@ -854,7 +855,15 @@ func TestOrderBookPartialChecksumCalculator(t *testing.T) {
 		t.Error(err)
 	}
 
-	calculatedChecksum := o.CalculatePartialOrderbookChecksum(&dataResponse)
+	bids, err := o.AppendWsOrderbookItems(dataResponse.Bids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	asks, err := o.AppendWsOrderbookItems(dataResponse.Asks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calculatedChecksum := o.CalculateUpdateOrderbookChecksum(&orderbook.Base{Bids: bids, Asks: asks})
 	if calculatedChecksum != dataResponse.Checksum {
 		t.Errorf("Expected %v, received %v", dataResponse.Checksum, calculatedChecksum)
 	}
@@ -25,6 +25,7 @@ import (
 	"github.com/openware/pkg/currency"
 	"github.com/openware/pkg/kline"
 	"github.com/openware/pkg/order"
+	"github.com/openware/pkg/orderbook"
 )
 
 // Please supply you own test keys here for due diligence testing.
@@ -182,6 +183,15 @@ func testStandardErrorHandling(t *testing.T, err error) {
 	}
 }
 
+// TestGetServerTime API endpoint test
+func TestGetServerTime(t *testing.T) {
+	t.Parallel()
+	_, err := o.GetServerTime()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
 // TestGetAccountCurrencies API endpoint test
 func TestGetAccountCurrencies(t *testing.T) {
 	t.Parallel()
@@ -1666,7 +1676,15 @@ func TestOrderBookPartialChecksumCalculator(t *testing.T) {
 		t.Error(err)
 	}
 
-	calculatedChecksum := o.CalculatePartialOrderbookChecksum(&dataResponse)
+	bids, err := o.AppendWsOrderbookItems(dataResponse.Bids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	asks, err := o.AppendWsOrderbookItems(dataResponse.Asks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calculatedChecksum := o.CalculateUpdateOrderbookChecksum(&orderbook.Base{Bids: bids, Asks: asks})
 	if calculatedChecksum != dataResponse.Checksum {
 		t.Errorf("Expected %v, received %v", dataResponse.Checksum, calculatedChecksum)
 	}
@@ -2251,3 +2269,21 @@ func TestGetHistoricTrades(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	err := o.UpdateOrderExecutionLimits(asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = o.UpdateOrderExecutionLimits(asset.Futures); err == nil {
+		t.Fatal("expected unhandled case")
+	}
+	p, err := o.GetPrecision(asset.Spot, currency.NewPair(currency.BTC, currency.USDT))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.PriceTick.IsPositive() || !p.AmountStep.IsPositive() {
+		t.Fatalf("unexpected precision %+v", p)
+	}
+}
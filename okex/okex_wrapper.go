@@ -287,6 +287,20 @@ func (o *OKEX) Run() {
 		}
 	}
 
+	a := o.GetAssetTypes()
+	for x := range a {
+		if err = o.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
+			err = o.UpdateOrderExecutionLimits(a[x])
+			o.SetOrderExecutionLimitsLoadError(a[x], err)
+			if err != nil {
+				log.Errorf(log.ExchangeSys,
+					"Could not set %s exchange exchange limits: %v",
+					o.Name,
+					err)
+			}
+		}
+	}
+
 	if !o.GetEnabledFeatures().AutoPairUpdates && !forceUpdate {
 		return
 	}
@@ -17,13 +17,17 @@ import (
 	exchange "github.com/openware/irix"
 	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/common/crypto"
+	"github.com/openware/pkg/currency"
 	"github.com/openware/pkg/log"
+	"github.com/openware/pkg/order"
 	"github.com/openware/pkg/request"
 )
 
 const (
 	okGroupAuthRate   = 0
 	okGroupUnauthRate = 0
+	// okGroupOrderNotExistMsg is the error message for an unknown order
+	okGroupOrderNotExistMsg = "order does not exist"
 	// OKGroupAPIPath const to help with api url formatting
 	OKGroupAPIPath = "api/"
 	// API subsections
@@ -32,6 +36,7 @@ const (
 	okGroupMarginTradingSubsection  = "margin"
 	okGroupFuturesTradingSubSection = "futures"
 	oKGroupSwapTradingSubSection    = "swap"
+	okGroupGeneralSubsection        = "general"
 	// OKGroupAccounts common api endpoint
 	OKGroupAccounts = "accounts"
 	// OKGroupLedger common api endpoint
@@ -68,6 +73,8 @@ const (
 	OKGroupGetSpotMarketData = "candles"
 	// OKGroupPriceLimit common api endpoint
 	OKGroupPriceLimit = "price_limit"
+	// okGroupServerTime returns the server time
+	okGroupServerTime = "time"
 	// Account based endpoints
 	okGroupGetAccountCurrencies        = "currencies"
 	okGroupGetAccountWalletInformation = "wallet"
@@ -102,6 +109,20 @@ type OKGroup struct {
 	WebsocketURL string
 }
 
+// GetServerTime returns the server time
+func (o *OKGroup) GetServerTime() (resp ServerTime, _ error) {
+	return resp, o.SendHTTPRequest(exchange.RestSpot, http.MethodGet, okGroupGeneralSubsection, okGroupServerTime, nil, &resp, false)
+}
+
+// serverTime returns the server time for the clock
+func (o *OKGroup) serverTime() (time.Time, error) {
+	t, err := o.GetServerTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.ISO, nil
+}
+
 // GetAccountCurrencies returns a list of tradable spot instruments and their properties
 func (o *OKGroup) GetAccountCurrencies() (resp []GetAccountCurrenciesResponse, _ error) {
 	return resp, o.SendHTTPRequest(exchange.RestSpot, http.MethodGet, okGroupAccountSubsection, okGroupGetAccountCurrencies, nil, &resp, true)
@@ -315,6 +336,44 @@ func (o *OKGroup) GetSpotTokenPairDetails() (resp []GetSpotTokenPairDetailsRespo
 	return resp, o.SendHTTPRequest(exchange.RestSpot, http.MethodGet, okGroupTokenSubsection, OKGroupInstruments, nil, &resp, false)
 }
 
+// FetchSpotExchangeLimits fetches spot order execution limits
+func (o *OKGroup) FetchSpotExchangeLimits() ([]order.MinMaxLevel, error) {
+	pairs, err := o.GetSpotTokenPairDetails()
+	if err != nil {
+		return nil, err
+	}
+	limits := make([]order.MinMaxLevel, len(pairs))
+	for x := range pairs {
+		var cp currency.Pair
+		cp, err = currency.NewPairFromStrings(pairs[x].BaseCurrency, pairs[x].QuoteCurrency)
+		if err != nil {
+			return nil, err
+		}
+		var minSize, sizeIncrement, tickSize float64
+		minSize, err = strconv.ParseFloat(pairs[x].MinSize, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s min size: %w", pairs[x].InstrumentID, err)
+		}
+		sizeIncrement, err = strconv.ParseFloat(pairs[x].SizeIncrement, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s size increment: %w", pairs[x].InstrumentID, err)
+		}
+		tickSize, err = strconv.ParseFloat(pairs[x].TickSize, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s tick size: %w", pairs[x].InstrumentID, err)
+		}
+		limits[x] = order.MinMaxLevel{
+			Pair:       cp,
+			Asset:      asset.Spot,
+			MinPrice:   tickSize,
+			StepPrice:  tickSize,
+			MinAmount:  minSize,
+			StepAmount: sizeIncrement,
+		}
+	}
+	return limits, nil
+}
+
 // GetOrderBook Getting the order book of a trading pair. Pagination is not
 // supported here. The whole book will be returned for one request. Websocket is
 // recommended here.
@@ -578,7 +637,7 @@ func (o *OKGroup) SendHTTPRequest(ep exchange.URL, httpMethod, requestType, requ
 		return err
 	}
 	now := time.Now()
-	utcTime := now.UTC().Format(time.RFC3339)
+	utcTime := o.Clock.Now().UTC().Format(time.RFC3339)
 	payload := []byte("")
 
 	if data != nil {
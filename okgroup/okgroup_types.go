@@ -83,6 +83,12 @@ type PerpSwapFundingRates struct {
 	FundingTime  time.Time `json:"funding_time"`
 }
 
+// ServerTime holds the server time
+type ServerTime struct {
+	ISO   time.Time `json:"iso"`
+	Epoch float64   `json:"epoch,string"`
+}
+
 // GetAccountCurrenciesResponse response data for GetAccountCurrencies
 type GetAccountCurrenciesResponse struct {
 	Name          string  `json:"name"`
@@ -294,6 +300,7 @@ type GetSpotOrdersRequest struct {
 
 // GetSpotOrderResponse response data for GetSpotOrders
 type GetSpotOrderResponse struct {
+	ClientOID      string    `json:"client_oid"`
 	FilledNotional float64   `json:"filled_notional,string"`
 	FilledSize     float64   `json:"filled_size,string"`
 	InstrumentID   string    `json:"instrument_id"`
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"hash/crc32"
 	"net/http"
 	"strconv"
 	"strings"
@@ -149,12 +148,22 @@ const (
 	delimiterDash       = "-"
 
 	maxConnByteLen = 4096
+	// wsChannelsPerConnection keeps each connection within the channels
+	// OKGroup exchanges serve on one connection
+	wsChannelsPerConnection = 30
 )
 
 // orderbookMutex Ensures if two entries arrive at once, only one can be
 // processed at a time
 var orderbookMutex sync.Mutex
 
+// orderbookChecksum verifies merged orderbooks against the checksum sent with
+// each depth update
+var orderbookChecksum = &buffer.CRC32{
+	Depth:     allowableIterations,
+	Separator: delimiterColon,
+}
+
 var defaultSpotSubscribedChannels = []string{okGroupWsSpotDepth,
 	okGroupWsSpotCandle300s,
 	okGroupWsSpotTicker,
@@ -206,17 +215,48 @@ func (o *OKGroup) WsConnect() error {
 	return nil
 }
 
+// wsConnectShard connects an additional connection once the primary carries
+// wsChannelsPerConnection channels, logging in so it can carry account and
+// order channels
+func (o *OKGroup) wsConnectShard(conn stream.Connection) error {
+	var dialer websocket.Dialer
+	dialer.ReadBufferSize = 8192
+	dialer.WriteBufferSize = 8192
+	err := conn.Dial(&dialer, http.Header{})
+	if err != nil {
+		return err
+	}
+	go o.wsReadData(conn)
+	if o.Websocket.CanUseAuthenticatedEndpoints() {
+		_, err = conn.SendMessageReturnResponse("login", o.wsLoginRequest())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // WsLogin sends a login request to websocket to enable access to authenticated endpoints
 func (o *OKGroup) WsLogin() error {
 	o.Websocket.SetCanUseAuthenticatedEndpoints(true)
-	unixTime := time.Now().UTC().Unix()
+	_, err := o.Websocket.Conn.SendMessageReturnResponse("login", o.wsLoginRequest())
+	if err != nil {
+		o.Websocket.SetCanUseAuthenticatedEndpoints(false)
+		return err
+	}
+	return nil
+}
+
+// wsLoginRequest returns a signed login request
+func (o *OKGroup) wsLoginRequest() WebsocketEventRequest {
+	unixTime := o.Clock.Now().UTC().Unix()
 	signPath := "/users/self/verify"
 	hmac := crypto.GetHMAC(crypto.HashSHA256,
 		[]byte(strconv.FormatInt(unixTime, 10)+http.MethodGet+signPath),
 		[]byte(o.API.Credentials.Secret),
 	)
 	base64 := crypto.Base64Encode(hmac)
-	request := WebsocketEventRequest{
+	return WebsocketEventRequest{
 		Operation: "login",
 		Arguments: []string{
 			o.API.Credentials.Key,
@@ -225,21 +265,20 @@ func (o *OKGroup) WsLogin() error {
 			base64,
 		},
 	}
-	_, err := o.Websocket.Conn.SendMessageReturnResponse("login", request)
-	if err != nil {
-		o.Websocket.SetCanUseAuthenticatedEndpoints(false)
-		return err
-	}
-	return nil
 }
 
 // WsReadData receives and passes on websocket messages for processing
 func (o *OKGroup) WsReadData() {
+	o.wsReadData(o.Websocket.Conn)
+}
+
+// wsReadData receives and passes on messages read from conn for processing
+func (o *OKGroup) wsReadData(conn stream.Connection) {
 	o.Websocket.Wg.Add(1)
 	defer o.Websocket.Wg.Done()
 
 	for {
-		resp := o.Websocket.Conn.ReadMessage()
+		resp := conn.ReadMessage()
 		if resp.Raw == nil {
 			return
 		}
@@ -589,16 +628,21 @@ func (o *OKGroup) WsProcessOrderBook(respRaw []byte) error {
 				}
 				return err
 			}
+			// A snapshot failing its checksum is resubscribed by the buffer
+			err = o.Websocket.Orderbook.VerifyChecksum(c,
+				a,
+				uint32(response.Data[i].Checksum),
+				nil)
+			if err != nil {
+				return err
+			}
 		} else if response.Action == okGroupWsOrderbookUpdate {
 			if len(response.Data[i].Asks) == 0 && len(response.Data[i].Bids) == 0 {
 				return nil
 			}
+			// A book failing its checksum is resubscribed by the buffer
 			err := o.WsProcessUpdateOrderbook(&response.Data[i], c, a)
 			if err != nil {
-				err2 := o.wsResubscribeToOrderbook(&response)
-				if err2 != nil {
-					o.Websocket.DataHandler <- err2
-				}
 				return err
 			}
 		}
@@ -651,22 +695,8 @@ func (o *OKGroup) AppendWsOrderbookItems(entries [][]interface{}) ([]orderbook.I
 }
 
 // WsProcessPartialOrderBook takes websocket orderbook data and creates an
-// orderbook Calculates checksum to ensure it is valid
+// orderbook
 func (o *OKGroup) WsProcessPartialOrderBook(wsEventData *WebsocketOrderBook, instrument currency.Pair, a asset.Item) error {
-	signedChecksum := o.CalculatePartialOrderbookChecksum(wsEventData)
-	if signedChecksum != wsEventData.Checksum {
-		return fmt.Errorf("%s channel: %s. Orderbook partial for %v checksum invalid",
-			o.Name,
-			a,
-			instrument)
-	}
-	if o.Verbose {
-		log.Debugf(log.ExchangeSys,
-			"%s passed checksum for instrument %s",
-			o.Name,
-			instrument)
-	}
-
 	asks, err := o.AppendWsOrderbookItems(wsEventData.Asks)
 	if err != nil {
 		return err
@@ -709,50 +739,8 @@ func (o *OKGroup) WsProcessUpdateOrderbook(wsEventData *WebsocketOrderBook, inst
 		return err
 	}
 
-	err = o.Websocket.Orderbook.Update(&update)
-	if err != nil {
-		return err
-	}
-
-	updatedOb, err := o.Websocket.Orderbook.GetOrderbook(instrument, a)
-	if err != nil {
-		return err
-	}
-	checksum := o.CalculateUpdateOrderbookChecksum(updatedOb)
-
-	if checksum != wsEventData.Checksum {
-		// re-sub
-		log.Warnf(log.ExchangeSys, "%s checksum failure for item %s",
-			o.Name,
-			wsEventData.InstrumentID)
-		return errors.New("checksum failed")
-	}
-	return nil
-}
-
-// CalculatePartialOrderbookChecksum alternates over the first 25 bid and ask
-// entries from websocket data. The checksum is made up of the price and the
-// quantity with a semicolon (:) deliminating them. This will also work when
-// there are less than 25 entries (for whatever reason)
-// eg Bid:Ask:Bid:Ask:Ask:Ask
-func (o *OKGroup) CalculatePartialOrderbookChecksum(orderbookData *WebsocketOrderBook) int32 {
-	var checksum strings.Builder
-	for i := 0; i < allowableIterations; i++ {
-		if len(orderbookData.Bids)-1 >= i {
-			checksum.WriteString(orderbookData.Bids[i][0].(string) +
-				delimiterColon +
-				orderbookData.Bids[i][1].(string) +
-				delimiterColon)
-		}
-		if len(orderbookData.Asks)-1 >= i {
-			checksum.WriteString(orderbookData.Asks[i][0].(string) +
-				delimiterColon +
-				orderbookData.Asks[i][1].(string) +
-				delimiterColon)
-		}
-	}
-	checksumStr := strings.TrimSuffix(checksum.String(), delimiterColon)
-	return int32(crc32.ChecksumIEEE([]byte(checksumStr)))
+	update.Checksum = uint32(wsEventData.Checksum)
+	return o.Websocket.Orderbook.Update(&update)
 }
 
 // CalculateUpdateOrderbookChecksum alternates over the first 25 bid and ask
@@ -761,21 +749,15 @@ func (o *OKGroup) CalculatePartialOrderbookChecksum(orderbookData *WebsocketOrde
 // there are less than 25 entries (for whatever reason)
 // eg Bid:Ask:Bid:Ask:Ask:Ask
 func (o *OKGroup) CalculateUpdateOrderbookChecksum(orderbookData *orderbook.Base) int32 {
-	var checksum strings.Builder
-	for i := 0; i < allowableIterations; i++ {
-		if len(orderbookData.Bids)-1 >= i {
-			price := strconv.FormatFloat(orderbookData.Bids[i].Price, 'f', -1, 64)
-			amount := strconv.FormatFloat(orderbookData.Bids[i].Amount, 'f', -1, 64)
-			checksum.WriteString(price + delimiterColon + amount + delimiterColon)
-		}
-		if len(orderbookData.Asks)-1 >= i {
-			price := strconv.FormatFloat(orderbookData.Asks[i].Price, 'f', -1, 64)
-			amount := strconv.FormatFloat(orderbookData.Asks[i].Amount, 'f', -1, 64)
-			checksum.WriteString(price + delimiterColon + amount + delimiterColon)
-		}
-	}
-	checksumStr := strings.TrimSuffix(checksum.String(), delimiterColon)
-	return int32(crc32.ChecksumIEEE([]byte(checksumStr)))
+	// Without a minimum depth the checksum cannot fail
+	checksum, _ := orderbookChecksum.Checksum(orderbookData.Bids, orderbookData.Asks)
+	return int32(checksum)
+}
+
+// isOrderbookChannel reports whether a subscription is to the incremental
+// depth channel, which sends a fresh partial when resubscribed
+func (o *OKGroup) isOrderbookChannel(c *stream.ChannelSubscription) bool {
+	return o.GetWsChannelWithoutOrderType(c.Channel) == okGroupWsDepth
 }
 
 // GenerateDefaultSubscriptions Adds default subscriptions to websocket to be
@@ -920,15 +902,25 @@ func (o *OKGroup) GenerateDefaultSubscriptions() ([]stream.ChannelSubscription,
 
 // Subscribe sends a websocket message to receive data from the channel
 func (o *OKGroup) Subscribe(channelsToSubscribe []stream.ChannelSubscription) error {
-	return o.handleSubscriptions("subscribe", channelsToSubscribe)
+	return o.handleSubscriptions(o.Websocket.Conn, "subscribe", channelsToSubscribe)
 }
 
 // Unsubscribe sends a websocket message to stop receiving data from the channel
 func (o *OKGroup) Unsubscribe(channelsToUnsubscribe []stream.ChannelSubscription) error {
-	return o.handleSubscriptions("unsubscribe", channelsToUnsubscribe)
+	return o.handleSubscriptions(o.Websocket.Conn, "unsubscribe", channelsToUnsubscribe)
+}
+
+// subscribeOn subscribes to channels on conn
+func (o *OKGroup) subscribeOn(conn stream.Connection, channelsToSubscribe []stream.ChannelSubscription) error {
+	return o.handleSubscriptions(conn, "subscribe", channelsToSubscribe)
+}
+
+// unsubscribeOn unsubscribes from channels on conn
+func (o *OKGroup) unsubscribeOn(conn stream.Connection, channelsToUnsubscribe []stream.ChannelSubscription) error {
+	return o.handleSubscriptions(conn, "unsubscribe", channelsToUnsubscribe)
 }
 
-func (o *OKGroup) handleSubscriptions(operation string, subs []stream.ChannelSubscription) error {
+func (o *OKGroup) handleSubscriptions(conn stream.Connection, operation string, subs []stream.ChannelSubscription) error {
 	request := WebsocketEventRequest{
 		Operation: operation,
 	}
@@ -960,7 +952,7 @@ func (o *OKGroup) handleSubscriptions(operation string, subs []stream.ChannelSub
 			// commit last payload.
 			i-- // reverse position in range to reuse channel unsubscription on
 			// next iteration
-			err = o.Websocket.Conn.SendJSONMessage(request)
+			err = conn.SendJSONMessage(request)
 			if err != nil {
 				return err
 			}
@@ -982,7 +974,7 @@ func (o *OKGroup) handleSubscriptions(operation string, subs []stream.ChannelSub
 	}
 
 	// Commit left overs to payload
-	err := o.Websocket.Conn.SendJSONMessage(request)
+	err := conn.SendJSONMessage(request)
 	if err != nil {
 		return err
 	}
@@ -38,6 +38,7 @@ func (o *OKGroup) Setup(exch *config.ExchangeConfig) error {
 	if err != nil {
 		return err
 	}
+	o.SetServerTimeSource(o.serverTime)
 
 	wsEndpoint, err := o.API.Endpoints.GetURL(exchange.WebsocketSpot)
 	if err != nil {
@@ -56,8 +57,14 @@ func (o *OKGroup) Setup(exch *config.ExchangeConfig) error {
 		UnSubscriber:                     o.Unsubscribe,
 		GenerateSubscriptions:            o.GenerateDefaultSubscriptions,
 		Features:                         &o.Features.Supports.WebsocketCapabilities,
+		OrderbookChannel:                 o.isOrderbookChannel,
+		OrderbookChecksum:                orderbookChecksum,
 		OrderbookBufferLimit:             exch.OrderbookConfig.WebsocketBufferLimit,
 		BufferEnabled:                    exch.OrderbookConfig.WebsocketBufferEnabled,
+		MaxSubscriptionsPerConnection:    wsChannelsPerConnection,
+		ShardConnector:                   o.wsConnectShard,
+		ShardSubscriber:                  o.subscribeOn,
+		ShardUnsubscriber:                o.unsubscribeOn,
 	})
 	if err != nil {
 		return err
@@ -278,6 +285,14 @@ func (o *OKGroup) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 	if err != nil {
 		return order.SubmitResponse{}, err
 	}
+	err = exchange.AttachClientOrderID(o, s)
+	if err != nil {
+		return order.SubmitResponse{}, err
+	}
+	err = o.ConformOrderToLimits(s)
+	if err != nil {
+		return order.SubmitResponse{}, err
+	}
 
 	fpair, err := o.FormatExchangeCurrency(s.Pair, s.AssetType)
 	if err != nil {
@@ -285,7 +300,7 @@ func (o *OKGroup) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 	}
 
 	request := PlaceOrderRequest{
-		ClientOID:    s.ClientID,
+		ClientOID:    s.ClientOrderID,
 		InstrumentID: fpair.String(),
 		Side:         s.Side.Lower(),
 		Type:         s.Type.Lower(),
@@ -395,22 +410,69 @@ func (o *OKGroup) GetOrderInfo(orderID string, pair currency.Pair, assetType ass
 	if err != nil {
 		return
 	}
+	return o.spotOrderToDetail(&mOrder, assetType)
+}
 
+// GetOrderInfoByClientOrderID returns order information based on the client
+// order ID supplied on submission
+func (o *OKGroup) GetOrderInfoByClientOrderID(clientOrderID string, pair currency.Pair, assetType asset.Item) (order.Detail, error) {
+	if clientOrderID == "" {
+		return order.Detail{}, order.ErrOrderIDNotSet
+	}
+	if assetType == "" {
+		assetType = asset.Spot
+	}
+	fpair, err := o.FormatExchangeCurrency(pair, assetType)
+	if err != nil {
+		return order.Detail{}, err
+	}
+	// Orders are queried by either ID on the same path
+	mOrder, err := o.GetSpotOrder(GetSpotOrderRequest{
+		OrderID:      clientOrderID,
+		InstrumentID: fpair.String(),
+	})
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), okGroupOrderNotExistMsg) {
+			return order.Detail{}, fmt.Errorf("%s client order ID %s %w: %v",
+				o.Name,
+				clientOrderID,
+				exchange.ErrOrderNotFound,
+				err)
+		}
+		return order.Detail{}, err
+	}
+	return o.spotOrderToDetail(&mOrder, assetType)
+}
+
+// GenerateClientOrderID returns a client order ID in the client_oid format
+// of up to 32 alphanumeric characters starting with a letter
+func (o *OKGroup) GenerateClientOrderID() (string, error) {
+	id, err := exchange.GenerateClientOrderID()
+	if err != nil {
+		return "", err
+	}
+	return "o" + strings.ReplaceAll(id, "-", "")[:31], nil
+}
+
+// spotOrderToDetail converts a spot order to an order detail
+func (o *OKGroup) spotOrderToDetail(mOrder *GetSpotOrderResponse, assetType asset.Item) (order.Detail, error) {
 	if assetType == "" {
 		assetType = asset.Spot
 	}
 
 	format, err := o.GetPairFormat(assetType, false)
 	if err != nil {
-		return resp, err
+		return order.Detail{}, err
 	}
 
 	p, err := currency.NewPairDelimiter(mOrder.InstrumentID, format.Delimiter)
 	if err != nil {
-		return resp, err
+		return order.Detail{}, err
 	}
 
-	resp = order.Detail{
+	return order.Detail{
+		ID:             mOrder.OrderID,
+		ClientOrderID:  mOrder.ClientOID,
 		Amount:         mOrder.Size,
 		Pair:           p,
 		Exchange:       o.Name,
@@ -418,8 +480,7 @@ func (o *OKGroup) GetOrderInfo(orderID string, pair currency.Pair, assetType ass
 		ExecutedAmount: mOrder.FilledSize,
 		Status:         order.Status(mOrder.Status),
 		Side:           order.Side(mOrder.Side),
-	}
-	return
+	}, nil
 }
 
 // GetDepositAddress returns a deposit address for a specified currency
@@ -740,3 +801,15 @@ func (o *OKGroup) GetHistoricCandlesExtended(pair currency.Pair, a asset.Item, s
 	ret.SortCandlesByTimestamp(false)
 	return ret, nil
 }
+
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (o *OKGroup) UpdateOrderExecutionLimits(a asset.Item) error {
+	if a != asset.Spot {
+		return fmt.Errorf("%s %w", a, asset.ErrNotSupported)
+	}
+	limits, err := o.FetchSpotExchangeLimits()
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return o.LoadLimits(limits)
+}
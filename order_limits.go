@@ -0,0 +1,133 @@
+package irix
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/common"
+	"github.com/openware/pkg/order"
+	"github.com/shopspring/decimal"
+)
+
+// ErrOrderLimitsNotLoaded is returned when an order is submitted for an asset
+// whose order execution limits failed to load. The order can be retried once
+// UpdateOrderExecutionLimits succeeds.
+var ErrOrderLimitsNotLoaded = errors.New("order execution limits failed to load")
+
+// limitsLoadStore holds the error of the last failed order execution limits
+// load for each asset
+type limitsLoadStore struct {
+	m   map[asset.Item]error
+	mtx sync.RWMutex
+}
+
+// floatStepTolerance is the fraction of a step within which a float value is
+// treated as lying on that step, this absorbs binary representation error
+// such as 0.1+0.2 before rounding
+var floatStepTolerance = decimal.New(1, -9)
+
+// IsRoundOrdersToLimitsEnabled checks the state of RoundOrdersToLimits in a
+// concurrent-friendly manner
+func (b *Base) IsRoundOrdersToLimitsEnabled() bool {
+	b.settingsMutex.RLock()
+	isEnabled := b.Features.Enabled.RoundOrdersToLimits
+	b.settingsMutex.RUnlock()
+	return isEnabled
+}
+
+// SetRoundOrdersToLimits locks and sets the status of the config and the
+// exchange's setting for RoundOrdersToLimits
+func (b *Base) SetRoundOrdersToLimits(enabled bool) {
+	b.settingsMutex.Lock()
+	defer b.settingsMutex.Unlock()
+	b.Features.Enabled.RoundOrdersToLimits = enabled
+	if b.Config != nil {
+		b.Config.Features.Enabled.RoundOrdersToLimits = enabled
+	}
+}
+
+// SetOrderExecutionLimitsLoadError records the result of loading the order
+// execution limits of an asset. While a load has failed orders for the asset
+// are rejected with ErrOrderLimitsNotLoaded. A nil error clears the failure,
+// as do errors for assets or exchanges which have no limits to load.
+func (b *Base) SetOrderExecutionLimitsLoadError(a asset.Item, err error) {
+	b.limitsLoad.mtx.Lock()
+	defer b.limitsLoad.mtx.Unlock()
+	if err == nil ||
+		errors.Is(err, asset.ErrNotSupported) ||
+		errors.Is(err, common.ErrFunctionNotSupported) {
+		delete(b.limitsLoad.m, a)
+		return
+	}
+	if b.limitsLoad.m == nil {
+		b.limitsLoad.m = make(map[asset.Item]error)
+	}
+	b.limitsLoad.m[a] = err
+}
+
+// ConformOrderToLimits validates an order submission against the loaded
+// execution limits before it is sent to the exchange. When RoundOrdersToLimits
+// is enabled the price and amount are first rounded to the tick and lot sizes,
+// buy prices down, sell prices up and amounts down. Pairs without loaded
+// limits are not checked unless the limits of their asset failed to load, in
+// which case a retryable ErrOrderLimitsNotLoaded is returned.
+func (b *Base) ConformOrderToLimits(s *order.Submit) error {
+	if s == nil {
+		return order.ErrSubmissionIsNil
+	}
+	if _, err := b.GetOrderExecutionLimits(s.AssetType, s.Pair); err != nil {
+		b.limitsLoad.mtx.RLock()
+		loadErr := b.limitsLoad.m[s.AssetType]
+		b.limitsLoad.mtx.RUnlock()
+		if loadErr != nil {
+			return fmt.Errorf("%s %s %s cannot conform order, %w: %v",
+				b.Name,
+				s.AssetType,
+				s.Pair,
+				ErrOrderLimitsNotLoaded,
+				loadErr)
+		}
+		return nil
+	}
+	if b.IsRoundOrdersToLimitsEnabled() {
+		if p, err := b.GetPrecision(s.AssetType, s.Pair); err == nil {
+			s.Amount = roundFloatToStep(s.Amount, p.AmountStep, false)
+			if s.Type != order.Market && s.Price != 0 {
+				s.Price = roundFloatToStep(s.Price,
+					p.PriceTick,
+					s.Side == order.Sell || s.Side == order.Ask)
+			}
+		}
+	}
+	return b.CheckOrderExecutionLimits(s.AssetType, s.Pair, s.Price, s.Amount, s.Type)
+}
+
+// StepFromDecimalPlaces returns the step of a value quoted to a number of
+// decimal places, such as 0.01 for two
+func StepFromDecimalPlaces(places int64) float64 {
+	f, _ := decimal.New(1, int32(-places)).Float64()
+	return f
+}
+
+// roundFloatToStep rounds a float to a multiple of step. Values within
+// floatStepTolerance of a step are snapped to it so representation error does
+// not push a value down or up a whole step.
+func roundFloatToStep(value float64, step decimal.Decimal, roundUp bool) float64 {
+	if step.Sign() <= 0 {
+		return value
+	}
+	steps := decimal.NewFromFloat(value).Div(step)
+	nearest := steps.Round(0)
+	switch {
+	case steps.Sub(nearest).Abs().LessThan(floatStepTolerance):
+		steps = nearest
+	case roundUp:
+		steps = steps.Ceil()
+	default:
+		steps = steps.Floor()
+	}
+	f, _ := steps.Mul(step).Float64()
+	return f
+}
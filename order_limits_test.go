@@ -0,0 +1,120 @@
+package irix
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/common"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
+	"github.com/shopspring/decimal"
+)
+
+func TestConformOrderToLimits(t *testing.T) {
+	t.Parallel()
+	b := &Base{Name: "limitex"}
+	if err := b.ConformOrderToLimits(nil); !errors.Is(err, order.ErrSubmissionIsNil) {
+		t.Fatalf("received: %v but expected: %v", err, order.ErrSubmissionIsNil)
+	}
+	cp := currency.NewPair(currency.BTC, currency.USDT)
+	s := &order.Submit{Pair: cp, AssetType: asset.Spot, Side: order.Buy, Type: order.Limit, Price: 35000.005, Amount: 0.1 + 0.2}
+	// Pairs without limits are not checked
+	if err := b.ConformOrderToLimits(s); err != nil {
+		t.Fatal(err)
+	}
+	err := b.UpdateOrderExecutionLimits(asset.Spot)
+	if !errors.Is(err, common.ErrFunctionNotSupported) {
+		t.Fatalf("received: %v but expected: %v", err, common.ErrFunctionNotSupported)
+	}
+	// Unsupported limits are not a failed load
+	b.SetOrderExecutionLimitsLoadError(asset.Spot, err)
+	if err = b.ConformOrderToLimits(s); err != nil {
+		t.Fatal(err)
+	}
+	b.SetOrderExecutionLimitsLoadError(asset.Spot, errors.New("timeout"))
+	if err = b.ConformOrderToLimits(s); !errors.Is(err, ErrOrderLimitsNotLoaded) {
+		t.Fatalf("received: %v but expected: %v", err, ErrOrderLimitsNotLoaded)
+	}
+	b.SetOrderExecutionLimitsLoadError(asset.Spot, nil)
+	if err = b.ConformOrderToLimits(s); err != nil {
+		t.Fatal(err)
+	}
+
+	// A successful load clears a failed one
+	b.SetOrderExecutionLimitsLoadError(asset.Spot, errors.New("timeout"))
+	err = b.LoadLimits([]order.MinMaxLevel{{
+		Pair:        cp,
+		Asset:       asset.Spot,
+		StepPrice:   0.01,
+		StepAmount:  0.1,
+		MinAmount:   0.1,
+		MinNotional: 10,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = b.ConformOrderToLimits(s); !errors.Is(err, order.ErrPriceExceedsStep) {
+		t.Fatalf("received: %v but expected: %v", err, order.ErrPriceExceedsStep)
+	}
+
+	b.SetRoundOrdersToLimits(true)
+	if !b.IsRoundOrdersToLimitsEnabled() {
+		t.Fatal("round orders to limits should be enabled")
+	}
+	if err = b.ConformOrderToLimits(s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Price != 35000 || s.Amount != 0.3 {
+		t.Fatalf("unexpected rounded price %v amount %v", s.Price, s.Amount)
+	}
+
+	s = &order.Submit{Pair: cp, AssetType: asset.Spot, Side: order.Sell, Type: order.Limit, Price: 35000.001, Amount: 0.7 - 0.4}
+	if err = b.ConformOrderToLimits(s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Price != 35000.01 || s.Amount != 0.3 {
+		t.Fatalf("unexpected rounded price %v amount %v", s.Price, s.Amount)
+	}
+
+	s = &order.Submit{Pair: cp, AssetType: asset.Spot, Side: order.Buy, Type: order.Limit, Price: 10, Amount: 0.15}
+	if err = b.ConformOrderToLimits(s); !errors.Is(err, order.ErrNotionalValue) {
+		t.Fatalf("received: %v but expected: %v", err, order.ErrNotionalValue)
+	}
+}
+
+func TestRoundFloatToStep(t *testing.T) {
+	t.Parallel()
+	step := decimal.RequireFromString("0.1")
+	for _, tt := range []struct {
+		value    float64
+		roundUp  bool
+		expected float64
+	}{
+		{0.1 + 0.2, false, 0.3},
+		{0.7 - 0.4, false, 0.3},
+		{0.7 - 0.4, true, 0.3},
+		{0.35, false, 0.3},
+		{0.35, true, 0.4},
+	} {
+		if r := roundFloatToStep(tt.value, step, tt.roundUp); r != tt.expected {
+			t.Errorf("%v round up %v received: %v but expected: %v", tt.value, tt.roundUp, r, tt.expected)
+		}
+	}
+	if r := roundFloatToStep(1.23, decimal.Zero, false); r != 1.23 {
+		t.Errorf("received: %v but expected: %v", r, 1.23)
+	}
+}
+
+func TestStepFromDecimalPlaces(t *testing.T) {
+	t.Parallel()
+	if s := StepFromDecimalPlaces(2); s != 0.01 {
+		t.Errorf("received: %v but expected: %v", s, 0.01)
+	}
+	if s := StepFromDecimalPlaces(0); s != 1 {
+		t.Errorf("received: %v but expected: %v", s, 1)
+	}
+	if s := StepFromDecimalPlaces(8); s != 0.00000001 {
+		t.Errorf("received: %v but expected: %v", s, 0.00000001)
+	}
+}
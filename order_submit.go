@@ -0,0 +1,252 @@
+package irix
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/common"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/log"
+	"github.com/openware/pkg/order"
+)
+
+const (
+	// DefaultReconcileAttempts is the amount of times an exchange is queried by
+	// client order ID after an ambiguous submission failure
+	DefaultReconcileAttempts = 3
+	// DefaultReconcileDelay is the delay between reconciliation queries, this
+	// gives an in-flight submission time to land on the exchange
+	DefaultReconcileDelay = time.Second
+)
+
+var (
+	// ErrOrderNotFound is returned when an exchange has no record of an order
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrOrderOutcomeUnknown is returned when a submission failed ambiguously
+	// and the exchange could not confirm whether the order was placed. The
+	// order must be reconciled before it is retried.
+	ErrOrderOutcomeUnknown = errors.New("order submission outcome unknown")
+	// ErrOrderNotPlaced is returned when the exchange definitively rejected a
+	// submission. The order can be retried.
+	ErrOrderNotPlaced = errors.New("order not placed")
+)
+
+// notPlacedError is a definitive rejection, it matches ErrOrderNotPlaced and
+// unwraps to the exchange's error
+type notPlacedError struct {
+	err error
+}
+
+func (e notPlacedError) Error() string {
+	return e.err.Error()
+}
+
+func (e notPlacedError) Unwrap() error {
+	return e.err
+}
+
+func (e notPlacedError) Is(target error) bool {
+	return target == ErrOrderNotPlaced
+}
+
+// GenerateClientOrderID returns a new unique client order ID. The UUID format
+// is accepted by most exchanges which support caller defined order IDs,
+// exchanges which need another format implement ClientOrderIDGenerator.
+func GenerateClientOrderID() (string, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// GenerateNumericClientOrderID returns a random client order ID between one
+// and max for exchanges whose client order IDs are integers
+func GenerateNumericClientOrderID(max int64) (string, error) {
+	if max <= 1 {
+		return "", fmt.Errorf("invalid client order ID bound %d", max)
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(max-1))
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(n.Int64()+1, 10), nil
+}
+
+// AttachClientOrderID sets a client order ID on the submission if the caller
+// has not supplied one. The exchange generates it when it implements
+// ClientOrderIDGenerator, a UUID is used otherwise.
+func AttachClientOrderID(e interface{}, s *order.Submit) error {
+	if s == nil {
+		return order.ErrSubmissionIsNil
+	}
+	if s.ClientOrderID != "" {
+		return nil
+	}
+	generate := GenerateClientOrderID
+	if g, ok := e.(ClientOrderIDGenerator); ok {
+		generate = g.GenerateClientOrderID
+	}
+	id, err := generate()
+	if err != nil {
+		return err
+	}
+	s.ClientOrderID = id
+	return nil
+}
+
+// IsAmbiguousOrderError returns true when an error leaves it unknown whether
+// a submission reached the exchange e.g. timeouts, dropped connections and
+// gateway errors.
+func IsAmbiguousOrderError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	// The requester flattens transport errors into strings so known fragments
+	// are matched as a fallback
+	msg := err.Error()
+	for _, fragment := range []string{
+		"Client.Timeout exceeded",
+		"deadline exceeded",
+		"deadline would be exceeded",
+		"failed to retry request",
+		"connection reset by peer",
+		"unsuccessful HTTP status code: 500",
+		"unsuccessful HTTP status code: 502",
+		"unsuccessful HTTP status code: 503",
+		"unsuccessful HTTP status code: 504",
+	} {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReconcileConfig defines how an ambiguous submission is reconciled
+type ReconcileConfig struct {
+	Attempts int
+	Delay    time.Duration
+}
+
+// SubmitOrderWithReconciliation attaches a client order ID to the submission
+// when one is not supplied and submits it to the exchange. If the submission
+// fails ambiguously the exchange is queried by client order ID before success
+// or failure is reported. ErrOrderNotPlaced is only returned when the exchange
+// rejected the submission, an order which cannot be found after an ambiguous
+// failure may still land so ErrOrderOutcomeUnknown is returned instead.
+func SubmitOrderWithReconciliation(e OrderSubmitter, s *order.Submit, cfg *ReconcileConfig) (order.SubmitResponse, error) {
+	if e == nil {
+		return order.SubmitResponse{}, errors.New("exchange is nil")
+	}
+	if err := AttachClientOrderID(e, s); err != nil {
+		return order.SubmitResponse{}, notPlacedError{err}
+	}
+	resp, submitErr := e.SubmitOrder(s)
+	if submitErr == nil {
+		return resp, nil
+	}
+	if !IsAmbiguousOrderError(submitErr) {
+		return resp, notPlacedError{submitErr}
+	}
+
+	attempts, delay := DefaultReconcileAttempts, DefaultReconcileDelay
+	if cfg != nil {
+		if cfg.Attempts > 0 {
+			attempts = cfg.Attempts
+		}
+		if cfg.Delay > 0 {
+			delay = cfg.Delay
+		}
+	}
+
+	log.Warnf(log.ExchangeSys,
+		"%s order %s submission failed ambiguously, reconciling: %v",
+		e.GetName(),
+		s.ClientOrderID,
+		submitErr)
+
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			time.Sleep(delay)
+		}
+		detail, err := e.GetOrderInfoByClientOrderID(s.ClientOrderID, s.Pair, s.AssetType)
+		switch {
+		case err == nil:
+			return submitResponseFromDetail(&detail), nil
+		case errors.Is(err, common.ErrFunctionNotSupported),
+			errors.Is(err, common.ErrNotYetImplemented):
+			return resp, fmt.Errorf("%s %w for client order ID %s, reconciliation unsupported: %v",
+				e.GetName(),
+				ErrOrderOutcomeUnknown,
+				s.ClientOrderID,
+				submitErr)
+		case errors.Is(err, ErrOrderOutcomeUnknown):
+			// The exchange cannot tell whether the order is the one submitted
+			return resp, fmt.Errorf("%w: %v", err, submitErr)
+		case errors.Is(err, ErrOrderNotFound):
+			continue
+		default:
+			log.Errorf(log.ExchangeSys,
+				"%s order %s reconciliation attempt %d failed: %v",
+				e.GetName(),
+				s.ClientOrderID,
+				i+1,
+				err)
+			if i == attempts-1 {
+				return resp, fmt.Errorf("%s %w for client order ID %s: %v",
+					e.GetName(),
+					ErrOrderOutcomeUnknown,
+					s.ClientOrderID,
+					submitErr)
+			}
+		}
+	}
+	// Not finding the order does not rule out that it lands later
+	return resp, fmt.Errorf("%s %w for client order ID %s, not found after %d lookups: %v",
+		e.GetName(),
+		ErrOrderOutcomeUnknown,
+		s.ClientOrderID,
+		attempts,
+		submitErr)
+}
+
+// submitResponseFromDetail converts a reconciled order into a submit response
+func submitResponseFromDetail(d *order.Detail) order.SubmitResponse {
+	resp := order.SubmitResponse{
+		IsOrderPlaced: true,
+		OrderID:       d.ID,
+		Rate:          d.Price,
+		Fee:           d.Fee,
+		Cost:          d.Cost,
+		Trades:        d.Trades,
+	}
+	resp.FullyMatched = d.Status == order.Filled ||
+		(d.Amount > 0 && d.ExecutedAmount >= d.Amount)
+	return resp
+}
+
+// GetOrderInfoByClientOrderID returns order information based on the client
+// order ID supplied on submission this is overridable
+func (b *Base) GetOrderInfoByClientOrderID(clientOrderID string, pair currency.Pair, a asset.Item) (order.Detail, error) {
+	return order.Detail{}, common.ErrFunctionNotSupported
+}
@@ -0,0 +1,204 @@
+package irix
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/common"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
+)
+
+type reconcileExchange struct {
+	Base
+	submitErr   error
+	lookupErrs  []error
+	detail      order.Detail
+	lookups     int
+	submittedID string
+}
+
+func (r *reconcileExchange) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
+	r.submittedID = s.ClientOrderID
+	if r.submitErr != nil {
+		return order.SubmitResponse{}, r.submitErr
+	}
+	return order.SubmitResponse{IsOrderPlaced: true, OrderID: "1337"}, nil
+}
+
+func (r *reconcileExchange) GetOrderInfoByClientOrderID(clientOrderID string, _ currency.Pair, _ asset.Item) (order.Detail, error) {
+	r.lookups++
+	if len(r.lookupErrs) > 0 {
+		err := r.lookupErrs[0]
+		r.lookupErrs = r.lookupErrs[1:]
+		if err != nil {
+			return order.Detail{}, err
+		}
+	}
+	if clientOrderID != r.submittedID {
+		return order.Detail{}, errors.New("client order ID mismatch")
+	}
+	return r.detail, nil
+}
+
+var (
+	reconcileTestCfg = &ReconcileConfig{Attempts: 3, Delay: time.Millisecond}
+	errTimeout       = fmt.Errorf("request.go error - failed to retry request, err: %w", errors.New("Client.Timeout exceeded while awaiting headers"))
+)
+
+func reconcileTestSubmit() *order.Submit {
+	return &order.Submit{
+		Pair:      currency.NewPair(currency.BTC, currency.USD),
+		AssetType: asset.Spot,
+		Side:      order.Buy,
+		Type:      order.Limit,
+		Price:     1,
+		Amount:    1,
+	}
+}
+
+func TestAttachClientOrderID(t *testing.T) {
+	t.Parallel()
+	if err := AttachClientOrderID(nil, nil); !errors.Is(err, order.ErrSubmissionIsNil) {
+		t.Fatalf("received: %v but expected: %v", err, order.ErrSubmissionIsNil)
+	}
+	s := &order.Submit{ClientOrderID: "supplied"}
+	if err := AttachClientOrderID(nil, s); err != nil {
+		t.Fatal(err)
+	}
+	if s.ClientOrderID != "supplied" {
+		t.Fatal("caller supplied client order ID should not be replaced")
+	}
+	s.ClientOrderID = ""
+	if err := AttachClientOrderID(nil, s); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.ClientOrderID) != 36 {
+		t.Fatalf("unexpected generated client order ID %s", s.ClientOrderID)
+	}
+	s.ClientOrderID = ""
+	if err := AttachClientOrderID(numericExchange{}, s); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := strconv.ParseInt(s.ClientOrderID, 10, 64); err != nil || n < 1 || n >= 10 {
+		t.Fatalf("unexpected generated client order ID %s", s.ClientOrderID)
+	}
+}
+
+type numericExchange struct{}
+
+func (numericExchange) GenerateClientOrderID() (string, error) {
+	return GenerateNumericClientOrderID(10)
+}
+
+func TestGenerateNumericClientOrderID(t *testing.T) {
+	t.Parallel()
+	if _, err := GenerateNumericClientOrderID(1); err == nil {
+		t.Fatal("expected error on invalid bound")
+	}
+	for i := 0; i < 100; i++ {
+		id, err := GenerateNumericClientOrderID(2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != "1" {
+			t.Fatalf("unexpected client order ID %s", id)
+		}
+	}
+}
+
+func TestIsAmbiguousOrderError(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{errTimeout, true},
+		{errors.New("Binance unsuccessful HTTP status code: 504 raw response: gateway timeout"), true},
+		{errors.New("Binance unsuccessful HTTP status code: 400 raw response: insufficient balance"), false},
+		{order.ErrAmountIsInvalid, false},
+	} {
+		if IsAmbiguousOrderError(tt.err) != tt.expected {
+			t.Errorf("%v expected ambiguous: %v", tt.err, tt.expected)
+		}
+	}
+}
+
+func TestSubmitOrderWithReconciliation(t *testing.T) {
+	t.Parallel()
+	_, err := SubmitOrderWithReconciliation(nil, reconcileTestSubmit(), nil)
+	if err == nil {
+		t.Fatal("expected error on nil exchange")
+	}
+
+	e := &reconcileExchange{}
+	resp, err := SubmitOrderWithReconciliation(e, reconcileTestSubmit(), reconcileTestCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.IsOrderPlaced || e.submittedID == "" || e.lookups != 0 {
+		t.Fatal("successful submission should not be reconciled")
+	}
+
+	errRejected := errors.New("insufficient funds")
+	e = &reconcileExchange{submitErr: errRejected}
+	_, err = SubmitOrderWithReconciliation(e, reconcileTestSubmit(), reconcileTestCfg)
+	if !errors.Is(err, ErrOrderNotPlaced) || !errors.Is(err, errRejected) || e.lookups != 0 {
+		t.Fatalf("definitive rejection should not be reconciled, received: %v", err)
+	}
+
+	e = &reconcileExchange{
+		submitErr:  errTimeout,
+		lookupErrs: []error{ErrOrderNotFound},
+		detail:     order.Detail{ID: "42", Amount: 1, ExecutedAmount: 1},
+	}
+	resp, err = SubmitOrderWithReconciliation(e, reconcileTestSubmit(), reconcileTestCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.IsOrderPlaced || resp.OrderID != "42" || !resp.FullyMatched || e.lookups != 2 {
+		t.Fatalf("unexpected reconciled response %+v after %d lookups", resp, e.lookups)
+	}
+
+	e = &reconcileExchange{
+		submitErr:  errTimeout,
+		lookupErrs: []error{ErrOrderNotFound, ErrOrderNotFound, ErrOrderNotFound},
+	}
+	// The order may still land so it is not reported as not placed
+	_, err = SubmitOrderWithReconciliation(e, reconcileTestSubmit(), reconcileTestCfg)
+	if !errors.Is(err, ErrOrderOutcomeUnknown) || errors.Is(err, ErrOrderNotPlaced) {
+		t.Fatalf("received: %v but expected: %v", err, ErrOrderOutcomeUnknown)
+	}
+
+	e = &reconcileExchange{
+		submitErr:  errTimeout,
+		lookupErrs: []error{ErrOrderNotFound, errors.New("bad gateway"), errors.New("bad gateway")},
+	}
+	_, err = SubmitOrderWithReconciliation(e, reconcileTestSubmit(), reconcileTestCfg)
+	if !errors.Is(err, ErrOrderOutcomeUnknown) {
+		t.Fatalf("received: %v but expected: %v", err, ErrOrderOutcomeUnknown)
+	}
+
+	e = &reconcileExchange{
+		submitErr:  errTimeout,
+		lookupErrs: []error{common.ErrFunctionNotSupported},
+	}
+	_, err = SubmitOrderWithReconciliation(e, reconcileTestSubmit(), reconcileTestCfg)
+	if !errors.Is(err, ErrOrderOutcomeUnknown) || e.lookups != 1 {
+		t.Fatalf("received: %v but expected: %v", err, ErrOrderOutcomeUnknown)
+	}
+
+	e = &reconcileExchange{
+		submitErr:  errTimeout,
+		lookupErrs: []error{fmt.Errorf("ambiguous match %w", ErrOrderOutcomeUnknown)},
+	}
+	_, err = SubmitOrderWithReconciliation(e, reconcileTestSubmit(), reconcileTestCfg)
+	if !errors.Is(err, ErrOrderOutcomeUnknown) || e.lookups != 1 {
+		t.Fatalf("received: %v after %d lookups but expected: %v", err, e.lookups, ErrOrderOutcomeUnknown)
+	}
+}
@@ -0,0 +1,463 @@
+package paper
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	exchange "github.com/openware/irix"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
+	"github.com/openware/pkg/orderbook"
+	"github.com/openware/pkg/trade"
+)
+
+// Deposit credits a simulated balance
+func (p *Paper) Deposit(c currency.Code, amount float64) error {
+	if amount <= 0 {
+		return fmt.Errorf("%s deposit amount must be positive, received %v",
+			p.Name,
+			amount)
+	}
+	p.mtx.Lock()
+	p.getBalance(c).total += amount
+	p.mtx.Unlock()
+	return nil
+}
+
+// AddLiquidity inserts an external resting order into the book, external
+// orders are matched like any other order but have no balances
+func (p *Paper) AddLiquidity(cp currency.Pair, a asset.Item, side order.Side, price, amount float64) error {
+	if price <= 0 || amount <= 0 {
+		return errInvalidLiquidityLevel
+	}
+	p.mtx.Lock()
+	b := p.getBook(cp, a)
+	b.insert(&restingOrder{
+		external:  true,
+		side:      normaliseSide(side),
+		price:     price,
+		remaining: amount,
+	})
+	ev := events{b.snapshot(p.Name, p.CanVerifyOrderbook)}
+	p.mtx.Unlock()
+	p.publish(ev)
+	return nil
+}
+
+// SeedLiquidity replaces the external liquidity of a book with the current
+// orderbook of the liquidity source exchange. Orders placed locally keep their
+// queue position ahead of seeded liquidity at the same price.
+func (p *Paper) SeedLiquidity(cp currency.Pair, a asset.Item) error {
+	if p.LiquiditySource == nil {
+		return errLiquiditySourceUnset
+	}
+	ob, err := p.LiquiditySource.UpdateOrderbook(cp, a)
+	if err != nil {
+		return err
+	}
+	p.mtx.Lock()
+	b := p.getBook(cp, a)
+	b.bids = removeExternal(b.bids)
+	b.asks = removeExternal(b.asks)
+	for x := range ob.Bids {
+		b.insert(&restingOrder{
+			external:  true,
+			side:      order.Buy,
+			price:     ob.Bids[x].Price,
+			remaining: ob.Bids[x].Amount,
+		})
+	}
+	for x := range ob.Asks {
+		b.insert(&restingOrder{
+			external:  true,
+			side:      order.Sell,
+			price:     ob.Asks[x].Price,
+			remaining: ob.Asks[x].Amount,
+		})
+	}
+	ev := events{b.snapshot(p.Name, p.CanVerifyOrderbook)}
+	p.mtx.Unlock()
+	p.publish(ev)
+	return nil
+}
+
+// submit runs an order through the matching engine, funds are reserved before
+// matching and any remainder of a limit order rests on the book
+func (p *Paper) submit(s *order.Submit) (order.SubmitResponse, events, error) {
+	side := normaliseSide(s.Side)
+	isMarket := s.Type == order.Market
+	crosses := func(o *restingOrder) bool {
+		switch {
+		case isMarket:
+			return true
+		case side == order.Buy:
+			return o.price <= s.Price
+		default:
+			return o.price >= s.Price
+		}
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	b := p.getBook(s.Pair, s.AssetType)
+	opposite := b.opposite(side)
+	if s.PostOnly && len(opposite) > 0 && crosses(opposite[0]) {
+		return order.SubmitResponse{}, nil, fmt.Errorf("%s %w", p.Name, errPostOnlyWouldCross)
+	}
+
+	var fillable, fillCost float64
+	for x := range opposite {
+		if fillable >= s.Amount || !crosses(opposite[x]) {
+			break
+		}
+		qty := math.Min(opposite[x].remaining, s.Amount-fillable)
+		fillable += qty
+		fillCost += qty * opposite[x].price
+	}
+	if s.FillOrKill && fillable < s.Amount {
+		return order.SubmitResponse{}, nil, fmt.Errorf("%s %w", p.Name, errFillOrKillUnfilled)
+	}
+	if isMarket && fillable == 0 {
+		return order.SubmitResponse{}, nil, fmt.Errorf("%s %s %w", p.Name, s.Pair, errNoLiquidity)
+	}
+
+	var held float64
+	funds := p.getBalance(s.Pair.Quote)
+	switch {
+	case side == order.Buy && isMarket:
+		held = fillCost * (1 + p.TakerFee)
+	case side == order.Buy:
+		held = s.Amount * s.Price * (1 + math.Max(p.MakerFee, p.TakerFee))
+	case isMarket:
+		funds = p.getBalance(s.Pair.Base)
+		held = fillable
+	default:
+		funds = p.getBalance(s.Pair.Base)
+		held = s.Amount
+	}
+	if available := funds.total - funds.hold; available < held {
+		return order.SubmitResponse{}, nil, fmt.Errorf("%s %w %s available: %v required: %v",
+			p.Name,
+			errInsufficientBalance,
+			funds.code,
+			available,
+			held)
+	}
+	funds.hold += held
+
+	p.sequence++
+	now := time.Now()
+	taker := &restingOrder{
+		detail: &order.Detail{
+			ImmediateOrCancel: s.ImmediateOrCancel,
+			FillOrKill:        s.FillOrKill,
+			PostOnly:          s.PostOnly,
+			Price:             s.Price,
+			Amount:            s.Amount,
+			RemainingAmount:   s.Amount,
+			Exchange:          p.Name,
+			ID:                strconv.FormatInt(p.sequence, 10),
+			ClientOrderID:     s.ClientOrderID,
+			ClientID:          s.ClientID,
+			Type:              s.Type,
+			Side:              side,
+			Status:            order.New,
+			AssetType:         s.AssetType,
+			Date:              now,
+			LastUpdated:       now,
+			Pair:              s.Pair,
+		},
+		side:      side,
+		price:     s.Price,
+		remaining: s.Amount,
+		held:      held,
+	}
+	p.orders[taker.detail.ID] = taker
+	if s.ClientOrderID != "" {
+		p.clientOrderIDs[s.ClientOrderID] = taker.detail.ID
+	}
+
+	var ev events
+	for taker.remaining > 0 {
+		opposite = b.opposite(side)
+		if len(opposite) == 0 || !crosses(opposite[0]) {
+			break
+		}
+		maker := opposite[0]
+		qty := math.Min(maker.remaining, taker.remaining)
+		p.sequence++
+		tid := strconv.FormatInt(p.sequence, 10)
+		p.fill(taker, qty, maker.price, p.TakerFee, false, tid, now)
+		if maker.external {
+			maker.remaining -= qty
+		} else {
+			p.fill(maker, qty, maker.price, p.MakerFee, true, tid, now)
+			ev = append(ev, copyDetail(maker.detail))
+		}
+		if maker.remaining <= 0 {
+			b.remove(maker)
+		}
+		t := trade.Data{
+			TID:          tid,
+			Exchange:     p.Name,
+			CurrencyPair: s.Pair,
+			AssetType:    s.AssetType,
+			Side:         side,
+			Price:        maker.price,
+			Amount:       qty,
+			Timestamp:    now,
+		}
+		b.addTrade(t)
+		ev = append(ev, t)
+	}
+
+	if taker.remaining > 0 {
+		if isMarket || s.ImmediateOrCancel {
+			p.release(taker)
+			taker.detail.Status = order.Cancelled
+			taker.detail.CloseTime = now
+		} else {
+			b.insert(taker)
+		}
+	}
+	ev = append(ev, copyDetail(taker.detail), b.snapshot(p.Name, p.CanVerifyOrderbook))
+
+	resp := order.SubmitResponse{
+		IsOrderPlaced: true,
+		FullyMatched:  taker.detail.Status == order.Filled,
+		OrderID:       taker.detail.ID,
+		Fee:           taker.detail.Fee,
+		Cost:          taker.detail.Cost,
+		Trades:        append([]order.TradeHistory(nil), taker.detail.Trades...),
+	}
+	if taker.detail.ExecutedAmount > 0 {
+		resp.Rate = taker.detail.Cost / taker.detail.ExecutedAmount
+	}
+	return resp, ev, nil
+}
+
+// cancel removes an open order from the book and releases its reserved funds
+func (p *Paper) cancel(id string) (events, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	o, ok := p.orders[id]
+	if !ok {
+		return nil, fmt.Errorf("%s %w: %s", p.Name, exchange.ErrOrderNotFound, id)
+	}
+	if o.detail.Status != order.New && o.detail.Status != order.PartiallyFilled {
+		return nil, fmt.Errorf("%s %w: %s %s", p.Name, errOrderNotOpen, id, o.detail.Status)
+	}
+	b := p.getBook(o.detail.Pair, o.detail.AssetType)
+	b.remove(o)
+	p.release(o)
+	now := time.Now()
+	o.detail.Status = order.Cancelled
+	o.detail.LastUpdated = now
+	o.detail.CloseTime = now
+	return events{copyDetail(o.detail), b.snapshot(p.Name, p.CanVerifyOrderbook)}, nil
+}
+
+// fill applies an execution to an order and settles balances, fees are
+// charged in the quote currency
+func (p *Paper) fill(o *restingOrder, qty, price, feeRate float64, isMaker bool, tid string, now time.Time) {
+	base := p.getBalance(o.detail.Pair.Base)
+	quote := p.getBalance(o.detail.Pair.Quote)
+	cost := qty * price
+	fee := cost * feeRate
+	if o.side == order.Buy {
+		release := o.held
+		if qty < o.remaining {
+			release = o.held * qty / o.remaining
+		}
+		quote.total -= cost + fee
+		quote.hold -= release
+		o.held -= release
+		base.total += qty
+	} else {
+		base.total -= qty
+		base.hold -= qty
+		o.held -= qty
+		quote.total += cost - fee
+	}
+	o.remaining -= qty
+
+	d := o.detail
+	d.ExecutedAmount += qty
+	d.RemainingAmount = o.remaining
+	d.Cost += cost
+	d.Fee += fee
+	d.LastUpdated = now
+	d.Trades = append(d.Trades, order.TradeHistory{
+		Price:     price,
+		Amount:    qty,
+		Fee:       fee,
+		Exchange:  p.Name,
+		TID:       tid,
+		Type:      d.Type,
+		Side:      d.Side,
+		Timestamp: now,
+		IsMaker:   isMaker,
+		FeeAsset:  quote.code.String(),
+		Total:     cost,
+	})
+	if o.remaining <= 0 {
+		d.Status = order.Filled
+		d.CloseTime = now
+		if o.held != 0 {
+			// Release any remainder reserved above the execution price
+			p.release(o)
+		}
+		return
+	}
+	d.Status = order.PartiallyFilled
+}
+
+// release returns the funds reserved by an order
+func (p *Paper) release(o *restingOrder) {
+	funds := p.getBalance(o.detail.Pair.Quote)
+	if o.side == order.Sell {
+		funds = p.getBalance(o.detail.Pair.Base)
+	}
+	funds.hold -= o.held
+	o.held = 0
+}
+
+// getBook returns the book for a pair, creating it if it does not exist
+func (p *Paper) getBook(cp currency.Pair, a asset.Item) *book {
+	m1, ok := p.books[a]
+	if !ok {
+		m1 = make(map[*currency.Item]map[*currency.Item]*book)
+		p.books[a] = m1
+	}
+	m2, ok := m1[cp.Base.Item]
+	if !ok {
+		m2 = make(map[*currency.Item]*book)
+		m1[cp.Base.Item] = m2
+	}
+	b, ok := m2[cp.Quote.Item]
+	if !ok {
+		b = &book{pair: cp, asset: a}
+		m2[cp.Quote.Item] = b
+	}
+	return b
+}
+
+// getBalance returns the balance for a currency, creating it if it does not
+// exist
+func (p *Paper) getBalance(c currency.Code) *balance {
+	bal, ok := p.balances[c.Item]
+	if !ok {
+		bal = &balance{code: c.Upper()}
+		p.balances[c.Item] = bal
+	}
+	return bal
+}
+
+// opposite returns the side of the book an order on side s matches against
+func (b *book) opposite(s order.Side) []*restingOrder {
+	if s == order.Buy {
+		return b.asks
+	}
+	return b.bids
+}
+
+// insert adds an order behind all orders at the same or a better price
+func (b *book) insert(o *restingOrder) {
+	if o.side == order.Buy {
+		i := sort.Search(len(b.bids), func(i int) bool { return b.bids[i].price < o.price })
+		b.bids = append(b.bids, nil)
+		copy(b.bids[i+1:], b.bids[i:])
+		b.bids[i] = o
+		return
+	}
+	i := sort.Search(len(b.asks), func(i int) bool { return b.asks[i].price > o.price })
+	b.asks = append(b.asks, nil)
+	copy(b.asks[i+1:], b.asks[i:])
+	b.asks[i] = o
+}
+
+// remove deletes an order from the book
+func (b *book) remove(o *restingOrder) {
+	side := &b.asks
+	if o.side == order.Buy {
+		side = &b.bids
+	}
+	for x := range *side {
+		if (*side)[x] == o {
+			*side = append((*side)[:x], (*side)[x+1:]...)
+			return
+		}
+	}
+}
+
+// addTrade stores a public trade keeping the most recent trades
+func (b *book) addTrade(t trade.Data) {
+	b.trades = append(b.trades, t)
+	if len(b.trades) > paperTradeHistoryLimit {
+		b.trades = b.trades[len(b.trades)-paperTradeHistoryLimit:]
+	}
+}
+
+// snapshot aggregates the book into price levels
+func (b *book) snapshot(exchangeName string, verify bool) *orderbook.Base {
+	return &orderbook.Base{
+		Bids:            aggregate(b.bids),
+		Asks:            aggregate(b.asks),
+		Exchange:        exchangeName,
+		Pair:            b.pair,
+		Asset:           b.asset,
+		LastUpdated:     time.Now(),
+		VerifyOrderbook: verify,
+	}
+}
+
+// aggregate sums the remaining amounts of orders at each price
+func aggregate(orders []*restingOrder) orderbook.Items {
+	var levels orderbook.Items
+	for x := range orders {
+		if len(levels) > 0 && levels[len(levels)-1].Price == orders[x].price {
+			levels[len(levels)-1].Amount += orders[x].remaining
+			continue
+		}
+		levels = append(levels, orderbook.Item{
+			Price:  orders[x].price,
+			Amount: orders[x].remaining,
+		})
+	}
+	return levels
+}
+
+// removeExternal filters seeded liquidity from a side of the book
+func removeExternal(orders []*restingOrder) []*restingOrder {
+	target := orders[:0]
+	for x := range orders {
+		if !orders[x].external {
+			target = append(target, orders[x])
+		}
+	}
+	return target
+}
+
+// normaliseSide maps bid and ask sides to buy and sell
+func normaliseSide(s order.Side) order.Side {
+	switch s {
+	case order.Bid:
+		return order.Buy
+	case order.Ask:
+		return order.Sell
+	default:
+		return s
+	}
+}
+
+// copyDetail returns a copy of an order detail which is safe to hand out of
+// the engine
+func copyDetail(d *order.Detail) *order.Detail {
+	c := *d
+	c.Trades = append([]order.TradeHistory(nil), d.Trades...)
+	return &c
+}
@@ -0,0 +1,342 @@
+package paper
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	exchange "github.com/openware/irix"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
+	"github.com/openware/pkg/orderbook"
+	"github.com/openware/pkg/trade"
+)
+
+var _ exchange.IBotExchange = (*Paper)(nil)
+
+var btcusd = currency.NewPairWithDelimiter("BTC", "USD", "-")
+
+func newPaper(t *testing.T) *Paper {
+	t.Helper()
+	p := new(Paper)
+	p.SetDefaults()
+	p.Verbose = false
+	cfg, err := p.GetDefaultConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Enabled = true
+	cfg.Features.Enabled.Websocket = true
+	cfg.WebsocketTrafficTimeout = time.Second * 30
+	if err = p.Setup(cfg); err != nil {
+		t.Fatal(err)
+	}
+	p.Verbose = false
+	return p
+}
+
+func limit(side order.Side, price, amount float64) *order.Submit {
+	return &order.Submit{
+		Pair:      btcusd,
+		AssetType: asset.Spot,
+		Side:      side,
+		Type:      order.Limit,
+		Price:     price,
+		Amount:    amount,
+	}
+}
+
+func balances(t *testing.T, p *Paper) map[string]float64 {
+	t.Helper()
+	h, err := p.UpdateAccountInfo(asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := make(map[string]float64)
+	for _, c := range h.Accounts[0].Currencies {
+		m[c.CurrencyName.String()] = c.TotalValue
+		m[c.CurrencyName.String()+"-hold"] = c.Hold
+	}
+	return m
+}
+
+func equal(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestPriceTimePriority(t *testing.T) {
+	t.Parallel()
+	p := newPaper(t)
+	if err := p.Deposit(currency.BTC, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Deposit(currency.USD, 100000); err != nil {
+		t.Fatal(err)
+	}
+	first, err := p.SubmitOrder(limit(order.Sell, 100, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := p.SubmitOrder(limit(order.Sell, 100, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	better, err := p.SubmitOrder(limit(order.Sell, 99, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := p.SubmitOrder(limit(order.Buy, 100, 1.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.FullyMatched || len(resp.Trades) != 2 {
+		t.Fatalf("expected two fills, received %+v", resp)
+	}
+	if resp.Trades[0].Price != 99 || resp.Trades[1].Price != 100 {
+		t.Errorf("expected best price first, received %v then %v",
+			resp.Trades[0].Price, resp.Trades[1].Price)
+	}
+	if !equal(resp.Rate, (99+50)/1.5) {
+		t.Errorf("unexpected average rate %v", resp.Rate)
+	}
+
+	for _, tc := range []struct {
+		id       string
+		status   order.Status
+		executed float64
+	}{
+		{better.OrderID, order.Filled, 1},
+		{first.OrderID, order.PartiallyFilled, 0.5},
+		{second.OrderID, order.New, 0},
+	} {
+		d, err := p.GetOrderInfo(tc.id, btcusd, asset.Spot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Status != tc.status || !equal(d.ExecutedAmount, tc.executed) {
+			t.Errorf("order %s expected %s executed %v, received %s executed %v",
+				tc.id, tc.status, tc.executed, d.Status, d.ExecutedAmount)
+		}
+	}
+}
+
+func TestSettlement(t *testing.T) {
+	t.Parallel()
+	p := newPaper(t)
+	if err := p.Deposit(currency.BTC, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Deposit(currency.USD, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.SubmitOrder(limit(order.Sell, 100, 1)); err != nil {
+		t.Fatal(err)
+	}
+	// Buying below the resting ask rests and reserves the worst case fee
+	resting, err := p.SubmitOrder(limit(order.Buy, 90, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := balances(t, p)
+	if !equal(b["USD-hold"], 90*(1+DefaultTakerFee)) || !equal(b["BTC-hold"], 1) {
+		t.Fatalf("unexpected holds %v", b)
+	}
+	if err = p.CancelOrder(&order.Cancel{ID: resting.OrderID, Pair: btcusd, AssetType: asset.Spot}); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.CancelOrder(&order.Cancel{ID: resting.OrderID, Pair: btcusd, AssetType: asset.Spot}); !errors.Is(err, errOrderNotOpen) {
+		t.Errorf("expected %v, received %v", errOrderNotOpen, err)
+	}
+
+	if _, err = p.SubmitOrder(limit(order.Buy, 110, 1)); err != nil {
+		t.Fatal(err)
+	}
+	b = balances(t, p)
+	// The taker pays the maker's price plus the taker fee and the maker
+	// receives the proceeds less the maker fee
+	wantUSD := 1000 - 100*(1+DefaultTakerFee) + 100*(1-DefaultMakerFee)
+	if !equal(b["USD"], wantUSD) || !equal(b["BTC"], 1) {
+		t.Errorf("unexpected balances %v", b)
+	}
+	if !equal(b["USD-hold"], 0) || !equal(b["BTC-hold"], 0) {
+		t.Errorf("expected holds to be released, received %v", b)
+	}
+}
+
+func TestSubmitOrderErrors(t *testing.T) {
+	t.Parallel()
+	p := newPaper(t)
+	if err := p.Deposit(currency.USD, 100); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.SubmitOrder(limit(order.Buy, 100, 1)); !errors.Is(err, errInsufficientBalance) {
+		t.Errorf("expected %v, received %v", errInsufficientBalance, err)
+	}
+	market := limit(order.Buy, 0, 0.1)
+	market.Type = order.Market
+	if _, err := p.SubmitOrder(market); !errors.Is(err, errNoLiquidity) {
+		t.Errorf("expected %v, received %v", errNoLiquidity, err)
+	}
+
+	if err := p.AddLiquidity(btcusd, asset.Spot, order.Ask, 50, 1); err != nil {
+		t.Fatal(err)
+	}
+	postOnly := limit(order.Buy, 50, 0.5)
+	postOnly.PostOnly = true
+	if _, err := p.SubmitOrder(postOnly); !errors.Is(err, errPostOnlyWouldCross) {
+		t.Errorf("expected %v, received %v", errPostOnlyWouldCross, err)
+	}
+	fok := limit(order.Buy, 50, 1.5)
+	fok.FillOrKill = true
+	if _, err := p.SubmitOrder(fok); !errors.Is(err, errFillOrKillUnfilled) {
+		t.Errorf("expected %v, received %v", errFillOrKillUnfilled, err)
+	}
+	if err := p.AddLiquidity(btcusd, asset.Spot, order.Ask, 0, 1); !errors.Is(err, errInvalidLiquidityLevel) {
+		t.Errorf("expected %v, received %v", errInvalidLiquidityLevel, err)
+	}
+}
+
+func TestImmediateOrCancel(t *testing.T) {
+	t.Parallel()
+	p := newPaper(t)
+	if err := p.Deposit(currency.USD, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AddLiquidity(btcusd, asset.Spot, order.Ask, 100, 0.25); err != nil {
+		t.Fatal(err)
+	}
+	ioc := limit(order.Buy, 100, 1)
+	ioc.ImmediateOrCancel = true
+	ioc.ClientOrderID = "ioc-1"
+	if _, err := p.SubmitOrder(ioc); err != nil {
+		t.Fatal(err)
+	}
+	d, err := p.GetOrderInfoByClientOrderID("ioc-1", btcusd, asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Status != order.Cancelled || !equal(d.ExecutedAmount, 0.25) {
+		t.Errorf("expected cancelled remainder after 0.25 fill, received %s %v", d.Status, d.ExecutedAmount)
+	}
+	if b := balances(t, p); !equal(b["USD-hold"], 0) {
+		t.Errorf("expected hold to be released, received %v", b["USD-hold"])
+	}
+	ob, err := p.UpdateOrderbook(btcusd, asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ob.Asks) != 0 || len(ob.Bids) != 0 {
+		t.Errorf("expected empty book, received %+v", ob)
+	}
+	if _, err = p.GetOrderInfoByClientOrderID("missing", btcusd, asset.Spot); !errors.Is(err, exchange.ErrOrderNotFound) {
+		t.Errorf("expected %v, received %v", exchange.ErrOrderNotFound, err)
+	}
+}
+
+func TestModifyAndCancelAll(t *testing.T) {
+	t.Parallel()
+	p := newPaper(t)
+	if err := p.Deposit(currency.USD, 1000); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := p.SubmitOrder(limit(order.Buy, 100, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := p.ModifyOrder(&order.Modify{ID: resp.OrderID, Pair: btcusd, AssetType: asset.Spot, Price: 90})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id == resp.OrderID {
+		t.Error("expected a replacement order ID")
+	}
+	active, err := p.GetActiveOrders(&order.GetOrdersRequest{AssetType: asset.Spot})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(active) != 1 || active[0].Price != 90 {
+		t.Fatalf("expected one replacement order at 90, received %+v", active)
+	}
+	all, err := p.CancelAllOrders(&order.Cancel{Pair: btcusd, AssetType: asset.Spot})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all.Count != 1 {
+		t.Errorf("expected one cancellation, received %d", all.Count)
+	}
+	history, err := p.GetOrderHistory(&order.GetOrdersRequest{AssetType: asset.Spot})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Errorf("expected two closed orders, received %d", len(history))
+	}
+}
+
+func TestGetFeeByType(t *testing.T) {
+	t.Parallel()
+	p := newPaper(t)
+	fee, err := p.GetFeeByType(&exchange.FeeBuilder{
+		FeeType:       exchange.CryptocurrencyTradeFee,
+		PurchasePrice: 100,
+		Amount:        2,
+		IsMaker:       true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal(fee, 200*DefaultMakerFee) {
+		t.Errorf("expected %v, received %v", 200*DefaultMakerFee, fee)
+	}
+}
+
+func TestWebsocketEvents(t *testing.T) {
+	t.Parallel()
+	p := newPaper(t)
+	if err := p.Websocket.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := p.Websocket.Shutdown(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		if err := p.Deposit(currency.USD, 1000); err != nil {
+			done <- err
+			return
+		}
+		if err := p.AddLiquidity(btcusd, asset.Spot, order.Ask, 100, 1); err != nil {
+			done <- err
+			return
+		}
+		_, err := p.SubmitOrder(limit(order.Buy, 100, 1))
+		done <- err
+	}()
+
+	var gotBook, gotTrade, gotOrder bool
+	timeout := time.After(time.Second * 5)
+	for !(gotBook && gotTrade && gotOrder) {
+		select {
+		case data := <-p.Websocket.ToRoutine:
+			switch d := data.(type) {
+			case *orderbook.Base:
+				gotBook = true
+			case trade.Data:
+				gotTrade = d.Price == 100
+			case *order.Detail:
+				gotOrder = d.Status == order.Filled
+			}
+		case <-timeout:
+			t.Fatalf("timed out, book %v trade %v order %v", gotBook, gotTrade, gotOrder)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,93 @@
+package paper
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	exchange "github.com/openware/irix"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
+	"github.com/openware/pkg/trade"
+)
+
+const (
+	paperRESTURL      = "http://paper.local"
+	paperWebsocketURL = "ws://paper.local"
+
+	// DefaultMakerFee is the fee rate charged on resting orders when filled
+	DefaultMakerFee = 0.001
+	// DefaultTakerFee is the fee rate charged on orders which remove liquidity
+	DefaultTakerFee = 0.002
+
+	paperHeartbeatInterval = time.Second
+	paperTradeHistoryLimit = 1000
+)
+
+var (
+	errInsufficientBalance   = errors.New("insufficient balance")
+	errNoLiquidity           = errors.New("no liquidity to match order")
+	errPostOnlyWouldCross    = errors.New("post only order would cross the book")
+	errFillOrKillUnfilled    = errors.New("fill or kill order cannot be fully filled")
+	errLiquiditySourceUnset  = errors.New("liquidity source exchange not set")
+	errInvalidLiquidityLevel = errors.New("liquidity price and amount must be positive")
+	errOrderNotOpen          = errors.New("order is not open")
+	errStartAfterEnd         = errors.New("start time cannot be after end time")
+)
+
+// Paper is an in-memory exchange which matches orders locally with
+// price-time priority. Balances are simulated and no network calls are made
+// unless a liquidity source is set to seed the books.
+type Paper struct {
+	exchange.Base
+	// MakerFee and TakerFee are the fee rates charged in the quote currency
+	MakerFee float64
+	TakerFee float64
+	// Latency is applied before every order submission, amendment and
+	// cancellation to simulate a round trip to an exchange
+	Latency time.Duration
+	// LiquiditySource is an optional exchange whose orderbooks are copied
+	// into the local books as external liquidity
+	LiquiditySource exchange.IBotExchange
+
+	books          map[asset.Item]map[*currency.Item]map[*currency.Item]*book
+	balances       map[*currency.Item]*balance
+	orders         map[string]*restingOrder
+	clientOrderIDs map[string]string
+	sequence       int64
+	mtx            sync.Mutex
+}
+
+// balance is a simulated currency balance, hold is the amount reserved by
+// open orders
+type balance struct {
+	code  currency.Code
+	total float64
+	hold  float64
+}
+
+// book is a price-time priority orderbook for a single pair. Bids are sorted
+// highest price first and asks lowest price first, orders at the same price
+// are sorted by arrival.
+type book struct {
+	pair   currency.Pair
+	asset  asset.Item
+	bids   []*restingOrder
+	asks   []*restingOrder
+	trades []trade.Data
+}
+
+// restingOrder is an order in the matching engine. External orders are seeded
+// liquidity which have no detail or balances.
+type restingOrder struct {
+	detail    *order.Detail
+	external  bool
+	side      order.Side
+	price     float64
+	remaining float64
+	held      float64
+}
+
+// events holds data published to the websocket after the engine is unlocked
+type events []interface{}
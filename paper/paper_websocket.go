@@ -0,0 +1,48 @@
+package paper
+
+import (
+	"errors"
+	"time"
+
+	"github.com/openware/irix/stream"
+)
+
+// WsConnect starts the simulated websocket connection. There is no network
+// connection so a heartbeat stands in for inbound traffic.
+func (p *Paper) WsConnect() error {
+	if !p.Websocket.IsEnabled() || !p.IsEnabled() {
+		return errors.New(stream.WebsocketNotEnabled)
+	}
+	p.Websocket.Wg.Add(1)
+	go p.wsHeartbeat(p.Websocket.ShutdownC)
+	return nil
+}
+
+// wsHeartbeat alerts the traffic monitor until the websocket is shutdown
+func (p *Paper) wsHeartbeat(shutdown <-chan struct{}) {
+	defer p.Websocket.Wg.Done()
+	t := time.NewTicker(paperHeartbeatInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-shutdown:
+			return
+		case <-t.C:
+			select {
+			case p.Websocket.TrafficAlert <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// publish sends engine events to the websocket data handler, events are only
+// published while the websocket is connected
+func (p *Paper) publish(ev events) {
+	if p.Websocket == nil || !p.Websocket.IsConnected() {
+		return
+	}
+	for x := range ev {
+		p.Websocket.DataHandler <- ev[x]
+	}
+}
@@ -0,0 +1,651 @@
+package paper
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	exchange "github.com/openware/irix"
+	"github.com/openware/irix/config"
+	"github.com/openware/irix/portfolio/withdraw"
+	"github.com/openware/irix/protocol"
+	"github.com/openware/irix/stream"
+	"github.com/openware/irix/ticker"
+	"github.com/openware/pkg/account"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/common"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/kline"
+	"github.com/openware/pkg/log"
+	"github.com/openware/pkg/order"
+	"github.com/openware/pkg/orderbook"
+	"github.com/openware/pkg/request"
+	"github.com/openware/pkg/trade"
+)
+
+// GetDefaultConfig returns a default exchange config
+func (p *Paper) GetDefaultConfig() (*config.ExchangeConfig, error) {
+	p.SetDefaults()
+	exchCfg := new(config.ExchangeConfig)
+	exchCfg.Name = p.Name
+	exchCfg.HTTPTimeout = exchange.DefaultHTTPTimeout
+	exchCfg.BaseCurrencies = p.BaseCurrencies
+	err := p.SetupDefaults(exchCfg)
+	if err != nil {
+		return nil, err
+	}
+	return exchCfg, nil
+}
+
+// SetDefaults sets the default values for the paper trading exchange
+func (p *Paper) SetDefaults() {
+	p.Name = "Paper"
+	p.Enabled = true
+	p.Verbose = true
+	p.API.CredentialsValidator.RequiresKey = false
+	p.MakerFee = DefaultMakerFee
+	p.TakerFee = DefaultTakerFee
+	p.books = make(map[asset.Item]map[*currency.Item]map[*currency.Item]*book)
+	p.balances = make(map[*currency.Item]*balance)
+	p.orders = make(map[string]*restingOrder)
+	p.clientOrderIDs = make(map[string]string)
+
+	fmt1 := currency.PairStore{
+		RequestFormat: &currency.PairFormat{Uppercase: true, Delimiter: currency.DashDelimiter},
+		ConfigFormat:  &currency.PairFormat{Uppercase: true, Delimiter: currency.DashDelimiter},
+	}
+	err := p.StoreAssetPairFormat(asset.Spot, fmt1)
+	if err != nil {
+		log.Errorln(log.ExchangeSys, err)
+	}
+	defaultPairs := currency.Pairs{currency.NewPairWithDelimiter(currency.BTC.String(),
+		currency.USD.String(),
+		currency.DashDelimiter)}
+	p.CurrencyPairs.StorePairs(asset.Spot, defaultPairs, false)
+	p.CurrencyPairs.StorePairs(asset.Spot, defaultPairs, true)
+
+	p.Features = exchange.Features{
+		Supports: exchange.FeaturesSupported{
+			REST:      true,
+			Websocket: true,
+			RESTCapabilities: protocol.Features{
+				TickerFetching:    true,
+				TradeFetching:     true,
+				OrderbookFetching: true,
+				GetOrder:          true,
+				GetOrders:         true,
+				CancelOrders:      true,
+				CancelOrder:       true,
+				SubmitOrder:       true,
+				ModifyOrder:       true,
+				UserTradeHistory:  true,
+				TradeFee:          true,
+			},
+			WebsocketCapabilities: protocol.Features{
+				TickerFetching:         true,
+				TradeFetching:          true,
+				OrderbookFetching:      true,
+				AuthenticatedEndpoints: true,
+				AccountInfo:            true,
+				GetOrders:              true,
+			},
+			Kline: kline.ExchangeCapabilitiesSupported{
+				Intervals: true,
+			},
+		},
+		Enabled: exchange.FeaturesEnabled{
+			Kline: kline.ExchangeCapabilitiesEnabled{
+				Intervals: map[string]bool{
+					kline.OneMin.Word():     true,
+					kline.FiveMin.Word():    true,
+					kline.FifteenMin.Word(): true,
+					kline.OneHour.Word():    true,
+					kline.OneDay.Word():     true,
+				},
+				ResultLimit: paperTradeHistoryLimit,
+			},
+		},
+	}
+
+	p.Requester = request.New(p.Name,
+		common.NewHTTPClientWithTimeout(exchange.DefaultHTTPTimeout))
+	p.API.Endpoints = p.NewEndpoints()
+	err = p.API.Endpoints.SetDefaultEndpoints(map[exchange.URL]string{
+		exchange.RestSpot:      paperRESTURL,
+		exchange.WebsocketSpot: paperWebsocketURL,
+	})
+	if err != nil {
+		log.Errorln(log.ExchangeSys, err)
+	}
+	p.Websocket = stream.New()
+	p.WebsocketResponseMaxLimit = exchange.DefaultWebsocketResponseMaxLimit
+	p.WebsocketResponseCheckTimeout = exchange.DefaultWebsocketResponseCheckTimeout
+	p.WebsocketOrderbookBufferLimit = exchange.DefaultWebsocketOrderbookBufferLimit
+}
+
+// Setup takes in the supplied exchange configuration details and sets params
+func (p *Paper) Setup(exch *config.ExchangeConfig) error {
+	if !exch.Enabled {
+		p.SetEnabled(false)
+		return nil
+	}
+
+	err := p.SetupDefaults(exch)
+	if err != nil {
+		return err
+	}
+
+	wsURL, err := p.API.Endpoints.GetURL(exchange.WebsocketSpot)
+	if err != nil {
+		return err
+	}
+
+	return p.Websocket.Setup(&stream.WebsocketSetup{
+		Enabled:                          exch.Features.Enabled.Websocket,
+		Verbose:                          exch.Verbose,
+		AuthenticatedWebsocketAPISupport: true,
+		WebsocketTimeout:                 exch.WebsocketTrafficTimeout,
+		DefaultURL:                       paperWebsocketURL,
+		ExchangeName:                     exch.Name,
+		RunningURL:                       wsURL,
+		Connector:                        p.WsConnect,
+		Features:                         &p.Features.Supports.WebsocketCapabilities,
+		OrderbookBufferLimit:             exch.OrderbookConfig.WebsocketBufferLimit,
+		BufferEnabled:                    exch.OrderbookConfig.WebsocketBufferEnabled,
+	})
+}
+
+// Start starts the paper trading exchange
+func (p *Paper) Start(wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		p.Run()
+		wg.Done()
+	}()
+}
+
+// Run seeds the enabled pairs from the liquidity source when one is set
+func (p *Paper) Run() {
+	if p.Verbose {
+		p.PrintEnabledPairs()
+	}
+
+	if p.LiquiditySource == nil {
+		return
+	}
+
+	pairs, err := p.GetEnabledPairs(asset.Spot)
+	if err != nil {
+		log.Errorf(log.ExchangeSys,
+			"%s failed to get enabled pairs. Err: %s",
+			p.Name,
+			err)
+		return
+	}
+	for x := range pairs {
+		err = p.SeedLiquidity(pairs[x], asset.Spot)
+		if err != nil {
+			log.Errorf(log.ExchangeSys,
+				"%s failed to seed %s liquidity. Err: %s",
+				p.Name,
+				pairs[x],
+				err)
+		}
+	}
+}
+
+// FetchTradablePairs returns a list of the exchanges tradable pairs, these
+// are the liquidity source's pairs when one is set
+func (p *Paper) FetchTradablePairs(a asset.Item) ([]string, error) {
+	var pairs currency.Pairs
+	var err error
+	if p.LiquiditySource != nil {
+		pairs, err = p.LiquiditySource.GetAvailablePairs(a)
+	} else {
+		pairs, err = p.GetAvailablePairs(a)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pairs.Strings(), nil
+}
+
+// UpdateTradablePairs updates the exchanges available pairs and stores
+// them in the exchanges config
+func (p *Paper) UpdateTradablePairs(forceUpdate bool) error {
+	pairs, err := p.FetchTradablePairs(asset.Spot)
+	if err != nil {
+		return err
+	}
+	cp, err := currency.NewPairsFromStrings(pairs)
+	if err != nil {
+		return err
+	}
+	return p.UpdatePairs(cp, asset.Spot, false, forceUpdate)
+}
+
+// UpdateTicker updates and returns the ticker for a currency pair from the
+// local book and trades
+func (p *Paper) UpdateTicker(cp currency.Pair, a asset.Item) (*ticker.Price, error) {
+	p.mtx.Lock()
+	b := p.getBook(cp, a)
+	tick := &ticker.Price{
+		Pair:         cp,
+		ExchangeName: p.Name,
+		AssetType:    a,
+		LastUpdated:  time.Now(),
+	}
+	if len(b.bids) > 0 {
+		tick.Bid = b.bids[0].price
+	}
+	if len(b.asks) > 0 {
+		tick.Ask = b.asks[0].price
+	}
+	dayAgo := tick.LastUpdated.Add(-time.Hour * 24)
+	for x := range b.trades {
+		if b.trades[x].Timestamp.Before(dayAgo) {
+			continue
+		}
+		if tick.Open == 0 {
+			tick.Open = b.trades[x].Price
+		}
+		if b.trades[x].Price > tick.High {
+			tick.High = b.trades[x].Price
+		}
+		if tick.Low == 0 || b.trades[x].Price < tick.Low {
+			tick.Low = b.trades[x].Price
+		}
+		tick.Volume += b.trades[x].Amount
+		tick.QuoteVolume += b.trades[x].Amount * b.trades[x].Price
+	}
+	if len(b.trades) > 0 {
+		tick.Last = b.trades[len(b.trades)-1].Price
+		tick.Close = tick.Last
+	}
+	p.mtx.Unlock()
+
+	err := ticker.ProcessTicker(tick)
+	if err != nil {
+		return nil, err
+	}
+	return ticker.GetTicker(p.Name, cp, a)
+}
+
+// FetchTicker returns the ticker for a currency pair
+func (p *Paper) FetchTicker(cp currency.Pair, a asset.Item) (*ticker.Price, error) {
+	return p.UpdateTicker(cp, a)
+}
+
+// FetchOrderbook returns the orderbook for a currency pair
+func (p *Paper) FetchOrderbook(cp currency.Pair, a asset.Item) (*orderbook.Base, error) {
+	return p.UpdateOrderbook(cp, a)
+}
+
+// UpdateOrderbook updates and returns the orderbook for a currency pair from
+// the local book
+func (p *Paper) UpdateOrderbook(cp currency.Pair, a asset.Item) (*orderbook.Base, error) {
+	p.mtx.Lock()
+	ob := p.getBook(cp, a).snapshot(p.Name, p.CanVerifyOrderbook)
+	p.mtx.Unlock()
+	err := ob.Process()
+	if err != nil {
+		return ob, err
+	}
+	return orderbook.Get(p.Name, cp, a)
+}
+
+// UpdateAccountInfo retrieves the simulated balances
+func (p *Paper) UpdateAccountInfo(a asset.Item) (account.Holdings, error) {
+	response := account.Holdings{Exchange: p.Name}
+	p.mtx.Lock()
+	currencies := make([]account.Balance, 0, len(p.balances))
+	for _, bal := range p.balances {
+		currencies = append(currencies, account.Balance{
+			CurrencyName: bal.code,
+			TotalValue:   bal.total,
+			Hold:         bal.hold,
+		})
+	}
+	p.mtx.Unlock()
+	sort.Slice(currencies, func(i, j int) bool {
+		return currencies[i].CurrencyName.String() < currencies[j].CurrencyName.String()
+	})
+	response.Accounts = append(response.Accounts, account.SubAccount{
+		AssetType:  a,
+		Currencies: currencies,
+	})
+
+	err := account.Process(&response)
+	if err != nil {
+		return account.Holdings{}, err
+	}
+	return response, nil
+}
+
+// FetchAccountInfo retrieves the simulated balances
+func (p *Paper) FetchAccountInfo(a asset.Item) (account.Holdings, error) {
+	return p.UpdateAccountInfo(a)
+}
+
+// GetFundingHistory returns funding history, deposits and
+// withdrawals
+func (p *Paper) GetFundingHistory() ([]exchange.FundHistory, error) {
+	return nil, common.ErrFunctionNotSupported
+}
+
+// GetWithdrawalsHistory returns previous withdrawals data
+func (p *Paper) GetWithdrawalsHistory(c currency.Code) ([]exchange.WithdrawalHistory, error) {
+	return nil, common.ErrFunctionNotSupported
+}
+
+// GetRecentTrades returns the most recent trades for a currency and asset
+func (p *Paper) GetRecentTrades(cp currency.Pair, a asset.Item) ([]trade.Data, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	b := p.getBook(cp, a)
+	return append([]trade.Data(nil), b.trades...), nil
+}
+
+// GetHistoricTrades returns historic trade data within the timeframe provided
+func (p *Paper) GetHistoricTrades(cp currency.Pair, a asset.Item, timestampStart, timestampEnd time.Time) ([]trade.Data, error) {
+	if !timestampEnd.IsZero() && timestampStart.After(timestampEnd) {
+		return nil, errStartAfterEnd
+	}
+	trades, err := p.GetRecentTrades(cp, a)
+	if err != nil {
+		return nil, err
+	}
+	resp := trades[:0]
+	for x := range trades {
+		if trades[x].Timestamp.Before(timestampStart) || (!timestampEnd.IsZero() && trades[x].Timestamp.After(timestampEnd)) {
+			continue
+		}
+		resp = append(resp, trades[x])
+	}
+	return resp, nil
+}
+
+// SubmitOrder submits a new order to the matching engine
+func (p *Paper) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
+	var submitOrderResponse order.SubmitResponse
+	if err := s.Validate(); err != nil {
+		return submitOrderResponse, err
+	}
+	if err := p.ConformOrderToLimits(s); err != nil {
+		return submitOrderResponse, err
+	}
+	if err := exchange.AttachClientOrderID(p, s); err != nil {
+		return submitOrderResponse, err
+	}
+	time.Sleep(p.Latency)
+	submitOrderResponse, ev, err := p.submit(s)
+	if err != nil {
+		return submitOrderResponse, err
+	}
+	p.publish(ev)
+	return submitOrderResponse, p.AddTradesToBuffer(tradesFromEvents(ev)...)
+}
+
+// ModifyOrder cancels an open order and replaces it with the modified price
+// and amount, the replacement loses its queue position
+func (p *Paper) ModifyOrder(action *order.Modify) (string, error) {
+	if err := action.Validate(); err != nil {
+		return "", err
+	}
+	existing, err := p.GetOrderInfo(action.ID, action.Pair, action.AssetType)
+	if err != nil {
+		return "", err
+	}
+	err = p.CancelOrder(&order.Cancel{
+		ID:        action.ID,
+		Pair:      existing.Pair,
+		AssetType: existing.AssetType,
+	})
+	if err != nil {
+		return "", err
+	}
+	replacement := &order.Submit{
+		ImmediateOrCancel: existing.ImmediateOrCancel,
+		PostOnly:          existing.PostOnly,
+		Price:             existing.Price,
+		Amount:            existing.RemainingAmount,
+		ClientOrderID:     existing.ClientOrderID,
+		ClientID:          existing.ClientID,
+		Type:              existing.Type,
+		Side:              existing.Side,
+		AssetType:         existing.AssetType,
+		Pair:              existing.Pair,
+	}
+	if action.Price != 0 {
+		replacement.Price = action.Price
+	}
+	if action.Amount != 0 {
+		replacement.Amount = action.Amount
+	}
+	resp, err := p.SubmitOrder(replacement)
+	if err != nil {
+		return "", err
+	}
+	return resp.OrderID, nil
+}
+
+// CancelOrder cancels an open order by its order ID or client order ID
+func (p *Paper) CancelOrder(o *order.Cancel) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	id := o.ID
+	if id == "" {
+		p.mtx.Lock()
+		id = p.clientOrderIDs[o.ClientOrderID]
+		p.mtx.Unlock()
+	}
+	time.Sleep(p.Latency)
+	ev, err := p.cancel(id)
+	if err != nil {
+		return err
+	}
+	p.publish(ev)
+	return nil
+}
+
+// CancelBatchOrders cancels orders by their corresponding ID numbers
+func (p *Paper) CancelBatchOrders(o []order.Cancel) (order.CancelBatchResponse, error) {
+	resp := order.CancelBatchResponse{Status: make(map[string]string)}
+	for x := range o {
+		err := p.CancelOrder(&o[x])
+		if err != nil {
+			resp.Status[o[x].ID] = err.Error()
+			continue
+		}
+		resp.Status[o[x].ID] = order.Cancelled.String()
+	}
+	return resp, nil
+}
+
+// CancelAllOrders cancels all open orders, filtered by pair and asset type
+// when they are set
+func (p *Paper) CancelAllOrders(o *order.Cancel) (order.CancelAllResponse, error) {
+	resp := order.CancelAllResponse{Status: make(map[string]string)}
+	p.mtx.Lock()
+	var ids []string
+	for id, ro := range p.orders {
+		if ro.detail.Status != order.New && ro.detail.Status != order.PartiallyFilled {
+			continue
+		}
+		if o != nil && !o.Pair.IsEmpty() && !o.Pair.Equal(ro.detail.Pair) {
+			continue
+		}
+		if o != nil && o.AssetType != "" && o.AssetType != ro.detail.AssetType {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	p.mtx.Unlock()
+	for x := range ids {
+		ev, err := p.cancel(ids[x])
+		if err != nil {
+			resp.Status[ids[x]] = err.Error()
+			continue
+		}
+		p.publish(ev)
+		resp.Status[ids[x]] = order.Cancelled.String()
+		resp.Count++
+	}
+	return resp, nil
+}
+
+// GetOrderInfo returns order information based on order ID
+func (p *Paper) GetOrderInfo(orderID string, _ currency.Pair, _ asset.Item) (order.Detail, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	o, ok := p.orders[orderID]
+	if !ok {
+		return order.Detail{}, fmt.Errorf("%s %w: %s", p.Name, exchange.ErrOrderNotFound, orderID)
+	}
+	return *copyDetail(o.detail), nil
+}
+
+// GetOrderInfoByClientOrderID returns order information based on the client
+// order ID supplied on submission
+func (p *Paper) GetOrderInfoByClientOrderID(clientOrderID string, pair currency.Pair, a asset.Item) (order.Detail, error) {
+	p.mtx.Lock()
+	id, ok := p.clientOrderIDs[clientOrderID]
+	p.mtx.Unlock()
+	if !ok {
+		return order.Detail{}, fmt.Errorf("%s %w: client order ID %s", p.Name, exchange.ErrOrderNotFound, clientOrderID)
+	}
+	return p.GetOrderInfo(id, pair, a)
+}
+
+// GetDepositAddress returns a deposit address for a specified currency
+func (p *Paper) GetDepositAddress(_ currency.Code, _ string) (string, error) {
+	return "", common.ErrFunctionNotSupported
+}
+
+// WithdrawCryptocurrencyFunds returns a withdrawal ID when a withdrawal is
+// submitted
+func (p *Paper) WithdrawCryptocurrencyFunds(_ *withdraw.Request) (*withdraw.ExchangeResponse, error) {
+	return nil, common.ErrFunctionNotSupported
+}
+
+// WithdrawFiatFunds returns a withdrawal ID when a withdrawal is
+// submitted
+func (p *Paper) WithdrawFiatFunds(_ *withdraw.Request) (*withdraw.ExchangeResponse, error) {
+	return nil, common.ErrFunctionNotSupported
+}
+
+// WithdrawFiatFundsToInternationalBank returns a withdrawal ID when a
+// withdrawal is submitted
+func (p *Paper) WithdrawFiatFundsToInternationalBank(_ *withdraw.Request) (*withdraw.ExchangeResponse, error) {
+	return nil, common.ErrFunctionNotSupported
+}
+
+// GetActiveOrders retrieves any orders that are active/open
+func (p *Paper) GetActiveOrders(req *order.GetOrdersRequest) ([]order.Detail, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	orders := p.filterOrders(func(d *order.Detail) bool {
+		return d.Status == order.New || d.Status == order.PartiallyFilled
+	})
+	order.FilterOrdersByCurrencies(&orders, req.Pairs)
+	order.FilterOrdersBySide(&orders, req.Side)
+	order.FilterOrdersByType(&orders, req.Type)
+	order.FilterOrdersByTimeRange(&orders, req.StartTime, req.EndTime)
+	return orders, nil
+}
+
+// GetOrderHistory retrieves account order information
+// Can Limit response to specific order status
+func (p *Paper) GetOrderHistory(req *order.GetOrdersRequest) ([]order.Detail, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	orders := p.filterOrders(func(d *order.Detail) bool {
+		return d.Status != order.New && d.Status != order.PartiallyFilled
+	})
+	order.FilterOrdersByCurrencies(&orders, req.Pairs)
+	order.FilterOrdersBySide(&orders, req.Side)
+	order.FilterOrdersByType(&orders, req.Type)
+	order.FilterOrdersByTimeRange(&orders, req.StartTime, req.EndTime)
+	return orders, nil
+}
+
+// GetFeeByType returns the fee for a trade, all other fee types are free
+func (p *Paper) GetFeeByType(feeBuilder *exchange.FeeBuilder) (float64, error) {
+	if feeBuilder == nil {
+		return 0, fmt.Errorf("%s fee builder is nil", p.Name)
+	}
+	switch feeBuilder.FeeType {
+	case exchange.CryptocurrencyTradeFee, exchange.OfflineTradeFee:
+		rate := p.TakerFee
+		if feeBuilder.IsMaker {
+			rate = p.MakerFee
+		}
+		return rate * feeBuilder.PurchasePrice * feeBuilder.Amount, nil
+	default:
+		return 0, nil
+	}
+}
+
+// ValidateCredentials validates current credentials used for wrapper
+// functionality, the paper exchange requires no credentials
+func (p *Paper) ValidateCredentials(_ asset.Item) error {
+	return nil
+}
+
+// GetHistoricCandles returns candles built from the local trade history
+func (p *Paper) GetHistoricCandles(pair currency.Pair, a asset.Item, start, end time.Time, interval kline.Interval) (kline.Item, error) {
+	if err := p.ValidateKline(pair, a, interval); err != nil {
+		return kline.Item{}, err
+	}
+	trades, err := p.GetHistoricTrades(pair, a, start, end)
+	if err != nil {
+		return kline.Item{}, err
+	}
+	history := make([]order.TradeHistory, len(trades))
+	for x := range trades {
+		history[x] = order.TradeHistory{
+			Price:     trades[x].Price,
+			Amount:    trades[x].Amount,
+			Exchange:  p.Name,
+			TID:       trades[x].TID,
+			Side:      trades[x].Side,
+			Timestamp: trades[x].Timestamp,
+		}
+	}
+	return kline.CreateKline(history, interval, pair, a, p.Name)
+}
+
+// GetHistoricCandlesExtended returns candles built from the local trade
+// history
+func (p *Paper) GetHistoricCandlesExtended(pair currency.Pair, a asset.Item, start, end time.Time, interval kline.Interval) (kline.Item, error) {
+	return p.GetHistoricCandles(pair, a, start, end, interval)
+}
+
+// filterOrders returns copies of the orders matching the supplied check
+func (p *Paper) filterOrders(check func(*order.Detail) bool) []order.Detail {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	var orders []order.Detail
+	for _, o := range p.orders {
+		if check(o.detail) {
+			orders = append(orders, *copyDetail(o.detail))
+		}
+	}
+	order.SortOrdersByDate(&orders, false)
+	return orders
+}
+
+// tradesFromEvents returns the public trades held in a set of engine events
+func tradesFromEvents(ev events) []trade.Data {
+	var trades []trade.Data
+	for x := range ev {
+		if t, ok := ev[x].(trade.Data); ok {
+			trades = append(trades, t)
+		}
+	}
+	return trades
+}
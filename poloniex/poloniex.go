@@ -12,6 +12,8 @@ import (
 	"time"
 
 	exchange "github.com/openware/irix"
+	"github.com/openware/irix/stream/buffer"
+	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/common/crypto"
 	"github.com/openware/pkg/currency"
 	"github.com/openware/pkg/order"
@@ -50,11 +52,16 @@ const (
 	poloniexLendingHistory       = "returnLendingHistory"
 	poloniexAutoRenew            = "toggleAutoRenew"
 	poloniexMaxOrderbookDepth    = 100
+
+	// Rates and amounts are accepted to eight decimal places
+	poloniexDecimalPlaces = 8
 )
 
 // Poloniex is the overarching type across the poloniex package
 type Poloniex struct {
 	exchange.Base
+	// obm fetches websocket orderbooks via REST when their sequence breaks
+	obm *buffer.SyncManager
 }
 
 // GetTicker returns current ticker information
@@ -69,6 +76,39 @@ func (p *Poloniex) GetTicker() (map[string]Ticker, error) {
 	return resp.Data, p.SendHTTPRequest(exchange.RestSpot, path, &resp.Data)
 }
 
+// FetchExchangeLimits returns the order execution limits of every market
+// that is not frozen. Poloniex publishes no per market precision, so every
+// market uses its fixed decimal places and minimum order total.
+func (p *Poloniex) FetchExchangeLimits(a asset.Item) ([]order.MinMaxLevel, error) {
+	if a != asset.Spot {
+		return nil, fmt.Errorf("%s %w", a, asset.ErrNotSupported)
+	}
+	tickers, err := p.GetTicker()
+	if err != nil {
+		return nil, err
+	}
+	step := exchange.StepFromDecimalPlaces(poloniexDecimalPlaces)
+	limits := make([]order.MinMaxLevel, 0, len(tickers))
+	for symbol, t := range tickers {
+		if t.IsFrozen != 0 {
+			continue
+		}
+		var cp currency.Pair
+		cp, err = currency.NewPairFromString(symbol)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, order.MinMaxLevel{
+			Pair:        cp,
+			Asset:       a,
+			StepPrice:   step,
+			StepAmount:  step,
+			MinNotional: minimumOrderTotals[cp.Base.Item],
+		})
+	}
+	return limits, nil
+}
+
 // GetVolume returns a list of currencies with associated volume
 func (p *Poloniex) GetVolume() (interface{}, error) {
 	var resp interface{}
@@ -119,6 +159,7 @@ func (p *Poloniex) GetOrderbook(currencyPair string, depth int) (OrderbookAll, e
 				Amount: resp.Bids[x][1].(float64),
 			})
 		}
+		ob.Seq = resp.Seq
 		oba.Data[currencyPair] = ob
 	} else {
 		vals.Set("currencyPair", "all")
@@ -151,6 +192,7 @@ func (p *Poloniex) GetOrderbook(currencyPair string, depth int) (OrderbookAll, e
 					Amount: orderbook.Bids[x][1].(float64),
 				})
 			}
+			ob.Seq = orderbook.Seq
 			oba.Data[currency] = ob
 		}
 	}
@@ -495,7 +537,7 @@ func (p *Poloniex) GetAuthenticatedOrderTrades(orderID string) (o []OrderTrade,
 }
 
 // PlaceOrder places a new order on the exchange
-func (p *Poloniex) PlaceOrder(currency string, rate, amount float64, immediate, fillOrKill, buy bool) (OrderResponse, error) {
+func (p *Poloniex) PlaceOrder(currency string, rate, amount float64, immediate, fillOrKill, buy bool, clientOrderID string) (OrderResponse, error) {
 	result := OrderResponse{}
 	values := url.Values{}
 
@@ -518,6 +560,10 @@ func (p *Poloniex) PlaceOrder(currency string, rate, amount float64, immediate,
 		values.Set("fillOrKill", "1")
 	}
 
+	if clientOrderID != "" {
+		values.Set("clientOrderId", clientOrderID)
+	}
+
 	return result, p.SendAuthenticatedHTTPRequest(exchange.RestSpot, http.MethodPost, orderType, values, &result)
 }
 
@@ -1,6 +1,7 @@
 package poloniex
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 	"testing"
@@ -369,12 +370,13 @@ func TestSubmitOrder(t *testing.T) {
 			Base:      currency.BTC,
 			Quote:     currency.LTC,
 		},
-		Side:      order.Buy,
-		Type:      order.Market,
-		Price:     10,
-		Amount:    10000000,
-		ClientID:  "hi",
-		AssetType: asset.Spot,
+		Side:          order.Buy,
+		Type:          order.Market,
+		Price:         10,
+		Amount:        10000000,
+		ClientID:      "hi",
+		ClientOrderID: "1",
+		AssetType:     asset.Spot,
 	}
 
 	response, err := p.SubmitOrder(orderSubmission)
@@ -616,6 +618,22 @@ func TestWsPriceAggregateOrderbook(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
+
+	// The changes of a message following on from the snapshot are applied
+	// together
+	pressXToJSON = []byte(`[148,827987829,[["o",1,"0.02300000","1.00000000"],["o",1,"0.02200000","2.00000000"]]]`)
+	err = p.wsHandleData(pressXToJSON)
+	if err != nil {
+		t.Error(err)
+	}
+	book, err := p.Websocket.Orderbook.GetOrderbook(currency.NewPair(currency.BTC, currency.ETH), asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.LastUpdateID != 827987829 || len(book.Bids) != 51 {
+		t.Errorf("expected both changes applied at sequence 827987829, received %d bids at %d",
+			len(book.Bids), book.LastUpdateID)
+	}
 }
 func TestWsHandleAccountData(t *testing.T) {
 	t.Parallel()
@@ -712,3 +730,24 @@ func TestGetHistoricTrades(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	err := p.UpdateOrderExecutionLimits(asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	limits, err := p.GetOrderExecutionLimits(asset.Spot, currency.NewPair(currency.BTC, currency.LTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = limits.Conforms(0.005, 1, order.Limit); err != nil {
+		t.Error(err)
+	}
+	if err = limits.Conforms(0.005, 0.01, order.Limit); !errors.Is(err, order.ErrNotionalValue) {
+		t.Errorf("received: %v but expected: %v", err, order.ErrNotionalValue)
+	}
+	if err = limits.Conforms(0.005, 1.000000001, order.Limit); !errors.Is(err, order.ErrAmountExceedsStep) {
+		t.Errorf("received: %v but expected: %v", err, order.ErrAmountExceedsStep)
+	}
+}
@@ -55,6 +55,8 @@ type OrderbookAll struct {
 type Orderbook struct {
 	Asks []OrderbookItem `json:"asks"`
 	Bids []OrderbookItem `json:"bids"`
+	// Seq is the websocket sequence number the book was taken at
+	Seq int64 `json:"seq"`
 }
 
 // TradeHistory holds trade history data
@@ -377,6 +379,16 @@ type WsTrade struct {
 	Timestamp int64
 }
 
+// minimumOrderTotals holds the smallest order total accepted by the markets
+// quoted in each currency
+var minimumOrderTotals = map[*currency.Item]float64{
+	currency.BTC.Item:  0.0001,
+	currency.ETH.Item:  0.0001,
+	currency.XMR.Item:  0.0001,
+	currency.USDT.Item: 1,
+	currency.USDC.Item: 1,
+}
+
 // WithdrawalFees the large list of predefined withdrawal fees
 // Prone to change, using highest value
 var WithdrawalFees = map[currency.Code]float64{
@@ -29,6 +29,11 @@ const (
 	wsTickerDataID           = 1002
 	ws24HourExchangeVolumeID = 1003
 	wsHeartbeat              = 1010
+	// wsOrderbookBufferLimit is the number of updates staged per book while
+	// it is fetched via REST
+	wsOrderbookBufferLimit = 150
+	// wsOrderbookWorkers is the number of books fetched via REST at once
+	wsOrderbookWorkers = 5
 )
 
 var (
@@ -274,6 +279,8 @@ func (p *Poloniex) wsHandleData(respRaw []byte) error {
 				return p.wsHandleTickerData(data)
 			default:
 				subData := data[2].([]interface{})
+				// A message's book changes share its sequence number
+				var changes [][]interface{}
 				for x := range subData {
 					dataL2 := subData[x]
 
@@ -293,23 +300,17 @@ func (p *Poloniex) wsHandleData(respRaw []byte) error {
 								p.Name)
 						}
 
-						err = p.WsProcessOrderbookSnapshot(orderbookData,
+						err = p.WsProcessOrderbookSnapshot(int64(data[1].(float64)),
+							orderbookData,
 							currencyPair)
 						if err != nil {
 							return err
 						}
 					case "o":
-						currencyPair := currencyIDMap[channelID]
-						dataL3 := dataL2.([]interface{})
-						err = p.WsProcessOrderbookUpdate(int64(data[1].(float64)),
-							dataL3,
-							currencyPair)
-						if err != nil {
-							return err
-						}
+						changes = append(changes, dataL2.([]interface{}))
 					case "t":
 						if !p.IsSaveTradeDataEnabled() {
-							return nil
+							continue
 						}
 						currencyPair := currencyIDMap[channelID]
 						var t WsTrade
@@ -355,7 +356,7 @@ func (p *Poloniex) wsHandleData(respRaw []byte) error {
 							return err
 						}
 
-						return p.AddTradesToBuffer(trade.Data{
+						err = p.AddTradesToBuffer(trade.Data{
 							TID:          strconv.FormatInt(t.TradeID, 10),
 							Exchange:     p.Name,
 							CurrencyPair: pair,
@@ -365,11 +366,19 @@ func (p *Poloniex) wsHandleData(respRaw []byte) error {
 							Amount:       t.Volume,
 							Timestamp:    time.Unix(t.Timestamp, 0),
 						})
+						if err != nil {
+							return err
+						}
 					default:
 						p.Websocket.DataHandler <- stream.UnhandledMessageWarning{Message: p.Name + stream.UnhandledMessage + string(respRaw)}
 						return nil
 					}
 				}
+				if len(changes) != 0 {
+					return p.WsProcessOrderbookUpdate(int64(data[1].(float64)),
+						changes,
+						currencyIDMap[channelID])
+				}
 			}
 		}
 	}
@@ -462,7 +471,7 @@ func (p *Poloniex) wsHandleTickerData(data []interface{}) error {
 
 // WsProcessOrderbookSnapshot processes a new orderbook snapshot into a local
 // of orderbooks
-func (p *Poloniex) WsProcessOrderbookSnapshot(ob []interface{}, symbol string) error {
+func (p *Poloniex) WsProcessOrderbookSnapshot(sequenceNumber int64, ob []interface{}, symbol string) error {
 	if len(ob) != 2 {
 		return errors.New("incorrect orderbook data returned")
 	}
@@ -514,35 +523,70 @@ func (p *Poloniex) WsProcessOrderbookSnapshot(ob []interface{}, symbol string) e
 		return err
 	}
 	book.Exchange = p.Name
+	book.LastUpdateID = sequenceNumber
 
-	return p.Websocket.Orderbook.LoadSnapshot(&book)
+	return p.obm.LoadSnapshot(&book)
 }
 
-// WsProcessOrderbookUpdate processes new orderbook updates
-func (p *Poloniex) WsProcessOrderbookUpdate(sequenceNumber int64, target []interface{}, symbol string) error {
+// WsProcessOrderbookUpdate processes the orderbook changes of a message,
+// which share its sequence number
+func (p *Poloniex) WsProcessOrderbookUpdate(sequenceNumber int64, changes [][]interface{}, symbol string) error {
 	cP, err := currency.NewPairFromString(symbol)
 	if err != nil {
 		return err
 	}
-	price, err := strconv.ParseFloat(target[2].(string), 64)
-	if err != nil {
-		return err
-	}
-	volume, err := strconv.ParseFloat(target[3].(string), 64)
-	if err != nil {
-		return err
-	}
 	update := &buffer.Update{
 		Pair:     cP,
 		Asset:    asset.Spot,
 		UpdateID: sequenceNumber,
 	}
-	if target[1].(float64) == 1 {
-		update.Bids = []orderbook.Item{{Price: price, Amount: volume}}
-	} else {
-		update.Asks = []orderbook.Item{{Price: price, Amount: volume}}
+	for _, target := range changes {
+		price, err := strconv.ParseFloat(target[2].(string), 64)
+		if err != nil {
+			return err
+		}
+		volume, err := strconv.ParseFloat(target[3].(string), 64)
+		if err != nil {
+			return err
+		}
+		if target[1].(float64) == 1 {
+			update.Bids = append(update.Bids, orderbook.Item{Price: price, Amount: volume})
+		} else {
+			update.Asks = append(update.Asks, orderbook.Item{Price: price, Amount: volume})
+		}
+	}
+	return p.obm.Update(update)
+}
+
+// fetchOrderbook fetches a book via REST carrying the websocket sequence
+// number it was taken at
+func (p *Poloniex) fetchOrderbook(c currency.Pair, assetType asset.Item) (*orderbook.Base, error) {
+	fpair, err := p.FormatExchangeCurrency(c, assetType)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.GetOrderbook(fpair.String(), poloniexMaxOrderbookDepth)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := resp.Data[fpair.String()]
+	if !ok {
+		return nil, fmt.Errorf("%s orderbook %s not returned", p.Name, fpair)
+	}
+	book := &orderbook.Base{
+		Exchange:        p.Name,
+		Pair:            c,
+		Asset:           assetType,
+		LastUpdateID:    data.Seq,
+		VerifyOrderbook: p.CanVerifyOrderbook,
+	}
+	for i := range data.Bids {
+		book.Bids = append(book.Bids, orderbook.Item{Price: data.Bids[i].Price, Amount: data.Bids[i].Amount})
+	}
+	for i := range data.Asks {
+		book.Asks = append(book.Asks, orderbook.Item{Price: data.Asks[i].Price, Amount: data.Asks[i].Amount})
 	}
-	return p.Websocket.Orderbook.Update(update)
+	return book, nil
 }
 
 // GenerateDefaultSubscriptions Adds default subscriptions to websocket to be handled by ManageSubscriptions()
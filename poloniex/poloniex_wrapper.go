@@ -2,6 +2,7 @@ package poloniex
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
@@ -13,6 +14,7 @@ import (
 	"github.com/openware/irix/portfolio/withdraw"
 	"github.com/openware/irix/protocol"
 	"github.com/openware/irix/stream"
+	"github.com/openware/irix/stream/buffer"
 	"github.com/openware/irix/ticker"
 	"github.com/openware/pkg/account"
 	"github.com/openware/pkg/asset"
@@ -179,11 +181,20 @@ func (p *Poloniex) Setup(exch *config.ExchangeConfig) error {
 		BufferEnabled:                    exch.OrderbookConfig.WebsocketBufferEnabled,
 		SortBuffer:                       true,
 		SortBufferByUpdateIDs:            true,
+		OrderbookResync:                  p.resyncOrderbook,
 	})
 	if err != nil {
 		return err
 	}
 
+	p.obm, err = buffer.NewSyncManager(&p.Websocket.Orderbook,
+		p.fetchOrderbook,
+		wsOrderbookBufferLimit,
+		wsOrderbookWorkers)
+	if err != nil {
+		return err
+	}
+
 	return p.Websocket.SetupNewConnection(stream.ConnectionSetup{
 		ResponseCheckTimeout: exch.WebsocketResponseCheckTimeout,
 		ResponseMaxLimit:     exch.WebsocketResponseMaxLimit,
@@ -228,6 +239,20 @@ func (p *Poloniex) Run() {
 		forceUpdate = true
 	}
 
+	a := p.GetAssetTypes()
+	for x := range a {
+		if err := p.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
+			err = p.UpdateOrderExecutionLimits(a[x])
+			p.SetOrderExecutionLimitsLoadError(a[x], err)
+			if err != nil {
+				log.Errorf(log.ExchangeSys,
+					"Could not set %s exchange exchange limits: %v",
+					p.Name,
+					err)
+			}
+		}
+	}
+
 	if !p.GetEnabledFeatures().AutoPairUpdates && !forceUpdate {
 		return
 	}
@@ -511,6 +536,12 @@ func (p *Poloniex) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 	if err := s.Validate(); err != nil {
 		return submitOrderResponse, err
 	}
+	if err := p.ConformOrderToLimits(s); err != nil {
+		return submitOrderResponse, err
+	}
+	if err := exchange.AttachClientOrderID(p, s); err != nil {
+		return submitOrderResponse, err
+	}
 
 	fPair, err := p.FormatExchangeCurrency(s.Pair, s.AssetType)
 	if err != nil {
@@ -524,7 +555,8 @@ func (p *Poloniex) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 		s.Amount,
 		false,
 		fillOrKill,
-		isBuyOrder)
+		isBuyOrder,
+		s.ClientOrderID)
 	if err != nil {
 		return submitOrderResponse, err
 	}
@@ -539,6 +571,12 @@ func (p *Poloniex) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 	return submitOrderResponse, nil
 }
 
+// GenerateClientOrderID returns a client order ID which is a positive 64 bit
+// integer as clientOrderId requires
+func (p *Poloniex) GenerateClientOrderID() (string, error) {
+	return exchange.GenerateNumericClientOrderID(math.MaxInt64)
+}
+
 // ModifyOrder will allow of changing orderbook placement and limit to
 // market conversion
 func (p *Poloniex) ModifyOrder(action *order.Modify) (string, error) {
@@ -832,6 +870,15 @@ func (p *Poloniex) GetOrderHistory(req *order.GetOrdersRequest) ([]order.Detail,
 	return orders, nil
 }
 
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (p *Poloniex) UpdateOrderExecutionLimits(a asset.Item) error {
+	limits, err := p.FetchExchangeLimits(a)
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return p.LoadLimits(limits)
+}
+
 // ValidateCredentials validates current credentials used for wrapper
 // functionality
 func (p *Poloniex) ValidateCredentials(assetType asset.Item) error {
@@ -886,3 +933,9 @@ func (p *Poloniex) GetHistoricCandles(pair currency.Pair, a asset.Item, start, e
 func (p *Poloniex) GetHistoricCandlesExtended(pair currency.Pair, a asset.Item, start, end time.Time, interval kline.Interval) (kline.Item, error) {
 	return p.GetHistoricCandles(pair, a, start, end, interval)
 }
+
+// resyncOrderbook fetches a websocket orderbook via REST once it falls out of
+// sync
+func (p *Poloniex) resyncOrderbook(c currency.Pair, a asset.Item) {
+	p.obm.Resync(c, a)
+}
@@ -0,0 +1,143 @@
+//go:build !windows
+// +build !windows
+
+package ratelimit
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var errInvalidKey = errors.New("rate limit key must be a valid file name")
+
+// FileStore persists the state of each key as JSON in a directory. Every
+// update holds an exclusive lock on the key's lock file so processes sharing
+// the directory draw from one budget. A missing or corrupt state file is
+// treated as empty as losing rate limit state only costs pacing accuracy.
+type FileStore struct {
+	dir string
+	// mtx avoids lock file contention between goroutines of this process
+	mtx sync.Mutex
+}
+
+// NewFileStore returns a store backed by dir, creating it if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		return nil, errStorePathEmpty
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// fileName maps a key to a file name, keys carry the host after an @ so only
+// path separators are refused
+func fileName(key string) (string, error) {
+	if key == "" {
+		return "", errKeyEmpty
+	}
+	if strings.ContainsAny(key, `/\`) || key == "." || key == ".." {
+		return "", errInvalidKey
+	}
+	return strings.ReplaceAll(key, ":", "_"), nil
+}
+
+// update applies fn to the state of key under the file lock and writes it
+// back when write is set
+func (f *FileStore) update(key string, write bool, fn func(*State)) error {
+	name, err := fileName(key)
+	if err != nil {
+		return err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	lock, err := os.OpenFile(filepath.Join(f.dir, name+".lock"), os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+	if err = syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(lock.Fd()), syscall.LOCK_UN) // nolint:errcheck // closing the file releases the lock regardless
+
+	path := filepath.Join(f.dir, name+".json")
+	var s State
+	contents, err := ioutil.ReadFile(path)
+	switch {
+	case err == nil:
+		if json.Unmarshal(contents, &s) != nil {
+			s = State{}
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+	if s.Usage == nil {
+		s.Usage = make(map[string]Usage)
+	}
+	fn(&s)
+	if !write {
+		return nil
+	}
+	return f.write(path, &s)
+}
+
+// write replaces the state file through a temporary file
+func (f *FileStore) write(path string, s *State) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(f.dir, filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Reserve counts a request against the buckets of key
+func (f *FileStore) Reserve(key string, buckets []string, now time.Time) (time.Duration, error) {
+	var wait time.Duration
+	err := f.update(key, true, func(s *State) {
+		wait = s.reserve(now, buckets)
+	})
+	return wait, err
+}
+
+// Observe merges a response into the state of key
+func (f *FileStore) Observe(key string, o Observation) error {
+	return f.update(key, true, func(s *State) {
+		s.observe(o)
+	})
+}
+
+// State returns the state of key
+func (f *FileStore) State(key string) (State, error) {
+	var c State
+	err := f.update(key, false, func(s *State) {
+		c = copyState(s)
+	})
+	return c, err
+}
+
+// Close is a no-op as no files are held open between calls
+func (f *FileStore) Close() error {
+	return nil
+}
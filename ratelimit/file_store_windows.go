@@ -0,0 +1,39 @@
+//go:build windows
+// +build windows
+
+package ratelimit
+
+import (
+	"errors"
+	"time"
+)
+
+var errFileStoreUnsupported = errors.New("file rate limit store is not supported on windows")
+
+// FileStore is unavailable on windows as it relies on flock
+type FileStore struct{}
+
+// NewFileStore returns an error on windows
+func NewFileStore(dir string) (*FileStore, error) {
+	return nil, errFileStoreUnsupported
+}
+
+// Reserve returns an error on windows
+func (f *FileStore) Reserve(key string, buckets []string, now time.Time) (time.Duration, error) {
+	return 0, errFileStoreUnsupported
+}
+
+// Observe returns an error on windows
+func (f *FileStore) Observe(key string, o Observation) error {
+	return errFileStoreUnsupported
+}
+
+// State returns an error on windows
+func (f *FileStore) State(key string) (State, error) {
+	return State{}, errFileStoreUnsupported
+}
+
+// Close is a no-op on windows
+func (f *FileStore) Close() error {
+	return nil
+}
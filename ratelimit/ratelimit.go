@@ -0,0 +1,324 @@
+// Package ratelimit adapts request pacing to the usage exchanges report in
+// their responses. It complements the fixed token buckets in each exchange's
+// ratelimit.go: reported usage tightens pacing as it nears the cap,
+// Retry-After is honoured and rate limit or ban responses pause every
+// request. State can be shared by processes using the same API key through a
+// file or socket store.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openware/pkg/common"
+	"github.com/openware/pkg/request"
+)
+
+const (
+	// TightenAt is the fraction of a reported cap above which requests are
+	// spread over the rest of the window
+	TightenAt = 0.8
+	// DefaultBackoff is the first pause after a rate limit response without
+	// Retry-After, it doubles on every consecutive one
+	DefaultBackoff = time.Second
+	// MaxBackoff caps the pause after consecutive rate limit responses
+	MaxBackoff = time.Minute
+	// DefaultBanBackoff is the pause after a ban response without
+	// Retry-After
+	DefaultBanBackoff = time.Minute * 2
+
+	// StatusBanned is returned by Binance once an IP keeps sending requests
+	// after being rate limited
+	StatusBanned = 418
+
+	// genericBucket stores usage reported by X-RateLimit-* headers
+	genericBucket     = "x-ratelimit"
+	headerRemaining   = "X-RateLimit-Remaining"
+	headerLimit       = "X-RateLimit-Limit"
+	headerReset       = "X-RateLimit-Reset"
+	unixResetBoundary = 1e9
+)
+
+var (
+	errKeyEmpty          = errors.New("rate limit key cannot be empty")
+	errStoreNil          = errors.New("rate limit store cannot be nil")
+	errInvalidBucket     = errors.New("rate limit bucket requires a header, window and cap")
+	errDeadlineExceeded  = errors.New("rate limit wait would exceed the request deadline")
+	errInvalidStoreKind  = errors.New("invalid rate limit store")
+	errStorePathEmpty    = errors.New("rate limit store path cannot be empty")
+	errServerClosed      = errors.New("rate limit server closed")
+	errSocketInUse       = errors.New("rate limit socket already has a server listening")
+	errUnknownSocketVerb = errors.New("unknown rate limit request")
+)
+
+// Bucket describes a usage counter an exchange reports in a response header,
+// such as Binance's X-MBX-USED-WEIGHT-1M. Windows are aligned to the clock,
+// a one minute window resets on the minute.
+type Bucket struct {
+	Header string
+	Window time.Duration
+	Cap    int64
+	// Host restricts the bucket to requests to one host, empty matches every
+	// host
+	Host string
+	// Paths restricts the bucket to requests whose path starts with one of
+	// the prefixes, empty matches every path
+	Paths []string
+}
+
+// matches reports whether a request to host and path counts against the
+// bucket
+func (b *Bucket) matches(host, path string) bool {
+	if b.Host != "" && b.Host != host {
+		return false
+	}
+	if len(b.Paths) == 0 {
+		return true
+	}
+	for i := range b.Paths {
+		if strings.HasPrefix(path, b.Paths[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Usage is the usage of a bucket within the window ending at Reset
+type Usage struct {
+	Used  int64     `json:"used"`
+	Cap   int64     `json:"cap"`
+	Reset time.Time `json:"reset"`
+}
+
+// Observation is what a response reported about rate limits
+type Observation struct {
+	At         time.Time        `json:"at"`
+	Status     int              `json:"status"`
+	RetryAfter time.Duration    `json:"retryAfter"`
+	Usage      map[string]Usage `json:"usage,omitempty"`
+}
+
+// State is the shared rate limit state of one key and host
+type State struct {
+	PausedUntil time.Time        `json:"pausedUntil"`
+	Strikes     int              `json:"strikes"`
+	Usage       map[string]Usage `json:"usage,omitempty"`
+}
+
+// reserve counts a request against the buckets it consumes and returns how
+// long it must wait before being sent. Buckets the request does not consume
+// neither delay nor count it.
+func (s *State) reserve(now time.Time, buckets []string) time.Duration {
+	var wait time.Duration
+	if now.Before(s.PausedUntil) {
+		wait = s.PausedUntil.Sub(now)
+	}
+	for k, u := range s.Usage {
+		if !u.Reset.After(now) {
+			delete(s.Usage, k)
+			continue
+		}
+		if !common.StringDataCompare(buckets, k) {
+			continue
+		}
+		remaining := u.Reset.Sub(now)
+		switch {
+		case u.Used >= u.Cap:
+			if remaining > wait {
+				wait = remaining
+			}
+		case float64(u.Used) >= float64(u.Cap)*TightenAt:
+			if pace := remaining / time.Duration(u.Cap-u.Used); pace > wait {
+				wait = pace
+			}
+		}
+		u.Used++
+		s.Usage[k] = u
+	}
+	return wait
+}
+
+// observe merges a response into the state. Reported usage for the current
+// window only ever raises the estimate as responses can arrive out of order.
+func (s *State) observe(o Observation) {
+	if s.Usage == nil {
+		s.Usage = make(map[string]Usage)
+	}
+	for k, u := range o.Usage {
+		cur, ok := s.Usage[k]
+		switch {
+		case !ok, u.Reset.After(cur.Reset):
+			s.Usage[k] = u
+		case u.Reset.Equal(cur.Reset):
+			if u.Used > cur.Used {
+				cur.Used = u.Used
+			}
+			cur.Cap = u.Cap
+			s.Usage[k] = cur
+		}
+	}
+
+	pause := o.RetryAfter
+	switch {
+	case o.Status == http.StatusTooManyRequests:
+		s.Strikes++
+		backoff := MaxBackoff
+		if s.Strikes <= 6 {
+			backoff = DefaultBackoff << (s.Strikes - 1)
+		}
+		if backoff > MaxBackoff {
+			backoff = MaxBackoff
+		}
+		if backoff > pause {
+			pause = backoff
+		}
+	case o.Status == StatusBanned:
+		s.Strikes++
+		if pause < DefaultBanBackoff {
+			pause = DefaultBanBackoff
+		}
+	case o.Status >= http.StatusOK && o.Status < http.StatusMultipleChoices:
+		s.Strikes = 0
+	}
+	if until := o.At.Add(pause); pause > 0 && until.After(s.PausedUntil) {
+		s.PausedUntil = until
+	}
+}
+
+// Limiter paces requests for one key, usually an exchange and API key pair
+type Limiter struct {
+	key     string
+	store   Store
+	buckets []Bucket
+	now     func() time.Time
+}
+
+// NewLimiter returns a limiter for key backed by store which reads the
+// supplied buckets and the generic X-RateLimit-* headers
+func NewLimiter(key string, store Store, buckets ...Bucket) (*Limiter, error) {
+	if key == "" {
+		return nil, errKeyEmpty
+	}
+	if store == nil {
+		return nil, errStoreNil
+	}
+	for i := range buckets {
+		if buckets[i].Header == "" || buckets[i].Window <= 0 || buckets[i].Cap <= 0 {
+			return nil, fmt.Errorf("%w: %+v", errInvalidBucket, buckets[i])
+		}
+	}
+	return &Limiter{key: key, store: store, buckets: buckets, now: time.Now}, nil
+}
+
+// stateKey keys state by host as exchanges budget each API host separately
+func (l *Limiter) stateKey(host string) string {
+	return l.key + "@" + host
+}
+
+// consumes returns the buckets a request to host and path counts against,
+// the generic bucket covers every request to a host
+func (l *Limiter) consumes(host, path string) []string {
+	buckets := []string{genericBucket}
+	for i := range l.buckets {
+		if l.buckets[i].matches(host, path) {
+			buckets = append(buckets, l.buckets[i].Header)
+		}
+	}
+	return buckets
+}
+
+// Wait blocks until a request to rawURL may be sent. It returns early with
+// an error when the wait would outlast the context's deadline.
+func (l *Limiter) Wait(ctx context.Context, rawURL string) error {
+	host, path := splitURL(rawURL)
+	now := l.now()
+	wait, err := l.store.Reserve(l.stateKey(host), l.consumes(host, path), now)
+	if err != nil || wait <= 0 {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok && now.Add(wait).After(deadline) {
+		return fmt.Errorf("%w: %s wait for %s", errDeadlineExceeded, wait, host)
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Observe records the status and headers of a response from rawURL
+func (l *Limiter) Observe(rawURL string, status int, h http.Header) error {
+	host, path := splitURL(rawURL)
+	now := l.now()
+	o := Observation{
+		At:         now,
+		Status:     status,
+		RetryAfter: request.RetryAfter(&http.Response{Header: h}, now),
+		Usage:      make(map[string]Usage),
+	}
+	for i := range l.buckets {
+		if !l.buckets[i].matches(host, path) {
+			continue
+		}
+		used, err := strconv.ParseInt(h.Get(l.buckets[i].Header), 10, 64)
+		if err != nil {
+			continue
+		}
+		o.Usage[l.buckets[i].Header] = Usage{
+			Used:  used,
+			Cap:   l.buckets[i].Cap,
+			Reset: now.Truncate(l.buckets[i].Window).Add(l.buckets[i].Window),
+		}
+	}
+	if u, ok := genericUsage(h, now); ok {
+		o.Usage[genericBucket] = u
+	}
+	return l.store.Observe(l.stateKey(host), o)
+}
+
+// State returns the current state for a host
+func (l *Limiter) State(host string) (State, error) {
+	return l.store.State(l.stateKey(host))
+}
+
+// genericUsage reads the X-RateLimit-* headers, the reset is either a unix
+// timestamp or seconds from now
+func genericUsage(h http.Header, now time.Time) (Usage, bool) {
+	remaining, err := strconv.ParseInt(h.Get(headerRemaining), 10, 64)
+	if err != nil {
+		return Usage{}, false
+	}
+	limit, err := strconv.ParseInt(h.Get(headerLimit), 10, 64)
+	if err != nil || limit <= 0 {
+		return Usage{}, false
+	}
+	reset, err := strconv.ParseFloat(h.Get(headerReset), 64)
+	if err != nil {
+		return Usage{}, false
+	}
+	var resetAt time.Time
+	if reset > unixResetBoundary {
+		resetAt = time.Unix(0, int64(reset*float64(time.Second)))
+	} else {
+		resetAt = now.Add(time.Duration(reset * float64(time.Second)))
+	}
+	return Usage{Used: limit - remaining, Cap: limit, Reset: resetAt}, true
+}
+
+// splitURL returns the host and path of a request URL
+func splitURL(rawURL string) (host, path string) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL, ""
+	}
+	return u.Host, u.Path
+}
@@ -0,0 +1,329 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const testURL = "https://api.binance.com/api/v3/time"
+
+var weightBucket = Bucket{Header: "X-Mbx-Used-Weight-1m", Window: time.Minute, Cap: 100, Host: "api.binance.com"}
+
+func newTestLimiter(t *testing.T, store Store, now time.Time) *Limiter {
+	t.Helper()
+	l, err := NewLimiter("binance", store, weightBucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.now = func() time.Time { return now }
+	return l
+}
+
+func TestNewLimiter(t *testing.T) {
+	t.Parallel()
+	if _, err := NewLimiter("", NewMemoryStore()); !errors.Is(err, errKeyEmpty) {
+		t.Errorf("expected %v, received %v", errKeyEmpty, err)
+	}
+	if _, err := NewLimiter("key", nil); !errors.Is(err, errStoreNil) {
+		t.Errorf("expected %v, received %v", errStoreNil, err)
+	}
+	if _, err := NewLimiter("key", NewMemoryStore(), Bucket{Header: "X"}); !errors.Is(err, errInvalidBucket) {
+		t.Errorf("expected %v, received %v", errInvalidBucket, err)
+	}
+}
+
+func TestTighten(t *testing.T) {
+	t.Parallel()
+	// Half way through the window
+	now := time.Unix(1600000050, 0)
+	l := newTestLimiter(t, NewMemoryStore(), now)
+	consumed := l.consumes("api.binance.com", "/api/v3/time")
+	h := http.Header{}
+	h.Set(weightBucket.Header, "50")
+	if err := l.Observe(testURL, http.StatusOK, h); err != nil {
+		t.Fatal(err)
+	}
+	if wait, _ := l.store.Reserve(l.stateKey("api.binance.com"), consumed, now); wait != 0 {
+		t.Errorf("expected no wait below the threshold, received %s", wait)
+	}
+
+	// 90 of 100 used with 30 seconds left spreads the rest over the window
+	h.Set(weightBucket.Header, "90")
+	if err := l.Observe(testURL, http.StatusOK, h); err != nil {
+		t.Fatal(err)
+	}
+	if wait, _ := l.store.Reserve(l.stateKey("api.binance.com"), consumed, now); wait != 3*time.Second {
+		t.Errorf("expected 3s pacing, received %s", wait)
+	}
+
+	// An older, lower count arriving late does not loosen the estimate
+	h.Set(weightBucket.Header, "10")
+	if err := l.Observe(testURL, http.StatusOK, h); err != nil {
+		t.Fatal(err)
+	}
+	state, err := l.State("api.binance.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used := state.Usage[weightBucket.Header].Used; used != 91 {
+		t.Errorf("expected 91 used, received %d", used)
+	}
+
+	// Exhausted buckets wait for the window to reset
+	h.Set(weightBucket.Header, "100")
+	if err = l.Observe(testURL, http.StatusOK, h); err != nil {
+		t.Fatal(err)
+	}
+	if wait, _ := l.store.Reserve(l.stateKey("api.binance.com"), consumed, now); wait != 30*time.Second {
+		t.Errorf("expected 30s wait, received %s", wait)
+	}
+
+	// Buckets for another host are ignored
+	if err = l.Observe("https://fapi.binance.com/fapi/v1/time", http.StatusOK, h); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ = l.State("fapi.binance.com"); len(state.Usage) != 0 {
+		t.Errorf("expected no usage for another host, received %+v", state.Usage)
+	}
+
+	// The window has reset
+	if wait, _ := l.store.Reserve(l.stateKey("api.binance.com"), consumed, now.Add(time.Minute)); wait != 0 {
+		t.Errorf("expected no wait after reset, received %s", wait)
+	}
+}
+
+func TestReserveConsumedBuckets(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1600000050, 0)
+	api := Bucket{Header: "X-Mbx-Used-Weight-1m", Window: time.Minute, Cap: 100, Host: "api.binance.com", Paths: []string{"/api/"}}
+	sapi := Bucket{Header: "X-Sapi-Used-Ip-Weight-1m", Window: time.Minute, Cap: 100, Host: "api.binance.com", Paths: []string{"/sapi/"}}
+	l, err := NewLimiter("binance", NewMemoryStore(), api, sapi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.now = func() time.Time { return now }
+
+	// SAPI responses only report the SAPI weight
+	h := http.Header{}
+	h.Set(api.Header, "100")
+	h.Set(sapi.Header, "10")
+	if err = l.Observe("https://api.binance.com/sapi/v1/system/status", http.StatusOK, h); err != nil {
+		t.Fatal(err)
+	}
+	state, err := l.State("api.binance.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := state.Usage[api.Header]; ok {
+		t.Error("expected spot weight to be ignored on a SAPI response")
+	}
+
+	// An exhausted spot weight does not delay or count SAPI requests
+	if err = l.Observe(testURL, http.StatusOK, h); err != nil {
+		t.Fatal(err)
+	}
+	if err = l.Wait(context.Background(), "https://api.binance.com/sapi/v1/system/status"); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ = l.State("api.binance.com"); state.Usage[api.Header].Used != 100 || state.Usage[sapi.Header].Used != 11 {
+		t.Errorf("expected only the SAPI weight to be counted, received %+v", state.Usage)
+	}
+	ctx, cancel := context.WithDeadline(context.Background(), now.Add(time.Second))
+	defer cancel()
+	if err = l.Wait(ctx, testURL); !errors.Is(err, errDeadlineExceeded) {
+		t.Errorf("expected %v, received %v", errDeadlineExceeded, err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1600000000, 0)
+	l := newTestLimiter(t, NewMemoryStore(), now)
+	key := l.stateKey("api.binance.com")
+
+	h := http.Header{}
+	h.Set("Retry-After", "5")
+	if err := l.Observe(testURL, http.StatusServiceUnavailable, h); err != nil {
+		t.Fatal(err)
+	}
+	if wait, _ := l.store.Reserve(key, nil, now); wait != 5*time.Second {
+		t.Errorf("expected Retry-After to be honoured, received %s", wait)
+	}
+
+	// Consecutive rate limit responses back off exponentially
+	later := now.Add(time.Hour)
+	l.now = func() time.Time { return later }
+	for i, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		if err := l.Observe(testURL, http.StatusTooManyRequests, http.Header{}); err != nil {
+			t.Fatal(err)
+		}
+		if wait, _ := l.store.Reserve(key, nil, later); wait != expected {
+			t.Errorf("strike %d: expected %s, received %s", i+1, expected, wait)
+		}
+	}
+	if err := l.Observe(testURL, http.StatusOK, http.Header{}); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := l.State("api.binance.com"); state.Strikes != 0 {
+		t.Errorf("expected success to reset strikes, received %d", state.Strikes)
+	}
+
+	// A ban pauses for at least the ban backoff
+	if err := l.Observe(testURL, StatusBanned, h); err != nil {
+		t.Fatal(err)
+	}
+	if wait, _ := l.store.Reserve(key, nil, later); wait != DefaultBanBackoff {
+		t.Errorf("expected %s, received %s", DefaultBanBackoff, wait)
+	}
+}
+
+func TestGenericHeaders(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1600000000, 0)
+	h := http.Header{}
+	h.Set(headerLimit, "10")
+	h.Set(headerRemaining, "0")
+	h.Set(headerReset, "2")
+	u, ok := genericUsage(h, now)
+	if !ok || u.Used != 10 || u.Cap != 10 || !u.Reset.Equal(now.Add(2*time.Second)) {
+		t.Errorf("unexpected relative usage %+v %v", u, ok)
+	}
+	h.Set(headerReset, strconv.FormatInt(now.Unix()+4, 10))
+	if u, ok = genericUsage(h, now); !ok || !u.Reset.Equal(now.Add(4*time.Second)) {
+		t.Errorf("unexpected absolute usage %+v %v", u, ok)
+	}
+	h.Del(headerLimit)
+	if _, ok = genericUsage(h, now); ok {
+		t.Error("expected usage to require a limit")
+	}
+}
+
+func TestWait(t *testing.T) {
+	t.Parallel()
+	l, err := NewLimiter("key", NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = l.Wait(context.Background(), testURL); err != nil {
+		t.Fatal(err)
+	}
+	h := http.Header{}
+	h.Set("Retry-After", "60")
+	if err = l.Observe(testURL, http.StatusTooManyRequests, h); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err = l.Wait(ctx, testURL); !errors.Is(err, errDeadlineExceeded) {
+		t.Errorf("expected %v, received %v", errDeadlineExceeded, err)
+	}
+	// Other hosts are unaffected
+	if err = l.Wait(ctx, "https://fapi.binance.com"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("file store is not supported on windows")
+	}
+	if _, err := NewFileStore(""); !errors.Is(err, errStorePathEmpty) {
+		t.Errorf("expected %v, received %v", errStorePathEmpty, err)
+	}
+	dir := t.TempDir()
+	a, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = a.Reserve("../escape", nil, time.Now()); !errors.Is(err, errInvalidKey) {
+		t.Errorf("expected %v, received %v", errInvalidKey, err)
+	}
+	// Two stores on the same directory stand in for two processes
+	b, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertShared(t, a, b)
+}
+
+func TestSocketStore(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "ratelimit.sock")
+	if _, err := DialSocketStore(path); err == nil {
+		t.Error("expected error dialling without a server")
+	}
+	srv, err := Serve(path, NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	if _, err = Serve(path, NewMemoryStore()); !errors.Is(err, errSocketInUse) {
+		t.Errorf("expected %v, received %v", errSocketInUse, err)
+	}
+	a, err := NewStore(SocketStoreKind, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := DialSocketStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	assertShared(t, a, b)
+	if _, err = b.Reserve("", nil, time.Now()); !errors.Is(err, errKeyEmpty) {
+		t.Errorf("expected %v, received %v", errKeyEmpty, err)
+	}
+	if resp := srv.serve(&socketRequest{Op: "drop", Key: "key"}); resp.Error == "" {
+		t.Error("expected unknown operation to be refused")
+	}
+}
+
+func TestNewStore(t *testing.T) {
+	t.Parallel()
+	if s, err := NewStore("", ""); err != nil || s == nil {
+		t.Errorf("expected memory store, received %v", err)
+	}
+	if _, err := NewStore("redis", ""); !errors.Is(err, errInvalidStoreKind) {
+		t.Errorf("expected %v, received %v", errInvalidStoreKind, err)
+	}
+	if _, err := NewStore(FileStoreKind, ""); err == nil {
+		t.Error("expected error for empty file store path")
+	}
+}
+
+// assertShared checks that usage observed through one store paces requests
+// reserved through another
+func assertShared(t *testing.T, a, b Store) {
+	t.Helper()
+	now := time.Unix(1600000000, 0)
+	err := a.Observe("binance@api.binance.com", Observation{
+		At:     now,
+		Status: http.StatusOK,
+		Usage:  map[string]Usage{"weight": {Used: 100, Cap: 100, Reset: now.Add(10 * time.Second)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wait, err := b.Reserve("binance@api.binance.com", []string{"weight"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wait != 10*time.Second {
+		t.Errorf("expected 10s wait, received %s", wait)
+	}
+	state, err := a.State("binance@api.binance.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used := state.Usage["weight"].Used; used != 101 {
+		t.Errorf("expected reservation to be shared, received %d used", used)
+	}
+}
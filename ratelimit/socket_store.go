@@ -0,0 +1,265 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Socket request operations
+const (
+	opReserve = "reserve"
+	opObserve = "observe"
+	opState   = "state"
+)
+
+// socketRequest is an operation on a Server's store
+type socketRequest struct {
+	Op          string       `json:"op"`
+	Key         string       `json:"key"`
+	Buckets     []string     `json:"buckets,omitempty"`
+	Now         time.Time    `json:"now,omitempty"`
+	Observation *Observation `json:"observation,omitempty"`
+}
+
+// socketResponse carries the result of an operation or the reason it failed
+type socketResponse struct {
+	Wait  time.Duration `json:"wait,omitempty"`
+	State *State        `json:"state,omitempty"`
+	Error string        `json:"error,omitempty"`
+}
+
+// Server shares a store with other processes over a Unix socket. Requests
+// are newline delimited JSON.
+type Server struct {
+	store    Store
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+	mtx      sync.Mutex
+}
+
+// Serve listens on the Unix socket at path and serves state from store until
+// closed. A stale socket file left by a previous server is removed.
+func Serve(path string, store Store) (*Server, error) {
+	if path == "" {
+		return nil, errStorePathEmpty
+	}
+	if store == nil {
+		return nil, errStoreNil
+	}
+	if _, err := os.Stat(path); err == nil {
+		if c, dialErr := net.Dial("unix", path); dialErr == nil {
+			c.Close()
+			return nil, errSocketInUse
+		}
+		if err = os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{store: store, listener: l, conns: make(map[net.Conn]struct{})}
+	s.wg.Add(1)
+	go s.accept()
+	return s, nil
+}
+
+// accept hands each connection to its own handler
+func (s *Server) accept() {
+	defer s.wg.Done()
+	for {
+		c, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mtx.Lock()
+		if s.closed {
+			s.mtx.Unlock()
+			c.Close()
+			return
+		}
+		s.conns[c] = struct{}{}
+		s.wg.Add(1)
+		s.mtx.Unlock()
+		go s.handle(c)
+	}
+}
+
+// handle serves requests on one connection until it is closed
+func (s *Server) handle(c net.Conn) {
+	defer func() {
+		s.mtx.Lock()
+		delete(s.conns, c)
+		s.mtx.Unlock()
+		c.Close()
+		s.wg.Done()
+	}()
+	dec := json.NewDecoder(c)
+	enc := json.NewEncoder(c)
+	for {
+		var req socketRequest
+		if err := dec.Decode(&req); err != nil {
+			return
+		}
+		resp := s.serve(&req)
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// serve applies one request to the store
+func (s *Server) serve(req *socketRequest) *socketResponse {
+	var resp socketResponse
+	var err error
+	switch req.Op {
+	case opReserve:
+		resp.Wait, err = s.store.Reserve(req.Key, req.Buckets, req.Now)
+	case opObserve:
+		if req.Observation == nil {
+			err = fmt.Errorf("%w: observe without observation", errUnknownSocketVerb)
+			break
+		}
+		err = s.store.Observe(req.Key, *req.Observation)
+	case opState:
+		var state State
+		state, err = s.store.State(req.Key)
+		resp.State = &state
+	default:
+		err = fmt.Errorf("%w: %q", errUnknownSocketVerb, req.Op)
+	}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	return &resp
+}
+
+// Close stops the server and drops every client, the backing store is left
+// open
+func (s *Server) Close() error {
+	s.mtx.Lock()
+	if s.closed {
+		s.mtx.Unlock()
+		return errServerClosed
+	}
+	s.closed = true
+	err := s.listener.Close()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mtx.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+// SocketStore shares state through a Server over a Unix socket so every
+// process on the host draws from one budget per key
+type SocketStore struct {
+	path string
+	conn net.Conn
+	enc  *json.Encoder
+	dec  *json.Decoder
+	mtx  sync.Mutex
+}
+
+// DialSocketStore connects to the server listening at path
+func DialSocketStore(path string) (*SocketStore, error) {
+	if path == "" {
+		return nil, errStorePathEmpty
+	}
+	s := &SocketStore{path: path}
+	if err := s.dial(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// dial connects to the server, the caller must hold the lock once the store
+// is in use
+func (s *SocketStore) dial() error {
+	c, err := net.Dial("unix", s.path)
+	if err != nil {
+		return err
+	}
+	s.conn = c
+	s.enc = json.NewEncoder(c)
+	s.dec = json.NewDecoder(c)
+	return nil
+}
+
+// do sends a request and returns the response. A dropped connection is
+// redialled once, a resent reservation may count a request twice which only
+// errs on the side of pacing.
+func (s *SocketStore) do(req *socketRequest) (*socketResponse, error) {
+	if req.Key == "" {
+		return nil, errKeyEmpty
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			if err = s.dial(); err != nil {
+				return nil, err
+			}
+		}
+		var resp socketResponse
+		if err = s.enc.Encode(req); err == nil {
+			err = s.dec.Decode(&resp)
+		}
+		if err != nil {
+			s.conn.Close()
+			s.conn = nil
+			continue
+		}
+		if resp.Error != "" {
+			return nil, errors.New(resp.Error)
+		}
+		return &resp, nil
+	}
+	return nil, err
+}
+
+// Reserve counts a request against the buckets of key
+func (s *SocketStore) Reserve(key string, buckets []string, now time.Time) (time.Duration, error) {
+	resp, err := s.do(&socketRequest{Op: opReserve, Key: key, Buckets: buckets, Now: now})
+	if err != nil {
+		return 0, err
+	}
+	return resp.Wait, nil
+}
+
+// Observe merges a response into the state of key
+func (s *SocketStore) Observe(key string, o Observation) error {
+	_, err := s.do(&socketRequest{Op: opObserve, Key: key, Observation: &o})
+	return err
+}
+
+// State returns the state of key
+func (s *SocketStore) State(key string) (State, error) {
+	resp, err := s.do(&socketRequest{Op: opState, Key: key})
+	if err != nil || resp.State == nil {
+		return State{}, err
+	}
+	return *resp.State, nil
+}
+
+// Close closes the connection to the server
+func (s *SocketStore) Close() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Store kinds which can be built by NewStore
+const (
+	MemoryStoreKind = "memory"
+	FileStoreKind   = "file"
+	SocketStoreKind = "socket"
+)
+
+// Store holds rate limit state by key. Reserve and Observe must be atomic for
+// every user of the store so processes sharing it share one budget.
+type Store interface {
+	// Reserve counts a request sent at now against the named buckets and
+	// returns how long it must wait first
+	Reserve(key string, buckets []string, now time.Time) (time.Duration, error)
+	// Observe merges what a response reported into the state
+	Observe(key string, o Observation) error
+	// State returns a copy of the state
+	State(key string) (State, error)
+	Close() error
+}
+
+// NewStore builds a store by kind. Path is the directory for a file store and
+// the socket for a socket store.
+func NewStore(kind, path string) (Store, error) {
+	switch kind {
+	case "", MemoryStoreKind:
+		return NewMemoryStore(), nil
+	case FileStoreKind:
+		return NewFileStore(path)
+	case SocketStoreKind:
+		return DialSocketStore(path)
+	}
+	return nil, fmt.Errorf("%w: %s", errInvalidStoreKind, kind)
+}
+
+// copyState returns a state which shares no maps with s
+func copyState(s *State) State {
+	c := State{PausedUntil: s.PausedUntil, Strikes: s.Strikes}
+	if len(s.Usage) > 0 {
+		c.Usage = make(map[string]Usage, len(s.Usage))
+		for k, v := range s.Usage {
+			c.Usage[k] = v
+		}
+	}
+	return c
+}
+
+// MemoryStore keeps state for the life of the process only
+type MemoryStore struct {
+	states map[string]*State
+	mtx    sync.Mutex
+}
+
+// NewMemoryStore returns an empty memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string]*State)}
+}
+
+// state returns the state for key, the caller must hold the lock
+func (m *MemoryStore) state(key string) *State {
+	s, ok := m.states[key]
+	if !ok {
+		s = &State{Usage: make(map[string]Usage)}
+		m.states[key] = s
+	}
+	return s
+}
+
+// Reserve counts a request against the buckets of key
+func (m *MemoryStore) Reserve(key string, buckets []string, now time.Time) (time.Duration, error) {
+	if key == "" {
+		return 0, errKeyEmpty
+	}
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.state(key).reserve(now, buckets), nil
+}
+
+// Observe merges a response into the state of key
+func (m *MemoryStore) Observe(key string, o Observation) error {
+	if key == "" {
+		return errKeyEmpty
+	}
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.state(key).observe(o)
+	return nil
+}
+
+// State returns the state of key
+func (m *MemoryStore) State(key string) (State, error) {
+	if key == "" {
+		return State{}, errKeyEmpty
+	}
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return copyState(m.state(key)), nil
+}
+
+// Close is a no-op for a memory store
+func (m *MemoryStore) Close() error {
+	return nil
+}
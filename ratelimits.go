@@ -0,0 +1,125 @@
+package irix
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/openware/irix/config"
+	"github.com/openware/irix/metrics"
+	"github.com/openware/irix/ratelimit"
+	"github.com/openware/pkg/log"
+	"github.com/openware/pkg/request"
+)
+
+// statusCodeRegexp extracts the HTTP status from requester errors which
+// report either "status code: 503" or "status: 429 Too Many Requests"
+var statusCodeRegexp = regexp.MustCompile(`status(?: code)?: (\d{3})`)
+
+// SetRateLimitStore sets where the rate limit state learnt from responses is
+// kept from config, a memory store is used when the config does not set one
+func (b *Base) SetRateLimitStore(cfg *config.RateLimitConfig) error {
+	if cfg == nil {
+		cfg = &config.RateLimitConfig{}
+	}
+	store, err := ratelimit.NewStore(cfg.Store, cfg.Path)
+	if err != nil {
+		return err
+	}
+	b.rateLimitMtx.Lock()
+	defer b.rateLimitMtx.Unlock()
+	if b.rateLimitStore != nil {
+		_ = b.rateLimitStore.Close()
+	}
+	b.rateLimitStore = store
+	b.rateLimiter = nil
+	return nil
+}
+
+// RateLimiter returns the adaptive limiter for the current API key, nil when
+// rate limiting is disabled
+func (b *Base) RateLimiter() (*ratelimit.Limiter, error) {
+	if atomic.LoadInt32(&b.rateLimitDisabled) == 1 {
+		return nil, nil
+	}
+	b.rateLimitMtx.Lock()
+	defer b.rateLimitMtx.Unlock()
+	if b.rateLimitStore == nil {
+		b.rateLimitStore = ratelimit.NewMemoryStore()
+	}
+	key := b.credentialKey()
+	if b.rateLimiter == nil || b.rateLimitKey != key {
+		l, err := ratelimit.NewLimiter(key, b.rateLimitStore, b.RateLimitBuckets...)
+		if err != nil {
+			return nil, err
+		}
+		b.rateLimiter = l
+		b.rateLimitKey = key
+	}
+	return b.rateLimiter, nil
+}
+
+// sendLimited sends a request once the adaptive limiter allows it, feeds the
+// response status and headers back to the limiter and records the request
+// with the exchange's metrics recorder
+func (b *Base) sendLimited(ctx context.Context, i *request.Item) error {
+	rec := b.recorder()
+	labels := metrics.Labels{Exchange: b.Name, Endpoint: metrics.Endpoint(i.Path)}
+	limiter, err := b.RateLimiter()
+	if err != nil {
+		return err
+	}
+	headers := i.HeaderResponse
+	if limiter != nil && i.Path != "" {
+		start := time.Now()
+		err = limiter.Wait(ctx, i.Path)
+		rec.ObserveHistogram(metrics.RateLimitWait, labels, time.Since(start).Seconds())
+		if err != nil {
+			rec.AddCounter(metrics.RESTErrors, labels, 1)
+			return err
+		}
+		if headers == nil {
+			headers = &http.Header{}
+			item := *i
+			item.HeaderResponse = headers
+			i = &item
+		}
+	}
+
+	start := time.Now()
+	err = b.Requester.SendPayload(ctx, i)
+	rec.ObserveHistogram(metrics.RESTDuration, labels, time.Since(start).Seconds())
+	rec.AddCounter(metrics.RESTRequests, labels, 1)
+	if err != nil {
+		rec.AddCounter(metrics.RESTErrors, labels, 1)
+	}
+	if limiter == nil || i.Path == "" {
+		return err
+	}
+
+	status := responseStatus(err)
+	if status == 0 && len(*headers) == 0 {
+		return err
+	}
+	if obsErr := limiter.Observe(i.Path, status, *headers); obsErr != nil && b.Verbose {
+		log.Debugf(log.ExchangeSys, "%s could not record rate limit state: %v", b.Name, obsErr)
+	}
+	return err
+}
+
+// responseStatus returns the HTTP status a request finished with, zero when
+// the request failed without a response
+func responseStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	m := statusCodeRegexp.FindStringSubmatch(err.Error())
+	if m == nil {
+		return 0
+	}
+	status, _ := strconv.Atoi(m[1])
+	return status
+}
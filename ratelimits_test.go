@@ -0,0 +1,97 @@
+package irix
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/openware/irix/config"
+	"github.com/openware/irix/ratelimit"
+	"github.com/openware/pkg/request"
+)
+
+func TestResponseStatus(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		err    error
+		status int
+	}{
+		{nil, http.StatusOK},
+		{errors.New("Binance unsuccessful HTTP status code: 503 raw response: {}"), http.StatusServiceUnavailable},
+		{errors.New("request.go error - failed to retry request, status: 429 Too Many Requests"), http.StatusTooManyRequests},
+		{errors.New("dial tcp: connection refused"), 0},
+	} {
+		if s := responseStatus(tc.err); s != tc.status {
+			t.Errorf("%v: expected %d, received %d", tc.err, tc.status, s)
+		}
+	}
+}
+
+func TestSetRateLimitStore(t *testing.T) {
+	t.Parallel()
+	b := Base{Name: "ratelimits"}
+	if err := b.SetRateLimitStore(&config.RateLimitConfig{Store: "redis"}); err == nil {
+		t.Error("expected error for unknown store")
+	}
+	if err := b.SetRateLimitStore(&config.RateLimitConfig{Store: ratelimit.FileStoreKind, Path: t.TempDir()}); err != nil {
+		t.Fatal(err)
+	}
+	l, err := b.RateLimiter()
+	if err != nil || l == nil {
+		t.Fatalf("expected limiter, received %v", err)
+	}
+	// A new API key gets its own limiter
+	b.API.Credentials.Key = "key"
+	if other, _ := b.RateLimiter(); other == l {
+		t.Error("expected a new limiter for a new key")
+	}
+}
+
+func TestSendPayloadRateLimit(t *testing.T) {
+	t.Parallel()
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-RateLimit-Limit", "10")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "60")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	b := Base{Name: "ratelimits"}
+	b.Requester = request.New(b.Name, new(http.Client))
+	send := func(ctx context.Context) error {
+		var resp struct{}
+		return b.SendPayload(ctx, &request.Item{Method: http.MethodGet, Path: srv.URL, Result: &resp})
+	}
+	if err := send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// The exhausted budget reported by the server holds the next request
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := send(ctx); err == nil {
+		t.Error("expected the exhausted budget to hold the request")
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call to reach the server, received %d", calls)
+	}
+
+	// Disabling the rate limiter also disables adaptive pacing
+	if err := b.DisableRateLimiter(); err != nil {
+		t.Fatal(err)
+	}
+	if err := send(ctx); err != nil {
+		t.Error(err)
+	}
+	if err := b.EnableRateLimiter(); err != nil {
+		t.Fatal(err)
+	}
+	if err := send(ctx); err == nil {
+		t.Error("expected pacing to resume once enabled")
+	}
+}
@@ -0,0 +1,391 @@
+// Package router splits orders across the venues of a consolidated book.
+package router
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	exchange "github.com/openware/irix"
+	"github.com/openware/irix/analytics"
+	"github.com/openware/irix/consolidated"
+	"github.com/openware/pkg/account"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/log"
+	"github.com/openware/pkg/order"
+)
+
+// New returns a router of the configured venues, fetching their taker fees
+func New(c *Config) (*Router, error) {
+	if c.Book == nil {
+		return nil, errBookUnset
+	}
+	if len(c.Venues) == 0 {
+		return nil, errNoVenues
+	}
+	r := &Router{
+		book:      c.Book,
+		venues:    make(map[string]*venue, len(c.Venues)),
+		rounds:    c.Rounds,
+		poll:      c.PollInterval,
+		timeout:   c.FillTimeout,
+		reconcile: c.Reconcile,
+	}
+	if r.rounds <= 0 {
+		r.rounds = DefaultRounds
+	}
+	if r.poll <= 0 {
+		r.poll = DefaultPollInterval
+	}
+	if r.timeout <= 0 {
+		r.timeout = DefaultFillTimeout
+	}
+	for i := range c.Venues {
+		v := c.Venues[i]
+		if v.Exchange == nil || v.Pair.IsEmpty() || v.Asset == "" {
+			return nil, fmt.Errorf("%w at index %d: exchange, pair and asset are required",
+				errInvalidVenue, i)
+		}
+		name := v.Exchange.GetName()
+		key := strings.ToLower(name)
+		if _, ok := r.venues[key]; ok {
+			return nil, fmt.Errorf("%w %s", errDuplicateVenue, name)
+		}
+		fee, err := analytics.TakerFeeRate(v.Exchange, v.Pair)
+		if err != nil {
+			return nil, fmt.Errorf("cannot fetch %s taker fee: %w", name, err)
+		}
+		r.venues[key] = &venue{Venue: v, name: name, fee: fee}
+	}
+	return r, nil
+}
+
+// Route splits an order into immediate or cancel child orders across venues,
+// routing what is left unfilled again against the updated book. Only amounts
+// confirmed not placed or cancelled are routed again, a child whose outcome
+// is unknown keeps its amount unresolved. An execution is returned with
+// exchange.ErrOrderOutcomeUnknown when any of it is unresolved, otherwise with
+// errPartiallyFilled when rounds run out before the order is filled.
+func (r *Router) Route(o *Order) (*Execution, error) {
+	var buy bool
+	switch o.Side {
+	case order.Buy, order.Bid:
+		buy = true
+	case order.Sell, order.Ask:
+	default:
+		return nil, fmt.Errorf("%w, received %s", errInvalidSide, o.Side)
+	}
+	if o.Amount <= 0 {
+		return nil, errInvalidAmount
+	}
+	if o.Price < 0 {
+		return nil, errInvalidPrice
+	}
+
+	balances := r.balances(buy)
+	excluded := make(map[string]bool)
+	e := &Execution{Order: *o, Remaining: o.Amount}
+	for round := 1; round <= r.rounds && e.Remaining > 0; round++ {
+		book, err := r.book()
+		if err != nil {
+			if round == 1 {
+				return nil, err
+			}
+			log.Errorf(log.ExchangeSys, "router cannot fetch book to route remainder %v: %v",
+				e.Remaining,
+				err)
+			break
+		}
+		children := r.plan(book, o, buy, e.Remaining, balances, excluded)
+		if len(children) == 0 {
+			break
+		}
+		r.execute(children, round, buy)
+		for _, c := range children {
+			v := r.venues[strings.ToLower(c.Exchange)]
+			// An unresolved amount may still fill so it stays committed
+			committed := c.Filled + c.Unresolved
+			if buy {
+				balances[v] -= committed * c.Price * (1 + v.fee)
+			} else {
+				balances[v] -= committed
+			}
+			if c.Err != nil {
+				excluded[v.name] = true
+			}
+			e.Filled += c.Filled
+			e.Unresolved += c.Unresolved
+		}
+		e.Children = append(e.Children, children...)
+		e.Remaining = o.Amount - e.Filled - e.Unresolved
+		// Float residue is not worth a round
+		if e.Remaining <= o.Amount*1e-9 {
+			e.Remaining = 0
+		}
+	}
+	switch {
+	case e.Remaining == 0 && e.Unresolved == 0:
+		return e, nil
+	case len(e.Children) == 0:
+		return e, errNoRoute
+	case e.Unresolved > 0:
+		// Reported ahead of a remainder as the unresolved amount may still
+		// fill and must be reconciled before anything is routed again
+		return e, fmt.Errorf("%w, %v of %v unresolved and %v remaining",
+			exchange.ErrOrderOutcomeUnknown,
+			e.Unresolved,
+			o.Amount,
+			e.Remaining)
+	default:
+		return e, fmt.Errorf("%w, %v of %v remaining", errPartiallyFilled, e.Remaining, o.Amount)
+	}
+}
+
+// balances returns what each venue can spend, the quote currency when buying
+// and the base currency when selling. Venues whose balances cannot be
+// fetched are not routed to.
+func (r *Router) balances(buy bool) map[*venue]float64 {
+	balances := make(map[*venue]float64, len(r.venues))
+	for _, v := range r.venues {
+		code := v.Pair.Base
+		if buy {
+			code = v.Pair.Quote
+		}
+		h, err := v.Exchange.FetchAccountInfo(v.Asset)
+		if err != nil {
+			log.Errorf(log.ExchangeSys, "router cannot fetch %s %s balance: %v",
+				v.name,
+				v.Asset,
+				err)
+			continue
+		}
+		balances[v] = available(h.Accounts, v, code)
+	}
+	return balances
+}
+
+// plan walks the book from its best level allocating the amount to venues.
+// Venues whose allocation does not conform to their order limits are left
+// out and the amount allocated again.
+func (r *Router) plan(book *consolidated.Book, o *Order, buy bool, amount float64, balances map[*venue]float64, excluded map[string]bool) []*Child {
+	levels := book.Bids
+	if buy {
+		levels = book.Asks
+	}
+	skip := make(map[string]bool, len(excluded))
+	for name := range excluded {
+		skip[name] = true
+	}
+	for {
+		children := r.allocate(levels, o, buy, amount, balances, skip)
+		conforming := children[:0]
+		for _, c := range children {
+			if err := r.conform(c); err != nil {
+				log.Debugf(log.ExchangeSys, "router not routing %v to %s: %v", c.Amount, c.Exchange, err)
+				skip[c.Exchange] = true
+				continue
+			}
+			conforming = append(conforming, c)
+		}
+		if len(conforming) == len(children) {
+			return conforming
+		}
+	}
+}
+
+// allocate allocates the amount to levels in order, one child per venue
+func (r *Router) allocate(levels []consolidated.Level, o *Order, buy bool, amount float64, balances map[*venue]float64, skip map[string]bool) []*Child {
+	var children []*Child
+	byVenue := make(map[*venue]*Child)
+	left := make(map[*venue]float64, len(balances))
+	for v, b := range balances {
+		left[v] = b
+	}
+	for i := 0; i < len(levels) && amount > 0; i++ {
+		l := levels[i]
+		if o.Price != 0 && ((buy && l.Price > o.Price) || (!buy && l.Price < o.Price)) {
+			break
+		}
+		v, ok := r.venues[strings.ToLower(l.Exchange)]
+		if !ok || skip[v.name] {
+			continue
+		}
+		take := l.Amount
+		if take > amount {
+			take = amount
+		}
+		if buy {
+			cost := l.VenuePrice * (1 + v.fee)
+			if affordable := left[v] / cost; take > affordable {
+				take = affordable
+			}
+			if take <= 0 {
+				continue
+			}
+			left[v] -= take * cost
+		} else {
+			if take > left[v] {
+				take = left[v]
+			}
+			if take <= 0 {
+				continue
+			}
+			left[v] -= take
+		}
+		c, ok := byVenue[v]
+		if !ok {
+			c = &Child{Exchange: v.name, Pair: v.Pair, Asset: v.Asset}
+			byVenue[v] = c
+			children = append(children, c)
+		}
+		// A venue's levels are listed best first so this is its worst
+		c.Price = l.VenuePrice
+		c.Amount += take
+		amount -= take
+	}
+	return children
+}
+
+// conform rounds a child's amount to its venue's lot size and checks it
+// against the venue's minimums. Venues without loaded limits are not checked.
+func (r *Router) conform(c *Child) error {
+	v := r.venues[strings.ToLower(c.Exchange)]
+	limits, err := v.Exchange.GetOrderExecutionLimits(v.Asset, v.Pair)
+	if err != nil {
+		return nil
+	}
+	c.Amount = limits.ConformToAmount(c.Amount)
+	if c.Amount <= 0 {
+		return errInvalidAmount
+	}
+	return limits.Conforms(c.Price, c.Amount, order.Limit)
+}
+
+// execute submits children concurrently and waits for them to settle
+func (r *Router) execute(children []*Child, round int, buy bool) {
+	side := order.Sell
+	if buy {
+		side = order.Buy
+	}
+	var wg sync.WaitGroup
+	for _, c := range children {
+		c.Round = round
+		wg.Add(1)
+		go func(c *Child) {
+			defer wg.Done()
+			r.submit(c, side)
+		}(c)
+	}
+	wg.Wait()
+}
+
+// submit submits a child order and records what it filled
+func (r *Router) submit(c *Child, side order.Side) {
+	v := r.venues[strings.ToLower(c.Exchange)]
+	s := &order.Submit{
+		Exchange:          v.name,
+		Pair:              v.Pair,
+		AssetType:         v.Asset,
+		Side:              side,
+		Type:              order.Limit,
+		Price:             c.Price,
+		Amount:            c.Amount,
+		ImmediateOrCancel: true,
+	}
+	resp, err := exchange.SubmitOrderWithReconciliation(v.Exchange, s, r.reconcile)
+	c.ClientOrderID = s.ClientOrderID
+	if err != nil {
+		c.Err = err
+		if !errors.Is(err, exchange.ErrOrderNotPlaced) {
+			// Only a rejection rules the order out, otherwise it may rest or
+			// have filled and routing its amount again could fill the
+			// parent twice
+			c.Unresolved = c.Amount
+		}
+		return
+	}
+	if !resp.IsOrderPlaced {
+		c.Err = fmt.Errorf("%s did not place order %s", v.name, s.ClientOrderID)
+		return
+	}
+	c.OrderID = resp.OrderID
+	if resp.FullyMatched {
+		c.Filled = c.Amount
+		return
+	}
+	c.Err = r.track(v, c)
+}
+
+// track polls a child order until it settles, cancelling it if it rests
+// beyond the fill timeout so its remainder is not filled after being routed
+// again. What is not confirmed cancelled is left unresolved.
+func (r *Router) track(v *venue, c *Child) error {
+	deadline := time.Now().Add(r.timeout)
+	for {
+		d, err := v.Exchange.GetOrderInfo(c.OrderID, v.Pair, v.Asset)
+		if err == nil {
+			c.Filled = d.ExecutedAmount
+			if settled(d.Status) {
+				return nil
+			}
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(r.poll)
+	}
+	err := v.Exchange.CancelOrder(&order.Cancel{
+		Exchange:      v.name,
+		ID:            c.OrderID,
+		ClientOrderID: c.ClientOrderID,
+		Pair:          v.Pair,
+		AssetType:     v.Asset,
+	})
+	if err != nil {
+		c.Unresolved = c.Amount - c.Filled
+		return fmt.Errorf("cannot cancel %s order %s: %w", v.name, c.OrderID, err)
+	}
+	// The order may have filled further before it was cancelled
+	d, err := v.Exchange.GetOrderInfo(c.OrderID, v.Pair, v.Asset)
+	if err != nil {
+		c.Unresolved = c.Amount - c.Filled
+		return err
+	}
+	c.Filled = d.ExecutedAmount
+	return nil
+}
+
+// settled returns true when an order can no longer fill
+func settled(s order.Status) bool {
+	switch s {
+	case order.Filled,
+		order.Cancelled,
+		order.PartiallyCancelled,
+		order.Rejected,
+		order.Expired,
+		order.InsufficientBalance,
+		order.MarketUnavailable,
+		order.Closed:
+		return true
+	}
+	return false
+}
+
+// available returns the free balance of a currency in a venue's accounts
+func available(accounts []account.SubAccount, v *venue, code currency.Code) float64 {
+	var free float64
+	for i := range accounts {
+		if accounts[i].AssetType != v.Asset {
+			continue
+		}
+		for j := range accounts[i].Currencies {
+			b := accounts[i].Currencies[j]
+			if b.CurrencyName.Match(code) {
+				free += b.TotalValue - b.Hold
+			}
+		}
+	}
+	return free
+}
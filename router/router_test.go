@@ -0,0 +1,389 @@
+package router
+
+import (
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	exchange "github.com/openware/irix"
+	"github.com/openware/irix/consolidated"
+	"github.com/openware/pkg/account"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/common"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
+)
+
+var testPair = currency.NewPair(currency.BTC, currency.USD)
+
+// testExchange fills submissions immediately unless it rests them
+type testExchange struct {
+	name     string
+	fee      float64
+	balances []account.Balance
+	limits   order.ExecutionLimits
+	// fill is the fraction of a submission filled on submission
+	fill float64
+	// rest leaves what is not filled open until cancelled
+	rest bool
+	// submitErr and cancelErr fail submissions and cancellations
+	submitErr error
+	cancelErr error
+	m         sync.Mutex
+	orders    []order.Detail
+}
+
+func newTestExchange(name string, fee, quote, base float64) *testExchange {
+	return &testExchange{
+		name: name,
+		fee:  fee,
+		fill: 1,
+		balances: []account.Balance{
+			{CurrencyName: currency.USD, TotalValue: quote},
+			{CurrencyName: currency.BTC, TotalValue: base},
+		},
+	}
+}
+
+func (e *testExchange) GetName() string { return e.name }
+
+func (e *testExchange) GetFeeByType(b *exchange.FeeBuilder) (float64, error) {
+	return e.fee * b.PurchasePrice * b.Amount, nil
+}
+
+func (e *testExchange) FetchAccountInfo(a asset.Item) (account.Holdings, error) {
+	return account.Holdings{
+		Exchange: e.name,
+		Accounts: []account.SubAccount{{AssetType: a, Currencies: e.balances}},
+	}, nil
+}
+
+func (e *testExchange) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
+	if e.submitErr != nil {
+		return order.SubmitResponse{}, e.submitErr
+	}
+	e.m.Lock()
+	defer e.m.Unlock()
+	d := order.Detail{
+		ID:             strconv.Itoa(len(e.orders)),
+		Price:          s.Price,
+		Amount:         s.Amount,
+		ExecutedAmount: s.Amount * e.fill,
+		Side:           s.Side,
+		Status:         order.Cancelled,
+	}
+	switch {
+	case e.fill == 1:
+		d.Status = order.Filled
+	case e.rest:
+		d.Status = order.Active
+	}
+	e.orders = append(e.orders, d)
+	return order.SubmitResponse{
+		IsOrderPlaced: true,
+		FullyMatched:  d.Status == order.Filled,
+		OrderID:       d.ID,
+	}, nil
+}
+
+func (e *testExchange) GetOrderInfo(orderID string, _ currency.Pair, _ asset.Item) (order.Detail, error) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	i, err := strconv.Atoi(orderID)
+	if err != nil || i >= len(e.orders) {
+		return order.Detail{}, exchange.ErrOrderNotFound
+	}
+	return e.orders[i], nil
+}
+
+func (e *testExchange) GetOrderInfoByClientOrderID(string, currency.Pair, asset.Item) (order.Detail, error) {
+	return order.Detail{}, common.ErrFunctionNotSupported
+}
+
+func (e *testExchange) CancelOrder(o *order.Cancel) error {
+	if e.cancelErr != nil {
+		return e.cancelErr
+	}
+	e.m.Lock()
+	defer e.m.Unlock()
+	i, err := strconv.Atoi(o.ID)
+	if err != nil || i >= len(e.orders) {
+		return exchange.ErrOrderNotFound
+	}
+	e.orders[i].Status = order.Cancelled
+	return nil
+}
+
+func (e *testExchange) GetOrderExecutionLimits(a asset.Item, cp currency.Pair) (*order.Limits, error) {
+	return e.limits.GetOrderExecutionLimits(a, cp)
+}
+
+func nearly(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func level(e *testExchange, price, amount float64) consolidated.Level {
+	return consolidated.Level{Exchange: e.name, Price: price, Amount: amount, VenuePrice: price}
+}
+
+func staticBook(b *consolidated.Book) func() (*consolidated.Book, error) {
+	return func() (*consolidated.Book, error) { return b, nil }
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	e := newTestExchange("a", 0, 0, 0)
+	v := Venue{Exchange: e, Pair: testPair, Asset: asset.Spot}
+	book := staticBook(&consolidated.Book{})
+	for _, tc := range []struct {
+		c   Config
+		err error
+	}{
+		{Config{Venues: []Venue{v}}, errBookUnset},
+		{Config{Book: book}, errNoVenues},
+		{Config{Book: book, Venues: []Venue{{Exchange: e}}}, errInvalidVenue},
+		{Config{Book: book, Venues: []Venue{v, v}}, errDuplicateVenue},
+	} {
+		c := tc.c
+		if _, err := New(&c); !errors.Is(err, tc.err) {
+			t.Errorf("expected %v, received %v", tc.err, err)
+		}
+	}
+	r, err := New(&Config{Book: book, Venues: []Venue{v}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.rounds != DefaultRounds || r.poll != DefaultPollInterval || r.timeout != DefaultFillTimeout {
+		t.Error("expected defaults to be set")
+	}
+	for _, o := range []Order{
+		{Side: order.AnySide, Amount: 1},
+		{Side: order.Buy},
+		{Side: order.Buy, Amount: 1, Price: -1},
+	} {
+		if _, err = r.Route(&o); err == nil {
+			t.Errorf("expected error routing %+v", o)
+		}
+	}
+	if _, err = r.Route(&Order{Side: order.Buy, Amount: 1}); !errors.Is(err, errNoRoute) {
+		t.Errorf("expected %v, received %v", errNoRoute, err)
+	}
+}
+
+func TestRoute(t *testing.T) {
+	t.Parallel()
+	a := newTestExchange("a", 0, 1000, 1)
+	// Affords one at 100.5 once its fee is charged
+	b := newTestExchange("b", 0.01, 100.5*1.01, 10)
+	c := newTestExchange("c", 0, 1000, 10)
+	err := c.limits.LoadLimits([]order.MinMaxLevel{{
+		Pair:      testPair,
+		Asset:     asset.Spot,
+		MinAmount: 2,
+		MaxAmount: 100,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(&Config{
+		Book: staticBook(&consolidated.Book{
+			Pair: testPair,
+			Bids: []consolidated.Level{level(a, 99, 5), level(b, 98, 5)},
+			Asks: []consolidated.Level{
+				level(a, 100, 1),
+				level(b, 100.5, 2),
+				level(c, 100.8, 5),
+				level(a, 101, 1),
+			},
+		}),
+		Venues: []Venue{
+			{Exchange: a, Pair: testPair, Asset: asset.Spot},
+			{Exchange: b, Pair: testPair, Asset: asset.Spot},
+			{Exchange: c, Pair: testPair, Asset: asset.Spot},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// c's single lot is below its minimum so the lot is taken from a
+	e, err := r.Route(&Order{Side: order.Buy, Amount: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Filled != 3 || e.Remaining != 0 || len(e.Children) != 2 {
+		t.Fatalf("unexpected execution %+v", e)
+	}
+	for _, child := range e.Children {
+		if child.ClientOrderID == "" || child.OrderID == "" || child.Round != 1 || child.Err != nil {
+			t.Errorf("unexpected child %+v", child)
+		}
+		switch child.Exchange {
+		case a.name:
+			if child.Amount != 2 || child.Price != 101 {
+				t.Errorf("unexpected child %+v", child)
+			}
+		case b.name:
+			if !nearly(child.Amount, 1) || child.Price != 100.5 {
+				t.Errorf("unexpected child %+v", child)
+			}
+		default:
+			t.Errorf("unexpected child %+v", child)
+		}
+	}
+	if a.orders[0].Side != order.Buy || b.orders[0].Price != 100.5 {
+		t.Errorf("unexpected submissions %+v %+v", a.orders, b.orders)
+	}
+
+	// Selling is bounded by base balances
+	if e, err = r.Route(&Order{Side: order.Sell, Amount: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Children) != 2 || e.Children[0].Amount != 1 || e.Children[1].Price != 98 {
+		t.Errorf("unexpected execution %+v", e)
+	}
+
+	// Levels beyond the price are not taken, each round takes the level again
+	if e, err = r.Route(&Order{Side: order.Buy, Amount: 10, Price: 100}); !errors.Is(err, errPartiallyFilled) {
+		t.Fatalf("expected %v, received %v", errPartiallyFilled, err)
+	}
+	if e.Filled != DefaultRounds || e.Remaining != 10-DefaultRounds {
+		t.Errorf("unexpected execution %+v", e)
+	}
+}
+
+func TestRouteRemainder(t *testing.T) {
+	t.Parallel()
+	// a fills a quarter and rests the remainder until it is cancelled
+	a := newTestExchange("a", 0, 1000, 0)
+	a.fill, a.rest = 0.25, true
+	c := newTestExchange("c", 0, 1000, 0)
+	books := []*consolidated.Book{
+		{Asks: []consolidated.Level{level(a, 100, 4), level(c, 101, 10)}},
+		{Asks: []consolidated.Level{level(c, 101, 10)}},
+	}
+	var round int
+	r, err := New(&Config{
+		Book: func() (*consolidated.Book, error) {
+			b := books[round]
+			round++
+			return b, nil
+		},
+		Venues: []Venue{
+			{Exchange: a, Pair: testPair, Asset: asset.Spot},
+			{Exchange: c, Pair: testPair, Asset: asset.Spot},
+		},
+		PollInterval: time.Millisecond,
+		FillTimeout:  10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := r.Route(&Order{Side: order.Buy, Amount: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Filled != 4 || len(e.Children) != 2 {
+		t.Fatalf("unexpected execution %+v", e)
+	}
+	if child := e.Children[0]; child.Exchange != a.name || child.Filled != 1 {
+		t.Errorf("unexpected child %+v", child)
+	}
+	if a.orders[0].Status != order.Cancelled {
+		t.Error("expected resting child to be cancelled")
+	}
+	if child := e.Children[1]; child.Exchange != c.name || child.Round != 2 || child.Amount != 3 || child.Filled != 3 {
+		t.Errorf("unexpected child %+v", child)
+	}
+}
+
+func TestRouteUnresolved(t *testing.T) {
+	t.Parallel()
+	// a's submission times out and cannot be reconciled, b rests a
+	// remainder it cannot cancel
+	a := newTestExchange("a", 0, 1000, 0)
+	a.submitErr = io.ErrUnexpectedEOF
+	b := newTestExchange("b", 0, 1000, 0)
+	b.fill, b.rest, b.cancelErr = 0.25, true, errors.New("cancel rejected")
+	c := newTestExchange("c", 0, 1000, 0)
+	r, err := New(&Config{
+		Book: staticBook(&consolidated.Book{Asks: []consolidated.Level{
+			level(a, 100, 2),
+			level(b, 100.5, 4),
+			level(c, 101, 10),
+		}}),
+		Venues: []Venue{
+			{Exchange: a, Pair: testPair, Asset: asset.Spot},
+			{Exchange: b, Pair: testPair, Asset: asset.Spot},
+			{Exchange: c, Pair: testPair, Asset: asset.Spot},
+		},
+		PollInterval: time.Millisecond,
+		FillTimeout:  10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := r.Route(&Order{Side: order.Buy, Amount: 7})
+	if !errors.Is(err, exchange.ErrOrderOutcomeUnknown) {
+		t.Fatalf("expected %v, received %v", exchange.ErrOrderOutcomeUnknown, err)
+	}
+	// Only c's confirmed level is taken, nothing unresolved is routed again
+	if e.Filled != 2 || e.Unresolved != 5 || e.Remaining != 0 || len(e.Children) != 3 {
+		t.Fatalf("unexpected execution %+v", e)
+	}
+	for _, child := range e.Children {
+		switch child.Exchange {
+		case a.name:
+			if child.Unresolved != 2 || child.Filled != 0 || child.Err == nil {
+				t.Errorf("unexpected child %+v", child)
+			}
+		case b.name:
+			if child.Unresolved != 3 || child.Filled != 1 || child.Err == nil {
+				t.Errorf("unexpected child %+v", child)
+			}
+		case c.name:
+			if child.Amount != 1 || child.Filled != 1 || child.Unresolved != 0 {
+				t.Errorf("unexpected child %+v", child)
+			}
+		}
+	}
+}
+
+func TestRouteUnresolvedRemainder(t *testing.T) {
+	t.Parallel()
+	// a's submission times out, b rejects its submission outright
+	a := newTestExchange("a", 0, 1000, 0)
+	a.submitErr = io.ErrUnexpectedEOF
+	b := newTestExchange("b", 0, 1000, 0)
+	b.submitErr = errors.New("insufficient funds")
+	r, err := New(&Config{
+		Book: staticBook(&consolidated.Book{Asks: []consolidated.Level{
+			level(a, 100, 2),
+			level(b, 100.5, 2),
+		}}),
+		Venues: []Venue{
+			{Exchange: a, Pair: testPair, Asset: asset.Spot},
+			{Exchange: b, Pair: testPair, Asset: asset.Spot},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The unknown outcome is reported even though some of the order remains
+	e, err := r.Route(&Order{Side: order.Buy, Amount: 5})
+	if !errors.Is(err, exchange.ErrOrderOutcomeUnknown) {
+		t.Fatalf("expected %v, received %v", exchange.ErrOrderOutcomeUnknown, err)
+	}
+	if e.Filled != 0 || e.Unresolved != 2 || e.Remaining != 3 {
+		t.Fatalf("unexpected execution %+v", e)
+	}
+	for _, child := range e.Children {
+		if child.Exchange == b.name && (child.Unresolved != 0 || !errors.Is(child.Err, exchange.ErrOrderNotPlaced)) {
+			t.Errorf("expected rejected child to be resolved, received %+v", child)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package router
+
+import (
+	"errors"
+	"time"
+
+	exchange "github.com/openware/irix"
+	"github.com/openware/irix/consolidated"
+	"github.com/openware/pkg/account"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
+)
+
+const (
+	// DefaultRounds is how many times an order is routed before its
+	// remainder is reported unfilled
+	DefaultRounds = 3
+	// DefaultPollInterval is the delay between queries of a child order
+	// which is not settled on submission
+	DefaultPollInterval = 250 * time.Millisecond
+	// DefaultFillTimeout is how long a child order may rest before it is
+	// cancelled and its remainder routed again
+	DefaultFillTimeout = 5 * time.Second
+)
+
+var (
+	errBookUnset       = errors.New("book source unset")
+	errNoVenues        = errors.New("no venues")
+	errInvalidVenue    = errors.New("invalid venue")
+	errDuplicateVenue  = errors.New("duplicate venue")
+	errInvalidSide     = errors.New("order side must be buy or sell")
+	errInvalidAmount   = errors.New("order amount must be positive")
+	errInvalidPrice    = errors.New("order price cannot be negative")
+	errNoRoute         = errors.New("no venue can fill order")
+	errPartiallyFilled = errors.New("order partially filled")
+)
+
+// Exchange is the functionality the router needs of a venue, satisfied by
+// IBotExchange
+type Exchange interface {
+	exchange.OrderSubmitter
+	exchange.FeeCalculator
+	FetchAccountInfo(a asset.Item) (account.Holdings, error)
+	GetOrderInfo(orderID string, pair currency.Pair, assetType asset.Item) (order.Detail, error)
+	CancelOrder(o *order.Cancel) error
+	GetOrderExecutionLimits(a asset.Item, cp currency.Pair) (*order.Limits, error)
+}
+
+// Venue is where the router sends child orders for a consolidated book's
+// levels tagged with the exchange's name. An exchange has one venue.
+type Venue struct {
+	Exchange Exchange
+	Pair     currency.Pair
+	Asset    asset.Item
+}
+
+// Config configures a router
+type Config struct {
+	// Book returns the consolidated book routed against, such as an
+	// aggregator's Book. It is called each round so remainders are routed
+	// against updated books.
+	Book   func() (*consolidated.Book, error)
+	Venues []Venue
+	// Rounds, PollInterval and FillTimeout use their defaults when zero
+	Rounds       int
+	PollInterval time.Duration
+	FillTimeout  time.Duration
+	Reconcile    *exchange.ReconcileConfig
+}
+
+// Order is a parent order split into child orders
+type Order struct {
+	Side order.Side
+	// Amount is in the base currency
+	Amount float64
+	// Price is the worst consolidated price accepted, zero accepts any
+	Price float64
+}
+
+// Child is an order submitted to a venue
+type Child struct {
+	Exchange      string
+	Pair          currency.Pair
+	Asset         asset.Item
+	Round         int
+	ClientOrderID string
+	OrderID       string
+	// Price is the venue price of the worst level taken
+	Price  float64
+	Amount float64
+	Filled float64
+	// Unresolved is the amount which may still fill, such as when submission
+	// could not be reconciled or the order could not be cancelled. It is not
+	// routed again.
+	Unresolved float64
+	// Err is set when a child could not be submitted or settled, its venue is
+	// not routed to again for the order
+	Err error
+}
+
+// Execution is the outcome of routing an order
+type Execution struct {
+	Order      Order
+	Children   []*Child
+	Filled     float64
+	Unresolved float64
+	Remaining  float64
+}
+
+// Router splits orders across the venues of a consolidated book by price,
+// fees, balances and order limits
+type Router struct {
+	book      func() (*consolidated.Book, error)
+	venues    map[string]*venue
+	rounds    int
+	poll      time.Duration
+	timeout   time.Duration
+	reconcile *exchange.ReconcileConfig
+}
+
+// venue is a venue and its taker fee rate
+type venue struct {
+	Venue
+	name string
+	fee  float64
+}
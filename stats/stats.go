@@ -1,28 +1,20 @@
 package stats
 
 import (
-	"errors"
+	"fmt"
+	"math"
 	"sort"
+	"strings"
+	"time"
 
+	"github.com/openware/irix/stream"
+	"github.com/openware/irix/ticker"
 	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/log"
+	"github.com/openware/pkg/trade"
 )
 
-// Item holds various fields for storing currency pair stats
-type Item struct {
-	Exchange  string
-	Pair      currency.Pair
-	AssetType asset.Item
-	Price     float64
-	Volume    float64
-}
-
-// Items var array
-var Items []Item
-
-// ByPrice allows sorting by price
-type ByPrice []Item
-
 func (b ByPrice) Len() int {
 	return len(b)
 }
@@ -35,9 +27,6 @@ func (b ByPrice) Swap(i, j int) {
 	b[i], b[j] = b[j], b[i]
 }
 
-// ByVolume allows sorting by volume
-type ByVolume []Item
-
 func (b ByVolume) Len() int {
 	return len(b)
 }
@@ -50,106 +39,325 @@ func (b ByVolume) Swap(i, j int) {
 	b[i], b[j] = b[j], b[i]
 }
 
-// Add adds or updates the item stats
-func Add(exchange string, p currency.Pair, a asset.Item, price, volume float64) error {
-	if exchange == "" ||
-		a == "" ||
-		price == 0 ||
-		volume == 0 ||
-		p.Base.IsEmpty() ||
-		p.Quote.IsEmpty() {
-		return errors.New("cannot add or update, invalid params")
+// New returns stats kept over the windows supplied, DefaultWindows when none
+// are. XBT is aliased to BTC and USDT to USD.
+func New(windows ...time.Duration) (*Stats, error) {
+	if len(windows) == 0 {
+		windows = DefaultWindows
 	}
-
-	if p.Base == currency.XBT {
-		newPair, err := currency.NewPairFromStrings(currency.BTC.String(),
-			p.Quote.String())
-		if err != nil {
-			return err
+	for _, w := range windows {
+		if w < time.Second {
+			return nil, fmt.Errorf("%w, received %s", errInvalidWindow, w)
 		}
-		Append(exchange, newPair, a, price, volume)
 	}
+	return &Stats{
+		windows: append([]time.Duration(nil), windows...),
+		now:     time.Now,
+		series:  make(map[string]map[currency.Code]map[currency.Code]map[asset.Item]*series),
+		aliases: map[currency.Code]currency.Code{
+			currency.XBT:  currency.BTC,
+			currency.USDT: currency.USD,
+		},
+	}, nil
+}
+
+// Alias treats a currency as another when pairs are matched across
+// exchanges, such as XBT as BTC or USDT as USD so exchanges quoting them rank
+// together. Stats are still kept under the pair each exchange trades. Aliasing
+// a currency as itself removes its alias.
+func (s *Stats) Alias(c, as currency.Code) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if c.Match(as) {
+		delete(s.aliases, c.Upper())
+		return
+	}
+	s.aliases[c.Upper()] = as.Upper()
+}
 
-	if p.Quote == currency.USDT {
-		newPair, err := currency.NewPairFromStrings(p.Base.String(), currency.USD.String())
-		if err != nil {
+// Handlers returns stream handlers feeding the stats from an event bus
+func (s *Stats) Handlers() stream.Handlers {
+	return stream.Handlers{
+		Ticker: func(t *ticker.Price) {
+			if err := s.AddTicker(t); err != nil {
+				log.Debugf(log.Ticker, "stats: %v", err)
+			}
+		},
+		Trades: func(trades []trade.Data) {
+			if err := s.AddTrades(trades...); err != nil {
+				log.Debugf(log.Trade, "stats: %v", err)
+			}
+		},
+	}
+}
+
+// AddTicker adds a ticker's last price and volume
+func (s *Stats) AddTicker(t *ticker.Price) error {
+	if t == nil {
+		return errInvalidParams
+	}
+	price := t.Last
+	if price == 0 {
+		price = t.Close
+	}
+	if err := validate(t.ExchangeName, t.Pair, t.AssetType, price); err != nil {
+		return err
+	}
+	at := t.LastUpdated
+	if at.IsZero() {
+		at = s.now()
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	ser := s.get(t.ExchangeName, t.Pair, t.AssetType)
+	ser.tickerVolume = t.Volume
+	ser.observe(at, price, 0)
+	return nil
+}
+
+// AddTrades adds trades, counting them and their volume
+func (s *Stats) AddTrades(trades ...trade.Data) error {
+	for i := range trades {
+		if err := validate(trades[i].Exchange, trades[i].CurrencyPair, trades[i].AssetType, trades[i].Price); err != nil {
 			return err
 		}
-		Append(exchange, newPair, a, price, volume)
+		if trades[i].Amount == 0 {
+			return fmt.Errorf("%w, %s trade amount unset", errInvalidParams, trades[i].Exchange)
+		}
+	}
+	now := s.now()
+	s.m.Lock()
+	defer s.m.Unlock()
+	for i := range trades {
+		at := trades[i].Timestamp
+		if at.IsZero() {
+			at = now
+		}
+		ser := s.get(trades[i].Exchange, trades[i].CurrencyPair, trades[i].AssetType)
+		ser.observe(at, trades[i].Price, math.Abs(trades[i].Amount))
 	}
-
-	Append(exchange, p, a, price, volume)
 	return nil
 }
 
-// Append adds or updates the item stats for a specific
-// currency pair and asset type
-func Append(exchange string, p currency.Pair, a asset.Item, price, volume float64) {
-	if AlreadyExists(exchange, p, a, price, volume) {
-		return
+// Get returns the stats of an exchange's pair and asset over a window
+func (s *Stats) Get(exchange string, p currency.Pair, a asset.Item, w time.Duration) (Item, error) {
+	idx, err := s.windowIndex(w)
+	if err != nil {
+		return Item{}, err
 	}
+	s.m.RLock()
+	defer s.m.RUnlock()
+	ser, ok := s.series[strings.ToLower(exchange)][p.Base.Upper()][p.Quote.Upper()][a]
+	if !ok {
+		return Item{}, fmt.Errorf("%w for %s %s %s", errNoStats, exchange, p, a)
+	}
+	return ser.item(idx, s.now()), nil
+}
 
-	i := Item{
-		Exchange:  exchange,
-		Pair:      p,
-		AssetType: a,
-		Price:     price,
-		Volume:    volume,
+// Items returns the stats of every exchange with a pair and asset over a
+// window. Pairs match their reciprocal and currencies aliased to their
+// currency, exchanges with nothing seen in the window are left out.
+func (s *Stats) Items(p currency.Pair, a asset.Item, w time.Duration) ([]Item, error) {
+	idx, err := s.windowIndex(w)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	s.m.RLock()
+	defer s.m.RUnlock()
+	p = s.alias(p)
+	var items []Item
+	for _, m1 := range s.series {
+		for _, m2 := range m1 {
+			for _, m3 := range m2 {
+				ser, ok := m3[a]
+				if !ok || !s.alias(ser.pair).EqualIncludeReciprocal(p) {
+					continue
+				}
+				if item := ser.item(idx, now); !item.LastUpdated.IsZero() {
+					items = append(items, item)
+				}
+			}
+		}
+	}
+	return items, nil
+}
+
+// SortExchangesByVolume sorts the stats of a pair and asset over a window by
+// volume. Reverse will reverse the order from lowest to highest
+func (s *Stats) SortExchangesByVolume(p currency.Pair, a asset.Item, w time.Duration, reverse bool) ([]Item, error) {
+	items, err := s.Items(p, a, w)
+	if err != nil {
+		return nil, err
+	}
+	if reverse {
+		sort.Sort(sort.Reverse(ByVolume(items)))
+	} else {
+		sort.Sort(ByVolume(items))
 	}
+	return items, nil
+}
 
-	Items = append(Items, i)
+// SortExchangesByPrice sorts the stats of a pair and asset over a window by
+// price. Reverse will reverse the order from lowest to highest
+func (s *Stats) SortExchangesByPrice(p currency.Pair, a asset.Item, w time.Duration, reverse bool) ([]Item, error) {
+	items, err := s.Items(p, a, w)
+	if err != nil {
+		return nil, err
+	}
+	if reverse {
+		sort.Sort(sort.Reverse(ByPrice(items)))
+	} else {
+		sort.Sort(ByPrice(items))
+	}
+	return items, nil
 }
 
-// AlreadyExists checks to see if item info already exists
-// for a specific currency pair and asset type
-func AlreadyExists(exchange string, p currency.Pair, assetType asset.Item, price, volume float64) bool {
-	for i := range Items {
-		if Items[i].Exchange == exchange &&
-			Items[i].Pair.EqualIncludeReciprocal(p) &&
-			Items[i].AssetType == assetType {
-			Items[i].Price, Items[i].Volume = price, volume
-			return true
+// windowIndex returns the index of a tracked window
+func (s *Stats) windowIndex(w time.Duration) (int, error) {
+	for i := range s.windows {
+		if s.windows[i] == w {
+			return i, nil
 		}
 	}
-	return false
+	return 0, fmt.Errorf("%w %s", errWindowNotTracked, w)
 }
 
-// SortExchangesByVolume sorts item info by volume for a specific
-// currency pair and asset type. Reverse will reverse the order from lowest to
-// highest
-func SortExchangesByVolume(p currency.Pair, assetType asset.Item, reverse bool) []Item {
-	var result []Item
-	for x := range Items {
-		if Items[x].Pair.EqualIncludeReciprocal(p) &&
-			Items[x].AssetType == assetType {
-			result = append(result, Items[x])
+// get returns an exchange's series for a pair and asset, adding it when
+// missing. The write lock must be held.
+func (s *Stats) get(exchange string, p currency.Pair, a asset.Item) *series {
+	name := strings.ToLower(exchange)
+	base, quote := p.Base.Upper(), p.Quote.Upper()
+	m1, ok := s.series[name]
+	if !ok {
+		m1 = make(map[currency.Code]map[currency.Code]map[asset.Item]*series)
+		s.series[name] = m1
+	}
+	m2, ok := m1[base]
+	if !ok {
+		m2 = make(map[currency.Code]map[asset.Item]*series)
+		m1[base] = m2
+	}
+	m3, ok := m2[quote]
+	if !ok {
+		m3 = make(map[asset.Item]*series)
+		m2[quote] = m3
+	}
+	ser, ok := m3[a]
+	if !ok {
+		ser = &series{exchange: exchange, pair: p, asset: a}
+		for _, length := range s.windows {
+			ser.windows = append(ser.windows, &window{
+				length: length,
+				width:  length / bucketsPerWindow,
+			})
 		}
+		m3[a] = ser
 	}
+	return ser
+}
 
-	if reverse {
-		sort.Sort(sort.Reverse(ByVolume(result)))
-	} else {
-		sort.Sort(ByVolume(result))
+// observe adds a price seen at a time and the volume traded at it
+func (ser *series) observe(at time.Time, price, volume float64) {
+	if !at.Before(ser.lastUpdated) {
+		ser.last = price
+		ser.lastUpdated = at
+	}
+	for _, w := range ser.windows {
+		w.observe(at, price, volume)
+	}
+}
+
+// item returns the series' stats over a window
+func (ser *series) item(idx int, now time.Time) Item {
+	i := Item{
+		Exchange:     ser.exchange,
+		Pair:         ser.pair,
+		AssetType:    ser.asset,
+		Window:       ser.windows[idx].length,
+		Price:        ser.last,
+		TickerVolume: ser.tickerVolume,
+	}
+	if ser.windows[idx].aggregate(now, &i) {
+		i.LastUpdated = ser.lastUpdated
 	}
-	return result
+	return i
 }
 
-// SortExchangesByPrice sorts item info by volume for a specific
-// currency pair and asset type. Reverse will reverse the order from lowest to
-// highest
-func SortExchangesByPrice(p currency.Pair, assetType asset.Item, reverse bool) []Item {
-	var result []Item
-	for x := range Items {
-		if Items[x].Pair.EqualIncludeReciprocal(p) &&
-			Items[x].AssetType == assetType {
-			result = append(result, Items[x])
+// observe adds a price and volume to the bucket covering a time. Times older
+// than the bucket now in their slot have rolled out of the window.
+func (w *window) observe(at time.Time, price, volume float64) {
+	start := at.Truncate(w.width)
+	b := &w.buckets[(start.UnixNano()/int64(w.width))%bucketsPerWindow]
+	switch {
+	case start.Before(b.start):
+		return
+	case !start.Equal(b.start):
+		*b = bucket{start: start, open: price, high: price, low: price}
+	}
+	if price > b.high {
+		b.high = price
+	}
+	if price < b.low {
+		b.low = price
+	}
+	if volume > 0 {
+		b.volume += volume
+		b.quoteVolume += volume * price
+		b.trades++
+	}
+}
+
+// aggregate sets an item's window stats from the buckets within the window
+// and reports whether there were any
+func (w *window) aggregate(now time.Time, i *Item) bool {
+	oldest := now.Truncate(w.width).Add(-w.width * (bucketsPerWindow - 1))
+	var first time.Time
+	for x := range w.buckets {
+		b := &w.buckets[x]
+		if b.start.IsZero() || b.start.Before(oldest) {
+			continue
+		}
+		if first.IsZero() || b.start.Before(first) {
+			first = b.start
+			i.Open = b.open
 		}
+		if i.High == 0 || b.high > i.High {
+			i.High = b.high
+		}
+		if i.Low == 0 || b.low < i.Low {
+			i.Low = b.low
+		}
+		i.Volume += b.volume
+		i.QuoteVolume += b.quoteVolume
+		i.Trades += b.trades
+	}
+	if i.Volume > 0 {
+		i.VWAP = i.QuoteVolume / i.Volume
 	}
+	return !first.IsZero()
+}
 
-	if reverse {
-		sort.Sort(sort.Reverse(ByPrice(result)))
-	} else {
-		sort.Sort(ByPrice(result))
+// validate checks what is added identifies an exchange's pair and asset
+func validate(exchange string, p currency.Pair, a asset.Item, price float64) error {
+	if exchange == "" ||
+		a == "" ||
+		price <= 0 ||
+		p.Base.IsEmpty() ||
+		p.Quote.IsEmpty() {
+		return errInvalidParams
+	}
+	return nil
+}
+
+// alias returns a pair with its aliased currencies replaced. The lock must
+// be held.
+func (s *Stats) alias(p currency.Pair) currency.Pair {
+	if as, ok := s.aliases[p.Base.Upper()]; ok {
+		p.Base = as
+	}
+	if as, ok := s.aliases[p.Quote.Upper()]; ok {
+		p.Quote = as
 	}
-	return result
+	return p
 }
@@ -1,220 +1,302 @@
 package stats
 
 import (
+	"errors"
+	"math"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/openware/irix/ticker"
 	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/trade"
 )
 
 const (
 	testExchange = "OKEX"
 )
 
-func TestLenByPrice(t *testing.T) {
-	p, err := currency.NewPairFromStrings("BTC", "USD")
-	if err != nil {
-		t.Fatal(err)
-	}
-	Items = []Item{
-		{
-			Exchange:  testExchange,
-			Pair:      p,
-			AssetType: asset.Spot,
-			Price:     1200,
-			Volume:    5,
-		},
-	}
-
-	if ByPrice.Len(Items) < 1 {
-		t.Error("stats LenByPrice() length not correct.")
-	}
-}
+var testPair = currency.NewPair(currency.BTC, currency.USD)
 
-func TestLessByPrice(t *testing.T) {
-	p, err := currency.NewPairFromStrings("BTC", "USD")
-	if err != nil {
-		t.Fatal(err)
-	}
-	Items = []Item{
+func testItems() []Item {
+	return []Item{
 		{
-			Exchange:  "alphapoint",
-			Pair:      p,
+			Exchange:  "bitstamp",
+			Pair:      testPair,
 			AssetType: asset.Spot,
-			Price:     1200,
+			Price:     1324,
 			Volume:    5,
 		},
 		{
 			Exchange:  "bitfinex",
-			Pair:      p,
+			Pair:      testPair,
 			AssetType: asset.Spot,
 			Price:     1198,
 			Volume:    20,
 		},
 	}
-
-	if !ByPrice.Less(Items, 1, 0) {
-		t.Error("stats LessByPrice() incorrect return.")
-	}
-	if ByPrice.Less(Items, 0, 1) {
-		t.Error("stats LessByPrice() incorrect return.")
-	}
 }
 
-func TestSwapByPrice(t *testing.T) {
-	p, err := currency.NewPairFromStrings("BTC", "USD")
+// testStats returns stats whose clock is set by the returned func
+func testStats(t *testing.T) (*Stats, func(time.Time)) {
+	t.Helper()
+	s, err := New()
 	if err != nil {
 		t.Fatal(err)
 	}
-	Items = []Item{
-		{
-			Exchange:  "bitstamp",
-			Pair:      p,
-			AssetType: asset.Spot,
-			Price:     1324,
-			Volume:    5,
-		},
-		{
-			Exchange:  "bitfinex",
-			Pair:      p,
-			AssetType: asset.Spot,
-			Price:     7863,
-			Volume:    20,
-		},
+	var m sync.Mutex
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time {
+		m.Lock()
+		defer m.Unlock()
+		return now
 	}
-
-	ByPrice.Swap(Items, 0, 1)
-	if Items[0].Exchange != "bitfinex" || Items[1].Exchange != "bitstamp" {
-		t.Error("stats SwapByPrice did not swap values.")
+	return s, func(t time.Time) {
+		m.Lock()
+		now = t
+		m.Unlock()
 	}
 }
 
-func TestLenByVolume(t *testing.T) {
-	if ByVolume.Len(Items) != 2 {
-		t.Error("stats lenByVolume did not swap values.")
+func testTrade(exchange string, p currency.Pair, price, amount float64, at time.Time) trade.Data {
+	return trade.Data{
+		Exchange:     exchange,
+		CurrencyPair: p,
+		AssetType:    asset.Spot,
+		Price:        price,
+		Amount:       amount,
+		Timestamp:    at,
 	}
 }
 
-func TestLessByVolume(t *testing.T) {
-	if !ByVolume.Less(Items, 1, 0) {
-		t.Error("stats LessByVolume() incorrect return.")
+func TestByPrice(t *testing.T) {
+	t.Parallel()
+	items := testItems()
+	if ByPrice.Len(items) != 2 {
+		t.Error("stats LenByPrice() length not correct.")
 	}
-	if ByVolume.Less(Items, 0, 1) {
-		t.Error("stats LessByVolume() incorrect return.")
+	if !ByPrice.Less(items, 1, 0) || ByPrice.Less(items, 0, 1) {
+		t.Error("stats LessByPrice() incorrect return.")
+	}
+	ByPrice.Swap(items, 0, 1)
+	if items[0].Exchange != "bitfinex" || items[1].Exchange != "bitstamp" {
+		t.Error("stats SwapByPrice did not swap values.")
 	}
 }
 
-func TestSwapByVolume(t *testing.T) {
-	ByPrice.Swap(Items, 0, 1)
-
-	if Items[1].Exchange != "bitfinex" || Items[0].Exchange != "bitstamp" {
+func TestByVolume(t *testing.T) {
+	t.Parallel()
+	items := testItems()
+	if ByVolume.Len(items) != 2 {
+		t.Error("stats LenByVolume() length not correct.")
+	}
+	if !ByVolume.Less(items, 0, 1) || ByVolume.Less(items, 1, 0) {
+		t.Error("stats LessByVolume() incorrect return.")
+	}
+	ByVolume.Swap(items, 0, 1)
+	if items[0].Exchange != "bitfinex" || items[1].Exchange != "bitstamp" {
 		t.Error("stats SwapByVolume did not swap values.")
 	}
 }
 
-func TestAdd(t *testing.T) {
-	Items = Items[:0]
-	p, err := currency.NewPairFromStrings("BTC", "USD")
-	if err != nil {
-		t.Fatal(err)
+func TestNew(t *testing.T) {
+	t.Parallel()
+	if _, err := New(time.Millisecond); !errors.Is(err, errInvalidWindow) {
+		t.Errorf("expected %v, received %v", errInvalidWindow, err)
 	}
-	err = Add(testExchange, p, asset.Spot, 1200, 42)
+	s, err := New(time.Minute)
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	if len(Items) < 1 {
-		t.Error("stats Add did not add exchange info.")
+	if _, err = s.Get(testExchange, testPair, asset.Spot, time.Hour); !errors.Is(err, errWindowNotTracked) {
+		t.Errorf("expected %v, received %v", errWindowNotTracked, err)
 	}
-
-	err = Add("", p, "", 0, 0)
-	if err == nil {
-		t.Fatal("error cannot be nil")
+	if _, err = s.Get(testExchange, testPair, asset.Spot, time.Minute); !errors.Is(err, errNoStats) {
+		t.Errorf("expected %v, received %v", errNoStats, err)
 	}
+}
 
-	if len(Items) != 1 {
-		t.Error("stats Add did not add exchange info.")
+func TestAdd(t *testing.T) {
+	t.Parallel()
+	s, _ := testStats(t)
+	if err := s.AddTicker(&ticker.Price{ExchangeName: testExchange, Pair: testPair}); !errors.Is(err, errInvalidParams) {
+		t.Errorf("expected %v, received %v", errInvalidParams, err)
+	}
+	if err := s.AddTrades(testTrade(testExchange, testPair, 100, 0, time.Time{})); !errors.Is(err, errInvalidParams) {
+		t.Errorf("expected %v, received %v", errInvalidParams, err)
 	}
 
-	p.Base = currency.XBT
-	err = Add(testExchange, p, asset.Spot, 1201, 43)
+	now := s.now()
+	err := s.AddTrades(
+		testTrade(testExchange, testPair, 100, 1, now.Add(-50*time.Minute)),
+		testTrade(testExchange, testPair, 110, 2, now.Add(-30*time.Second)),
+		// Sells are sent with negative amounts by some exchanges
+		testTrade(testExchange, testPair, 90, -1, now),
+	)
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	if Items[1].Pair.String() != "XBTUSD" {
-		t.Fatal("stats Add did not add exchange info.")
+	err = s.AddTicker(&ticker.Price{
+		ExchangeName: testExchange,
+		Pair:         testPair,
+		AssetType:    asset.Spot,
+		Last:         95,
+		Volume:       1000,
+		LastUpdated:  now,
+	})
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	p, err = currency.NewPairFromStrings("ETH", "USDT")
+	i, err := s.Get("okex", testPair, asset.Spot, OneMinute)
 	if err != nil {
 		t.Fatal(err)
 	}
-	Add(testExchange, p, asset.Spot, 300, 1000)
-
-	if Items[2].Pair.String() != "ETHUSD" {
-		t.Fatal("stats Add did not add exchange info.")
+	if i.Price != 95 || i.Open != 110 || i.High != 110 || i.Low != 90 || i.TickerVolume != 1000 {
+		t.Errorf("unexpected prices %+v", i)
+	}
+	// Tickers move prices but add no volume
+	if i.Volume != 3 || i.Trades != 2 || math.Abs(i.VWAP-(220+90)/3.0) > 1e-9 || !i.LastUpdated.Equal(now) {
+		t.Errorf("unexpected volume %+v", i)
+	}
+	if i, err = s.Get(testExchange, testPair, asset.Spot, OneHour); err != nil {
+		t.Fatal(err)
+	}
+	if i.Open != 100 || i.Volume != 4 || i.Trades != 3 {
+		t.Errorf("unexpected hourly stats %+v", i)
 	}
 }
 
-func TestAppend(t *testing.T) {
-	p, err := currency.NewPairFromStrings("BTC", "USD")
+func TestRolling(t *testing.T) {
+	t.Parallel()
+	s, setNow := testStats(t)
+	start := s.now()
+	if err := s.AddTrades(testTrade(testExchange, testPair, 100, 1, start)); err != nil {
+		t.Fatal(err)
+	}
+
+	setNow(start.Add(2 * time.Minute))
+	i, err := s.Get(testExchange, testPair, asset.Spot, OneMinute)
 	if err != nil {
 		t.Fatal(err)
 	}
-	Append("sillyexchange", p, asset.Spot, 1234, 45)
-	if len(Items) < 2 {
-		t.Error("stats Append did not add exchange values.")
+	if i.Volume != 0 || i.Trades != 0 || !i.LastUpdated.IsZero() || i.Price != 100 {
+		t.Errorf("expected trade to have rolled out of the minute %+v", i)
+	}
+	if i, _ = s.Get(testExchange, testPair, asset.Spot, FiveMinutes); i.Volume != 1 {
+		t.Errorf("expected trade within five minutes %+v", i)
+	}
+	if items, _ := s.Items(testPair, asset.Spot, OneMinute); len(items) != 0 {
+		t.Errorf("expected exchange without trades in the window to be left out %+v", items)
 	}
 
-	Append("sillyexchange", p, asset.Spot, 1234, 45)
-	if len(Items) == 3 {
-		t.Error("stats Append added exchange values")
+	// The slot's bucket is reused once the window rolls round
+	if err = s.AddTrades(testTrade(testExchange, testPair, 120, 2, s.now())); err != nil {
+		t.Fatal(err)
+	}
+	// Trades older than the slot's bucket have rolled out
+	if err = s.AddTrades(testTrade(testExchange, testPair, 80, 5, start)); err != nil {
+		t.Fatal(err)
+	}
+	if i, _ = s.Get(testExchange, testPair, asset.Spot, OneMinute); i.Volume != 2 || i.Low != 120 || i.Price != 120 {
+		t.Errorf("unexpected minute stats %+v", i)
 	}
 }
 
-func TestAlreadyExists(t *testing.T) {
-	p, err := currency.NewPairFromStrings("BTC", "USD")
+func TestSortExchanges(t *testing.T) {
+	t.Parallel()
+	s, _ := testStats(t)
+	now := s.now()
+	err := s.AddTrades(
+		testTrade(testExchange, testPair, 1200, 42, now),
+		testTrade("kraken", currency.NewPair(currency.XBT, currency.USD), 1201, 43, now),
+		testTrade("binance", currency.NewPair(currency.BTC, currency.USDT), 1199, 1, now),
+		testTrade("sillyexchange", currency.NewPair(currency.USD, currency.BTC), 1234, 45, now),
+		testTrade(testExchange, currency.NewPair(currency.ETH, currency.USD), 300, 1000, now),
+	)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if !AlreadyExists(testExchange, p, asset.Spot, 1200, 42) {
-		t.Error("stats AlreadyExists exchange does not exist.")
+
+	// Pairs match their reciprocal and XBT and USDT are aliased by default
+	topVolume, err := s.SortExchangesByVolume(testPair, asset.Spot, OneDay, true)
+	if err != nil {
+		t.Fatal(err)
 	}
-	p.Base = currency.NewCode("dii")
-	if AlreadyExists("bla", p, asset.Spot, 1234, 123) {
-		t.Error("stats AlreadyExists found incorrect exchange.")
+	if len(topVolume) != 4 || topVolume[0].Exchange != "sillyexchange" || topVolume[3].Exchange != "binance" {
+		t.Errorf("stats SortExchangesByVolume incorrectly sorted values %+v", topVolume)
 	}
-}
-
-func TestSortExchangesByVolume(t *testing.T) {
-	p, err := currency.NewPairFromStrings("BTC", "USD")
+	topPrice, err := s.SortExchangesByPrice(testPair, asset.Spot, OneDay, false)
 	if err != nil {
 		t.Fatal(err)
 	}
-	topVolume := SortExchangesByVolume(p, asset.Spot, true)
-	if topVolume[0].Exchange != "sillyexchange" {
-		t.Error("stats SortExchangesByVolume incorrectly sorted values.")
+	if topPrice[0].Exchange != "binance" || topPrice[3].Exchange != "sillyexchange" {
+		t.Errorf("stats SortExchangesByPrice incorrectly sorted values %+v", topPrice)
 	}
 
-	topVolume = SortExchangesByVolume(p, asset.Spot, false)
-	if topVolume[0].Exchange != testExchange {
-		t.Error("stats SortExchangesByVolume incorrectly sorted values.")
+	// Aliasing a currency as itself keeps it apart
+	s.Alias(currency.USDT, currency.USDT)
+	if topVolume, err = s.SortExchangesByVolume(testPair, asset.Spot, OneDay, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(topVolume) != 3 || topVolume[2].Exchange != testExchange {
+		t.Errorf("stats SortExchangesByVolume incorrectly sorted values %+v", topVolume)
+	}
+	if _, err = s.SortExchangesByPrice(testPair, asset.Spot, time.Second, false); !errors.Is(err, errWindowNotTracked) {
+		t.Errorf("expected %v, received %v", errWindowNotTracked, err)
 	}
 }
 
-func TestSortExchangesByPrice(t *testing.T) {
-	p, err := currency.NewPairFromStrings("BTC", "USD")
+func TestHandlers(t *testing.T) {
+	t.Parallel()
+	s, _ := testStats(t)
+	h := s.Handlers()
+	h.Ticker(&ticker.Price{ExchangeName: testExchange, Pair: testPair, AssetType: asset.Spot, Last: 10})
+	h.Trades([]trade.Data{testTrade(testExchange, testPair, 11, 1, time.Time{})})
+	// Invalid data is dropped
+	h.Ticker(&ticker.Price{})
+	i, err := s.Get(testExchange, testPair, asset.Spot, OneMinute)
 	if err != nil {
 		t.Fatal(err)
 	}
-	topPrice := SortExchangesByPrice(p, asset.Spot, true)
-	if topPrice[0].Exchange != "sillyexchange" {
-		t.Error("stats SortExchangesByPrice incorrectly sorted values.")
+	if i.Price != 11 || i.Trades != 1 || i.Low != 10 {
+		t.Errorf("unexpected stats %+v", i)
 	}
+}
 
-	topPrice = SortExchangesByPrice(p, asset.Spot, false)
-	if topPrice[0].Exchange != testExchange {
-		t.Error("stats SortExchangesByPrice incorrectly sorted values.")
+func TestConcurrency(t *testing.T) {
+	t.Parallel()
+	s, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for x := 0; x < 4; x++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if err := s.AddTrades(testTrade(testExchange, testPair, 100, 1, time.Time{})); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if _, err := s.SortExchangesByVolume(testPair, asset.Spot, OneMinute, true); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	i, err := s.Get(testExchange, testPair, asset.Spot, OneMinute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i.Trades != 400 {
+		t.Errorf("expected 400 trades, received %d", i.Trades)
 	}
 }
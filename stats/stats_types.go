@@ -0,0 +1,98 @@
+package stats
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+)
+
+// Rolling windows stats can be kept over
+const (
+	OneMinute   = time.Minute
+	FiveMinutes = 5 * time.Minute
+	OneHour     = time.Hour
+	OneDay      = 24 * time.Hour
+)
+
+// bucketsPerWindow is how many buckets a window is divided into, a window
+// rolls forward a bucket at a time
+const bucketsPerWindow = 60
+
+// DefaultWindows are the windows kept when none are supplied
+var DefaultWindows = []time.Duration{OneMinute, FiveMinutes, OneHour, OneDay}
+
+var (
+	errInvalidParams    = errors.New("cannot add or update, invalid params")
+	errInvalidWindow    = errors.New("window must be at least a second")
+	errWindowNotTracked = errors.New("window not tracked")
+	errNoStats          = errors.New("no stats")
+)
+
+// Item holds the stats of an exchange's pair and asset over a window
+type Item struct {
+	Exchange  string
+	Pair      currency.Pair
+	AssetType asset.Item
+	Window    time.Duration
+	// Price is the last price seen, which may predate the window
+	Price float64
+	Open  float64
+	High  float64
+	Low   float64
+	// Volume and QuoteVolume are traded in the window
+	Volume      float64
+	QuoteVolume float64
+	VWAP        float64
+	Trades      int64
+	// TickerVolume is the volume reported by the exchange's latest ticker
+	TickerVolume float64
+	LastUpdated  time.Time
+}
+
+// ByPrice allows sorting by price
+type ByPrice []Item
+
+// ByVolume allows sorting by volume
+type ByVolume []Item
+
+// Stats keeps rolling window stats per exchange, pair and asset fed from
+// ticker and trade streams
+type Stats struct {
+	windows []time.Duration
+	now     func() time.Time
+	series  map[string]map[currency.Code]map[currency.Code]map[asset.Item]*series
+	aliases map[currency.Code]currency.Code
+	m       sync.RWMutex
+}
+
+// series is the stats of an exchange's pair and asset
+type series struct {
+	exchange     string
+	pair         currency.Pair
+	asset        asset.Item
+	windows      []*window
+	last         float64
+	tickerVolume float64
+	lastUpdated  time.Time
+}
+
+// window is a ring of buckets covering a rolling window
+type window struct {
+	length  time.Duration
+	width   time.Duration
+	buckets [bucketsPerWindow]bucket
+}
+
+// bucket aggregates what was seen from its start for a window's bucket width
+type bucket struct {
+	start       time.Time
+	open        float64
+	high        float64
+	low         float64
+	volume      float64
+	quoteVolume float64
+	trades      int64
+}
@@ -0,0 +1,474 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/openware/irix/metrics"
+	"github.com/openware/irix/ticker"
+	"github.com/openware/pkg/account"
+	"github.com/openware/pkg/log"
+	"github.com/openware/pkg/order"
+	"github.com/openware/pkg/orderbook"
+	"github.com/openware/pkg/trade"
+)
+
+// DataKind groups data passed to the data handler so each group can be given
+// its own backpressure policy
+type DataKind uint8
+
+// Data kinds
+const (
+	// KindOther is any data without a kind of its own
+	KindOther DataKind = iota
+	KindTicker
+	KindOrderbook
+	KindKline
+	KindTrade
+	KindOrder
+	KindFunding
+	KindError
+	// KindAccount is balance and holdings data. Exchange specific account
+	// types are KindOther.
+	KindAccount
+	kindCount
+)
+
+// String implements the stringer interface
+func (k DataKind) String() string {
+	switch k {
+	case KindTicker:
+		return "ticker"
+	case KindOrderbook:
+		return "orderbook"
+	case KindKline:
+		return "kline"
+	case KindTrade:
+		return "trade"
+	case KindOrder:
+		return "order"
+	case KindFunding:
+		return "funding"
+	case KindError:
+		return "error"
+	case KindAccount:
+		return "account"
+	}
+	return "other"
+}
+
+// guaranteed reports whether data of kind k must never be dropped, losing an
+// order or balance update leaves the consumer with a wrong view of the
+// account
+func guaranteed(k DataKind) bool {
+	return k == KindOrder || k == KindAccount
+}
+
+// kindOf returns the kind of data passed to the data handler
+func kindOf(d interface{}) DataKind {
+	switch d.(type) {
+	case *ticker.Price, ticker.Price:
+		return KindTicker
+	case *orderbook.Base:
+		return KindOrderbook
+	case KlineData, *KlineData:
+		return KindKline
+	case []trade.Data, trade.Data:
+		return KindTrade
+	case *order.Detail, order.Detail, *order.Modify, order.Modify,
+		*order.Cancel, order.Cancel:
+		return KindOrder
+	case FundingData:
+		return KindFunding
+	case error, order.ClassificationError:
+		return KindError
+	case account.Holdings, *account.Holdings, account.SubAccount,
+		*account.SubAccount, account.Balance, []account.Balance:
+		return KindAccount
+	}
+	return KindOther
+}
+
+// forwardOrder is the order queued data is passed to ToRoutine in, order
+// updates and errors go first so market data can never hold them up
+var forwardOrder = [kindCount]DataKind{
+	KindOrder,
+	KindAccount,
+	KindError,
+	KindFunding,
+	KindOther,
+	KindTrade,
+	KindKline,
+	KindOrderbook,
+	KindTicker,
+}
+
+// Policy decides what happens to data when its queue is full
+type Policy uint8
+
+// Backpressure policies
+const (
+	// PolicyBlock holds the data handler until the consumer catches up
+	PolicyBlock Policy = iota
+	// PolicyDropOldest discards the longest queued item to make room
+	PolicyDropOldest
+	// PolicyDropNewest discards the incoming item
+	PolicyDropNewest
+	// PolicyConflate keeps only the latest value per pair and asset, queued
+	// values are replaced in place. Suited to tickers and orderbook
+	// snapshots where only the current state matters.
+	PolicyConflate
+	policyCount
+)
+
+// DefaultBackpressureSize is the queue size used when a policy sets none
+const DefaultBackpressureSize = 1024
+
+var (
+	errInvalidPolicy  = errors.New("invalid backpressure policy")
+	errGuaranteedKind = errors.New("order and account data must use PolicyBlock")
+)
+
+// Backpressure configures the queue data of one kind waits in for ToRoutine
+type Backpressure struct {
+	Policy Policy
+	// Size bounds the queue, under PolicyConflate it bounds the number of
+	// distinct pairs. Zero uses DefaultBackpressureSize.
+	Size int
+}
+
+// DefaultBackpressure returns the policies used for kinds which are not
+// configured. Every kind blocks so nothing is lost unless SetBackpressure
+// chooses to drop or conflate market data.
+func DefaultBackpressure() map[DataKind]Backpressure {
+	return map[DataKind]Backpressure{
+		KindTicker:    {Policy: PolicyBlock},
+		KindOrderbook: {Policy: PolicyBlock},
+		KindKline:     {Policy: PolicyBlock},
+		KindTrade:     {Policy: PolicyBlock},
+		KindOrder:     {Policy: PolicyBlock},
+		KindAccount:   {Policy: PolicyBlock},
+		KindFunding:   {Policy: PolicyBlock},
+		KindError:     {Policy: PolicyBlock},
+		KindOther:     {Policy: PolicyBlock},
+	}
+}
+
+// validateBackpressure checks a policy for data of kind k
+func validateBackpressure(k DataKind, b Backpressure) error {
+	if k >= kindCount {
+		return fmt.Errorf("%w: unknown data kind %d", errInvalidPolicy, k)
+	}
+	if b.Policy >= policyCount {
+		return fmt.Errorf("%w: %d for %s", errInvalidPolicy, b.Policy, k)
+	}
+	if b.Size < 0 {
+		return fmt.Errorf("%w: %s queue size cannot be negative", errInvalidPolicy, k)
+	}
+	if guaranteed(k) && b.Policy != PolicyBlock {
+		return fmt.Errorf("%w: %s", errGuaranteedKind, k)
+	}
+	return nil
+}
+
+// SetBackpressure sets the policy for data of kind k. It applies to ToRoutine
+// from the next connection and to event bus subscriptions made afterwards.
+func (w *Websocket) SetBackpressure(k DataKind, b Backpressure) error {
+	if err := validateBackpressure(k, b); err != nil {
+		return err
+	}
+	if err := w.Events().SetBackpressure(k, b); err != nil {
+		return err
+	}
+	w.backpressureMtx.Lock()
+	if w.backpressure == nil {
+		w.backpressure = DefaultBackpressure()
+	}
+	w.backpressure[k] = b
+	w.backpressureMtx.Unlock()
+	return nil
+}
+
+// Dropped returns the number of items of kind k discarded by backpressure,
+// including conflated values
+func (w *Websocket) Dropped(k DataKind) uint64 {
+	if k >= kindCount {
+		return 0
+	}
+	return atomic.LoadUint64(&w.dropped[k])
+}
+
+// newPipeline returns a pipeline using the websocket's policies
+func (w *Websocket) newPipeline() *pipeline {
+	w.backpressureMtx.Lock()
+	defer w.backpressureMtx.Unlock()
+	if w.backpressure == nil {
+		w.backpressure = DefaultBackpressure()
+	}
+	return newPipeline(w.backpressure)
+}
+
+// DisableToRoutine stops data being queued for ToRoutine, for consumers which
+// only use the event bus. Otherwise ToRoutine must be read as data of blocking
+// kinds waits for room in its queue.
+func (w *Websocket) DisableToRoutine() {
+	atomic.StoreInt32(&w.toRoutineDisabled, 1)
+}
+
+// enqueue queues data for ToRoutine under its kind's policy. It returns false
+// if the websocket shut down while waiting for room.
+func (w *Websocket) enqueue(p *pipeline, d interface{}) bool {
+	if atomic.LoadInt32(&w.toRoutineDisabled) == 1 {
+		return true
+	}
+	k := kindOf(d)
+	switch p.offer(k, d) {
+	case offerQueued:
+		return true
+	case offerDropped:
+		w.discarded(k, d, metrics.WebsocketDataDropped)
+		return true
+	case offerConflated:
+		w.discarded(k, d, metrics.WebsocketDataConflated)
+		return true
+	}
+	w.recorder().AddCounter(metrics.DataHandlerBacklogEvents,
+		metrics.Labels{Exchange: w.exchangeName}, 1)
+	log.Warnf(log.WebsocketMgr,
+		"%s exchange backlog in websocket processing detected",
+		w.exchangeName)
+	return p.wait(k, d, w.ShutdownC)
+}
+
+// discarded records data lost to backpressure
+func (w *Websocket) discarded(k DataKind, d interface{}, metric string) {
+	atomic.AddUint64(&w.dropped[k], 1)
+	w.recorder().AddCounter(metric,
+		metrics.Labels{Exchange: w.exchangeName, Asset: assetOf(d)}, 1)
+}
+
+// forward passes queued data to ToRoutine until shutdown
+func (w *Websocket) forward(p *pipeline) {
+	defer w.Wg.Done()
+	for {
+		d, ok := p.next(w.ShutdownC)
+		if !ok {
+			return
+		}
+		select {
+		case w.ToRoutine <- d:
+		case <-w.ShutdownC:
+			return
+		}
+	}
+}
+
+// offerResult is the outcome of offering data to a pipeline
+type offerResult uint8
+
+const (
+	offerQueued offerResult = iota
+	offerDropped
+	offerConflated
+	offerFull
+)
+
+// pipeline holds data waiting for a consumer in a bounded queue per kind. It
+// backs ToRoutine, fed by the data monitor, and each event bus subscription,
+// fed by every websocket publishing on the bus. It has a single consumer.
+type pipeline struct {
+	queues [kindCount]*kindQueue
+	// ready is signalled when data is queued and space when it is taken
+	ready chan struct{}
+	space chan struct{}
+	mtx   sync.Mutex
+}
+
+// newPipeline returns a pipeline with a queue per kind, kinds missing from
+// policies block
+func newPipeline(policies map[DataKind]Backpressure) *pipeline {
+	p := &pipeline{
+		ready: make(chan struct{}, 1),
+		space: make(chan struct{}, 1),
+	}
+	for k := range p.queues {
+		b := policies[DataKind(k)]
+		if b.Size <= 0 {
+			b.Size = DefaultBackpressureSize
+		}
+		q := &kindQueue{Backpressure: b}
+		if b.Policy == PolicyConflate {
+			q.latest = make(map[string]interface{})
+		}
+		p.queues[k] = q
+	}
+	return p
+}
+
+// offer queues d without blocking, offerFull is returned when d's queue is
+// full and its policy blocks
+func (p *pipeline) offer(k DataKind, d interface{}) offerResult {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	r := p.queues[k].push(d)
+	if r != offerFull {
+		signal(p.ready)
+	}
+	return r
+}
+
+// wait queues d once its queue has room, it returns false on shutdown
+func (p *pipeline) wait(k DataKind, d interface{}, shutdown <-chan struct{}) bool {
+	for {
+		if p.offer(k, d) != offerFull {
+			// Pass the wake up on as several producers may be waiting and
+			// only one signal is buffered
+			signal(p.space)
+			return true
+		}
+		select {
+		case <-p.space:
+		case <-shutdown:
+			return false
+		}
+	}
+}
+
+// next returns the most urgent queued data, waiting until there is some. It
+// returns false on shutdown.
+func (p *pipeline) next(shutdown <-chan struct{}) (interface{}, bool) {
+	for {
+		p.mtx.Lock()
+		for _, k := range forwardOrder {
+			if d, ok := p.queues[k].pop(); ok {
+				p.mtx.Unlock()
+				signal(p.space)
+				return d, true
+			}
+		}
+		p.mtx.Unlock()
+		select {
+		case <-p.ready:
+		case <-shutdown:
+			return nil, false
+		}
+	}
+}
+
+// len returns the number of queued items
+func (p *pipeline) len() int {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	var n int
+	for _, q := range p.queues {
+		n += len(q.items) + len(q.keys)
+	}
+	return n
+}
+
+// clear discards every queued item and wakes a blocked producer
+func (p *pipeline) clear() {
+	p.mtx.Lock()
+	for _, q := range p.queues {
+		q.items = nil
+		q.keys = nil
+		if q.latest != nil {
+			q.latest = make(map[string]interface{})
+		}
+	}
+	p.mtx.Unlock()
+	signal(p.space)
+}
+
+// signal wakes a waiter on c without blocking
+func signal(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
+
+// kindQueue is the queue for one kind of data. Conflating queues keep the
+// order pairs were first queued in keys and their latest value in latest.
+type kindQueue struct {
+	Backpressure
+	items  []interface{}
+	keys   []string
+	latest map[string]interface{}
+}
+
+// push applies the queue's policy to d
+func (q *kindQueue) push(d interface{}) offerResult {
+	if q.Policy == PolicyConflate {
+		key := conflationKey(d)
+		if _, ok := q.latest[key]; ok {
+			q.latest[key] = d
+			return offerConflated
+		}
+		r := offerQueued
+		if len(q.keys) >= q.Size {
+			delete(q.latest, q.keys[0])
+			q.keys[0] = ""
+			q.keys = q.keys[1:]
+			r = offerDropped
+		}
+		q.keys = append(q.keys, key)
+		q.latest[key] = d
+		return r
+	}
+	if len(q.items) < q.Size {
+		q.items = append(q.items, d)
+		return offerQueued
+	}
+	switch q.Policy {
+	case PolicyDropOldest:
+		q.items[0] = nil
+		q.items = append(q.items[1:], d)
+		return offerDropped
+	case PolicyDropNewest:
+		return offerDropped
+	}
+	return offerFull
+}
+
+// pop removes the next item
+func (q *kindQueue) pop() (interface{}, bool) {
+	if q.Policy == PolicyConflate {
+		if len(q.keys) == 0 {
+			return nil, false
+		}
+		key := q.keys[0]
+		q.keys[0] = ""
+		q.keys = q.keys[1:]
+		d := q.latest[key]
+		delete(q.latest, key)
+		return d, true
+	}
+	if len(q.items) == 0 {
+		return nil, false
+	}
+	d := q.items[0]
+	q.items[0] = nil
+	q.items = q.items[1:]
+	return d, true
+}
+
+// conflationKey identifies the stream data belongs to, klines of different
+// intervals are kept apart and events on a shared bus are kept apart by
+// exchange
+func conflationKey(d interface{}) string {
+	if ev, ok := d.(Event); ok {
+		return ev.Exchange + ":" + conflationKey(ev.Data)
+	}
+	e := newEvent("", d)
+	key := e.Asset.String() + ":" + e.Pair.String()
+	switch v := d.(type) {
+	case KlineData:
+		key += ":" + v.Interval
+	case *KlineData:
+		key += ":" + v.Interval
+	}
+	return key
+}
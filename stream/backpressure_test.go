@@ -0,0 +1,322 @@
+package stream
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openware/irix/ticker"
+	"github.com/openware/pkg/account"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/order"
+	"github.com/openware/pkg/orderbook"
+	"github.com/openware/pkg/trade"
+)
+
+func TestKindOf(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		data interface{}
+		kind DataKind
+	}{
+		{&ticker.Price{}, KindTicker},
+		{ticker.Price{}, KindTicker},
+		{&orderbook.Base{}, KindOrderbook},
+		{KlineData{}, KindKline},
+		{[]trade.Data{}, KindTrade},
+		{&order.Detail{}, KindOrder},
+		{&order.Modify{}, KindOrder},
+		{FundingData{}, KindFunding},
+		{errors.New("failed"), KindError},
+		{order.ClassificationError{}, KindError},
+		{account.Holdings{}, KindAccount},
+		{[]account.Balance{}, KindAccount},
+		{UnhandledMessageWarning{}, KindOther},
+	} {
+		if k := kindOf(tc.data); k != tc.kind {
+			t.Errorf("%T: expected %s, received %s", tc.data, tc.kind, k)
+		}
+	}
+}
+
+func TestSetBackpressure(t *testing.T) {
+	t.Parallel()
+	w := New()
+	if err := w.SetBackpressure(kindCount, Backpressure{}); !errors.Is(err, errInvalidPolicy) {
+		t.Errorf("expected %v, received %v", errInvalidPolicy, err)
+	}
+	if err := w.SetBackpressure(KindTicker, Backpressure{Policy: policyCount}); !errors.Is(err, errInvalidPolicy) {
+		t.Errorf("expected %v, received %v", errInvalidPolicy, err)
+	}
+	if err := w.SetBackpressure(KindTicker, Backpressure{Size: -1}); !errors.Is(err, errInvalidPolicy) {
+		t.Errorf("expected %v, received %v", errInvalidPolicy, err)
+	}
+	for _, k := range []DataKind{KindOrder, KindAccount} {
+		if err := w.SetBackpressure(k, Backpressure{Policy: PolicyDropOldest}); !errors.Is(err, errGuaranteedKind) {
+			t.Errorf("%s: expected %v, received %v", k, errGuaranteedKind, err)
+		}
+	}
+	if err := w.SetBackpressure(KindTicker, Backpressure{Policy: PolicyDropNewest, Size: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if bp := w.Events().backpressure[KindTicker]; bp.Policy != PolicyDropNewest || bp.Size != 3 {
+		t.Errorf("expected policy applied to the event bus, received %+v", bp)
+	}
+	p := w.newPipeline()
+	if q := p.queues[KindTicker]; q.Policy != PolicyDropNewest || q.Size != 3 {
+		t.Errorf("unexpected ticker queue %+v", q.Backpressure)
+	}
+	if q := p.queues[KindOrderbook]; q.Policy != PolicyBlock || q.Size != DefaultBackpressureSize {
+		t.Errorf("expected default orderbook queue, received %+v", q.Backpressure)
+	}
+
+	s := *defaultSetup
+	s.Backpressure = map[DataKind]Backpressure{KindOrder: {Policy: policyCount}}
+	if err := New().Setup(&s); !errors.Is(err, errInvalidPolicy) {
+		t.Errorf("expected %v, received %v", errInvalidPolicy, err)
+	}
+}
+
+func TestPipelinePolicies(t *testing.T) {
+	t.Parallel()
+	p := newPipeline(map[DataKind]Backpressure{
+		KindTrade:  {Policy: PolicyDropOldest, Size: 2},
+		KindKline:  {Policy: PolicyDropNewest, Size: 2},
+		KindTicker: {Policy: PolicyConflate, Size: 2},
+		KindOther:  {Policy: PolicyBlock, Size: 1},
+	})
+	btc := func(last float64) *ticker.Price {
+		return &ticker.Price{Pair: btcusd, AssetType: asset.Spot, Last: last}
+	}
+	eth := &ticker.Price{Pair: ethusd, AssetType: asset.Spot}
+	for _, tc := range []struct {
+		data     interface{}
+		expected offerResult
+	}{
+		{trade.Data{Price: 1}, offerQueued},
+		{trade.Data{Price: 2}, offerQueued},
+		{trade.Data{Price: 3}, offerDropped},
+		{KlineData{OpenPrice: 1}, offerQueued},
+		{KlineData{OpenPrice: 2}, offerQueued},
+		{KlineData{OpenPrice: 3}, offerDropped},
+		{btc(1), offerQueued},
+		{eth, offerQueued},
+		{btc(2), offerConflated},
+		{&ticker.Price{Pair: btcusd, AssetType: asset.Margin}, offerDropped},
+		{"first", offerQueued},
+		{"second", offerFull},
+	} {
+		if r := p.offer(kindOf(tc.data), tc.data); r != tc.expected {
+			t.Errorf("%+v: expected %d, received %d", tc.data, tc.expected, r)
+		}
+	}
+
+	shutdown := make(chan struct{})
+	var got []interface{}
+	for i := 0; i < 7; i++ {
+		d, ok := p.next(shutdown)
+		if !ok {
+			t.Fatal("expected data")
+		}
+		got = append(got, d)
+	}
+	if got[0] != "first" {
+		t.Errorf("expected other data before market data, received %v", got[0])
+	}
+	if got[1].(trade.Data).Price != 2 || got[2].(trade.Data).Price != 3 {
+		t.Errorf("expected oldest trade dropped, received %v", got[1:3])
+	}
+	if got[3].(KlineData).OpenPrice != 1 || got[4].(KlineData).OpenPrice != 2 {
+		t.Errorf("expected newest kline dropped, received %v", got[3:5])
+	}
+	if got[5] != eth {
+		t.Errorf("expected evicted btc pair to leave eth first, received %v", got[5])
+	}
+	if tp := got[6].(*ticker.Price); tp.AssetType != asset.Margin {
+		t.Errorf("expected margin ticker, received %v", tp)
+	}
+
+	// A blocked offer waits for space
+	if r := p.offer(KindOther, "first"); r != offerQueued {
+		t.Fatalf("expected queued, received %d", r)
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if !p.wait(KindOther, "second", shutdown) {
+			t.Error("expected wait to queue data")
+		}
+	}()
+	for _, expected := range []string{"first", "second"} {
+		if d, _ := p.next(shutdown); d != expected {
+			t.Errorf("expected %s, received %v", expected, d)
+		}
+	}
+	wg.Wait()
+
+	if p.offer(KindOther, "third") != offerQueued || p.offer(KindOther, "fourth") != offerFull {
+		t.Fatal("expected full queue")
+	}
+	close(shutdown)
+	if p.wait(KindOther, "fourth", shutdown) {
+		t.Error("expected wait to stop on shutdown")
+	}
+}
+
+func TestConflateKeepsPosition(t *testing.T) {
+	t.Parallel()
+	p := newPipeline(map[DataKind]Backpressure{KindTicker: {Policy: PolicyConflate}})
+	p.offer(KindTicker, &ticker.Price{Pair: btcusd, AssetType: asset.Spot, Last: 1})
+	p.offer(KindTicker, &ticker.Price{Pair: ethusd, AssetType: asset.Spot})
+	p.offer(KindTicker, &ticker.Price{Pair: btcusd, AssetType: asset.Spot, Last: 2})
+	d, _ := p.next(nil)
+	if tp := d.(*ticker.Price); !tp.Pair.Equal(btcusd) || tp.Last != 2 {
+		t.Errorf("expected latest btc ticker first, received %+v", tp)
+	}
+	d, _ = p.next(nil)
+	if tp := d.(*ticker.Price); !tp.Pair.Equal(ethusd) {
+		t.Errorf("expected eth ticker, received %+v", tp)
+	}
+
+	if conflationKey(KlineData{Pair: btcusd, Interval: "1m"}) == conflationKey(KlineData{Pair: btcusd, Interval: "5m"}) {
+		t.Error("expected kline intervals to be conflated apart")
+	}
+}
+
+func TestDataMonitorBackpressure(t *testing.T) {
+	t.Parallel()
+	ws := New()
+	ws.exchangeName = "Binance"
+	ws.ShutdownC = make(chan struct{})
+	ws.Wg = new(sync.WaitGroup)
+	// An unbuffered ToRoutine without a reader is the slowest consumer
+	ws.ToRoutine = make(chan interface{})
+	if err := ws.SetBackpressure(KindTicker, Backpressure{Policy: PolicyConflate}); err != nil {
+		t.Fatal(err)
+	}
+	ws.dataMonitor()
+
+	const n = 100
+	for i := 1; i <= n; i++ {
+		ws.DataHandler <- &ticker.Price{Pair: btcusd, AssetType: asset.Spot, Last: float64(i)}
+	}
+	ws.DataHandler <- &order.Detail{ID: "1"}
+	// DataHandler is unbuffered so accepting this means the order was queued
+	ws.DataHandler <- "sentinel"
+
+	var tickers []*ticker.Price
+	var sawOrder bool
+	timeout := time.After(5 * time.Second)
+	for !sawOrder || len(tickers) == 0 || tickers[len(tickers)-1].Last != n {
+		select {
+		case d := <-ws.ToRoutine:
+			switch v := d.(type) {
+			case *ticker.Price:
+				tickers = append(tickers, v)
+			case *order.Detail:
+				if len(tickers) > 1 {
+					t.Error("expected order ahead of queued tickers")
+				}
+				sawOrder = true
+			}
+		case <-timeout:
+			t.Fatalf("timed out, order received %v, tickers %v", sawOrder, tickers)
+		}
+	}
+	if len(tickers) > 2 {
+		t.Errorf("expected tickers to be conflated, received %d", len(tickers))
+	}
+	if d := ws.Dropped(KindTicker); d+uint64(len(tickers)) != n {
+		t.Errorf("expected %d tickers dropped, received %d", n-len(tickers), d)
+	}
+	close(ws.ShutdownC)
+	ws.Wg.Wait()
+}
+
+func TestDataMonitorGuaranteesOrders(t *testing.T) {
+	t.Parallel()
+	ws := New()
+	ws.exchangeName = "Binance"
+	ws.ShutdownC = make(chan struct{})
+	ws.Wg = new(sync.WaitGroup)
+	ws.ToRoutine = make(chan interface{})
+	if err := ws.SetBackpressure(KindTicker, Backpressure{Policy: PolicyDropNewest, Size: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.SetBackpressure(KindOrder, Backpressure{Size: 1}); err != nil {
+		t.Fatal(err)
+	}
+	// A subscriber does not change ToRoutine's policies, tickers are dropped
+	// while orders wait for room
+	sub := ws.Events().Subscribe(Filter{}, Handlers{})
+	defer sub.Unsubscribe()
+	ws.dataMonitor()
+
+	for i := 0; i < 3; i++ {
+		ws.DataHandler <- &ticker.Price{Last: float64(i)}
+	}
+	go func() {
+		for _, id := range []string{"1", "2", "3"} {
+			ws.DataHandler <- &order.Detail{ID: id}
+		}
+	}()
+
+	var ids []string
+	timeout := time.After(5 * time.Second)
+	for len(ids) != 3 {
+		select {
+		case d := <-ws.ToRoutine:
+			if o, ok := d.(*order.Detail); ok {
+				ids = append(ids, o.ID)
+			}
+		case <-timeout:
+			t.Fatalf("timed out, received orders %v", ids)
+		}
+	}
+	if ids[0] != "1" || ids[1] != "2" || ids[2] != "3" {
+		t.Errorf("expected orders in sequence, received %v", ids)
+	}
+	if d := ws.Dropped(KindOrder); d != 0 {
+		t.Errorf("expected no orders dropped, received %d", d)
+	}
+	if d := ws.Dropped(KindTicker); d == 0 {
+		t.Error("expected tickers to be dropped")
+	}
+	close(ws.ShutdownC)
+	ws.Wg.Wait()
+}
+
+func TestDisableToRoutine(t *testing.T) {
+	t.Parallel()
+	ws := New()
+	ws.exchangeName = "Binance"
+	ws.ShutdownC = make(chan struct{})
+	ws.Wg = new(sync.WaitGroup)
+	ws.ToRoutine = make(chan interface{})
+	ws.DisableToRoutine()
+	orders := make(chan *order.Detail, 10)
+	sub := ws.Events().Subscribe(Filter{}, Handlers{Order: func(o *order.Detail) { orders <- o }})
+	defer sub.Unsubscribe()
+	ws.dataMonitor()
+
+	for i := 0; i < 5; i++ {
+		ws.DataHandler <- &order.Detail{ID: "1"}
+	}
+	timeout := time.After(5 * time.Second)
+	for i := 0; i < 5; i++ {
+		select {
+		case <-orders:
+		case <-timeout:
+			t.Fatalf("timed out, received %d orders", i)
+		}
+	}
+	select {
+	case d := <-ws.ToRoutine:
+		t.Errorf("expected nothing sent to ToRoutine, received %T", d)
+	default:
+	}
+	close(ws.ShutdownC)
+	ws.Wg.Wait()
+}
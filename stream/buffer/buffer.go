@@ -82,24 +82,13 @@ func (w *Orderbook) Update(u *Update) error {
 			u.Asset)
 	}
 
-	// Checks for when the rest protocol overwrites a streaming dominated book
-	// will stop updating book via incremental updates. This occurs because our
-	// sync manager (engine/sync.go) timer has elapsed for streaming. Usually
-	// because the book is highly illiquid. TODO: Book resubscribe on websocket.
-	if book.ob.IsRestSnapshot() {
-		if w.verbose {
-			log.Warnf(log.WebsocketMgr,
-				"%s for Exchange %s CurrencyPair: %s AssetType: %s consider extending synctimeoutwebsocket",
-				errRESTOverwrite,
-				w.exchangeName,
-				u.Pair,
-				u.Asset)
-		}
-		return fmt.Errorf("%w for Exchange %s CurrencyPair: %s AssetType: %s",
-			errRESTOverwrite,
-			w.exchangeName,
-			u.Pair,
-			u.Asset)
+	if book.invalid {
+		// Dropped until the next snapshot
+		return nil
+	}
+
+	if err := w.checkRESTOverwrite(book, u.Pair, u.Asset); err != nil {
+		return err
 	}
 
 	// Apply new update information
@@ -108,6 +97,7 @@ func (w *Orderbook) Update(u *Update) error {
 	if w.bufferEnabled {
 		processed, err := w.processBufferUpdate(book, u)
 		if err != nil {
+			w.requestResubscribe(book, u.Pair, u.Asset)
 			return err
 		}
 
@@ -116,11 +106,48 @@ func (w *Orderbook) Update(u *Update) error {
 		}
 	} else {
 		err := w.processObUpdate(book, u)
+		if err != nil {
+			// The book is out of sync with the exchange
+			w.requestResubscribe(book, u.Pair, u.Asset)
+			return err
+		}
+		err = w.verifyChecksum(book, u)
 		if err != nil {
 			return err
 		}
 	}
 
+	return w.publish(book)
+}
+
+// checkRESTOverwrite checks for when the rest protocol overwrites a streaming
+// dominated book, which will stop updating the book via incremental updates.
+// This occurs because our sync manager (engine/sync.go) timer has elapsed for
+// streaming. Usually because the book is highly illiquid. The book is
+// resubscribed so the stream takes it back with a fresh snapshot.
+func (w *Orderbook) checkRESTOverwrite(book *orderbookHolder, p currency.Pair, a asset.Item) error {
+	if !book.ob.IsRestSnapshot() {
+		return nil
+	}
+	w.requestResubscribe(book, p, a)
+	if w.verbose {
+		log.Warnf(log.WebsocketMgr,
+			"%s for Exchange %s CurrencyPair: %s AssetType: %s consider extending synctimeoutwebsocket",
+			errRESTOverwrite,
+			w.exchangeName,
+			p,
+			a)
+	}
+	return fmt.Errorf("%w for Exchange %s CurrencyPair: %s AssetType: %s",
+		errRESTOverwrite,
+		w.exchangeName,
+		p,
+		a)
+}
+
+// publish verifies an updated book and alerts the data handler when the
+// ticker allows
+func (w *Orderbook) publish(book *orderbookHolder) error {
 	if book.ob.VerifyOrderbook { // This is used here so as to not retrieve
 		// book if verification is off.
 		// On every update, this will retrieve and verify orderbook depths
@@ -149,6 +176,33 @@ func (w *Orderbook) Update(u *Update) error {
 	return nil
 }
 
+// SetResubscriber sets the function called with the pair and asset of a book
+// which can no longer be updated incrementally, either because REST has
+// overwritten it or an update failed to apply. It is called once per book
+// until the next snapshot is loaded and must not block.
+func (w *Orderbook) SetResubscriber(f func(currency.Pair, asset.Item)) {
+	w.m.Lock()
+	w.resubscribe = f
+	w.m.Unlock()
+}
+
+// requestResubscribe asks for a fresh snapshot of a book, the caller must hold
+// the lock
+func (w *Orderbook) requestResubscribe(book *orderbookHolder, p currency.Pair, a asset.Item) {
+	if w.resubscribe == nil || book.resubscribing {
+		return
+	}
+	book.resubscribing = true
+	if w.verbose {
+		log.Warnf(log.WebsocketMgr,
+			"%s websocket: resubscribing orderbook %s %s",
+			w.exchangeName,
+			p,
+			a)
+	}
+	w.resubscribe(p, a)
+}
+
 // processBufferUpdate stores update into buffer, when buffer at capacity as
 // defined by w.obBufferLimit it well then sort and apply updates.
 func (w *Orderbook) processBufferUpdate(o *orderbookHolder, u *Update) (bool, error) {
@@ -219,6 +273,19 @@ func (o *orderbookHolder) updateByIDAndAction(updts *Update) error {
 func (w *Orderbook) LoadSnapshot(book *orderbook.Base) error {
 	w.m.Lock()
 	defer w.m.Unlock()
+	holder, err := w.load(book)
+	if err != nil {
+		return err
+	}
+	// A price level snapshot replaces any order level book
+	holder.l3 = nil
+	w.dataHandler <- holder.ob.Retrieve()
+	holder.ob.Publish()
+	return nil
+}
+
+// load deploys a snapshot to its depth, the caller must hold the lock
+func (w *Orderbook) load(book *orderbook.Base) (*orderbookHolder, error) {
 	m1, ok := w.ob[book.Pair.Base]
 	if !ok {
 		m1 = make(map[currency.Code]map[asset.Item]*orderbookHolder)
@@ -234,7 +301,7 @@ func (w *Orderbook) LoadSnapshot(book *orderbook.Base) error {
 		// Associate orderbook pointer with local exchange depth map
 		depth, err := orderbook.DeployDepth(book.Exchange, book.Pair, book.Asset)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		depth.AssignOptions(book)
 		buffer := make([]Update, w.obBufferLimit)
@@ -245,12 +312,17 @@ func (w *Orderbook) LoadSnapshot(book *orderbook.Base) error {
 			ticker: ticker,
 		}
 		m2[book.Asset] = holder
+	} else {
+		// A snapshot from the stream takes the book back from REST
+		holder.ob.SetLastUpdate(book.LastUpdated, book.LastUpdateID, book.RestSnapshot)
+		holder.resubscribing = false
+		holder.invalid = false
 	}
 
 	// Checks if book can deploy to linked list
 	err := book.Verify()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	holder.ob.LoadSnapshot(book.Bids, book.Asks)
@@ -261,13 +333,10 @@ func (w *Orderbook) LoadSnapshot(book *orderbook.Base) error {
 		// altered in any way
 		err = holder.ob.Retrieve().Verify()
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
-
-	w.dataHandler <- holder.ob.Retrieve()
-	holder.ob.Publish()
-	return nil
+	return holder, nil
 }
 
 // GetOrderbook returns an orderbook copy as orderbook.Base
@@ -1038,3 +1038,66 @@ func TestFlushOrderbook(t *testing.T) {
 		t.Fatal("orderbook items not flushed")
 	}
 }
+
+func TestResubscribe(t *testing.T) {
+	holder, _, _, err := createSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	holder.updateEntriesByID = true
+	var requested int
+	holder.SetResubscriber(func(p currency.Pair, a asset.Item) {
+		if !p.Equal(cp) || a != asset.Spot {
+			t.Errorf("unexpected resubscription %s %s", p, a)
+		}
+		requested++
+	})
+
+	// An amendment to an unknown ID means the book is out of sync
+	outOfSync := &Update{
+		Action: Amend,
+		Bids:   []orderbook.Item{{Price: 100, ID: 6969}},
+		Pair:   cp,
+		Asset:  asset.Spot,
+	}
+	for i := 0; i < 2; i++ {
+		if err = holder.Update(outOfSync); err == nil {
+			t.Fatal("expected error for unknown ID")
+		}
+	}
+	if requested != 1 {
+		t.Fatalf("expected one resubscription until the next snapshot, received %d", requested)
+	}
+
+	book := &orderbook.Base{
+		Exchange: exchangeName,
+		Bids:     orderbook.Items{{Price: 4000, Amount: 1, ID: 6}},
+		Asks:     orderbook.Items{{Price: 4001, Amount: 1, ID: 7}},
+		Asset:    asset.Spot,
+		Pair:     cp,
+	}
+	if err = holder.LoadSnapshot(book); err != nil {
+		t.Fatal(err)
+	}
+	holder.ob[cp.Base][cp.Quote][asset.Spot].ob.SetLastUpdate(time.Now(), 0, true)
+	amend := &Update{
+		Action: Amend,
+		Bids:   []orderbook.Item{{Price: 4000, Amount: 2, ID: 6}},
+		Pair:   cp,
+		Asset:  asset.Spot,
+	}
+	if err = holder.Update(amend); !errors.Is(err, errRESTOverwrite) {
+		t.Fatalf("expected %v, received %v", errRESTOverwrite, err)
+	}
+	if requested != 2 {
+		t.Fatalf("expected resubscription of REST overwritten book, received %d", requested)
+	}
+
+	// The snapshot sent on resubscription hands the book back to the stream
+	if err = holder.LoadSnapshot(book); err != nil {
+		t.Fatal(err)
+	}
+	if err = holder.Update(amend); err != nil {
+		t.Fatal(err)
+	}
+}
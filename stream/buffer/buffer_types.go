@@ -4,8 +4,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/openware/irix/metrics"
 	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
 	"github.com/openware/pkg/orderbook"
 )
 
@@ -25,7 +27,14 @@ type Orderbook struct {
 	exchangeName          string
 	dataHandler           chan interface{}
 	verbose               bool
-	m                     sync.Mutex
+	// resubscribe is called when a book can no longer be updated
+	// incrementally so the stream sends a fresh snapshot
+	resubscribe func(currency.Pair, asset.Item)
+	// checksummer verifies checksums sent with updates which do not set
+	// their own algorithm
+	checksummer Checksummer
+	metrics     metrics.Recorder
+	m           sync.Mutex
 }
 
 // orderbookHolder defines a store of pending updates and a pointer to the
@@ -38,13 +47,25 @@ type orderbookHolder struct {
 	// The sync agent only requires an alert every 15 seconds for a specific
 	// currency.
 	ticker *time.Ticker
+	// resubscribing is set once a resubscription has been requested and
+	// cleared by the next snapshot
+	resubscribing bool
+	// invalid is set once the book is dropped for failing a checksum,
+	// updates are ignored until the next snapshot
+	invalid bool
+	// l3 is set for books loaded through LoadL3Snapshot, the depth holds its
+	// price level view
+	l3 *L3Book
 }
 
 // Update stores orderbook updates and dictates what features to use when processing
 type Update struct {
-	UpdateID   int64 // Used when no time is provided
-	UpdateTime time.Time
-	Asset      asset.Item
+	UpdateID int64 // Used when no time is provided
+	// FirstUpdateID is the first ID covered by an update spanning several,
+	// used by SyncManager to detect gaps
+	FirstUpdateID int64
+	UpdateTime    time.Time
+	Asset         asset.Item
 	Action
 	Bids []orderbook.Item
 	Asks []orderbook.Item
@@ -54,6 +75,13 @@ type Update struct {
 	// should remove any items that are outside of this scope. Kraken is the
 	// only exchange utilising this field.
 	MaxDepth int
+
+	// Checksum is the exchange's checksum of the book once the update is
+	// applied, zero skips verification. Buffered updates are not verified.
+	Checksum uint32
+	// Checksummer overrides the orderbook's checksum algorithm, for exchanges
+	// whose formatting varies between updates
+	Checksummer Checksummer
 }
 
 // Action defines a set of differing states required to implement an incoming
@@ -71,3 +99,72 @@ const (
 	// to book
 	UpdateInsert Action = "update/insert"
 )
+
+// L3Order is an individual order resting in a level 3 book
+type L3Order struct {
+	ID     string
+	Price  float64
+	Amount float64
+	// Time is when the order took its queue position
+	Time time.Time
+}
+
+// L3Snapshot is a full order by order book, orders are listed in time
+// priority within each price level
+type L3Snapshot struct {
+	Exchange        string
+	Pair            currency.Pair
+	Asset           asset.Item
+	Bids            []L3Order
+	Asks            []L3Order
+	Sequence        int64
+	LastUpdated     time.Time
+	VerifyOrderbook bool
+}
+
+// L3Update is an order level change to a level 3 book
+type L3Update struct {
+	Action  L3Action
+	OrderID string
+	// Side is required when opening an order
+	Side   order.Side
+	Price  float64
+	Amount float64
+	// Sequence drops stale updates when set, updates at or below the book's
+	// sequence are ignored. Sequences must be consecutive, a gap drops the
+	// book.
+	Sequence int64
+	Time     time.Time
+	Pair     currency.Pair
+	Asset    asset.Item
+}
+
+// L3Action defines how an order level update is applied
+type L3Action string
+
+const (
+	// L3Open places an order at the back of its price level, an order already
+	// resting is changed to the update's price and amount
+	L3Open L3Action = "open"
+	// L3Match reduces a resting order by the traded amount, removing it once
+	// filled
+	L3Match L3Action = "match"
+	// L3Change sets a resting order's amount, and price when set. The order
+	// keeps its queue position only when its amount is reduced in place.
+	L3Change L3Action = "change"
+	// L3Done removes an order from the book
+	L3Done L3Action = "done"
+	// L3Sequence only advances the book's sequence, for sequenced messages
+	// which leave the book unchanged
+	L3Sequence L3Action = "sequence"
+)
+
+// QueuePosition is where an order rests within its price level
+type QueuePosition struct {
+	Price float64
+	Side  order.Side
+	// Index is the number of orders ahead at the price level
+	Index int
+	// Ahead is the amount resting ahead at the price level
+	Ahead float64
+}
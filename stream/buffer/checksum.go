@@ -0,0 +1,229 @@
+package buffer
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"strings"
+
+	"github.com/openware/irix/metrics"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/log"
+	"github.com/openware/pkg/orderbook"
+)
+
+var (
+	errChecksumMismatch      = errors.New("orderbook checksum mismatch")
+	errChecksumInsufficient  = errors.New("insufficient bids and asks to calculate checksum")
+	errChecksummerUnset      = errors.New("orderbook checksummer unset")
+	errL3ChecksumUnsupported = errors.New("checksummer cannot calculate level 3 checksums")
+)
+
+// Checksummer calculates the checksum an exchange publishes for its book
+type Checksummer interface {
+	Checksum(bids, asks orderbook.Items) (uint32, error)
+}
+
+// L3Checksummer calculates the checksum an exchange publishes for an order by
+// order book, such as one keyed by order ID
+type L3Checksummer interface {
+	ChecksumL3(bids, asks []L3Order) (uint32, error)
+}
+
+// ChecksumLayout is the order levels are written in before hashing
+type ChecksumLayout uint8
+
+// Checksum layouts
+const (
+	// InterleaveBidsAsks alternates bid and ask levels best first, starting
+	// with the best bid
+	InterleaveBidsAsks ChecksumLayout = iota
+	// AsksThenBids writes the ask levels followed by the bid levels
+	AsksThenBids
+)
+
+// CRC32 is the checksum most exchanges publish, a CRC32 (IEEE) of the top
+// levels of each side formatted and joined the way the exchange does
+type CRC32 struct {
+	// Depth is the number of levels taken from each side
+	Depth int
+	// MinDepth fails the checksum when either side holds fewer levels
+	MinDepth int
+	Layout   ChecksumLayout
+	// Separator is written between levels
+	Separator string
+	// Format writes a level, unset writes price:amount
+	Format func(item orderbook.Item, bid bool) string
+}
+
+// Checksum implements Checksummer
+func (c *CRC32) Checksum(bids, asks orderbook.Items) (uint32, error) {
+	if len(bids) < c.MinDepth || len(asks) < c.MinDepth {
+		return 0, fmt.Errorf("%w: %d levels required", errChecksumInsufficient, c.MinDepth)
+	}
+	format := c.Format
+	if format == nil {
+		format = FormatPriceAmount
+	}
+	var levels []string
+	if c.Layout == AsksThenBids {
+		for i := 0; i < c.Depth && i < len(asks); i++ {
+			levels = append(levels, format(asks[i], false))
+		}
+		for i := 0; i < c.Depth && i < len(bids); i++ {
+			levels = append(levels, format(bids[i], true))
+		}
+	} else {
+		for i := 0; i < c.Depth; i++ {
+			if i < len(bids) {
+				levels = append(levels, format(bids[i], true))
+			}
+			if i < len(asks) {
+				levels = append(levels, format(asks[i], false))
+			}
+		}
+	}
+	return crc32.ChecksumIEEE([]byte(strings.Join(levels, c.Separator))), nil
+}
+
+// FormatPriceAmount formats a level as its price and amount separated by a
+// colon
+func FormatPriceAmount(item orderbook.Item, _ bool) string {
+	return strconv.FormatFloat(item.Price, 'f', -1, 64) + ":" +
+		strconv.FormatFloat(item.Amount, 'f', -1, 64)
+}
+
+// SetChecksummer sets the algorithm verifying checksums sent with updates
+// which do not set their own
+func (w *Orderbook) SetChecksummer(c Checksummer) {
+	w.m.Lock()
+	w.checksummer = c
+	w.m.Unlock()
+}
+
+// SetMetrics sets the recorder checksum failures are counted in, nil falls
+// back to metrics.Default
+func (w *Orderbook) SetMetrics(r metrics.Recorder) {
+	w.m.Lock()
+	w.metrics = r
+	w.m.Unlock()
+}
+
+// VerifyChecksum checks a book against a checksum the exchange sends apart
+// from updates, dropping the book on mismatch. Unset c uses the checksummer
+// set on the orderbook, a level 3 book requires it to be an L3Checksummer.
+// Books awaiting a snapshot or holding buffered updates are not verified.
+func (w *Orderbook) VerifyChecksum(p currency.Pair, a asset.Item, checksum uint32, c Checksummer) error {
+	w.m.Lock()
+	defer w.m.Unlock()
+	if c == nil && w.checksummer == nil {
+		return errChecksummerUnset
+	}
+	book, ok := w.ob[p.Base][p.Quote][a]
+	if !ok {
+		return fmt.Errorf("cannot verify orderbook checksum %s %s %s %w",
+			w.exchangeName,
+			p,
+			a,
+			errDepthNotFound)
+	}
+	if book.invalid || len(*book.buffer) != 0 {
+		return nil
+	}
+	return w.checkBook(book, p, a, checksum, c)
+}
+
+// verifyChecksum checks the book against the checksum sent with an update,
+// dropping the book on mismatch. A book too shallow to checksum is kept. The
+// caller must hold the lock.
+func (w *Orderbook) verifyChecksum(book *orderbookHolder, u *Update) error {
+	if u.Checksum == 0 {
+		return nil
+	}
+	return w.checkBook(book, u.Pair, u.Asset, u.Checksum, u.Checksummer)
+}
+
+// checkBook calculates a book's checksum with c, or the orderbook's
+// checksummer when unset, and drops the book when it does not match. The
+// caller must hold the lock.
+func (w *Orderbook) checkBook(book *orderbookHolder, p currency.Pair, a asset.Item, checksum uint32, c Checksummer) error {
+	if c == nil {
+		c = w.checksummer
+	}
+	if c == nil {
+		return nil
+	}
+	var calculated uint32
+	var err error
+	if book.l3 != nil {
+		l3, ok := c.(L3Checksummer)
+		if !ok {
+			return errL3ChecksumUnsupported
+		}
+		bids, asks := book.l3.Orders()
+		calculated, err = l3.ChecksumL3(bids, asks)
+	} else {
+		ob := book.ob.Retrieve()
+		calculated, err = c.Checksum(ob.Bids, ob.Asks)
+	}
+	if err == nil && calculated == checksum {
+		return nil
+	}
+	switch {
+	case err == nil:
+		err = fmt.Errorf("%w: calculated [%d] does not match [%d]",
+			errChecksumMismatch,
+			calculated,
+			checksum)
+		w.invalidate(book, p, a, err)
+	case !errors.Is(err, errChecksumInsufficient):
+		w.invalidate(book, p, a, err)
+	}
+	return fmt.Errorf("%w for Exchange %s CurrencyPair: %s AssetType: %s",
+		err,
+		w.exchangeName,
+		p,
+		a)
+}
+
+// InvalidateBook drops a book which failed a checksum the exchange sends
+// apart from updates. Updates are ignored until the snapshot requested
+// through the resubscriber is loaded.
+func (w *Orderbook) InvalidateBook(p currency.Pair, a asset.Item, reason error) error {
+	w.m.Lock()
+	defer w.m.Unlock()
+	book, ok := w.ob[p.Base][p.Quote][a]
+	if !ok {
+		return fmt.Errorf("cannot invalidate orderbook %s %s %s %w",
+			w.exchangeName,
+			p,
+			a,
+			errDepthNotFound)
+	}
+	w.invalidate(book, p, a, reason)
+	return nil
+}
+
+// invalidate drops a book which is out of sync with the exchange and requests
+// a fresh snapshot, the caller must hold the lock
+func (w *Orderbook) invalidate(book *orderbookHolder, p currency.Pair, a asset.Item, reason error) {
+	log.Warnf(log.WebsocketMgr,
+		"%s websocket: dropping orderbook %s %s: %v",
+		w.exchangeName,
+		p,
+		a,
+		reason)
+	r := w.metrics
+	if r == nil {
+		r = metrics.Default()
+	}
+	r.AddCounter(metrics.OrderbookChecksumFailures,
+		metrics.Labels{Exchange: w.exchangeName, Asset: a.String()}, 1)
+	book.invalid = true
+	book.l3 = nil
+	*book.buffer = nil
+	book.ob.Flush()
+	w.requestResubscribe(book, p, a)
+}
@@ -0,0 +1,238 @@
+package buffer
+
+import (
+	"errors"
+	"hash/crc32"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/openware/irix/metrics"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/orderbook"
+)
+
+func TestCRC32(t *testing.T) {
+	t.Parallel()
+	bids := orderbook.Items{{Price: 100, Amount: 1, ID: 1}, {Price: 99, Amount: 2, ID: 2}}
+	asks := orderbook.Items{{Price: 101, Amount: 3.5, ID: 3}}
+	tests := []struct {
+		name     string
+		c        *CRC32
+		expected string
+	}{
+		{"interleaved", &CRC32{Depth: 10, Separator: ":"}, "100:1:101:3.5:99:2"},
+		{"depth", &CRC32{Depth: 1, Separator: ":"}, "100:1:101:3.5"},
+		{"asks then bids", &CRC32{Depth: 10, Layout: AsksThenBids}, "101:3.5100:199:2"},
+		{"format", &CRC32{
+			Depth:     10,
+			Separator: "|",
+			Format: func(item orderbook.Item, bid bool) string {
+				if bid {
+					return strconv.FormatInt(item.ID, 10)
+				}
+				return "-" + strconv.FormatInt(item.ID, 10)
+			},
+		}, "1|-3|2"},
+	}
+	for x := range tests {
+		checksum, err := tests[x].c.Checksum(bids, asks)
+		if err != nil {
+			t.Fatalf("%s: %v", tests[x].name, err)
+		}
+		if expected := crc32.ChecksumIEEE([]byte(tests[x].expected)); checksum != expected {
+			t.Errorf("%s: expected checksum of %q [%d], received [%d]",
+				tests[x].name, tests[x].expected, expected, checksum)
+		}
+	}
+
+	if _, err := (&CRC32{Depth: 10, MinDepth: 2}).Checksum(bids, asks); !errors.Is(err, errChecksumInsufficient) {
+		t.Errorf("expected %v, received %v", errChecksumInsufficient, err)
+	}
+}
+
+func TestUpdateChecksum(t *testing.T) {
+	t.Parallel()
+	r, err := metrics.NewRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &Orderbook{
+		exchangeName: exchangeName,
+		dataHandler:  make(chan interface{}, 100),
+		ob:           make(map[currency.Code]map[currency.Code]map[asset.Item]*orderbookHolder),
+	}
+	checksummer := &CRC32{Depth: 10, Separator: ":"}
+	w.SetChecksummer(checksummer)
+	w.SetMetrics(r)
+	var requested int
+	w.SetResubscriber(func(currency.Pair, asset.Item) { requested++ })
+
+	snapshot := &orderbook.Base{
+		Exchange: exchangeName,
+		Pair:     cp,
+		Asset:    asset.Spot,
+		Bids:     orderbook.Items{{Price: 100, Amount: 1}},
+		Asks:     orderbook.Items{{Price: 101, Amount: 1}},
+	}
+	if err = w.LoadSnapshot(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	update := func(checksum uint32) error {
+		return w.Update(&Update{
+			Bids:       orderbook.Items{{Price: 99, Amount: 2}},
+			Pair:       cp,
+			Asset:      asset.Spot,
+			UpdateTime: time.Now(),
+			Checksum:   checksum,
+		})
+	}
+
+	if err = update(crc32.ChecksumIEEE([]byte("100:1:101:1:99:2"))); err != nil {
+		t.Fatal(err)
+	}
+	// Updates without a checksum are not verified
+	if err = update(0); err != nil {
+		t.Fatal(err)
+	}
+	// A book too shallow to checksum is kept
+	err = w.Update(&Update{
+		Bids:        orderbook.Items{{Price: 99, Amount: 2}},
+		Pair:        cp,
+		Asset:       asset.Spot,
+		UpdateTime:  time.Now(),
+		Checksum:    1,
+		Checksummer: &CRC32{MinDepth: 5},
+	})
+	if !errors.Is(err, errChecksumInsufficient) {
+		t.Fatalf("expected %v, received %v", errChecksumInsufficient, err)
+	}
+	if requested != 0 {
+		t.Fatalf("expected shallow book to be kept, received %d resubscriptions", requested)
+	}
+
+	if err = update(1); !errors.Is(err, errChecksumMismatch) {
+		t.Fatalf("expected %v, received %v", errChecksumMismatch, err)
+	}
+	if requested != 1 {
+		t.Errorf("expected resubscription, received %d", requested)
+	}
+	labels := metrics.Labels{Exchange: exchangeName, Asset: asset.Spot.String()}
+	if v, _ := r.Value(metrics.OrderbookChecksumFailures, labels); v != 1 {
+		t.Errorf("expected one checksum failure counted, received %v", v)
+	}
+	book, err := w.GetOrderbook(cp, asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(book.Bids) != 0 || len(book.Asks) != 0 {
+		t.Errorf("expected book to be dropped, received %+v %+v", book.Bids, book.Asks)
+	}
+
+	// Updates are ignored until the next snapshot
+	if err = update(1); err != nil {
+		t.Fatal(err)
+	}
+	if book, _ = w.GetOrderbook(cp, asset.Spot); len(book.Bids) != 0 {
+		t.Errorf("expected update to be ignored, received %+v", book.Bids)
+	}
+	if err = w.LoadSnapshot(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if err = update(crc32.ChecksumIEEE([]byte("100:1:101:1:99:2"))); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = w.InvalidateBook(cp, asset.Spot, errChecksumMismatch); err != nil {
+		t.Fatal(err)
+	}
+	if requested != 2 {
+		t.Errorf("expected resubscription, received %d", requested)
+	}
+	if err = w.InvalidateBook(cp, asset.Futures, errChecksumMismatch); !errors.Is(err, errDepthNotFound) {
+		t.Errorf("expected %v, received %v", errDepthNotFound, err)
+	}
+}
+
+// idChecksum hashes the IDs of each side's orders, bids first
+type idChecksum struct{}
+
+func (idChecksum) Checksum(bids, asks orderbook.Items) (uint32, error) {
+	return 0, errors.New("level 2 checksum unsupported")
+}
+
+func (idChecksum) ChecksumL3(bids, asks []L3Order) (uint32, error) {
+	var ids string
+	for _, side := range [][]L3Order{bids, asks} {
+		for i := range side {
+			ids += side[i].ID
+		}
+	}
+	return crc32.ChecksumIEEE([]byte(ids)), nil
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	t.Parallel()
+	w := &Orderbook{
+		exchangeName: exchangeName,
+		dataHandler:  make(chan interface{}, 100),
+		ob:           make(map[currency.Code]map[currency.Code]map[asset.Item]*orderbookHolder),
+	}
+	var requested int
+	w.SetResubscriber(func(currency.Pair, asset.Item) { requested++ })
+	if err := w.VerifyChecksum(cp, asset.Spot, 1, nil); !errors.Is(err, errChecksummerUnset) {
+		t.Fatalf("expected %v, received %v", errChecksummerUnset, err)
+	}
+	w.SetChecksummer(&CRC32{Depth: 10, Separator: ":"})
+	if err := w.VerifyChecksum(cp, asset.Spot, 1, nil); !errors.Is(err, errDepthNotFound) {
+		t.Fatalf("expected %v, received %v", errDepthNotFound, err)
+	}
+
+	err := w.LoadSnapshot(&orderbook.Base{
+		Exchange: exchangeName,
+		Pair:     cp,
+		Asset:    asset.Spot,
+		Bids:     orderbook.Items{{Price: 100, Amount: 1}},
+		Asks:     orderbook.Items{{Price: 101, Amount: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.VerifyChecksum(cp, asset.Spot, crc32.ChecksumIEEE([]byte("100:1:101:1")), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.VerifyChecksum(cp, asset.Spot, 1, nil); !errors.Is(err, errChecksumMismatch) {
+		t.Fatalf("expected %v, received %v", errChecksumMismatch, err)
+	}
+	// A dropped book is not verified until its next snapshot
+	if err = w.VerifyChecksum(cp, asset.Spot, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if requested != 1 {
+		t.Errorf("expected one resubscription, received %d", requested)
+	}
+
+	err = w.LoadL3Snapshot(&L3Snapshot{
+		Exchange: exchangeName,
+		Pair:     cp,
+		Asset:    asset.Futures,
+		Bids:     []L3Order{{ID: "1", Price: 100, Amount: 1}},
+		Asks:     []L3Order{{ID: "2", Price: 101, Amount: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.VerifyChecksum(cp, asset.Futures, 1, nil); !errors.Is(err, errL3ChecksumUnsupported) {
+		t.Fatalf("expected %v, received %v", errL3ChecksumUnsupported, err)
+	}
+	if err = w.VerifyChecksum(cp, asset.Futures, crc32.ChecksumIEEE([]byte("12")), idChecksum{}); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.VerifyChecksum(cp, asset.Futures, 1, idChecksum{}); !errors.Is(err, errChecksumMismatch) {
+		t.Fatalf("expected %v, received %v", errChecksumMismatch, err)
+	}
+	if w.HasL3Book(cp, asset.Futures) {
+		t.Error("expected level 3 book to be dropped")
+	}
+}
@@ -0,0 +1,442 @@
+package buffer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
+	"github.com/openware/pkg/orderbook"
+)
+
+var (
+	errL3OrderNotFound  = errors.New("order not found in level 3 book")
+	errL3DuplicateOrder = errors.New("duplicate order in level 3 book")
+	errL3InvalidSide    = errors.New("invalid order side")
+	errL3InvalidOrder   = errors.New("order price and amount must be positive")
+	errL3InvalidAction  = errors.New("invalid level 3 action")
+	errL3BookNotFound   = errors.New("level 3 book not found")
+	errL3SequenceGap    = errors.New("level 3 book missed updates")
+)
+
+// L3Book keeps the individual orders of a book by price level in time
+// priority. It is not safe for concurrent use, the buffer guards its books.
+type L3Book struct {
+	bids     l3Side
+	asks     l3Side
+	orders   map[string]*l3Entry
+	sequence int64
+}
+
+// l3Entry is an order resting in the book
+type l3Entry struct {
+	L3Order
+	bid bool
+}
+
+// l3Side holds the price levels of one side of the book, prices are kept best
+// first
+type l3Side struct {
+	bid    bool
+	levels map[float64][]*l3Entry
+	prices []float64
+}
+
+// NewL3Book returns a level 3 book loaded with orders listed in time priority
+// within their price level
+func NewL3Book(bids, asks []L3Order, sequence int64) (*L3Book, error) {
+	b := &L3Book{
+		bids:     l3Side{bid: true, levels: make(map[float64][]*l3Entry)},
+		asks:     l3Side{levels: make(map[float64][]*l3Entry)},
+		orders:   make(map[string]*l3Entry, len(bids)+len(asks)),
+		sequence: sequence,
+	}
+	for _, side := range []struct {
+		orders []L3Order
+		bid    bool
+	}{{bids, true}, {asks, false}} {
+		for x := range side.orders {
+			o := side.orders[x]
+			if o.Price <= 0 || o.Amount <= 0 {
+				return nil, fmt.Errorf("%w: %s", errL3InvalidOrder, o.ID)
+			}
+			if _, ok := b.orders[o.ID]; ok {
+				return nil, fmt.Errorf("%w: %s", errL3DuplicateOrder, o.ID)
+			}
+			b.add(&l3Entry{L3Order: o, bid: side.bid})
+		}
+	}
+	return b, nil
+}
+
+// LoadL3Snapshot loads an order by order snapshot, the orderbook depth holds
+// its price level view for consumers of the level 2 book
+func (w *Orderbook) LoadL3Snapshot(s *L3Snapshot) error {
+	book, err := NewL3Book(s.Bids, s.Asks, s.Sequence)
+	if err != nil {
+		return fmt.Errorf(packageError, err)
+	}
+	bids, asks := book.L2()
+	w.m.Lock()
+	defer w.m.Unlock()
+	holder, err := w.load(&orderbook.Base{
+		Exchange:        s.Exchange,
+		Pair:            s.Pair,
+		Asset:           s.Asset,
+		Bids:            bids,
+		Asks:            asks,
+		LastUpdated:     s.LastUpdated,
+		LastUpdateID:    s.Sequence,
+		VerifyOrderbook: s.VerifyOrderbook,
+	})
+	if err != nil {
+		return err
+	}
+	holder.l3 = book
+	w.dataHandler <- holder.ob.Retrieve()
+	holder.ob.Publish()
+	return nil
+}
+
+// UpdateL3 applies an order level update to a book loaded through
+// LoadL3Snapshot and updates the price levels it changed. A sequenced update
+// which does not follow on from the book drops it, the exchange must load a
+// fresh snapshot.
+func (w *Orderbook) UpdateL3(u *L3Update) error {
+	if u == nil {
+		return fmt.Errorf(packageError, errUpdateIsNil)
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	book, err := w.l3Book(u.Pair, u.Asset)
+	if err != nil {
+		return err
+	}
+	if u.Sequence != 0 && u.Sequence <= book.l3.sequence {
+		return nil
+	}
+	if u.Sequence != 0 && book.l3.sequence != 0 && u.Sequence != book.l3.sequence+1 {
+		missed := book.l3.sequence + 1
+		book.l3 = nil
+		book.invalid = true
+		book.ob.Flush()
+		return fmt.Errorf("%w for Exchange %s CurrencyPair: %s AssetType: %s sequence %d to %d",
+			errL3SequenceGap,
+			w.exchangeName,
+			u.Pair,
+			u.Asset,
+			missed,
+			u.Sequence-1)
+	}
+	if err = w.checkRESTOverwrite(book, u.Pair, u.Asset); err != nil {
+		return err
+	}
+	bids, asks, err := book.l3.Apply(u)
+	if err != nil {
+		// The book is out of sync with the exchange
+		w.requestResubscribe(book, u.Pair, u.Asset)
+		return err
+	}
+	if u.Sequence != 0 {
+		book.l3.sequence = u.Sequence
+	}
+	if len(bids) == 0 && len(asks) == 0 {
+		return nil
+	}
+	book.ob.SetLastUpdate(u.Time, u.Sequence, false)
+	book.ob.UpdateBidAskByPrice(bids, asks, 0)
+	return w.publish(book)
+}
+
+// HasL3Book reports whether a level 3 book is loaded for the pair and asset
+func (w *Orderbook) HasL3Book(p currency.Pair, a asset.Item) bool {
+	w.m.Lock()
+	defer w.m.Unlock()
+	book, ok := w.ob[p.Base][p.Quote][a]
+	return ok && book.l3 != nil
+}
+
+// GetL3Orders returns copies of the resting orders of a level 3 book, best
+// price first and in time priority within each price level
+func (w *Orderbook) GetL3Orders(p currency.Pair, a asset.Item) (bids, asks []L3Order, err error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	book, err := w.l3Book(p, a)
+	if err != nil {
+		return nil, nil, err
+	}
+	bids, asks = book.l3.Orders()
+	return bids, asks, nil
+}
+
+// GetQueuePosition returns where an order rests in a level 3 book
+func (w *Orderbook) GetQueuePosition(p currency.Pair, a asset.Item, orderID string) (QueuePosition, error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	book, err := w.l3Book(p, a)
+	if err != nil {
+		return QueuePosition{}, err
+	}
+	return book.l3.QueuePosition(orderID)
+}
+
+// l3Book returns the holder of a level 3 book, the caller must hold the lock
+func (w *Orderbook) l3Book(p currency.Pair, a asset.Item) (*orderbookHolder, error) {
+	book, ok := w.ob[p.Base][p.Quote][a]
+	if !ok || book.l3 == nil {
+		return nil, fmt.Errorf("%w for Exchange %s CurrencyPair: %s AssetType: %s",
+			errL3BookNotFound,
+			w.exchangeName,
+			p,
+			a)
+	}
+	return book, nil
+}
+
+// Sequence returns the sequence of the last applied update
+func (b *L3Book) Sequence() int64 {
+	return b.sequence
+}
+
+// Len returns the number of resting orders
+func (b *L3Book) Len() int {
+	return len(b.orders)
+}
+
+// Order returns a resting order by ID
+func (b *L3Book) Order(id string) (L3Order, bool) {
+	e, ok := b.orders[id]
+	if !ok {
+		return L3Order{}, false
+	}
+	return e.L3Order, true
+}
+
+// Orders returns copies of the resting orders, best price first and in time
+// priority within each price level
+func (b *L3Book) Orders() (bids, asks []L3Order) {
+	return b.bids.orders(), b.asks.orders()
+}
+
+// L2 returns the book aggregated by price level
+func (b *L3Book) L2() (bids, asks orderbook.Items) {
+	return b.bids.items(), b.asks.items()
+}
+
+// QueuePosition returns where an order rests in its price level
+func (b *L3Book) QueuePosition(id string) (QueuePosition, error) {
+	e, ok := b.orders[id]
+	if !ok {
+		return QueuePosition{}, fmt.Errorf("%w: %s", errL3OrderNotFound, id)
+	}
+	pos := QueuePosition{Price: e.Price, Side: order.Ask}
+	if e.bid {
+		pos.Side = order.Bid
+	}
+	for _, o := range b.side(e.bid).levels[e.Price] {
+		if o == e {
+			break
+		}
+		pos.Index++
+		pos.Ahead += o.Amount
+	}
+	return pos, nil
+}
+
+// Apply applies an order level update and returns the new totals of the price
+// levels it changed, an emptied level has a zero amount. Updates for orders
+// which are not resting are ignored except for matches, which mean the book
+// is out of sync.
+func (b *L3Book) Apply(u *L3Update) (bids, asks orderbook.Items, err error) {
+	var changed []*l3Entry
+	switch u.Action {
+	case L3Open:
+		bid, err := isBid(u.Side)
+		if err != nil {
+			return nil, nil, err
+		}
+		if u.Price <= 0 || u.Amount <= 0 {
+			return nil, nil, fmt.Errorf("%w: %s", errL3InvalidOrder, u.OrderID)
+		}
+		if e, ok := b.orders[u.OrderID]; ok {
+			if e.bid == bid {
+				changed = b.change(e, u.Price, u.Amount, u)
+				break
+			}
+			b.remove(e)
+			changed = append(changed, e)
+		}
+		e := &l3Entry{L3Order: L3Order{ID: u.OrderID, Price: u.Price, Amount: u.Amount, Time: u.Time}, bid: bid}
+		b.add(e)
+		changed = append(changed, e)
+	case L3Match:
+		e, ok := b.orders[u.OrderID]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %s", errL3OrderNotFound, u.OrderID)
+		}
+		changed = b.change(e, e.Price, e.Amount-u.Amount, u)
+	case L3Change:
+		e, ok := b.orders[u.OrderID]
+		if !ok {
+			return nil, nil, nil
+		}
+		price := u.Price
+		if price <= 0 {
+			price = e.Price
+		}
+		changed = b.change(e, price, u.Amount, u)
+	case L3Done:
+		e, ok := b.orders[u.OrderID]
+		if !ok {
+			return nil, nil, nil
+		}
+		b.remove(e)
+		changed = append(changed, e)
+	case L3Sequence:
+		return nil, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("%w [%s]", errL3InvalidAction, u.Action)
+	}
+
+	for _, e := range changed {
+		s := b.side(e.bid)
+		item := orderbook.Item{Price: e.Price, Amount: s.total(e.Price)}
+		if e.bid {
+			bids = appendLevel(bids, item)
+		} else {
+			asks = appendLevel(asks, item)
+		}
+	}
+	return bids, asks, nil
+}
+
+// change sets the price and amount of a resting order, removing it once its
+// amount is spent. An order loses its queue position when its price changes
+// or its amount grows. It returns the entries of the levels changed.
+func (b *L3Book) change(e *l3Entry, price, amount float64, u *L3Update) []*l3Entry {
+	if amount <= 0 {
+		b.remove(e)
+		return []*l3Entry{e}
+	}
+	if price == e.Price && amount <= e.Amount {
+		e.Amount = amount
+		return []*l3Entry{e}
+	}
+	old := *e
+	b.remove(e)
+	e.Price = price
+	e.Amount = amount
+	if !u.Time.IsZero() {
+		e.Time = u.Time
+	}
+	b.add(e)
+	if old.Price == price {
+		return []*l3Entry{e}
+	}
+	return []*l3Entry{&old, e}
+}
+
+// add places an order at the back of its price level
+func (b *L3Book) add(e *l3Entry) {
+	s := b.side(e.bid)
+	level, ok := s.levels[e.Price]
+	if !ok {
+		i := s.search(e.Price)
+		s.prices = append(s.prices, 0)
+		copy(s.prices[i+1:], s.prices[i:])
+		s.prices[i] = e.Price
+	}
+	s.levels[e.Price] = append(level, e)
+	b.orders[e.ID] = e
+}
+
+// remove takes an order out of its price level, dropping the level once empty
+func (b *L3Book) remove(e *l3Entry) {
+	delete(b.orders, e.ID)
+	s := b.side(e.bid)
+	level := s.levels[e.Price]
+	for x := range level {
+		if level[x] != e {
+			continue
+		}
+		copy(level[x:], level[x+1:])
+		level[len(level)-1] = nil
+		level = level[:len(level)-1]
+		break
+	}
+	if len(level) > 0 {
+		s.levels[e.Price] = level
+		return
+	}
+	delete(s.levels, e.Price)
+	if i := s.search(e.Price); i < len(s.prices) && s.prices[i] == e.Price {
+		s.prices = append(s.prices[:i], s.prices[i+1:]...)
+	}
+}
+
+func (b *L3Book) side(bid bool) *l3Side {
+	if bid {
+		return &b.bids
+	}
+	return &b.asks
+}
+
+// search returns the index price is or would be listed at
+func (s *l3Side) search(price float64) int {
+	if s.bid {
+		return sort.Search(len(s.prices), func(i int) bool { return s.prices[i] <= price })
+	}
+	return sort.Search(len(s.prices), func(i int) bool { return s.prices[i] >= price })
+}
+
+// total returns the amount resting at a price level
+func (s *l3Side) total(price float64) float64 {
+	var amount float64
+	for _, e := range s.levels[price] {
+		amount += e.Amount
+	}
+	return amount
+}
+
+func (s *l3Side) orders() []L3Order {
+	var orders []L3Order
+	for _, price := range s.prices {
+		for _, e := range s.levels[price] {
+			orders = append(orders, e.L3Order)
+		}
+	}
+	return orders
+}
+
+func (s *l3Side) items() orderbook.Items {
+	items := make(orderbook.Items, 0, len(s.prices))
+	for _, price := range s.prices {
+		items = append(items, orderbook.Item{Price: price, Amount: s.total(price)})
+	}
+	return items
+}
+
+// appendLevel adds a changed price level, replacing an earlier change to the
+// same level
+func appendLevel(items orderbook.Items, item orderbook.Item) orderbook.Items {
+	for x := range items {
+		if items[x].Price == item.Price {
+			items[x] = item
+			return items
+		}
+	}
+	return append(items, item)
+}
+
+// isBid reports whether an order side rests on the bid side of the book
+func isBid(s order.Side) (bool, error) {
+	switch s {
+	case order.Buy, order.Bid:
+		return true, nil
+	case order.Sell, order.Ask:
+		return false, nil
+	}
+	return false, fmt.Errorf("%w [%s]", errL3InvalidSide, s)
+}
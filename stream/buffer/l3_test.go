@@ -0,0 +1,249 @@
+package buffer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
+	"github.com/openware/pkg/orderbook"
+)
+
+func l3Snapshot() ([]L3Order, []L3Order) {
+	return []L3Order{
+		{ID: "b1", Price: 100, Amount: 1},
+		{ID: "b2", Price: 99, Amount: 2},
+		{ID: "b3", Price: 100, Amount: 3},
+	}, []L3Order{
+		{ID: "a1", Price: 101, Amount: 1},
+		{ID: "a2", Price: 101, Amount: 2},
+	}
+}
+
+func TestNewL3Book(t *testing.T) {
+	t.Parallel()
+	bids, asks := l3Snapshot()
+	b, err := NewL3Book(bids, asks, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Len() != 5 || b.Sequence() != 5 {
+		t.Errorf("unexpected book of %d orders at sequence %d", b.Len(), b.Sequence())
+	}
+	l2Bids, l2Asks := b.L2()
+	if len(l2Bids) != 2 || l2Bids[0].Price != 100 || l2Bids[0].Amount != 4 || l2Bids[1].Price != 99 {
+		t.Errorf("unexpected bids %+v", l2Bids)
+	}
+	if len(l2Asks) != 1 || l2Asks[0].Amount != 3 {
+		t.Errorf("unexpected asks %+v", l2Asks)
+	}
+	orders, _ := b.Orders()
+	if orders[0].ID != "b1" || orders[1].ID != "b3" || orders[2].ID != "b2" {
+		t.Errorf("expected bids in price then time priority, received %+v", orders)
+	}
+
+	if _, err = NewL3Book(append(bids, L3Order{ID: "b1", Price: 1, Amount: 1}), nil, 0); !errors.Is(err, errL3DuplicateOrder) {
+		t.Errorf("expected %v, received %v", errL3DuplicateOrder, err)
+	}
+	if _, err = NewL3Book(nil, []L3Order{{ID: "a", Price: 1}}, 0); !errors.Is(err, errL3InvalidOrder) {
+		t.Errorf("expected %v, received %v", errL3InvalidOrder, err)
+	}
+}
+
+func TestL3BookApply(t *testing.T) {
+	t.Parallel()
+	bids, asks := l3Snapshot()
+	b, err := NewL3Book(bids, asks, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	level := func(items orderbook.Items, price, amount float64) {
+		t.Helper()
+		for x := range items {
+			if items[x].Price == price {
+				if items[x].Amount != amount {
+					t.Errorf("expected level %v amount %v, received %v", price, amount, items[x].Amount)
+				}
+				return
+			}
+		}
+		t.Errorf("expected level %v in %+v", price, items)
+	}
+	position := func(id string, index int, ahead float64) {
+		t.Helper()
+		pos, err := b.QueuePosition(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pos.Index != index || pos.Ahead != ahead {
+			t.Errorf("%s: expected position %d behind %v, received %d behind %v",
+				id, index, ahead, pos.Index, pos.Ahead)
+		}
+	}
+
+	changedBids, _, err := b.Apply(&L3Update{Action: L3Open, OrderID: "b4", Side: order.Buy, Price: 100, Amount: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	level(changedBids, 100, 9)
+	position("b4", 2, 4)
+
+	// A reduced order keeps its queue position
+	if changedBids, _, err = b.Apply(&L3Update{Action: L3Change, OrderID: "b1", Amount: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+	level(changedBids, 100, 8.5)
+	position("b1", 0, 0)
+
+	// An increased order goes to the back of the queue
+	if _, _, err = b.Apply(&L3Update{Action: L3Change, OrderID: "b1", Amount: 1}); err != nil {
+		t.Fatal(err)
+	}
+	position("b1", 2, 8)
+
+	// A repriced order leaves its level
+	if changedBids, _, err = b.Apply(&L3Update{Action: L3Change, OrderID: "b3", Price: 99, Amount: 3}); err != nil {
+		t.Fatal(err)
+	}
+	level(changedBids, 100, 6)
+	level(changedBids, 99, 5)
+	position("b3", 1, 2)
+
+	_, changedAsks, err := b.Apply(&L3Update{Action: L3Match, OrderID: "a1", Amount: 0.25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	level(changedAsks, 101, 2.75)
+	if _, changedAsks, err = b.Apply(&L3Update{Action: L3Match, OrderID: "a1", Amount: 0.75}); err != nil {
+		t.Fatal(err)
+	}
+	level(changedAsks, 101, 2)
+	if _, ok := b.Order("a1"); ok {
+		t.Error("expected filled order to be removed")
+	}
+	if _, _, err = b.Apply(&L3Update{Action: L3Match, OrderID: "a1", Amount: 1}); !errors.Is(err, errL3OrderNotFound) {
+		t.Errorf("expected %v, received %v", errL3OrderNotFound, err)
+	}
+
+	if _, changedAsks, err = b.Apply(&L3Update{Action: L3Done, OrderID: "a2"}); err != nil {
+		t.Fatal(err)
+	}
+	level(changedAsks, 101, 0)
+	if _, l2Asks := b.L2(); len(l2Asks) != 0 {
+		t.Errorf("expected emptied level to be removed, received %+v", l2Asks)
+	}
+
+	// Orders which are not resting are ignored
+	changedBids, changedAsks, err = b.Apply(&L3Update{Action: L3Done, OrderID: "unknown"})
+	if err != nil || len(changedBids) != 0 || len(changedAsks) != 0 {
+		t.Errorf("expected unknown order to be ignored, received %v %v %v", changedBids, changedAsks, err)
+	}
+	if _, _, err = b.Apply(&L3Update{Action: L3Open, OrderID: "x", Side: order.AnySide, Price: 1, Amount: 1}); !errors.Is(err, errL3InvalidSide) {
+		t.Errorf("expected %v, received %v", errL3InvalidSide, err)
+	}
+	if _, _, err = b.Apply(&L3Update{Action: "bogus"}); !errors.Is(err, errL3InvalidAction) {
+		t.Errorf("expected %v, received %v", errL3InvalidAction, err)
+	}
+}
+
+func TestOrderbookL3(t *testing.T) {
+	t.Parallel()
+	w := &Orderbook{
+		exchangeName: "l3Test",
+		dataHandler:  make(chan interface{}, 100),
+		ob:           make(map[currency.Code]map[currency.Code]map[asset.Item]*orderbookHolder),
+	}
+	var requested int
+	w.SetResubscriber(func(currency.Pair, asset.Item) { requested++ })
+	update := func(u L3Update) error {
+		u.Pair, u.Asset = cp, asset.Spot
+		return w.UpdateL3(&u)
+	}
+	if err := update(L3Update{Action: L3Done, OrderID: "b1"}); !errors.Is(err, errL3BookNotFound) {
+		t.Fatalf("expected %v, received %v", errL3BookNotFound, err)
+	}
+
+	bids, asks := l3Snapshot()
+	err := w.LoadL3Snapshot(&L3Snapshot{
+		Exchange: "l3Test",
+		Pair:     cp,
+		Asset:    asset.Spot,
+		Bids:     bids,
+		Asks:     asks,
+		Sequence: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !w.HasL3Book(cp, asset.Spot) {
+		t.Fatal("expected level 3 book to be loaded")
+	}
+	// Updates preceding the snapshot are stale
+	if err = update(L3Update{Action: L3Done, OrderID: "b1", Sequence: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if err = update(L3Update{Action: L3Done, OrderID: "b1", Sequence: 11}); err != nil {
+		t.Fatal(err)
+	}
+	if err = update(L3Update{Action: L3Open, OrderID: "a3", Side: order.Sell, Price: 102, Amount: 1, Sequence: 12}); err != nil {
+		t.Fatal(err)
+	}
+	book, err := w.GetOrderbook(cp, asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(book.Bids) != 2 || book.Bids[0].Amount != 3 || len(book.Asks) != 2 || book.Asks[1].Price != 102 {
+		t.Errorf("unexpected price level view %+v %+v", book.Bids, book.Asks)
+	}
+	pos, err := w.GetQueuePosition(cp, asset.Spot, "a2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos.Side != order.Ask || pos.Index != 1 || pos.Ahead != 1 {
+		t.Errorf("unexpected queue position %+v", pos)
+	}
+	l3Bids, _, err := w.GetL3Orders(cp, asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l3Bids) != 2 || l3Bids[0].ID != "b3" {
+		t.Errorf("unexpected bids %+v", l3Bids)
+	}
+
+	// A match for an order which is not resting means the book is out of sync
+	if err = update(L3Update{Action: L3Match, OrderID: "b1", Amount: 1, Sequence: 13}); !errors.Is(err, errL3OrderNotFound) {
+		t.Fatalf("expected %v, received %v", errL3OrderNotFound, err)
+	}
+	if requested != 1 {
+		t.Errorf("expected resubscription, received %d", requested)
+	}
+
+	// A missed sequence drops the book until a fresh snapshot
+	if err = w.LoadL3Snapshot(&L3Snapshot{Exchange: "l3Test", Pair: cp, Asset: asset.Spot, Bids: bids, Asks: asks, Sequence: 20}); err != nil {
+		t.Fatal(err)
+	}
+	if err = update(L3Update{Action: L3Sequence, Sequence: 21}); err != nil {
+		t.Fatal(err)
+	}
+	if err = update(L3Update{Action: L3Done, OrderID: "b1", Sequence: 23}); !errors.Is(err, errL3SequenceGap) {
+		t.Fatalf("expected %v, received %v", errL3SequenceGap, err)
+	}
+	if w.HasL3Book(cp, asset.Spot) {
+		t.Error("expected level 3 book to be dropped")
+	}
+
+	// A price level snapshot replaces the order level book
+	err = w.LoadSnapshot(&orderbook.Base{
+		Exchange: "l3Test",
+		Pair:     cp,
+		Asset:    asset.Spot,
+		Bids:     orderbook.Items{{Price: 1, Amount: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = w.GetL3Orders(cp, asset.Spot); !errors.Is(err, errL3BookNotFound) {
+		t.Errorf("expected %v, received %v", errL3BookNotFound, err)
+	}
+}
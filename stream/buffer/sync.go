@@ -0,0 +1,450 @@
+package buffer
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/log"
+	"github.com/openware/pkg/orderbook"
+)
+
+var (
+	errSyncOrderbookUnset = errors.New("sync manager orderbook unset")
+	errSyncFetcherUnset   = errors.New("sync manager snapshot fetcher unset")
+	errSyncInvalidLimits  = errors.New("sync manager buffer limit and workers must be positive")
+	errSyncSnapshotNil    = errors.New("fetched snapshot is nil")
+)
+
+// Snapshot retry delays, doubled after each consecutive failed fetch of a book
+const (
+	syncRetryDelay    = time.Second
+	syncMaxRetryDelay = time.Minute
+)
+
+// SnapshotFetcher fetches a full book over REST carrying the ID of the last
+// update it holds, such as an exchange wrapper's UpdateOrderbook
+type SnapshotFetcher func(currency.Pair, asset.Item) (*orderbook.Base, error)
+
+// L3SnapshotFetcher fetches a full order by order book over REST carrying the
+// sequence of the last update it holds
+type L3SnapshotFetcher func(currency.Pair, asset.Item) (*L3Snapshot, error)
+
+// SyncManager keeps books built from sequenced updates in line with the
+// exchange. A book is paused when an update does not follow on from the last
+// one applied, a snapshot is fetched and the updates staged in the meantime
+// are spliced onto it.
+type SyncManager struct {
+	ob          *Orderbook
+	fetch       SnapshotFetcher
+	fetchL3     L3SnapshotFetcher
+	bufferLimit int
+	// workers bounds the snapshots fetched at once
+	workers chan struct{}
+	// retryDelay and maxRetryDelay back off fetching a book's snapshot after
+	// a failed fetch
+	retryDelay    time.Duration
+	maxRetryDelay time.Duration
+	books         map[currency.Code]map[currency.Code]map[asset.Item]*syncState
+	wg            sync.WaitGroup
+	m             sync.Mutex
+}
+
+// syncState is the synchronisation state of a book
+type syncState struct {
+	// staged and stagedL3 hold the updates received while a snapshot is
+	// fetched
+	staged   []*Update
+	stagedL3 []*L3Update
+	fetching bool
+	// synced is set once a snapshot is loaded and cleared when the book is
+	// paused
+	synced bool
+	// aligning is set until the first update following a snapshot, which
+	// must straddle the snapshot's last update ID
+	aligning bool
+	// failures counts consecutive failed fetches, no snapshot is fetched
+	// before retryAt
+	failures int
+	retryAt  time.Time
+}
+
+// NewSyncManager returns a sync manager applying updates to ob. Up to
+// bufferLimit updates are staged per book while its snapshot is fetched, by at
+// most workers fetches at once.
+func NewSyncManager(ob *Orderbook, fetch SnapshotFetcher, bufferLimit, workers int) (*SyncManager, error) {
+	if fetch == nil {
+		return nil, errSyncFetcherUnset
+	}
+	s, err := newSyncManager(ob, bufferLimit, workers)
+	if err != nil {
+		return nil, err
+	}
+	s.fetch = fetch
+	return s, nil
+}
+
+// NewL3SyncManager returns a sync manager applying order level updates to the
+// level 3 books of ob, see NewSyncManager
+func NewL3SyncManager(ob *Orderbook, fetch L3SnapshotFetcher, bufferLimit, workers int) (*SyncManager, error) {
+	if fetch == nil {
+		return nil, errSyncFetcherUnset
+	}
+	s, err := newSyncManager(ob, bufferLimit, workers)
+	if err != nil {
+		return nil, err
+	}
+	s.fetchL3 = fetch
+	return s, nil
+}
+
+func newSyncManager(ob *Orderbook, bufferLimit, workers int) (*SyncManager, error) {
+	if ob == nil {
+		return nil, errSyncOrderbookUnset
+	}
+	if bufferLimit < 1 || workers < 1 {
+		return nil, errSyncInvalidLimits
+	}
+	return &SyncManager{
+		ob:            ob,
+		bufferLimit:   bufferLimit,
+		workers:       make(chan struct{}, workers),
+		retryDelay:    syncRetryDelay,
+		maxRetryDelay: syncMaxRetryDelay,
+		books:         make(map[currency.Code]map[currency.Code]map[asset.Item]*syncState),
+	}, nil
+}
+
+// Update applies an update to its book, staging it while the book is being
+// synchronised. An update is taken to cover the IDs from FirstUpdateID, or
+// UpdateID when unset, through to UpdateID. Updates the book already holds are
+// dropped and a gap in IDs pauses the book until a snapshot is fetched.
+// Updates without IDs cannot be placed against a snapshot, they are applied
+// once the book is loaded and dropped while it is fetched.
+func (s *SyncManager) Update(u *Update) error {
+	if err := s.ob.validate(u); err != nil {
+		return err
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	state := s.state(u.Pair, u.Asset)
+	if state.fetching {
+		s.stage(state, u)
+		return nil
+	}
+	return s.apply(state, u)
+}
+
+// LoadSnapshot loads a book the exchange sent without it being requested,
+// such as one seeded on subscription, and applies any staged updates
+func (s *SyncManager) LoadSnapshot(book *orderbook.Base) error {
+	if err := s.ob.LoadSnapshot(book); err != nil {
+		return err
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.splice(s.state(book.Pair, book.Asset), book.Pair, book.Asset)
+	return nil
+}
+
+// LoadL3Snapshot loads a level 3 book obtained apart from the manager and
+// applies any staged updates, see LoadSnapshot
+func (s *SyncManager) LoadL3Snapshot(book *L3Snapshot) error {
+	if err := s.ob.LoadL3Snapshot(book); err != nil {
+		return err
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.splice(s.state(book.Pair, book.Asset), book.Pair, book.Asset)
+	return nil
+}
+
+// Resync pauses a book and fetches a fresh snapshot of it
+func (s *SyncManager) Resync(p currency.Pair, a asset.Item) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if state := s.state(p, a); !state.fetching {
+		s.pause(state, p, a)
+	}
+}
+
+// IsSyncing reports whether a snapshot of a book is being fetched
+func (s *SyncManager) IsSyncing(p currency.Pair, a asset.Item) bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+	state, ok := s.books[p.Base][p.Quote][a]
+	return ok && state.fetching
+}
+
+// state returns the synchronisation state of a book, the caller must hold the
+// lock
+func (s *SyncManager) state(p currency.Pair, a asset.Item) *syncState {
+	m1, ok := s.books[p.Base]
+	if !ok {
+		m1 = make(map[currency.Code]map[asset.Item]*syncState)
+		s.books[p.Base] = m1
+	}
+	m2, ok := m1[p.Quote]
+	if !ok {
+		m2 = make(map[asset.Item]*syncState)
+		m1[p.Quote] = m2
+	}
+	state, ok := m2[a]
+	if !ok {
+		state = &syncState{aligning: true}
+		m2[a] = state
+	}
+	return state
+}
+
+// stage holds an update until the book's snapshot is loaded. Once the limit is
+// reached the oldest update is dropped, should the snapshot not cover it the
+// gap is caught when the staged updates are spliced.
+func (s *SyncManager) stage(state *syncState, u *Update) {
+	if u.UpdateID == 0 {
+		return
+	}
+	if len(state.staged) >= s.bufferLimit {
+		state.staged = state.staged[1:]
+	}
+	state.staged = append(state.staged, u)
+}
+
+// apply validates an update follows on from the book before applying it, the
+// caller must hold the lock
+func (s *SyncManager) apply(state *syncState, u *Update) error {
+	last, ok := s.ob.lastUpdateID(u.Pair, u.Asset)
+	if !state.synced || !ok {
+		if s.pause(state, u.Pair, u.Asset) {
+			s.stage(state, u)
+		}
+		return nil
+	}
+	if u.UpdateID == 0 {
+		// A book failing its checksum is resynchronised by the orderbook
+		return s.ob.Update(u)
+	}
+	if u.UpdateID <= last {
+		// Already held by the book
+		return nil
+	}
+	first := u.FirstUpdateID
+	if first == 0 {
+		first = u.UpdateID
+	}
+	if first > last+1 || (!state.aligning && first != last+1) {
+		if s.ob.verbose {
+			log.Warnf(log.WebsocketMgr,
+				"%s websocket: orderbook %s %s missed updates %d to %d, resynchronising",
+				s.ob.exchangeName,
+				u.Pair,
+				u.Asset,
+				last+1,
+				first-1)
+		}
+		if s.pause(state, u.Pair, u.Asset) {
+			s.stage(state, u)
+		}
+		return nil
+	}
+	state.aligning = false
+	if err := s.ob.Update(u); err != nil {
+		s.pause(state, u.Pair, u.Asset)
+		return err
+	}
+	return nil
+}
+
+// UpdateL3 applies an order level update to its level 3 book, staging it while
+// the book's snapshot is fetched. A book which is not held or misses a
+// sequence is paused until a snapshot is fetched, updates it already holds are
+// dropped.
+func (s *SyncManager) UpdateL3(u *L3Update) error {
+	if u == nil {
+		return fmt.Errorf(packageError, errUpdateIsNil)
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	state := s.state(u.Pair, u.Asset)
+	if state.fetching {
+		s.stageL3(state, u)
+		return nil
+	}
+	return s.applyL3(state, u)
+}
+
+// stageL3 holds an order level update until the book's snapshot is loaded,
+// see stage
+func (s *SyncManager) stageL3(state *syncState, u *L3Update) {
+	if len(state.stagedL3) >= s.bufferLimit {
+		state.stagedL3 = state.stagedL3[1:]
+	}
+	state.stagedL3 = append(state.stagedL3, u)
+}
+
+// applyL3 applies an order level update, pausing the book when it cannot be.
+// The caller must hold the lock.
+func (s *SyncManager) applyL3(state *syncState, u *L3Update) error {
+	if !state.synced || !s.ob.HasL3Book(u.Pair, u.Asset) {
+		if s.pause(state, u.Pair, u.Asset) {
+			s.stageL3(state, u)
+		}
+		return nil
+	}
+	if err := s.ob.UpdateL3(u); err != nil {
+		// The book is dropped, updates up to the snapshot's sequence are
+		// dropped as stale once it is loaded
+		if s.pause(state, u.Pair, u.Asset) {
+			s.stageL3(state, u)
+		}
+		return err
+	}
+	return nil
+}
+
+// pause flushes a book and fetches a snapshot of it, the updates staged from
+// then on are spliced onto it. It returns false while backing off from a
+// failed fetch, updates are dropped meanwhile. The caller must hold the lock.
+func (s *SyncManager) pause(state *syncState, p currency.Pair, a asset.Item) bool {
+	if !state.synced && time.Now().Before(state.retryAt) {
+		return false
+	}
+	state.synced = false
+	state.fetching = true
+	state.aligning = true
+	state.staged = nil
+	state.stagedL3 = nil
+	// The book is not loaded before its first snapshot
+	_ = s.ob.FlushOrderbook(p, a)
+	s.wg.Add(1)
+	go s.sync(p, a)
+	return true
+}
+
+// sync fetches and loads a snapshot of a book then splices the staged updates
+// onto it
+func (s *SyncManager) sync(p currency.Pair, a asset.Item) {
+	defer s.wg.Done()
+	s.workers <- struct{}{}
+	var err error
+	if s.fetchL3 != nil {
+		var book *L3Snapshot
+		if book, err = s.fetchL3(p, a); err == nil {
+			err = s.loadL3(book)
+		}
+	} else {
+		var book *orderbook.Base
+		if book, err = s.fetch(p, a); err == nil {
+			err = s.load(book)
+		}
+	}
+	<-s.workers
+
+	s.m.Lock()
+	defer s.m.Unlock()
+	state := s.state(p, a)
+	state.fetching = false
+	if err != nil {
+		// The first update after the retry delay retries
+		state.staged = nil
+		state.stagedL3 = nil
+		state.failures++
+		delay := s.retryDelay
+		for i := 1; i < state.failures && delay < s.maxRetryDelay; i++ {
+			delay *= 2
+		}
+		if delay > s.maxRetryDelay {
+			delay = s.maxRetryDelay
+		}
+		state.retryAt = time.Now().Add(delay)
+		log.Errorf(log.WebsocketMgr,
+			"%s websocket: cannot synchronise orderbook %s %s, retrying in %s: %v",
+			s.ob.exchangeName,
+			p,
+			a,
+			delay,
+			err)
+		return
+	}
+	s.splice(state, p, a)
+}
+
+// load loads a fetched snapshot, handing the book from REST to the stream
+func (s *SyncManager) load(book *orderbook.Base) error {
+	if book == nil {
+		return errSyncSnapshotNil
+	}
+	snapshot := *book
+	snapshot.RestSnapshot = false
+	return s.ob.LoadSnapshot(&snapshot)
+}
+
+// loadL3 loads a fetched level 3 snapshot
+func (s *SyncManager) loadL3(book *L3Snapshot) error {
+	if book == nil {
+		return errSyncSnapshotNil
+	}
+	return s.ob.LoadL3Snapshot(book)
+}
+
+// splice marks a book synchronised to its loaded snapshot and applies the
+// staged updates the snapshot does not hold. Updates following a further gap
+// are staged for the next snapshot. The caller must hold the lock.
+func (s *SyncManager) splice(state *syncState, p currency.Pair, a asset.Item) {
+	state.synced = true
+	state.fetching = false
+	state.aligning = true
+	state.failures = 0
+	state.retryAt = time.Time{}
+	staged, stagedL3 := state.staged, state.stagedL3
+	state.staged, state.stagedL3 = nil, nil
+	for i := range stagedL3 {
+		err := s.applyL3(state, stagedL3[i])
+		if state.fetching {
+			for _, u := range stagedL3[i+1:] {
+				s.stageL3(state, u)
+			}
+			return
+		}
+		if err != nil {
+			log.Errorf(log.WebsocketMgr,
+				"%s websocket: cannot apply staged level 3 orderbook update %s %s: %v",
+				s.ob.exchangeName,
+				p,
+				a,
+				err)
+			return
+		}
+	}
+	for i := range staged {
+		if err := s.apply(state, staged[i]); err != nil {
+			log.Errorf(log.WebsocketMgr,
+				"%s websocket: cannot apply staged orderbook update %s %s: %v",
+				s.ob.exchangeName,
+				p,
+				a,
+				err)
+			return
+		}
+		if state.fetching {
+			for _, u := range staged[i+1:] {
+				s.stage(state, u)
+			}
+			return
+		}
+	}
+}
+
+// lastUpdateID returns the ID of the last update applied to a book
+func (w *Orderbook) lastUpdateID(p currency.Pair, a asset.Item) (int64, bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	book, ok := w.ob[p.Base][p.Quote][a]
+	if !ok {
+		return 0, false
+	}
+	return book.ob.LastUpdateID(), true
+}
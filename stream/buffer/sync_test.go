@@ -0,0 +1,381 @@
+package buffer
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
+	"github.com/openware/pkg/orderbook"
+)
+
+// syncFetcher serves snapshots to a sync manager, blocking each fetch until
+// released. Each fetch takes the next of ids as the snapshot's last update ID.
+type syncFetcher struct {
+	release chan struct{}
+	m       sync.Mutex
+	fetched int
+	ids     []int64
+	book    *orderbook.Base
+	err     error
+}
+
+func (f *syncFetcher) fetch(p currency.Pair, a asset.Item) (*orderbook.Base, error) {
+	<-f.release
+	f.m.Lock()
+	defer f.m.Unlock()
+	f.fetched++
+	if len(f.ids) != 0 {
+		f.book.LastUpdateID, f.ids = f.ids[0], f.ids[1:]
+	}
+	return f.book, f.err
+}
+
+// queue sets the last update IDs of the snapshots fetched next
+func (f *syncFetcher) queue(ids ...int64) {
+	f.m.Lock()
+	f.ids = ids
+	f.m.Unlock()
+}
+
+func newSyncTest(t *testing.T, snapshotID int64) (*SyncManager, *syncFetcher) {
+	t.Helper()
+	w := &Orderbook{
+		exchangeName: exchangeName,
+		dataHandler:  make(chan interface{}, 100),
+		ob:           make(map[currency.Code]map[currency.Code]map[asset.Item]*orderbookHolder),
+	}
+	f := &syncFetcher{
+		release: make(chan struct{}),
+		book: &orderbook.Base{
+			Exchange:     exchangeName,
+			Pair:         cp,
+			Asset:        asset.Spot,
+			Bids:         orderbook.Items{{Price: 100, Amount: 1}},
+			Asks:         orderbook.Items{{Price: 101, Amount: 1}},
+			LastUpdateID: snapshotID,
+			RestSnapshot: true,
+		},
+	}
+	s, err := NewSyncManager(w, f.fetch, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, f
+}
+
+func syncUpdate(first, last int64, bidPrice, bidAmount float64) *Update {
+	return &Update{
+		FirstUpdateID: first,
+		UpdateID:      last,
+		Bids:          orderbook.Items{{Price: bidPrice, Amount: bidAmount}},
+		Pair:          cp,
+		Asset:         asset.Spot,
+	}
+}
+
+func TestNewSyncManager(t *testing.T) {
+	t.Parallel()
+	fetch := func(currency.Pair, asset.Item) (*orderbook.Base, error) { return nil, nil }
+	if _, err := NewSyncManager(nil, fetch, 1, 1); !errors.Is(err, errSyncOrderbookUnset) {
+		t.Errorf("expected %v, received %v", errSyncOrderbookUnset, err)
+	}
+	if _, err := NewSyncManager(&Orderbook{}, nil, 1, 1); !errors.Is(err, errSyncFetcherUnset) {
+		t.Errorf("expected %v, received %v", errSyncFetcherUnset, err)
+	}
+	if _, err := NewSyncManager(&Orderbook{}, fetch, 1, 0); !errors.Is(err, errSyncInvalidLimits) {
+		t.Errorf("expected %v, received %v", errSyncInvalidLimits, err)
+	}
+}
+
+func TestSyncManager(t *testing.T) {
+	t.Parallel()
+	s, f := newSyncTest(t, 10)
+	bid := func(price, amount float64) {
+		t.Helper()
+		book, err := s.ob.GetOrderbook(cp, asset.Spot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for x := range book.Bids {
+			if book.Bids[x].Price == price {
+				if book.Bids[x].Amount != amount {
+					t.Errorf("expected bid %v amount %v, received %v", price, amount, book.Bids[x].Amount)
+				}
+				return
+			}
+		}
+		if amount != 0 {
+			t.Errorf("expected bid %v in %+v", price, book.Bids)
+		}
+	}
+
+	// The first update fetches the book, updates are staged meanwhile
+	for _, u := range []*Update{
+		syncUpdate(5, 8, 98, 1),   // held by the snapshot
+		syncUpdate(9, 11, 99, 2),  // straddles the snapshot
+		syncUpdate(12, 12, 99, 3), // follows on
+	} {
+		if err := s.Update(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !s.IsSyncing(cp, asset.Spot) {
+		t.Fatal("expected book to be syncing")
+	}
+	f.release <- struct{}{}
+	s.wg.Wait()
+	if s.IsSyncing(cp, asset.Spot) {
+		t.Fatal("expected book to be synced")
+	}
+	bid(98, 0)
+	bid(99, 3)
+	if s.ob.ob[cp.Base][cp.Quote][asset.Spot].ob.IsRestSnapshot() {
+		t.Error("expected the stream to take the book over from REST")
+	}
+
+	if err := s.Update(syncUpdate(13, 14, 100, 4)); err != nil {
+		t.Fatal(err)
+	}
+	bid(100, 4)
+
+	// A gap pauses and flushes the book until the next snapshot
+	if err := s.Update(syncUpdate(16, 16, 100, 5)); err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsSyncing(cp, asset.Spot) {
+		t.Fatal("expected gap to resynchronise the book")
+	}
+	if book, _ := s.ob.GetOrderbook(cp, asset.Spot); len(book.Bids) != 0 {
+		t.Errorf("expected paused book to be flushed, received %+v", book.Bids)
+	}
+	// Staged updates beyond the limit are dropped oldest first
+	for i := int64(17); i < 20; i++ {
+		if err := s.Update(syncUpdate(i, i, 100, float64(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.queue(17)
+	f.release <- struct{}{}
+	s.wg.Wait()
+	bid(100, 19)
+	if book, _ := s.ob.GetOrderbook(cp, asset.Spot); book.LastUpdateID != 19 {
+		t.Errorf("expected last update ID 19, received %d", book.LastUpdateID)
+	}
+
+	// A snapshot which does not reach the staged updates is fetched again
+	f.queue(19, 24)
+	s.Resync(cp, asset.Spot)
+	if err := s.Update(syncUpdate(25, 25, 100, 25)); err != nil {
+		t.Fatal(err)
+	}
+	f.release <- struct{}{}
+	f.release <- struct{}{}
+	s.wg.Wait()
+	bid(100, 25)
+	if f.fetched != 4 {
+		t.Errorf("expected 4 snapshots fetched, received %d", f.fetched)
+	}
+
+	// A failed fetch is retried by the first update after the retry delay,
+	// which doubles with each consecutive failure
+	s.retryDelay = 50 * time.Millisecond
+	f.m.Lock()
+	f.err = errors.New("REST unavailable")
+	f.m.Unlock()
+	s.Resync(cp, asset.Spot)
+	f.release <- struct{}{}
+	s.wg.Wait()
+	if s.IsSyncing(cp, asset.Spot) {
+		t.Fatal("expected failed fetch to finish syncing")
+	}
+	if err := s.Update(syncUpdate(26, 26, 100, 26)); err != nil {
+		t.Fatal(err)
+	}
+	if s.IsSyncing(cp, asset.Spot) {
+		t.Fatal("expected update within the retry delay not to fetch the book")
+	}
+	time.Sleep(s.retryDelay)
+	if err := s.Update(syncUpdate(27, 27, 100, 27)); err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsSyncing(cp, asset.Spot) {
+		t.Fatal("expected update to retry fetching the book")
+	}
+	f.release <- struct{}{}
+	s.wg.Wait()
+	s.m.Lock()
+	state := s.state(cp, asset.Spot)
+	if state.failures != 2 || time.Until(state.retryAt) <= s.retryDelay {
+		t.Errorf("expected second failure to double the retry delay, received %d failures retrying in %s",
+			state.failures, time.Until(state.retryAt))
+	}
+	s.m.Unlock()
+
+	// A loaded snapshot clears the backoff
+	f.m.Lock()
+	f.err = nil
+	f.m.Unlock()
+	book := *f.book
+	book.LastUpdateID = 30
+	book.RestSnapshot = false
+	if err := s.LoadSnapshot(&book); err != nil {
+		t.Fatal(err)
+	}
+	s.m.Lock()
+	if state.failures != 0 || !state.retryAt.IsZero() {
+		t.Error("expected snapshot to clear the retry backoff")
+	}
+	s.m.Unlock()
+}
+
+func TestSyncManagerLoadSnapshot(t *testing.T) {
+	t.Parallel()
+	s, f := newSyncTest(t, 0)
+	book := *f.book
+	book.LastUpdateID = 50
+	book.RestSnapshot = false
+	if err := s.LoadSnapshot(&book); err != nil {
+		t.Fatal(err)
+	}
+	// Updates the book holds are dropped without fetching it
+	if err := s.Update(syncUpdate(40, 49, 99, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Update(syncUpdate(50, 51, 99, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if s.IsSyncing(cp, asset.Spot) {
+		t.Fatal("expected loaded book not to be fetched")
+	}
+	ob, err := s.ob.GetOrderbook(cp, asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ob.LastUpdateID != 51 || len(ob.Bids) != 2 {
+		t.Errorf("unexpected book %+v at update %d", ob.Bids, ob.LastUpdateID)
+	}
+}
+
+func TestSyncManagerUnsequenced(t *testing.T) {
+	t.Parallel()
+	s, f := newSyncTest(t, 0)
+	update := &Update{
+		Bids:  orderbook.Items{{Price: 99, Amount: 1}},
+		Pair:  cp,
+		Asset: asset.Spot,
+	}
+	// An update before the book is loaded fetches it, but cannot be staged
+	if err := s.Update(update); err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsSyncing(cp, asset.Spot) {
+		t.Fatal("expected book to be fetched")
+	}
+	if err := s.Update(update); err != nil {
+		t.Fatal(err)
+	}
+	f.release <- struct{}{}
+	s.wg.Wait()
+	ob, err := s.ob.GetOrderbook(cp, asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ob.Bids) != 1 {
+		t.Fatalf("expected updates fetched meanwhile to be dropped, received %+v", ob.Bids)
+	}
+	// Once loaded updates are applied as they arrive
+	if err = s.Update(update); err != nil {
+		t.Fatal(err)
+	}
+	if ob, _ = s.ob.GetOrderbook(cp, asset.Spot); len(ob.Bids) != 2 {
+		t.Errorf("expected update to be applied, received %+v", ob.Bids)
+	}
+}
+
+func TestSyncManagerL3(t *testing.T) {
+	t.Parallel()
+	w := &Orderbook{
+		exchangeName: exchangeName,
+		dataHandler:  make(chan interface{}, 100),
+		ob:           make(map[currency.Code]map[currency.Code]map[asset.Item]*orderbookHolder),
+	}
+	release := make(chan struct{})
+	var sequences []int64
+	fetch := func(p currency.Pair, a asset.Item) (*L3Snapshot, error) {
+		<-release
+		seq := sequences[0]
+		sequences = sequences[1:]
+		return &L3Snapshot{
+			Exchange: exchangeName,
+			Pair:     p,
+			Asset:    a,
+			Bids:     []L3Order{{ID: "1", Price: 100, Amount: 1}},
+			Asks:     []L3Order{{ID: "2", Price: 101, Amount: 1}},
+			Sequence: seq,
+		}, nil
+	}
+	if _, err := NewL3SyncManager(w, nil, 1, 1); !errors.Is(err, errSyncFetcherUnset) {
+		t.Fatalf("expected %v, received %v", errSyncFetcherUnset, err)
+	}
+	s, err := NewL3SyncManager(w, fetch, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	open := func(id string, seq int64) *L3Update {
+		return &L3Update{
+			Action:   L3Open,
+			OrderID:  id,
+			Side:     order.Bid,
+			Price:    99,
+			Amount:   1,
+			Sequence: seq,
+			Pair:     cp,
+			Asset:    asset.Spot,
+		}
+	}
+	orders := func() int {
+		bids, _, err := w.GetL3Orders(cp, asset.Spot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(bids)
+	}
+
+	// Updates are staged while the book is fetched, those the snapshot holds
+	// are dropped
+	sequences = []int64{10}
+	for _, u := range []*L3Update{open("a", 10), open("b", 11), open("c", 12)} {
+		if err = s.UpdateL3(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !s.IsSyncing(cp, asset.Spot) {
+		t.Fatal("expected book to be syncing")
+	}
+	release <- struct{}{}
+	s.wg.Wait()
+	if n := orders(); n != 3 {
+		t.Fatalf("expected staged updates to be spliced, received %d bids", n)
+	}
+
+	// A sequence gap pauses the book until the next snapshot
+	sequences = []int64{15}
+	if err = s.UpdateL3(open("d", 14)); !errors.Is(err, errL3SequenceGap) {
+		t.Fatalf("expected %v, received %v", errL3SequenceGap, err)
+	}
+	if !s.IsSyncing(cp, asset.Spot) {
+		t.Fatal("expected gap to resynchronise the book")
+	}
+	if err = s.UpdateL3(open("e", 16)); err != nil {
+		t.Fatal(err)
+	}
+	release <- struct{}{}
+	s.wg.Wait()
+	if n := orders(); n != 2 {
+		t.Errorf("expected snapshot and the update following it, received %d bids", n)
+	}
+}
@@ -0,0 +1,294 @@
+package stream
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/openware/irix/ticker"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
+	"github.com/openware/pkg/orderbook"
+	"github.com/openware/pkg/trade"
+)
+
+// Event is data passed to the data handler along with where it came from
+type Event struct {
+	Exchange string
+	Pair     currency.Pair
+	Asset    asset.Item
+	Data     interface{}
+}
+
+// Filter restricts a subscription to an exchange, pair and asset, unset
+// fields match everything. Errors carry no pair or asset so are delivered
+// whenever the exchange matches.
+type Filter struct {
+	Exchange string
+	Pair     currency.Pair
+	Asset    asset.Item
+}
+
+// match reports whether the filter accepts e
+func (f *Filter) match(e *Event) bool {
+	if f.Exchange != "" && !strings.EqualFold(f.Exchange, e.Exchange) {
+		return false
+	}
+	if _, ok := e.Data.(error); ok {
+		return true
+	}
+	if f.Asset != "" && f.Asset != e.Asset {
+		return false
+	}
+	return f.Pair.IsEmpty() || f.Pair.Equal(e.Pair)
+}
+
+// Handlers are called with data of their type, nil handlers skip that type.
+// Value and pointer forms of a type are normalised so a handler sees every
+// instance regardless of how the exchange sent it.
+type Handlers struct {
+	Ticker    func(*ticker.Price)
+	Orderbook func(*orderbook.Base)
+	Kline     func(KlineData)
+	Trades    func([]trade.Data)
+	Order     func(*order.Detail)
+	Funding   func(FundingData)
+	Error     func(error)
+	// Other receives every type without a dedicated handler, so nothing sent
+	// by an exchange is missed silently
+	Other func(interface{})
+}
+
+// dispatch calls the handler for the event's type
+func (h *Handlers) dispatch(d interface{}) {
+	switch v := d.(type) {
+	case *ticker.Price:
+		if h.Ticker != nil {
+			h.Ticker(v)
+		}
+	case ticker.Price:
+		if h.Ticker != nil {
+			h.Ticker(&v)
+		}
+	case *orderbook.Base:
+		if h.Orderbook != nil {
+			h.Orderbook(v)
+		}
+	case KlineData:
+		if h.Kline != nil {
+			h.Kline(v)
+		}
+	case *KlineData:
+		if h.Kline != nil {
+			h.Kline(*v)
+		}
+	case []trade.Data:
+		if h.Trades != nil {
+			h.Trades(v)
+		}
+	case trade.Data:
+		if h.Trades != nil {
+			h.Trades([]trade.Data{v})
+		}
+	case *order.Detail:
+		if h.Order != nil {
+			h.Order(v)
+		}
+	case order.Detail:
+		if h.Order != nil {
+			h.Order(&v)
+		}
+	case FundingData:
+		if h.Funding != nil {
+			h.Funding(v)
+		}
+	case order.ClassificationError:
+		if h.Error != nil {
+			h.Error(&v)
+		}
+	case error:
+		if h.Error != nil {
+			h.Error(v)
+		}
+	default:
+		if h.Other != nil {
+			h.Other(d)
+		}
+	}
+}
+
+// newEvent describes data published by exchange
+func newEvent(exchange string, d interface{}) Event {
+	e := Event{Exchange: exchange, Data: d}
+	switch v := d.(type) {
+	case *ticker.Price:
+		e.Pair, e.Asset = v.Pair, v.AssetType
+	case ticker.Price:
+		e.Pair, e.Asset = v.Pair, v.AssetType
+	case *orderbook.Base:
+		e.Pair, e.Asset = v.Pair, v.Asset
+	case KlineData:
+		e.Pair, e.Asset = v.Pair, v.AssetType
+	case *KlineData:
+		e.Pair, e.Asset = v.Pair, v.AssetType
+	case []trade.Data:
+		if len(v) != 0 {
+			e.Pair, e.Asset = v[0].CurrencyPair, v[0].AssetType
+		}
+	case trade.Data:
+		e.Pair, e.Asset = v.CurrencyPair, v.AssetType
+	case *order.Detail:
+		e.Pair, e.Asset = v.Pair, v.AssetType
+	case order.Detail:
+		e.Pair, e.Asset = v.Pair, v.AssetType
+	case FundingData:
+		e.Pair, e.Asset = v.CurrencyPair, v.AssetType
+	}
+	return e
+}
+
+// EventBus fans data out to every subscription. Each subscription has its own
+// bounded queue per data kind and its own goroutine so a slow consumer never
+// delays another. A full queue blocks the publisher unless SetBackpressure
+// gives the kind a dropping policy, the same policies which bound ToRoutine.
+// Order and account data is never dropped. Events of a kind are delivered in
+// order, order updates, account data and errors ahead of market data.
+type EventBus struct {
+	subs         map[*Subscription]struct{}
+	backpressure map[DataKind]Backpressure
+	mtx          sync.RWMutex
+}
+
+// NewEventBus returns a bus without subscriptions, one bus can be shared by
+// several websockets through SetEventBus. Every event is delivered, a full
+// queue blocks the publisher until the subscription catches up.
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subs:         make(map[*Subscription]struct{}),
+		backpressure: make(map[DataKind]Backpressure),
+	}
+}
+
+// SetBackpressure sets the policy for data of kind k, it applies to
+// subscriptions made afterwards. Dropping market data keeps a slow
+// subscription from holding up the websockets publishing on the bus.
+func (b *EventBus) SetBackpressure(k DataKind, bp Backpressure) error {
+	if err := validateBackpressure(k, bp); err != nil {
+		return err
+	}
+	b.mtx.Lock()
+	b.backpressure[k] = bp
+	b.mtx.Unlock()
+	return nil
+}
+
+// Subscribe attaches handlers for events matching f
+func (b *EventBus) Subscribe(f Filter, h Handlers) *Subscription {
+	s := &Subscription{
+		bus:      b,
+		filter:   f,
+		handlers: h,
+		closing:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	b.mtx.Lock()
+	s.queue = newPipeline(b.backpressure)
+	b.subs[s] = struct{}{}
+	b.mtx.Unlock()
+	go s.run()
+	return s
+}
+
+// Len returns the number of subscriptions
+func (b *EventBus) Len() int {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+	return len(b.subs)
+}
+
+// Publish queues data from exchange for every matching subscription. It
+// blocks while a matching subscription's queue for a blocking kind is full.
+func (b *EventBus) Publish(exchange string, d interface{}) {
+	e := newEvent(exchange, d)
+	b.mtx.RLock()
+	matched := make([]*Subscription, 0, len(b.subs))
+	for s := range b.subs {
+		if s.filter.match(&e) {
+			matched = append(matched, s)
+		}
+	}
+	// Pushing without the lock lets a blocked subscription unsubscribe
+	b.mtx.RUnlock()
+	for i := range matched {
+		matched[i].push(e)
+	}
+}
+
+// Subscription is a consumer attached to an EventBus
+type Subscription struct {
+	bus       *EventBus
+	filter    Filter
+	handlers  Handlers
+	queue     *pipeline
+	dropped   [kindCount]uint64
+	closing   chan struct{}
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// push queues an event for delivery under its kind's policy
+func (s *Subscription) push(e Event) {
+	select {
+	case <-s.closing:
+		return
+	default:
+	}
+	k := kindOf(e.Data)
+	switch s.queue.offer(k, e) {
+	case offerDropped, offerConflated:
+		atomic.AddUint64(&s.dropped[k], 1)
+	case offerFull:
+		s.queue.wait(k, e, s.closing)
+	}
+}
+
+// run delivers queued events until the subscription is closed
+func (s *Subscription) run() {
+	defer close(s.done)
+	for {
+		d, ok := s.queue.next(s.closing)
+		if !ok {
+			return
+		}
+		s.handlers.dispatch(d.(Event).Data)
+	}
+}
+
+// Pending returns the number of events waiting to be delivered
+func (s *Subscription) Pending() int {
+	return s.queue.len()
+}
+
+// Dropped returns the number of events of kind k discarded by backpressure,
+// including conflated values
+func (s *Subscription) Dropped(k DataKind) uint64 {
+	if k >= kindCount {
+		return 0
+	}
+	return atomic.LoadUint64(&s.dropped[k])
+}
+
+// Unsubscribe detaches the subscription, discarding undelivered events. It
+// waits for a handler already running to return so must not be called from a
+// handler.
+func (s *Subscription) Unsubscribe() {
+	s.bus.mtx.Lock()
+	delete(s.bus.subs, s)
+	s.bus.mtx.Unlock()
+	s.closeOnce.Do(func() {
+		close(s.closing)
+		s.queue.clear()
+	})
+	<-s.done
+}
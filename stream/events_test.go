@@ -0,0 +1,295 @@
+package stream
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openware/irix/ticker"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
+	"github.com/openware/pkg/orderbook"
+	"github.com/openware/pkg/trade"
+)
+
+var (
+	btcusd = currency.NewPair(currency.BTC, currency.USD)
+	ethusd = currency.NewPair(currency.ETH, currency.USD)
+)
+
+// waitFor polls cond until it holds or the test times out
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for delivery")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestEventHandlers(t *testing.T) {
+	t.Parallel()
+	bus := NewEventBus()
+	var mtx sync.Mutex
+	received := make(map[string]int)
+	count := func(kind string) {
+		mtx.Lock()
+		received[kind]++
+		mtx.Unlock()
+	}
+	sub := bus.Subscribe(Filter{}, Handlers{
+		Ticker:    func(*ticker.Price) { count("ticker") },
+		Orderbook: func(*orderbook.Base) { count("orderbook") },
+		Kline:     func(KlineData) { count("kline") },
+		Trades:    func(d []trade.Data) { count("trades") },
+		Order:     func(*order.Detail) { count("order") },
+		Funding:   func(FundingData) { count("funding") },
+		Error:     func(error) { count("error") },
+		Other:     func(interface{}) { count("other") },
+	})
+	defer sub.Unsubscribe()
+
+	for _, d := range []interface{}{
+		&ticker.Price{}, ticker.Price{},
+		&orderbook.Base{},
+		KlineData{}, &KlineData{},
+		[]trade.Data{{}}, trade.Data{},
+		&order.Detail{}, order.Detail{},
+		FundingData{},
+		errors.New("failed"), order.ClassificationError{Err: errors.New("bad")},
+		UnhandledMessageWarning{Message: "?"},
+	} {
+		bus.Publish("test", d)
+	}
+	expected := map[string]int{
+		"ticker": 2, "orderbook": 1, "kline": 2, "trades": 2, "order": 2,
+		"funding": 1, "error": 2, "other": 1,
+	}
+	waitFor(t, func() bool { return sub.Pending() == 0 })
+	waitFor(t, func() bool {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return received["other"] == 1
+	})
+	mtx.Lock()
+	defer mtx.Unlock()
+	for kind, n := range expected {
+		if received[kind] != n {
+			t.Errorf("%s: expected %d, received %d", kind, n, received[kind])
+		}
+	}
+}
+
+func TestEventFilter(t *testing.T) {
+	t.Parallel()
+	bus := NewEventBus()
+	var mtx sync.Mutex
+	var tickers []*ticker.Price
+	var errs int
+	sub := bus.Subscribe(Filter{Exchange: "binance", Pair: btcusd, Asset: asset.Spot}, Handlers{
+		Ticker: func(p *ticker.Price) {
+			mtx.Lock()
+			tickers = append(tickers, p)
+			mtx.Unlock()
+		},
+		Error: func(error) {
+			mtx.Lock()
+			errs++
+			mtx.Unlock()
+		},
+	})
+	defer sub.Unsubscribe()
+
+	bus.Publish("Binance", &ticker.Price{Pair: btcusd, AssetType: asset.Spot, Last: 1})
+	bus.Publish("Binance", &ticker.Price{Pair: ethusd, AssetType: asset.Spot})
+	bus.Publish("Binance", &ticker.Price{Pair: btcusd, AssetType: asset.Margin})
+	bus.Publish("Kraken", &ticker.Price{Pair: btcusd, AssetType: asset.Spot})
+	bus.Publish("Binance", errors.New("disconnected"))
+	bus.Publish("Kraken", errors.New("disconnected"))
+
+	waitFor(t, func() bool {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return len(tickers) == 1 && errs == 1
+	})
+	if tickers[0].Last != 1 {
+		t.Errorf("unexpected ticker %+v", tickers[0])
+	}
+}
+
+func TestEventIndependentConsumers(t *testing.T) {
+	t.Parallel()
+	bus := NewEventBus()
+	release := make(chan struct{})
+	slow := bus.Subscribe(Filter{}, Handlers{Other: func(interface{}) { <-release }})
+
+	var mtx sync.Mutex
+	var order []int
+	fast := bus.Subscribe(Filter{}, Handlers{Other: func(d interface{}) {
+		mtx.Lock()
+		order = append(order, d.(int))
+		mtx.Unlock()
+	}})
+	defer fast.Unsubscribe()
+
+	const n = 1000
+	for i := 0; i < n; i++ {
+		bus.Publish("test", i)
+	}
+	// The blocked consumer holds nothing up for the other
+	waitFor(t, func() bool {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return len(order) == n
+	})
+	for i := range order {
+		if order[i] != i {
+			t.Fatalf("expected in order delivery, received %d at %d", order[i], i)
+		}
+	}
+	// The first event is held by the blocked handler, the rest stay queued
+	waitFor(t, func() bool { return slow.Pending() == n-1 })
+	if bus.Len() != 2 {
+		t.Errorf("expected 2 subscriptions, received %d", bus.Len())
+	}
+	close(release)
+	slow.Unsubscribe()
+	slow.Unsubscribe()
+	if bus.Len() != 1 {
+		t.Errorf("expected 1 subscription, received %d", bus.Len())
+	}
+	bus.Publish("test", 0)
+	if p := slow.Pending(); p != 0 {
+		t.Errorf("expected nothing queued after unsubscribing, received %d", p)
+	}
+}
+
+func TestDataMonitorPublishesEvents(t *testing.T) {
+	t.Parallel()
+	ws := New()
+	ws.exchangeName = "Binance"
+	ws.ShutdownC = make(chan struct{})
+	ws.Wg = new(sync.WaitGroup)
+	// Leave ToRoutine without a reader, subscribers must still be served
+	ws.ToRoutine = make(chan interface{})
+
+	shared := NewEventBus()
+	ws.SetEventBus(shared)
+	received := make(chan *ticker.Price, 1)
+	sub := ws.Events().Subscribe(Filter{Exchange: "binance"}, Handlers{
+		Ticker: func(p *ticker.Price) { received <- p },
+	})
+	defer sub.Unsubscribe()
+
+	ws.dataMonitor()
+	ws.DataHandler <- "unhandled"
+	ws.DataHandler <- &ticker.Price{Pair: btcusd, AssetType: asset.Spot}
+	select {
+	case p := <-received:
+		if !p.Pair.Equal(btcusd) {
+			t.Errorf("unexpected pair %s", p.Pair)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for ticker")
+	}
+	close(ws.ShutdownC)
+	ws.Wg.Wait()
+}
+
+func TestSubscriptionBackpressure(t *testing.T) {
+	t.Parallel()
+	bus := NewEventBus()
+	// Nothing is dropped unless a policy is set
+	for k, q := range newPipeline(bus.backpressure).queues {
+		if q.Policy != PolicyBlock {
+			t.Errorf("expected %s to block by default, received %d", DataKind(k), q.Policy)
+		}
+	}
+	if err := bus.SetBackpressure(kindCount, Backpressure{}); !errors.Is(err, errInvalidPolicy) {
+		t.Errorf("expected %v, received %v", errInvalidPolicy, err)
+	}
+	if err := bus.SetBackpressure(KindTrade, Backpressure{Policy: PolicyDropOldest, Size: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.SetBackpressure(KindOther, Backpressure{Policy: PolicyBlock, Size: 1}); err != nil {
+		t.Fatal(err)
+	}
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	var mtx sync.Mutex
+	var trades []float64
+	sub := bus.Subscribe(Filter{}, Handlers{
+		Ticker: func(*ticker.Price) {
+			started <- struct{}{}
+			<-release
+		},
+		Trades: func(d []trade.Data) {
+			mtx.Lock()
+			trades = append(trades, d[0].Price)
+			mtx.Unlock()
+		},
+	})
+
+	// Hold the handler so later events queue
+	bus.Publish("Binance", &ticker.Price{Pair: btcusd, AssetType: asset.Spot})
+	<-started
+	for i := 1; i <= 4; i++ {
+		bus.Publish("Binance", trade.Data{CurrencyPair: btcusd, AssetType: asset.Spot, Price: float64(i)})
+	}
+	if d := sub.Dropped(KindTrade); d != 2 {
+		t.Errorf("expected 2 trades dropped, received %d", d)
+	}
+	if p := sub.Pending(); p != 2 {
+		t.Errorf("expected queue bounded to 2, received %d", p)
+	}
+
+	// A full blocking queue holds the publisher until there is room
+	bus.Publish("Binance", UnhandledMessageWarning{Message: "1"})
+	published := make(chan struct{})
+	go func() {
+		bus.Publish("Binance", UnhandledMessageWarning{Message: "2"})
+		close(published)
+	}()
+	select {
+	case <-published:
+		t.Fatal("expected publish to block on a full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for publish")
+	}
+	waitFor(t, func() bool { return sub.Pending() == 0 })
+	mtx.Lock()
+	if len(trades) != 2 || trades[0] != 3 || trades[1] != 4 {
+		t.Errorf("expected the newest trades, received %v", trades)
+	}
+	mtx.Unlock()
+
+	// Unsubscribing releases a blocked publisher
+	hold := make(chan struct{})
+	blocked := bus.Subscribe(Filter{}, Handlers{Other: func(interface{}) { <-hold }})
+	bus.Publish("Binance", UnhandledMessageWarning{})
+	waitFor(t, func() bool { return blocked.Pending() == 0 })
+	bus.Publish("Binance", UnhandledMessageWarning{})
+	published = make(chan struct{})
+	go func() {
+		bus.Publish("Binance", UnhandledMessageWarning{})
+		close(published)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	go blocked.Unsubscribe()
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for blocked publish to be released")
+	}
+	close(hold)
+	sub.Unsubscribe()
+}
@@ -0,0 +1,316 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/openware/irix/metrics"
+	"github.com/openware/pkg/log"
+)
+
+var errInvalidBackoff = errors.New("invalid reconnection backoff")
+
+// Backoff controls the delay between reconnection attempts. Each failed
+// attempt multiplies the delay until it reaches Max.
+type Backoff struct {
+	// Initial is the delay before the first reconnection attempt and how
+	// often the connection is checked while healthy
+	Initial time.Duration
+	// Max caps the delay, jitter included
+	Max time.Duration
+	// Multiplier grows the delay after each failed attempt, at least 1
+	Multiplier float64
+	// Jitter randomises each delay by up to this fraction either way so
+	// clients disconnected together do not reconnect together, 0 to 1
+	Jitter float64
+	// MaxAttempts gives up after this many consecutive failed attempts, zero
+	// retries forever
+	MaxAttempts int
+}
+
+// DefaultBackoff returns the backoff used when none is set
+func DefaultBackoff() Backoff {
+	return Backoff{
+		Initial:    connectionMonitorDelay,
+		Max:        time.Minute,
+		Multiplier: 2,
+		Jitter:     0.2,
+	}
+}
+
+// Validate checks the backoff is usable
+func (b *Backoff) Validate() error {
+	switch {
+	case b.Initial <= 0:
+		return fmt.Errorf("%w: initial delay must be positive", errInvalidBackoff)
+	case b.Max < b.Initial:
+		return fmt.Errorf("%w: max delay %s is less than initial delay %s",
+			errInvalidBackoff, b.Max, b.Initial)
+	case b.Multiplier < 1:
+		return fmt.Errorf("%w: multiplier must be at least 1", errInvalidBackoff)
+	case b.Jitter < 0 || b.Jitter > 1:
+		return fmt.Errorf("%w: jitter must be between 0 and 1", errInvalidBackoff)
+	case b.MaxAttempts < 0:
+		return fmt.Errorf("%w: max attempts cannot be negative", errInvalidBackoff)
+	}
+	return nil
+}
+
+// Delay returns the wait after failed consecutive attempts with random jitter
+func (b *Backoff) Delay(failed int) time.Duration {
+	return b.delay(failed, jitter())
+}
+
+// delay returns the wait after failed consecutive attempts, r is a random
+// number in [0, 1) applied as jitter
+func (b *Backoff) delay(failed int, r float64) time.Duration {
+	d := float64(b.Initial)
+	if failed > 1 {
+		d *= math.Pow(b.Multiplier, float64(failed-1))
+	}
+	d += d * b.Jitter * (2*r - 1)
+	if d > float64(b.Max) {
+		return b.Max
+	}
+	return time.Duration(d)
+}
+
+// SetBackoff sets the reconnection backoff, it applies from the next check
+// of the connection
+func (w *Websocket) SetBackoff(b Backoff) error {
+	if err := b.Validate(); err != nil {
+		return err
+	}
+	w.connectionMutex.Lock()
+	w.reconnect = b
+	w.connectionMutex.Unlock()
+	return nil
+}
+
+// backoff returns the reconnection backoff
+func (w *Websocket) backoff() Backoff {
+	w.connectionMutex.RLock()
+	defer w.connectionMutex.RUnlock()
+	if w.reconnect.Initial == 0 {
+		return DefaultBackoff()
+	}
+	return w.reconnect
+}
+
+// ConnectionState is a stage in the lifecycle of a websocket
+type ConnectionState uint8
+
+// Connection states
+const (
+	// StateConnecting is sent before each connection attempt
+	StateConnecting ConnectionState = iota
+	// StateConnected is sent once the connector succeeds
+	StateConnected
+	// StateDisconnected is sent when the connection drops or is shut down
+	StateDisconnected
+	// StateResubscribed is sent once subscriptions are restored after a
+	// reconnection
+	StateResubscribed
+	// StateGaveUp is sent when reconnection stops after MaxAttempts
+	StateGaveUp
+)
+
+// String implements the stringer interface
+func (s ConnectionState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateDisconnected:
+		return "disconnected"
+	case StateResubscribed:
+		return "resubscribed"
+	case StateGaveUp:
+		return "gave up"
+	}
+	return "unknown"
+}
+
+// StateChange describes a lifecycle event
+type StateChange struct {
+	Exchange string
+	State    ConnectionState
+	// Attempt is the reconnection attempt, zero for connections not made by
+	// the connection monitor
+	Attempt int
+	// Shard is the connection the event concerns when subscriptions are
+	// spread across connections, zero for the primary connection
+	Shard int
+	// Err is the cause of a disconnection or of giving up
+	Err  error
+	Time time.Time
+}
+
+// SetStateHandler sets a function called on each lifecycle event. It is
+// called synchronously so must return quickly and must not call Connect or
+// Shutdown.
+func (w *Websocket) SetStateHandler(f func(StateChange)) {
+	w.connectionMutex.Lock()
+	w.stateHandler = f
+	w.connectionMutex.Unlock()
+}
+
+// notify passes a lifecycle event of the websocket to the state handler
+func (w *Websocket) notify(s ConnectionState, err error) {
+	w.notifyConnection(s, 0, int(atomic.LoadInt32(&w.attempt)), err)
+}
+
+// notifyConnection passes a lifecycle event of a shard to the state handler
+func (w *Websocket) notifyConnection(s ConnectionState, shard, attempt int, err error) {
+	w.connectionMutex.RLock()
+	f := w.stateHandler
+	w.connectionMutex.RUnlock()
+	if f == nil {
+		return
+	}
+	f(StateChange{
+		Exchange: w.exchangeName,
+		State:    s,
+		Attempt:  attempt,
+		Shard:    shard,
+		Err:      err,
+		Time:     time.Now(),
+	})
+}
+
+// giveUp stops reconnecting after the final failed attempt, releasing the
+// routines started for it. It is called from the connection monitor.
+func (w *Websocket) giveUp(err error) {
+	log.Errorf(log.WebsocketMgr,
+		"%v websocket: giving up reconnecting after %d attempts: %v",
+		w.exchangeName, atomic.LoadInt32(&w.attempt), err)
+	w.m.Lock()
+	for _, c := range []Connection{w.Conn, w.AuthConn} {
+		if c != nil {
+			_ = c.Shutdown()
+		}
+	}
+	// Nothing else reads errors once the connection monitor stops
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-w.ReadMessageErrors:
+			case <-done:
+				return
+			}
+		}
+	}()
+	close(w.ShutdownC)
+	w.Wg.Wait()
+	close(done)
+	w.ShutdownC = make(chan struct{})
+	w.setConnectingStatus(false)
+	w.m.Unlock()
+	w.notify(StateGaveUp, err)
+}
+
+// ConnectionStats describes the health of a connection
+type ConnectionStats struct {
+	URL           string
+	Authenticated bool
+	// Shard is the connection's index when subscriptions are spread across
+	// connections and Subscriptions the number it carries
+	Shard         int
+	Subscriptions int
+	// RTT is the round trip of the latest answered ping, zero until a pong
+	// is received. Only control frame pings are timed.
+	RTT time.Duration
+	// LastMessage is when a message was last read, zero if none has been
+	LastMessage time.Time
+	// Staleness is the time since the last message or since dialing
+	Staleness time.Duration
+}
+
+// Stats returns the health of each connection
+func (w *Websocket) Stats() []ConnectionStats {
+	var stats []ConnectionStats
+	for _, c := range []Connection{w.Conn, w.AuthConn} {
+		if wc, ok := c.(*WebsocketConnection); ok && wc != nil {
+			s := wc.Stats()
+			s.Authenticated = c == w.AuthConn
+			stats = append(stats, s)
+		}
+	}
+	w.shardMtx.Lock()
+	defer w.shardMtx.Unlock()
+	for _, sh := range w.shards {
+		wc, ok := sh.conn.(*WebsocketConnection)
+		if !ok || wc == nil {
+			continue
+		}
+		if sh.index == 0 {
+			if len(stats) != 0 && !stats[0].Authenticated {
+				stats[0].Subscriptions = len(sh.subs)
+			}
+			continue
+		}
+		s := wc.Stats()
+		s.Shard = sh.index
+		s.Subscriptions = len(sh.subs)
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// Stats returns the health of the connection
+func (w *WebsocketConnection) Stats() ConnectionStats {
+	s := ConnectionStats{
+		URL: w.URL,
+		RTT: time.Duration(atomic.LoadInt64(&w.rtt)),
+	}
+	last := atomic.LoadInt64(&w.lastMessage)
+	if last != 0 {
+		s.LastMessage = time.Unix(0, last)
+	} else {
+		last = atomic.LoadInt64(&w.dialed)
+	}
+	if last != 0 {
+		s.Staleness = time.Since(time.Unix(0, last))
+	}
+	return s
+}
+
+// timePongs records the round trip of control frame pings sent by the ping
+// handler
+func (w *WebsocketConnection) timePongs() {
+	w.Connection.SetPongHandler(func(string) error {
+		sent := atomic.LoadInt64(&w.pingSent)
+		if sent == 0 {
+			return nil
+		}
+		rtt := time.Since(time.Unix(0, sent))
+		atomic.StoreInt64(&w.rtt, int64(rtt))
+		w.recorder().ObserveHistogram(metrics.WebsocketPingRTT, w.labels(), rtt.Seconds())
+		select {
+		case w.Traffic <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+}
+
+// sendPing sends the ping handler's message, noting when control frame pings
+// are sent
+func (w *WebsocketConnection) sendPing(handler *PingHandler) error {
+	if handler.MessageType == websocket.PingMessage {
+		atomic.StoreInt64(&w.pingSent, time.Now().UnixNano())
+	}
+	return w.SendRawMessage(handler.MessageType, handler.Message)
+}
+
+// jitter returns a random number in [0, 1) for backoff jitter
+func jitter() float64 {
+	return rand.Float64() // nolint:gosec // jitter needs no cryptographic randomness
+}
@@ -0,0 +1,216 @@
+package stream
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+	for _, b := range []Backoff{
+		{Max: time.Second, Multiplier: 1},
+		{Initial: time.Second, Max: time.Millisecond, Multiplier: 1},
+		{Initial: time.Second, Max: time.Second, Multiplier: 0.5},
+		{Initial: time.Second, Max: time.Second, Multiplier: 1, Jitter: 2},
+		{Initial: time.Second, Max: time.Second, Multiplier: 1, MaxAttempts: -1},
+	} {
+		if err := new(Websocket).SetBackoff(b); !errors.Is(err, errInvalidBackoff) {
+			t.Errorf("%+v: expected %v, received %v", b, errInvalidBackoff, err)
+		}
+	}
+
+	b := Backoff{Initial: time.Second, Max: 10 * time.Second, Multiplier: 2, Jitter: 0.5}
+	for failed, expected := range []time.Duration{time.Second, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second} {
+		if d := b.delay(failed, 0.5); d != expected {
+			t.Errorf("%d failed: expected %s, received %s", failed, expected, d)
+		}
+	}
+	if d := b.delay(2, 0); d != time.Second {
+		t.Errorf("expected jitter to halve the delay, received %s", d)
+	}
+	if d := b.delay(4, 0.99); d != 10*time.Second {
+		t.Errorf("expected jitter to be capped by max, received %s", d)
+	}
+
+	w := New()
+	if w.backoff() != DefaultBackoff() {
+		t.Errorf("expected default backoff, received %+v", w.backoff())
+	}
+	if err := w.SetBackoff(b); err != nil {
+		t.Fatal(err)
+	}
+	if w.backoff() != b {
+		t.Errorf("expected %+v, received %+v", b, w.backoff())
+	}
+}
+
+// stateRecorder collects lifecycle events
+type stateRecorder struct {
+	changes []StateChange
+	mtx     sync.Mutex
+}
+
+func (r *stateRecorder) handle(s StateChange) {
+	r.mtx.Lock()
+	r.changes = append(r.changes, s)
+	r.mtx.Unlock()
+}
+
+func (r *stateRecorder) states() []ConnectionState {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	states := make([]ConnectionState, len(r.changes))
+	for i := range r.changes {
+		states[i] = r.changes[i].State
+	}
+	return states
+}
+
+func TestStateHandler(t *testing.T) {
+	t.Parallel()
+	var r stateRecorder
+	s := *defaultSetup
+	s.StateHandler = r.handle
+	ws := New()
+	if err := ws.Setup(&s); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	ws.subscriptions = []ChannelSubscription{{Channel: "trades"}}
+	if err := ws.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	ws.subscriptions = []ChannelSubscription{{Channel: "trades"}}
+	if err := ws.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []ConnectionState{
+		StateConnecting, StateConnected, StateDisconnected,
+		StateConnecting, StateConnected, StateResubscribed,
+	}
+	states := r.states()
+	if len(states) != len(expected) {
+		t.Fatalf("expected %v, received %v", expected, states)
+	}
+	for i := range expected {
+		if states[i] != expected[i] {
+			t.Errorf("expected %v, received %v", expected, states)
+			break
+		}
+	}
+	if r.changes[0].Exchange != s.ExchangeName || r.changes[0].Time.IsZero() {
+		t.Errorf("unexpected state change %+v", r.changes[0])
+	}
+}
+
+func TestReconnectGivesUp(t *testing.T) {
+	t.Parallel()
+	var r stateRecorder
+	gaveUp := make(chan struct{})
+	s := *defaultSetup
+	s.Reconnect = Backoff{Initial: 10 * time.Millisecond, Max: 20 * time.Millisecond, Multiplier: 2, MaxAttempts: 3}
+	s.StateHandler = func(c StateChange) {
+		r.handle(c)
+		if c.State == StateGaveUp {
+			close(gaveUp)
+		}
+	}
+	errOutage := errors.New("exchange outage")
+	var mtx sync.Mutex
+	var connects int
+	s.Connector = func() error {
+		mtx.Lock()
+		defer mtx.Unlock()
+		connects++
+		if connects > 1 {
+			return errOutage
+		}
+		return nil
+	}
+	ws := New()
+	if err := ws.Setup(&s); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	ws.ReadMessageErrors <- &websocket.CloseError{Code: websocket.CloseAbnormalClosure}
+
+	select {
+	case <-gaveUp:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting to give up, states %v", r.states())
+	}
+	if ws.IsConnectionMonitorRunning() || ws.IsConnected() || ws.IsConnecting() {
+		t.Error("expected reconnection to stop")
+	}
+	mtx.Lock()
+	if connects != 4 {
+		t.Errorf("expected 3 reconnection attempts, received %d", connects-1)
+	}
+	mtx.Unlock()
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	last := r.changes[len(r.changes)-1]
+	if !errors.Is(last.Err, errOutage) || last.Attempt != 3 {
+		t.Errorf("unexpected give up %+v", last)
+	}
+	if r.changes[2].State != StateDisconnected || r.changes[2].Err == nil {
+		t.Errorf("expected disconnection with cause, received %+v", r.changes[2])
+	}
+	var attempts []int
+	for _, c := range r.changes {
+		if c.State == StateConnecting {
+			attempts = append(attempts, c.Attempt)
+		}
+	}
+	if len(attempts) != 4 || attempts[0] != 0 || attempts[1] != 1 || attempts[3] != 3 {
+		t.Errorf("unexpected connection attempts %v", attempts)
+	}
+}
+
+func TestConnectionStats(t *testing.T) {
+	t.Parallel()
+	wc := &WebsocketConnection{
+		ExchangeName:     "stats",
+		URL:              newEchoServer(t).URL,
+		ResponseMaxLimit: time.Second,
+	}
+	if s := wc.Stats(); s.Staleness != 0 || !s.LastMessage.IsZero() {
+		t.Errorf("expected empty stats before dialing, received %+v", s)
+	}
+	if err := wc.Dial(&dialer, http.Header{}); err != nil {
+		t.Fatal(err)
+	}
+	defer wc.Shutdown()
+	if err := wc.sendPing(&PingHandler{MessageType: websocket.PingMessage}); err != nil {
+		t.Fatal(err)
+	}
+	if err := wc.SendJSONMessage(map[string]string{"op": "echo"}); err != nil {
+		t.Fatal(err)
+	}
+	// The pong arrives ahead of the echo and is handled while reading
+	if resp := wc.ReadMessage(); len(resp.Raw) == 0 {
+		t.Fatal("expected echoed message")
+	}
+	s := wc.Stats()
+	if s.RTT <= 0 {
+		t.Errorf("expected ping round trip, received %s", s.RTT)
+	}
+	if s.LastMessage.IsZero() || s.Staleness <= 0 || s.Staleness > time.Minute {
+		t.Errorf("unexpected staleness %+v", s)
+	}
+
+	w := &Websocket{Conn: wc}
+	if stats := w.Stats(); len(stats) != 1 || stats[0].URL != wc.URL || stats[0].Authenticated {
+		t.Errorf("unexpected websocket stats %+v", stats)
+	}
+}
@@ -0,0 +1,146 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Priority orders outbound messages waiting on a connection's rate limit,
+// higher priorities are sent first
+type Priority uint8
+
+// Message priorities
+const (
+	// PrioritySubscription is for subscription traffic which can wait
+	PrioritySubscription Priority = iota
+	// PriorityDefault is used for messages sent without a priority
+	PriorityDefault
+	// PriorityOrder is for order placement, amendment and cancellation
+	PriorityOrder
+	priorityCount
+)
+
+var errConnectionShutdown = errors.New("websocket connection shutting down")
+
+// PrioritisedMessage is a message queued at a priority other than the default
+type PrioritisedMessage struct {
+	Priority Priority
+	Message  interface{}
+}
+
+// WithPriority wraps a message so SendJSONMessage and
+// SendMessageReturnResponse queue it at p
+func WithPriority(p Priority, message interface{}) PrioritisedMessage {
+	return PrioritisedMessage{Priority: p, Message: message}
+}
+
+// unwrapPriority returns the priority and underlying message
+func unwrapPriority(data interface{}) (Priority, interface{}) {
+	if m, ok := data.(PrioritisedMessage); ok {
+		if m.Priority >= priorityCount {
+			m.Priority = PriorityOrder
+		}
+		return m.Priority, m.Message
+	}
+	return PriorityDefault, data
+}
+
+// sendQueue releases waiting senders one token at a time, highest priority
+// first and in arrival order within a priority
+type sendQueue struct {
+	limiter *rate.Limiter
+	waiting [priorityCount][]chan struct{}
+	running bool
+	mtx     sync.Mutex
+}
+
+// newSendQueue returns a queue allowing perSecond messages with bursts of up
+// to burst
+func newSendQueue(perSecond float64, burst int) *sendQueue {
+	if burst < 1 {
+		burst = 1
+	}
+	return &sendQueue{limiter: rate.NewLimiter(rate.Limit(perSecond), burst)}
+}
+
+// wait blocks until the caller may send a message at priority p
+func (q *sendQueue) wait(p Priority, shutdown <-chan struct{}) error {
+	ticket := make(chan struct{})
+	q.mtx.Lock()
+	q.waiting[p] = append(q.waiting[p], ticket)
+	if !q.running {
+		q.running = true
+		go q.dispatch()
+	}
+	q.mtx.Unlock()
+	select {
+	case <-ticket:
+		return nil
+	case <-shutdown:
+		return errConnectionShutdown
+	}
+}
+
+// dispatch hands out a token to the most urgent waiter each time one becomes
+// available and exits once nobody is waiting. Taking the token before
+// choosing the waiter lets messages queued during the wait jump ahead.
+func (q *sendQueue) dispatch() {
+	for {
+		q.mtx.Lock()
+		if q.empty() {
+			q.running = false
+			q.mtx.Unlock()
+			return
+		}
+		q.mtx.Unlock()
+		_ = q.limiter.Wait(context.Background())
+		q.mtx.Lock()
+		ticket := q.pop()
+		q.mtx.Unlock()
+		close(ticket)
+	}
+}
+
+// empty reports whether nobody is waiting, the caller must hold the lock
+func (q *sendQueue) empty() bool {
+	for p := range q.waiting {
+		if len(q.waiting[p]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// pop removes the most urgent waiter, the caller must hold the lock and
+// ensure the queue is not empty. Only the dispatcher pops.
+func (q *sendQueue) pop() chan struct{} {
+	p := priorityCount - 1
+	for len(q.waiting[p]) == 0 {
+		p--
+	}
+	ticket := q.waiting[p][0]
+	q.waiting[p][0] = nil
+	q.waiting[p] = q.waiting[p][1:]
+	return ticket
+}
+
+// queue waits for the connection's rate limit, it returns straight away when
+// the connection has none
+func (w *WebsocketConnection) queue(p Priority) error {
+	w.queueOnce.Do(func() {
+		switch {
+		case w.MessageRate > 0:
+			w.sendQueue = newSendQueue(w.MessageRate, w.MessageBurst)
+		case w.RateLimit > 0:
+			w.sendQueue = newSendQueue(float64(time.Second)/float64(time.Duration(w.RateLimit)*time.Millisecond), 1)
+		}
+	})
+	if w.sendQueue == nil {
+		return nil
+	}
+	return w.sendQueue.wait(p, w.ShutdownC)
+}
@@ -0,0 +1,144 @@
+package stream
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSendQueuePriority(t *testing.T) {
+	t.Parallel()
+	q := newSendQueue(5, 1)
+	// Take the only token so the next waiters queue up
+	if err := q.wait(PriorityDefault, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var mtx sync.Mutex
+	var order []Priority
+	var wg sync.WaitGroup
+	send := func(p Priority) {
+		defer wg.Done()
+		if err := q.wait(p, nil); err != nil {
+			t.Error(err)
+			return
+		}
+		mtx.Lock()
+		order = append(order, p)
+		mtx.Unlock()
+	}
+	waiting := func(p Priority, n int) {
+		for i := 0; i < 100; i++ {
+			q.mtx.Lock()
+			l := len(q.waiting[p])
+			q.mtx.Unlock()
+			if l >= n {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Fatalf("waiters were not queued at %d", p)
+	}
+	wg.Add(3)
+	go send(PrioritySubscription)
+	waiting(PrioritySubscription, 1)
+	go send(PrioritySubscription)
+	waiting(PrioritySubscription, 2)
+	go send(PriorityOrder)
+	wg.Wait()
+
+	expected := []Priority{PriorityOrder, PrioritySubscription, PrioritySubscription}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("expected %v, received %v", expected, order)
+		}
+	}
+}
+
+func TestSendQueueShutdown(t *testing.T) {
+	t.Parallel()
+	q := newSendQueue(0.1, 1)
+	if err := q.wait(PriorityDefault, nil); err != nil {
+		t.Fatal(err)
+	}
+	shutdown := make(chan struct{})
+	close(shutdown)
+	if err := q.wait(PriorityOrder, shutdown); !errors.Is(err, errConnectionShutdown) {
+		t.Errorf("expected %v, received %v", errConnectionShutdown, err)
+	}
+}
+
+func TestUnwrapPriority(t *testing.T) {
+	t.Parallel()
+	if p, m := unwrapPriority("msg"); p != PriorityDefault || m != "msg" {
+		t.Errorf("unexpected %v %v", p, m)
+	}
+	if p, m := unwrapPriority(WithPriority(PriorityOrder, "msg")); p != PriorityOrder || m != "msg" {
+		t.Errorf("unexpected %v %v", p, m)
+	}
+	if p, _ := unwrapPriority(WithPriority(200, "msg")); p != PriorityOrder {
+		t.Errorf("expected out of range priority to be clamped, received %v", p)
+	}
+}
+
+func TestSendJSONMessageRate(t *testing.T) {
+	t.Parallel()
+	wc := &WebsocketConnection{
+		ExchangeName:     "rate",
+		URL:              newEchoServer(t).URL,
+		MessageRate:      20,
+		MessageBurst:     2,
+		ResponseMaxLimit: time.Second,
+	}
+	if err := wc.Dial(&dialer, http.Header{}); err != nil {
+		t.Fatal(err)
+	}
+	defer wc.Shutdown()
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		if err := wc.SendJSONMessage(WithPriority(PrioritySubscription, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Two messages go out in the burst and the remaining four 50ms apart
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Errorf("expected sends to be paced, took %s", elapsed)
+	}
+}
+
+func TestBatchSubscriptions(t *testing.T) {
+	t.Parallel()
+	subs := make([]ChannelSubscription, 5)
+	for i := range subs {
+		subs[i].Channel = string(rune('a' + i))
+	}
+	if b := BatchSubscriptions(subs, 0); len(b) != 1 || len(b[0]) != 5 {
+		t.Errorf("expected one batch, received %v", b)
+	}
+	b := BatchSubscriptions(subs, 2)
+	if len(b) != 3 || len(b[0]) != 2 || len(b[2]) != 1 || b[2][0].Channel != "e" {
+		t.Errorf("unexpected batches %v", b)
+	}
+	// Appending to a batch must not overwrite the next one
+	_ = append(b[0], ChannelSubscription{Channel: "z"})
+	if b[1][0].Channel != "c" {
+		t.Errorf("batch overwritten, received %v", b[1])
+	}
+
+	var calls [][]ChannelSubscription
+	w := Websocket{
+		maxSubscriptionsPerMessage: 2,
+		Subscriber: func(c []ChannelSubscription) error {
+			calls = append(calls, c)
+			return nil
+		},
+	}
+	if err := w.SubscribeToChannels(subs); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 3 {
+		t.Errorf("expected 3 subscriber calls, received %d", len(calls))
+	}
+}
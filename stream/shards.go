@@ -0,0 +1,336 @@
+package stream
+
+import (
+	"errors"
+	"time"
+
+	"github.com/openware/irix/metrics"
+	"github.com/openware/pkg/log"
+)
+
+var errShardingUnsupported = errors.New("subscription sharding requires the primary connection to be a websocket connection")
+
+// shard is a connection carrying part of the subscriptions when they are
+// spread across connections. The primary connection is shard zero and
+// reconnects with the websocket, additional shards reconnect on their own.
+type shard struct {
+	index int
+	conn  Connection
+	subs  []ChannelSubscription
+	// errs receives read errors of additional shards, the primary reports to
+	// ReadMessageErrors
+	errs chan error
+	stop chan struct{}
+}
+
+// sharded reports whether subscriptions are spread across connections
+func (w *Websocket) sharded() bool {
+	return w.maxSubscriptionsPerConnection > 0
+}
+
+// primaryShard returns shard zero, tracking the current primary connection.
+// The caller must hold shardMtx.
+func (w *Websocket) primaryShard() *shard {
+	if len(w.shards) == 0 {
+		w.shards = []*shard{{}}
+	}
+	w.shards[0].conn = w.Conn
+	return w.shards[0]
+}
+
+// subscribeShards subscribes channels on the first shards with room, opening
+// new connections once every shard is full
+func (w *Websocket) subscribeShards(channels []ChannelSubscription) error {
+	w.shardMtx.Lock()
+	defer w.shardMtx.Unlock()
+	w.primaryShard()
+	for len(channels) != 0 {
+		var s *shard
+		for _, candidate := range w.shards {
+			if len(candidate.subs) < w.maxSubscriptionsPerConnection {
+				s = candidate
+				break
+			}
+		}
+		if s == nil {
+			var err error
+			if s, err = w.newShard(); err != nil {
+				return err
+			}
+		}
+		n := w.maxSubscriptionsPerConnection - len(s.subs)
+		if n > len(channels) {
+			n = len(channels)
+		}
+		if err := w.subscribeOn(s, channels[:n:n]); err != nil {
+			return err
+		}
+		channels = channels[n:]
+	}
+	return nil
+}
+
+// unsubscribeShards unsubscribes channels from the shards carrying them,
+// closing additional shards left without subscriptions
+func (w *Websocket) unsubscribeShards(channels []ChannelSubscription) error {
+	w.shardMtx.Lock()
+	defer w.shardMtx.Unlock()
+	primary := w.primaryShard()
+	held := make(map[*shard][]ChannelSubscription)
+channels:
+	for x := range channels {
+		for _, s := range w.shards {
+			for y := range s.subs {
+				if channels[x].Equal(&s.subs[y]) {
+					held[s] = append(held[s], channels[x])
+					continue channels
+				}
+			}
+		}
+		held[primary] = append(held[primary], channels[x])
+	}
+	for _, s := range append([]*shard(nil), w.shards...) {
+		if len(held[s]) == 0 {
+			continue
+		}
+		for _, batch := range BatchSubscriptions(held[s], w.maxSubscriptionsPerMessage) {
+			if err := w.ShardUnsubscriber(s.conn, batch); err != nil {
+				return err
+			}
+			s.subs = removeSubscriptions(s.subs, batch)
+		}
+		if s != primary && len(s.subs) == 0 {
+			w.closeShard(s)
+		}
+	}
+	return nil
+}
+
+// subscribeOn passes channels to the shard subscriber in batches of the
+// exchange's per message limit. The caller must hold shardMtx.
+func (w *Websocket) subscribeOn(s *shard, channels []ChannelSubscription) error {
+	for _, batch := range BatchSubscriptions(channels, w.maxSubscriptionsPerMessage) {
+		if err := w.ShardSubscriber(s.conn, batch); err != nil {
+			return err
+		}
+		s.subs = append(s.subs, batch...)
+	}
+	return nil
+}
+
+// resubscribeShard restores a shard's subscriptions after it reconnects
+func (w *Websocket) resubscribeShard(s *shard) error {
+	w.subscriptionMutex.Lock()
+	defer w.subscriptionMutex.Unlock()
+	w.shardMtx.Lock()
+	defer w.shardMtx.Unlock()
+	subs := s.subs
+	s.subs = nil
+	// The subscriber records them again as they succeed
+	w.RemoveSuccessfulUnsubscriptions(subs...)
+	if err := w.subscribeOn(s, subs); err != nil {
+		s.subs = subs
+		return err
+	}
+	return nil
+}
+
+// resubscribePrimary restores the primary connection's subscriptions after
+// the websocket reconnects, additional shards keep theirs
+func (w *Websocket) resubscribePrimary() (bool, error) {
+	w.shardMtx.Lock()
+	if len(w.shards) == 0 || len(w.shards[0].subs) == 0 {
+		w.shardMtx.Unlock()
+		return false, nil
+	}
+	primary := w.primaryShard()
+	w.shardMtx.Unlock()
+	return true, w.resubscribeShard(primary)
+}
+
+// newShard opens an additional connection configured like the primary. The
+// caller must hold shardMtx.
+func (w *Websocket) newShard() (*shard, error) {
+	primary, ok := w.Conn.(*WebsocketConnection)
+	if !ok || primary == nil {
+		return nil, errShardingUnsupported
+	}
+	w.nextShard++
+	s := &shard{
+		index: w.nextShard,
+		errs:  make(chan error, 1),
+		stop:  make(chan struct{}),
+	}
+	conn := &WebsocketConnection{
+		ExchangeName:      w.exchangeName,
+		URL:               primary.URL,
+		ProxyURL:          primary.ProxyURL,
+		Verbose:           w.verbose,
+		ResponseMaxLimit:  primary.ResponseMaxLimit,
+		Traffic:           w.TrafficAlert,
+		readMessageErrors: s.errs,
+		ShutdownC:         w.ShutdownC,
+		Wg:                w.Wg,
+		Match:             w.Match,
+		RateLimit:         primary.RateLimit,
+		MessageRate:       primary.MessageRate,
+		MessageBurst:      primary.MessageBurst,
+	}
+	w.metricsMtx.Lock()
+	conn.setMetrics(w.metrics)
+	w.metricsMtx.Unlock()
+	s.conn = conn
+	if err := w.ShardConnector(conn); err != nil {
+		return nil, err
+	}
+	w.shards = append(w.shards, s)
+	w.Wg.Add(1)
+	go w.monitorShard(s, conn.ShutdownC)
+	return s, nil
+}
+
+// closeShard stops an additional shard and closes its connection. The caller
+// must hold shardMtx.
+func (w *Websocket) closeShard(s *shard) {
+	i := 0
+	for i < len(w.shards) && w.shards[i] != s {
+		i++
+	}
+	if i == len(w.shards) {
+		return
+	}
+	w.shards = append(w.shards[:i], w.shards[i+1:]...)
+	close(s.stop)
+	if err := s.conn.Shutdown(); err != nil {
+		log.Errorf(log.WebsocketMgr, "%v websocket: shard %d shutdown error: %v",
+			w.exchangeName, s.index, err)
+	}
+}
+
+// closeShards closes every additional shard and forgets the primary's
+// subscriptions, used when the websocket shuts down
+func (w *Websocket) closeShards() {
+	w.shardMtx.Lock()
+	defer w.shardMtx.Unlock()
+	for len(w.shards) > 1 {
+		w.closeShard(w.shards[len(w.shards)-1])
+	}
+	w.shards = nil
+}
+
+// monitorShard reconnects an additional shard whenever it drops
+func (w *Websocket) monitorShard(s *shard, shutdown <-chan struct{}) {
+	defer w.Wg.Done()
+	for {
+		select {
+		case <-shutdown:
+			return
+		case <-s.stop:
+			return
+		case err := <-s.errs:
+			if !isDisconnectionError(err) {
+				select {
+				case w.DataHandler <- err:
+				case <-shutdown:
+					return
+				}
+				continue
+			}
+			w.recorder().AddCounter(metrics.WebsocketDisconnects,
+				metrics.Labels{Exchange: w.exchangeName}, 1)
+			log.Warnf(log.WebsocketMgr,
+				"%v websocket: shard %d has been disconnected. Reason: %v",
+				w.exchangeName, s.index, err)
+			w.notifyConnection(StateDisconnected, s.index, 0, err)
+			if !w.reconnectShard(s, shutdown) {
+				return
+			}
+		}
+	}
+}
+
+// reconnectShard redials a shard under the websocket's backoff and restores
+// its subscriptions. It returns false once the shard is stopped or gives up.
+func (w *Websocket) reconnectShard(s *shard, shutdown <-chan struct{}) bool {
+	b := w.backoff()
+	for failed := 0; ; {
+		delay := b.Initial
+		if failed != 0 {
+			delay = b.delay(failed, jitter())
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-shutdown:
+			timer.Stop()
+			return false
+		case <-s.stop:
+			timer.Stop()
+			return false
+		}
+		w.recorder().AddCounter(metrics.WebsocketReconnects,
+			metrics.Labels{Exchange: w.exchangeName}, 1)
+		w.notifyConnection(StateConnecting, s.index, failed+1, nil)
+		_ = s.conn.Shutdown()
+		err := w.ShardConnector(s.conn)
+		if err == nil {
+			w.notifyConnection(StateConnected, s.index, failed+1, nil)
+			if err = w.resubscribeShard(s); err == nil {
+				w.notifyConnection(StateResubscribed, s.index, failed+1, nil)
+				return true
+			}
+		}
+		failed++
+		if b.MaxAttempts > 0 && failed >= b.MaxAttempts {
+			log.Errorf(log.WebsocketMgr,
+				"%v websocket: giving up reconnecting shard %d after %d attempts: %v",
+				w.exchangeName, s.index, failed, err)
+			w.dropShard(s)
+			w.notifyConnection(StateGaveUp, s.index, failed, err)
+			return false
+		}
+		log.Errorf(log.WebsocketMgr,
+			"%v websocket: shard %d reconnection attempt %d failed: %v",
+			w.exchangeName, s.index, failed, err)
+	}
+}
+
+// dropShard closes a shard which gave up reconnecting, forgetting its
+// subscriptions so FlushChannels can place them on another connection
+func (w *Websocket) dropShard(s *shard) {
+	w.subscriptionMutex.Lock()
+	defer w.subscriptionMutex.Unlock()
+	w.shardMtx.Lock()
+	defer w.shardMtx.Unlock()
+	w.RemoveSuccessfulUnsubscriptions(s.subs...)
+	s.subs = nil
+	w.closeShard(s)
+}
+
+// releaseShardSubscriptions removes channels from the shards carrying them so
+// they can be placed again, empty shards stay open for reuse
+func (w *Websocket) releaseShardSubscriptions(channels []ChannelSubscription) {
+	w.shardMtx.Lock()
+	defer w.shardMtx.Unlock()
+	for _, s := range w.shards {
+		s.subs = removeSubscriptions(s.subs, channels)
+	}
+}
+
+// removeSubscriptions returns subs without channels
+func removeSubscriptions(subs, channels []ChannelSubscription) []ChannelSubscription {
+	kept := subs[:0]
+subs:
+	for x := range subs {
+		for y := range channels {
+			if subs[x].Equal(&channels[y]) {
+				continue subs
+			}
+		}
+		kept = append(kept, subs[x])
+	}
+	for x := len(kept); x < len(subs); x++ {
+		subs[x] = ChannelSubscription{}
+	}
+	return kept
+}
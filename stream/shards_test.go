@@ -0,0 +1,175 @@
+package stream
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openware/irix/protocol"
+	"github.com/openware/irix/stream/streamtest"
+)
+
+func TestShardSetup(t *testing.T) {
+	t.Parallel()
+	s := *defaultSetup
+	s.MaxSubscriptionsPerConnection = -1
+	if err := New().Setup(&s); err == nil {
+		t.Error("expected error for negative limit")
+	}
+	s.MaxSubscriptionsPerConnection = 10
+	if err := New().Setup(&s); err == nil {
+		t.Error("expected error for unset shard functions")
+	}
+	s.ShardConnector = func(Connection) error { return nil }
+	s.ShardSubscriber = func(Connection, []ChannelSubscription) error { return nil }
+	s.ShardUnsubscriber = s.ShardSubscriber
+	s.Features = &protocol.Features{FullPayloadSubscribe: true}
+	if err := New().Setup(&s); err == nil {
+		t.Error("expected error for full payload subscriptions")
+	}
+	s.Features = defaultSetup.Features
+	if err := New().Setup(&s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readInto passes messages read from c to the data handler
+func readInto(ws *Websocket, c Connection) {
+	ws.Wg.Add(1)
+	go func() {
+		defer ws.Wg.Done()
+		for {
+			resp := c.ReadMessage()
+			if resp.Raw == nil {
+				return
+			}
+			ws.DataHandler <- resp.Raw
+		}
+	}()
+}
+
+func TestShardedSubscriptions(t *testing.T) {
+	srv := streamtest.NewServer()
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	ws := New()
+	setup := *defaultSetup
+	setup.MaxSubscriptionsPerConnection = 2
+	setup.MaxSubscriptionsPerMessage = 1
+	setup.Reconnect = Backoff{Initial: 50 * time.Millisecond, Max: time.Second, Multiplier: 2}
+	setup.Connector = func() error {
+		if err := ws.Conn.Dial(&dialer, http.Header{}); err != nil {
+			return err
+		}
+		readInto(ws, ws.Conn)
+		return nil
+	}
+	setup.ShardConnector = func(c Connection) error {
+		if err := c.Dial(&dialer, http.Header{}); err != nil {
+			return err
+		}
+		readInto(ws, c)
+		return nil
+	}
+	send := func(event string) func(Connection, []ChannelSubscription) error {
+		return func(c Connection, subs []ChannelSubscription) error {
+			var channels []string
+			for x := range subs {
+				channels = append(channels, subs[x].Channel)
+			}
+			err := c.SendJSONMessage(map[string]string{
+				"event":   event,
+				"channel": strings.Join(channels, ","),
+			})
+			if err != nil {
+				return err
+			}
+			if event == "subscribe" {
+				ws.AddSuccessfulSubscriptions(subs...)
+			} else {
+				ws.RemoveSuccessfulUnsubscriptions(subs...)
+			}
+			return nil
+		}
+	}
+	setup.ShardSubscriber = send("subscribe")
+	setup.ShardUnsubscriber = send("unsubscribe")
+	if err := ws.Setup(&setup); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.SetWebsocketURL(srv.URL, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.SetupNewConnection(ConnectionSetup{ResponseMaxLimit: time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	subs := []ChannelSubscription{
+		{Channel: "a"}, {Channel: "b"}, {Channel: "c"}, {Channel: "d"}, {Channel: "e"},
+	}
+	if err := ws.SubscribeToChannels(subs); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.WaitForConnections(3, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	stats := ws.Stats()
+	if len(stats) != 3 {
+		t.Fatalf("expected 3 connections, received %+v", stats)
+	}
+	for i, expected := range []int{2, 2, 1} {
+		if stats[i].Subscriptions != expected || stats[i].Shard != i {
+			t.Errorf("shard %d: expected %d subscriptions, received %+v", i, expected, stats[i])
+		}
+	}
+
+	// Every connection feeds the same data handler
+	if err := srv.Send([]byte(`{"event":"update"}`)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case <-ws.ToRoutine:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for update %d", i)
+		}
+	}
+
+	// Emptying a shard closes its connection
+	if err := ws.UnsubscribeChannels(subs[4:]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Expect(streamtest.JSONEqual(`{"event":"unsubscribe","channel":"e"}`), 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return srv.Connections() == 2 })
+	if n := len(ws.Stats()); n != 2 {
+		t.Errorf("expected 2 connections, received %d", n)
+	}
+
+	// Each connection reconnects and restores its own subscriptions
+	srv.Disconnect()
+	for _, channel := range []string{"a", "b", "c", "d"} {
+		match := streamtest.JSONEqual(`{"event":"subscribe","channel":"` + channel + `"}`)
+		if err := srv.ExpectCount(match, 2, 10*time.Second); err != nil {
+			t.Fatalf("%s: %v", channel, err)
+		}
+	}
+	if n := len(ws.GetSubscriptions()); n != 4 {
+		t.Errorf("expected 4 subscriptions, received %d", n)
+	}
+
+	if err := ws.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(ws.Stats()); n != 1 {
+		t.Errorf("expected only the primary connection after shutdown, received %d", n)
+	}
+}
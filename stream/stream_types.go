@@ -37,15 +37,27 @@ type ChannelSubscription struct {
 	Currency currency.Pair
 	Asset    asset.Item
 	Params   map[string]interface{}
+	// State is set once the subscription is listed by the websocket
+	State SubscriptionState
+	// Err is why the subscription last failed
+	Err error
+	// failures counts consecutive failed attempts
+	failures int
 }
 
 // ConnectionSetup defines variables for an individual stream connection
 type ConnectionSetup struct {
 	ResponseCheckTimeout time.Duration
 	ResponseMaxLimit     time.Duration
-	RateLimit            int64
-	URL                  string
-	Authenticated        bool
+	// RateLimit is the gap in milliseconds between outbound messages, used
+	// when MessageRate is unset
+	RateLimit int64
+	// MessageRate caps outbound messages per second through a token bucket,
+	// MessageBurst is its size
+	MessageRate   float64
+	MessageBurst  int
+	URL           string
+	Authenticated bool
 }
 
 // PingHandler container for ping handler settings
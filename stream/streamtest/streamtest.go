@@ -0,0 +1,412 @@
+// Package streamtest provides a local websocket server which stands in for an
+// exchange in stream and exchange websocket tests. Frames are scripted by the
+// test, inbound payloads are recorded for assertions and connection faults
+// such as abrupt disconnects, slow reads and malformed frames can be
+// triggered on demand.
+package streamtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// MalformedFrame is a truncated JSON payload used to exercise message handler
+// error paths
+var MalformedFrame = []byte(`{"event":"update","data":[`)
+
+var (
+	// ErrTimeout is returned when an expected message or connection does not
+	// arrive in time
+	ErrTimeout = errors.New("timed out waiting on test server")
+
+	errServerStarted    = errors.New("test server already started")
+	errServerNotStarted = errors.New("test server not started")
+)
+
+// Message is a frame received from or sent to a client
+type Message struct {
+	Type int
+	Data []byte
+}
+
+// Matcher reports whether a received message is the one being waited on
+type Matcher func(Message) bool
+
+// responder replies to any received message which matches
+type responder struct {
+	match Matcher
+	reply func(Message) []Message
+}
+
+// Server is a local websocket server. Configure it with OnConnect and Respond
+// before calling Start, then point a websocket at URL.
+type Server struct {
+	// URL is the ws:// address of the server, set by Start
+	URL string
+	// ReadDelay is slept before each inbound frame is read to simulate a slow
+	// consuming exchange
+	ReadDelay time.Duration
+	// WriteDelay is slept before each outbound frame is written to simulate a
+	// slow producing exchange
+	WriteDelay time.Duration
+	// Echo writes every inbound frame back to the sending client
+	Echo bool
+
+	server     *httptest.Server
+	upgrader   websocket.Upgrader
+	onConnect  []Message
+	responders []responder
+
+	mtx      sync.Mutex
+	conns    map[*conn]struct{}
+	received []Message
+	notify   chan struct{}
+	total    int
+}
+
+// conn serialises writes to a single client connection
+type conn struct {
+	ws  *websocket.Conn
+	mtx sync.Mutex
+}
+
+// NewServer returns an unstarted test server
+func NewServer() *Server {
+	return &Server{
+		conns:  make(map[*conn]struct{}),
+		notify: make(chan struct{}),
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+	}
+}
+
+// OnConnect scripts text frames which are sent to every client as soon as it
+// connects, in order
+func (s *Server) OnConnect(frames ...[]byte) {
+	for x := range frames {
+		s.onConnect = append(s.onConnect, Message{Type: websocket.TextMessage, Data: frames[x]})
+	}
+}
+
+// Respond scripts text frames which are sent back to a client whenever it
+// sends a matching message
+func (s *Server) Respond(match Matcher, frames ...[]byte) {
+	reply := make([]Message, len(frames))
+	for x := range frames {
+		reply[x] = Message{Type: websocket.TextMessage, Data: frames[x]}
+	}
+	s.RespondFunc(match, func(Message) []Message { return reply })
+}
+
+// RespondFunc builds the reply to a matching message, used when the reply
+// echoes a request ID or other part of the request
+func (s *Server) RespondFunc(match Matcher, reply func(Message) []Message) {
+	s.responders = append(s.responders, responder{match: match, reply: reply})
+}
+
+// Start starts the server and sets URL
+func (s *Server) Start() error {
+	if s.server != nil {
+		return errServerStarted
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.URL = "ws" + strings.TrimPrefix(s.server.URL, "http")
+	return nil
+}
+
+// Close drops every client and stops the server
+func (s *Server) Close() {
+	if s.server == nil {
+		return
+	}
+	s.Disconnect()
+	s.server.Close()
+}
+
+// handle upgrades a client connection, sends the scripted connect frames and
+// records inbound frames until the connection drops
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := &conn{ws: ws}
+	s.mtx.Lock()
+	s.conns[c] = struct{}{}
+	s.total++
+	s.signal()
+	s.mtx.Unlock()
+	defer func() {
+		s.mtx.Lock()
+		delete(s.conns, c)
+		s.signal()
+		s.mtx.Unlock()
+		_ = ws.Close()
+	}()
+
+	for x := range s.onConnect {
+		if s.write(c, s.onConnect[x]) != nil {
+			return
+		}
+	}
+
+	for {
+		time.Sleep(s.ReadDelay)
+		mType, data, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		m := Message{Type: mType, Data: data}
+		s.mtx.Lock()
+		s.received = append(s.received, m)
+		s.signal()
+		s.mtx.Unlock()
+
+		if s.Echo {
+			if s.write(c, m) != nil {
+				return
+			}
+		}
+		for x := range s.responders {
+			if !s.responders[x].match(m) {
+				continue
+			}
+			reply := s.responders[x].reply(m)
+			for y := range reply {
+				if s.write(c, reply[y]) != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// signal wakes anything waiting on a change in server state, the caller must
+// hold the lock
+func (s *Server) signal() {
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// write sends a frame to a single client
+func (s *Server) write(c *conn, m Message) error {
+	time.Sleep(s.WriteDelay)
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.ws.WriteMessage(m.Type, m.Data)
+}
+
+// broadcast sends a frame to every connected client
+func (s *Server) broadcast(m Message) error {
+	if s.server == nil {
+		return errServerNotStarted
+	}
+	s.mtx.Lock()
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mtx.Unlock()
+	var errs []string
+	for x := range conns {
+		if err := s.write(conns[x], m); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("test server write: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// Send sends a text frame to every connected client
+func (s *Server) Send(data []byte) error {
+	return s.broadcast(Message{Type: websocket.TextMessage, Data: data})
+}
+
+// SendJSON sends a JSON encoded text frame to every connected client
+func (s *Server) SendJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.Send(data)
+}
+
+// SendRaw sends a frame of any message type to every connected client, used
+// for binary and compressed payloads
+func (s *Server) SendRaw(messageType int, data []byte) error {
+	return s.broadcast(Message{Type: messageType, Data: data})
+}
+
+// SendMalformed sends a truncated JSON frame to every connected client
+func (s *Server) SendMalformed() error {
+	return s.Send(MalformedFrame)
+}
+
+// Flood sends the same text frame n times as fast as possible to simulate a
+// burst which a slow consumer cannot keep up with
+func (s *Server) Flood(data []byte, n int) error {
+	for i := 0; i < n; i++ {
+		if err := s.Send(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Disconnect drops every client without a close frame, as a network failure
+// would
+func (s *Server) Disconnect() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for c := range s.conns {
+		_ = c.ws.UnderlyingConn().Close()
+	}
+}
+
+// CloseWithCode sends a close frame with the supplied code and reason to
+// every client then drops the connections
+func (s *Server) CloseWithCode(code int, reason string) error {
+	err := s.SendRaw(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason))
+	s.Disconnect()
+	return err
+}
+
+// Connections returns the number of connected clients
+func (s *Server) Connections() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return len(s.conns)
+}
+
+// TotalConnections returns the number of connections accepted since start,
+// used to assert on reconnection
+func (s *Server) TotalConnections() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.total
+}
+
+// WaitForConnections blocks until the number of accepted connections since
+// start reaches n
+func (s *Server) WaitForConnections(n int, timeout time.Duration) error {
+	return s.wait(timeout, func() bool { return s.total >= n })
+}
+
+// WaitForDisconnect blocks until no clients are connected
+func (s *Server) WaitForDisconnect(timeout time.Duration) error {
+	return s.wait(timeout, func() bool { return len(s.conns) == 0 })
+}
+
+// Received returns a copy of every frame received from clients in order
+func (s *Server) Received() []Message {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]Message(nil), s.received...)
+}
+
+// Expect blocks until a received frame matches and returns it. Frames
+// received before the call are checked first.
+func (s *Server) Expect(match Matcher, timeout time.Duration) (Message, error) {
+	var found Message
+	err := s.wait(timeout, func() bool {
+		for x := range s.received {
+			if match(s.received[x]) {
+				found = s.received[x]
+				return true
+			}
+		}
+		return false
+	})
+	return found, err
+}
+
+// ExpectCount blocks until n received frames match, used to assert that
+// subscriptions are sent again after a reconnect
+func (s *Server) ExpectCount(match Matcher, n int, timeout time.Duration) error {
+	return s.wait(timeout, func() bool {
+		var count int
+		for x := range s.received {
+			if match(s.received[x]) {
+				count++
+			}
+		}
+		return count >= n
+	})
+}
+
+// wait blocks until check passes, check is called with the lock held
+func (s *Server) wait(timeout time.Duration, check func() bool) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		s.mtx.Lock()
+		if check() {
+			s.mtx.Unlock()
+			return nil
+		}
+		notify := s.notify
+		s.mtx.Unlock()
+		select {
+		case <-notify:
+		case <-timer.C:
+			return ErrTimeout
+		}
+	}
+}
+
+// Contains matches frames which contain substr
+func Contains(substr string) Matcher {
+	return func(m Message) bool {
+		return bytes.Contains(m.Data, []byte(substr))
+	}
+}
+
+// JSONEqual matches frames which decode to the same JSON value as v, key
+// order and whitespace are ignored. It is used to assert on exact subscribe
+// and unsubscribe payloads.
+func JSONEqual(v interface{}) Matcher {
+	want, err := normalise(v)
+	return func(m Message) bool {
+		if err != nil {
+			return false
+		}
+		var got interface{}
+		if json.Unmarshal(m.Data, &got) != nil {
+			return false
+		}
+		return reflect.DeepEqual(got, want)
+	}
+}
+
+// normalise round trips a value through JSON so it compares equal to a
+// decoded frame
+func normalise(v interface{}) (interface{}, error) {
+	var data []byte
+	switch t := v.(type) {
+	case []byte:
+		data = t
+	case string:
+		data = []byte(t)
+	default:
+		var err error
+		data, err = json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var out interface{}
+	return out, json.Unmarshal(data, &out)
+}
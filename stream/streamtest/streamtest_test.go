@@ -0,0 +1,147 @@
+package streamtest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const testTimeout = time.Second * 5
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	s := NewServer()
+	s.OnConnect([]byte(`{"event":"info"}`))
+	s.Respond(Contains(`"subscribe"`), []byte(`{"event":"subscribed"}`))
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+func dial(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+	c, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func read(t *testing.T, c *websocket.Conn) string {
+	t.Helper()
+	if err := c.SetReadDeadline(time.Now().Add(testTimeout)); err != nil {
+		t.Fatal(err)
+	}
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestStart(t *testing.T) {
+	t.Parallel()
+	s := newTestServer(t)
+	if err := s.Start(); !errors.Is(err, errServerStarted) {
+		t.Errorf("expected %v, received %v", errServerStarted, err)
+	}
+	if err := NewServer().Send(nil); !errors.Is(err, errServerNotStarted) {
+		t.Errorf("expected %v, received %v", errServerNotStarted, err)
+	}
+}
+
+func TestScriptedFrames(t *testing.T) {
+	t.Parallel()
+	s := newTestServer(t)
+	c := dial(t, s.URL)
+	if got := read(t, c); got != `{"event":"info"}` {
+		t.Fatalf("expected connect frame, received %s", got)
+	}
+
+	sub := map[string]interface{}{"event": "subscribe", "channels": []string{"ticker"}}
+	if err := c.WriteJSON(sub); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(t, c); got != `{"event":"subscribed"}` {
+		t.Fatalf("expected subscribe reply, received %s", got)
+	}
+	if _, err := s.Expect(JSONEqual(`{"channels":["ticker"],"event":"subscribe"}`), testTimeout); err != nil {
+		t.Error(err)
+	}
+	if err := s.ExpectCount(Contains("subscribe"), 2, time.Millisecond*10); !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected %v, received %v", ErrTimeout, err)
+	}
+	if _, err := s.Expect(JSONEqual(`{"event":"unsubscribe"}`), time.Millisecond*10); !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected %v, received %v", ErrTimeout, err)
+	}
+
+	if err := s.SendMalformed(); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(t, c); got != string(MalformedFrame) {
+		t.Errorf("expected malformed frame, received %s", got)
+	}
+	if err := s.Flood([]byte("x"), 10); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		read(t, c)
+	}
+	if len(s.Received()) != 1 {
+		t.Errorf("expected one received frame, received %d", len(s.Received()))
+	}
+}
+
+func TestDisconnect(t *testing.T) {
+	t.Parallel()
+	s := newTestServer(t)
+	c := dial(t, s.URL)
+	read(t, c)
+	if err := s.WaitForConnections(1, testTimeout); err != nil {
+		t.Fatal(err)
+	}
+	s.Disconnect()
+	if err := s.WaitForDisconnect(testTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.ReadMessage(); err == nil {
+		t.Error("expected read error after disconnect")
+	}
+
+	c = dial(t, s.URL)
+	read(t, c)
+	if err := s.CloseWithCode(websocket.CloseGoingAway, "maintenance"); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err := c.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("expected going away close error, received %v", err)
+	}
+	if s.TotalConnections() != 2 {
+		t.Errorf("expected two connections, received %d", s.TotalConnections())
+	}
+}
+
+func TestEcho(t *testing.T) {
+	t.Parallel()
+	s := NewServer()
+	s.Echo = true
+	s.ReadDelay = time.Millisecond
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	c := dial(t, s.URL)
+	if err := c.WriteMessage(websocket.TextMessage, []byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(t, c); got != "ping" {
+		t.Errorf("expected echo, received %s", got)
+	}
+}
@@ -0,0 +1,363 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/log"
+)
+
+// defaultSubscriptionTimeout is how long an exchange has to acknowledge a
+// subscription when none is set
+const defaultSubscriptionTimeout = 10 * time.Second
+
+var (
+	errSubscriptionTimeout  = errors.New("subscription was not acknowledged in time")
+	errSubscriptionNotFound = errors.New("subscription not found")
+	errMatchUnset           = errors.New("match is not set")
+	errBookResync           = errors.New("orderbook cannot be resubscribed, reconnecting to resync it")
+)
+
+// SubscriptionState is the stage of a subscription between the request and
+// the exchange's acknowledgement
+type SubscriptionState uint8
+
+// Subscription states
+const (
+	// SubscriptionActive is acknowledged by the exchange through Match
+	SubscriptionActive SubscriptionState = iota
+	// SubscriptionPending is sent and awaiting acknowledgement
+	SubscriptionPending
+	// SubscriptionFailed was rejected or not acknowledged in time and is
+	// retried under the reconnection backoff
+	SubscriptionFailed
+	// SubscriptionUnsubscribing is being removed
+	SubscriptionUnsubscribing
+	// SubscriptionUnconfirmed is sent by an exchange which does not route
+	// acknowledgements through Match, recorded by AddSuccessfulSubscriptions.
+	// The exchange may have rejected it.
+	SubscriptionUnconfirmed
+)
+
+// String implements the stringer interface
+func (s SubscriptionState) String() string {
+	switch s {
+	case SubscriptionActive:
+		return "active"
+	case SubscriptionPending:
+		return "pending"
+	case SubscriptionFailed:
+		return "failed"
+	case SubscriptionUnsubscribing:
+		return "unsubscribing"
+	case SubscriptionUnconfirmed:
+		return "unconfirmed"
+	}
+	return "unknown"
+}
+
+// ExpectSubscriptions records channels as pending until a response is matched
+// to signature through Match. It is called by subscribers in place of
+// AddSuccessfulSubscriptions once the request is sent, the exchange's read
+// routine passes the acknowledgement to Match.IncomingWithData.
+func (w *Websocket) ExpectSubscriptions(signature interface{}, channels ...ChannelSubscription) error {
+	m, err := w.expect(signature)
+	if err != nil {
+		return err
+	}
+	channels = append(channels[:0:0], channels...)
+	w.recordSubscriptions(channels, SubscriptionPending)
+	w.Wg.Add(1)
+	go w.awaitAcknowledgement(m, channels, false, w.ShutdownC)
+	return nil
+}
+
+// ExpectUnsubscriptions keeps channels as unsubscribing until a response is
+// matched to signature through Match, removing them once it is. It is called
+// by unsubscribers in place of RemoveSuccessfulUnsubscriptions.
+func (w *Websocket) ExpectUnsubscriptions(signature interface{}, channels ...ChannelSubscription) error {
+	m, err := w.expect(signature)
+	if err != nil {
+		return err
+	}
+	channels = append(channels[:0:0], channels...)
+	w.setSubscriptionState(channels, SubscriptionUnsubscribing)
+	w.Wg.Add(1)
+	go w.awaitAcknowledgement(m, channels, true, w.ShutdownC)
+	return nil
+}
+
+// expect registers signature with the matcher
+func (w *Websocket) expect(signature interface{}) (matcher, error) {
+	if w.Match == nil {
+		return matcher{}, fmt.Errorf("%s websocket: %w", w.exchangeName, errMatchUnset)
+	}
+	return w.Match.set(signature)
+}
+
+// awaitAcknowledgement resolves expected subscriptions with the matched
+// response, or as failed once the subscription timeout passes
+func (w *Websocket) awaitAcknowledgement(m matcher, channels []ChannelSubscription, unsubscribe bool, shutdown <-chan struct{}) {
+	defer w.Wg.Done()
+	defer m.Cleanup()
+	timeout := w.subscriptionTimeout
+	if timeout <= 0 {
+		timeout = defaultSubscriptionTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var err error
+	select {
+	case resp := <-m.C:
+		if w.confirmSubscriptions != nil {
+			err = w.confirmSubscriptions(resp)
+		}
+	case <-timer.C:
+		err = errSubscriptionTimeout
+	case <-shutdown:
+		return
+	}
+
+	w.subscriptionMutex.Lock()
+	defer w.subscriptionMutex.Unlock()
+	for x := range channels {
+		i := w.subscriptionIndex(&channels[x])
+		if i < 0 {
+			continue
+		}
+		s := &w.subscriptions[i]
+		switch {
+		case unsubscribe && s.State == SubscriptionUnsubscribing:
+			if err == nil {
+				w.removeSubscription(i)
+				continue
+			}
+			log.Errorf(log.WebsocketMgr, "%s websocket: unsubscribing %s failed: %v",
+				w.exchangeName, s.Channel, err)
+			s.State = SubscriptionActive
+			s.Err = err
+		case !unsubscribe && s.State == SubscriptionPending:
+			if err == nil {
+				s.State = SubscriptionActive
+				s.Err = nil
+				s.failures = 0
+				continue
+			}
+			w.failSubscription(i, err, shutdown)
+		}
+	}
+}
+
+// failSubscription marks a subscription as failed and schedules its retry
+// under the reconnection backoff. The caller must hold subscriptionMutex.
+func (w *Websocket) failSubscription(i int, err error, shutdown <-chan struct{}) {
+	s := &w.subscriptions[i]
+	s.State = SubscriptionFailed
+	s.Err = err
+	s.failures++
+	b := w.backoff()
+	if b.MaxAttempts > 0 && s.failures >= b.MaxAttempts {
+		log.Errorf(log.WebsocketMgr,
+			"%s websocket: giving up subscribing %s %s after %d attempts: %v",
+			w.exchangeName, s.Channel, s.Currency, s.failures, err)
+		return
+	}
+	delay := b.delay(s.failures, jitter())
+	log.Errorf(log.WebsocketMgr,
+		"%s websocket: subscribing %s %s failed, retrying in %s: %v",
+		w.exchangeName, s.Channel, s.Currency, delay, err)
+	w.Wg.Add(1)
+	go w.retrySubscription(*s, delay, shutdown)
+}
+
+// retrySubscription subscribes a failed subscription again after delay unless
+// it has since been resubscribed, unsubscribed or the websocket reconnected
+func (w *Websocket) retrySubscription(c ChannelSubscription, delay time.Duration, shutdown <-chan struct{}) {
+	defer w.Wg.Done()
+	timer := time.NewTimer(delay)
+	select {
+	case <-timer.C:
+	case <-shutdown:
+		timer.Stop()
+		return
+	}
+	w.subscriptionMutex.Lock()
+	defer w.subscriptionMutex.Unlock()
+	i := w.subscriptionIndex(&c)
+	// A reconnection resubscribes every subscription
+	if i < 0 || w.subscriptions[i].State != SubscriptionFailed || !w.IsConnected() {
+		return
+	}
+	c = w.subscriptions[i]
+	if w.sharded() {
+		w.releaseShardSubscriptions([]ChannelSubscription{c})
+	}
+	if err := w.subscribe([]ChannelSubscription{c}); err != nil {
+		if i = w.subscriptionIndex(&c); i >= 0 && w.subscriptions[i].State == SubscriptionFailed {
+			w.failSubscription(i, err, shutdown)
+		}
+	}
+}
+
+// recordSubscriptions adds channels to the subscription list in state,
+// replacing any already listed
+func (w *Websocket) recordSubscriptions(channels []ChannelSubscription, state SubscriptionState) {
+	for x := range channels {
+		c := channels[x]
+		c.State = state
+		if state == SubscriptionActive || state == SubscriptionUnconfirmed {
+			c.Err = nil
+			c.failures = 0
+		}
+		if i := w.subscriptionIndex(&c); i >= 0 {
+			w.subscriptions[i] = c
+			continue
+		}
+		w.subscriptions = append(w.subscriptions, c)
+	}
+}
+
+// setSubscriptionState sets the state of listed channels
+func (w *Websocket) setSubscriptionState(channels []ChannelSubscription, s SubscriptionState) {
+	for x := range channels {
+		if i := w.subscriptionIndex(&channels[x]); i >= 0 {
+			w.subscriptions[i].State = s
+		}
+	}
+}
+
+// subscriptionIndex returns the position of c in the subscription list, -1
+// when it is not listed
+func (w *Websocket) subscriptionIndex(c *ChannelSubscription) int {
+	for i := range w.subscriptions {
+		if c.Equal(&w.subscriptions[i]) && c.Asset == w.subscriptions[i].Asset {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeSubscription removes the subscription at i
+func (w *Websocket) removeSubscription(i int) {
+	last := len(w.subscriptions) - 1
+	w.subscriptions[i] = w.subscriptions[last]
+	w.subscriptions[last] = ChannelSubscription{}
+	w.subscriptions = w.subscriptions[:last]
+}
+
+// GetSubscriptionState returns the state of a subscription
+func (w *Websocket) GetSubscriptionState(c *ChannelSubscription) (SubscriptionState, error) {
+	w.subscriptionMutex.Lock()
+	defer w.subscriptionMutex.Unlock()
+	i := w.subscriptionIndex(c)
+	if i < 0 {
+		return 0, fmt.Errorf("%s websocket: %w: %s %s",
+			w.exchangeName, errSubscriptionNotFound, c.Channel, c.Currency)
+	}
+	return w.subscriptions[i].State, nil
+}
+
+// GetSubscriptionsByState returns a copied list of subscriptions in state
+func (w *Websocket) GetSubscriptionsByState(s SubscriptionState) []ChannelSubscription {
+	w.subscriptionMutex.Lock()
+	defer w.subscriptionMutex.Unlock()
+	var subs []ChannelSubscription
+	for x := range w.subscriptions {
+		if w.subscriptions[x].State == s {
+			subs = append(subs, w.subscriptions[x])
+		}
+	}
+	return subs
+}
+
+// resubscribeBook resynchronises the local book of a pair which can no longer
+// be updated incrementally. Its orderbook channels are resubscribed when the
+// exchange supports it, as it sends a fresh snapshot on subscription.
+// Otherwise the book is fetched over REST through OrderbookResync, or the
+// connection carrying it is dropped and reconnected. It is called by the
+// orderbook buffer.
+func (w *Websocket) resubscribeBook(p currency.Pair, a asset.Item) {
+	if !w.IsConnected() {
+		return
+	}
+	var book []ChannelSubscription
+	if w.orderbookChannel != nil {
+		subs := w.GetSubscriptions()
+		for x := range subs {
+			if (subs[x].State != SubscriptionActive && subs[x].State != SubscriptionUnconfirmed) ||
+				!subs[x].Currency.Equal(p) ||
+				(subs[x].Asset != "" && subs[x].Asset != a) || !w.orderbookChannel(&subs[x]) {
+				continue
+			}
+			book = append(book, subs[x])
+		}
+	}
+	switch {
+	case len(book) != 0 && w.features != nil && w.features.Subscribe && w.features.Unsubscribe:
+		go func() {
+			err := w.UnsubscribeChannels(book)
+			if err == nil {
+				err = w.SubscribeToChannels(book)
+			}
+			if err != nil {
+				log.Errorf(log.WebsocketMgr, "%s websocket: resubscribing orderbook %s %s failed: %v",
+					w.exchangeName, p, a, err)
+			}
+		}()
+	case w.orderbookResync != nil:
+		go w.orderbookResync(p, a)
+	case len(book) != 0:
+		log.Warnf(log.WebsocketMgr, "%s websocket: orderbook %s %s cannot be resubscribed, reconnecting",
+			w.exchangeName, p, a)
+		go w.reconnectBook(book[0])
+	}
+}
+
+// reconnectBook drops the connection carrying a book's channel so the
+// connection monitor, or the shard's own, redials it and resubscribes its
+// channels
+func (w *Websocket) reconnectBook(c ChannelSubscription) {
+	w.shardMtx.Lock()
+	var s *shard
+	for _, candidate := range w.shards {
+		for y := range candidate.subs {
+			if candidate != w.shards[0] && c.Equal(&candidate.subs[y]) {
+				s = candidate
+			}
+		}
+	}
+	w.shardMtx.Unlock()
+	if s != nil {
+		// reconnectShard closes the connection before redialling it
+		select {
+		case s.errs <- errBookResync:
+		default:
+		}
+		return
+	}
+
+	w.m.Lock()
+	if !w.IsConnected() || w.IsConnecting() {
+		w.m.Unlock()
+		return
+	}
+	// Closing locally stops the readers without reporting a disconnection
+	for _, conn := range []Connection{w.Conn, w.AuthConn} {
+		if conn == nil {
+			continue
+		}
+		if err := conn.Shutdown(); err != nil {
+			log.Errorf(log.WebsocketMgr, "%s websocket: closing connection to resync orderbook failed: %v",
+				w.exchangeName, err)
+		}
+	}
+	shutdown := w.ShutdownC
+	w.m.Unlock()
+	select {
+	case w.ReadMessageErrors <- errBookResync:
+	case <-shutdown:
+	}
+}
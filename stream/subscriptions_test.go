@@ -0,0 +1,337 @@
+package stream
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openware/irix/protocol"
+	"github.com/openware/irix/stream/buffer"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/orderbook"
+)
+
+var errRejected = errors.New("rejected")
+
+// subscriptionCounter counts subscribe and unsubscribe requests by channel
+type subscriptionCounter struct {
+	subscribed   map[string]int
+	unsubscribed map[string]int
+	mtx          sync.Mutex
+}
+
+func (c *subscriptionCounter) add(m map[string]int, subs []ChannelSubscription) {
+	c.mtx.Lock()
+	for x := range subs {
+		m[subs[x].Channel+subs[x].Currency.String()]++
+	}
+	c.mtx.Unlock()
+}
+
+func (c *subscriptionCounter) count(m map[string]int, key string) int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return m[key]
+}
+
+func newSubscriptionCounter() *subscriptionCounter {
+	return &subscriptionCounter{subscribed: make(map[string]int), unsubscribed: make(map[string]int)}
+}
+
+func TestSubscriptionStates(t *testing.T) {
+	t.Parallel()
+	ws := New()
+	counter := newSubscriptionCounter()
+	s := *defaultSetup
+	s.SubscriptionTimeout = 50 * time.Millisecond
+	s.Reconnect = Backoff{Initial: 10 * time.Millisecond, Max: 20 * time.Millisecond, Multiplier: 2, MaxAttempts: 2}
+	s.ConfirmSubscriptions = func(resp []byte) error {
+		if bytes.Contains(resp, []byte("error")) {
+			return errRejected
+		}
+		return nil
+	}
+	s.Subscriber = func(subs []ChannelSubscription) error {
+		counter.add(counter.subscribed, subs)
+		return ws.ExpectSubscriptions(subs[0].Channel, subs...)
+	}
+	s.UnSubscriber = func(subs []ChannelSubscription) error {
+		counter.add(counter.unsubscribed, subs)
+		return ws.ExpectUnsubscriptions("un"+subs[0].Channel, subs...)
+	}
+	if err := ws.Setup(&s); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	state := func(channel string) SubscriptionState {
+		st, err := ws.GetSubscriptionState(&ChannelSubscription{Channel: channel})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return st
+	}
+
+	ok, rejected, silent := ChannelSubscription{Channel: "ok"}, ChannelSubscription{Channel: "rejected"}, ChannelSubscription{Channel: "silent"}
+	for _, c := range []ChannelSubscription{ok, rejected, silent} {
+		if err := ws.SubscribeToChannels([]ChannelSubscription{c}); err != nil {
+			t.Fatal(err)
+		}
+		if st := state(c.Channel); st != SubscriptionPending {
+			t.Errorf("%s: expected pending, received %s", c.Channel, st)
+		}
+	}
+
+	ws.Match.IncomingWithData("ok", []byte(`{"result":true}`))
+	waitFor(t, func() bool { return state("ok") == SubscriptionActive })
+
+	// A rejection is retried under the backoff
+	ws.Match.IncomingWithData("rejected", []byte(`{"error":"invalid channel"}`))
+	waitFor(t, func() bool { return counter.count(counter.subscribed, "rejected") == 2 })
+	ws.Match.IncomingWithData("rejected", []byte(`{"result":true}`))
+	waitFor(t, func() bool { return state("rejected") == SubscriptionActive })
+
+	// Unacknowledged subscriptions fail until MaxAttempts
+	waitFor(t, func() bool {
+		failed := ws.GetSubscriptionsByState(SubscriptionFailed)
+		return len(failed) == 1 && counter.count(counter.subscribed, "silent") == 2
+	})
+	failed := ws.GetSubscriptionsByState(SubscriptionFailed)
+	if failed[0].Channel != "silent" || !errors.Is(failed[0].Err, errSubscriptionTimeout) {
+		t.Errorf("unexpected failed subscription %+v", failed[0])
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := counter.count(counter.subscribed, "silent"); n != 2 {
+		t.Errorf("expected to give up after 2 attempts, received %d", n)
+	}
+	// A failed subscription can be subscribed again
+	if err := ws.SubscribeToChannels([]ChannelSubscription{silent}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.SubscribeToChannels([]ChannelSubscription{ok}); err == nil {
+		t.Error("expected error subscribing an active subscription")
+	}
+
+	if err := ws.UnsubscribeChannels([]ChannelSubscription{ok}); err != nil {
+		t.Fatal(err)
+	}
+	if st := state("ok"); st != SubscriptionUnsubscribing {
+		t.Errorf("expected unsubscribing, received %s", st)
+	}
+	ws.Match.IncomingWithData("unok", nil)
+	waitFor(t, func() bool {
+		_, err := ws.GetSubscriptionState(&ok)
+		return errors.Is(err, errSubscriptionNotFound)
+	})
+
+	if err := ws.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAddSuccessfulSubscriptions(t *testing.T) {
+	t.Parallel()
+	ws := New()
+	subs := []ChannelSubscription{
+		{Channel: "trades", Asset: asset.Spot, State: SubscriptionPending},
+		{Channel: "trades", Asset: asset.Futures},
+	}
+	ws.AddSuccessfulSubscriptions(subs...)
+	ws.AddSuccessfulSubscriptions(subs[0])
+	if n := len(ws.GetSubscriptions()); n != 2 {
+		t.Errorf("expected subscriptions to be recorded once per asset, received %d", n)
+	}
+	if n := len(ws.GetSubscriptionsByState(SubscriptionUnconfirmed)); n != 2 {
+		t.Errorf("expected unconfirmed subscriptions, received %d", n)
+	}
+	if subs[0].State != SubscriptionPending {
+		t.Error("expected the caller's subscriptions to be left unchanged")
+	}
+}
+
+func TestResubscribeBook(t *testing.T) {
+	t.Parallel()
+	ws := New()
+	counter := newSubscriptionCounter()
+	s := *defaultSetup
+	s.ExchangeName = "resubscribeBook"
+	s.UpdateEntriesByID = true
+	s.OrderbookChannel = func(c *ChannelSubscription) bool { return c.Channel == "book" }
+	s.Subscriber = func(subs []ChannelSubscription) error {
+		counter.add(counter.subscribed, subs)
+		ws.AddSuccessfulSubscriptions(subs...)
+		return nil
+	}
+	s.UnSubscriber = func(subs []ChannelSubscription) error {
+		counter.add(counter.unsubscribed, subs)
+		ws.RemoveSuccessfulUnsubscriptions(subs...)
+		return nil
+	}
+	if err := ws.Setup(&s); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for range ws.ToRoutine {
+		}
+	}()
+	err := ws.SubscribeToChannels([]ChannelSubscription{
+		{Channel: "book", Currency: btcusd, Asset: asset.Spot},
+		{Channel: "trades", Currency: btcusd, Asset: asset.Spot},
+		{Channel: "book", Currency: ethusd, Asset: asset.Spot},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ws.Orderbook.LoadSnapshot(&orderbook.Base{
+		Exchange: s.ExchangeName,
+		Pair:     btcusd,
+		Asset:    asset.Spot,
+		Bids:     orderbook.Items{{Price: 1, Amount: 1, ID: 1}},
+		Asks:     orderbook.Items{{Price: 2, Amount: 1, ID: 2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Amending an unknown ID means the book is out of sync
+	err = ws.Orderbook.Update(&buffer.Update{
+		Action: buffer.Amend,
+		Pair:   btcusd,
+		Asset:  asset.Spot,
+		Bids:   orderbook.Items{{Price: 1, Amount: 2, ID: 3}},
+	})
+	if err == nil {
+		t.Fatal("expected error amending unknown ID")
+	}
+	waitFor(t, func() bool { return counter.count(counter.subscribed, "book"+btcusd.String()) == 2 })
+	if n := counter.count(counter.unsubscribed, "book"+btcusd.String()); n != 1 {
+		t.Errorf("expected book to be unsubscribed once, received %d", n)
+	}
+	if n := counter.count(counter.subscribed, "trades"+btcusd.String()); n != 1 {
+		t.Errorf("expected trades to be left subscribed, received %d subscriptions", n)
+	}
+	if n := counter.count(counter.subscribed, "book"+ethusd.String()); n != 1 {
+		t.Errorf("expected other books to be left subscribed, received %d subscriptions", n)
+	}
+	if err = ws.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResubscribeBookFallback(t *testing.T) {
+	t.Parallel()
+	outOfSync := func(t *testing.T, ws *Websocket, exch string) {
+		t.Helper()
+		err := ws.Orderbook.LoadSnapshot(&orderbook.Base{
+			Exchange: exch,
+			Pair:     btcusd,
+			Asset:    asset.Spot,
+			Bids:     orderbook.Items{{Price: 1, Amount: 1, ID: 1}},
+			Asks:     orderbook.Items{{Price: 2, Amount: 1, ID: 2}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ws.Orderbook.Update(&buffer.Update{
+			Action: buffer.Amend,
+			Pair:   btcusd,
+			Asset:  asset.Spot,
+			Bids:   orderbook.Items{{Price: 1, Amount: 2, ID: 3}},
+		})
+		if err == nil {
+			t.Fatal("expected error amending unknown ID")
+		}
+	}
+	setup := func(name string, counter *subscriptionCounter, ws *Websocket) *WebsocketSetup {
+		s := *defaultSetup
+		s.ExchangeName = name
+		s.UpdateEntriesByID = true
+		s.Features = &protocol.Features{Subscribe: true}
+		s.Reconnect = Backoff{Initial: 10 * time.Millisecond, Max: 20 * time.Millisecond, Multiplier: 2}
+		s.OrderbookChannel = func(c *ChannelSubscription) bool { return c.Channel == "book" }
+		s.Subscriber = func(subs []ChannelSubscription) error {
+			counter.add(counter.subscribed, subs)
+			ws.AddSuccessfulSubscriptions(subs...)
+			return nil
+		}
+		return &s
+	}
+	connect := func(t *testing.T, ws *Websocket, s *WebsocketSetup) {
+		t.Helper()
+		if err := ws.Setup(s); err != nil {
+			t.Fatal(err)
+		}
+		if err := ws.Connect(); err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			for range ws.ToRoutine {
+			}
+		}()
+		err := ws.SubscribeToChannels([]ChannelSubscription{
+			{Channel: "book", Currency: btcusd, Asset: asset.Spot},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("rest", func(t *testing.T) {
+		t.Parallel()
+		ws := New()
+		counter := newSubscriptionCounter()
+		s := setup("resyncBookREST", counter, ws)
+		resynced := make(chan asset.Item, 1)
+		s.OrderbookResync = func(p currency.Pair, a asset.Item) {
+			if p.Equal(btcusd) {
+				resynced <- a
+			}
+		}
+		connect(t, ws, s)
+		outOfSync(t, ws, s.ExchangeName)
+		select {
+		case a := <-resynced:
+			if a != asset.Spot {
+				t.Errorf("expected spot book to be resynced, received %s", a)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected book to be fetched over REST")
+		}
+		if n := counter.count(counter.subscribed, "book"+btcusd.String()); n != 1 {
+			t.Errorf("expected book not to be resubscribed, received %d subscriptions", n)
+		}
+		if err := ws.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("reconnect", func(t *testing.T) {
+		t.Parallel()
+		ws := New()
+		counter := newSubscriptionCounter()
+		s := setup("resyncBookReconnect", counter, ws)
+		var connects int32
+		s.Connector = func() error {
+			atomic.AddInt32(&connects, 1)
+			return nil
+		}
+		connect(t, ws, s)
+		outOfSync(t, ws, s.ExchangeName)
+		waitFor(t, func() bool { return counter.count(counter.subscribed, "book"+btcusd.String()) == 2 })
+		if n := atomic.LoadInt32(&connects); n != 2 {
+			t.Errorf("expected websocket to reconnect once, received %d connections", n)
+		}
+		waitFor(t, ws.IsConnected)
+		if err := ws.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
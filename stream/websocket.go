@@ -7,10 +7,12 @@ import (
 	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/openware/irix/config"
+	"github.com/openware/irix/metrics"
 	"github.com/openware/pkg/log"
 )
 
@@ -36,9 +38,33 @@ func New() *Websocket {
 		Subscribe:         make(chan []ChannelSubscription),
 		Unsubscribe:       make(chan []ChannelSubscription),
 		Match:             NewMatch(),
+		events:            NewEventBus(),
 	}
 }
 
+// Events returns the bus data passed to the data handler is published on.
+// Typed subscribers attach here instead of reading ToRoutine, and should call
+// DisableToRoutine if nothing reads it.
+func (w *Websocket) Events() *EventBus {
+	w.eventsMtx.Lock()
+	defer w.eventsMtx.Unlock()
+	if w.events == nil {
+		w.events = NewEventBus()
+	}
+	return w.events
+}
+
+// SetEventBus publishes the websocket's data on b, letting several exchanges
+// share one bus
+func (w *Websocket) SetEventBus(b *EventBus) {
+	if b == nil {
+		return
+	}
+	w.eventsMtx.Lock()
+	w.events = b
+	w.eventsMtx.Unlock()
+}
+
 // Setup sets main variables for websocket connection
 func (w *Websocket) Setup(s *WebsocketSetup) error {
 	if w == nil {
@@ -72,6 +98,47 @@ func (w *Websocket) Setup(s *WebsocketSetup) error {
 	w.Unsubscriber = s.UnSubscriber
 
 	w.GenerateSubs = s.GenerateSubscriptions
+	if s.MaxSubscriptionsPerMessage < 0 {
+		return errors.New("max subscriptions per message cannot be negative")
+	}
+	w.maxSubscriptionsPerMessage = s.MaxSubscriptionsPerMessage
+
+	if s.MaxSubscriptionsPerConnection < 0 {
+		return errors.New("max subscriptions per connection cannot be negative")
+	}
+	if s.MaxSubscriptionsPerConnection > 0 {
+		if w.features.FullPayloadSubscribe {
+			return errors.New("subscriptions cannot be spread across connections when the full payload is resubscribed")
+		}
+		if s.ShardConnector == nil || s.ShardSubscriber == nil ||
+			(w.features.Unsubscribe && s.ShardUnsubscriber == nil) {
+			return errors.New("subscriptions per connection have been limited yet shard functions are not set")
+		}
+	}
+	w.maxSubscriptionsPerConnection = s.MaxSubscriptionsPerConnection
+	w.ShardConnector = s.ShardConnector
+	w.ShardSubscriber = s.ShardSubscriber
+	w.ShardUnsubscriber = s.ShardUnsubscriber
+
+	if s.SubscriptionTimeout < 0 {
+		return errors.New("subscription timeout cannot be negative")
+	}
+	w.subscriptionTimeout = s.SubscriptionTimeout
+	w.confirmSubscriptions = s.ConfirmSubscriptions
+	w.orderbookChannel = s.OrderbookChannel
+	w.orderbookResync = s.OrderbookResync
+
+	for k, b := range s.Backpressure {
+		if err := w.SetBackpressure(k, b); err != nil {
+			return err
+		}
+	}
+	if s.Reconnect != (Backoff{}) {
+		if err := w.SetBackoff(s.Reconnect); err != nil {
+			return err
+		}
+	}
+	w.SetStateHandler(s.StateHandler)
 
 	w.enabled = s.Enabled
 	if s.DefaultURL == "" {
@@ -108,7 +175,7 @@ func (w *Websocket) Setup(s *WebsocketSetup) error {
 	w.Wg = new(sync.WaitGroup)
 	w.SetCanUseAuthenticatedEndpoints(s.AuthenticatedWebsocketAPISupport)
 
-	return w.Orderbook.Setup(s.OrderbookBufferLimit,
+	err = w.Orderbook.Setup(s.OrderbookBufferLimit,
 		s.BufferEnabled,
 		s.SortBuffer,
 		s.SortBufferByUpdateIDs,
@@ -116,6 +183,12 @@ func (w *Websocket) Setup(s *WebsocketSetup) error {
 		s.Verbose,
 		w.exchangeName,
 		w.DataHandler)
+	if err != nil {
+		return err
+	}
+	w.Orderbook.SetResubscriber(w.resubscribeBook)
+	w.Orderbook.SetChecksummer(s.OrderbookChecksum)
+	return nil
 }
 
 // SetupNewConnection sets up an auth or unauth streaming connection
@@ -156,7 +229,12 @@ func (w *Websocket) SetupNewConnection(c ConnectionSetup) error {
 		Wg:                w.Wg,
 		Match:             w.Match,
 		RateLimit:         c.RateLimit,
+		MessageRate:       c.MessageRate,
+		MessageBurst:      c.MessageBurst,
 	}
+	w.metricsMtx.Lock()
+	newConn.setMetrics(w.metrics)
+	w.metricsMtx.Unlock()
 
 	if c.Authenticated {
 		w.AuthConn = newConn
@@ -191,27 +269,47 @@ func (w *Websocket) Connect() error {
 	w.dataMonitor()
 	w.trafficMonitor()
 	w.setConnectingStatus(true)
+	w.notify(StateConnecting, nil)
 
 	err := w.connector()
 	if err != nil {
 		w.setConnectingStatus(false)
-		return fmt.Errorf("%v Error connecting %s",
+		w.notify(StateDisconnected, err)
+		return fmt.Errorf("%v Error connecting %w",
 			w.exchangeName, err)
 	}
 	w.setConnectedStatus(true)
 	w.setConnectingStatus(false)
 	w.setInit(true)
+	w.notify(StateConnected, nil)
 
 	if !w.IsConnectionMonitorRunning() {
 		w.connectionMonitor()
 	}
 
 	// Resubscribe after re-connection
-	if len(w.subscriptions) != 0 {
-		err = w.Subscriber(w.subscriptions)
+	if w.sharded() {
+		var resubscribed bool
+		resubscribed, err = w.resubscribePrimary()
+		if err != nil {
+			return fmt.Errorf("%v Error subscribing %s", w.exchangeName, err)
+		}
+		if resubscribed {
+			w.notify(StateResubscribed, nil)
+		}
+	} else {
+		w.subscriptionMutex.Lock()
+		subs := append(w.subscriptions[:0:0], w.subscriptions...)
+		if len(subs) != 0 {
+			err = w.subscribe(subs)
+		}
+		w.subscriptionMutex.Unlock()
 		if err != nil {
 			return fmt.Errorf("%v Error subscribing %s", w.exchangeName, err)
 		}
+		if len(subs) != 0 {
+			w.notify(StateResubscribed, nil)
+		}
 	}
 
 	return nil
@@ -245,7 +343,9 @@ func (w *Websocket) dataMonitor() {
 		return
 	}
 	w.setDataMonitorRunning(true)
-	w.Wg.Add(1)
+	p := w.newPipeline()
+	w.Wg.Add(2)
+	go w.forward(p)
 
 	go func() {
 		defer func() {
@@ -266,19 +366,14 @@ func (w *Websocket) dataMonitor() {
 			case <-w.ShutdownC:
 				return
 			case d := <-w.DataHandler:
-				select {
-				case w.ToRoutine <- d:
-				case <-w.ShutdownC:
+				rec := w.recorder()
+				rec.AddCounter(metrics.WebsocketData,
+					metrics.Labels{Exchange: w.exchangeName, Asset: assetOf(d)}, 1)
+				rec.SetGauge(metrics.DataHandlerBacklog,
+					metrics.Labels{Exchange: w.exchangeName}, float64(len(w.DataHandler)))
+				w.Events().Publish(w.exchangeName, d)
+				if !w.enqueue(p, d) {
 					return
-				default:
-					log.Warnf(log.WebsocketMgr,
-						"%s exchange backlog in websocket processing detected",
-						w.exchangeName)
-					select {
-					case w.ToRoutine <- d:
-					case <-w.ShutdownC:
-						return
-					}
 				}
 			}
 		}
@@ -292,7 +387,9 @@ func (w *Websocket) connectionMonitor() {
 	}
 	w.setConnectionMonitorRunning(true)
 	go func() {
-		timer := time.NewTimer(connectionMonitorDelay)
+		// failed counts consecutive failed reconnection attempts
+		var failed int
+		timer := time.NewTimer(w.backoff().Initial)
 
 		for {
 			if w.verbose {
@@ -324,20 +421,46 @@ func (w *Websocket) connectionMonitor() {
 			select {
 			case err := <-w.ReadMessageErrors:
 				if isDisconnectionError(err) {
+					w.recorder().AddCounter(metrics.WebsocketDisconnects,
+						metrics.Labels{Exchange: w.exchangeName}, 1)
 					w.setInit(false)
 					log.Warnf(log.WebsocketMgr,
 						"%v websocket has been disconnected. Reason: %v",
 						w.exchangeName, err)
 					w.setConnectedStatus(false)
+					w.notify(StateDisconnected, err)
 				} else {
 					// pass off non disconnect errors to datahandler to manage
 					w.DataHandler <- err
 				}
 			case <-timer.C:
-				if !w.IsConnecting() && !w.IsConnected() {
+				b := w.backoff()
+				delay := b.Initial
+				if w.IsConnected() {
+					failed = 0
+				} else if !w.IsConnecting() {
+					w.recorder().AddCounter(metrics.WebsocketReconnects,
+						metrics.Labels{Exchange: w.exchangeName}, 1)
+					atomic.StoreInt32(&w.attempt, int32(failed+1))
 					err := w.Connect()
-					if err != nil {
-						log.Error(log.WebsocketMgr, err)
+					switch {
+					case err == nil || w.IsConnected():
+						failed = 0
+					case b.MaxAttempts > 0 && failed+1 >= b.MaxAttempts:
+						timer.Stop()
+						w.giveUp(err)
+						atomic.StoreInt32(&w.attempt, 0)
+						w.setConnectionMonitorRunning(false)
+						return
+					default:
+						failed++
+						delay = b.delay(failed, jitter())
+						log.Errorf(log.WebsocketMgr,
+							"%v websocket: reconnection attempt %d failed, retrying in %s: %v",
+							w.exchangeName, failed, delay, err)
+					}
+					if failed == 0 {
+						atomic.StoreInt32(&w.attempt, 0)
 					}
 				}
 				if !timer.Stop() {
@@ -346,7 +469,7 @@ func (w *Websocket) connectionMonitor() {
 					default:
 					}
 				}
-				timer.Reset(connectionMonitorDelay)
+				timer.Reset(delay)
 			}
 		}
 	}()
@@ -392,12 +515,20 @@ func (w *Websocket) Shutdown() error {
 	w.subscriptionMutex.Lock()
 	w.subscriptions = nil
 	w.subscriptionMutex.Unlock()
+	w.closeShards()
 
 	close(w.ShutdownC)
 	w.Wg.Wait()
 	w.ShutdownC = make(chan struct{})
 	w.setConnectedStatus(false)
 	w.setConnectingStatus(false)
+	w.notify(StateDisconnected, nil)
+	w.connectionMutex.RLock()
+	hooks := w.shutdownHooks
+	w.connectionMutex.RUnlock()
+	for i := range hooks {
+		hooks[i]()
+	}
 	if w.verbose {
 		log.Debugf(log.WebsocketMgr,
 			"%v websocket: completed websocket shutdown\n",
@@ -406,6 +537,15 @@ func (w *Websocket) Shutdown() error {
 	return nil
 }
 
+// OnShutdown registers a function called once Shutdown completes, used to
+// stop routines which run alongside the connection. It is not called when the
+// connection drops and is reconnected.
+func (w *Websocket) OnShutdown(f func()) {
+	w.connectionMutex.Lock()
+	w.shutdownHooks = append(w.shutdownHooks, f)
+	w.connectionMutex.Unlock()
+}
+
 // FlushChannels flushes channel subscriptions when there is a pair/asset change
 func (w *Websocket) FlushChannels() error {
 	if !w.IsEnabled() {
@@ -807,7 +947,24 @@ channels:
 			w.exchangeName,
 			channels[x])
 	}
-	return w.Unsubscriber(channels)
+	previous := make([]SubscriptionState, len(channels))
+	for x := range channels {
+		if i := w.subscriptionIndex(&channels[x]); i >= 0 {
+			previous[x] = w.subscriptions[i].State
+		}
+	}
+	w.setSubscriptionState(channels, SubscriptionUnsubscribing)
+	err := w.unsubscribe(channels)
+	if err != nil {
+		// Those still listed remain subscribed
+		for x := range channels {
+			if i := w.subscriptionIndex(&channels[x]); i >= 0 &&
+				w.subscriptions[i].State == SubscriptionUnsubscribing {
+				w.subscriptions[i].State = previous[x]
+			}
+		}
+	}
+	return err
 }
 
 // ResubscribeToChannel resubscribes to channel
@@ -829,20 +986,67 @@ func (w *Websocket) SubscribeToChannels(channels []ChannelSubscription) error {
 	defer w.subscriptionMutex.Unlock()
 	for x := range channels {
 		for y := range w.subscriptions {
-			if channels[x].Equal(&w.subscriptions[y]) {
+			// Failed subscriptions and those being removed can be subscribed
+			// again
+			if channels[x].Equal(&w.subscriptions[y]) &&
+				w.subscriptions[y].State != SubscriptionFailed &&
+				w.subscriptions[y].State != SubscriptionUnsubscribing {
 				return fmt.Errorf("%s websocket: %v already subscribed",
 					w.exchangeName,
 					channels[x])
 			}
 		}
 	}
-	return w.Subscriber(channels)
+	return w.subscribe(channels)
 }
 
-// AddSuccessfulSubscriptions adds subscriptions to the subscription lists that
-// has been successfully subscribed
+// subscribe passes channels to the subscriber in batches of the exchange's
+// per message limit, spreading them across connections when sharded
+func (w *Websocket) subscribe(channels []ChannelSubscription) error {
+	if w.sharded() {
+		return w.subscribeShards(channels)
+	}
+	for _, batch := range BatchSubscriptions(channels, w.maxSubscriptionsPerMessage) {
+		if err := w.Subscriber(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unsubscribe passes channels to the unsubscriber in batches of the
+// exchange's per message limit
+func (w *Websocket) unsubscribe(channels []ChannelSubscription) error {
+	if w.sharded() {
+		return w.unsubscribeShards(channels)
+	}
+	for _, batch := range BatchSubscriptions(channels, w.maxSubscriptionsPerMessage) {
+		if err := w.Unsubscriber(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BatchSubscriptions splits channels into batches of at most size, a size of
+// zero or less returns every channel in one batch
+func BatchSubscriptions(channels []ChannelSubscription, size int) [][]ChannelSubscription {
+	if size <= 0 || len(channels) <= size {
+		return [][]ChannelSubscription{channels}
+	}
+	batches := make([][]ChannelSubscription, 0, (len(channels)+size-1)/size)
+	for len(channels) > size {
+		batches = append(batches, channels[:size:size])
+		channels = channels[size:]
+	}
+	return append(batches, channels)
+}
+
+// AddSuccessfulSubscriptions adds subscriptions to the subscription lists once
+// the request is sent, marking them unconfirmed. Exchanges which acknowledge
+// subscriptions use ExpectSubscriptions instead.
 func (w *Websocket) AddSuccessfulSubscriptions(channels ...ChannelSubscription) {
-	w.subscriptions = append(w.subscriptions, channels...)
+	w.recordSubscriptions(channels, SubscriptionUnconfirmed)
 }
 
 // RemoveSuccessfulUnsubscriptions removes subscriptions from the subscription
@@ -895,6 +1099,9 @@ func isDisconnectionError(err error) bool {
 	if websocket.IsUnexpectedCloseError(err) {
 		return true
 	}
+	if errors.Is(err, errBookResync) {
+		return true
+	}
 	if _, ok := err.(*net.OpError); ok {
 		return !errors.Is(err, errClosedConnection)
 	}
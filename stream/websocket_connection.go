@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/openware/irix/metrics"
 	"github.com/openware/pkg/log"
 )
 
@@ -74,6 +75,11 @@ func (w *WebsocketConnection) Dial(dialer *websocket.Dialer, headers http.Header
 			err)
 	}
 	defer conStatus.Body.Close()
+	atomic.StoreInt64(&w.dialed, time.Now().UnixNano())
+	atomic.StoreInt64(&w.lastMessage, 0)
+	atomic.StoreInt64(&w.pingSent, 0)
+	atomic.StoreInt64(&w.rtt, 0)
+	w.timePongs()
 
 	if w.Verbose {
 		log.Infof(log.WebsocketMgr,
@@ -89,13 +95,24 @@ func (w *WebsocketConnection) Dial(dialer *websocket.Dialer, headers http.Header
 	return nil
 }
 
-// SendJSONMessage sends a JSON encoded message over the connection
+// SendJSONMessage sends a JSON encoded message over the connection once the
+// connection's rate limit allows it. Messages wrapped by WithPriority are
+// queued at that priority.
 func (w *WebsocketConnection) SendJSONMessage(data interface{}) error {
 	if !w.IsConnected() {
 		return fmt.Errorf("%s websocket connection: cannot send message to a disconnected websocket",
 			w.ExchangeName)
 	}
 
+	priority, data := unwrapPriority(data)
+	if err := w.queue(priority); err != nil {
+		return fmt.Errorf("%s websocket connection: %w", w.ExchangeName, err)
+	}
+	if !w.IsConnected() {
+		return fmt.Errorf("%v websocket connection: cannot send message to a disconnected websocket",
+			w.ExchangeName)
+	}
+
 	w.writeControl.Lock()
 	defer w.writeControl.Unlock()
 
@@ -105,15 +122,11 @@ func (w *WebsocketConnection) SendJSONMessage(data interface{}) error {
 			w.ExchangeName,
 			data)
 	}
-
-	if w.RateLimit > 0 {
-		time.Sleep(time.Duration(w.RateLimit) * time.Millisecond)
-		if !w.IsConnected() {
-			return fmt.Errorf("%v websocket connection: cannot send message to a disconnected websocket",
-				w.ExchangeName)
-		}
+	if err := w.Connection.WriteJSON(data); err != nil {
+		return err
 	}
-	return w.Connection.WriteJSON(data)
+	w.recorder().AddCounter(metrics.WebsocketMessagesSent, w.labels(), 1)
+	return nil
 }
 
 // SendRawMessage sends a message over the connection without JSON encoding it
@@ -123,6 +136,10 @@ func (w *WebsocketConnection) SendRawMessage(messageType int, message []byte) er
 			w.ExchangeName)
 	}
 
+	if err := w.queue(PriorityDefault); err != nil {
+		return fmt.Errorf("%s websocket connection: %w", w.ExchangeName, err)
+	}
+
 	w.writeControl.Lock()
 	defer w.writeControl.Unlock()
 
@@ -132,18 +149,15 @@ func (w *WebsocketConnection) SendRawMessage(messageType int, message []byte) er
 			w.ExchangeName,
 			message)
 	}
-	if w.RateLimit > 0 {
-		time.Sleep(time.Duration(w.RateLimit) * time.Millisecond)
-		if !w.IsConnected() {
-			return fmt.Errorf("%v websocket connection: cannot send message to a disconnected websocket",
-				w.ExchangeName)
-		}
-	}
 	if !w.IsConnected() {
 		return fmt.Errorf("%v websocket connection: cannot send message to a disconnected websocket",
 			w.ExchangeName)
 	}
-	return w.Connection.WriteMessage(messageType, message)
+	if err := w.Connection.WriteMessage(messageType, message); err != nil {
+		return err
+	}
+	w.recorder().AddCounter(metrics.WebsocketMessagesSent, w.labels(), 1)
+	return nil
 }
 
 // SetupPingHandler will automatically send ping or pong messages based on
@@ -174,7 +188,7 @@ func (w *WebsocketConnection) SetupPingHandler(handler PingHandler) {
 				ticker.Stop()
 				return
 			case <-ticker.C:
-				err := w.SendRawMessage(handler.MessageType, handler.Message)
+				err := w.sendPing(&handler)
 				if err != nil {
 					log.Errorf(log.WebsocketMgr,
 						"%v websocket connection: ping handler failed to send message [%s]",
@@ -211,10 +225,12 @@ func (w *WebsocketConnection) ReadMessage() Response {
 		return Response{}
 	}
 
+	atomic.StoreInt64(&w.lastMessage, time.Now().UnixNano())
 	select {
 	case w.Traffic <- struct{}{}:
 	default: // causes contention, just bypass if there is no receiver.
 	}
+	w.recorder().AddCounter(metrics.WebsocketMessagesReceived, w.labels(), 1)
 
 	var standardMessage []byte
 	switch mType {
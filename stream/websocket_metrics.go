@@ -0,0 +1,86 @@
+package stream
+
+import (
+	"github.com/openware/irix/metrics"
+	"github.com/openware/irix/ticker"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/order"
+	"github.com/openware/pkg/trade"
+)
+
+// SetMetrics sets the recorder the websocket, its connections and orderbook
+// buffer report to, nil falls back to metrics.Default
+func (w *Websocket) SetMetrics(r metrics.Recorder) {
+	w.metricsMtx.Lock()
+	w.metrics = r
+	w.metricsMtx.Unlock()
+	w.Orderbook.SetMetrics(r)
+	for _, c := range []Connection{w.Conn, w.AuthConn} {
+		if wc, ok := c.(*WebsocketConnection); ok && wc != nil {
+			wc.setMetrics(r)
+		}
+	}
+}
+
+// recorder returns the websocket's recorder
+func (w *Websocket) recorder() metrics.Recorder {
+	w.metricsMtx.Lock()
+	r := w.metrics
+	w.metricsMtx.Unlock()
+	if r == nil {
+		return metrics.Default()
+	}
+	return r
+}
+
+// setMetrics sets the recorder for the connection
+func (w *WebsocketConnection) setMetrics(r metrics.Recorder) {
+	w.metricsMtx.Lock()
+	w.metrics = r
+	w.metricsMtx.Unlock()
+}
+
+// recorder returns the connection's recorder
+func (w *WebsocketConnection) recorder() metrics.Recorder {
+	w.metricsMtx.Lock()
+	r := w.metrics
+	w.metricsMtx.Unlock()
+	if r == nil {
+		return metrics.Default()
+	}
+	return r
+}
+
+// labels identifies the connection in metrics
+func (w *WebsocketConnection) labels() metrics.Labels {
+	return metrics.Labels{Exchange: w.ExchangeName, Endpoint: metrics.Endpoint(w.URL)}
+}
+
+// assetOf returns the asset of data passed to the data handler, empty when
+// the data does not carry one
+func assetOf(d interface{}) string {
+	var a asset.Item
+	switch v := d.(type) {
+	case *ticker.Price:
+		a = v.AssetType
+	case ticker.Price:
+		a = v.AssetType
+	case *order.Detail:
+		a = v.AssetType
+	case order.Detail:
+		a = v.AssetType
+	case KlineData:
+		a = v.AssetType
+	case *KlineData:
+		a = v.AssetType
+	case FundingData:
+		a = v.AssetType
+	case trade.Data:
+		a = v.AssetType
+	case []trade.Data:
+		if len(v) != 0 {
+			a = v[0].AssetType
+		}
+	}
+	return a.String()
+}
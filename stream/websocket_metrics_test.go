@@ -0,0 +1,62 @@
+package stream
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/openware/irix/metrics"
+	"github.com/openware/irix/ticker"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/trade"
+)
+
+func TestAssetOf(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		data  interface{}
+		asset string
+	}{
+		{&ticker.Price{AssetType: asset.Spot}, "spot"},
+		{KlineData{AssetType: asset.Futures}, "futures"},
+		{[]trade.Data{{AssetType: asset.Margin}}, "margin"},
+		{[]trade.Data{}, ""},
+		{"unknown", ""},
+	} {
+		if a := assetOf(tc.data); a != tc.asset {
+			t.Errorf("%T: expected %q, received %q", tc.data, tc.asset, a)
+		}
+	}
+}
+
+func TestConnectionMetrics(t *testing.T) {
+	t.Parallel()
+	reg, err := metrics.NewRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wc := &WebsocketConnection{
+		ExchangeName:     "metrics",
+		URL:              newEchoServer(t).URL,
+		ResponseMaxLimit: time.Second,
+	}
+	w := &Websocket{Conn: wc}
+	w.SetMetrics(reg)
+	if err = wc.Dial(&dialer, http.Header{}); err != nil {
+		t.Fatal(err)
+	}
+	defer wc.Shutdown()
+	if err = wc.SendJSONMessage(map[string]string{"op": "ping"}); err != nil {
+		t.Fatal(err)
+	}
+	if resp := wc.ReadMessage(); len(resp.Raw) == 0 {
+		t.Fatal("expected echoed message")
+	}
+	l := wc.labels()
+	if v, _ := reg.Value(metrics.WebsocketMessagesSent, l); v != 1 {
+		t.Errorf("expected 1 message sent, received %v", v)
+	}
+	if v, _ := reg.Value(metrics.WebsocketMessagesReceived, l); v != 1 {
+		t.Errorf("expected 1 message received, received %v", v)
+	}
+}
@@ -17,13 +17,13 @@ import (
 
 	"github.com/gorilla/websocket"
 	"github.com/openware/irix/protocol"
+	"github.com/openware/irix/stream/streamtest"
 	"github.com/openware/pkg/currency"
 )
 
 const (
-	websocketTestURL = "wss://www.bitmex.com/realtime"
-	useProxyTests    = false                     // Disabled by default. Freely available proxy servers that work all the time are difficult to find
-	proxyURL         = "http://212.186.171.4:80" // Replace with a usable proxy server
+	useProxyTests = false                     // Disabled by default. Freely available proxy servers that work all the time are difficult to find
+	proxyURL      = "http://212.186.171.4:80" // Replace with a usable proxy server
 )
 
 var dialer websocket.Dialer
@@ -86,6 +86,18 @@ func (d *dodgyConnection) Connect() error {
 	return errors.New("cannot connect due to some dastardly reason")
 }
 
+// newEchoServer starts a local websocket server which echoes every frame
+func newEchoServer(t *testing.T) *streamtest.Server {
+	t.Helper()
+	s := streamtest.NewServer()
+	s.Echo = true
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
 func TestSetup(t *testing.T) {
 	var w *Websocket
 	err := w.Setup(nil)
@@ -593,6 +605,7 @@ func TestSetCanUseAuthenticatedEndpoints(t *testing.T) {
 
 // TestDial logic test
 func TestDial(t *testing.T) {
+	websocketTestURL := newEchoServer(t).URL
 	var testCases = []testStruct{
 		{Error: nil,
 			WC: WebsocketConnection{
@@ -640,6 +653,7 @@ func TestDial(t *testing.T) {
 
 // TestSendMessage logic test
 func TestSendMessage(t *testing.T) {
+	websocketTestURL := newEchoServer(t).URL
 	var testCases = []testStruct{
 		{Error: nil, WC: WebsocketConnection{
 			ExchangeName:     "test1",
@@ -696,7 +710,7 @@ func TestSendMessage(t *testing.T) {
 func TestSendMessageWithResponse(t *testing.T) {
 	wc := &WebsocketConnection{
 		Verbose:          true,
-		URL:              "wss://echo.websocket.org",
+		URL:              newEchoServer(t).URL,
 		ResponseMaxLimit: time.Second * 5,
 		Match:            NewMatch(),
 	}
@@ -756,7 +770,7 @@ func readMessages(wc *WebsocketConnection, t *testing.T) {
 // TestSetupPingHandler logic test
 func TestSetupPingHandler(t *testing.T) {
 	wc := &WebsocketConnection{
-		URL:              "wss://echo.websocket.org",
+		URL:              newEchoServer(t).URL,
 		ResponseMaxLimit: time.Second * 5,
 		Match:            NewMatch(),
 		Wg:               &sync.WaitGroup{},
@@ -1241,7 +1255,7 @@ func TestWebsocketConnectionShutdown(t *testing.T) {
 		t.Fatal("error cannot be nil")
 	}
 
-	wc.URL = "wss://echo.websocket.org"
+	wc.URL = newEchoServer(t).URL
 
 	err = wc.Dial(&websocket.Dialer{}, nil)
 	if err != nil {
@@ -1253,3 +1267,112 @@ func TestWebsocketConnectionShutdown(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestWebsocketTestServer(t *testing.T) {
+	srv := streamtest.NewServer()
+	srv.OnConnect([]byte(`{"event":"info"}`))
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	ws := New()
+	setup := *defaultSetup
+	setup.Connector = func() error {
+		err := ws.SetupNewConnection(ConnectionSetup{ResponseMaxLimit: time.Second})
+		if err != nil {
+			return err
+		}
+		err = ws.Conn.Dial(&dialer, http.Header{})
+		if err != nil {
+			return err
+		}
+		ws.Wg.Add(1)
+		go func() {
+			defer ws.Wg.Done()
+			for {
+				resp := ws.Conn.ReadMessage()
+				if resp.Raw == nil {
+					return
+				}
+				ws.DataHandler <- resp.Raw
+			}
+		}()
+		return nil
+	}
+	setup.Subscriber = func(subs []ChannelSubscription) error {
+		for x := range subs {
+			err := ws.Conn.SendJSONMessage(map[string]string{
+				"event":   "subscribe",
+				"channel": subs[x].Channel,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err := ws.Setup(&setup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ws.SetWebsocketURL(srv.URL, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ws.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case d := <-ws.ToRoutine:
+		if string(d.([]byte)) != `{"event":"info"}` {
+			t.Errorf("unexpected connect frame %s", d)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting on connect frame")
+	}
+
+	subs := []ChannelSubscription{{Channel: "ticker"}}
+	err = ws.SubscribeToChannels(subs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws.AddSuccessfulSubscriptions(subs...)
+	subscribe := streamtest.JSONEqual(`{"event":"subscribe","channel":"ticker"}`)
+	if _, err = srv.Expect(subscribe, time.Second*5); err != nil {
+		t.Fatal(err)
+	}
+
+	// Dropping the connection should trigger a reconnect and resubscribe
+	srv.Disconnect()
+	if err = srv.WaitForConnections(2, connectionMonitorDelay*5); err != nil {
+		t.Fatal(err)
+	}
+	if err = srv.ExpectCount(subscribe, 2, time.Second*5); err != nil {
+		t.Fatal(err)
+	}
+
+	err = ws.Shutdown()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOnShutdown(t *testing.T) {
+	t.Parallel()
+	ws := New()
+	ws.ShutdownC = make(chan struct{})
+	ws.Wg = new(sync.WaitGroup)
+	var called int
+	ws.OnShutdown(func() { called++ })
+	ws.setConnectedStatus(true)
+	ws.Conn = &WebsocketConnection{}
+	if err := ws.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if called != 1 {
+		t.Fatalf("expected shutdown hook to be called once, received %d", called)
+	}
+}
@@ -5,8 +5,11 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/openware/irix/metrics"
 	"github.com/openware/irix/protocol"
 	"github.com/openware/irix/stream/buffer"
+	"github.com/openware/pkg/asset"
+	"github.com/openware/pkg/currency"
 )
 
 // Websocket functionality list and state consts
@@ -44,10 +47,37 @@ type Websocket struct {
 	connectionMutex              sync.RWMutex
 	connector                    func() error
 
-	subscriptionMutex sync.Mutex
-	subscriptions     []ChannelSubscription
-	Subscribe         chan []ChannelSubscription
-	Unsubscribe       chan []ChannelSubscription
+	subscriptionMutex          sync.Mutex
+	subscriptions              []ChannelSubscription
+	maxSubscriptionsPerMessage int
+	subscriptionTimeout        time.Duration
+	confirmSubscriptions       func([]byte) error
+	orderbookChannel           func(*ChannelSubscription) bool
+	orderbookResync            func(currency.Pair, asset.Item)
+	Subscribe                  chan []ChannelSubscription
+	Unsubscribe                chan []ChannelSubscription
+
+	maxSubscriptionsPerConnection int
+	shards                        []*shard
+	nextShard                     int
+	shardMtx                      sync.Mutex
+
+	metrics    metrics.Recorder
+	metricsMtx sync.Mutex
+	events     *EventBus
+	eventsMtx  sync.Mutex
+
+	backpressure    map[DataKind]Backpressure
+	backpressureMtx sync.Mutex
+	dropped         [kindCount]uint64
+	// toRoutineDisabled is set when nothing reads ToRoutine
+	toRoutineDisabled int32
+
+	reconnect    Backoff
+	stateHandler func(StateChange)
+	// shutdownHooks are called once Shutdown completes
+	shutdownHooks []func()
+	attempt       int32
 
 	// Subscriber function for package defined websocket subscriber
 	// functionality
@@ -58,9 +88,21 @@ type Websocket struct {
 	// GenerateSubs function for package defined websocket generate
 	// subscriptions functionality
 	GenerateSubs func() ([]ChannelSubscription, error)
+	// ShardConnector dials a connection opened to carry subscriptions beyond
+	// the primary connection's limit and starts reading it into the data
+	// handler
+	ShardConnector func(Connection) error
+	// ShardSubscriber and ShardUnsubscriber replace Subscriber and
+	// Unsubscriber when subscriptions are spread across connections
+	ShardSubscriber   func(Connection, []ChannelSubscription) error
+	ShardUnsubscriber func(Connection, []ChannelSubscription) error
 
 	DataHandler chan interface{}
-	ToRoutine   chan interface{}
+	// ToRoutine receives data sent to DataHandler under the backpressure
+	// policy of its kind, order updates and errors ahead of market data.
+	// Once a typed subscriber is attached through Events blocking policies
+	// drop instead of waiting for a reader.
+	ToRoutine chan interface{}
 
 	Match *Match
 
@@ -99,6 +141,44 @@ type WebsocketSetup struct {
 	UnSubscriber                     func([]ChannelSubscription) error
 	GenerateSubscriptions            func() ([]ChannelSubscription, error)
 	Features                         *protocol.Features
+	// MaxSubscriptionsPerMessage splits subscribe and unsubscribe calls into
+	// batches no larger than the exchange accepts in one message, zero sends
+	// every channel in one call
+	MaxSubscriptionsPerMessage int
+	// MaxSubscriptionsPerConnection spreads subscriptions across as many
+	// connections as needed to keep each under the exchange's limit, zero
+	// keeps every subscription on the primary connection. The shard
+	// functions must be set when it is.
+	MaxSubscriptionsPerConnection int
+	ShardConnector                func(Connection) error
+	ShardSubscriber               func(Connection, []ChannelSubscription) error
+	ShardUnsubscriber             func(Connection, []ChannelSubscription) error
+	// SubscriptionTimeout is how long the exchange has to acknowledge
+	// subscriptions expected through ExpectSubscriptions, zero waits ten
+	// seconds
+	SubscriptionTimeout time.Duration
+	// ConfirmSubscriptions checks a response matched to expected
+	// subscriptions and returns the exchange's rejection, unset accepts every
+	// matched response
+	ConfirmSubscriptions func(response []byte) error
+	// OrderbookChannel reports whether a subscription carries orderbook data.
+	// When set a local book falling out of sync resubscribes its channels,
+	// for exchanges sending a snapshot on subscription.
+	OrderbookChannel func(*ChannelSubscription) bool
+	// OrderbookResync fetches a fresh book over REST, such as through a
+	// buffer.SyncManager, when its channels cannot be resubscribed. Unset
+	// reconnects the connection carrying the book instead.
+	OrderbookResync func(currency.Pair, asset.Item)
+	// OrderbookChecksum verifies the checksums exchanges send with orderbook
+	// updates or through Orderbook.VerifyChecksum, a book failing it is
+	// dropped and resynchronised
+	OrderbookChecksum buffer.Checksummer
+	// Backpressure overrides DefaultBackpressure for the kinds it sets
+	Backpressure map[DataKind]Backpressure
+	// Reconnect sets the reconnection backoff, unset uses DefaultBackoff
+	Reconnect Backoff
+	// StateHandler is called on each lifecycle event, see SetStateHandler
+	StateHandler func(StateChange)
 	// Local orderbook buffer config values
 	OrderbookBufferLimit  int
 	BufferEnabled         bool
@@ -117,7 +197,24 @@ type WebsocketConnection struct {
 	// writes methods
 	writeControl sync.Mutex
 
-	RateLimit    int64
+	// RateLimit is the gap in milliseconds between messages, used when
+	// MessageRate is unset
+	RateLimit int64
+	// MessageRate caps outbound messages per second, MessageBurst allows
+	// short bursts above it
+	MessageRate  float64
+	MessageBurst int
+	sendQueue    *sendQueue
+	queueOnce    sync.Once
+	metrics      metrics.Recorder
+	metricsMtx   sync.Mutex
+
+	// Health in unix nanoseconds, rtt in nanoseconds
+	dialed      int64
+	lastMessage int64
+	pingSent    int64
+	rtt         int64
+
 	ExchangeName string
 	URL          string
 	ProxyURL     string
@@ -10,6 +10,7 @@ import (
 	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/currency"
 	"github.com/openware/pkg/dispatch"
+	"github.com/shopspring/decimal"
 )
 
 func init() {
@@ -186,3 +187,32 @@ func (s *Service) GetAssociations(p *Price, fmtName string) ([]uuid.UUID, error)
 	ids = append(ids, exchangeID)
 	return ids, nil
 }
+
+// Decimal returns the ticker prices as decimals with the shortest decimal
+// representation of each float. Use the exchange's TickerDecimal where the
+// pair's precision is loaded to recover the exchange's values exactly.
+func (p *Price) Decimal() PriceDecimal {
+	return PriceDecimal{
+		Last:        decimal.NewFromFloat(p.Last),
+		High:        decimal.NewFromFloat(p.High),
+		Low:         decimal.NewFromFloat(p.Low),
+		Bid:         decimal.NewFromFloat(p.Bid),
+		Ask:         decimal.NewFromFloat(p.Ask),
+		Volume:      decimal.NewFromFloat(p.Volume),
+		QuoteVolume: decimal.NewFromFloat(p.QuoteVolume),
+		Open:        decimal.NewFromFloat(p.Open),
+		Close:       decimal.NewFromFloat(p.Close),
+		Pair:        p.Pair,
+		AssetType:   p.AssetType,
+		LastUpdated: p.LastUpdated,
+	}
+}
+
+// GetTickerDecimal checks and returns a requested ticker as exact decimals
+func GetTickerDecimal(exchange string, p currency.Pair, a asset.Item) (PriceDecimal, error) {
+	t, err := GetTicker(exchange, p, a)
+	if err != nil {
+		return PriceDecimal{}, err
+	}
+	return t.Decimal(), nil
+}
@@ -407,3 +407,40 @@ func TestGetAssociation(t *testing.T) {
 
 	service.mux = cpyMux
 }
+
+func TestGetTickerDecimal(t *testing.T) {
+	newPair, err := currency.NewPairFromStrings("ETH", "DAI")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ProcessTicker(&Price{
+		Pair:         newPair,
+		Last:         0.1 + 0.2,
+		Bid:          0.3,
+		Ask:          1e-8,
+		ExchangeName: "decimalex",
+		AssetType:    asset.Spot,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = GetTickerDecimal("decimalex", newPair, asset.Margin)
+	if err == nil {
+		t.Fatal("expected error on unloaded asset")
+	}
+
+	p, err := GetTickerDecimal("decimalex", newPair, asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Bid.String() != "0.3" {
+		t.Errorf("received: %v but expected: %v", p.Bid, "0.3")
+	}
+	if p.Ask.String() != "0.00000001" {
+		t.Errorf("received: %v but expected: %v", p.Ask, "0.00000001")
+	}
+	if p.Pair != newPair || p.AssetType != asset.Spot {
+		t.Error("unexpected pair or asset")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/currency"
 	"github.com/openware/pkg/dispatch"
+	"github.com/shopspring/decimal"
 )
 
 // const values for the ticker package
@@ -63,3 +64,19 @@ type Ticker struct {
 	Main  uuid.UUID
 	Assoc []uuid.UUID
 }
+
+// PriceDecimal stores exact decimal pricing information for a currency pair
+type PriceDecimal struct {
+	Last        decimal.Decimal
+	High        decimal.Decimal
+	Low         decimal.Decimal
+	Bid         decimal.Decimal
+	Ask         decimal.Decimal
+	Volume      decimal.Decimal
+	QuoteVolume decimal.Decimal
+	Open        decimal.Decimal
+	Close       decimal.Decimal
+	Pair        currency.Pair
+	AssetType   asset.Item
+	LastUpdated time.Time
+}
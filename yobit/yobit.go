@@ -10,9 +10,11 @@ import (
 	"strings"
 
 	exchange "github.com/openware/irix"
+	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/common/crypto"
 	"github.com/openware/pkg/currency"
 	"github.com/openware/pkg/log"
+	"github.com/openware/pkg/order"
 	"github.com/openware/pkg/request"
 )
 
@@ -52,6 +54,35 @@ func (y *Yobit) GetInfo() (Info, error) {
 	return resp, y.SendHTTPRequest(exchange.RestSpot, path, &resp)
 }
 
+// FetchExchangeLimits fetches spot order execution limits, hidden pairs are
+// skipped
+func (y *Yobit) FetchExchangeLimits() ([]order.MinMaxLevel, error) {
+	info, err := y.GetInfo()
+	if err != nil {
+		return nil, err
+	}
+	limits := make([]order.MinMaxLevel, 0, len(info.Pairs))
+	for symbol, p := range info.Pairs {
+		if p.Hidden == 1 {
+			continue
+		}
+		var cp currency.Pair
+		cp, err = currency.NewPairFromString(symbol)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, order.MinMaxLevel{
+			Pair:      cp,
+			Asset:     asset.Spot,
+			MinPrice:  p.MinPrice,
+			MaxPrice:  p.MaxPrice,
+			StepPrice: exchange.StepFromDecimalPlaces(int64(p.DecimalPlaces)),
+			MinAmount: p.MinAmount,
+		})
+	}
+	return limits, nil
+}
+
 // GetTicker returns a ticker for a specific currency
 func (y *Yobit) GetTicker(symbol string) (map[string]Ticker, error) {
 	type Response struct {
@@ -292,7 +323,12 @@ func (y *Yobit) SendAuthenticatedHTTPRequest(ep exchange.URL, path string, param
 		params = url.Values{}
 	}
 
-	n := y.Requester.GetNonce(false).String()
+	nonce, release, err := y.NextNonce()
+	if err != nil {
+		return err
+	}
+	defer release()
+	n := nonce.String()
 
 	params.Set("nonce", n)
 	params.Set("method", path)
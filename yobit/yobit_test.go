@@ -535,3 +535,14 @@ func TestGetHistoricTrades(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	err := y.UpdateOrderExecutionLimits(asset.Spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = y.GetOrderExecutionLimits(asset.Spot, currency.NewPair(currency.LTC, currency.BTC)); err != nil {
+		t.Fatal(err)
+	}
+}
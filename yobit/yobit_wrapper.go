@@ -2,6 +2,7 @@ package yobit
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"sort"
 	"strconv"
@@ -11,6 +12,7 @@ import (
 
 	exchange "github.com/openware/irix"
 	"github.com/openware/irix/config"
+	"github.com/openware/irix/nonce"
 	"github.com/openware/irix/portfolio/withdraw"
 	"github.com/openware/irix/protocol"
 	"github.com/openware/irix/ticker"
@@ -56,6 +58,8 @@ func (y *Yobit) SetDefaults() {
 	y.Verbose = true
 	y.API.CredentialsValidator.RequiresKey = true
 	y.API.CredentialsValidator.RequiresSecret = true
+	// Yobit nonces must fit in 32 bits
+	y.NonceFormat = nonce.Seconds
 
 	requestFmt := &currency.PairFormat{Delimiter: currency.UnderscoreDelimiter, Separator: currency.DashDelimiter}
 	configFmt := &currency.PairFormat{Delimiter: currency.UnderscoreDelimiter, Uppercase: true}
@@ -132,6 +136,20 @@ func (y *Yobit) Run() {
 		y.PrintEnabledPairs()
 	}
 
+	a := y.GetAssetTypes()
+	for x := range a {
+		if err := y.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
+			err = y.UpdateOrderExecutionLimits(a[x])
+			y.SetOrderExecutionLimitsLoadError(a[x], err)
+			if err != nil {
+				log.Errorf(log.ExchangeSys,
+					"Could not set %s exchange exchange limits: %v",
+					y.Name,
+					err)
+			}
+		}
+	}
+
 	if !y.GetEnabledFeatures().AutoPairUpdates {
 		return
 	}
@@ -386,6 +404,9 @@ func (y *Yobit) SubmitOrder(s *order.Submit) (order.SubmitResponse, error) {
 	if err := s.Validate(); err != nil {
 		return submitOrderResponse, err
 	}
+	if err := y.ConformOrderToLimits(s); err != nil {
+		return submitOrderResponse, err
+	}
 
 	if s.Type != order.Limit {
 		return submitOrderResponse, errors.New("only limit orders are allowed")
@@ -657,3 +678,15 @@ func (y *Yobit) GetHistoricCandles(pair currency.Pair, a asset.Item, start, end
 func (y *Yobit) GetHistoricCandlesExtended(pair currency.Pair, a asset.Item, start, end time.Time, interval kline.Interval) (kline.Item, error) {
 	return kline.Item{}, common.ErrFunctionNotSupported
 }
+
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (y *Yobit) UpdateOrderExecutionLimits(a asset.Item) error {
+	if a != asset.Spot {
+		return fmt.Errorf("%s %w", a, asset.ErrNotSupported)
+	}
+	limits, err := y.FetchExchangeLimits()
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return y.LoadLimits(limits)
+}
@@ -12,9 +12,11 @@ import (
 	"time"
 
 	exchange "github.com/openware/irix"
+	"github.com/openware/pkg/asset"
 	"github.com/openware/pkg/common/convert"
 	"github.com/openware/pkg/common/crypto"
 	"github.com/openware/pkg/currency"
+	"github.com/openware/pkg/order"
 	"github.com/openware/pkg/request"
 )
 
@@ -148,6 +150,31 @@ func (z *ZB) GetMarkets() (map[string]MarketResponseItem, error) {
 	return res, nil
 }
 
+// FetchExchangeLimits fetches spot order execution limits
+func (z *ZB) FetchExchangeLimits() ([]order.MinMaxLevel, error) {
+	markets, err := z.GetMarkets()
+	if err != nil {
+		return nil, err
+	}
+	limits := make([]order.MinMaxLevel, 0, len(markets))
+	for symbol, m := range markets {
+		var cp currency.Pair
+		cp, err = currency.NewPairFromString(symbol)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, order.MinMaxLevel{
+			Pair:        cp,
+			Asset:       asset.Spot,
+			StepPrice:   exchange.StepFromDecimalPlaces(int64(m.PriceScale)),
+			StepAmount:  exchange.StepFromDecimalPlaces(int64(m.AmountScale)),
+			MinAmount:   m.MinAmount,
+			MinNotional: m.MinSize,
+		})
+	}
+	return limits, nil
+}
+
 // GetLatestSpotPrice returns latest spot price of symbol
 //
 // symbol: string of currency pair
@@ -1015,3 +1015,20 @@ func TestGetHistoricTrades(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestUpdateOrderExecutionLimits(t *testing.T) {
+	t.Parallel()
+	if err := z.UpdateOrderExecutionLimits(asset.Spot); err != nil {
+		t.Fatal(err)
+	}
+	if err := z.UpdateOrderExecutionLimits(asset.Futures); err == nil {
+		t.Fatal("expected unhandled case")
+	}
+	p, err := z.GetPrecision(asset.Spot, currency.NewPairWithDelimiter("aaa", "qc", "_"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.PriceTick.String() != "0.000001" || p.AmountStep.String() != "0.1" || p.MinNotional.String() != "5" {
+		t.Errorf("unexpected precision %+v", p)
+	}
+}
@@ -65,6 +65,8 @@ type AccountsResponse struct {
 type MarketResponseItem struct {
 	AmountScale float64 `json:"amountScale"`
 	PriceScale  float64 `json:"priceScale"`
+	MinAmount   float64 `json:"minAmount"`
+	MinSize     float64 `json:"minSize"`
 }
 
 // TickerResponse holds the ticker response data
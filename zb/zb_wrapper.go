@@ -204,6 +204,20 @@ func (z *ZB) Run() {
 		z.PrintEnabledPairs()
 	}
 
+	a := z.GetAssetTypes()
+	for x := range a {
+		if err := z.CurrencyPairs.IsAssetEnabled(a[x]); err == nil {
+			err = z.UpdateOrderExecutionLimits(a[x])
+			z.SetOrderExecutionLimitsLoadError(a[x], err)
+			if err != nil {
+				log.Errorf(log.ExchangeSys,
+					"Could not set %s exchange exchange limits: %v",
+					z.Name,
+					err)
+			}
+		}
+	}
+
 	if !z.GetEnabledFeatures().AutoPairUpdates {
 		return
 	}
@@ -461,6 +475,10 @@ func (z *ZB) SubmitOrder(o *order.Submit) (order.SubmitResponse, error) {
 	if err != nil {
 		return submitOrderResponse, err
 	}
+	err = z.ConformOrderToLimits(o)
+	if err != nil {
+		return submitOrderResponse, err
+	}
 	if z.Websocket.CanUseAuthenticatedWebsocketForWrapper() {
 		var isBuyOrder int64
 		if o.Side == order.Buy {
@@ -954,3 +972,15 @@ func (z *ZB) validateCandlesRequest(p currency.Pair, a asset.Item, start, end ti
 		Interval: interval,
 	}, nil
 }
+
+// UpdateOrderExecutionLimits sets exchange executions for a required asset type
+func (z *ZB) UpdateOrderExecutionLimits(a asset.Item) error {
+	if a != asset.Spot {
+		return fmt.Errorf("%s %w", a, asset.ErrNotSupported)
+	}
+	limits, err := z.FetchExchangeLimits()
+	if err != nil {
+		return fmt.Errorf("cannot update exchange execution limits: %w", err)
+	}
+	return z.LoadLimits(limits)
+}